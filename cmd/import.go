@@ -0,0 +1,369 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"go-personal-finance/pkg/api"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+const importCSVCommand = "import-csv"
+const importOFXCommand = "import-ofx"
+const importQIFCommand = "import-qif"
+const exportQIFCommand = "export-qif"
+const importRatesCommand = "import-rates"
+const importPricesCommand = "import-prices"
+const addUserCommand = "add-user"
+
+func usage() {
+	output := flag.CommandLine.Output()
+	fmt.Fprintf(output, "Usage: %s [flags] [command]\n\nStarts the server when no command is given.\n\n", os.Args[0])
+	fmt.Fprintf(output, "Commands:\n  %s -profile <id> -file <statement.csv> [-account <id> [-commit]]\n"+
+		"\tpreviews a csv statement, or imports it into the account with -commit\n", importCSVCommand)
+	fmt.Fprintf(output, "  %s -file <statement.ofx> [-account <id> | -create-account [-org <id>]] [-commit]\n"+
+		"\tpreviews an ofx or qfx statement, or imports it with -commit\n", importOFXCommand)
+	fmt.Fprintf(output, "  %s -file <export.qif> [-account <id> [-commit]]\n"+
+		"\tpreviews a qif file, or imports it into the account with -commit\n", importQIFCommand)
+	fmt.Fprintf(output, "  %s -account <id> [-from <date>] [-to <date>] [-file <export.qif>]\n"+
+		"\twrites the transactions of the account as a qif file, or to stdout without -file\n", exportQIFCommand)
+	fmt.Fprintf(output, "  %s -file <rates.csv>\n"+
+		"\tstores the exchange rates of a csv file with date, base, quote and rate columns\n", importRatesCommand)
+	fmt.Fprintf(output, "  %s -file <prices.csv>\n"+
+		"\tstores the security prices of a csv file with date, symbol and price columns\n", importPricesCommand)
+	fmt.Fprintf(output, "  %s -username <name>\n"+
+		"\tcreates a user that can sign in to the api, reading its password from the first line of stdin\n\nFlags:\n",
+		addUserCommand)
+	flag.PrintDefaults()
+	fmt.Fprintf(output, "\nRows duplicating transactions of the account are skipped on import unless the -decisions "+
+		"flag of the\nimport commands says otherwise, like -decisions 4=insert,7=merge,9=merge:12 to insert line 4 "+
+		"and\nmerge line 7 into its best match and line 9 into transaction 12.\n")
+	fmt.Fprintf(output, "\nEvery api route other than /v1/api/ping and /v1/api/login needs the token of a session "+
+		"started at\n/v1/api/login in an Authorization: Bearer <token> header.\n")
+}
+
+// runImportCSV previews a csv statement on the terminal and imports it when asked to, mirroring the preview and
+// commit endpoints.
+func runImportCSV(importService *api.ImportService, args []string) error {
+	flags := flag.NewFlagSet(importCSVCommand, flag.ContinueOnError)
+	profileId := flags.Int64("profile", 0, "id of the import profile describing the columns of the statement.")
+	accountId := flags.Int64("account", 0, "id of the account to import the transactions into.")
+	filePath := flags.String("file", "", "path of the csv statement.")
+	commit := flags.Bool("commit", false, "import the transactions instead of only previewing them.")
+	decisionsFlag := flags.String("decisions", "", "what to do with duplicate rows, like 4=insert,7=merge:12.")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	decisions, err := parseDecisionsFlag(*decisionsFlag)
+	if err != nil {
+		return err
+	}
+	if *profileId == 0 || *filePath == "" || (*commit && *accountId == 0) {
+		flags.Usage()
+		return fmt.Errorf("-profile and -file are required, and -account is required with -commit")
+	}
+
+	statement, err := os.Open(*filePath)
+	if err != nil {
+		return fmt.Errorf("failed to open statement with err: %v", err)
+	}
+	defer statement.Close()
+
+	if *commit {
+		result, err := importService.ImportCSV(*profileId, *accountId, statement, decisions)
+		if err != nil {
+			return err
+		}
+		printImportResult(result)
+		return nil
+	}
+
+	preview, err := importService.PreviewCSV(*profileId, optionalId(*accountId), statement)
+	if err != nil {
+		return err
+	}
+	return printAccountPreview(preview, *accountId)
+}
+
+// runImportOFX previews an ofx or qfx statement on the terminal and imports it when asked to. Statements are imported
+// into the account linked to the account number of the statement unless another account is given.
+func runImportOFX(importService *api.ImportService, args []string) error {
+	flags := flag.NewFlagSet(importOFXCommand, flag.ContinueOnError)
+	accountId := flags.Int64("account", 0, "id of the account to import the transactions into and link to the "+
+		"statement.")
+	createAccount := flags.Bool("create-account", false, "create an account when none is linked to the statement.")
+	orgId := flags.Int64("org", 0, "id of the organization of a created account. Defaults to the bank of the "+
+		"statement.")
+	filePath := flags.String("file", "", "path of the ofx or qfx statement.")
+	commit := flags.Bool("commit", false, "import the transactions instead of only previewing them.")
+	decisionsFlag := flags.String("decisions", "", "what to do with duplicate rows, like 4=insert,7=merge:12.")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	decisions, err := parseDecisionsFlag(*decisionsFlag)
+	if err != nil {
+		return err
+	}
+	if *filePath == "" {
+		flags.Usage()
+		return fmt.Errorf("-file is required")
+	}
+
+	statement, err := os.Open(*filePath)
+	if err != nil {
+		return fmt.Errorf("failed to open statement with err: %v", err)
+	}
+	defer statement.Close()
+
+	if *commit {
+		options := api.OFXImportOptions{CreateAccount: *createAccount, Decisions: decisions}
+		if *accountId != 0 {
+			options.AccountId = accountId
+		}
+		if *orgId != 0 {
+			options.OrgId = orgId
+		}
+		result, err := importService.ImportOFX(statement, options)
+		if err != nil {
+			return err
+		}
+		printImportResult(result)
+		return nil
+	}
+
+	preview, err := importService.PreviewOFX(statement)
+	if err != nil {
+		return err
+	}
+	if err = printImportRows(preview.Rows); err != nil {
+		return err
+	}
+	fmt.Printf("\n%d rows can be imported, %d rows have errors and %d rows are duplicates.\n",
+		preview.ValidRows-preview.DuplicateRows, preview.InvalidRows, preview.DuplicateRows)
+	if preview.MatchedAccountId != nil {
+		fmt.Printf("Statement of account %s is linked to account %d. Run again with -commit to import.\n",
+			preview.Account.AccountId, *preview.MatchedAccountId)
+	} else {
+		fmt.Printf("No account is linked to account %s of the statement. Run again with -commit and either "+
+			"-account <id> or -create-account to import.\n", preview.Account.AccountId)
+	}
+	return nil
+}
+
+// runImportQIF previews a qif file on the terminal and imports it into the account when asked to.
+func runImportQIF(importService *api.ImportService, args []string) error {
+	flags := flag.NewFlagSet(importQIFCommand, flag.ContinueOnError)
+	accountId := flags.Int64("account", 0, "id of the account to import the transactions into.")
+	filePath := flags.String("file", "", "path of the qif file.")
+	commit := flags.Bool("commit", false, "import the transactions instead of only previewing them.")
+	decisionsFlag := flags.String("decisions", "", "what to do with duplicate rows, like 4=insert,7=merge:12.")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	decisions, err := parseDecisionsFlag(*decisionsFlag)
+	if err != nil {
+		return err
+	}
+	if *filePath == "" || (*commit && *accountId == 0) {
+		flags.Usage()
+		return fmt.Errorf("-file is required, and -account is required with -commit")
+	}
+
+	statement, err := os.Open(*filePath)
+	if err != nil {
+		return fmt.Errorf("failed to open qif file with err: %v", err)
+	}
+	defer statement.Close()
+
+	if *commit {
+		result, err := importService.ImportQIF(*accountId, statement, decisions)
+		if err != nil {
+			return err
+		}
+		printImportResult(result)
+		return nil
+	}
+
+	preview, err := importService.PreviewQIF(optionalId(*accountId), statement)
+	if err != nil {
+		return err
+	}
+	return printAccountPreview(preview, *accountId)
+}
+
+// runExportQIF writes the transactions of an account as a qif file.
+func runExportQIF(exportService *api.ExportService, args []string) error {
+	flags := flag.NewFlagSet(exportQIFCommand, flag.ContinueOnError)
+	accountId := flags.Int64("account", 0, "id of the account to export.")
+	fromDate := flags.String("from", "", "only export transactions made on or after this date (2006-01-02).")
+	toDate := flags.String("to", "", "only export transactions made before this date (2006-01-02).")
+	filePath := flags.String("file", "", "path of the qif file to write. Defaults to stdout.")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *accountId == 0 {
+		flags.Usage()
+		return fmt.Errorf("-account is required")
+	}
+	from, err := parseDateFlag("from", *fromDate)
+	if err != nil {
+		return err
+	}
+	to, err := parseDateFlag("to", *toDate)
+	if err != nil {
+		return err
+	}
+
+	if *filePath == "" {
+		return exportService.ExportQIF(*accountId, from, to, os.Stdout)
+	}
+	file, err := os.Create(*filePath)
+	if err != nil {
+		return fmt.Errorf("failed to create qif file with err: %v", err)
+	}
+	if err = exportService.ExportQIF(*accountId, from, to, file); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// runImportRates stores the exchange rates of a csv file.
+func runImportRates(exchangeRatesService *api.ExchangeRatesService, args []string) error {
+	flags := flag.NewFlagSet(importRatesCommand, flag.ContinueOnError)
+	filePath := flags.String("file", "", "path of the csv file of exchange rates.")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *filePath == "" {
+		flags.Usage()
+		return fmt.Errorf("-file is required")
+	}
+
+	file, err := os.Open(*filePath)
+	if err != nil {
+		return fmt.Errorf("failed to open exchange rates with err: %v", err)
+	}
+	defer file.Close()
+	imported, err := exchangeRatesService.ImportExchangeRates(file)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("imported %d exchange rates\n", imported)
+	return nil
+}
+
+// runImportPrices stores the security prices of a csv file.
+func runImportPrices(investmentsService *api.InvestmentsService, args []string) error {
+	flags := flag.NewFlagSet(importPricesCommand, flag.ContinueOnError)
+	filePath := flags.String("file", "", "path of the csv file of security prices.")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *filePath == "" {
+		flags.Usage()
+		return fmt.Errorf("-file is required")
+	}
+
+	file, err := os.Open(*filePath)
+	if err != nil {
+		return fmt.Errorf("failed to open security prices with err: %v", err)
+	}
+	defer file.Close()
+	imported, err := investmentsService.ImportSecurityPrices(file)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("imported %d security prices\n", imported)
+	return nil
+}
+
+// parseDateFlag returns nil for an empty flag.
+func parseDateFlag(name string, value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	parsed, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return nil, fmt.Errorf("-%s must be a date (2006-01-02)", name)
+	}
+	return &parsed, nil
+}
+
+// printAccountPreview prints the rows of a statement previewed against the account, or against no account when the
+// id is 0.
+func printAccountPreview(preview api.ImportPreview, accountId int64) error {
+	if err := printImportRows(preview.Rows); err != nil {
+		return err
+	}
+	if accountId == 0 {
+		fmt.Printf("\n%d rows can be imported, %d rows have errors. Run again with -account <id> to look for "+
+			"duplicates and with -commit -account <id> to import.\n", preview.ValidRows, preview.InvalidRows)
+		return nil
+	}
+	fmt.Printf("\n%d rows can be imported, %d rows have errors and %d rows are duplicates. Run again with -commit "+
+		"to import.\n", preview.ValidRows-preview.DuplicateRows, preview.InvalidRows, preview.DuplicateRows)
+	return nil
+}
+
+func printImportResult(result api.ImportResult) {
+	fmt.Printf("imported %d transactions into account %d, skipped %d and merged %d duplicate transactions\n",
+		result.Imported, result.AccountId, result.Skipped, result.Merged)
+}
+
+// parseDecisionsFlag reads decisions written as line=action or line=merge:transaction id, separated by commas.
+func parseDecisionsFlag(value string) ([]api.DuplicateDecision, error) {
+	var decisions []api.DuplicateDecision
+	for _, entry := range strings.Split(value, ",") {
+		if strings.TrimSpace(entry) == "" {
+			continue
+		}
+		lineValue, action, ok := strings.Cut(strings.TrimSpace(entry), "=")
+		line, err := strconv.Atoi(lineValue)
+		if !ok || err != nil {
+			return nil, fmt.Errorf("-decisions entry %s has to be written as line=action", entry)
+		}
+		decision := api.DuplicateDecision{Line: line, Action: action}
+		if action, idValue, ok := strings.Cut(action, ":"); ok {
+			id, err := strconv.ParseInt(idValue, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("-decisions entry %s has an invalid transaction id", entry)
+			}
+			decision.Action, decision.TransactionId = action, &id
+		}
+		decisions = append(decisions, decision)
+	}
+	return decisions, nil
+}
+
+func optionalId(id int64) *int64 {
+	if id == 0 {
+		return nil
+	}
+	return &id
+}
+
+func printImportRows(rows []api.ImportRow) error {
+	writer := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(writer, "LINE\tDATE\tAMOUNT\tDESCRIPTION\tERROR")
+	for _, row := range rows {
+		date := ""
+		if row.TransactionTime != nil {
+			date = row.TransactionTime.Format("2006-01-02")
+		}
+		note := row.Error
+		if row.Duplicate {
+			match := row.Matches[0]
+			note = fmt.Sprintf("%s duplicate of transaction %d (score %.2f)", match.MatchType, match.TransactionId,
+				match.Score)
+			if len(row.Matches) > 1 {
+				note += fmt.Sprintf(" and %d more", len(row.Matches)-1)
+			}
+		}
+		fmt.Fprintf(writer, "%d\t%s\t%s\t%s\t%s\n", row.Line, date, row.Amount, row.Description, note)
+	}
+	return writer.Flush()
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"crypto/rand"
 	"database/sql"
 	"flag"
 	"fmt"
@@ -15,6 +16,7 @@ import (
 	"go-personal-finance/pkg/app"
 	"go-personal-finance/pkg/repository"
 	"log"
+	"time"
 )
 
 func main() {
@@ -27,7 +29,13 @@ func run() error {
 
 	shouldMigratePtr := flag.Bool("migrate", false, "apply any new migration files. Only supports forward migrations.")
 	dbFilePathPtr := flag.String("db-path", "./sqlite.db", "relative file path from current folder.")
+	scheduleIntervalPtr := flag.Duration("schedule-interval", time.Hour,
+		"how often the server materializes due recurring transactions, 0 disables it.")
+	sessionSecretPtr := flag.String("session-secret", "",
+		"secret signing session tokens. A random one is used when empty, so sessions end when the server restarts.")
+	sessionTTLPtr := flag.Duration("session-ttl", 24*time.Hour, "how long a session lasts after signing in.")
 
+	flag.Usage = usage
 	flag.Parse()
 
 	db, err := setupDatabase(shouldMigratePtr, dbFilePathPtr)
@@ -35,21 +43,103 @@ func run() error {
 		return err
 	}
 
-	// setup router dependency
-	router := gin.Default()
-	//TODO: this is not secure, remove it later
-	router.Use(cors.Default())
-
 	accountsDAO := repository.NewAccountsDAO(db)
+	transactionsDAO := repository.NewTransactionsDAO(db)
+	organizationsDAO := repository.NewOrganizationsDAO(db)
+	payeesDAO := repository.NewPayeesDAO(db)
+	categoriesDAO := repository.NewCategoriesDAO(db)
+	tagsDAO := repository.NewTagsDAO(db)
+	importProfilesDAO := repository.NewImportProfilesDAO(db)
+	rulesDAO := repository.NewRulesDAO(db)
+	budgetsDAO := repository.NewBudgetsDAO(db)
+	exchangeRatesDAO := repository.NewExchangeRatesDAO(db)
+	reportsDAO := repository.NewReportsDAO(db)
+	recurringTransactionsDAO := repository.NewRecurringTransactionsDAO(db)
+	loansDAO := repository.NewLoansDAO(db)
+	investmentsDAO := repository.NewInvestmentsDAO(db)
+	creditCardsDAO := repository.NewCreditCardsDAO(db)
+	usersDAO := repository.NewUsersDAO(db)
 	// create all required services
-	accountsService := api.NewAccountsService(accountsDAO)
+	exchangeRatesService := api.NewExchangeRatesService(exchangeRatesDAO)
+	accountsService := api.NewAccountsService(accountsDAO, exchangeRatesService)
+	payeesService := api.NewPayeesService(payeesDAO)
+	rulesService := api.NewRulesService(rulesDAO)
+	transactionsService := api.NewTransactionsService(transactionsDAO, payeesService, rulesService)
+	organizationsService := api.NewOrganizationsService(organizationsDAO)
+	categoriesService := api.NewCategoriesService(categoriesDAO, exchangeRatesService)
+	tagsService := api.NewTagsService(tagsDAO)
+	budgetsService := api.NewBudgetsService(budgetsDAO, categoriesService)
+	importService := api.NewImportService(importProfilesDAO, transactionsService, accountsService,
+		organizationsService, payeesService, categoriesService)
+	exportService := api.NewExportService(transactionsService, accountsService, payeesService, categoriesService)
+	reportsService := api.NewReportsService(reportsDAO, categoriesService, exchangeRatesService)
+	recurringTransactionsService := api.NewRecurringTransactionsService(recurringTransactionsDAO, transactionsService)
+	loansService := api.NewLoansService(loansDAO, accountsService, transactionsService)
+	investmentsService := api.NewInvestmentsService(investmentsDAO, accountsService)
+	creditCardsService := api.NewCreditCardsService(creditCardsDAO, accountsService, transactionsService)
+	secret, err := sessionSecret(*sessionSecretPtr)
+	if err != nil {
+		return err
+	}
+	usersService := api.NewUsersService(usersDAO, secret, *sessionTTLPtr)
+
+	// subcommands run against the database instead of starting the server
+	switch flag.Arg(0) {
+	case "":
+	case importCSVCommand:
+		return runImportCSV(importService, flag.Args()[1:])
+	case importOFXCommand:
+		return runImportOFX(importService, flag.Args()[1:])
+	case importQIFCommand:
+		return runImportQIF(importService, flag.Args()[1:])
+	case exportQIFCommand:
+		return runExportQIF(exportService, flag.Args()[1:])
+	case importRatesCommand:
+		return runImportRates(exchangeRatesService, flag.Args()[1:])
+	case importPricesCommand:
+		return runImportPrices(investmentsService, flag.Args()[1:])
+	case addUserCommand:
+		return runAddUser(usersService, flag.Args()[1:])
+	default:
+		usage()
+		return fmt.Errorf("unknown command %s", flag.Arg(0))
+	}
 
-	server := app.NewServer(router, accountsService)
+	// setup router dependency
+	router := gin.Default()
+	//TODO: this is not secure, remove it later
+	corsConfig := cors.DefaultConfig()
+	corsConfig.AllowAllOrigins = true
+	corsConfig.AddAllowHeaders("Authorization")
+	router.Use(cors.New(corsConfig))
+
+	server := app.NewServer(router, accountsService, transactionsService, organizationsService, payeesService,
+		categoriesService, tagsService, importService, exportService, rulesService, budgetsService,
+		exchangeRatesService, reportsService, recurringTransactionsService, loansService,
+		investmentsService, creditCardsService, usersService)
+	if *sessionSecretPtr == "" {
+		log.Println("no -session-secret given, sessions end when the server restarts")
+	}
+	if *scheduleIntervalPtr > 0 {
+		startScheduler(recurringTransactionsService, *scheduleIntervalPtr)
+	}
 	err = server.Run()
 
 	return err
 }
 
+// sessionSecret returns the secret of the -session-secret flag, or a random one when the flag is empty.
+func sessionSecret(secret string) ([]byte, error) {
+	if secret != "" {
+		return []byte(secret), nil
+	}
+	random := make([]byte, 32)
+	if _, err := rand.Read(random); err != nil {
+		return nil, fmt.Errorf("failed to generate a session secret with err: %v", err)
+	}
+	return random, nil
+}
+
 func setupDatabase(shouldMigratePtr *bool, dbFilePathPtr *string) (*sql.DB, error) {
 
 	db, err := sql.Open("sqlite", *dbFilePathPtr)
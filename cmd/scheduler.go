@@ -0,0 +1,29 @@
+package main
+
+import (
+	"go-personal-finance/pkg/api"
+	"log"
+	"time"
+)
+
+// startScheduler materializes the recurring transactions that are due right away and then on every tick of the
+// interval, for as long as the server runs. Failures are logged and retried on the next tick.
+func startScheduler(recurringTransactionsService *api.RecurringTransactionsService, interval time.Duration) {
+	materialize := func() {
+		created, err := recurringTransactionsService.MaterializeTransactions(time.Now())
+		if err != nil {
+			log.Printf("failed to materialize recurring transactions with err: %v\n", err)
+			return
+		}
+		if created > 0 {
+			log.Printf("materialized %d recurring transactions\n", created)
+		}
+	}
+	go func() {
+		materialize()
+		ticker := time.NewTicker(interval)
+		for range ticker.C {
+			materialize()
+		}
+	}()
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"go-personal-finance/pkg/api"
+	"os"
+	"strings"
+)
+
+// runAddUser creates a user from the terminal, which is how the first user is made as every api route other than
+// login needs a session. The password is read from stdin so that it stays out of the shell history.
+func runAddUser(usersService *api.UsersService, args []string) error {
+	flags := flag.NewFlagSet(addUserCommand, flag.ContinueOnError)
+	username := flags.String("username", "", "name the user signs in with.")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *username == "" {
+		flags.Usage()
+		return fmt.Errorf("-username is required")
+	}
+
+	fmt.Fprint(os.Stderr, "password: ")
+	password, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && password == "" {
+		return fmt.Errorf("failed to read password with err: %v", err)
+	}
+	password = strings.TrimRight(password, "\r\n")
+	id, err := usersService.CreateUser(api.UserCreationRequest{Username: username, Password: &password})
+	if err != nil {
+		return err
+	}
+	fmt.Printf("created user %s with id %d\n", *username, id)
+	return nil
+}
@@ -0,0 +1,13 @@
+-- splits break a transaction down into several categories, like a supermarket bill covering groceries and household
+-- items. The amounts of the splits of a transaction add up to the amount of the transaction.
+CREATE TABLE transaction_splits
+(
+    id             integer primary key autoincrement,
+    transaction_id integer   not null references transactions (id),
+    category_id    integer references categories (id),
+    amount         NUMERIC   not null,
+    memo           varchar,
+    created_ts     timestamp not null default current_timestamp
+);
+
+CREATE INDEX transaction_splits_transaction_id_idx ON transaction_splits (transaction_id);
@@ -0,0 +1,21 @@
+-- rules that categorize transactions as they are created or imported. every condition that is set has to match and
+-- every action that is set is applied.
+CREATE TABLE transaction_rules
+(
+    id                   integer primary key autoincrement,
+    name                 varchar   not null,
+    priority             integer   not null default 0,         -- rules with a higher priority are evaluated first
+    description_contains varchar,
+    description_regex    varchar,
+    min_amount           NUMERIC,                              -- signed, so expenses have negative bounds
+    max_amount           NUMERIC,
+    account_id           integer references accounts (id),
+    payee_id             integer references payees (id),
+    set_category_id      integer references categories (id),
+    set_payee_id         integer references payees (id),
+    set_tags             varchar,                              -- json array of tag names added to the transaction
+    set_memo             varchar,
+    is_deleted           tinyint            default 0,
+    created_ts           timestamp not null default current_timestamp,
+    updated_ts           timestamp not null default current_timestamp -- needs to be manually updated on updates
+);
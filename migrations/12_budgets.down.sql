@@ -0,0 +1,2 @@
+DROP INDEX budgets_category_idx;
+DROP TABLE budgets;
@@ -0,0 +1,15 @@
+-- monthly spending limits per category. spending in the child categories counts towards the budget of the parent.
+CREATE TABLE budgets
+(
+    id          integer primary key autoincrement,
+    category_id integer   not null references categories (id),
+    amount      NUMERIC   not null,                           -- monthly limit, as a positive amount
+    rollover    tinyint   not null default 0,                 -- carries the unused amount over to the next month
+    start_month varchar   not null,                           -- first month of the budget formatted as 2006-01
+    is_deleted  tinyint            default 0,
+    created_ts  timestamp not null default current_timestamp,
+    updated_ts  timestamp not null default current_timestamp -- needs to be manually updated on updates
+);
+
+-- a category has a single active budget
+CREATE UNIQUE INDEX budgets_category_idx ON budgets (category_id) WHERE is_deleted = 0;
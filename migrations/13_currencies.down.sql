@@ -0,0 +1,3 @@
+DROP INDEX exchange_rates_pair_date_idx;
+DROP TABLE exchange_rates;
+ALTER TABLE accounts DROP COLUMN currency;
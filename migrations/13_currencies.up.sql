@@ -0,0 +1,17 @@
+-- the currency an account is held in. transactions without a currency of their own are in the currency of their
+-- account.
+ALTER TABLE accounts ADD COLUMN currency varchar not null default 'USD';
+
+-- dated exchange rates, where one unit of the base currency is worth rate units of the quote currency
+CREATE TABLE exchange_rates
+(
+    id             integer primary key autoincrement,
+    rate_date      varchar   not null,                         -- formatted as 2006-01-02
+    base_currency  varchar   not null,
+    quote_currency varchar   not null,
+    rate           NUMERIC   not null,
+    created_ts     timestamp not null default current_timestamp,
+    updated_ts     timestamp not null default current_timestamp -- needs to be manually updated on updates
+);
+
+CREATE UNIQUE INDEX exchange_rates_pair_date_idx ON exchange_rates (base_currency, quote_currency, rate_date);
@@ -0,0 +1,16 @@
+UPDATE budgets
+SET amount = amount / 100.0;
+
+UPDATE transaction_rules
+SET min_amount = min_amount / 100.0,
+    max_amount = max_amount / 100.0;
+
+UPDATE transaction_splits
+SET amount = amount / 100.0;
+
+UPDATE transactions
+SET amount = amount / 100.0;
+
+UPDATE accounts
+SET opening_balance = opening_balance / 100.0,
+    current_balance = current_balance / 100.0;
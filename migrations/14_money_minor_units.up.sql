@@ -0,0 +1,18 @@
+-- amounts are stored as whole cents instead of decimals so that sums and balances are exact. NUMERIC columns keep
+-- integers as they are, so only the values need converting.
+UPDATE accounts
+SET opening_balance = CAST(ROUND(opening_balance * 100) AS INTEGER),
+    current_balance = CAST(ROUND(current_balance * 100) AS INTEGER);
+
+UPDATE transactions
+SET amount = CAST(ROUND(amount * 100) AS INTEGER);
+
+UPDATE transaction_splits
+SET amount = CAST(ROUND(amount * 100) AS INTEGER);
+
+UPDATE transaction_rules
+SET min_amount = CAST(ROUND(min_amount * 100) AS INTEGER),
+    max_amount = CAST(ROUND(max_amount * 100) AS INTEGER);
+
+UPDATE budgets
+SET amount = CAST(ROUND(amount * 100) AS INTEGER);
@@ -0,0 +1,24 @@
+-- templates of transactions that repeat on a schedule, like rent or subscriptions. every occurrence up to a day is
+-- materialized into the ledger, after which next_date moves on to the following occurrence.
+CREATE TABLE recurring_transactions
+(
+    id             integer primary key autoincrement,
+    name           varchar   not null,
+    account_id     integer   not null references accounts (id),
+    description    varchar,
+    memo           varchar,
+    amount         integer   not null,                          -- whole cents, negative for bills like transactions
+    currency       varchar,
+    payee_id       integer references payees (id),
+    category_id    integer references categories (id),
+    frequency      varchar   not null,                          -- daily, weekly, monthly or yearly
+    interval_count integer   not null default 1,                -- number of periods between occurrences
+    week_of_month  integer,                                     -- 1 to 4 or -1 for the last week of monthly schedules on a weekday
+    day_of_week    integer,                                     -- 0 for sunday to 6 for saturday, along with week_of_month
+    start_date     varchar   not null,                          -- formatted as 2006-01-02
+    end_date       varchar,                                     -- last day an occurrence can fall on
+    next_date      varchar,                                     -- next occurrence to materialize, null once the schedule has ended
+    is_deleted     tinyint            default 0,
+    created_ts     timestamp not null default current_timestamp,
+    updated_ts     timestamp not null default current_timestamp -- needs to be manually updated on updates
+);
@@ -0,0 +1,2 @@
+DROP INDEX loans_account_idx;
+DROP TABLE loans;
@@ -0,0 +1,17 @@
+-- terms of the loans held in loan and mortgage accounts, from which their amortization schedules are derived.
+CREATE TABLE loans
+(
+    id          integer primary key autoincrement,
+    account_id  integer   not null references accounts (id),
+    principal   integer   not null,                           -- borrowed amount in whole cents
+    annual_rate real      not null,                           -- yearly interest rate, like 0.045 for 4.5%
+    term_months integer   not null,                           -- number of monthly payments
+    start_date  varchar   not null,                           -- day the loan was taken out formatted as 2006-01-02
+    payment_day integer   not null,                           -- day of the month payments are due on
+    is_deleted  tinyint            default 0,
+    created_ts  timestamp not null default current_timestamp,
+    updated_ts  timestamp not null default current_timestamp -- needs to be manually updated on updates
+);
+
+-- an account holds a single active loan
+CREATE UNIQUE INDEX loans_account_idx ON loans (account_id) WHERE is_deleted = 0;
@@ -0,0 +1,4 @@
+DROP INDEX security_prices_symbol_date_idx;
+DROP TABLE security_prices;
+DROP INDEX investment_transactions_account_symbol_idx;
+DROP TABLE investment_transactions;
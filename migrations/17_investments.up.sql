@@ -0,0 +1,31 @@
+-- buys, sells and dividends of the securities held in investment accounts, from which holdings, lots and gains are
+-- derived.
+CREATE TABLE investment_transactions
+(
+    id         integer primary key autoincrement,
+    account_id integer   not null references accounts (id),
+    symbol     varchar   not null,                           -- ticker of the security, upper cased
+    type       varchar   not null,                           -- buy, sell or dividend
+    trade_date varchar   not null,                           -- formatted as 2006-01-02
+    quantity   real      not null default 0,                 -- units bought or sold, 0 for dividends
+    amount     integer   not null,                           -- cost, proceeds or dividend in whole cents, before fees
+    fee        integer   not null default 0,                 -- commission in whole cents
+    is_deleted tinyint            default 0,
+    created_ts timestamp not null default current_timestamp,
+    updated_ts timestamp not null default current_timestamp -- needs to be manually updated on updates
+);
+
+CREATE INDEX investment_transactions_account_symbol_idx ON investment_transactions (account_id, symbol, trade_date);
+
+-- dated closing prices of securities, in the currency of the accounts holding them
+CREATE TABLE security_prices
+(
+    id         integer primary key autoincrement,
+    symbol     varchar   not null,
+    price_date varchar   not null,                           -- formatted as 2006-01-02
+    price      NUMERIC   not null,                           -- price of one unit
+    created_ts timestamp not null default current_timestamp,
+    updated_ts timestamp not null default current_timestamp -- needs to be manually updated on updates
+);
+
+CREATE UNIQUE INDEX security_prices_symbol_date_idx ON security_prices (symbol, price_date);
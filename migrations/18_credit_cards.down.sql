@@ -0,0 +1,2 @@
+DROP INDEX credit_cards_account_idx;
+DROP TABLE credit_cards;
@@ -0,0 +1,16 @@
+-- statement terms of credit card accounts, from which their statement cycles and due dates are derived.
+CREATE TABLE credit_cards
+(
+    id                   integer primary key autoincrement,
+    account_id           integer   not null references accounts (id),
+    closing_day          integer   not null,                           -- day of the month statements close on
+    due_day              integer   not null,                           -- day of the month payments are due on
+    minimum_payment_rate real      not null,                           -- share of the balance due, like 0.02 for 2%
+    minimum_payment      integer   not null,                           -- least amount due in whole cents
+    is_deleted           tinyint            default 0,
+    created_ts           timestamp not null default current_timestamp,
+    updated_ts           timestamp not null default current_timestamp -- needs to be manually updated on updates
+);
+
+-- an account holds a single active credit card
+CREATE UNIQUE INDEX credit_cards_account_idx ON credit_cards (account_id) WHERE is_deleted = 0;
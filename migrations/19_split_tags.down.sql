@@ -0,0 +1,2 @@
+DROP INDEX transaction_split_tags_tag_name_idx;
+DROP TABLE transaction_split_tags;
@@ -0,0 +1,10 @@
+-- links the splits of a transaction to tags of their own, next to the tags of the whole transaction
+CREATE TABLE transaction_split_tags
+(
+    split_id   integer   not null references transaction_splits (id),
+    tag_name   varchar   not null references tags (name),
+    created_ts timestamp not null default current_timestamp,
+    primary key (split_id, tag_name)
+);
+
+CREATE INDEX transaction_split_tags_tag_name_idx ON transaction_split_tags (tag_name);
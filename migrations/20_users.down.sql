@@ -0,0 +1,2 @@
+DROP INDEX users_username_idx;
+DROP TABLE users;
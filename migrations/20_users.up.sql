@@ -0,0 +1,13 @@
+-- users that can sign in to the api. Passwords are only kept as bcrypt hashes.
+CREATE TABLE users
+(
+    id            integer primary key autoincrement,
+    username      varchar   not null,
+    password_hash varchar   not null,
+    is_deleted    tinyint            default 0,
+    created_ts    timestamp not null default current_timestamp,
+    updated_ts    timestamp not null default current_timestamp -- needs to be manually updated on updates
+);
+
+-- usernames are unique among active users
+CREATE UNIQUE INDEX users_username_idx ON users (username) WHERE is_deleted = 0;
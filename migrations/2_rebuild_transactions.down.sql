@@ -0,0 +1,29 @@
+DROP INDEX IF EXISTS transactions_account_time_idx;
+
+CREATE TABLE transactions_old
+(
+    id               integer primary key autoincrement,
+    transaction_time timestamp not null,
+    type             timestamp not null,                          -- transfer or normal
+    account_id       integer references accounts (id),
+    description      varchar,
+    memo             varchar,                                     -- personal notes about a transaction
+    amount           NUMERIC,                                     -- negative value indicates expense, positive value indicates income
+    currency         varchar,                                     -- to handle multi currency computations
+    payee            integer references payees (id),
+    category_id      varchar references categories (id),
+    tags             varchar,                                     -- no foreign key because it's a json column
+    is_deleted       tinyint            default 0,
+    created_ts       timestamp not null default current_timestamp,
+    updated_ts       timestamp not null default current_timestamp -- needs to be manually updated on updates
+);
+
+INSERT INTO transactions_old (id, transaction_time, type, account_id, description, memo, amount, currency, payee,
+                              category_id, tags, is_deleted, created_ts, updated_ts)
+SELECT id, transaction_time, type, account_id, description, memo, amount, currency, payee,
+       category_id, tags, is_deleted, created_ts, updated_ts
+FROM transactions;
+
+DROP TABLE transactions;
+
+ALTER TABLE transactions_old RENAME TO transactions;
@@ -0,0 +1,31 @@
+-- The initial schema declared transactions.type as a timestamp and category_id as a varchar.
+-- sqlite cannot change column types in place, so the table is rebuilt and existing rows are copied over.
+CREATE TABLE transactions_new
+(
+    id               integer primary key autoincrement,
+    transaction_time timestamp not null,
+    type             varchar   not null default 'normal',         -- transfer or normal
+    account_id       integer   not null references accounts (id),
+    description      varchar,
+    memo             varchar,                                     -- personal notes about a transaction
+    amount           NUMERIC   not null,                          -- negative value indicates expense, positive value indicates income
+    currency         varchar,                                     -- to handle multi currency computations
+    payee            integer references payees (id),
+    category_id      integer references categories (id),
+    tags             varchar,                                     -- no foreign key because it's a json column
+    is_deleted       tinyint            default 0,
+    created_ts       timestamp not null default current_timestamp,
+    updated_ts       timestamp not null default current_timestamp -- needs to be manually updated on updates
+);
+
+INSERT INTO transactions_new (id, transaction_time, type, account_id, description, memo, amount, currency, payee,
+                              category_id, tags, is_deleted, created_ts, updated_ts)
+SELECT id, transaction_time, type, account_id, description, memo, amount, currency, payee,
+       category_id, tags, is_deleted, created_ts, updated_ts
+FROM transactions;
+
+DROP TABLE transactions;
+
+ALTER TABLE transactions_new RENAME TO transactions;
+
+CREATE INDEX transactions_account_time_idx ON transactions (account_id, transaction_time);
@@ -0,0 +1 @@
+DROP TABLE payee_rules;
@@ -0,0 +1,12 @@
+-- rules mapping raw transaction descriptions like "AMZN MKTP US*2K4" onto a canonical payee
+CREATE TABLE payee_rules
+(
+    id         integer primary key autoincrement,
+    payee_id   integer   not null references payees (id),
+    match_type varchar   not null,                            -- contains, prefix or regex
+    pattern    varchar   not null,
+    priority   integer   not null default 0,                  -- rules with a higher priority are evaluated first
+    is_deleted tinyint            default 0,
+    created_ts timestamp not null default current_timestamp,
+    updated_ts timestamp not null default current_timestamp -- needs to be manually updated on updates
+);
@@ -0,0 +1,19 @@
+DROP INDEX IF EXISTS categories_parent_idx;
+
+CREATE TABLE categories_old
+(
+    id         integer primary key autoincrement,
+    name       varchar   not null,
+    parent     integer            default name,
+    is_deleted tinyint            default 0,
+    created_ts timestamp not null default current_timestamp,
+    updated_ts timestamp not null default current_timestamp -- needs to be manually updated on updates
+);
+
+INSERT INTO categories_old (id, name, parent, is_deleted, created_ts, updated_ts)
+SELECT id, name, parent, is_deleted, created_ts, updated_ts
+FROM categories;
+
+DROP TABLE categories;
+
+ALTER TABLE categories_old RENAME TO categories;
@@ -0,0 +1,26 @@
+-- The initial schema declared categories.parent with a default of name, which silently stored the category's own
+-- name as its parent. The table is rebuilt with a nullable self reference where NULL marks a root category.
+CREATE TABLE categories_new
+(
+    id         integer primary key autoincrement,
+    name       varchar   not null,
+    parent     integer references categories (id),
+    is_deleted tinyint            default 0,
+    created_ts timestamp not null default current_timestamp,
+    updated_ts timestamp not null default current_timestamp -- needs to be manually updated on updates
+);
+
+INSERT INTO categories_new (id, name, parent, is_deleted, created_ts, updated_ts)
+SELECT id,
+       name,
+       CASE WHEN typeof(parent) = 'integer' AND parent != id THEN parent END,
+       is_deleted,
+       created_ts,
+       updated_ts
+FROM categories;
+
+DROP TABLE categories;
+
+ALTER TABLE categories_new RENAME TO categories;
+
+CREATE INDEX categories_parent_idx ON categories (parent);
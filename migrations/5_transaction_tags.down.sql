@@ -0,0 +1,7 @@
+ALTER TABLE transactions ADD COLUMN tags varchar; -- no foreign key because it's a json column
+
+UPDATE transactions
+SET tags = (SELECT json_group_array(tag_name) FROM transaction_tags WHERE transaction_id = transactions.id)
+WHERE id IN (SELECT transaction_id FROM transaction_tags);
+
+DROP TABLE transaction_tags;
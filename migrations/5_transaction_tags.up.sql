@@ -0,0 +1,28 @@
+-- links transactions to tags, replacing the json encoded transactions.tags column
+CREATE TABLE transaction_tags
+(
+    transaction_id integer   not null references transactions (id),
+    tag_name       varchar   not null references tags (name),
+    created_ts     timestamp not null default current_timestamp,
+    primary key (transaction_id, tag_name)
+);
+
+CREATE INDEX transaction_tags_tag_name_idx ON transaction_tags (tag_name);
+
+INSERT OR IGNORE INTO tags (name)
+SELECT DISTINCT trim(tag.value)
+FROM transactions,
+     json_each(transactions.tags) AS tag
+WHERE transactions.tags IS NOT NULL
+  AND json_valid(transactions.tags)
+  AND trim(tag.value) != '';
+
+INSERT OR IGNORE INTO transaction_tags (transaction_id, tag_name)
+SELECT transactions.id, trim(tag.value)
+FROM transactions,
+     json_each(transactions.tags) AS tag
+WHERE transactions.tags IS NOT NULL
+  AND json_valid(transactions.tags)
+  AND trim(tag.value) != '';
+
+ALTER TABLE transactions DROP COLUMN tags;
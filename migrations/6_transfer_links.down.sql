@@ -0,0 +1 @@
+ALTER TABLE transactions DROP COLUMN linked_transaction_id;
@@ -0,0 +1,2 @@
+-- both legs of a transfer point at each other so that edits and deletes can be applied to the pair
+ALTER TABLE transactions ADD COLUMN linked_transaction_id integer references transactions (id);
@@ -0,0 +1,11 @@
+-- balances are derived from an opening balance plus the ledger. current_balance is kept as a running total that is
+-- maintained along with the transactions and can be reconciled against the derived balance.
+ALTER TABLE accounts ADD COLUMN opening_balance NUMERIC not null default 0;
+
+-- existing balances were entered by hand, so the opening balance is whatever makes the ledger add up to them
+UPDATE accounts
+SET opening_balance = COALESCE(current_balance, 0) - COALESCE((SELECT SUM(amount)
+                                                               FROM transactions
+                                                               WHERE account_id = accounts.id
+                                                                 AND is_deleted = 0), 0),
+    current_balance = COALESCE(current_balance, 0);
@@ -0,0 +1,19 @@
+-- column mappings used to read the csv statements exported by a bank. columns are referenced by their header name.
+CREATE TABLE import_profiles
+(
+    id                 integer primary key autoincrement,
+    name               varchar   not null,
+    delimiter          varchar   not null default ',',
+    skip_rows          integer   not null default 0,           -- lines before the header row, like account summaries
+    date_column        varchar   not null,
+    date_format        varchar   not null,                     -- go reference layout like 01/02/2006
+    description_column varchar,
+    memo_column        varchar,
+    amount_column      varchar,                                -- either a signed amount column
+    debit_column       varchar,                                -- or separate debit and credit columns
+    credit_column      varchar,
+    amount_sign        varchar   not null default 'normal',    -- inverted when the bank reports expenses as positive
+    is_deleted         tinyint            default 0,
+    created_ts         timestamp not null default current_timestamp,
+    updated_ts         timestamp not null default current_timestamp -- needs to be manually updated on updates
+);
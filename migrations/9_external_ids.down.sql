@@ -0,0 +1,4 @@
+DROP INDEX accounts_external_account_id_idx;
+DROP INDEX transactions_account_external_id_idx;
+ALTER TABLE accounts DROP COLUMN external_account_id;
+ALTER TABLE transactions DROP COLUMN external_id;
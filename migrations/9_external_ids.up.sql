@@ -0,0 +1,9 @@
+-- ids assigned by the bank, like the FITID of an ofx transaction and the ACCTID of an ofx account, so that
+-- statements can be imported more than once without duplicating anything
+ALTER TABLE transactions ADD COLUMN external_id varchar;
+ALTER TABLE accounts ADD COLUMN external_account_id varchar;
+
+CREATE UNIQUE INDEX transactions_account_external_id_idx ON transactions (account_id, external_id)
+    WHERE external_id IS NOT NULL AND is_deleted = 0;
+CREATE UNIQUE INDEX accounts_external_account_id_idx ON accounts (external_account_id)
+    WHERE external_account_id IS NOT NULL AND is_deleted = 0;
@@ -2,43 +2,90 @@ package api
 
 import (
 	"fmt"
+	"strings"
 	"time"
 )
 
+// Account balances are derived from the ledger. CurrentBalance is the OpeningBalance plus every transaction of the
+// account; it is kept up to date by each write to the ledger and cannot be set directly.
 type Account struct {
-	Id             int64     `json:"id"`
-	Name           string    `json:"name"`
-	AccountType    string    `json:"account_type"`
-	AccountSubType string    `json:"account_sub_type"`
-	OrgId          int64     `json:"org_id"`
-	CurrentBalance float64   `json:"current_balance"`
-	IsDeleted      bool      `json:"is_deleted"`
-	CreatedTs      time.Time `json:"created_ts"`
-	UpdatedTs      time.Time `json:"updated_ts"`
+	Id             int64  `json:"id"`
+	Name           string `json:"name"`
+	AccountType    string `json:"account_type"`
+	AccountSubType string `json:"account_sub_type"`
+	OrgId          int64  `json:"org_id"`
+	OpeningBalance Money  `json:"opening_balance"`
+	CurrentBalance Money  `json:"current_balance"`
+	// ExternalAccountId is the account number used by the bank in exported statements
+	ExternalAccountId string `json:"external_account_id"`
+	// Currency is the currency the balances of the account and its transactions are in
+	Currency  string    `json:"currency"`
+	IsDeleted bool      `json:"is_deleted"`
+	CreatedTs time.Time `json:"created_ts"`
+	UpdatedTs time.Time `json:"updated_ts"`
 }
 
 type AccountCreationRequest struct {
-	Name           *string  `json:"name"`
-	AccountType    *string  `json:"account_type"`
-	AccountSubType *string  `json:"account_sub_type"`
-	OrgId          *int64   `json:"org_id"`
-	CurrentBalance *float64 `json:"current_balance"`
+	Name              *string `json:"name"`
+	AccountType       *string `json:"account_type"`
+	AccountSubType    *string `json:"account_sub_type"`
+	OrgId             *int64  `json:"org_id"`
+	OpeningBalance    *Money  `json:"opening_balance"`
+	ExternalAccountId *string `json:"external_account_id"`
+	Currency          *string `json:"currency"`
 }
 
 type AccountUpdateRequest struct {
-	Name           *string  `json:"name"`
-	AccountType    *string  `json:"account_type"`
-	AccountSubType *string  `json:"account_sub_type"`
-	OrgId          *int64   `json:"org_id"`
-	CurrentBalance *float64 `json:"current_balance"`
+	Name           *string `json:"name"`
+	AccountType    *string `json:"account_type"`
+	AccountSubType *string `json:"account_sub_type"`
+	OrgId          *int64  `json:"org_id"`
+	OpeningBalance *Money  `json:"opening_balance"`
+	Currency       *string `json:"currency"`
+}
+
+// normalizeAccountType lower cases an account type and joins its words with underscores so that "Credit Cards",
+// "credit-cards" and "credit_cards" are the same type.
+func normalizeAccountType(accountType string) string {
+	return strings.NewReplacer(" ", "_", "-", "_").Replace(strings.ToLower(strings.TrimSpace(accountType)))
+}
+
+const AccountSortByName = "name"
+const AccountSortByBalance = "balance"
+const AccountSortByCreatedTs = "created_ts"
+
+const defaultAccountsPageSize = 50
+const maxAccountsPageSize = 500
+
+// AccountFilter narrows down and orders ListAccounts. Nil fields are not applied.
+type AccountFilter struct {
+	AccountType    *string
+	AccountSubType *string
+	OrgId          *int64
+	IncludeDeleted bool
+	SortBy         string
+	Descending     bool
+	Limit          int
+	Offset         int
+}
+
+// AccountsPage is a single page of accounts along with the total number of accounts matching the filter.
+type AccountsPage struct {
+	Accounts []Account `json:"accounts"`
+	Total    int64     `json:"total"`
+	Limit    int       `json:"limit"`
+	Offset   int       `json:"offset"`
 }
 
 type AccountsService struct {
-	dao AccountsDataAccessor
+	dao           AccountsDataAccessor
+	exchangeRates ExchangeRateSource
 }
 
-func NewAccountsService(dao AccountsDataAccessor) *AccountsService {
-	return &AccountsService{dao}
+// NewAccountsService creates the service. exchangeRates is optional and, when present, is used to convert balances
+// into other currencies.
+func NewAccountsService(dao AccountsDataAccessor, exchangeRates ExchangeRateSource) *AccountsService {
+	return &AccountsService{dao: dao, exchangeRates: exchangeRates}
 }
 
 func (service *AccountsService) GetAccount(id int64) (Account, error) {
@@ -49,8 +96,62 @@ func (service *AccountsService) GetAccount(id int64) (Account, error) {
 	return account, nil
 }
 
-func (service *AccountsService) CreateAccount(request AccountCreationRequest) error {
-	_, err := service.dao.InsertAccount(request)
+func (service *AccountsService) ListAccounts(filter AccountFilter) (AccountsPage, error) {
+	switch filter.SortBy {
+	case "":
+		filter.SortBy = AccountSortByName
+	case AccountSortByName, AccountSortByBalance, AccountSortByCreatedTs:
+	default:
+		return AccountsPage{}, fmt.Errorf("%w: accounts cannot be sorted by %s", ErrInvalidRequest, filter.SortBy)
+	}
+	if filter.Limit < 0 || filter.Offset < 0 {
+		return AccountsPage{}, fmt.Errorf("%w: limit and offset cannot be negative", ErrInvalidRequest)
+	}
+	if filter.Limit == 0 {
+		filter.Limit = defaultAccountsPageSize
+	}
+	if filter.Limit > maxAccountsPageSize {
+		filter.Limit = maxAccountsPageSize
+	}
+	accounts, total, err := service.dao.ListAccounts(filter)
+	if err != nil {
+		return AccountsPage{}, fmt.Errorf("failed to list accounts with err:%v", err)
+	}
+	return AccountsPage{Accounts: accounts, Total: total, Limit: filter.Limit, Offset: filter.Offset}, nil
+}
+
+// CreateAccount creates an account held in DefaultCurrency unless the request names a currency.
+func (service *AccountsService) CreateAccount(request AccountCreationRequest) (int64, error) {
+	currency := DefaultCurrency
+	if request.Currency != nil {
+		normalized, err := NormalizeCurrency(*request.Currency)
+		if err != nil {
+			return -1, err
+		}
+		currency = normalized
+	}
+	request.Currency = &currency
+	id, err := service.dao.InsertAccount(request)
+	if err != nil {
+		return -1, err
+	}
+	return id, nil
+}
+
+// FindAccountByExternalId looks up the account linked to the account number used by the bank. The boolean is false
+// when no account is linked to it.
+func (service *AccountsService) FindAccountByExternalId(externalAccountId string) (Account, bool, error) {
+	account, found, err := service.dao.FindAccountByExternalId(externalAccountId)
+	if err != nil {
+		return account, false, fmt.Errorf("failed to find account with external id %s with err:%v",
+			externalAccountId, err)
+	}
+	return account, found, nil
+}
+
+// LinkExternalAccount links the account number used by the bank to the account, unlinking it from any other account.
+func (service *AccountsService) LinkExternalAccount(id int64, externalAccountId string) error {
+	err := service.dao.LinkExternalAccount(id, externalAccountId)
 	if err != nil {
 		return err
 	}
@@ -66,6 +167,13 @@ func (service *AccountsService) DeleteAccount(id int64) error {
 }
 
 func (service *AccountsService) UpdateAccount(id int64, request AccountUpdateRequest) error {
+	if request.Currency != nil {
+		currency, err := NormalizeCurrency(*request.Currency)
+		if err != nil {
+			return err
+		}
+		request.Currency = &currency
+	}
 	err := service.dao.UpdateAccount(id, request)
 	if err != nil {
 		return err
@@ -75,8 +183,12 @@ func (service *AccountsService) UpdateAccount(id int64, request AccountUpdateReq
 
 type AccountsDataAccessor interface {
 	GetAccount(id int64) (Account, error)
-	//ListAccounts(id int64) []Account
+	ListAccounts(filter AccountFilter) ([]Account, int64, error)
 	InsertAccount(request AccountCreationRequest) (int64, error)
 	DeleteAccount(id int64) error
 	UpdateAccount(id int64, request AccountUpdateRequest) error
+	GetDerivedBalance(id int64, asOf time.Time) (Money, error)
+	ListAccountBalances() ([]AccountReconciliation, error)
+	FindAccountByExternalId(externalAccountId string) (Account, bool, error)
+	LinkExternalAccount(id int64, externalAccountId string) error
 }
@@ -16,13 +16,17 @@ func (m *happyMock) GetAccount(id int64) (Account, error) {
 		AccountType:    "savings",
 		AccountSubType: "sub",
 		OrgId:          int64(1),
-		CurrentBalance: 50.00,
+		CurrentBalance: 5000,
 		IsDeleted:      false,
 		CreatedTs:      time.Now(),
 		UpdatedTs:      time.Now(),
 	}, nil
 }
 
+func (m *happyMock) ListAccounts(filter AccountFilter) ([]Account, int64, error) {
+	return []Account{{Id: 1}, {Id: 2}}, 12, nil
+}
+
 func (m *happyMock) InsertAccount(request AccountCreationRequest) (int64, error) {
 	return 1, nil
 }
@@ -34,11 +38,31 @@ func (m *happyMock) UpdateAccount(id int64, request AccountUpdateRequest) error
 	return nil
 }
 
+func (m *happyMock) GetDerivedBalance(id int64, asOf time.Time) (Money, error) {
+	return 4250, nil
+}
+
+func (m *happyMock) ListAccountBalances() ([]AccountReconciliation, error) {
+	return []AccountReconciliation{
+		{AccountId: 1, StoredBalance: 5000, DerivedBalance: 5000},
+		{AccountId: 2, StoredBalance: 1000, DerivedBalance: 1000},
+		{AccountId: 3, StoredBalance: 7500, DerivedBalance: 7000},
+	}, nil
+}
+
+func (m *happyMock) FindAccountByExternalId(externalAccountId string) (Account, bool, error) {
+	return Account{}, false, nil
+}
+
+func (m *happyMock) LinkExternalAccount(id int64, externalAccountId string) error {
+	return nil
+}
+
 func TestAccountsService_GetAccount(t *testing.T) {
 
 	t.Run("testing happy flow of getting an account", func(t *testing.T) {
 		dao := happyMock{}
-		accountsService := NewAccountsService(&dao)
+		accountsService := NewAccountsService(&dao, nil)
 		account, err := accountsService.GetAccount(1)
 		if err != nil {
 			t.Errorf("unexpected error when retrieving account")
@@ -51,7 +75,7 @@ func TestAccountsService_GetAccount(t *testing.T) {
 	t.Run("testing general error flow of getting an account", func(t *testing.T) {
 
 		dao := errorMock{}
-		accountsService := NewAccountsService(&dao)
+		accountsService := NewAccountsService(&dao, nil)
 		_, err := accountsService.GetAccount(1)
 		expectedErrorMsg := "failed to retrieve account of id 1 with err:db timeout"
 		if err.Error() != expectedErrorMsg {
@@ -60,11 +84,53 @@ func TestAccountsService_GetAccount(t *testing.T) {
 	})
 }
 
+func TestAccountsService_ListAccounts(t *testing.T) {
+
+	t.Run("testing happy flow of listing accounts with defaults", func(t *testing.T) {
+		accountsService := NewAccountsService(&happyMock{}, nil)
+		page, err := accountsService.ListAccounts(AccountFilter{})
+		if err != nil {
+			t.Errorf("unexpected error when listing accounts")
+		}
+		if len(page.Accounts) != 2 || page.Total != 12 {
+			t.Errorf("unexpected page with %d accounts and total %d", len(page.Accounts), page.Total)
+		}
+		if page.Limit != defaultAccountsPageSize {
+			t.Errorf("expected default limit %d but found %d", defaultAccountsPageSize, page.Limit)
+		}
+	})
+
+	t.Run("testing page size is capped", func(t *testing.T) {
+		accountsService := NewAccountsService(&happyMock{}, nil)
+		page, _ := accountsService.ListAccounts(AccountFilter{Limit: 10000})
+		if page.Limit != maxAccountsPageSize {
+			t.Errorf("expected limit %d but found %d", maxAccountsPageSize, page.Limit)
+		}
+	})
+
+	t.Run("testing validation of unknown sort keys", func(t *testing.T) {
+		accountsService := NewAccountsService(&happyMock{}, nil)
+		_, err := accountsService.ListAccounts(AccountFilter{SortBy: "id; DROP TABLE accounts"})
+		if !errors.Is(err, ErrInvalidRequest) {
+			t.Errorf("expected invalid request error but found %v", err)
+		}
+	})
+
+	t.Run("testing general error flow of listing accounts", func(t *testing.T) {
+		accountsService := NewAccountsService(&errorMock{}, nil)
+		_, err := accountsService.ListAccounts(AccountFilter{})
+		expectedErrorMsg := "failed to list accounts with err:db timeout"
+		if err.Error() != expectedErrorMsg {
+			t.Errorf("expected error message %s but found %s", expectedErrorMsg, err.Error())
+		}
+	})
+}
+
 func TestAccountsService_DeleteAccount(t *testing.T) {
 
 	t.Run("testing happy flow of deleting an account", func(t *testing.T) {
 		dao := happyMock{}
-		accountsService := NewAccountsService(&dao)
+		accountsService := NewAccountsService(&dao, nil)
 		err := accountsService.DeleteAccount(1)
 		if err != nil {
 			t.Errorf("unexpected error when deleting account")
@@ -73,7 +139,7 @@ func TestAccountsService_DeleteAccount(t *testing.T) {
 
 	t.Run("testing general error flow of deleting an account", func(t *testing.T) {
 		dao := errorMock{}
-		accountsService := NewAccountsService(&dao)
+		accountsService := NewAccountsService(&dao, nil)
 		err := accountsService.DeleteAccount(1)
 		expectedErrorMsg := "db timeout"
 		if err.Error() != expectedErrorMsg {
@@ -87,13 +153,13 @@ func TestAccountsService_UpdateAccount(t *testing.T) {
 	accountName := "acc1"
 	accountType := "savings"
 	orgId := int64(1)
-	currentBalance := 24.00
+	openingBalance := Money(2400)
 	t.Run("testing happy flow of updating an account", func(t *testing.T) {
 		dao := happyMock{}
-		accountsService := NewAccountsService(&dao)
+		accountsService := NewAccountsService(&dao, nil)
 
 		err := accountsService.UpdateAccount(1, AccountUpdateRequest{AccountType: &accountType, Name: &accountName,
-			AccountSubType: nil, OrgId: &orgId, CurrentBalance: &currentBalance})
+			AccountSubType: nil, OrgId: &orgId, OpeningBalance: &openingBalance})
 		if err != nil {
 			t.Errorf("unexpected error when updating account")
 		}
@@ -102,9 +168,9 @@ func TestAccountsService_UpdateAccount(t *testing.T) {
 	t.Run("testing general error flow of updating an account", func(t *testing.T) {
 
 		dao := errorMock{}
-		accountsService := NewAccountsService(&dao)
+		accountsService := NewAccountsService(&dao, nil)
 		err := accountsService.UpdateAccount(1, AccountUpdateRequest{AccountType: &accountType, Name: &accountName,
-			AccountSubType: nil, OrgId: &orgId, CurrentBalance: &currentBalance})
+			AccountSubType: nil, OrgId: &orgId, OpeningBalance: &openingBalance})
 		expectedErrorMsg := "db timeout"
 		if err.Error() != expectedErrorMsg {
 			t.Errorf("expected error message %s but found %s", expectedErrorMsg, err.Error())
@@ -117,24 +183,27 @@ func TestAccountsService_CreateAccount(t *testing.T) {
 	accountName := "acc1"
 	accountType := "savings"
 	orgId := int64(1)
-	currentBalance := 24.00
+	openingBalance := Money(2400)
 	t.Run("testing happy flow of creating an account", func(t *testing.T) {
 
 		dao := happyMock{}
-		accountsService := NewAccountsService(&dao)
-		err := accountsService.CreateAccount(AccountCreationRequest{AccountType: &accountType, Name: &accountName,
-			AccountSubType: nil, OrgId: &orgId, CurrentBalance: &currentBalance})
+		accountsService := NewAccountsService(&dao, nil)
+		id, err := accountsService.CreateAccount(AccountCreationRequest{AccountType: &accountType, Name: &accountName,
+			AccountSubType: nil, OrgId: &orgId, OpeningBalance: &openingBalance})
 		if err != nil {
 			t.Errorf("unexpected error when creating account")
 		}
+		if id != 1 {
+			t.Errorf("unexpected account id %d", id)
+		}
 	})
 
 	t.Run("testing general error flow of creating an account", func(t *testing.T) {
 
 		dao := errorMock{}
-		accountsService := NewAccountsService(&dao)
-		err := accountsService.CreateAccount(AccountCreationRequest{AccountType: &accountType, Name: &accountName,
-			AccountSubType: nil, OrgId: &orgId, CurrentBalance: &currentBalance})
+		accountsService := NewAccountsService(&dao, nil)
+		_, err := accountsService.CreateAccount(AccountCreationRequest{AccountType: &accountType, Name: &accountName,
+			AccountSubType: nil, OrgId: &orgId, OpeningBalance: &openingBalance})
 		expectedErrorMsg := "db timeout"
 		if err.Error() != expectedErrorMsg {
 			t.Errorf("expected error message %s but found %s", expectedErrorMsg, err.Error())
@@ -149,6 +218,10 @@ func (m *errorMock) GetAccount(id int64) (Account, error) {
 	return Account{}, errors.New("db timeout")
 }
 
+func (m *errorMock) ListAccounts(filter AccountFilter) ([]Account, int64, error) {
+	return nil, 0, errors.New("db timeout")
+}
+
 func (m *errorMock) InsertAccount(request AccountCreationRequest) (int64, error) {
 	return 0, errors.New("db timeout")
 }
@@ -159,3 +232,19 @@ func (m *errorMock) DeleteAccount(id int64) error {
 func (m *errorMock) UpdateAccount(id int64, request AccountUpdateRequest) error {
 	return errors.New("db timeout")
 }
+
+func (m *errorMock) GetDerivedBalance(id int64, asOf time.Time) (Money, error) {
+	return 0, errors.New("db timeout")
+}
+
+func (m *errorMock) ListAccountBalances() ([]AccountReconciliation, error) {
+	return nil, errors.New("db timeout")
+}
+
+func (m *errorMock) FindAccountByExternalId(externalAccountId string) (Account, bool, error) {
+	return Account{}, false, errors.New("db timeout")
+}
+
+func (m *errorMock) LinkExternalAccount(id int64, externalAccountId string) error {
+	return errors.New("db timeout")
+}
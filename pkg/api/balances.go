@@ -0,0 +1,94 @@
+package api
+
+import (
+	"fmt"
+	"time"
+)
+
+// AccountBalance is the balance of an account derived from the ledger at a point in time. Balance is in the currency
+// of the account and BaseBalance is converted into BaseCurrency at the rate of AsOf.
+type AccountBalance struct {
+	AccountId    int64     `json:"account_id"`
+	AsOf         time.Time `json:"as_of"`
+	Balance      Money     `json:"balance"`
+	Currency     string    `json:"currency"`
+	BaseBalance  Money     `json:"base_balance"`
+	BaseCurrency string    `json:"base_currency"`
+}
+
+// AccountReconciliation compares the stored current balance of an account with the balance derived from its
+// opening balance and transactions. Drift is the stored balance minus the derived balance.
+type AccountReconciliation struct {
+	AccountId      int64  `json:"account_id"`
+	Name           string `json:"name"`
+	StoredBalance  Money  `json:"stored_balance"`
+	DerivedBalance Money  `json:"derived_balance"`
+	Drift          Money  `json:"drift"`
+	Drifted        bool   `json:"drifted"`
+}
+
+// GetAccountBalance derives the balance of the account at asOf from the transactions made before it. When asOf is
+// nil the balance as of now is returned. The balance is converted into baseCurrency, which defaults to the currency
+// of the account.
+func (service *AccountsService) GetAccountBalance(id int64, asOf *time.Time, baseCurrency string) (AccountBalance,
+	error) {
+	balanceTime := time.Now().UTC()
+	if asOf != nil {
+		balanceTime = *asOf
+	}
+	balance, err := service.dao.GetDerivedBalance(id, balanceTime)
+	if err != nil {
+		return AccountBalance{}, fmt.Errorf("failed to retrieve balance of account %d with err:%v", id, err)
+	}
+	account, err := service.GetAccount(id)
+	if err != nil {
+		return AccountBalance{}, err
+	}
+	currency := accountCurrency(account)
+	if baseCurrency == "" {
+		baseCurrency = currency
+	}
+	if baseCurrency, err = NormalizeCurrency(baseCurrency); err != nil {
+		return AccountBalance{}, err
+	}
+	accountBalance := AccountBalance{AccountId: id, AsOf: balanceTime, Balance: balance, Currency: currency,
+		BaseBalance: balance, BaseCurrency: baseCurrency}
+	if baseCurrency == currency {
+		return accountBalance, nil
+	}
+	converter, err := loadConverter(service.exchangeRates)
+	if err != nil {
+		return AccountBalance{}, err
+	}
+	if accountBalance.BaseBalance, err = converter.Convert(balance, currency, baseCurrency, balanceTime); err != nil {
+		return AccountBalance{}, err
+	}
+	return accountBalance, nil
+}
+
+// accountCurrency is the currency of the account, falling back to DefaultCurrency for accounts created before
+// accounts had one.
+func accountCurrency(account Account) string {
+	if account.Currency == "" {
+		return DefaultCurrency
+	}
+	return account.Currency
+}
+
+// ReconcileAccounts flags every account whose stored balance has drifted from the balance derived from the ledger.
+// Accounts that are in sync are only returned when includeAll is set.
+func (service *AccountsService) ReconcileAccounts(includeAll bool) ([]AccountReconciliation, error) {
+	balances, err := service.dao.ListAccountBalances()
+	if err != nil {
+		return nil, fmt.Errorf("failed to reconcile accounts with err:%v", err)
+	}
+	reconciliations := []AccountReconciliation{}
+	for _, balance := range balances {
+		balance.Drift = balance.StoredBalance - balance.DerivedBalance
+		balance.Drifted = balance.Drift != 0
+		if balance.Drifted || includeAll {
+			reconciliations = append(reconciliations, balance)
+		}
+	}
+	return reconciliations, nil
+}
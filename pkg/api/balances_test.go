@@ -0,0 +1,69 @@
+package api
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAccountsService_GetAccountBalance(t *testing.T) {
+
+	t.Run("testing happy flow of getting the balance of an account as of a date", func(t *testing.T) {
+		accountsService := NewAccountsService(&happyMock{}, nil)
+		asOf := time.Date(2022, 7, 1, 0, 0, 0, 0, time.UTC)
+		balance, err := accountsService.GetAccountBalance(1, &asOf, "")
+		if err != nil {
+			t.Errorf("unexpected error when retrieving balance: %v", err)
+		}
+		if balance.Balance != 4250 || !balance.AsOf.Equal(asOf) {
+			t.Errorf("unexpected balance %s as of %v", balance.Balance, balance.AsOf)
+		}
+	})
+
+	t.Run("testing balance defaults to now", func(t *testing.T) {
+		accountsService := NewAccountsService(&happyMock{}, nil)
+		balance, _ := accountsService.GetAccountBalance(1, nil, "")
+		if time.Since(balance.AsOf) > time.Minute {
+			t.Errorf("expected balance as of now but found %v", balance.AsOf)
+		}
+	})
+
+	t.Run("testing general error flow of getting the balance of an account", func(t *testing.T) {
+		accountsService := NewAccountsService(&errorMock{}, nil)
+		_, err := accountsService.GetAccountBalance(1, nil, "")
+		expectedErrorMsg := "failed to retrieve balance of account 1 with err:db timeout"
+		if err == nil || err.Error() != expectedErrorMsg {
+			t.Errorf("expected error message %s but found %v", expectedErrorMsg, err)
+		}
+	})
+}
+
+func TestAccountsService_ReconcileAccounts(t *testing.T) {
+
+	t.Run("testing happy flow of flagging drifted accounts", func(t *testing.T) {
+		accountsService := NewAccountsService(&happyMock{}, nil)
+		reconciliations, err := accountsService.ReconcileAccounts(false)
+		if err != nil {
+			t.Errorf("unexpected error when reconciling accounts: %v", err)
+		}
+		if len(reconciliations) != 1 || reconciliations[0].AccountId != 3 || reconciliations[0].Drift != 500 {
+			t.Errorf("expected only account 3 to drift by 5 but found %+v", reconciliations)
+		}
+	})
+
+	t.Run("testing accounts in sync are returned when requested", func(t *testing.T) {
+		accountsService := NewAccountsService(&happyMock{}, nil)
+		reconciliations, _ := accountsService.ReconcileAccounts(true)
+		if len(reconciliations) != 3 || reconciliations[1].Drifted {
+			t.Errorf("expected 3 accounts with account 2 in sync but found %+v", reconciliations)
+		}
+	})
+
+	t.Run("testing general error flow of reconciling accounts", func(t *testing.T) {
+		accountsService := NewAccountsService(&errorMock{}, nil)
+		_, err := accountsService.ReconcileAccounts(false)
+		expectedErrorMsg := "failed to reconcile accounts with err:db timeout"
+		if err == nil || err.Error() != expectedErrorMsg {
+			t.Errorf("expected error message %s but found %v", expectedErrorMsg, err)
+		}
+	})
+}
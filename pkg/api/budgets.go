@@ -0,0 +1,257 @@
+package api
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// BudgetMonthLayout formats the months budgets are tracked by, like 2022-07.
+const BudgetMonthLayout = "2006-01"
+
+// Budget limits the monthly spending of a category, including the spending of its child categories, starting
+// with StartMonth. With Rollover the amount left over at the end of a month is added to the next month.
+type Budget struct {
+	Id         int64     `json:"id"`
+	CategoryId int64     `json:"category_id"`
+	Amount     Money     `json:"amount"`
+	Rollover   bool      `json:"rollover"`
+	StartMonth string    `json:"start_month"`
+	IsDeleted  bool      `json:"is_deleted"`
+	CreatedTs  time.Time `json:"created_ts"`
+	UpdatedTs  time.Time `json:"updated_ts"`
+}
+
+// BudgetRequest is used to both create and update a budget. StartMonth defaults to the current month.
+type BudgetRequest struct {
+	CategoryId *int64  `json:"category_id"`
+	Amount     *Money  `json:"amount"`
+	Rollover   *bool   `json:"rollover"`
+	StartMonth *string `json:"start_month"`
+}
+
+// BudgetProgress is how much of a budget has been spent in a month. Spent is the net amount going out of the
+// category and its descendants, so refunds lower it. Available is the budgeted amount plus the amount rolled over
+// from the previous months.
+type BudgetProgress struct {
+	BudgetId   int64  `json:"budget_id"`
+	CategoryId int64  `json:"category_id"`
+	Category   string `json:"category"`
+	Budgeted   Money  `json:"budgeted"`
+	RolledOver Money  `json:"rolled_over"`
+	Available  Money  `json:"available"`
+	Spent      Money  `json:"spent"`
+	Remaining  Money  `json:"remaining"`
+}
+
+// MonthlyBudget is the progress of every budget active in a month. The totals leave out budgets of categories whose
+// ancestor has a budget too, since the spending of the category is already part of the budget of the ancestor.
+type MonthlyBudget struct {
+	Month     string           `json:"month"`
+	Currency  string           `json:"currency"`
+	Budgeted  Money            `json:"budgeted"`
+	Available Money            `json:"available"`
+	Spent     Money            `json:"spent"`
+	Remaining Money            `json:"remaining"`
+	Budgets   []BudgetProgress `json:"budgets"`
+}
+
+type BudgetsService struct {
+	dao               BudgetsDataAccessor
+	categoriesService *CategoriesService
+}
+
+func NewBudgetsService(dao BudgetsDataAccessor, categoriesService *CategoriesService) *BudgetsService {
+	return &BudgetsService{dao, categoriesService}
+}
+
+func (service *BudgetsService) GetBudget(id int64) (Budget, error) {
+	budget, err := service.dao.GetBudget(id)
+	if err != nil {
+		return budget, fmt.Errorf("failed to retrieve budget of id %d with err:%v", id, err)
+	}
+	return budget, nil
+}
+
+func (service *BudgetsService) ListBudgets() ([]Budget, error) {
+	budgets, err := service.dao.ListBudgets()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list budgets with err:%v", err)
+	}
+	return budgets, nil
+}
+
+func (service *BudgetsService) CreateBudget(request BudgetRequest) (int64, error) {
+	request, err := service.validateBudget(0, request)
+	if err != nil {
+		return -1, err
+	}
+	return service.dao.InsertBudget(request)
+}
+
+func (service *BudgetsService) UpdateBudget(id int64, request BudgetRequest) error {
+	request, err := service.validateBudget(id, request)
+	if err != nil {
+		return err
+	}
+	return service.dao.UpdateBudget(id, request)
+}
+
+func (service *BudgetsService) DeleteBudget(id int64) error {
+	err := service.dao.DeleteBudget(id)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// validateBudget checks the request of budget id, which is 0 for a new budget, and fills in its defaults.
+func (service *BudgetsService) validateBudget(id int64, request BudgetRequest) (BudgetRequest, error) {
+	if request.CategoryId == nil {
+		return request, fmt.Errorf("%w: category_id is required", ErrInvalidRequest)
+	}
+	if request.Amount == nil {
+		return request, fmt.Errorf("%w: amount is required", ErrInvalidRequest)
+	}
+	if *request.Amount < 0 {
+		return request, fmt.Errorf("%w: amount cannot be negative", ErrInvalidRequest)
+	}
+	if request.Rollover == nil {
+		rollover := false
+		request.Rollover = &rollover
+	}
+	if isBlank(request.StartMonth) {
+		startMonth := time.Now().UTC().Format(BudgetMonthLayout)
+		request.StartMonth = &startMonth
+	} else if _, err := ParseBudgetMonth(*request.StartMonth); err != nil {
+		return request, err
+	}
+	if _, err := service.categoriesService.GetCategory(*request.CategoryId); err != nil {
+		return request, fmt.Errorf("%w: category %d does not exist", ErrInvalidRequest, *request.CategoryId)
+	}
+	budgets, err := service.ListBudgets()
+	if err != nil {
+		return request, err
+	}
+	for _, budget := range budgets {
+		if budget.CategoryId == *request.CategoryId && budget.Id != id {
+			return request, fmt.Errorf("%w: category %d already has budget %d", ErrInvalidRequest,
+				budget.CategoryId, budget.Id)
+		}
+	}
+	return request, nil
+}
+
+// ParseBudgetMonth returns the first instant of a month formatted like 2022-07.
+func ParseBudgetMonth(month string) (time.Time, error) {
+	parsed, err := time.Parse(BudgetMonthLayout, month)
+	if err != nil {
+		return parsed, fmt.Errorf("%w: month %q has to be formatted as 2006-01", ErrInvalidRequest, month)
+	}
+	return parsed, nil
+}
+
+// GetMonthlyBudget reports the progress of the budgets that have started by the month. Rolling budgets carry over
+// what was left of every month since they started, while overspending a month does not lower the next one. With a
+// currency the spending is converted into it and the budgets are taken to be in it as well.
+func (service *BudgetsService) GetMonthlyBudget(month string, currency string) (MonthlyBudget, error) {
+	start, err := ParseBudgetMonth(month)
+	if err != nil {
+		return MonthlyBudget{}, err
+	}
+	if currency != "" {
+		if currency, err = NormalizeCurrency(currency); err != nil {
+			return MonthlyBudget{}, err
+		}
+	}
+	budgets, err := service.ListBudgets()
+	if err != nil {
+		return MonthlyBudget{}, err
+	}
+	categories, err := service.categoriesService.ListCategories()
+	if err != nil {
+		return MonthlyBudget{}, err
+	}
+	spending := map[string]map[int64]Money{}
+	spentIn := func(month time.Time) (map[int64]Money, error) {
+		key := month.Format(BudgetMonthLayout)
+		if spent, ok := spending[key]; ok {
+			return spent, nil
+		}
+		end := month.AddDate(0, 1, 0)
+		totals, err := service.categoriesService.SumAmountsByCategory(&month, &end, currency)
+		if err != nil {
+			return nil, err
+		}
+		spending[key] = SubtreeTotals(categories, totals)
+		return spending[key], nil
+	}
+
+	report := MonthlyBudget{Month: start.Format(BudgetMonthLayout), Currency: currency, Budgets: []BudgetProgress{}}
+	active := map[int64]bool{}
+	for _, budget := range budgets {
+		budgetStart, err := ParseBudgetMonth(budget.StartMonth)
+		if err != nil || budgetStart.After(start) {
+			continue
+		}
+		active[budget.CategoryId] = true
+		var rolledOver Money
+		for current := budgetStart; budget.Rollover && current.Before(start); current = current.AddDate(0, 1, 0) {
+			spent, err := spentIn(current)
+			if err != nil {
+				return MonthlyBudget{}, err
+			}
+			if rolledOver += budget.Amount + spent[budget.CategoryId]; rolledOver < 0 {
+				rolledOver = 0
+			}
+		}
+		spent, err := spentIn(start)
+		if err != nil {
+			return MonthlyBudget{}, err
+		}
+		progress := BudgetProgress{BudgetId: budget.Id, CategoryId: budget.CategoryId, Budgeted: budget.Amount,
+			RolledOver: rolledOver, Available: budget.Amount + rolledOver, Spent: -spent[budget.CategoryId]}
+		progress.Remaining = progress.Available - progress.Spent
+		report.Budgets = append(report.Budgets, progress)
+	}
+
+	paths := CategoryPaths(categories)
+	parents := make(map[int64]int64, len(categories))
+	for _, category := range categories {
+		parents[category.Id] = category.ParentId
+	}
+	for i := range report.Budgets {
+		progress := &report.Budgets[i]
+		progress.Category = paths[progress.CategoryId]
+		if hasBudgetedAncestor(progress.CategoryId, parents, active) {
+			continue
+		}
+		report.Budgeted += progress.Budgeted
+		report.Available += progress.Available
+		report.Spent += progress.Spent
+	}
+	sort.SliceStable(report.Budgets, func(i, j int) bool {
+		return report.Budgets[i].Category < report.Budgets[j].Category
+	})
+	report.Remaining = report.Available - report.Spent
+	return report, nil
+}
+
+func hasBudgetedAncestor(categoryId int64, parents map[int64]int64, budgeted map[int64]bool) bool {
+	seen := map[int64]bool{categoryId: true}
+	for parent := parents[categoryId]; parent != 0 && !seen[parent]; parent = parents[parent] {
+		if budgeted[parent] {
+			return true
+		}
+		seen[parent] = true
+	}
+	return false
+}
+
+type BudgetsDataAccessor interface {
+	GetBudget(id int64) (Budget, error)
+	ListBudgets() ([]Budget, error)
+	InsertBudget(request BudgetRequest) (int64, error)
+	UpdateBudget(id int64, request BudgetRequest) error
+	DeleteBudget(id int64) error
+}
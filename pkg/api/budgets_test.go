@@ -0,0 +1,178 @@
+package api
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// budgetsMock keeps budgets in memory and fails every call when err is set.
+type budgetsMock struct {
+	budgets  []Budget
+	inserted *BudgetRequest
+	err      error
+}
+
+func (m *budgetsMock) GetBudget(id int64) (Budget, error) {
+	for _, budget := range m.budgets {
+		if budget.Id == id {
+			return budget, m.err
+		}
+	}
+	return Budget{}, errors.New("sql: no rows in result set")
+}
+
+func (m *budgetsMock) ListBudgets() ([]Budget, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	return m.budgets, nil
+}
+
+func (m *budgetsMock) InsertBudget(request BudgetRequest) (int64, error) {
+	m.inserted = &request
+	return 4, m.err
+}
+
+func (m *budgetsMock) UpdateBudget(id int64, request BudgetRequest) error {
+	return m.err
+}
+
+func (m *budgetsMock) DeleteBudget(id int64) error {
+	return m.err
+}
+
+// budgetCategoriesMock spends on groceries in May and June, and reports the totals of categoriesMock for July.
+type budgetCategoriesMock struct {
+	categoriesMock
+	months []string
+}
+
+func (m *budgetCategoriesMock) SumAmountsByCategory(from *time.Time, to *time.Time) (map[int64]Money, error) {
+	m.months = append(m.months, from.Format(BudgetMonthLayout))
+	switch from.Format(BudgetMonthLayout) {
+	case "2022-05":
+		return map[int64]Money{2: -30000}, nil
+	case "2022-06":
+		return map[int64]Money{2: -45000, 4: -1000}, nil
+	}
+	return m.categoriesMock.SumAmountsByCategory(from, to)
+}
+
+func budgets() []Budget {
+	return []Budget{
+		{Id: 1, CategoryId: 1, Amount: 40000, Rollover: true, StartMonth: "2022-05"},
+		{Id: 2, CategoryId: 4, Amount: 2000, StartMonth: "2022-06"},
+		{Id: 3, CategoryId: 5, Amount: 10000, StartMonth: "2022-08"},
+	}
+}
+
+func TestBudgetsService_CreateBudget(t *testing.T) {
+
+	categoryId := int64(2)
+	amount := Money(15000)
+
+	t.Run("testing happy flow of creating a budget", func(t *testing.T) {
+		dao := budgetsMock{budgets: budgets()}
+		budgetsService := NewBudgetsService(&dao, NewCategoriesService(&categoriesMock{}, nil))
+		id, err := budgetsService.CreateBudget(BudgetRequest{CategoryId: &categoryId, Amount: &amount})
+		if err != nil || id != 4 {
+			t.Errorf("unexpected id %d or error %v", id, err)
+			return
+		}
+		if *dao.inserted.Rollover || *dao.inserted.StartMonth != time.Now().UTC().Format(BudgetMonthLayout) {
+			t.Errorf("unexpected defaults %+v", dao.inserted)
+		}
+	})
+
+	t.Run("testing validation of budgets", func(t *testing.T) {
+		negative := Money(-100)
+		missingCategoryId := int64(9)
+		budgetedCategoryId := int64(4)
+		invalidMonth := "07/2022"
+		for _, request := range []BudgetRequest{
+			{Amount: &amount},
+			{CategoryId: &categoryId},
+			{CategoryId: &categoryId, Amount: &negative},
+			{CategoryId: &categoryId, Amount: &amount, StartMonth: &invalidMonth},
+			{CategoryId: &missingCategoryId, Amount: &amount},
+			{CategoryId: &budgetedCategoryId, Amount: &amount},
+		} {
+			budgetsService := NewBudgetsService(&budgetsMock{budgets: budgets()},
+				NewCategoriesService(&categoriesMock{}, nil))
+			_, err := budgetsService.CreateBudget(request)
+			if !errors.Is(err, ErrInvalidRequest) {
+				t.Errorf("expected invalid request error for %+v but found %v", request, err)
+			}
+		}
+	})
+
+	t.Run("testing a budget can be updated without changing its category", func(t *testing.T) {
+		budgetedCategoryId := int64(4)
+		budgetsService := NewBudgetsService(&budgetsMock{budgets: budgets()},
+			NewCategoriesService(&categoriesMock{}, nil))
+		err := budgetsService.UpdateBudget(2, BudgetRequest{CategoryId: &budgetedCategoryId, Amount: &amount})
+		if err != nil {
+			t.Errorf("unexpected error when updating budget: %v", err)
+		}
+	})
+}
+
+func TestBudgetsService_GetMonthlyBudget(t *testing.T) {
+
+	t.Run("testing happy flow of tracking budgets with rollover", func(t *testing.T) {
+		categoriesDAO := budgetCategoriesMock{}
+		budgetsService := NewBudgetsService(&budgetsMock{budgets: budgets()}, NewCategoriesService(&categoriesDAO, nil))
+		report, err := budgetsService.GetMonthlyBudget("2022-07", "")
+		if err != nil {
+			t.Errorf("unexpected error when tracking budgets: %v", err)
+			return
+		}
+		if len(report.Budgets) != 2 {
+			t.Errorf("expected the budgets of food and coffee but found %+v", report.Budgets)
+			return
+		}
+		expectedFood := BudgetProgress{BudgetId: 1, CategoryId: 1, Category: "Food", Budgeted: 40000, RolledOver: 4000,
+			Available: 44000, Spent: 15750, Remaining: 28250}
+		expectedCoffee := BudgetProgress{BudgetId: 2, CategoryId: 4, Category: "Food:Restaurants:Coffee",
+			Budgeted: 2000, Available: 2000, Spent: 1250, Remaining: 750}
+		if report.Budgets[0] != expectedFood || report.Budgets[1] != expectedCoffee {
+			t.Errorf("unexpected progress %+v", report.Budgets)
+		}
+		if report.Month != "2022-07" || report.Budgeted != 40000 || report.Available != 44000 ||
+			report.Spent != 15750 || report.Remaining != 28250 {
+			t.Errorf("expected the coffee budget to be left out of the totals but found %+v", report)
+		}
+		if len(categoriesDAO.months) != 3 {
+			t.Errorf("expected the spending of every month to be summed once but found %v", categoriesDAO.months)
+		}
+	})
+
+	t.Run("testing overspending is not rolled over", func(t *testing.T) {
+		rolling := []Budget{{Id: 1, CategoryId: 2, Amount: 35000, Rollover: true, StartMonth: "2022-05"}}
+		budgetsService := NewBudgetsService(&budgetsMock{budgets: rolling},
+			NewCategoriesService(&budgetCategoriesMock{}, nil))
+		report, err := budgetsService.GetMonthlyBudget("2022-07", "")
+		if err != nil || report.Budgets[0].RolledOver != 0 || report.Budgets[0].Remaining != 25000 {
+			t.Errorf("unexpected progress %+v or error %v", report.Budgets, err)
+		}
+	})
+
+	t.Run("testing validation of the month", func(t *testing.T) {
+		budgetsService := NewBudgetsService(&budgetsMock{}, NewCategoriesService(&categoriesMock{}, nil))
+		_, err := budgetsService.GetMonthlyBudget("July", "")
+		if !errors.Is(err, ErrInvalidRequest) {
+			t.Errorf("expected invalid request error but found %v", err)
+		}
+	})
+
+	t.Run("testing general error flow of tracking budgets", func(t *testing.T) {
+		budgetsService := NewBudgetsService(&budgetsMock{err: errors.New("db timeout")},
+			NewCategoriesService(&categoriesMock{}, nil))
+		_, err := budgetsService.GetMonthlyBudget("2022-07", "")
+		expectedErrorMsg := "failed to list budgets with err:db timeout"
+		if err == nil || err.Error() != expectedErrorMsg {
+			t.Errorf("expected error message %s but found %v", expectedErrorMsg, err)
+		}
+	})
+}
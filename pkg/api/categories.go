@@ -0,0 +1,311 @@
+package api
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Category is a node of the category hierarchy. A ParentId of 0 marks a root category.
+type Category struct {
+	Id        int64     `json:"id"`
+	Name      string    `json:"name"`
+	ParentId  int64     `json:"parent_id"`
+	IsDeleted bool      `json:"is_deleted"`
+	CreatedTs time.Time `json:"created_ts"`
+	UpdatedTs time.Time `json:"updated_ts"`
+}
+
+type CategoryNode struct {
+	Category
+	Children []*CategoryNode `json:"children"`
+}
+
+// CategoryRollup holds the transaction totals of a category. OwnTotal only counts transactions and splits assigned
+// directly to the category while Total also includes every descendant.
+type CategoryRollup struct {
+	CategoryId int64             `json:"category_id"`
+	Name       string            `json:"name"`
+	OwnTotal   Money             `json:"own_total"`
+	Total      Money             `json:"total"`
+	Children   []*CategoryRollup `json:"children"`
+}
+
+// CategoryCurrencyTotal is the total of the transactions of a category made in a currency on a day formatted like
+// 2006-01-02.
+type CategoryCurrencyTotal struct {
+	CategoryId int64
+	Currency   string
+	Day        string
+	Amount     Money
+}
+
+type CategoryCreationRequest struct {
+	Name     *string `json:"name"`
+	ParentId *int64  `json:"parent_id"`
+}
+
+type CategoryUpdateRequest struct {
+	Name *string `json:"name"`
+}
+
+// CategoryMoveRequest moves a category under a new parent. A nil ParentId turns the category into a root.
+type CategoryMoveRequest struct {
+	ParentId *int64 `json:"parent_id"`
+}
+
+type CategoriesService struct {
+	dao           CategoriesDataAccessor
+	exchangeRates ExchangeRateSource
+}
+
+// NewCategoriesService creates the service. exchangeRates is optional and, when present, is used to total
+// transactions of several currencies.
+func NewCategoriesService(dao CategoriesDataAccessor, exchangeRates ExchangeRateSource) *CategoriesService {
+	return &CategoriesService{dao: dao, exchangeRates: exchangeRates}
+}
+
+func (service *CategoriesService) GetCategory(id int64) (Category, error) {
+	category, err := service.dao.GetCategory(id)
+	if err != nil {
+		return category, fmt.Errorf("failed to retrieve category of id %d with err:%v", id, err)
+	}
+	return category, nil
+}
+
+func (service *CategoriesService) ListCategories() ([]Category, error) {
+	categories, err := service.dao.ListCategories()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list categories with err:%v", err)
+	}
+	return categories, nil
+}
+
+// GetCategoryTree returns every root category with its descendants nested underneath, ordered by name.
+func (service *CategoriesService) GetCategoryTree() ([]*CategoryNode, error) {
+	categories, err := service.ListCategories()
+	if err != nil {
+		return nil, err
+	}
+	return BuildCategoryTree(categories), nil
+}
+
+func (service *CategoriesService) CreateCategory(request CategoryCreationRequest) (int64, error) {
+	if request.Name == nil || strings.TrimSpace(*request.Name) == "" {
+		return -1, fmt.Errorf("%w: name is required", ErrInvalidRequest)
+	}
+	if request.ParentId != nil {
+		if _, err := service.dao.GetCategory(*request.ParentId); err != nil {
+			return -1, fmt.Errorf("%w: parent category %d does not exist", ErrInvalidRequest, *request.ParentId)
+		}
+	}
+	return service.dao.InsertCategory(request)
+}
+
+func (service *CategoriesService) UpdateCategory(id int64, request CategoryUpdateRequest) error {
+	if request.Name == nil || strings.TrimSpace(*request.Name) == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidRequest)
+	}
+	return service.dao.UpdateCategory(id, request)
+}
+
+// MoveCategory re-parents a category along with its whole subtree. Moving a category underneath itself or one of
+// its descendants is rejected since it would detach the subtree from the hierarchy.
+func (service *CategoriesService) MoveCategory(id int64, request CategoryMoveRequest) error {
+	categories, err := service.ListCategories()
+	if err != nil {
+		return err
+	}
+	parents := make(map[int64]int64, len(categories))
+	for _, category := range categories {
+		parents[category.Id] = category.ParentId
+	}
+	if _, ok := parents[id]; !ok {
+		return fmt.Errorf("%w: category %d does not exist", ErrInvalidRequest, id)
+	}
+	if request.ParentId != nil {
+		ancestor := *request.ParentId
+		if _, ok := parents[ancestor]; !ok {
+			return fmt.Errorf("%w: parent category %d does not exist", ErrInvalidRequest, ancestor)
+		}
+		for ancestor != 0 {
+			if ancestor == id {
+				return fmt.Errorf("%w: moving category %d under %d would create a cycle", ErrInvalidRequest, id,
+					*request.ParentId)
+			}
+			ancestor = parents[ancestor]
+		}
+	}
+	return service.dao.MoveCategory(id, request.ParentId)
+}
+
+// DeleteCategory soft deletes a category. Its children are attached to its parent so the rest of the tree stays
+// reachable.
+func (service *CategoriesService) DeleteCategory(id int64) error {
+	err := service.dao.DeleteCategory(id)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// GetCategoryRollup returns the transaction totals of a category and all of its descendants over [from, to),
+// converted into currency unless it is empty.
+func (service *CategoriesService) GetCategoryRollup(id int64, from *time.Time, to *time.Time,
+	currency string) (*CategoryRollup, error) {
+	categories, err := service.ListCategories()
+	if err != nil {
+		return nil, err
+	}
+	totals, err := service.SumAmountsByCategory(from, to, currency)
+	if err != nil {
+		return nil, err
+	}
+	node := findCategoryNode(BuildCategoryTree(categories), id)
+	if node == nil {
+		return nil, fmt.Errorf("%w: category %d does not exist", ErrInvalidRequest, id)
+	}
+	return rollupCategory(node, totals), nil
+}
+
+// SumAmountsByCategory totals the transactions and splits assigned directly to each category over [from, to), so a
+// split transaction counts towards the categories of its splits rather than its own. With a currency every
+// transaction is converted into it at the rate of its day, otherwise the amounts are summed as they are.
+func (service *CategoriesService) SumAmountsByCategory(from *time.Time, to *time.Time,
+	currency string) (map[int64]Money, error) {
+	if currency == "" {
+		totals, err := service.dao.SumAmountsByCategory(from, to)
+		if err != nil {
+			return nil, fmt.Errorf("failed to sum transactions by category with err:%v", err)
+		}
+		return totals, nil
+	}
+	currency, err := NormalizeCurrency(currency)
+	if err != nil {
+		return nil, err
+	}
+	converter, err := loadConverter(service.exchangeRates)
+	if err != nil {
+		return nil, err
+	}
+	currencyTotals, err := service.dao.SumAmountsByCategoryAndCurrency(from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sum transactions by category with err:%v", err)
+	}
+	totals := map[int64]Money{}
+	for _, total := range currencyTotals {
+		day, err := time.Parse(ExchangeRateDateLayout, total.Day)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read day %q of the totals of category %d", total.Day, total.CategoryId)
+		}
+		amount, err := converter.Convert(total.Amount, total.Currency, currency, day)
+		if err != nil {
+			return nil, err
+		}
+		totals[total.CategoryId] += amount
+	}
+	return totals, nil
+}
+
+// SubtreeTotals totals the transactions of every category together with the ones of its descendants.
+func SubtreeTotals(categories []Category, totals map[int64]Money) map[int64]Money {
+	subtreeTotals := make(map[int64]Money, len(categories))
+	var collect func(rollup *CategoryRollup)
+	collect = func(rollup *CategoryRollup) {
+		subtreeTotals[rollup.CategoryId] = rollup.Total
+		for _, child := range rollup.Children {
+			collect(child)
+		}
+	}
+	for _, root := range BuildCategoryTree(categories) {
+		collect(rollupCategory(root, totals))
+	}
+	return subtreeTotals
+}
+
+func rollupCategory(node *CategoryNode, totals map[int64]Money) *CategoryRollup {
+	rollup := &CategoryRollup{
+		CategoryId: node.Id,
+		Name:       node.Name,
+		OwnTotal:   totals[node.Id],
+		Total:      totals[node.Id],
+		Children:   []*CategoryRollup{},
+	}
+	for _, child := range node.Children {
+		childRollup := rollupCategory(child, totals)
+		rollup.Total += childRollup.Total
+		rollup.Children = append(rollup.Children, childRollup)
+	}
+	return rollup
+}
+
+func findCategoryNode(nodes []*CategoryNode, id int64) *CategoryNode {
+	for _, node := range nodes {
+		if node.Id == id {
+			return node
+		}
+		if found := findCategoryNode(node.Children, id); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+// BuildCategoryTree nests categories under their parents. Categories whose parent is missing from the list are
+// treated as roots so that nothing is silently dropped.
+func BuildCategoryTree(categories []Category) []*CategoryNode {
+	nodes := make(map[int64]*CategoryNode, len(categories))
+	for _, category := range categories {
+		nodes[category.Id] = &CategoryNode{Category: category, Children: []*CategoryNode{}}
+	}
+	roots := []*CategoryNode{}
+	for _, category := range categories {
+		node := nodes[category.Id]
+		parent, ok := nodes[category.ParentId]
+		if category.ParentId == 0 || !ok {
+			roots = append(roots, node)
+			continue
+		}
+		parent.Children = append(parent.Children, node)
+	}
+	sortCategoryNodes(roots)
+	return roots
+}
+
+// CategoryPathSeparator joins the names of a category and its ancestors into a path like Food:Groceries.
+const CategoryPathSeparator = ":"
+
+// CategoryPaths maps every category onto its path from the root category.
+func CategoryPaths(categories []Category) map[int64]string {
+	paths := make(map[int64]string, len(categories))
+	var walk func(nodes []*CategoryNode, prefix string)
+	walk = func(nodes []*CategoryNode, prefix string) {
+		for _, node := range nodes {
+			paths[node.Id] = prefix + node.Name
+			walk(node.Children, paths[node.Id]+CategoryPathSeparator)
+		}
+	}
+	walk(BuildCategoryTree(categories), "")
+	return paths
+}
+
+func sortCategoryNodes(nodes []*CategoryNode) {
+	sort.Slice(nodes, func(i, j int) bool {
+		return nodes[i].Name < nodes[j].Name
+	})
+	for _, node := range nodes {
+		sortCategoryNodes(node.Children)
+	}
+}
+
+type CategoriesDataAccessor interface {
+	GetCategory(id int64) (Category, error)
+	ListCategories() ([]Category, error)
+	InsertCategory(request CategoryCreationRequest) (int64, error)
+	UpdateCategory(id int64, request CategoryUpdateRequest) error
+	MoveCategory(id int64, parentId *int64) error
+	DeleteCategory(id int64) error
+	SumAmountsByCategory(from *time.Time, to *time.Time) (map[int64]Money, error)
+	SumAmountsByCategoryAndCurrency(from *time.Time, to *time.Time) ([]CategoryCurrencyTotal, error)
+}
@@ -0,0 +1,216 @@
+package api
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// categoriesMock models the hierarchy
+//
+//	Food (1)
+//	├── Groceries (2)
+//	└── Restaurants (3)
+//	    └── Coffee (4)
+//	Travel (5)
+type categoriesMock struct {
+	movedId       int64
+	movedParentId *int64
+	err           error
+}
+
+func (m *categoriesMock) GetCategory(id int64) (Category, error) {
+	for _, category := range m.categories() {
+		if category.Id == id {
+			return category, nil
+		}
+	}
+	return Category{}, errors.New("sql: no rows in result set")
+}
+
+func (m *categoriesMock) categories() []Category {
+	return []Category{
+		{Id: 1, Name: "Food"},
+		{Id: 2, Name: "Groceries", ParentId: 1},
+		{Id: 3, Name: "Restaurants", ParentId: 1},
+		{Id: 4, Name: "Coffee", ParentId: 3},
+		{Id: 5, Name: "Travel"},
+	}
+}
+
+func (m *categoriesMock) ListCategories() ([]Category, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	return m.categories(), nil
+}
+
+func (m *categoriesMock) InsertCategory(request CategoryCreationRequest) (int64, error) {
+	return 6, nil
+}
+
+func (m *categoriesMock) UpdateCategory(id int64, request CategoryUpdateRequest) error {
+	return nil
+}
+
+func (m *categoriesMock) MoveCategory(id int64, parentId *int64) error {
+	m.movedId = id
+	m.movedParentId = parentId
+	return nil
+}
+
+func (m *categoriesMock) DeleteCategory(id int64) error {
+	return nil
+}
+
+func (m *categoriesMock) SumAmountsByCategory(from *time.Time, to *time.Time) (map[int64]Money, error) {
+	return map[int64]Money{1: -500, 2: -10000, 3: -4000, 4: -1250, 5: -30000}, nil
+}
+
+// SumAmountsByCategoryAndCurrency spends on groceries in dollars and euros, and on travel in pounds.
+func (m *categoriesMock) SumAmountsByCategoryAndCurrency(from *time.Time,
+	to *time.Time) ([]CategoryCurrencyTotal, error) {
+	return []CategoryCurrencyTotal{
+		{CategoryId: 2, Currency: "USD", Day: "2022-07-01", Amount: -10000},
+		{CategoryId: 2, Currency: "EUR", Day: "2022-07-02", Amount: -5000},
+		{CategoryId: 2, Currency: "EUR", Day: "2022-07-20", Amount: -5000},
+		{CategoryId: 5, Currency: "GBP", Day: "2022-07-20", Amount: -30000},
+	}, nil
+}
+
+func TestCategoriesService_GetCategoryTree(t *testing.T) {
+
+	t.Run("testing happy flow of building the category tree", func(t *testing.T) {
+		categoriesService := NewCategoriesService(&categoriesMock{}, nil)
+		tree, err := categoriesService.GetCategoryTree()
+		if err != nil {
+			t.Errorf("unexpected error when building category tree: %v", err)
+			return
+		}
+		if len(tree) != 2 || tree[0].Name != "Food" || tree[1].Name != "Travel" {
+			t.Errorf("unexpected roots %v", tree)
+			return
+		}
+		if len(tree[0].Children) != 2 || len(tree[0].Children[1].Children) != 1 {
+			t.Errorf("unexpected children of Food %v", tree[0].Children)
+		}
+	})
+
+	t.Run("testing general error flow of building the category tree", func(t *testing.T) {
+		categoriesService := NewCategoriesService(&categoriesMock{err: errors.New("db timeout")}, nil)
+		_, err := categoriesService.GetCategoryTree()
+		expectedErrorMsg := "failed to list categories with err:db timeout"
+		if err == nil || err.Error() != expectedErrorMsg {
+			t.Errorf("expected error message %s but found %v", expectedErrorMsg, err)
+		}
+	})
+}
+
+func TestCategoriesService_CreateCategory(t *testing.T) {
+
+	name := "Fuel"
+
+	t.Run("testing happy flow of creating a child category", func(t *testing.T) {
+		categoriesService := NewCategoriesService(&categoriesMock{}, nil)
+		parentId := int64(5)
+		id, err := categoriesService.CreateCategory(CategoryCreationRequest{Name: &name, ParentId: &parentId})
+		if err != nil || id != 6 {
+			t.Errorf("unexpected result %d, %v when creating category", id, err)
+		}
+	})
+
+	t.Run("testing validation of unknown parents", func(t *testing.T) {
+		categoriesService := NewCategoriesService(&categoriesMock{}, nil)
+		parentId := int64(42)
+		_, err := categoriesService.CreateCategory(CategoryCreationRequest{Name: &name, ParentId: &parentId})
+		if !errors.Is(err, ErrInvalidRequest) {
+			t.Errorf("expected invalid request error but found %v", err)
+		}
+	})
+}
+
+func TestCategoriesService_MoveCategory(t *testing.T) {
+
+	t.Run("testing happy flow of moving a category", func(t *testing.T) {
+		dao := categoriesMock{}
+		categoriesService := NewCategoriesService(&dao, nil)
+		parentId := int64(5)
+		err := categoriesService.MoveCategory(3, CategoryMoveRequest{ParentId: &parentId})
+		if err != nil {
+			t.Errorf("unexpected error when moving category: %v", err)
+		}
+		if dao.movedId != 3 || *dao.movedParentId != 5 {
+			t.Errorf("unexpected move of %d under %v", dao.movedId, dao.movedParentId)
+		}
+	})
+
+	t.Run("testing moving a category to the root", func(t *testing.T) {
+		dao := categoriesMock{}
+		categoriesService := NewCategoriesService(&dao, nil)
+		err := categoriesService.MoveCategory(4, CategoryMoveRequest{})
+		if err != nil || dao.movedParentId != nil {
+			t.Errorf("unexpected result %v when moving category to the root", err)
+		}
+	})
+
+	t.Run("testing cycles are rejected", func(t *testing.T) {
+		categoriesService := NewCategoriesService(&categoriesMock{}, nil)
+		parentId := int64(4)
+		err := categoriesService.MoveCategory(1, CategoryMoveRequest{ParentId: &parentId})
+		expectedErrorMsg := "invalid request: moving category 1 under 4 would create a cycle"
+		if err == nil || err.Error() != expectedErrorMsg {
+			t.Errorf("expected error message %s but found %v", expectedErrorMsg, err)
+		}
+	})
+
+	t.Run("testing moving a category under itself is rejected", func(t *testing.T) {
+		categoriesService := NewCategoriesService(&categoriesMock{}, nil)
+		parentId := int64(2)
+		err := categoriesService.MoveCategory(2, CategoryMoveRequest{ParentId: &parentId})
+		if !errors.Is(err, ErrInvalidRequest) {
+			t.Errorf("expected invalid request error but found %v", err)
+		}
+	})
+}
+
+func TestCategoriesService_GetCategoryRollup(t *testing.T) {
+
+	t.Run("testing totals include all descendants", func(t *testing.T) {
+		categoriesService := NewCategoriesService(&categoriesMock{}, nil)
+		rollup, err := categoriesService.GetCategoryRollup(1, nil, nil, "")
+		if err != nil {
+			t.Errorf("unexpected error when computing rollup: %v", err)
+			return
+		}
+		if rollup.OwnTotal != -500 || rollup.Total != -15750 {
+			t.Errorf("unexpected own total %s or total %s", rollup.OwnTotal, rollup.Total)
+		}
+		restaurants := rollup.Children[1]
+		if restaurants.Name != "Restaurants" || restaurants.Total != -5250 {
+			t.Errorf("unexpected rollup of %s with total %s", restaurants.Name, restaurants.Total)
+		}
+	})
+
+	t.Run("testing rollup of unknown categories", func(t *testing.T) {
+		categoriesService := NewCategoriesService(&categoriesMock{}, nil)
+		_, err := categoriesService.GetCategoryRollup(42, nil, nil, "")
+		if !errors.Is(err, ErrInvalidRequest) {
+			t.Errorf("expected invalid request error but found %v", err)
+		}
+	})
+}
+
+func TestCategoryPaths(t *testing.T) {
+
+	paths := CategoryPaths((&categoriesMock{}).categories())
+	expected := map[int64]string{1: "Food", 2: "Food:Groceries", 3: "Food:Restaurants", 4: "Food:Restaurants:Coffee",
+		5: "Travel"}
+	if len(paths) != len(expected) {
+		t.Errorf("expected %d paths but found %v", len(expected), paths)
+	}
+	for id, path := range expected {
+		if paths[id] != path {
+			t.Errorf("expected path of category %d to be %s but found %s", id, path, paths[id])
+		}
+	}
+}
@@ -0,0 +1,331 @@
+package api
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+const defaultMinimumPaymentRate = 0.02
+const defaultMinimumPayment = Money(2500)
+
+const StatementOpen = "open"
+const StatementNoPaymentDue = "no_payment_due"
+const StatementDue = "due"
+const StatementPaid = "paid"
+const StatementPartial = "partial"
+const StatementLate = "late"
+
+// creditCardAccountTypes are the account types that can hold a credit card.
+var creditCardAccountTypes = map[string]bool{
+	"credit_card":  true,
+	"credit_cards": true,
+	"creditcard":   true,
+	"creditcards":  true,
+}
+
+// IsCreditCardAccountType tells whether accounts of the type, like "credit card", can hold a credit card.
+func IsCreditCardAccountType(accountType string) bool {
+	return creditCardAccountTypes[normalizeAccountType(accountType)]
+}
+
+// CreditCard holds the statement terms of a credit card account. Statements close on ClosingDay and are due on the
+// first DueDay after closing, both falling on the last day of shorter months. The minimum due is MinimumPaymentRate of
+// the statement balance, like 0.02 for 2%, but at least MinimumPayment.
+type CreditCard struct {
+	Id                 int64     `json:"id"`
+	AccountId          int64     `json:"account_id"`
+	ClosingDay         int       `json:"closing_day"`
+	DueDay             int       `json:"due_day"`
+	MinimumPaymentRate float64   `json:"minimum_payment_rate"`
+	MinimumPayment     Money     `json:"minimum_payment"`
+	IsDeleted          bool      `json:"is_deleted"`
+	CreatedTs          time.Time `json:"created_ts"`
+	UpdatedTs          time.Time `json:"updated_ts"`
+}
+
+// CreditCardRequest is used to both create and update a credit card. MinimumPaymentRate defaults to 2% and
+// MinimumPayment to 25.00.
+type CreditCardRequest struct {
+	AccountId          *int64   `json:"account_id"`
+	ClosingDay         *int     `json:"closing_day"`
+	DueDay             *int     `json:"due_day"`
+	MinimumPaymentRate *float64 `json:"minimum_payment_rate"`
+	MinimumPayment     *Money   `json:"minimum_payment"`
+}
+
+// CreditCardStatement is a statement cycle running from StartDate to ClosingDate, both inclusive. Balances are what
+// is owed, so charges raise them and credits such as payments and refunds lower them. Paid counts the payments made
+// after the statement closed until the next one closes, which are the transfers into the card account, so refunds
+// and payments imported as ordinary transactions are not counted. Status tells how the statement was paid by DueDate:
+// paid in full, partial when only the minimum was paid, or late when not even the minimum was. Statements are due
+// until DueDate passes, after which DaysUntilDue turns negative. PaidLate flags the statements whose minimum was not
+// paid by DueDate and Partial the ones whose balance is still not paid in full after it. The cycle still open only
+// reports its balance so far.
+type CreditCardStatement struct {
+	StartDate        string `json:"start_date"`
+	ClosingDate      string `json:"closing_date"`
+	DueDate          string `json:"due_date"`
+	PreviousBalance  Money  `json:"previous_balance"`
+	Charges          Money  `json:"charges"`
+	Credits          Money  `json:"credits"`
+	StatementBalance Money  `json:"statement_balance"`
+	MinimumDue       Money  `json:"minimum_due"`
+	Paid             Money  `json:"paid"`
+	PaidByDueDate    Money  `json:"paid_by_due_date"`
+	Remaining        Money  `json:"remaining"`
+	DaysUntilDue     int    `json:"days_until_due"`
+	Status           string `json:"status"`
+	PaidLate         bool   `json:"paid_late"`
+	Partial          bool   `json:"partial"`
+}
+
+// CreditCardStatements lists the statement cycles of a credit card from the oldest to the one open on AsOf. Balance
+// is what is owed at the end of AsOf.
+type CreditCardStatements struct {
+	CreditCardId int64                 `json:"credit_card_id"`
+	AccountId    int64                 `json:"account_id"`
+	AsOf         string                `json:"as_of"`
+	Balance      Money                 `json:"balance"`
+	Statements   []CreditCardStatement `json:"statements"`
+}
+
+type CreditCardsService struct {
+	dao                 CreditCardsDataAccessor
+	accountsService     *AccountsService
+	transactionsService *TransactionsService
+}
+
+func NewCreditCardsService(dao CreditCardsDataAccessor, accountsService *AccountsService,
+	transactionsService *TransactionsService) *CreditCardsService {
+	return &CreditCardsService{dao, accountsService, transactionsService}
+}
+
+func (service *CreditCardsService) GetCreditCard(id int64) (CreditCard, error) {
+	creditCard, err := service.dao.GetCreditCard(id)
+	if err != nil {
+		return creditCard, fmt.Errorf("failed to retrieve credit card of id %d with err:%v", id, err)
+	}
+	return creditCard, nil
+}
+
+func (service *CreditCardsService) ListCreditCards() ([]CreditCard, error) {
+	creditCards, err := service.dao.ListCreditCards()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list credit cards with err:%v", err)
+	}
+	return creditCards, nil
+}
+
+func (service *CreditCardsService) CreateCreditCard(request CreditCardRequest) (int64, error) {
+	request, err := service.validateCreditCard(request)
+	if err != nil {
+		return -1, err
+	}
+	return service.dao.InsertCreditCard(request)
+}
+
+func (service *CreditCardsService) UpdateCreditCard(id int64, request CreditCardRequest) error {
+	request, err := service.validateCreditCard(request)
+	if err != nil {
+		return err
+	}
+	return service.dao.UpdateCreditCard(id, request)
+}
+
+func (service *CreditCardsService) DeleteCreditCard(id int64) error {
+	err := service.dao.DeleteCreditCard(id)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// GetStatements groups the transactions of the credit card account until the end of asOf into statement cycles,
+// starting with the cycle holding from. from is optional and defaults to the first transaction of the account.
+func (service *CreditCardsService) GetStatements(id int64, from *time.Time, asOf time.Time) (CreditCardStatements,
+	error) {
+	creditCard, err := service.GetCreditCard(id)
+	if err != nil {
+		return CreditCardStatements{}, err
+	}
+	account, err := service.accountsService.GetAccount(creditCard.AccountId)
+	if err != nil {
+		return CreditCardStatements{}, err
+	}
+	today := truncateToDay(asOf)
+	end := today.AddDate(0, 0, 1)
+	transactions, err := service.transactionsService.ListTransactions(TransactionFilter{
+		AccountId: &creditCard.AccountId, To: &end})
+	if err != nil {
+		return CreditCardStatements{}, err
+	}
+	sort.SliceStable(transactions, func(i, j int) bool {
+		return transactions[i].TransactionTime.Before(transactions[j].TransactionTime)
+	})
+
+	first := today
+	if from != nil {
+		first = truncateToDay(*from)
+	} else if len(transactions) > 0 {
+		first = truncateToDay(transactions[0].TransactionTime)
+	}
+	if first.After(today) {
+		return CreditCardStatements{}, fmt.Errorf("%w: from cannot be after as_of", ErrInvalidRequest)
+	}
+
+	statements := CreditCardStatements{CreditCardId: creditCard.Id, AccountId: account.Id,
+		AsOf: today.Format(ReportDateLayout), Statements: []CreditCardStatement{}}
+	balance := account.OpeningBalance
+	next := 0
+	closing := statementClosingDate(first, creditCard.ClosingDay)
+	for {
+		start := dayOfMonth(closing.Year(), closing.Month()-1, creditCard.ClosingDay).AddDate(0, 0, 1)
+		closed := closing.AddDate(0, 0, 1)
+		for ; next < len(transactions) && transactions[next].TransactionTime.Before(start); next++ {
+			balance += transactions[next].Amount
+		}
+		dueDate := statementDueDate(closing, creditCard.DueDay)
+		statement := CreditCardStatement{StartDate: start.Format(ReportDateLayout),
+			ClosingDate: closing.Format(ReportDateLayout), DueDate: dueDate.Format(ReportDateLayout),
+			PreviousBalance: -balance, DaysUntilDue: int(dueDate.Sub(today).Hours() / 24)}
+		for ; next < len(transactions) && transactions[next].TransactionTime.Before(closed); next++ {
+			amount := transactions[next].Amount
+			if amount < 0 {
+				statement.Charges -= amount
+			} else {
+				statement.Credits += amount
+			}
+			balance += amount
+		}
+		statement.StatementBalance = -balance
+		if closing.After(today) {
+			statement.Status = StatementOpen
+			statements.Statements = append(statements.Statements, statement)
+			break
+		}
+
+		nextClosing := statementClosingDate(closed, creditCard.ClosingDay).AddDate(0, 0, 1)
+		for i := next; i < len(transactions) && transactions[i].TransactionTime.Before(nextClosing); i++ {
+			if transactions[i].Amount <= 0 || transactions[i].TransactionType != TransferTransactionType {
+				continue
+			}
+			statement.Paid += transactions[i].Amount
+			if !truncateToDay(transactions[i].TransactionTime).After(dueDate) {
+				statement.PaidByDueDate += transactions[i].Amount
+			}
+		}
+		statement.MinimumDue = minimumDue(statement.StatementBalance, creditCard)
+		if statement.Paid < statement.StatementBalance {
+			statement.Remaining = statement.StatementBalance - statement.Paid
+		}
+		switch {
+		case statement.StatementBalance <= 0:
+			statement.Status = StatementNoPaymentDue
+		case statement.PaidByDueDate >= statement.StatementBalance:
+			statement.Status = StatementPaid
+		case !dueDate.Before(today):
+			statement.Status = StatementDue
+		case statement.PaidByDueDate >= statement.MinimumDue:
+			statement.Status, statement.Partial = StatementPartial, statement.Remaining > 0
+		default:
+			statement.Status, statement.Partial, statement.PaidLate = StatementLate, statement.Remaining > 0, true
+		}
+		statements.Statements = append(statements.Statements, statement)
+		closing = statementClosingDate(closed, creditCard.ClosingDay)
+	}
+	for ; next < len(transactions); next++ {
+		balance += transactions[next].Amount
+	}
+	statements.Balance = -balance
+	return statements, nil
+}
+
+func (service *CreditCardsService) validateCreditCard(request CreditCardRequest) (CreditCardRequest, error) {
+	if request.AccountId == nil {
+		return request, fmt.Errorf("%w: account_id is required", ErrInvalidRequest)
+	}
+	if request.ClosingDay == nil || *request.ClosingDay < 1 || *request.ClosingDay > 31 {
+		return request, fmt.Errorf("%w: closing_day is required and has to be between 1 and 31", ErrInvalidRequest)
+	}
+	if request.DueDay == nil || *request.DueDay < 1 || *request.DueDay > 31 {
+		return request, fmt.Errorf("%w: due_day is required and has to be between 1 and 31", ErrInvalidRequest)
+	}
+	if request.MinimumPaymentRate == nil {
+		rate := defaultMinimumPaymentRate
+		request.MinimumPaymentRate = &rate
+	}
+	if *request.MinimumPaymentRate < 0 || *request.MinimumPaymentRate > 1 {
+		return request, fmt.Errorf("%w: minimum_payment_rate has to be between 0 and 1, like 0.02 for 2%%",
+			ErrInvalidRequest)
+	}
+	if request.MinimumPayment == nil {
+		minimumPayment := defaultMinimumPayment
+		request.MinimumPayment = &minimumPayment
+	}
+	if *request.MinimumPayment < 0 {
+		return request, fmt.Errorf("%w: minimum_payment cannot be negative", ErrInvalidRequest)
+	}
+	account, err := service.accountsService.GetAccount(*request.AccountId)
+	if err != nil {
+		return request, err
+	}
+	if !IsCreditCardAccountType(account.AccountType) {
+		return request, fmt.Errorf("%w: account %d of type %s is not a credit card", ErrInvalidRequest, account.Id,
+			account.AccountType)
+	}
+	return request, nil
+}
+
+// minimumDue is the share of the balance due, rounded up to the cent, but at least the minimum payment and at most
+// the balance.
+func minimumDue(balance Money, creditCard CreditCard) Money {
+	if balance <= 0 {
+		return 0
+	}
+	due := Money(math.Ceil(float64(balance) * creditCard.MinimumPaymentRate))
+	if due < creditCard.MinimumPayment {
+		due = creditCard.MinimumPayment
+	}
+	if due > balance {
+		due = balance
+	}
+	return due
+}
+
+// statementClosingDate is the closing date of the statement cycle holding the day.
+func statementClosingDate(day time.Time, closingDay int) time.Time {
+	closing := dayOfMonth(day.Year(), day.Month(), closingDay)
+	if day.After(closing) {
+		closing = dayOfMonth(day.Year(), day.Month()+1, closingDay)
+	}
+	return closing
+}
+
+// statementDueDate is the first due day after the closing date.
+func statementDueDate(closing time.Time, dueDay int) time.Time {
+	due := dayOfMonth(closing.Year(), closing.Month(), dueDay)
+	if !due.After(closing) {
+		due = dayOfMonth(closing.Year(), closing.Month()+1, dueDay)
+	}
+	return due
+}
+
+// dayOfMonth is the day of the month, falling on the last day of shorter months. Months past December roll over into
+// the next year.
+func dayOfMonth(year int, month time.Month, day int) time.Time {
+	lastDay := time.Date(year, month+1, 0, 0, 0, 0, 0, time.UTC)
+	if day > lastDay.Day() {
+		return lastDay
+	}
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+type CreditCardsDataAccessor interface {
+	GetCreditCard(id int64) (CreditCard, error)
+	ListCreditCards() ([]CreditCard, error)
+	InsertCreditCard(request CreditCardRequest) (int64, error)
+	UpdateCreditCard(id int64, request CreditCardRequest) error
+	DeleteCreditCard(id int64) error
+}
@@ -0,0 +1,227 @@
+package api
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// creditCardsMock holds a card closing on the 15th and due on the 10th of the next month.
+type creditCardsMock struct {
+	inserted *CreditCardRequest
+	err      error
+}
+
+func (m *creditCardsMock) GetCreditCard(id int64) (CreditCard, error) {
+	if id != 1 {
+		return CreditCard{}, errors.New("sql: no rows in result set")
+	}
+	return CreditCard{Id: 1, AccountId: 5, ClosingDay: 15, DueDay: 10, MinimumPaymentRate: 0.02,
+		MinimumPayment: 2500}, m.err
+}
+
+func (m *creditCardsMock) ListCreditCards() ([]CreditCard, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	creditCard, _ := m.GetCreditCard(1)
+	return []CreditCard{creditCard}, nil
+}
+
+func (m *creditCardsMock) InsertCreditCard(request CreditCardRequest) (int64, error) {
+	m.inserted = &request
+	return 2, m.err
+}
+
+func (m *creditCardsMock) UpdateCreditCard(id int64, request CreditCardRequest) error {
+	return m.err
+}
+
+func (m *creditCardsMock) DeleteCreditCard(id int64) error {
+	return m.err
+}
+
+// creditCardAccountsMock makes account 5 a credit card.
+type creditCardAccountsMock struct {
+	happyMock
+}
+
+func (m *creditCardAccountsMock) GetAccount(id int64) (Account, error) {
+	account, err := m.happyMock.GetAccount(id)
+	if id == 5 {
+		account.Id, account.AccountType = 5, "Credit Card"
+	}
+	return account, err
+}
+
+// creditCardLedgerMock pays the January statement in full, misses the due date of the February statement and only
+// pays part of the March statement. Payments are transfers, and the credit of transaction 6 is a refund.
+type creditCardLedgerMock struct {
+	happyTransactionsMock
+	filter TransactionFilter
+}
+
+func (m *creditCardLedgerMock) ListTransactions(filter TransactionFilter) ([]Transaction, error) {
+	m.filter = filter
+	return []Transaction{
+		{Id: 8, TransactionTime: time.Date(2022, 4, 8, 9, 0, 0, 0, time.UTC), TransactionType: TransferTransactionType,
+			Amount: 20000},
+		{Id: 7, TransactionTime: time.Date(2022, 3, 20, 9, 0, 0, 0, time.UTC), Amount: -1000},
+		{Id: 6, TransactionTime: time.Date(2022, 3, 14, 9, 0, 0, 0, time.UTC), Amount: 500},
+		{Id: 5, TransactionTime: time.Date(2022, 3, 12, 9, 0, 0, 0, time.UTC), TransactionType: TransferTransactionType,
+			Amount: 2500},
+		{Id: 4, TransactionTime: time.Date(2022, 2, 10, 9, 0, 0, 0, time.UTC), Amount: -3000},
+		{Id: 3, TransactionTime: time.Date(2022, 2, 5, 9, 0, 0, 0, time.UTC), TransactionType: TransferTransactionType,
+			Amount: 10000},
+		{Id: 2, TransactionTime: time.Date(2022, 1, 20, 9, 0, 0, 0, time.UTC), Amount: -50000},
+		{Id: 1, TransactionTime: time.Date(2022, 1, 5, 9, 0, 0, 0, time.UTC), Amount: -10000},
+	}, nil
+}
+
+// lateCreditCardLedgerMock pays the January statement in full a day after its due date, after a refund.
+type lateCreditCardLedgerMock struct {
+	happyTransactionsMock
+}
+
+func (m *lateCreditCardLedgerMock) ListTransactions(filter TransactionFilter) ([]Transaction, error) {
+	return []Transaction{
+		{Id: 1, TransactionTime: time.Date(2022, 1, 5, 9, 0, 0, 0, time.UTC), Amount: -10000},
+		{Id: 2, TransactionTime: time.Date(2022, 2, 1, 9, 0, 0, 0, time.UTC), Amount: 500},
+		{Id: 3, TransactionTime: time.Date(2022, 2, 11, 9, 0, 0, 0, time.UTC), TransactionType: TransferTransactionType,
+			Amount: 10000},
+	}, nil
+}
+
+func TestCreditCardsService_CreateCreditCard(t *testing.T) {
+
+	accountId, closingDay, dueDay := int64(5), 15, 10
+	accountsService := NewAccountsService(&creditCardAccountsMock{}, nil)
+
+	t.Run("testing happy flow of creating a credit card", func(t *testing.T) {
+		dao := creditCardsMock{}
+		id, err := NewCreditCardsService(&dao, accountsService, nil).CreateCreditCard(CreditCardRequest{
+			AccountId: &accountId, ClosingDay: &closingDay, DueDay: &dueDay})
+		if err != nil || id != 2 || *dao.inserted.MinimumPaymentRate != 0.02 || *dao.inserted.MinimumPayment != 2500 {
+			t.Errorf("unexpected id %d, request %+v or error %v", id, dao.inserted, err)
+		}
+	})
+
+	t.Run("testing validation of credit cards", func(t *testing.T) {
+		savingsId, zero, badDay, badRate, negative := int64(1), 0, 32, 1.5, Money(-100)
+		for _, request := range []CreditCardRequest{
+			{ClosingDay: &closingDay, DueDay: &dueDay},
+			{AccountId: &accountId, DueDay: &dueDay},
+			{AccountId: &accountId, ClosingDay: &zero, DueDay: &dueDay},
+			{AccountId: &accountId, ClosingDay: &closingDay, DueDay: &badDay},
+			{AccountId: &accountId, ClosingDay: &closingDay, DueDay: &dueDay, MinimumPaymentRate: &badRate},
+			{AccountId: &accountId, ClosingDay: &closingDay, DueDay: &dueDay, MinimumPayment: &negative},
+			{AccountId: &savingsId, ClosingDay: &closingDay, DueDay: &dueDay},
+		} {
+			_, err := NewCreditCardsService(&creditCardsMock{}, accountsService, nil).CreateCreditCard(request)
+			if !errors.Is(err, ErrInvalidRequest) {
+				t.Errorf("expected invalid request error for %+v but found %v", request, err)
+			}
+		}
+	})
+}
+
+func TestCreditCardsService_GetStatements(t *testing.T) {
+
+	accountsService := NewAccountsService(&creditCardAccountsMock{}, nil)
+	asOf := time.Date(2022, 4, 20, 18, 0, 0, 0, time.UTC)
+
+	t.Run("testing happy flow of statement cycles", func(t *testing.T) {
+		ledger := creditCardLedgerMock{}
+		service := NewCreditCardsService(&creditCardsMock{}, accountsService, NewTransactionsService(&ledger, nil, nil))
+		statements, err := service.GetStatements(1, nil, asOf)
+		if err != nil {
+			t.Errorf("unexpected error when listing statements: %v", err)
+			return
+		}
+		if *ledger.filter.AccountId != 5 || !ledger.filter.To.Equal(time.Date(2022, 4, 21, 0, 0, 0, 0, time.UTC)) {
+			t.Errorf("unexpected filter %+v", ledger.filter)
+		}
+		if statements.Balance != 31000 || statements.AsOf != "2022-04-20" || len(statements.Statements) != 5 {
+			t.Errorf("unexpected statements %+v", statements)
+			return
+		}
+		expected := []CreditCardStatement{
+			{StartDate: "2021-12-16", ClosingDate: "2022-01-15", DueDate: "2022-02-10", Charges: 10000,
+				StatementBalance: 10000, MinimumDue: 2500, Paid: 10000, PaidByDueDate: 10000, DaysUntilDue: -69,
+				Status: StatementPaid},
+			{StartDate: "2022-01-16", ClosingDate: "2022-02-15", DueDate: "2022-03-10", PreviousBalance: 10000,
+				Charges: 53000, Credits: 10000, StatementBalance: 53000, MinimumDue: 2500, Paid: 2500,
+				Remaining: 50500, DaysUntilDue: -41, Status: StatementLate, PaidLate: true, Partial: true},
+			{StartDate: "2022-02-16", ClosingDate: "2022-03-15", DueDate: "2022-04-10", PreviousBalance: 53000,
+				Credits: 3000, StatementBalance: 50000, MinimumDue: 2500, Paid: 20000, PaidByDueDate: 20000,
+				Remaining: 30000, DaysUntilDue: -10, Status: StatementPartial, Partial: true},
+			{StartDate: "2022-03-16", ClosingDate: "2022-04-15", DueDate: "2022-05-10", PreviousBalance: 50000,
+				Charges: 1000, Credits: 20000, StatementBalance: 31000, MinimumDue: 2500, Remaining: 31000,
+				DaysUntilDue: 20, Status: StatementDue},
+			{StartDate: "2022-04-16", ClosingDate: "2022-05-15", DueDate: "2022-06-10", PreviousBalance: 31000,
+				StatementBalance: 31000, DaysUntilDue: 51, Status: StatementOpen},
+		}
+		for i, statement := range expected {
+			if statements.Statements[i] != statement {
+				t.Errorf("expected statement %+v but found %+v", statement, statements.Statements[i])
+			}
+		}
+	})
+
+	t.Run("testing a statement paid in full after its due date is late but not partial", func(t *testing.T) {
+		service := NewCreditCardsService(&creditCardsMock{}, accountsService,
+			NewTransactionsService(&lateCreditCardLedgerMock{}, nil, nil))
+		statements, err := service.GetStatements(1, nil, asOf)
+		if err != nil || len(statements.Statements) == 0 {
+			t.Errorf("unexpected statements %+v or error %v", statements, err)
+			return
+		}
+		expected := CreditCardStatement{StartDate: "2021-12-16", ClosingDate: "2022-01-15", DueDate: "2022-02-10",
+			Charges: 10000, StatementBalance: 10000, MinimumDue: 2500, Paid: 10000, DaysUntilDue: -69,
+			Status: StatementLate, PaidLate: true}
+		if statements.Statements[0] != expected {
+			t.Errorf("expected statement %+v but found %+v", expected, statements.Statements[0])
+		}
+	})
+
+	t.Run("testing statements from a later cycle", func(t *testing.T) {
+		service := NewCreditCardsService(&creditCardsMock{}, accountsService,
+			NewTransactionsService(&creditCardLedgerMock{}, nil, nil))
+		from := time.Date(2022, 3, 1, 0, 0, 0, 0, time.UTC)
+		statements, err := service.GetStatements(1, &from, asOf)
+		if err != nil || len(statements.Statements) != 3 || statements.Statements[0].PreviousBalance != 53000 {
+			t.Errorf("unexpected statements %+v or error %v", statements, err)
+		}
+	})
+
+	t.Run("testing general error flow of listing statements", func(t *testing.T) {
+		service := NewCreditCardsService(&creditCardsMock{err: errors.New("db timeout")}, accountsService, nil)
+		_, err := service.GetStatements(1, nil, asOf)
+		expectedErrorMsg := "failed to retrieve credit card of id 1 with err:db timeout"
+		if err == nil || err.Error() != expectedErrorMsg {
+			t.Errorf("expected error message %s but found %v", expectedErrorMsg, err)
+		}
+	})
+}
+
+func TestStatementDates(t *testing.T) {
+
+	for _, test := range []struct {
+		day        time.Time
+		closingDay int
+		dueDay     int
+		closing    string
+		due        string
+	}{
+		{time.Date(2022, 2, 10, 0, 0, 0, 0, time.UTC), 31, 25, "2022-02-28", "2022-03-25"},
+		{time.Date(2022, 2, 10, 0, 0, 0, 0, time.UTC), 5, 25, "2022-03-05", "2022-03-25"},
+		{time.Date(2022, 12, 20, 0, 0, 0, 0, time.UTC), 15, 31, "2023-01-15", "2023-01-31"},
+		{time.Date(2022, 1, 31, 0, 0, 0, 0, time.UTC), 31, 31, "2022-01-31", "2022-02-28"},
+	} {
+		closing := statementClosingDate(test.day, test.closingDay)
+		due := statementDueDate(closing, test.dueDay)
+		if closing.Format(ReportDateLayout) != test.closing || due.Format(ReportDateLayout) != test.due {
+			t.Errorf("expected closing %s and due %s but found %s and %s", test.closing, test.due, closing, due)
+		}
+	}
+}
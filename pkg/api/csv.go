@@ -0,0 +1,197 @@
+package api
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// PreviewCSV parses a csv statement with the profile without importing it. When the account is given, the rows that
+// duplicate its transactions are reported.
+func (service *ImportService) PreviewCSV(profileId int64, accountId *int64, statement io.Reader) (ImportPreview,
+	error) {
+	profile, err := service.GetImportProfile(profileId)
+	if err != nil {
+		return ImportPreview{}, err
+	}
+	rows, err := ParseCSV(profile, statement)
+	if err != nil {
+		return ImportPreview{}, err
+	}
+	return service.previewAccountRows(accountId, rows)
+}
+
+// ImportCSV parses a csv statement with the profile and imports every row as a transaction of the account, handling
+// the rows that duplicate existing transactions as decided.
+func (service *ImportService) ImportCSV(profileId int64, accountId int64, statement io.Reader,
+	decisions []DuplicateDecision) (ImportResult, error) {
+	profile, err := service.GetImportProfile(profileId)
+	if err != nil {
+		return ImportResult{}, err
+	}
+	rows, err := ParseCSV(profile, statement)
+	if err != nil {
+		return ImportResult{}, err
+	}
+	return service.commitRows(accountId, rows, decisions)
+}
+
+// ParseCSV reads a statement using the column mapping of the profile. Errors in individual rows are reported on the
+// rows themselves, while a statement that does not match the profile at all fails as a whole.
+func ParseCSV(profile ImportProfile, statement io.Reader) ([]ImportRow, error) {
+	reader := csv.NewReader(statement)
+	reader.Comma = []rune(profile.Delimiter)[0]
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+	reader.TrimLeadingSpace = true
+
+	for i := int64(0); i < profile.SkipRows; i++ {
+		if _, err := reader.Read(); err != nil {
+			return nil, fmt.Errorf("%w: statement ended before the header row", ErrInvalidRequest)
+		}
+	}
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("%w: statement has no header row", ErrInvalidRequest)
+	}
+	columns := map[string]int{}
+	for i, name := range header {
+		columns[normalizeColumnName(name)] = i
+	}
+	columnIndex := func(name string) (int, error) {
+		if name == "" {
+			return -1, nil
+		}
+		index, ok := columns[normalizeColumnName(name)]
+		if !ok {
+			return -1, fmt.Errorf("%w: column %s is missing from the statement", ErrInvalidRequest, name)
+		}
+		return index, nil
+	}
+	mapping := csvColumnMapping{}
+	for _, column := range []struct {
+		name  string
+		index *int
+	}{
+		{profile.DateColumn, &mapping.date},
+		{profile.DescriptionColumn, &mapping.description},
+		{profile.MemoColumn, &mapping.memo},
+		{profile.AmountColumn, &mapping.amount},
+		{profile.DebitColumn, &mapping.debit},
+		{profile.CreditColumn, &mapping.credit},
+	} {
+		if *column.index, err = columnIndex(column.name); err != nil {
+			return nil, err
+		}
+	}
+
+	rows := []ImportRow{}
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			var parseErr *csv.ParseError
+			if errors.As(err, &parseErr) {
+				rows = append(rows, ImportRow{Line: parseErr.StartLine, Error: parseErr.Err.Error()})
+				continue
+			}
+			return nil, fmt.Errorf("failed to read statement with err:%v", err)
+		}
+		if isBlankRecord(record) {
+			continue
+		}
+		line, _ := reader.FieldPos(0)
+		rows = append(rows, parseCSVRecord(profile, mapping, record, line))
+	}
+	return rows, nil
+}
+
+// csvColumnMapping holds the index of each mapped column, or -1 when the profile does not map it.
+type csvColumnMapping struct {
+	date, description, memo, amount, debit, credit int
+}
+
+func parseCSVRecord(profile ImportProfile, mapping csvColumnMapping, record []string, line int) ImportRow {
+	row := ImportRow{Line: line}
+	field := func(index int) string {
+		if index < 0 || index >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[index])
+	}
+	transactionTime, err := time.Parse(profile.DateFormat, field(mapping.date))
+	if err != nil {
+		row.Error = fmt.Sprintf("date %q does not match format %s", field(mapping.date), profile.DateFormat)
+		return row
+	}
+	row.TransactionTime = &transactionTime
+	row.Description = field(mapping.description)
+	row.Memo = field(mapping.memo)
+
+	if mapping.amount >= 0 {
+		amount, err := parseStatementAmount(field(mapping.amount))
+		if err != nil {
+			row.Error = err.Error()
+			return row
+		}
+		if profile.AmountSign == AmountSignInverted {
+			amount = -amount
+		}
+		row.Amount = amount
+		return row
+	}
+	// debits and credits are reported as positive values in their own columns by most banks, so the sign convention
+	// of the profile does not apply to them
+	debit, err := parseStatementAmount(field(mapping.debit))
+	if err != nil {
+		row.Error = err.Error()
+		return row
+	}
+	credit, err := parseStatementAmount(field(mapping.credit))
+	if err != nil {
+		row.Error = err.Error()
+		return row
+	}
+	row.Amount = credit.Abs() - debit.Abs()
+	return row
+}
+
+// parseStatementAmount understands currency symbols, thousands separators and accounting style negatives like
+// (12.50). Empty values are read as zero.
+func parseStatementAmount(value string) (Money, error) {
+	cleaned := strings.NewReplacer(",", "", "$", "", "€", "", "£", "", "₹", "", " ", "").Replace(value)
+	if cleaned == "" {
+		return 0, nil
+	}
+	negative := false
+	if strings.HasPrefix(cleaned, "(") && strings.HasSuffix(cleaned, ")") {
+		negative = true
+		cleaned = strings.TrimSuffix(strings.TrimPrefix(cleaned, "("), ")")
+	}
+	amount, err := ParseMoney(cleaned)
+	if err != nil {
+		return 0, fmt.Errorf("amount %q is not a number", value)
+	}
+	if negative {
+		amount = -amount
+	}
+	return amount, nil
+}
+
+func normalizeColumnName(name string) string {
+	return strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+}
+
+func isBlankRecord(record []string) bool {
+	for _, value := range record {
+		if strings.TrimSpace(value) != "" {
+			return false
+		}
+	}
+	return true
+}
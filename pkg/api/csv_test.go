@@ -0,0 +1,104 @@
+package api
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseCSV(t *testing.T) {
+
+	t.Run("testing signed amounts with an inverted sign convention", func(t *testing.T) {
+		profile := ImportProfile{Delimiter: ",", DateColumn: "Date", DateFormat: "01/02/2006",
+			DescriptionColumn: "Description", AmountColumn: "Amount", AmountSign: AmountSignInverted}
+		statement := "Date,Description,Amount\n" +
+			"07/01/2022,\"AMZN MKTP US, SEATTLE\",\"1,234.50\"\n" +
+			"\n" +
+			"07/02/2022,PAYMENT THANK YOU,(200.00)\n"
+		rows, err := ParseCSV(profile, strings.NewReader(statement))
+		if err != nil {
+			t.Errorf("unexpected error when parsing statement: %v", err)
+			return
+		}
+		if len(rows) != 2 {
+			t.Errorf("expected 2 rows but found %d", len(rows))
+			return
+		}
+		if rows[0].Amount != -123450 || rows[0].Description != "AMZN MKTP US, SEATTLE" {
+			t.Errorf("unexpected first row %+v", rows[0])
+		}
+		if rows[1].Amount != 20000 || rows[1].Line != 4 {
+			t.Errorf("unexpected second row %+v", rows[1])
+		}
+		if !rows[1].TransactionTime.Equal(time.Date(2022, 7, 2, 0, 0, 0, 0, time.UTC)) {
+			t.Errorf("unexpected transaction time %v", rows[1].TransactionTime)
+		}
+	})
+
+	t.Run("testing separate debit and credit columns after a preamble", func(t *testing.T) {
+		profile := ImportProfile{Delimiter: ";", SkipRows: 2, DateColumn: "booking date", DateFormat: "2006-01-02",
+			DescriptionColumn: "Text", DebitColumn: "Debit", CreditColumn: "Credit"}
+		statement := "Account;DE89 3704\nBalance;1000\n" +
+			"\ufeffBooking Date;Text;Debit;Credit\n" +
+			"2022-07-01;Rent;950.00;\n" +
+			"2022-07-03;Salary;;3200.00\n" +
+			"07/04/2022;Groceries;12;\n" +
+			"2022-07-05;Refund;;abc\n"
+		rows, err := ParseCSV(profile, strings.NewReader(statement))
+		if err != nil {
+			t.Errorf("unexpected error when parsing statement: %v", err)
+			return
+		}
+		if len(rows) != 4 {
+			t.Errorf("expected 4 rows but found %d", len(rows))
+			return
+		}
+		if rows[0].Amount != -95000 || rows[1].Amount != 320000 {
+			t.Errorf("unexpected amounts %s and %s", rows[0].Amount, rows[1].Amount)
+		}
+		if rows[2].Error != "date \"07/04/2022\" does not match format 2006-01-02" {
+			t.Errorf("unexpected error %s", rows[2].Error)
+		}
+		if rows[3].Error != "amount \"abc\" is not a number" || rows[3].Line != 7 {
+			t.Errorf("unexpected error %s on line %d", rows[3].Error, rows[3].Line)
+		}
+	})
+
+	t.Run("testing statements missing a mapped column", func(t *testing.T) {
+		profile := ImportProfile{Delimiter: ",", DateColumn: "Date", DateFormat: "01/02/2006", AmountColumn: "Amount"}
+		_, err := ParseCSV(profile, strings.NewReader("Date,Value\n07/01/2022,10\n"))
+		expectedErrorMsg := "invalid request: column Amount is missing from the statement"
+		if err == nil || err.Error() != expectedErrorMsg {
+			t.Errorf("expected error message %s but found %v", expectedErrorMsg, err)
+		}
+	})
+}
+
+func TestImportService_PreviewCSV(t *testing.T) {
+
+	profiles := map[int64]ImportProfile{1: {Id: 1, Delimiter: ",", DateColumn: "Date", DateFormat: "2006-01-02",
+		AmountColumn: "Amount"}}
+	statement := "Date,Amount\n2022-07-01,-10\n2022-07-02,ten\n"
+
+	t.Run("testing happy flow of previewing a statement", func(t *testing.T) {
+		importService := NewImportService(&importProfilesMock{profiles: profiles}, nil, nil, nil, nil, nil)
+		preview, err := importService.PreviewCSV(1, nil, strings.NewReader(statement))
+		if err != nil {
+			t.Errorf("unexpected error when previewing statement: %v", err)
+		}
+		if preview.ValidRows != 1 || preview.InvalidRows != 1 {
+			t.Errorf("unexpected preview with %d valid and %d invalid rows", preview.ValidRows, preview.InvalidRows)
+		}
+	})
+
+	t.Run("testing statements with invalid rows are not imported", func(t *testing.T) {
+		dao := happyTransactionsMock{}
+		importService := NewImportService(&importProfilesMock{profiles: profiles},
+			NewTransactionsService(&dao, nil, nil), nil, nil, nil, nil)
+		_, err := importService.ImportCSV(1, 3, strings.NewReader(statement), nil)
+		if !errors.Is(err, ErrInvalidRequest) || dao.inserted != nil {
+			t.Errorf("expected invalid request error without inserts but found %v", err)
+		}
+	})
+}
@@ -0,0 +1,281 @@
+package api
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+)
+
+const ExactDuplicateMatch = "exact"
+const FuzzyDuplicateMatch = "fuzzy"
+
+const SkipDuplicateAction = "skip"
+const MergeDuplicateAction = "merge"
+const InsertDuplicateAction = "insert"
+
+// DuplicateWindowDays is how many days apart an imported row and an existing transaction can be and still be reported
+// as near-duplicates, which covers banks that report the posting date instead of the transaction date.
+const DuplicateWindowDays = 3
+
+// duplicateSimilarityThreshold is the share of description words two transactions need to have in common to be
+// reported as near-duplicates.
+const duplicateSimilarityThreshold = 0.5
+
+// DuplicateMatch is an existing transaction that an imported row may duplicate. Exact matches share the external id
+// or the fingerprint of the row, while fuzzy matches have the same amount within DuplicateWindowDays and a similar
+// description. Score ranks the matches of a row from 1 for an exact match down to 0.
+type DuplicateMatch struct {
+	TransactionId   int64     `json:"transaction_id"`
+	MatchType       string    `json:"match_type"`
+	Score           float64   `json:"score"`
+	TransactionTime time.Time `json:"transaction_time"`
+	Description     string    `json:"description"`
+	Amount          Money     `json:"amount"`
+}
+
+// DuplicateDecision picks what an import does with a row that has duplicate matches. Rows without a decision are
+// skipped. Merge fills in the external id, memo, payee and category of the matched transaction when it has none, and
+// TransactionId chooses the match to merge into, defaulting to the best one. Insert imports the row anyway.
+type DuplicateDecision struct {
+	Line          int    `json:"line"`
+	Action        string `json:"action"`
+	TransactionId *int64 `json:"transaction_id"`
+}
+
+// TransactionFingerprint identifies a transaction by its account, day, amount in cents and normalized description.
+// External ids are compared on their own since only some statements carry them.
+func TransactionFingerprint(accountId int64, transactionTime time.Time, amount Money, description string) string {
+	return fmt.Sprintf("%d|%s|%d|%s", accountId, transactionTime.Format("2006-01-02"), int64(amount),
+		strings.Join(descriptionWords(description), " "))
+}
+
+// descriptionWords lower cases the description and drops the numbers and punctuation banks add to it, like card
+// numbers, store numbers and reference ids.
+func descriptionWords(description string) []string {
+	words := strings.FieldsFunc(strings.ToLower(description), func(r rune) bool {
+		return !unicode.IsLetter(r)
+	})
+	normalized := words[:0]
+	for _, word := range words {
+		if len(word) > 1 {
+			normalized = append(normalized, word)
+		}
+	}
+	return normalized
+}
+
+// descriptionSimilarity is the dice coefficient of the words of both descriptions.
+func descriptionSimilarity(first []string, second []string) float64 {
+	if len(first) == 0 || len(second) == 0 {
+		return 0
+	}
+	words := make(map[string]bool, len(first))
+	for _, word := range first {
+		words[word] = true
+	}
+	common := 0
+	for _, word := range second {
+		if words[word] {
+			common++
+			delete(words, word)
+		}
+	}
+	return 2 * float64(common) / float64(len(first)+len(second))
+}
+
+// FindDuplicates fills in the matches of the rows among the existing transactions of the account and marks the rows
+// that have any as Duplicate. Every existing transaction is the exact match of one row at most, so a statement
+// holding two identical transactions is only a duplicate of an account holding both.
+func FindDuplicates(accountId int64, rows []ImportRow, existing []Transaction) {
+	claimed := map[int64]bool{}
+	fingerprints := make([]string, len(existing))
+	for i, transaction := range existing {
+		fingerprints[i] = TransactionFingerprint(accountId, transaction.TransactionTime, transaction.Amount,
+			transaction.Description)
+	}
+	for i := range rows {
+		row := &rows[i]
+		row.Matches, row.Duplicate = nil, false
+		if row.Error != "" || row.TransactionTime == nil {
+			continue
+		}
+		fingerprint := TransactionFingerprint(accountId, *row.TransactionTime, row.Amount, row.Description)
+		for j, transaction := range existing {
+			if claimed[transaction.Id] || !isExactDuplicate(row.ExternalId, transaction.ExternalId, fingerprint,
+				fingerprints[j]) {
+				continue
+			}
+			claimed[transaction.Id] = true
+			row.Matches = []DuplicateMatch{newDuplicateMatch(transaction, ExactDuplicateMatch, 1)}
+			break
+		}
+	}
+	for i := range rows {
+		row := &rows[i]
+		if row.Error != "" || row.TransactionTime == nil || len(row.Matches) > 0 {
+			row.Duplicate = len(row.Matches) > 0
+			continue
+		}
+		words := descriptionWords(row.Description)
+		for _, transaction := range existing {
+			if claimed[transaction.Id] {
+				continue
+			}
+			if score, ok := fuzzyDuplicateScore(*row, words, transaction); ok {
+				row.Matches = append(row.Matches, newDuplicateMatch(transaction, FuzzyDuplicateMatch, score))
+			}
+		}
+		sort.SliceStable(row.Matches, func(a, b int) bool {
+			return row.Matches[a].Score > row.Matches[b].Score
+		})
+		row.Duplicate = len(row.Matches) > 0
+	}
+}
+
+// isExactDuplicate compares the external ids of both transactions when both have one, and their fingerprints otherwise.
+func isExactDuplicate(rowExternalId string, externalId string, rowFingerprint string, fingerprint string) bool {
+	if rowExternalId != "" && externalId != "" {
+		return rowExternalId == externalId
+	}
+	return rowFingerprint == fingerprint
+}
+
+// fuzzyDuplicateScore averages the similarity of the descriptions with how close the dates are. Transactions the bank
+// gave different external ids are never duplicates. A missing description counts as half similar so that rows are
+// still matched on their amount and date alone.
+func fuzzyDuplicateScore(row ImportRow, words []string, transaction Transaction) (float64, bool) {
+	if row.ExternalId != "" && transaction.ExternalId != "" {
+		return 0, false
+	}
+	if row.Amount != transaction.Amount {
+		return 0, false
+	}
+	days := math.Abs(dayOf(*row.TransactionTime).Sub(dayOf(transaction.TransactionTime)).Hours() / 24)
+	if days > DuplicateWindowDays {
+		return 0, false
+	}
+	similarity := 0.5
+	existingWords := descriptionWords(transaction.Description)
+	if len(words) > 0 && len(existingWords) > 0 {
+		similarity = descriptionSimilarity(words, existingWords)
+		if similarity < duplicateSimilarityThreshold {
+			return 0, false
+		}
+	}
+	closeness := 1 - days/(DuplicateWindowDays+1)
+	return math.Round((similarity+closeness)/2*100) / 100, true
+}
+
+func dayOf(value time.Time) time.Time {
+	return time.Date(value.Year(), value.Month(), value.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+func newDuplicateMatch(transaction Transaction, matchType string, score float64) DuplicateMatch {
+	return DuplicateMatch{TransactionId: transaction.Id, MatchType: matchType, Score: score,
+		TransactionTime: transaction.TransactionTime, Description: transaction.Description, Amount: transaction.Amount}
+}
+
+// detectDuplicates looks for the rows among the transactions of the account made around the dates of the rows. Rows
+// whose external id was imported outside of that range are still matched exactly.
+func (service *ImportService) detectDuplicates(accountId int64, rows []ImportRow) error {
+	var from, to *time.Time
+	for _, row := range rows {
+		if row.Error != "" || row.TransactionTime == nil {
+			continue
+		}
+		if from == nil || row.TransactionTime.Before(*from) {
+			from = row.TransactionTime
+		}
+		if to == nil || row.TransactionTime.After(*to) {
+			to = row.TransactionTime
+		}
+	}
+	if from == nil {
+		return nil
+	}
+	windowFrom := dayOf(*from).AddDate(0, 0, -DuplicateWindowDays)
+	windowTo := dayOf(*to).AddDate(0, 0, DuplicateWindowDays+1)
+	existing, err := service.transactionsService.ListTransactions(TransactionFilter{AccountId: &accountId,
+		From: &windowFrom, To: &windowTo})
+	if err != nil {
+		return err
+	}
+	listed := make(map[string]bool, len(existing))
+	for _, transaction := range existing {
+		if transaction.ExternalId != "" {
+			listed[transaction.ExternalId] = true
+		}
+	}
+	for _, row := range rows {
+		if row.Error != "" || row.ExternalId == "" || listed[row.ExternalId] {
+			continue
+		}
+		id, found, err := service.transactionsService.FindTransactionByExternalId(accountId, row.ExternalId)
+		if err != nil {
+			return err
+		}
+		if !found {
+			continue
+		}
+		transaction, err := service.transactionsService.GetTransaction(id)
+		if err != nil {
+			return err
+		}
+		existing = append(existing, transaction)
+		listed[row.ExternalId] = true
+	}
+	FindDuplicates(accountId, rows, existing)
+	return nil
+}
+
+// duplicateActions validates the decisions against the matches of the rows and returns the decision of each line.
+// A merge without a transaction id is resolved to the best match of the row. Two lines are never merged into the same
+// transaction, as only one of them would be kept.
+func duplicateActions(rows []ImportRow, decisions []DuplicateDecision) (map[int]DuplicateDecision, error) {
+	matches := make(map[int][]DuplicateMatch, len(rows))
+	for _, row := range rows {
+		if row.Duplicate {
+			matches[row.Line] = row.Matches
+		}
+	}
+	actions := make(map[int]DuplicateDecision, len(decisions))
+	mergedLines := map[int64]int{}
+	for _, decision := range decisions {
+		rowMatches, ok := matches[decision.Line]
+		if !ok {
+			return nil, fmt.Errorf("%w: line %d is not a duplicate", ErrInvalidRequest, decision.Line)
+		}
+		switch decision.Action {
+		case SkipDuplicateAction, InsertDuplicateAction:
+		case MergeDuplicateAction:
+			if decision.TransactionId == nil {
+				decision.TransactionId = &rowMatches[0].TransactionId
+			} else if !containsMatch(rowMatches, *decision.TransactionId) {
+				return nil, fmt.Errorf("%w: line %d does not match transaction %d", ErrInvalidRequest, decision.Line,
+					*decision.TransactionId)
+			}
+			if line, ok := mergedLines[*decision.TransactionId]; ok && line != decision.Line {
+				return nil, fmt.Errorf("%w: lines %d and %d cannot both be merged into transaction %d",
+					ErrInvalidRequest, line, decision.Line, *decision.TransactionId)
+			}
+			mergedLines[*decision.TransactionId] = decision.Line
+		default:
+			return nil, fmt.Errorf("%w: action of line %d has to be %s, %s or %s", ErrInvalidRequest, decision.Line,
+				SkipDuplicateAction, MergeDuplicateAction, InsertDuplicateAction)
+		}
+		actions[decision.Line] = decision
+	}
+	return actions, nil
+}
+
+func containsMatch(matches []DuplicateMatch, transactionId int64) bool {
+	for _, match := range matches {
+		if match.TransactionId == transactionId {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,207 @@
+package api
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// duplicatesTransactionsMock holds a coffee bought twice on July 1st and a payroll credit imported from an ofx
+// statement, and records merges and inserts.
+type duplicatesTransactionsMock struct {
+	happyTransactionsMock
+	filter        TransactionFilter
+	mergedId      int64
+	merged        *TransactionMergeRequest
+	insertedCount int
+}
+
+func (m *duplicatesTransactionsMock) ListTransactions(filter TransactionFilter) ([]Transaction, error) {
+	m.filter = filter
+	return existingDuplicateTransactions(), nil
+}
+
+func (m *duplicatesTransactionsMock) ImportTransactions(batch TransactionImport) ([]int64, error) {
+	m.insertedCount += len(batch.Inserts)
+	for _, merge := range batch.Merges {
+		m.mergedId = merge.TransactionId
+		request := merge.Request
+		m.merged = &request
+	}
+	return m.happyTransactionsMock.ImportTransactions(batch)
+}
+
+func existingDuplicateTransactions() []Transaction {
+	return []Transaction{
+		{Id: 1, TransactionTime: time.Date(2022, 7, 1, 9, 0, 0, 0, time.UTC), AccountId: 3,
+			Description: "POS 1234 STARBUCKS #889", Amount: -450},
+		{Id: 2, TransactionTime: time.Date(2022, 7, 1, 15, 0, 0, 0, time.UTC), AccountId: 3,
+			Description: "POS 5678 STARBUCKS #889", Amount: -450},
+		{Id: 3, TransactionTime: time.Date(2022, 7, 2, 0, 0, 0, 0, time.UTC), AccountId: 3, Description: "PAYROLL",
+			Amount: 100000, ExternalId: "FIT-2"},
+	}
+}
+
+func TestTransactionFingerprint(t *testing.T) {
+
+	first := TransactionFingerprint(3, time.Date(2022, 7, 1, 9, 0, 0, 0, time.UTC), -450, "POS 1234 STARBUCKS #889")
+	second := TransactionFingerprint(3, time.Date(2022, 7, 1, 18, 0, 0, 0, time.UTC), -450, "pos starbucks")
+	if first != "3|2022-07-01|-450|pos starbucks" || first != second {
+		t.Errorf("expected both fingerprints to be 3|2022-07-01|-450|pos starbucks but found %s and %s", first,
+			second)
+	}
+	other := TransactionFingerprint(4, time.Date(2022, 7, 1, 9, 0, 0, 0, time.UTC), -450, "POS STARBUCKS")
+	if other == first {
+		t.Errorf("expected the fingerprint of another account to differ")
+	}
+}
+
+func TestFindDuplicates(t *testing.T) {
+
+	july := func(day int) *time.Time {
+		value := time.Date(2022, 7, day, 0, 0, 0, 0, time.UTC)
+		return &value
+	}
+
+	t.Run("testing happy flow of finding exact duplicates", func(t *testing.T) {
+		rows := []ImportRow{
+			{Line: 2, TransactionTime: july(1), Description: "POS 9999 STARBUCKS #889", Amount: -450},
+			{Line: 3, TransactionTime: july(1), Description: "POS 9999 STARBUCKS #889", Amount: -450},
+			{Line: 4, TransactionTime: july(5), Description: "PAYROLL", Amount: 100000, ExternalId: "FIT-2"},
+		}
+		FindDuplicates(3, rows, existingDuplicateTransactions())
+		for i, expectedId := range []int64{1, 2, 3} {
+			if !rows[i].Duplicate || len(rows[i].Matches) != 1 || rows[i].Matches[0].TransactionId != expectedId ||
+				rows[i].Matches[0].MatchType != ExactDuplicateMatch {
+				t.Errorf("expected line %d to exactly match transaction %d but found %+v", rows[i].Line, expectedId,
+					rows[i].Matches)
+			}
+		}
+	})
+
+	t.Run("testing a transaction is the exact duplicate of a single row", func(t *testing.T) {
+		rows := []ImportRow{
+			{Line: 2, TransactionTime: july(1), Description: "POS STARBUCKS", Amount: -450},
+			{Line: 3, TransactionTime: july(1), Description: "POS STARBUCKS", Amount: -450},
+			{Line: 4, TransactionTime: july(1), Description: "POS STARBUCKS", Amount: -450},
+		}
+		FindDuplicates(3, rows, existingDuplicateTransactions()[:1])
+		if rows[0].Matches[0].MatchType != ExactDuplicateMatch || rows[1].Duplicate || rows[2].Duplicate {
+			t.Errorf("expected only line 2 to be a duplicate but found %+v", rows)
+		}
+	})
+
+	t.Run("testing near-duplicates within the date window are ranked", func(t *testing.T) {
+		rows := []ImportRow{
+			{Line: 2, TransactionTime: july(3), Description: "STARBUCKS COFFEE", Amount: -450},
+			{Line: 3, TransactionTime: july(4), Amount: 100000},
+		}
+		existing := existingDuplicateTransactions()
+		existing[1].TransactionTime = *july(2)
+		FindDuplicates(3, rows, existing)
+		matches := rows[0].Matches
+		if len(matches) != 2 || matches[0].TransactionId != 2 || matches[0].MatchType != FuzzyDuplicateMatch ||
+			matches[0].Score != 0.63 || matches[1].Score != 0.5 {
+			t.Errorf("unexpected matches %+v", matches)
+		}
+		if len(rows[1].Matches) != 1 || rows[1].Matches[0].TransactionId != 3 || rows[1].Matches[0].Score != 0.5 {
+			t.Errorf("expected line 3 to match transaction 3 on its amount and date but found %+v", rows[1].Matches)
+		}
+	})
+
+	t.Run("testing transactions that only look alike are not duplicates", func(t *testing.T) {
+		rows := []ImportRow{
+			{Line: 2, TransactionTime: july(5), Description: "STARBUCKS", Amount: -450},
+			{Line: 3, TransactionTime: july(1), Description: "PEETS COFFEE", Amount: -450},
+			{Line: 4, TransactionTime: july(2), Description: "STARBUCKS", Amount: -550},
+			{Line: 5, TransactionTime: july(2), Description: "PAYROLL", Amount: 100000, ExternalId: "FIT-9"},
+			{Line: 6, Error: "date is missing"},
+		}
+		FindDuplicates(3, rows, existingDuplicateTransactions())
+		for _, row := range rows {
+			if row.Duplicate {
+				t.Errorf("expected line %d not to be a duplicate but found %+v", row.Line, row.Matches)
+			}
+		}
+	})
+}
+
+func TestImportService_commitRows_duplicates(t *testing.T) {
+
+	transactionTime := time.Date(2022, 7, 1, 0, 0, 0, 0, time.UTC)
+	rows := func() []ImportRow {
+		return []ImportRow{
+			{Line: 2, TransactionTime: &transactionTime, Description: "POS 1234 STARBUCKS #889", Amount: -450,
+				ExternalId: "FIT-1"},
+			{Line: 3, TransactionTime: &transactionTime, Description: "STARBUCKS", Amount: -450, Memo: "latte"},
+			{Line: 4, TransactionTime: &transactionTime, Description: "BAKERY", Amount: -700},
+		}
+	}
+
+	t.Run("testing happy flow of skipping duplicates", func(t *testing.T) {
+		dao := duplicatesTransactionsMock{}
+		importService := NewImportService(&importProfilesMock{}, NewTransactionsService(&dao, nil, nil), nil, nil, nil,
+			nil)
+		result, err := importService.commitRows(3, rows(), nil)
+		if err != nil {
+			t.Errorf("unexpected error when committing rows: %v", err)
+			return
+		}
+		if result.Imported != 1 || result.Skipped != 2 || dao.insertedCount != 1 ||
+			*dao.inserted.Description != "BAKERY" {
+			t.Errorf("unexpected result %+v", result)
+		}
+		if !dao.filter.From.Equal(time.Date(2022, 6, 28, 0, 0, 0, 0, time.UTC)) ||
+			!dao.filter.To.Equal(time.Date(2022, 7, 5, 0, 0, 0, 0, time.UTC)) {
+			t.Errorf("unexpected date window %v - %v", dao.filter.From, dao.filter.To)
+		}
+	})
+
+	t.Run("testing duplicates are merged and force inserted as decided", func(t *testing.T) {
+		dao := duplicatesTransactionsMock{}
+		importService := NewImportService(&importProfilesMock{}, NewTransactionsService(&dao, nil, nil), nil, nil, nil,
+			nil)
+		result, err := importService.commitRows(3, rows(), []DuplicateDecision{{Line: 2, Action: InsertDuplicateAction},
+			{Line: 3, Action: MergeDuplicateAction}})
+		if err != nil {
+			t.Errorf("unexpected error when committing rows: %v", err)
+			return
+		}
+		if result.Imported != 2 || result.Merged != 1 || result.Skipped != 0 {
+			t.Errorf("unexpected result %+v", result)
+		}
+		if dao.mergedId != 2 || *dao.merged.Memo != "latte" || dao.merged.ExternalId != nil {
+			t.Errorf("unexpected merge into transaction %d of %+v", dao.mergedId, dao.merged)
+		}
+	})
+
+	t.Run("testing two lines cannot be merged into the same transaction", func(t *testing.T) {
+		dao := duplicatesTransactionsMock{}
+		importService := NewImportService(&importProfilesMock{}, NewTransactionsService(&dao, nil, nil), nil, nil, nil,
+			nil)
+		statement := append(rows(), ImportRow{Line: 5, TransactionTime: &transactionTime, Description: "STARBUCKS",
+			Amount: -450, Memo: "muffin"})
+		_, err := importService.commitRows(3, statement, []DuplicateDecision{{Line: 3, Action: MergeDuplicateAction},
+			{Line: 5, Action: MergeDuplicateAction}})
+		expectedErrorMsg := "invalid request: lines 3 and 5 cannot both be merged into transaction 2"
+		if err == nil || err.Error() != expectedErrorMsg {
+			t.Errorf("expected error message %s but found %v", expectedErrorMsg, err)
+		}
+		if dao.merged != nil || dao.insertedCount != 0 {
+			t.Errorf("expected nothing to be imported but found merge %+v", dao.merged)
+		}
+	})
+
+	t.Run("testing validation of decisions", func(t *testing.T) {
+		importService := NewImportService(&importProfilesMock{},
+			NewTransactionsService(&duplicatesTransactionsMock{}, nil, nil), nil, nil, nil, nil)
+		otherId := int64(3)
+		for _, decision := range []DuplicateDecision{{Line: 4, Action: SkipDuplicateAction},
+			{Line: 2, Action: "delete"}, {Line: 3, Action: MergeDuplicateAction, TransactionId: &otherId}} {
+			_, err := importService.commitRows(3, rows(), []DuplicateDecision{decision})
+			if !errors.Is(err, ErrInvalidRequest) {
+				t.Errorf("expected invalid request error for %+v but found %v", decision, err)
+			}
+		}
+	})
+}
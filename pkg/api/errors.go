@@ -0,0 +1,13 @@
+package api
+
+import "errors"
+
+// ErrInvalidRequest is wrapped by services when a request fails validation so that handlers
+// can tell client mistakes apart from storage failures.
+var ErrInvalidRequest = errors.New("invalid request")
+
+// ErrUnauthorized is wrapped by services when a request lacks valid credentials or a valid session.
+var ErrUnauthorized = errors.New("unauthorized")
+
+// ErrForbidden is wrapped when the signed in user is not allowed to act on what a request targets.
+var ErrForbidden = errors.New("forbidden")
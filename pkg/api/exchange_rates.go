@@ -0,0 +1,309 @@
+package api
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultCurrency is the currency of accounts created without one.
+const DefaultCurrency = "USD"
+
+// ExchangeRateDateLayout formats the day an exchange rate applies from.
+const ExchangeRateDateLayout = "2006-01-02"
+
+// ExchangeRate says that on Date and the following days, until a later rate of the pair, one unit of BaseCurrency
+// is worth Rate units of QuoteCurrency.
+type ExchangeRate struct {
+	Id            int64     `json:"id"`
+	Date          string    `json:"date"`
+	BaseCurrency  string    `json:"base_currency"`
+	QuoteCurrency string    `json:"quote_currency"`
+	Rate          float64   `json:"rate"`
+	CreatedTs     time.Time `json:"created_ts"`
+	UpdatedTs     time.Time `json:"updated_ts"`
+}
+
+// ExchangeRateRequest creates an exchange rate, or replaces the rate of the pair on the same date.
+type ExchangeRateRequest struct {
+	Date          *string  `json:"date"`
+	BaseCurrency  *string  `json:"base_currency"`
+	QuoteCurrency *string  `json:"quote_currency"`
+	Rate          *float64 `json:"rate"`
+}
+
+// ExchangeRateFilter narrows down ListExchangeRates. Empty fields are not applied.
+type ExchangeRateFilter struct {
+	BaseCurrency  string
+	QuoteCurrency string
+}
+
+// ExchangeRateSource loads the exchange rates used to convert amounts between currencies.
+type ExchangeRateSource interface {
+	Converter() (*CurrencyConverter, error)
+}
+
+type ExchangeRatesService struct {
+	dao ExchangeRatesDataAccessor
+}
+
+func NewExchangeRatesService(dao ExchangeRatesDataAccessor) *ExchangeRatesService {
+	return &ExchangeRatesService{dao}
+}
+
+func (service *ExchangeRatesService) ListExchangeRates(filter ExchangeRateFilter) ([]ExchangeRate, error) {
+	var err error
+	if filter.BaseCurrency != "" {
+		if filter.BaseCurrency, err = NormalizeCurrency(filter.BaseCurrency); err != nil {
+			return nil, err
+		}
+	}
+	if filter.QuoteCurrency != "" {
+		if filter.QuoteCurrency, err = NormalizeCurrency(filter.QuoteCurrency); err != nil {
+			return nil, err
+		}
+	}
+	rates, err := service.dao.ListExchangeRates(filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list exchange rates with err:%v", err)
+	}
+	return rates, nil
+}
+
+func (service *ExchangeRatesService) SaveExchangeRate(request ExchangeRateRequest) error {
+	request, err := validateExchangeRate(request)
+	if err != nil {
+		return err
+	}
+	return service.dao.UpsertExchangeRates([]ExchangeRateRequest{request})
+}
+
+// ImportExchangeRates saves every rate of a csv file with a header row naming the date, base, quote and rate
+// columns, in any order. Dates are formatted as 2006-01-02. Nothing is saved when a row is invalid.
+func (service *ExchangeRatesService) ImportExchangeRates(file io.Reader) (int, error) {
+	reader := csv.NewReader(file)
+	reader.TrimLeadingSpace = true
+	header, err := reader.Read()
+	if err != nil {
+		return 0, fmt.Errorf("%w: exchange rate file has no header row", ErrInvalidRequest)
+	}
+	columns := map[string]int{}
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))] = i
+	}
+	for _, name := range []string{"date", "base", "quote", "rate"} {
+		if _, ok := columns[name]; !ok {
+			return 0, fmt.Errorf("%w: exchange rate file has no %s column", ErrInvalidRequest, name)
+		}
+	}
+	requests := []ExchangeRateRequest{}
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return 0, fmt.Errorf("%w: line %d is not valid csv: %v", ErrInvalidRequest, line, err)
+		}
+		if isBlankRecord(record) {
+			continue
+		}
+		field := func(name string) *string {
+			value := ""
+			if columns[name] < len(record) {
+				value = strings.TrimSpace(record[columns[name]])
+			}
+			return &value
+		}
+		request := ExchangeRateRequest{Date: field("date"), BaseCurrency: field("base"),
+			QuoteCurrency: field("quote")}
+		rate, err := strconv.ParseFloat(*field("rate"), 64)
+		if err != nil {
+			return 0, fmt.Errorf("%w: rate %q of line %d is not a number", ErrInvalidRequest, *field("rate"), line)
+		}
+		request.Rate = &rate
+		if request, err = validateExchangeRate(request); err != nil {
+			return 0, fmt.Errorf("%w (line %d)", err, line)
+		}
+		requests = append(requests, request)
+	}
+	if len(requests) == 0 {
+		return 0, nil
+	}
+	if err = service.dao.UpsertExchangeRates(requests); err != nil {
+		return 0, err
+	}
+	return len(requests), nil
+}
+
+// Converter loads every exchange rate into a converter.
+func (service *ExchangeRatesService) Converter() (*CurrencyConverter, error) {
+	rates, err := service.ListExchangeRates(ExchangeRateFilter{})
+	if err != nil {
+		return nil, err
+	}
+	return NewCurrencyConverter(rates), nil
+}
+
+// loadConverter loads the converter of an optional source of exchange rates.
+func loadConverter(exchangeRates ExchangeRateSource) (*CurrencyConverter, error) {
+	if exchangeRates == nil {
+		return nil, fmt.Errorf("%w: exchange rates are not available", ErrInvalidRequest)
+	}
+	return exchangeRates.Converter()
+}
+
+func validateExchangeRate(request ExchangeRateRequest) (ExchangeRateRequest, error) {
+	if isBlank(request.Date) {
+		return request, fmt.Errorf("%w: date is required", ErrInvalidRequest)
+	}
+	if _, err := time.Parse(ExchangeRateDateLayout, *request.Date); err != nil {
+		return request, fmt.Errorf("%w: date %q has to be formatted as 2006-01-02", ErrInvalidRequest,
+			*request.Date)
+	}
+	if request.BaseCurrency == nil || request.QuoteCurrency == nil {
+		return request, fmt.Errorf("%w: base_currency and quote_currency are required", ErrInvalidRequest)
+	}
+	base, err := NormalizeCurrency(*request.BaseCurrency)
+	if err != nil {
+		return request, err
+	}
+	quote, err := NormalizeCurrency(*request.QuoteCurrency)
+	if err != nil {
+		return request, err
+	}
+	if base == quote {
+		return request, fmt.Errorf("%w: base_currency and quote_currency have to differ", ErrInvalidRequest)
+	}
+	if request.Rate == nil || *request.Rate <= 0 {
+		return request, fmt.Errorf("%w: rate has to be positive", ErrInvalidRequest)
+	}
+	request.BaseCurrency = &base
+	request.QuoteCurrency = &quote
+	return request, nil
+}
+
+// currencyMinorUnits lists the ISO 4217 currencies whose minor unit is not a hundredth, with its decimal places.
+var currencyMinorUnits = map[string]int{
+	"BIF": 0, "CLP": 0, "DJF": 0, "GNF": 0, "ISK": 0, "JPY": 0, "KMF": 0, "KRW": 0, "PYG": 0, "RWF": 0, "UGX": 0,
+	"UYI": 0, "VND": 0, "VUV": 0, "XAF": 0, "XOF": 0, "XPF": 0,
+	"BHD": 3, "IQD": 3, "JOD": 3, "KWD": 3, "LYD": 3, "OMR": 3, "TND": 3,
+	"CLF": 4, "UYW": 4,
+}
+
+// NormalizeCurrency upper cases a three letter ISO 4217 currency code. Money always holds two decimal places, so
+// currencies with another number of them, like JPY or KWD, are refused rather than stored off by a factor of ten.
+func NormalizeCurrency(currency string) (string, error) {
+	normalized := strings.ToUpper(strings.TrimSpace(currency))
+	if len(normalized) != 3 || strings.IndexFunc(normalized, func(r rune) bool {
+		return r < 'A' || r > 'Z'
+	}) >= 0 {
+		return "", fmt.Errorf("%w: currency %q is not a three letter code", ErrInvalidRequest, currency)
+	}
+	if decimals, ok := currencyMinorUnits[normalized]; ok {
+		return "", fmt.Errorf("%w: currency %s has %d decimal places but only currencies with 2 are supported",
+			ErrInvalidRequest, normalized, decimals)
+	}
+	return normalized, nil
+}
+
+// CurrencyConverter converts amounts with the latest rate on or before the day of the conversion. Pairs without a
+// rate of their own are converted with the inverse rate or through a third currency both are quoted against.
+type CurrencyConverter struct {
+	// rates of every pair as they were stored, ordered by date
+	rates      map[string][]ExchangeRate
+	currencies []string
+}
+
+func NewCurrencyConverter(rates []ExchangeRate) *CurrencyConverter {
+	converter := &CurrencyConverter{rates: map[string][]ExchangeRate{}}
+	currencies := map[string]bool{}
+	for _, rate := range rates {
+		key := currencyPair(rate.BaseCurrency, rate.QuoteCurrency)
+		converter.rates[key] = append(converter.rates[key], rate)
+		currencies[rate.BaseCurrency] = true
+		currencies[rate.QuoteCurrency] = true
+	}
+	for _, pairRates := range converter.rates {
+		sort.SliceStable(pairRates, func(i, j int) bool {
+			return pairRates[i].Date < pairRates[j].Date
+		})
+	}
+	for currency := range currencies {
+		converter.currencies = append(converter.currencies, currency)
+	}
+	sort.Strings(converter.currencies)
+	return converter
+}
+
+func currencyPair(from string, to string) string {
+	return from + "/" + to
+}
+
+// Rate is the number of units of to that one unit of from is worth on the day.
+func (converter *CurrencyConverter) Rate(from string, to string, on time.Time) (float64, error) {
+	if from == to {
+		return 1, nil
+	}
+	day := on.UTC().Format(ExchangeRateDateLayout)
+	if rate, ok := converter.latestRate(from, to, day); ok {
+		return rate, nil
+	}
+	for _, via := range converter.currencies {
+		if via == from || via == to {
+			continue
+		}
+		first, ok := converter.latestRate(from, via, day)
+		if !ok {
+			continue
+		}
+		if second, ok := converter.latestRate(via, to, day); ok {
+			return first * second, nil
+		}
+	}
+	return 0, fmt.Errorf("%w: there is no exchange rate from %s to %s on or before %s", ErrInvalidRequest, from,
+		to, day)
+}
+
+// Convert converts an amount from one currency to another at the rate of the day, rounded to cents.
+func (converter *CurrencyConverter) Convert(amount Money, from string, to string, on time.Time) (Money, error) {
+	rate, err := converter.Rate(from, to, on)
+	if err != nil {
+		return 0, err
+	}
+	return amount.Multiply(rate), nil
+}
+
+// latestRate picks the more recent of the rate of the pair and the inverse of the rate of the opposite pair,
+// preferring the rate of the pair when both are of the same day.
+func (converter *CurrencyConverter) latestRate(from string, to string, day string) (float64, bool) {
+	direct, directFound := lastRateOn(converter.rates[currencyPair(from, to)], day)
+	inverse, inverseFound := lastRateOn(converter.rates[currencyPair(to, from)], day)
+	switch {
+	case directFound && (!inverseFound || direct.Date >= inverse.Date):
+		return direct.Rate, true
+	case inverseFound:
+		return 1 / inverse.Rate, true
+	}
+	return 0, false
+}
+
+func lastRateOn(rates []ExchangeRate, day string) (ExchangeRate, bool) {
+	index := sort.Search(len(rates), func(i int) bool {
+		return rates[i].Date > day
+	})
+	if index == 0 {
+		return ExchangeRate{}, false
+	}
+	return rates[index-1], true
+}
+
+type ExchangeRatesDataAccessor interface {
+	ListExchangeRates(filter ExchangeRateFilter) ([]ExchangeRate, error)
+	UpsertExchangeRates(requests []ExchangeRateRequest) error
+}
@@ -0,0 +1,184 @@
+package api
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// exchangeRatesMock quotes the dollar in euros and pounds in dollars, with a newer inverse euro rate in June.
+type exchangeRatesMock struct {
+	upserted []ExchangeRateRequest
+	err      error
+}
+
+func (m *exchangeRatesMock) ListExchangeRates(filter ExchangeRateFilter) ([]ExchangeRate, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	return []ExchangeRate{
+		{Id: 1, Date: "2022-06-01", BaseCurrency: "USD", QuoteCurrency: "EUR", Rate: 0.9},
+		{Id: 2, Date: "2022-08-01", BaseCurrency: "USD", QuoteCurrency: "EUR", Rate: 0.95},
+		{Id: 3, Date: "2022-06-20", BaseCurrency: "EUR", QuoteCurrency: "USD", Rate: 1.25},
+		{Id: 4, Date: "2022-06-01", BaseCurrency: "GBP", QuoteCurrency: "USD", Rate: 1.2},
+	}, nil
+}
+
+func (m *exchangeRatesMock) UpsertExchangeRates(requests []ExchangeRateRequest) error {
+	m.upserted = requests
+	return m.err
+}
+
+func TestCurrencyConverter_Rate(t *testing.T) {
+
+	converter, _ := NewExchangeRatesService(&exchangeRatesMock{}).Converter()
+	july := time.Date(2022, 7, 1, 0, 0, 0, 0, time.UTC)
+
+	t.Run("testing the latest rate on or before the day is used", func(t *testing.T) {
+		for _, test := range []struct {
+			from, to string
+			on       time.Time
+			expected float64
+		}{
+			{"USD", "USD", july, 1},
+			{"USD", "EUR", time.Date(2022, 6, 10, 0, 0, 0, 0, time.UTC), 0.9},
+			{"USD", "EUR", july, 0.8},
+			{"EUR", "USD", time.Date(2022, 6, 10, 0, 0, 0, 0, time.UTC), 1 / 0.9},
+			{"USD", "EUR", time.Date(2022, 8, 1, 12, 0, 0, 0, time.UTC), 0.95},
+			{"GBP", "EUR", july, 1.2 * 0.8},
+		} {
+			rate, err := converter.Rate(test.from, test.to, test.on)
+			if err != nil || rate != test.expected {
+				t.Errorf("expected rate %f from %s to %s but found %f or error %v", test.expected, test.from,
+					test.to, rate, err)
+			}
+		}
+	})
+
+	t.Run("testing conversion without a rate", func(t *testing.T) {
+		for _, currency := range []string{"CHF", "EUR"} {
+			_, err := converter.Convert(1000, "GBP", currency, time.Date(2022, 5, 1, 0, 0, 0, 0, time.UTC))
+			if !errors.Is(err, ErrInvalidRequest) {
+				t.Errorf("expected invalid request error converting to %s but found %v", currency, err)
+			}
+		}
+	})
+
+	t.Run("testing amounts are rounded to cents", func(t *testing.T) {
+		amount, err := converter.Convert(1001, "GBP", "USD", july)
+		if err != nil || amount != 1201 {
+			t.Errorf("unexpected amount %s or error %v", amount, err)
+		}
+	})
+}
+
+func TestNormalizeCurrency(t *testing.T) {
+
+	t.Run("testing happy flow of normalizing a currency", func(t *testing.T) {
+		currency, err := NormalizeCurrency(" eur ")
+		if err != nil || currency != "EUR" {
+			t.Errorf("unexpected currency %s or error %v", currency, err)
+		}
+	})
+
+	t.Run("testing currencies without two decimal places are refused", func(t *testing.T) {
+		for currency, expectedErrorMsg := range map[string]string{
+			"jpy":  "invalid request: currency JPY has 0 decimal places but only currencies with 2 are supported",
+			"KWD":  "invalid request: currency KWD has 3 decimal places but only currencies with 2 are supported",
+			"EURO": "invalid request: currency \"EURO\" is not a three letter code",
+		} {
+			_, err := NormalizeCurrency(currency)
+			if err == nil || err.Error() != expectedErrorMsg {
+				t.Errorf("expected error message %s but found %v", expectedErrorMsg, err)
+			}
+		}
+	})
+}
+
+func TestExchangeRatesService_ImportExchangeRates(t *testing.T) {
+
+	t.Run("testing happy flow of importing a csv file", func(t *testing.T) {
+		dao := exchangeRatesMock{}
+		file := "\ufeffRate,Date,Base,Quote\n1.02, 2022-07-01,eur,usd\n\n0.85,2022-07-01,USD,GBP\n"
+		imported, err := NewExchangeRatesService(&dao).ImportExchangeRates(strings.NewReader(file))
+		if err != nil || imported != 2 {
+			t.Errorf("unexpected count %d or error %v", imported, err)
+			return
+		}
+		first := dao.upserted[0]
+		if *first.Date != "2022-07-01" || *first.BaseCurrency != "EUR" || *first.QuoteCurrency != "USD" ||
+			*first.Rate != 1.02 {
+			t.Errorf("unexpected rate %+v", first)
+		}
+	})
+
+	t.Run("testing nothing is imported when a row is invalid", func(t *testing.T) {
+		for _, file := range []string{
+			"date,base,rate\n2022-07-01,EUR,1.02\n",
+			"date,base,quote,rate\n2022-07-01,EUR,USD,1.02\n07/02/2022,EUR,USD,1.03\n",
+			"date,base,quote,rate\n2022-07-01,EUR,USD,one\n",
+			"date,base,quote,rate\n2022-07-01,EUR,EUR,1\n",
+			"date,base,quote,rate\n2022-07-01,EURO,USD,1\n",
+			"date,base,quote,rate\n2022-07-01,EUR,USD,-1\n",
+		} {
+			dao := exchangeRatesMock{}
+			_, err := NewExchangeRatesService(&dao).ImportExchangeRates(strings.NewReader(file))
+			if !errors.Is(err, ErrInvalidRequest) || dao.upserted != nil {
+				t.Errorf("expected invalid request error for %q but found %v", file, err)
+			}
+		}
+	})
+}
+
+func TestAccountsService_GetAccountBalance_currency(t *testing.T) {
+
+	asOf := time.Date(2022, 7, 1, 0, 0, 0, 0, time.UTC)
+
+	t.Run("testing happy flow of converting a balance", func(t *testing.T) {
+		accountsService := NewAccountsService(&happyMock{}, NewExchangeRatesService(&exchangeRatesMock{}))
+		balance, err := accountsService.GetAccountBalance(1, &asOf, "eur")
+		if err != nil {
+			t.Errorf("unexpected error when retrieving balance: %v", err)
+			return
+		}
+		if balance.Balance != 4250 || balance.Currency != "USD" || balance.BaseBalance != 3400 ||
+			balance.BaseCurrency != "EUR" {
+			t.Errorf("unexpected balance %+v", balance)
+		}
+	})
+
+	t.Run("testing conversion requires exchange rates", func(t *testing.T) {
+		_, err := NewAccountsService(&happyMock{}, nil).GetAccountBalance(1, &asOf, "EUR")
+		if !errors.Is(err, ErrInvalidRequest) {
+			t.Errorf("expected invalid request error but found %v", err)
+		}
+	})
+}
+
+func TestCategoriesService_GetCategoryRollup_currency(t *testing.T) {
+
+	t.Run("testing happy flow of converting totals", func(t *testing.T) {
+		categoriesService := NewCategoriesService(&categoriesMock{}, NewExchangeRatesService(&exchangeRatesMock{}))
+		rollup, err := categoriesService.GetCategoryRollup(1, nil, nil, "usd")
+		if err != nil {
+			t.Errorf("unexpected error when computing rollup: %v", err)
+			return
+		}
+		if rollup.Total != -22500 || rollup.Children[0].OwnTotal != -22500 {
+			t.Errorf("unexpected rollup %+v", rollup)
+		}
+		travel, _ := categoriesService.GetCategoryRollup(5, nil, nil, "USD")
+		if travel.Total != -36000 {
+			t.Errorf("expected pounds to be converted but found %+v", travel)
+		}
+	})
+
+	t.Run("testing validation of the currency", func(t *testing.T) {
+		categoriesService := NewCategoriesService(&categoriesMock{}, NewExchangeRatesService(&exchangeRatesMock{}))
+		_, err := categoriesService.GetCategoryRollup(1, nil, nil, "dollars")
+		if !errors.Is(err, ErrInvalidRequest) {
+			t.Errorf("expected invalid request error but found %v", err)
+		}
+	})
+}
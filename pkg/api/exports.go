@@ -0,0 +1,14 @@
+package api
+
+// ExportService writes the transactions of an account in file formats understood by other finance tools.
+type ExportService struct {
+	transactionsService *TransactionsService
+	accountsService     *AccountsService
+	payeesService       *PayeesService
+	categoriesService   *CategoriesService
+}
+
+func NewExportService(transactionsService *TransactionsService, accountsService *AccountsService,
+	payeesService *PayeesService, categoriesService *CategoriesService) *ExportService {
+	return &ExportService{transactionsService, accountsService, payeesService, categoriesService}
+}
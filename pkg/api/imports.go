@@ -0,0 +1,414 @@
+package api
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+const AmountSignNormal = "normal"
+const AmountSignInverted = "inverted"
+
+// ImportProfile maps the columns of the csv statements exported by a bank onto transactions. Columns are referenced
+// by their header name. The amount is either read from a single signed AmountColumn or computed from separate
+// DebitColumn and CreditColumn values. AmountSign only applies to AmountColumn.
+type ImportProfile struct {
+	Id                int64     `json:"id"`
+	Name              string    `json:"name"`
+	Delimiter         string    `json:"delimiter"`
+	SkipRows          int64     `json:"skip_rows"`
+	DateColumn        string    `json:"date_column"`
+	DateFormat        string    `json:"date_format"`
+	DescriptionColumn string    `json:"description_column"`
+	MemoColumn        string    `json:"memo_column"`
+	AmountColumn      string    `json:"amount_column"`
+	DebitColumn       string    `json:"debit_column"`
+	CreditColumn      string    `json:"credit_column"`
+	AmountSign        string    `json:"amount_sign"`
+	IsDeleted         bool      `json:"is_deleted"`
+	CreatedTs         time.Time `json:"created_ts"`
+	UpdatedTs         time.Time `json:"updated_ts"`
+}
+
+// ImportProfileRequest is used to both create and update a profile. DateFormat is a go reference layout such as
+// 01/02/2006.
+type ImportProfileRequest struct {
+	Name              *string `json:"name"`
+	Delimiter         *string `json:"delimiter"`
+	SkipRows          *int64  `json:"skip_rows"`
+	DateColumn        *string `json:"date_column"`
+	DateFormat        *string `json:"date_format"`
+	DescriptionColumn *string `json:"description_column"`
+	MemoColumn        *string `json:"memo_column"`
+	AmountColumn      *string `json:"amount_column"`
+	DebitColumn       *string `json:"debit_column"`
+	CreditColumn      *string `json:"credit_column"`
+	AmountSign        *string `json:"amount_sign"`
+}
+
+// ImportRow is a single transaction parsed from a statement. Rows that could not be parsed carry an Error and are
+// never imported. ExternalId is the id the bank assigned to the transaction, when the statement format has one.
+// Duplicate marks rows that match transactions already in the account, which are listed best first in Matches. Payee
+// and Category carry names that are matched against existing payees and categories, and created when missing, on
+// import. Category is a path of names from the root category separated by ":".
+type ImportRow struct {
+	Line            int              `json:"line"`
+	TransactionTime *time.Time       `json:"transaction_time"`
+	Description     string           `json:"description"`
+	Memo            string           `json:"memo"`
+	Amount          Money            `json:"amount"`
+	Currency        string           `json:"currency,omitempty"`
+	ExternalId      string           `json:"external_id,omitempty"`
+	Payee           string           `json:"payee,omitempty"`
+	Category        string           `json:"category,omitempty"`
+	Splits          []ImportSplit    `json:"splits,omitempty"`
+	Duplicate       bool             `json:"duplicate,omitempty"`
+	Matches         []DuplicateMatch `json:"matches,omitempty"`
+	Error           string           `json:"error,omitempty"`
+}
+
+// ImportSplit is a split of an imported transaction. Category is a path of names like the Category of ImportRow.
+type ImportSplit struct {
+	Category string `json:"category,omitempty"`
+	Memo     string `json:"memo,omitempty"`
+	Amount   Money  `json:"amount"`
+}
+
+// ImportPreview shows how a statement would be imported without writing anything. Duplicates are only looked for
+// when the account is known, and DuplicateRows counts the valid rows that are duplicates.
+type ImportPreview struct {
+	Rows          []ImportRow `json:"rows"`
+	ValidRows     int         `json:"valid_rows"`
+	InvalidRows   int         `json:"invalid_rows"`
+	DuplicateRows int         `json:"duplicate_rows"`
+}
+
+// ImportResult lists the transactions created by an import. Skipped and Merged count the duplicate rows that were
+// skipped and merged into the transactions they duplicate.
+type ImportResult struct {
+	AccountId      int64   `json:"account_id"`
+	Imported       int     `json:"imported"`
+	Skipped        int     `json:"skipped"`
+	Merged         int     `json:"merged"`
+	TransactionIds []int64 `json:"transaction_ids"`
+}
+
+// ImportService parses bank statements and writes them into an account through the transactions service, so
+// imported transactions go through the same validation and payee recognition as manually entered ones. The accounts
+// and organizations services are used to match the account information carried by ofx statements, and the payees
+// and categories services to match the names carried by qif files.
+type ImportService struct {
+	dao                  ImportProfilesDataAccessor
+	transactionsService  *TransactionsService
+	accountsService      *AccountsService
+	organizationsService *OrganizationsService
+	payeesService        *PayeesService
+	categoriesService    *CategoriesService
+}
+
+func NewImportService(dao ImportProfilesDataAccessor, transactionsService *TransactionsService,
+	accountsService *AccountsService, organizationsService *OrganizationsService, payeesService *PayeesService,
+	categoriesService *CategoriesService) *ImportService {
+	return &ImportService{dao, transactionsService, accountsService, organizationsService, payeesService,
+		categoriesService}
+}
+
+func (service *ImportService) GetImportProfile(id int64) (ImportProfile, error) {
+	profile, err := service.dao.GetImportProfile(id)
+	if err != nil {
+		return profile, fmt.Errorf("failed to retrieve import profile of id %d with err:%v", id, err)
+	}
+	return profile, nil
+}
+
+func (service *ImportService) ListImportProfiles() ([]ImportProfile, error) {
+	profiles, err := service.dao.ListImportProfiles()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list import profiles with err:%v", err)
+	}
+	return profiles, nil
+}
+
+func (service *ImportService) CreateImportProfile(request ImportProfileRequest) (int64, error) {
+	request, err := validateImportProfile(request)
+	if err != nil {
+		return -1, err
+	}
+	return service.dao.InsertImportProfile(request)
+}
+
+func (service *ImportService) UpdateImportProfile(id int64, request ImportProfileRequest) error {
+	request, err := validateImportProfile(request)
+	if err != nil {
+		return err
+	}
+	return service.dao.UpdateImportProfile(id, request)
+}
+
+func (service *ImportService) DeleteImportProfile(id int64) error {
+	err := service.dao.DeleteImportProfile(id)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// validateImportProfile fills in the defaults of optional fields.
+func validateImportProfile(request ImportProfileRequest) (ImportProfileRequest, error) {
+	if request.Name == nil || strings.TrimSpace(*request.Name) == "" {
+		return request, fmt.Errorf("%w: name is required", ErrInvalidRequest)
+	}
+	if isBlank(request.DateColumn) || isBlank(request.DateFormat) {
+		return request, fmt.Errorf("%w: date_column and date_format are required", ErrInvalidRequest)
+	}
+	if isBlank(request.AmountColumn) && isBlank(request.DebitColumn) && isBlank(request.CreditColumn) {
+		return request, fmt.Errorf("%w: either amount_column or debit_column and credit_column are required",
+			ErrInvalidRequest)
+	}
+	if !isBlank(request.AmountColumn) && (!isBlank(request.DebitColumn) || !isBlank(request.CreditColumn)) {
+		return request, fmt.Errorf("%w: amount_column cannot be combined with debit_column or credit_column",
+			ErrInvalidRequest)
+	}
+	if request.Delimiter == nil || *request.Delimiter == "" {
+		delimiter := ","
+		request.Delimiter = &delimiter
+	}
+	if len([]rune(*request.Delimiter)) != 1 {
+		return request, fmt.Errorf("%w: delimiter has to be a single character", ErrInvalidRequest)
+	}
+	if request.SkipRows != nil && *request.SkipRows < 0 {
+		return request, fmt.Errorf("%w: skip_rows cannot be negative", ErrInvalidRequest)
+	}
+	if request.AmountSign == nil {
+		amountSign := AmountSignNormal
+		request.AmountSign = &amountSign
+	}
+	if *request.AmountSign != AmountSignNormal && *request.AmountSign != AmountSignInverted {
+		return request, fmt.Errorf("%w: amount_sign has to be %s or %s", ErrInvalidRequest, AmountSignNormal,
+			AmountSignInverted)
+	}
+	return request, nil
+}
+
+func isBlank(value *string) bool {
+	return value == nil || strings.TrimSpace(*value) == ""
+}
+
+// previewRows summarises parsed rows.
+func previewRows(rows []ImportRow) ImportPreview {
+	preview := ImportPreview{Rows: rows}
+	for _, row := range rows {
+		if row.Error != "" {
+			preview.InvalidRows++
+		} else {
+			preview.ValidRows++
+		}
+		if row.Duplicate {
+			preview.DuplicateRows++
+		}
+	}
+	return preview
+}
+
+// previewAccountRows summarises parsed rows after looking for duplicates of them in the account, when one is given.
+func (service *ImportService) previewAccountRows(accountId *int64, rows []ImportRow) (ImportPreview, error) {
+	if accountId != nil {
+		if err := service.detectDuplicates(*accountId, rows); err != nil {
+			return ImportPreview{}, err
+		}
+	}
+	return previewRows(rows), nil
+}
+
+// commitRows creates a transaction for each row in the account. Rows that duplicate existing transactions are
+// skipped, merged or inserted as decided, and skipped when there is no decision for them. Every row is prepared
+// before the ledger is written to in one go, so nothing is imported when any of the rows is invalid or fails to be
+// written. Only the payees and categories created for the names of the rows are kept, to be reused by the next
+// import.
+func (service *ImportService) commitRows(accountId int64, rows []ImportRow, decisions []DuplicateDecision) (
+	ImportResult, error) {
+	for _, row := range rows {
+		if row.Error != "" {
+			return ImportResult{}, fmt.Errorf("%w: line %d cannot be imported: %s", ErrInvalidRequest, row.Line,
+				row.Error)
+		}
+	}
+	if err := service.detectDuplicates(accountId, rows); err != nil {
+		return ImportResult{}, err
+	}
+	actions, err := duplicateActions(rows, decisions)
+	if err != nil {
+		return ImportResult{}, err
+	}
+	names := service.newImportNames()
+	result := ImportResult{AccountId: accountId, TransactionIds: []int64{}}
+	batch := TransactionImport{}
+	for _, row := range rows {
+		row := row
+		action := actions[row.Line]
+		if row.Duplicate && (action.Action == "" || action.Action == SkipDuplicateAction) {
+			result.Skipped++
+			continue
+		}
+		request := TransactionCreationRequest{
+			TransactionTime: row.TransactionTime,
+			AccountId:       &accountId,
+			Amount:          &row.Amount,
+		}
+		if row.Description != "" {
+			request.Description = &row.Description
+		}
+		if row.Memo != "" {
+			request.Memo = &row.Memo
+		}
+		if row.Currency != "" {
+			request.Currency = &row.Currency
+		}
+		if row.ExternalId != "" {
+			request.ExternalId = &row.ExternalId
+		}
+		if err := names.resolve(row, &request); err != nil {
+			return ImportResult{}, fmt.Errorf("failed to import line %d with err:%w", row.Line, err)
+		}
+		if row.Duplicate && action.Action == MergeDuplicateAction {
+			batch.Merges = append(batch.Merges, TransactionMerge{TransactionId: *action.TransactionId,
+				Request: TransactionMergeRequest{ExternalId: request.ExternalId, Memo: request.Memo,
+					PayeeId: request.PayeeId, CategoryId: request.CategoryId}})
+			continue
+		}
+		request, err := service.transactionsService.PrepareTransaction(request)
+		if err != nil {
+			return ImportResult{}, fmt.Errorf("failed to import line %d with err:%w", row.Line, err)
+		}
+		batch.Inserts = append(batch.Inserts, request)
+	}
+	ids, err := service.transactionsService.ImportTransactions(batch)
+	if err != nil {
+		return ImportResult{}, err
+	}
+	result.Imported = len(ids)
+	result.Merged = len(batch.Merges)
+	result.TransactionIds = append(result.TransactionIds, ids...)
+	return result, nil
+}
+
+// importNames matches the payee and category names of imported rows onto payees and categories, creating the ones
+// that do not exist yet. Names are compared ignoring case and the existing payees and categories are only listed
+// once per import.
+type importNames struct {
+	service    *ImportService
+	payees     map[string]int64
+	categories map[importCategoryKey]int64
+}
+
+type importCategoryKey struct {
+	parentId int64
+	name     string
+}
+
+func (service *ImportService) newImportNames() *importNames {
+	return &importNames{service: service}
+}
+
+// resolve fills in the payee, category and splits of the request from the names of the row.
+func (names *importNames) resolve(row ImportRow, request *TransactionCreationRequest) error {
+	if row.Payee != "" {
+		payeeId, err := names.payeeId(row.Payee)
+		if err != nil {
+			return err
+		}
+		request.PayeeId = &payeeId
+	}
+	if row.Category != "" {
+		categoryId, err := names.categoryId(row.Category)
+		if err != nil {
+			return err
+		}
+		request.CategoryId = &categoryId
+	}
+	for _, split := range row.Splits {
+		split := split
+		splitRequest := TransactionSplitRequest{Amount: &split.Amount}
+		if split.Category != "" {
+			categoryId, err := names.categoryId(split.Category)
+			if err != nil {
+				return err
+			}
+			splitRequest.CategoryId = &categoryId
+		}
+		if split.Memo != "" {
+			splitRequest.Memo = &split.Memo
+		}
+		request.Splits = append(request.Splits, splitRequest)
+	}
+	return nil
+}
+
+func (names *importNames) payeeId(name string) (int64, error) {
+	if names.payees == nil {
+		payees, err := names.service.payeesService.ListPayees()
+		if err != nil {
+			return -1, err
+		}
+		names.payees = make(map[string]int64, len(payees))
+		for _, payee := range payees {
+			names.payees[strings.ToLower(strings.TrimSpace(payee.Name))] = payee.Id
+		}
+	}
+	key := strings.ToLower(name)
+	if id, ok := names.payees[key]; ok {
+		return id, nil
+	}
+	id, err := names.service.payeesService.CreatePayee(PayeeCreationRequest{Name: &name})
+	if err != nil {
+		return -1, err
+	}
+	names.payees[key] = id
+	return id, nil
+}
+
+// categoryId walks the path of the category from the root, creating every missing category on the way.
+func (names *importNames) categoryId(path string) (int64, error) {
+	if names.categories == nil {
+		categories, err := names.service.categoriesService.ListCategories()
+		if err != nil {
+			return -1, err
+		}
+		names.categories = make(map[importCategoryKey]int64, len(categories))
+		for _, category := range categories {
+			key := importCategoryKey{category.ParentId, strings.ToLower(strings.TrimSpace(category.Name))}
+			names.categories[key] = category.Id
+		}
+	}
+	var parentId int64
+	for _, name := range strings.Split(path, CategoryPathSeparator) {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			return -1, fmt.Errorf("%w: category %s has an empty name", ErrInvalidRequest, path)
+		}
+		key := importCategoryKey{parentId, strings.ToLower(name)}
+		id, ok := names.categories[key]
+		if !ok {
+			request := CategoryCreationRequest{Name: &name}
+			if parentId != 0 {
+				parent := parentId
+				request.ParentId = &parent
+			}
+			var err error
+			if id, err = names.service.categoriesService.CreateCategory(request); err != nil {
+				return -1, err
+			}
+			names.categories[key] = id
+		}
+		parentId = id
+	}
+	return parentId, nil
+}
+
+type ImportProfilesDataAccessor interface {
+	GetImportProfile(id int64) (ImportProfile, error)
+	ListImportProfiles() ([]ImportProfile, error)
+	InsertImportProfile(request ImportProfileRequest) (int64, error)
+	UpdateImportProfile(id int64, request ImportProfileRequest) error
+	DeleteImportProfile(id int64) error
+}
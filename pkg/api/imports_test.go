@@ -0,0 +1,218 @@
+package api
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// importProfilesMock keeps profiles in memory and fails every call when err is set.
+type importProfilesMock struct {
+	profiles map[int64]ImportProfile
+	inserted *ImportProfileRequest
+	err      error
+}
+
+func (m *importProfilesMock) GetImportProfile(id int64) (ImportProfile, error) {
+	if m.err != nil {
+		return ImportProfile{}, m.err
+	}
+	profile, ok := m.profiles[id]
+	if !ok {
+		return ImportProfile{}, errors.New("sql: no rows in result set")
+	}
+	return profile, nil
+}
+
+func (m *importProfilesMock) ListImportProfiles() ([]ImportProfile, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	profiles := []ImportProfile{}
+	for _, profile := range m.profiles {
+		profiles = append(profiles, profile)
+	}
+	return profiles, nil
+}
+
+func (m *importProfilesMock) InsertImportProfile(request ImportProfileRequest) (int64, error) {
+	if m.err != nil {
+		return -1, m.err
+	}
+	m.inserted = &request
+	return 1, nil
+}
+
+func (m *importProfilesMock) UpdateImportProfile(id int64, request ImportProfileRequest) error {
+	return m.err
+}
+
+func (m *importProfilesMock) DeleteImportProfile(id int64) error {
+	return m.err
+}
+
+func TestImportService_GetImportProfile(t *testing.T) {
+
+	t.Run("testing happy flow of getting an import profile", func(t *testing.T) {
+		importService := NewImportService(&importProfilesMock{profiles: map[int64]ImportProfile{
+			2: {Id: 2, Name: "chase"}}}, nil, nil, nil, nil, nil)
+		profile, err := importService.GetImportProfile(2)
+		if err != nil || profile.Name != "chase" {
+			t.Errorf("unexpected profile %+v or error %v", profile, err)
+		}
+	})
+
+	t.Run("testing general error flow of getting an import profile", func(t *testing.T) {
+		importService := NewImportService(&importProfilesMock{err: errors.New("db timeout")}, nil, nil, nil, nil, nil)
+		_, err := importService.GetImportProfile(2)
+		expectedErrorMsg := "failed to retrieve import profile of id 2 with err:db timeout"
+		if err == nil || err.Error() != expectedErrorMsg {
+			t.Errorf("expected error message %s but found %v", expectedErrorMsg, err)
+		}
+	})
+}
+
+func TestImportService_CreateImportProfile(t *testing.T) {
+
+	name := "chase"
+	dateColumn := "Posting Date"
+	dateFormat := "01/02/2006"
+	amountColumn := "Amount"
+	debitColumn := "Debit"
+
+	t.Run("testing happy flow of creating an import profile with defaults", func(t *testing.T) {
+		dao := importProfilesMock{}
+		importService := NewImportService(&dao, nil, nil, nil, nil, nil)
+		id, err := importService.CreateImportProfile(ImportProfileRequest{Name: &name, DateColumn: &dateColumn,
+			DateFormat: &dateFormat, AmountColumn: &amountColumn})
+		if err != nil || id != 1 {
+			t.Errorf("unexpected id %d or error %v", id, err)
+			return
+		}
+		if *dao.inserted.Delimiter != "," || *dao.inserted.AmountSign != AmountSignNormal {
+			t.Errorf("unexpected defaults %s and %s", *dao.inserted.Delimiter, *dao.inserted.AmountSign)
+		}
+	})
+
+	t.Run("testing validation of missing amount columns", func(t *testing.T) {
+		importService := NewImportService(&importProfilesMock{}, nil, nil, nil, nil, nil)
+		_, err := importService.CreateImportProfile(ImportProfileRequest{Name: &name, DateColumn: &dateColumn,
+			DateFormat: &dateFormat})
+		if !errors.Is(err, ErrInvalidRequest) {
+			t.Errorf("expected invalid request error but found %v", err)
+		}
+	})
+
+	t.Run("testing validation of conflicting amount columns", func(t *testing.T) {
+		importService := NewImportService(&importProfilesMock{}, nil, nil, nil, nil, nil)
+		_, err := importService.CreateImportProfile(ImportProfileRequest{Name: &name, DateColumn: &dateColumn,
+			DateFormat: &dateFormat, AmountColumn: &amountColumn, DebitColumn: &debitColumn})
+		if !errors.Is(err, ErrInvalidRequest) {
+			t.Errorf("expected invalid request error but found %v", err)
+		}
+	})
+
+	t.Run("testing validation of delimiters", func(t *testing.T) {
+		importService := NewImportService(&importProfilesMock{}, nil, nil, nil, nil, nil)
+		delimiter := ";;"
+		_, err := importService.CreateImportProfile(ImportProfileRequest{Name: &name, DateColumn: &dateColumn,
+			DateFormat: &dateFormat, AmountColumn: &amountColumn, Delimiter: &delimiter})
+		expectedErrorMsg := "invalid request: delimiter has to be a single character"
+		if err == nil || err.Error() != expectedErrorMsg {
+			t.Errorf("expected error message %s but found %v", expectedErrorMsg, err)
+		}
+	})
+
+	t.Run("testing validation of amount sign conventions", func(t *testing.T) {
+		importService := NewImportService(&importProfilesMock{}, nil, nil, nil, nil, nil)
+		amountSign := "reversed"
+		_, err := importService.CreateImportProfile(ImportProfileRequest{Name: &name, DateColumn: &dateColumn,
+			DateFormat: &dateFormat, AmountColumn: &amountColumn, AmountSign: &amountSign})
+		if !errors.Is(err, ErrInvalidRequest) {
+			t.Errorf("expected invalid request error but found %v", err)
+		}
+	})
+}
+
+// failingImportTransactionsMock finds no duplicates but fails to write an import.
+type failingImportTransactionsMock struct {
+	happyTransactionsMock
+}
+
+func (m *failingImportTransactionsMock) ListTransactions(filter TransactionFilter) ([]Transaction, error) {
+	return nil, nil
+}
+
+func (m *failingImportTransactionsMock) ImportTransactions(batch TransactionImport) ([]int64, error) {
+	return nil, errors.New("db timeout")
+}
+
+func TestImportService_commitRows(t *testing.T) {
+
+	transactionTime := time.Date(2022, 7, 1, 0, 0, 0, 0, time.UTC)
+
+	t.Run("testing happy flow of committing rows", func(t *testing.T) {
+		dao := happyTransactionsMock{}
+		importService := NewImportService(&importProfilesMock{}, NewTransactionsService(&dao, nil, nil), nil, nil, nil,
+			nil)
+		result, err := importService.commitRows(3, []ImportRow{
+			{Line: 2, TransactionTime: &transactionTime, Description: "COFFEE", Amount: -450},
+			{Line: 3, TransactionTime: &transactionTime, Description: "PAYROLL", Amount: 100000},
+		}, nil)
+		if err != nil {
+			t.Errorf("unexpected error when committing rows: %v", err)
+			return
+		}
+		if result.Imported != 2 || len(result.TransactionIds) != 2 {
+			t.Errorf("expected 2 imported transactions but found %d", result.Imported)
+		}
+		if *dao.inserted.AccountId != 3 || *dao.inserted.Description != "PAYROLL" {
+			t.Errorf("unexpected transaction inserted %+v", dao.inserted)
+		}
+	})
+
+	t.Run("testing nothing is committed when a row is invalid", func(t *testing.T) {
+		dao := happyTransactionsMock{}
+		importService := NewImportService(&importProfilesMock{}, NewTransactionsService(&dao, nil, nil), nil, nil, nil,
+			nil)
+		_, err := importService.commitRows(3, []ImportRow{
+			{Line: 2, TransactionTime: &transactionTime, Amount: -450},
+			{Line: 3, Error: "amount \"abc\" is not a number"},
+		}, nil)
+		expectedErrorMsg := "invalid request: line 3 cannot be imported: amount \"abc\" is not a number"
+		if err == nil || err.Error() != expectedErrorMsg {
+			t.Errorf("expected error message %s but found %v", expectedErrorMsg, err)
+		}
+		if dao.inserted != nil {
+			t.Errorf("expected no transaction to be inserted")
+		}
+	})
+
+	t.Run("testing nothing is imported when the ledger rejects the batch", func(t *testing.T) {
+		dao := failingImportTransactionsMock{}
+		importService := NewImportService(&importProfilesMock{}, NewTransactionsService(&dao, nil, nil), nil, nil, nil,
+			nil)
+		result, err := importService.commitRows(3, []ImportRow{
+			{Line: 2, TransactionTime: &transactionTime, Description: "COFFEE", Amount: -450},
+			{Line: 3, TransactionTime: &transactionTime, Description: "PAYROLL", Amount: 100000},
+		}, nil)
+		expectedErrorMsg := "failed to import transactions with err:db timeout"
+		if err == nil || err.Error() != expectedErrorMsg {
+			t.Errorf("expected error message %s but found %v", expectedErrorMsg, err)
+		}
+		if result.Imported != 0 || len(result.TransactionIds) != 0 || dao.inserted != nil {
+			t.Errorf("expected nothing to be imported but found %+v", result)
+		}
+	})
+
+	t.Run("testing general error flow of committing rows", func(t *testing.T) {
+		importService := NewImportService(&importProfilesMock{},
+			NewTransactionsService(&errorTransactionsMock{}, nil, nil), nil, nil, nil, nil)
+		_, err := importService.commitRows(3, []ImportRow{{Line: 2, TransactionTime: &transactionTime, Amount: 100}},
+			nil)
+		expectedErrorMsg := "failed to list transactions with err:db timeout"
+		if err == nil || err.Error() != expectedErrorMsg {
+			t.Errorf("expected error message %s but found %v", expectedErrorMsg, err)
+		}
+	})
+}
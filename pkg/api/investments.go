@@ -0,0 +1,572 @@
+package api
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const InvestmentBuy = "buy"
+const InvestmentSell = "sell"
+const InvestmentDividend = "dividend"
+
+// CostBasisFIFO sells the oldest lots first, and CostBasisAverage sells every unit at the average cost of the units
+// held.
+const CostBasisFIFO = "fifo"
+const CostBasisAverage = "average"
+
+// quantityTolerance absorbs the rounding of fractional quantities, like the units of mutual funds.
+const quantityTolerance = 1e-9
+
+// investmentAccountTypes are the account types that can hold securities.
+var investmentAccountTypes = map[string]bool{
+	"investment":  true,
+	"investments": true,
+}
+
+// IsInvestmentAccountType tells whether accounts of the type can hold securities.
+func IsInvestmentAccountType(accountType string) bool {
+	return investmentAccountTypes[normalizeAccountType(accountType)]
+}
+
+// InvestmentTransaction buys or sells Quantity units of the security with the Symbol, or receives a dividend of it,
+// on TradeDate formatted as 2006-01-02. Amount is the cost of a buy, the proceeds of a sell or the dividend, before
+// the Fee, all in the currency of the account.
+type InvestmentTransaction struct {
+	Id        int64     `json:"id"`
+	AccountId int64     `json:"account_id"`
+	Symbol    string    `json:"symbol"`
+	Type      string    `json:"type"`
+	TradeDate string    `json:"trade_date"`
+	Quantity  float64   `json:"quantity"`
+	Amount    Money     `json:"amount"`
+	Fee       Money     `json:"fee"`
+	IsDeleted bool      `json:"is_deleted"`
+	CreatedTs time.Time `json:"created_ts"`
+	UpdatedTs time.Time `json:"updated_ts"`
+}
+
+// InvestmentTransactionRequest is used to both create and update an investment transaction. TradeDate defaults to
+// today and Fee to 0.
+type InvestmentTransactionRequest struct {
+	AccountId *int64   `json:"account_id"`
+	Symbol    *string  `json:"symbol"`
+	Type      *string  `json:"type"`
+	TradeDate *string  `json:"trade_date"`
+	Quantity  *float64 `json:"quantity"`
+	Amount    *Money   `json:"amount"`
+	Fee       *Money   `json:"fee"`
+}
+
+// InvestmentTransactionFilter narrows down ListInvestmentTransactions. Empty fields are not applied.
+type InvestmentTransactionFilter struct {
+	AccountId *int64
+	Symbol    string
+}
+
+// SecurityPrice is the price of one unit of the security on Date and the following days, until a later price.
+type SecurityPrice struct {
+	Id        int64     `json:"id"`
+	Symbol    string    `json:"symbol"`
+	Date      string    `json:"date"`
+	Price     float64   `json:"price"`
+	CreatedTs time.Time `json:"created_ts"`
+	UpdatedTs time.Time `json:"updated_ts"`
+}
+
+// SecurityPriceRequest creates a price, or replaces the price of the security on the same date.
+type SecurityPriceRequest struct {
+	Symbol *string  `json:"symbol"`
+	Date   *string  `json:"date"`
+	Price  *float64 `json:"price"`
+}
+
+// Lot is what is left of a buy. CostBasis includes the fee of the buy.
+type Lot struct {
+	AcquiredDate string  `json:"acquired_date"`
+	Quantity     float64 `json:"quantity"`
+	CostBasis    Money   `json:"cost_basis"`
+}
+
+// Holding is the position of an account in a security. MarketValue and UnrealizedGain are only present along with a
+// price, and Lots only with the FIFO method. Positions that have been sold off are kept for their realized gains.
+type Holding struct {
+	AccountId      int64    `json:"account_id"`
+	Symbol         string   `json:"symbol"`
+	Quantity       float64  `json:"quantity"`
+	CostBasis      Money    `json:"cost_basis"`
+	Price          *float64 `json:"price"`
+	PriceDate      string   `json:"price_date,omitempty"`
+	MarketValue    *Money   `json:"market_value"`
+	UnrealizedGain *Money   `json:"unrealized_gain"`
+	RealizedGain   Money    `json:"realized_gain"`
+	Dividends      Money    `json:"dividends"`
+	Lots           []Lot    `json:"lots,omitempty"`
+}
+
+// RealizedSale is the gain of a sell, which is its proceeds after fees minus the cost basis of the units sold.
+type RealizedSale struct {
+	AccountId int64   `json:"account_id"`
+	Symbol    string  `json:"symbol"`
+	Date      string  `json:"date"`
+	Quantity  float64 `json:"quantity"`
+	Proceeds  Money   `json:"proceeds"`
+	CostBasis Money   `json:"cost_basis"`
+	Gain      Money   `json:"gain"`
+}
+
+// Portfolio holds the holdings on AsOf with the cost basis of the Method. The market value and unrealized gain
+// totals leave out the Unpriced symbols, which have no price on or before AsOf.
+type Portfolio struct {
+	AsOf           string         `json:"as_of"`
+	Method         string         `json:"method"`
+	CostBasis      Money          `json:"cost_basis"`
+	MarketValue    Money          `json:"market_value"`
+	UnrealizedGain Money          `json:"unrealized_gain"`
+	RealizedGain   Money          `json:"realized_gain"`
+	Dividends      Money          `json:"dividends"`
+	Unpriced       []string       `json:"unpriced,omitempty"`
+	Holdings       []Holding      `json:"holdings"`
+	Sales          []RealizedSale `json:"sales"`
+}
+
+type InvestmentsService struct {
+	dao             InvestmentsDataAccessor
+	accountsService *AccountsService
+}
+
+func NewInvestmentsService(dao InvestmentsDataAccessor, accountsService *AccountsService) *InvestmentsService {
+	return &InvestmentsService{dao, accountsService}
+}
+
+func (service *InvestmentsService) GetInvestmentTransaction(id int64) (InvestmentTransaction, error) {
+	transaction, err := service.dao.GetInvestmentTransaction(id)
+	if err != nil {
+		return transaction, fmt.Errorf("failed to retrieve investment transaction of id %d with err:%v", id, err)
+	}
+	return transaction, nil
+}
+
+func (service *InvestmentsService) ListInvestmentTransactions(filter InvestmentTransactionFilter) (
+	[]InvestmentTransaction, error) {
+	if filter.Symbol != "" {
+		filter.Symbol = normalizeSymbol(filter.Symbol)
+	}
+	transactions, err := service.dao.ListInvestmentTransactions(filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list investment transactions with err:%v", err)
+	}
+	return transactions, nil
+}
+
+// CreateInvestmentTransaction refuses sells of more units than the account holds on the trade date.
+func (service *InvestmentsService) CreateInvestmentTransaction(request InvestmentTransactionRequest) (int64, error) {
+	request, err := service.validateInvestmentTransaction(request)
+	if err != nil {
+		return -1, err
+	}
+	if err = service.checkHoldings(*request.AccountId, *request.Symbol, 0, &request); err != nil {
+		return -1, err
+	}
+	return service.dao.InsertInvestmentTransaction(request)
+}
+
+// UpdateInvestmentTransaction refuses changes that leave a sell of more units than the account holds.
+func (service *InvestmentsService) UpdateInvestmentTransaction(id int64, request InvestmentTransactionRequest) error {
+	request, err := service.validateInvestmentTransaction(request)
+	if err != nil {
+		return err
+	}
+	existing, err := service.GetInvestmentTransaction(id)
+	if err != nil {
+		return err
+	}
+	// the position the transaction moves away from has to hold up without it
+	if existing.AccountId != *request.AccountId || existing.Symbol != *request.Symbol {
+		if err = service.checkHoldings(existing.AccountId, existing.Symbol, id, nil); err != nil {
+			return err
+		}
+	}
+	if err = service.checkHoldings(*request.AccountId, *request.Symbol, id, &request); err != nil {
+		return err
+	}
+	return service.dao.UpdateInvestmentTransaction(id, request)
+}
+
+// DeleteInvestmentTransaction refuses to delete a buy whose units have been sold since.
+func (service *InvestmentsService) DeleteInvestmentTransaction(id int64) error {
+	existing, err := service.GetInvestmentTransaction(id)
+	if err != nil {
+		return err
+	}
+	if err = service.checkHoldings(existing.AccountId, existing.Symbol, id, nil); err != nil {
+		return err
+	}
+	return service.dao.DeleteInvestmentTransaction(id)
+}
+
+// checkHoldings replays the transactions of the security in the account, with the request in place of the one with
+// the id, to make sure that no sell is left selling more units than are held. The id is 0 for new transactions.
+func (service *InvestmentsService) checkHoldings(accountId int64, symbol string, id int64,
+	request *InvestmentTransactionRequest) error {
+	transactions, err := service.ListInvestmentTransactions(InvestmentTransactionFilter{AccountId: &accountId,
+		Symbol: symbol})
+	if err != nil {
+		return err
+	}
+	replayed := make([]InvestmentTransaction, 0, len(transactions)+1)
+	for _, transaction := range transactions {
+		if transaction.Id != id {
+			replayed = append(replayed, transaction)
+		}
+	}
+	if request != nil {
+		// a new transaction goes last on its trade date, as it would once stored
+		requestId := id
+		if requestId == 0 {
+			requestId = math.MaxInt64
+		}
+		replayed = append(replayed, InvestmentTransaction{Id: requestId, AccountId: *request.AccountId,
+			Symbol: *request.Symbol, Type: *request.Type, TradeDate: *request.TradeDate,
+			Quantity: *request.Quantity, Amount: *request.Amount, Fee: *request.Fee})
+	}
+	_, _, err = replayInvestments(replayed, CostBasisFIFO)
+	return err
+}
+
+// GetPortfolio values the holdings at the end of asOf with the latest prices on or before it. accountId is optional
+// and method defaults to CostBasisFIFO.
+func (service *InvestmentsService) GetPortfolio(accountId *int64, method string, asOf time.Time) (Portfolio,
+	error) {
+	switch method {
+	case "":
+		method = CostBasisFIFO
+	case CostBasisFIFO, CostBasisAverage:
+	default:
+		return Portfolio{}, fmt.Errorf("%w: method has to be fifo or average instead of %s", ErrInvalidRequest,
+			method)
+	}
+	day := asOf.UTC().Format(ReportDateLayout)
+	transactions, err := service.ListInvestmentTransactions(InvestmentTransactionFilter{AccountId: accountId})
+	if err != nil {
+		return Portfolio{}, err
+	}
+	traded := make([]InvestmentTransaction, 0, len(transactions))
+	for _, transaction := range transactions {
+		if transaction.TradeDate <= day {
+			traded = append(traded, transaction)
+		}
+	}
+	holdings, sales, err := replayInvestments(traded, method)
+	if err != nil {
+		return Portfolio{}, err
+	}
+	prices, err := service.dao.ListSecurityPrices("")
+	if err != nil {
+		return Portfolio{}, fmt.Errorf("failed to list security prices with err:%v", err)
+	}
+	latestPrices := map[string]SecurityPrice{}
+	for _, price := range prices {
+		if price.Date <= day && price.Date >= latestPrices[price.Symbol].Date {
+			latestPrices[price.Symbol] = price
+		}
+	}
+
+	portfolio := Portfolio{AsOf: day, Method: method, Holdings: holdings, Sales: sales}
+	unpriced := map[string]bool{}
+	for i := range portfolio.Holdings {
+		holding := &portfolio.Holdings[i]
+		portfolio.CostBasis += holding.CostBasis
+		portfolio.RealizedGain += holding.RealizedGain
+		portfolio.Dividends += holding.Dividends
+		if price, ok := latestPrices[holding.Symbol]; ok {
+			marketValue := toMoney(holding.Quantity * price.Price * 100)
+			unrealizedGain := marketValue - holding.CostBasis
+			holding.Price, holding.PriceDate = &price.Price, price.Date
+			holding.MarketValue, holding.UnrealizedGain = &marketValue, &unrealizedGain
+		} else if holding.Quantity == 0 {
+			var zero Money
+			holding.MarketValue, holding.UnrealizedGain = &zero, &zero
+		} else {
+			unpriced[holding.Symbol] = true
+			continue
+		}
+		portfolio.MarketValue += *holding.MarketValue
+		portfolio.UnrealizedGain += *holding.UnrealizedGain
+	}
+	for symbol := range unpriced {
+		portfolio.Unpriced = append(portfolio.Unpriced, symbol)
+	}
+	sort.Strings(portfolio.Unpriced)
+	return portfolio, nil
+}
+
+func (service *InvestmentsService) ListSecurityPrices(symbol string) ([]SecurityPrice, error) {
+	if symbol != "" {
+		symbol = normalizeSymbol(symbol)
+	}
+	prices, err := service.dao.ListSecurityPrices(symbol)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list security prices with err:%v", err)
+	}
+	return prices, nil
+}
+
+func (service *InvestmentsService) SaveSecurityPrice(request SecurityPriceRequest) error {
+	request, err := validateSecurityPrice(request)
+	if err != nil {
+		return err
+	}
+	return service.dao.UpsertSecurityPrices([]SecurityPriceRequest{request})
+}
+
+// ImportSecurityPrices saves every price of a csv file with a header row naming the date, symbol and price columns,
+// in any order. Dates are formatted as 2006-01-02. Nothing is saved when a row is invalid.
+func (service *InvestmentsService) ImportSecurityPrices(file io.Reader) (int, error) {
+	reader := csv.NewReader(file)
+	reader.TrimLeadingSpace = true
+	header, err := reader.Read()
+	if err != nil {
+		return 0, fmt.Errorf("%w: price file has no header row", ErrInvalidRequest)
+	}
+	columns := map[string]int{}
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))] = i
+	}
+	for _, name := range []string{"date", "symbol", "price"} {
+		if _, ok := columns[name]; !ok {
+			return 0, fmt.Errorf("%w: price file has no %s column", ErrInvalidRequest, name)
+		}
+	}
+	requests := []SecurityPriceRequest{}
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return 0, fmt.Errorf("%w: line %d is not valid csv: %v", ErrInvalidRequest, line, err)
+		}
+		if isBlankRecord(record) {
+			continue
+		}
+		field := func(name string) *string {
+			value := ""
+			if columns[name] < len(record) {
+				value = strings.TrimSpace(record[columns[name]])
+			}
+			return &value
+		}
+		request := SecurityPriceRequest{Date: field("date"), Symbol: field("symbol")}
+		price, err := strconv.ParseFloat(*field("price"), 64)
+		if err != nil {
+			return 0, fmt.Errorf("%w: price %q of line %d is not a number", ErrInvalidRequest, *field("price"),
+				line)
+		}
+		request.Price = &price
+		if request, err = validateSecurityPrice(request); err != nil {
+			return 0, fmt.Errorf("%w (line %d)", err, line)
+		}
+		requests = append(requests, request)
+	}
+	if len(requests) == 0 {
+		return 0, nil
+	}
+	if err = service.dao.UpsertSecurityPrices(requests); err != nil {
+		return 0, err
+	}
+	return len(requests), nil
+}
+
+func (service *InvestmentsService) validateInvestmentTransaction(request InvestmentTransactionRequest) (
+	InvestmentTransactionRequest, error) {
+	if request.AccountId == nil {
+		return request, fmt.Errorf("%w: account_id is required", ErrInvalidRequest)
+	}
+	if isBlank(request.Symbol) {
+		return request, fmt.Errorf("%w: symbol is required", ErrInvalidRequest)
+	}
+	symbol := normalizeSymbol(*request.Symbol)
+	request.Symbol = &symbol
+	if request.Type == nil {
+		return request, fmt.Errorf("%w: type is required", ErrInvalidRequest)
+	}
+	transactionType := strings.ToLower(strings.TrimSpace(*request.Type))
+	switch transactionType {
+	case InvestmentBuy, InvestmentSell:
+		if request.Quantity == nil || *request.Quantity <= 0 {
+			return request, fmt.Errorf("%w: quantity has to be positive", ErrInvalidRequest)
+		}
+	case InvestmentDividend:
+		if request.Quantity != nil && *request.Quantity != 0 {
+			return request, fmt.Errorf("%w: dividends have no quantity", ErrInvalidRequest)
+		}
+		var quantity float64
+		request.Quantity = &quantity
+	default:
+		return request, fmt.Errorf("%w: type has to be buy, sell or dividend instead of %s", ErrInvalidRequest,
+			*request.Type)
+	}
+	request.Type = &transactionType
+	if isBlank(request.TradeDate) {
+		tradeDate := time.Now().UTC().Format(ReportDateLayout)
+		request.TradeDate = &tradeDate
+	} else if _, err := time.Parse(ReportDateLayout, *request.TradeDate); err != nil {
+		return request, fmt.Errorf("%w: trade_date %q has to be formatted as 2006-01-02", ErrInvalidRequest,
+			*request.TradeDate)
+	}
+	if request.Amount == nil || *request.Amount < 0 {
+		return request, fmt.Errorf("%w: amount is required and cannot be negative", ErrInvalidRequest)
+	}
+	if request.Fee == nil {
+		var fee Money
+		request.Fee = &fee
+	}
+	if *request.Fee < 0 {
+		return request, fmt.Errorf("%w: fee cannot be negative", ErrInvalidRequest)
+	}
+	account, err := service.accountsService.GetAccount(*request.AccountId)
+	if err != nil {
+		return request, err
+	}
+	if !IsInvestmentAccountType(account.AccountType) {
+		return request, fmt.Errorf("%w: account %d of type %s cannot hold securities", ErrInvalidRequest,
+			account.Id, account.AccountType)
+	}
+	return request, nil
+}
+
+func validateSecurityPrice(request SecurityPriceRequest) (SecurityPriceRequest, error) {
+	if isBlank(request.Symbol) {
+		return request, fmt.Errorf("%w: symbol is required", ErrInvalidRequest)
+	}
+	if isBlank(request.Date) {
+		return request, fmt.Errorf("%w: date is required", ErrInvalidRequest)
+	}
+	if _, err := time.Parse(ReportDateLayout, *request.Date); err != nil {
+		return request, fmt.Errorf("%w: date %q has to be formatted as 2006-01-02", ErrInvalidRequest,
+			*request.Date)
+	}
+	if request.Price == nil || *request.Price <= 0 {
+		return request, fmt.Errorf("%w: price has to be positive", ErrInvalidRequest)
+	}
+	symbol := normalizeSymbol(*request.Symbol)
+	request.Symbol = &symbol
+	return request, nil
+}
+
+func normalizeSymbol(symbol string) string {
+	return strings.ToUpper(strings.TrimSpace(symbol))
+}
+
+type positionKey struct {
+	accountId int64
+	symbol    string
+}
+
+// replayInvestments replays the transactions in the order of their trade dates into the holdings of every account
+// and security, ordered by account and symbol, along with the gains of every sell.
+func replayInvestments(transactions []InvestmentTransaction, method string) ([]Holding, []RealizedSale, error) {
+	sorted := make([]InvestmentTransaction, len(transactions))
+	copy(sorted, transactions)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].TradeDate != sorted[j].TradeDate {
+			return sorted[i].TradeDate < sorted[j].TradeDate
+		}
+		return sorted[i].Id < sorted[j].Id
+	})
+	positions := map[positionKey]*Holding{}
+	var keys []positionKey
+	sales := []RealizedSale{}
+	for _, transaction := range sorted {
+		key := positionKey{transaction.AccountId, transaction.Symbol}
+		holding, ok := positions[key]
+		if !ok {
+			holding = &Holding{AccountId: transaction.AccountId, Symbol: transaction.Symbol}
+			positions[key] = holding
+			keys = append(keys, key)
+		}
+		switch transaction.Type {
+		case InvestmentBuy:
+			cost := transaction.Amount + transaction.Fee
+			holding.Quantity += transaction.Quantity
+			holding.CostBasis += cost
+			if method == CostBasisFIFO {
+				holding.Lots = append(holding.Lots, Lot{AcquiredDate: transaction.TradeDate,
+					Quantity: transaction.Quantity, CostBasis: cost})
+			}
+		case InvestmentSell:
+			if transaction.Quantity > holding.Quantity+quantityTolerance {
+				return nil, nil, fmt.Errorf("%w: the sell of %g units of %s on %s exceeds the %g units held",
+					ErrInvalidRequest, transaction.Quantity, transaction.Symbol, transaction.TradeDate,
+					holding.Quantity)
+			}
+			var basis Money
+			if method == CostBasisFIFO {
+				basis, holding.Lots = sellLots(holding.Lots, transaction.Quantity)
+			} else {
+				basis = toMoney(float64(holding.CostBasis) * math.Min(1, transaction.Quantity/holding.Quantity))
+			}
+			holding.Quantity -= transaction.Quantity
+			holding.CostBasis -= basis
+			if holding.Quantity < quantityTolerance {
+				holding.Quantity, holding.CostBasis = 0, 0
+			}
+			proceeds := transaction.Amount - transaction.Fee
+			holding.RealizedGain += proceeds - basis
+			sales = append(sales, RealizedSale{AccountId: transaction.AccountId, Symbol: transaction.Symbol,
+				Date: transaction.TradeDate, Quantity: transaction.Quantity, Proceeds: proceeds, CostBasis: basis,
+				Gain: proceeds - basis})
+		case InvestmentDividend:
+			holding.Dividends += transaction.Amount - transaction.Fee
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].accountId != keys[j].accountId {
+			return keys[i].accountId < keys[j].accountId
+		}
+		return keys[i].symbol < keys[j].symbol
+	})
+	holdings := make([]Holding, len(keys))
+	for i, key := range keys {
+		holdings[i] = *positions[key]
+	}
+	return holdings, sales, nil
+}
+
+// sellLots sells the quantity out of the oldest lots first and returns the cost basis of the units sold along with
+// the lots left.
+func sellLots(lots []Lot, quantity float64) (Money, []Lot) {
+	var basis Money
+	for quantity > quantityTolerance && len(lots) > 0 {
+		lot := &lots[0]
+		if lot.Quantity <= quantity+quantityTolerance {
+			basis += lot.CostBasis
+			quantity -= lot.Quantity
+			lots = lots[1:]
+			continue
+		}
+		part := toMoney(float64(lot.CostBasis) * quantity / lot.Quantity)
+		basis += part
+		lot.CostBasis -= part
+		lot.Quantity -= quantity
+		quantity = 0
+	}
+	return basis, lots
+}
+
+type InvestmentsDataAccessor interface {
+	GetInvestmentTransaction(id int64) (InvestmentTransaction, error)
+	ListInvestmentTransactions(filter InvestmentTransactionFilter) ([]InvestmentTransaction, error)
+	InsertInvestmentTransaction(request InvestmentTransactionRequest) (int64, error)
+	UpdateInvestmentTransaction(id int64, request InvestmentTransactionRequest) error
+	DeleteInvestmentTransaction(id int64) error
+	ListSecurityPrices(symbol string) ([]SecurityPrice, error)
+	UpsertSecurityPrices(requests []SecurityPriceRequest) error
+}
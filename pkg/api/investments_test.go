@@ -0,0 +1,300 @@
+package api
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// investmentsMock holds ACME bought twice and partly sold in account 9, with a dividend, and units of a fund without
+// a price.
+type investmentsMock struct {
+	inserted *InvestmentTransactionRequest
+	updated  *InvestmentTransactionRequest
+	deleted  int64
+	upserted []SecurityPriceRequest
+	err      error
+}
+
+func (m *investmentsMock) investmentTransactions() []InvestmentTransaction {
+	return []InvestmentTransaction{
+		{Id: 1, AccountId: 9, Symbol: "ACME", Type: InvestmentBuy, TradeDate: "2022-01-10", Quantity: 10,
+			Amount: 100000, Fee: 500},
+		{Id: 3, AccountId: 9, Symbol: "ACME", Type: InvestmentSell, TradeDate: "2022-05-10", Quantity: 15,
+			Amount: 195000, Fee: 1000},
+		{Id: 2, AccountId: 9, Symbol: "ACME", Type: InvestmentBuy, TradeDate: "2022-03-10", Quantity: 10,
+			Amount: 140000, Fee: 500},
+		{Id: 4, AccountId: 9, Symbol: "ACME", Type: InvestmentDividend, TradeDate: "2022-06-01", Amount: 2500},
+		{Id: 5, AccountId: 9, Symbol: "FUND", Type: InvestmentBuy, TradeDate: "2022-02-01", Quantity: 12.345,
+			Amount: 50000},
+		{Id: 6, AccountId: 9, Symbol: "ACME", Type: InvestmentBuy, TradeDate: "2022-07-15", Quantity: 1,
+			Amount: 16000},
+	}
+}
+
+func (m *investmentsMock) GetInvestmentTransaction(id int64) (InvestmentTransaction, error) {
+	for _, transaction := range m.investmentTransactions() {
+		if transaction.Id == id {
+			return transaction, m.err
+		}
+	}
+	return InvestmentTransaction{}, errors.New("sql: no rows in result set")
+}
+
+func (m *investmentsMock) ListInvestmentTransactions(filter InvestmentTransactionFilter) ([]InvestmentTransaction,
+	error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	transactions := []InvestmentTransaction{}
+	for _, transaction := range m.investmentTransactions() {
+		if (filter.AccountId == nil || *filter.AccountId == transaction.AccountId) &&
+			(filter.Symbol == "" || filter.Symbol == transaction.Symbol) {
+			transactions = append(transactions, transaction)
+		}
+	}
+	return transactions, nil
+}
+
+func (m *investmentsMock) InsertInvestmentTransaction(request InvestmentTransactionRequest) (int64, error) {
+	m.inserted = &request
+	return 7, m.err
+}
+
+func (m *investmentsMock) UpdateInvestmentTransaction(id int64, request InvestmentTransactionRequest) error {
+	m.updated = &request
+	return m.err
+}
+
+func (m *investmentsMock) DeleteInvestmentTransaction(id int64) error {
+	m.deleted = id
+	return m.err
+}
+
+func (m *investmentsMock) ListSecurityPrices(symbol string) ([]SecurityPrice, error) {
+	return []SecurityPrice{
+		{Id: 1, Symbol: "ACME", Date: "2022-05-31", Price: 140},
+		{Id: 2, Symbol: "ACME", Date: "2022-06-30", Price: 150},
+		{Id: 3, Symbol: "ACME", Date: "2022-07-31", Price: 160},
+	}, m.err
+}
+
+func (m *investmentsMock) UpsertSecurityPrices(requests []SecurityPriceRequest) error {
+	m.upserted = requests
+	return m.err
+}
+
+// investmentAccountsMock makes account 9 an investment account.
+type investmentAccountsMock struct {
+	happyMock
+}
+
+func (m *investmentAccountsMock) GetAccount(id int64) (Account, error) {
+	account, err := m.happyMock.GetAccount(id)
+	if id == 9 {
+		account.Id, account.AccountType, account.AccountSubType = 9, "Investment", "Stocks"
+	}
+	return account, err
+}
+
+func TestInvestmentsService_GetPortfolio(t *testing.T) {
+
+	asOf := time.Date(2022, 7, 1, 12, 0, 0, 0, time.UTC)
+
+	t.Run("testing happy flow of a portfolio with fifo lots", func(t *testing.T) {
+		portfolio, err := NewInvestmentsService(&investmentsMock{}, nil).GetPortfolio(nil, "", asOf)
+		if err != nil {
+			t.Errorf("unexpected error when valuing the portfolio: %v", err)
+			return
+		}
+		if portfolio.Method != CostBasisFIFO || portfolio.CostBasis != 120250 || portfolio.MarketValue != 75000 ||
+			portfolio.UnrealizedGain != 4750 || portfolio.RealizedGain != 23250 || portfolio.Dividends != 2500 ||
+			len(portfolio.Unpriced) != 1 || portfolio.Unpriced[0] != "FUND" {
+			t.Errorf("unexpected totals %+v", portfolio)
+		}
+		acme := portfolio.Holdings[0]
+		if len(portfolio.Holdings) != 2 || acme.Symbol != "ACME" || acme.Quantity != 5 || acme.CostBasis != 70250 ||
+			*acme.Price != 150 || acme.PriceDate != "2022-06-30" || *acme.MarketValue != 75000 {
+			t.Errorf("unexpected holdings %+v", portfolio.Holdings)
+		}
+		if len(acme.Lots) != 1 || acme.Lots[0] != (Lot{AcquiredDate: "2022-03-10", Quantity: 5, CostBasis: 70250}) {
+			t.Errorf("unexpected lots %+v", acme.Lots)
+		}
+		expectedSale := RealizedSale{AccountId: 9, Symbol: "ACME", Date: "2022-05-10", Quantity: 15,
+			Proceeds: 194000, CostBasis: 170750, Gain: 23250}
+		if len(portfolio.Sales) != 1 || portfolio.Sales[0] != expectedSale {
+			t.Errorf("unexpected sales %+v", portfolio.Sales)
+		}
+		if fund := portfolio.Holdings[1]; fund.Quantity != 12.345 || fund.MarketValue != nil || fund.Price != nil {
+			t.Errorf("expected the fund to be unpriced but found %+v", fund)
+		}
+	})
+
+	t.Run("testing the average cost method", func(t *testing.T) {
+		accountId := int64(9)
+		portfolio, err := NewInvestmentsService(&investmentsMock{}, nil).GetPortfolio(&accountId,
+			CostBasisAverage, asOf)
+		if err != nil || portfolio.RealizedGain != 13250 || portfolio.Holdings[0].CostBasis != 60250 ||
+			*portfolio.Holdings[0].UnrealizedGain != 14750 || portfolio.Holdings[0].Lots != nil {
+			t.Errorf("unexpected portfolio %+v or error %v", portfolio, err)
+		}
+	})
+
+	t.Run("testing validation of the method", func(t *testing.T) {
+		_, err := NewInvestmentsService(&investmentsMock{}, nil).GetPortfolio(nil, "lifo", asOf)
+		if !errors.Is(err, ErrInvalidRequest) {
+			t.Errorf("expected invalid request error but found %v", err)
+		}
+	})
+
+	t.Run("testing general error flow of valuing a portfolio", func(t *testing.T) {
+		_, err := NewInvestmentsService(&investmentsMock{err: errors.New("db timeout")}, nil).GetPortfolio(nil, "",
+			asOf)
+		expectedErrorMsg := "failed to list investment transactions with err:db timeout"
+		if err == nil || err.Error() != expectedErrorMsg {
+			t.Errorf("expected error message %s but found %v", expectedErrorMsg, err)
+		}
+	})
+}
+
+func TestInvestmentsService_CreateInvestmentTransaction(t *testing.T) {
+
+	accountId, symbol, buy, sell := int64(9), " acme ", "Buy", InvestmentSell
+	tradeDate, quantity, amount := "2022-06-15", 2.0, Money(29000)
+	accountsService := NewAccountsService(&investmentAccountsMock{}, nil)
+
+	t.Run("testing happy flow of buying a security", func(t *testing.T) {
+		dao := investmentsMock{}
+		id, err := NewInvestmentsService(&dao, accountsService).CreateInvestmentTransaction(
+			InvestmentTransactionRequest{AccountId: &accountId, Symbol: &symbol, Type: &buy, TradeDate: &tradeDate,
+				Quantity: &quantity, Amount: &amount})
+		if err != nil || id != 7 || *dao.inserted.Symbol != "ACME" || *dao.inserted.Type != InvestmentBuy ||
+			*dao.inserted.Fee != 0 {
+			t.Errorf("unexpected id %d, request %+v or error %v", id, dao.inserted, err)
+		}
+	})
+
+	t.Run("testing sells cannot exceed the units held", func(t *testing.T) {
+		six, early := 6.0, "2022-01-09"
+		for _, request := range []InvestmentTransactionRequest{
+			{AccountId: &accountId, Symbol: &symbol, Type: &sell, TradeDate: &tradeDate, Quantity: &six,
+				Amount: &amount},
+			{AccountId: &accountId, Symbol: &symbol, Type: &sell, TradeDate: &early, Quantity: &quantity,
+				Amount: &amount},
+		} {
+			_, err := NewInvestmentsService(&investmentsMock{}, accountsService).CreateInvestmentTransaction(request)
+			if !errors.Is(err, ErrInvalidRequest) {
+				t.Errorf("expected invalid request error for %+v but found %v", request, err)
+			}
+		}
+	})
+
+	t.Run("testing validation of investment transactions", func(t *testing.T) {
+		savingsId, dividend, split, badDate := int64(1), InvestmentDividend, "split", "15/06/2022"
+		negative, zero := Money(-1), 0.0
+		for _, request := range []InvestmentTransactionRequest{
+			{Symbol: &symbol, Type: &buy, Quantity: &quantity, Amount: &amount},
+			{AccountId: &accountId, Type: &buy, Quantity: &quantity, Amount: &amount},
+			{AccountId: &accountId, Symbol: &symbol, Type: &split, Quantity: &quantity, Amount: &amount},
+			{AccountId: &accountId, Symbol: &symbol, Type: &buy, Quantity: &zero, Amount: &amount},
+			{AccountId: &accountId, Symbol: &symbol, Type: &dividend, Quantity: &quantity, Amount: &amount},
+			{AccountId: &accountId, Symbol: &symbol, Type: &buy, Quantity: &quantity, Amount: &negative},
+			{AccountId: &accountId, Symbol: &symbol, Type: &buy, Quantity: &quantity, Amount: &amount,
+				Fee: &negative},
+			{AccountId: &accountId, Symbol: &symbol, Type: &buy, Quantity: &quantity, Amount: &amount,
+				TradeDate: &badDate},
+			{AccountId: &savingsId, Symbol: &symbol, Type: &buy, Quantity: &quantity, Amount: &amount},
+		} {
+			_, err := NewInvestmentsService(&investmentsMock{}, accountsService).CreateInvestmentTransaction(request)
+			if !errors.Is(err, ErrInvalidRequest) {
+				t.Errorf("expected invalid request error for %+v but found %v", request, err)
+			}
+		}
+	})
+}
+
+func TestInvestmentsService_UpdateInvestmentTransaction(t *testing.T) {
+
+	accountId, symbol, buy, tradeDate := int64(9), "ACME", InvestmentBuy, "2022-03-10"
+	amount := Money(140000)
+	accountsService := NewAccountsService(&investmentAccountsMock{}, nil)
+
+	t.Run("testing happy flow of updating a buy", func(t *testing.T) {
+		dao := investmentsMock{}
+		quantity := 12.0
+		err := NewInvestmentsService(&dao, accountsService).UpdateInvestmentTransaction(2,
+			InvestmentTransactionRequest{AccountId: &accountId, Symbol: &symbol, Type: &buy, TradeDate: &tradeDate,
+				Quantity: &quantity, Amount: &amount})
+		if err != nil || *dao.updated.Quantity != 12 {
+			t.Errorf("unexpected request %+v or error %v", dao.updated, err)
+		}
+	})
+
+	t.Run("testing updates cannot leave a sell exceeding the units held", func(t *testing.T) {
+		quantity, other := 4.0, "OTHER"
+		for _, request := range []InvestmentTransactionRequest{
+			{AccountId: &accountId, Symbol: &symbol, Type: &buy, TradeDate: &tradeDate, Quantity: &quantity,
+				Amount: &amount},
+			{AccountId: &accountId, Symbol: &other, Type: &buy, TradeDate: &tradeDate, Quantity: &quantity,
+				Amount: &amount},
+		} {
+			dao := investmentsMock{}
+			err := NewInvestmentsService(&dao, accountsService).UpdateInvestmentTransaction(2, request)
+			if !errors.Is(err, ErrInvalidRequest) || dao.updated != nil {
+				t.Errorf("expected invalid request error for %+v but found %v", request, err)
+			}
+		}
+	})
+}
+
+func TestInvestmentsService_DeleteInvestmentTransaction(t *testing.T) {
+
+	t.Run("testing happy flow of deleting a dividend", func(t *testing.T) {
+		dao := investmentsMock{}
+		err := NewInvestmentsService(&dao, nil).DeleteInvestmentTransaction(4)
+		if err != nil || dao.deleted != 4 {
+			t.Errorf("unexpected deletion of %d or error %v", dao.deleted, err)
+		}
+	})
+
+	t.Run("testing buys that have been sold cannot be deleted", func(t *testing.T) {
+		dao := investmentsMock{}
+		err := NewInvestmentsService(&dao, nil).DeleteInvestmentTransaction(1)
+		if !errors.Is(err, ErrInvalidRequest) || dao.deleted != 0 {
+			t.Errorf("expected invalid request error but found %v", err)
+		}
+	})
+}
+
+func TestInvestmentsService_ImportSecurityPrices(t *testing.T) {
+
+	t.Run("testing happy flow of importing prices", func(t *testing.T) {
+		dao := investmentsMock{}
+		file := "Symbol,Date,Price\n acme ,2022-07-29,161.25\n\nFUND,2022-07-29,40.1234\n"
+		imported, err := NewInvestmentsService(&dao, nil).ImportSecurityPrices(strings.NewReader(file))
+		if err != nil || imported != 2 {
+			t.Errorf("unexpected number of prices %d or error %v", imported, err)
+			return
+		}
+		if *dao.upserted[0].Symbol != "ACME" || *dao.upserted[0].Price != 161.25 || *dao.upserted[1].Price != 40.1234 {
+			t.Errorf("unexpected prices %+v", dao.upserted)
+		}
+	})
+
+	t.Run("testing invalid price files", func(t *testing.T) {
+		for _, file := range []string{
+			"",
+			"symbol,date\nACME,2022-07-29\n",
+			"symbol,date,price\nACME,2022-07-29,abc\n",
+			"symbol,date,price\nACME,2022-07-29,-1\n",
+			"symbol,date,price\nACME,29/07/2022,161.25\n",
+		} {
+			dao := investmentsMock{}
+			_, err := NewInvestmentsService(&dao, nil).ImportSecurityPrices(strings.NewReader(file))
+			if !errors.Is(err, ErrInvalidRequest) || dao.upserted != nil {
+				t.Errorf("expected invalid request error for %q but found %v", file, err)
+			}
+		}
+	})
+}
@@ -0,0 +1,393 @@
+package api
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+const maxLoanTermMonths = 600
+
+const LoanPaymentPaid = "paid"
+const LoanPaymentPaidLate = "paid_late"
+const LoanPaymentPartial = "partial"
+const LoanPaymentOverdue = "overdue"
+const LoanPaymentUpcoming = "upcoming"
+
+// loanAccountTypes are the account types that can hold a loan.
+var loanAccountTypes = map[string]bool{
+	"loan":      true,
+	"loans":     true,
+	"mortgage":  true,
+	"mortgages": true,
+}
+
+// IsLoanAccountType tells whether accounts of the type, like "loan" or "mortgage", can hold a loan.
+func IsLoanAccountType(accountType string) bool {
+	return loanAccountTypes[normalizeAccountType(accountType)]
+}
+
+// Loan holds the terms of the loan of a loan or mortgage account. Principal is paid back with interest at AnnualRate,
+// like 0.045 for 4.5%, in TermMonths equal monthly payments. The first payment is due the month after StartDate on
+// PaymentDay, which falls on the last day of shorter months.
+type Loan struct {
+	Id         int64     `json:"id"`
+	AccountId  int64     `json:"account_id"`
+	Principal  Money     `json:"principal"`
+	AnnualRate float64   `json:"annual_rate"`
+	TermMonths int       `json:"term_months"`
+	StartDate  string    `json:"start_date"`
+	PaymentDay int       `json:"payment_day"`
+	IsDeleted  bool      `json:"is_deleted"`
+	CreatedTs  time.Time `json:"created_ts"`
+	UpdatedTs  time.Time `json:"updated_ts"`
+}
+
+// LoanRequest is used to both create and update a loan. PaymentDay defaults to the day of StartDate.
+type LoanRequest struct {
+	AccountId  *int64   `json:"account_id"`
+	Principal  *Money   `json:"principal"`
+	AnnualRate *float64 `json:"annual_rate"`
+	TermMonths *int     `json:"term_months"`
+	StartDate  *string  `json:"start_date"`
+	PaymentDay *int     `json:"payment_day"`
+}
+
+// AmortizationRow is a monthly payment of a loan. Payment is split into the Interest accrued over the month and the
+// Principal paid back, which includes any Prepayment. Balance is the principal left after the payment.
+type AmortizationRow struct {
+	Number     int    `json:"number"`
+	Date       string `json:"date"`
+	Payment    Money  `json:"payment"`
+	Principal  Money  `json:"principal"`
+	Interest   Money  `json:"interest"`
+	Prepayment Money  `json:"prepayment"`
+	Balance    Money  `json:"balance"`
+}
+
+// AmortizationSchedule lists the payments of a loan until it is paid off. The last payment is adjusted to clear the
+// balance left by rounding every payment to the cent.
+type AmortizationSchedule struct {
+	LoanId         int64             `json:"loan_id"`
+	MonthlyPayment Money             `json:"monthly_payment"`
+	TotalPaid      Money             `json:"total_paid"`
+	TotalInterest  Money             `json:"total_interest"`
+	PayoffDate     string            `json:"payoff_date"`
+	Payments       []AmortizationRow `json:"payments"`
+}
+
+// LoanPrepaymentRequest adds ExtraMonthly to every payment and LumpSum to the first payment due on or after
+// LumpSumDate, which defaults to the first payment of the loan.
+type LoanPrepaymentRequest struct {
+	ExtraMonthly *Money  `json:"extra_monthly"`
+	LumpSum      *Money  `json:"lump_sum"`
+	LumpSumDate  *string `json:"lump_sum_date"`
+}
+
+// LoanPrepaymentResult compares the schedule with prepayments to the schedule of the loan.
+type LoanPrepaymentResult struct {
+	BaselineInterest   Money                `json:"baseline_interest"`
+	BaselinePayoffDate string               `json:"baseline_payoff_date"`
+	Interest           Money                `json:"interest"`
+	PayoffDate         string               `json:"payoff_date"`
+	InterestSaved      Money                `json:"interest_saved"`
+	MonthsSaved        int                  `json:"months_saved"`
+	Schedule           AmortizationSchedule `json:"schedule"`
+}
+
+// LoanInstallment tracks a scheduled payment against the payments made into the loan account. Payments are applied
+// to the installments in order, and PaidDate is the day the installment was paid in full.
+type LoanInstallment struct {
+	Number    int    `json:"number"`
+	DueDate   string `json:"due_date"`
+	Scheduled Money  `json:"scheduled"`
+	Paid      Money  `json:"paid"`
+	PaidDate  string `json:"paid_date,omitempty"`
+	Status    string `json:"status"`
+}
+
+// LoanPayments compares the payments made until AsOf with the schedule. Difference is positive when the payments are
+// ahead of the schedule.
+type LoanPayments struct {
+	LoanId          int64             `json:"loan_id"`
+	AsOf            string            `json:"as_of"`
+	ScheduledToDate Money             `json:"scheduled_to_date"`
+	PaidToDate      Money             `json:"paid_to_date"`
+	Difference      Money             `json:"difference"`
+	Installments    []LoanInstallment `json:"installments"`
+}
+
+type LoansService struct {
+	dao                 LoansDataAccessor
+	accountsService     *AccountsService
+	transactionsService *TransactionsService
+}
+
+func NewLoansService(dao LoansDataAccessor, accountsService *AccountsService,
+	transactionsService *TransactionsService) *LoansService {
+	return &LoansService{dao, accountsService, transactionsService}
+}
+
+func (service *LoansService) GetLoan(id int64) (Loan, error) {
+	loan, err := service.dao.GetLoan(id)
+	if err != nil {
+		return loan, fmt.Errorf("failed to retrieve loan of id %d with err:%v", id, err)
+	}
+	return loan, nil
+}
+
+func (service *LoansService) ListLoans() ([]Loan, error) {
+	loans, err := service.dao.ListLoans()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list loans with err:%v", err)
+	}
+	return loans, nil
+}
+
+func (service *LoansService) CreateLoan(request LoanRequest) (int64, error) {
+	request, err := service.validateLoan(request)
+	if err != nil {
+		return -1, err
+	}
+	return service.dao.InsertLoan(request)
+}
+
+func (service *LoansService) UpdateLoan(id int64, request LoanRequest) error {
+	request, err := service.validateLoan(request)
+	if err != nil {
+		return err
+	}
+	return service.dao.UpdateLoan(id, request)
+}
+
+func (service *LoansService) DeleteLoan(id int64) error {
+	err := service.dao.DeleteLoan(id)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// GetAmortizationSchedule lists the payments of the loan from the first to the last.
+func (service *LoansService) GetAmortizationSchedule(id int64) (AmortizationSchedule, error) {
+	loan, err := service.GetLoan(id)
+	if err != nil {
+		return AmortizationSchedule{}, err
+	}
+	return amortize(loan, 0, 0, 0), nil
+}
+
+// SimulatePrepayment works out the schedule of the loan with prepayments and how much interest they save. Payments
+// stay the same, so prepayments pay the loan off sooner.
+func (service *LoansService) SimulatePrepayment(id int64, request LoanPrepaymentRequest) (LoanPrepaymentResult,
+	error) {
+	loan, err := service.GetLoan(id)
+	if err != nil {
+		return LoanPrepaymentResult{}, err
+	}
+	var extraMonthly, lumpSum Money
+	if request.ExtraMonthly != nil {
+		extraMonthly = *request.ExtraMonthly
+	}
+	if request.LumpSum != nil {
+		lumpSum = *request.LumpSum
+	}
+	if extraMonthly < 0 || lumpSum < 0 {
+		return LoanPrepaymentResult{}, fmt.Errorf("%w: extra_monthly and lump_sum cannot be negative",
+			ErrInvalidRequest)
+	}
+	baseline := amortize(loan, 0, 0, 0)
+	lumpSumNumber := 1
+	if !isBlank(request.LumpSumDate) {
+		if _, err = time.Parse(ReportDateLayout, *request.LumpSumDate); err != nil {
+			return LoanPrepaymentResult{}, fmt.Errorf("%w: lump_sum_date %q has to be formatted as 2006-01-02",
+				ErrInvalidRequest, *request.LumpSumDate)
+		}
+		lumpSumNumber = sort.Search(len(baseline.Payments), func(i int) bool {
+			return baseline.Payments[i].Date >= *request.LumpSumDate
+		}) + 1
+		if lumpSumNumber > len(baseline.Payments) {
+			return LoanPrepaymentResult{}, fmt.Errorf("%w: lump_sum_date is after the loan is paid off",
+				ErrInvalidRequest)
+		}
+	}
+	schedule := amortize(loan, extraMonthly, lumpSum, lumpSumNumber)
+	return LoanPrepaymentResult{BaselineInterest: baseline.TotalInterest, BaselinePayoffDate: baseline.PayoffDate,
+		Interest: schedule.TotalInterest, PayoffDate: schedule.PayoffDate,
+		InterestSaved: baseline.TotalInterest - schedule.TotalInterest,
+		MonthsSaved:   len(baseline.Payments) - len(schedule.Payments), Schedule: schedule}, nil
+}
+
+// GetLoanPayments tracks the payments into the loan account until the end of asOf against the schedule. Every
+// deposit into the account on or after the start of the loan counts as a payment.
+func (service *LoansService) GetLoanPayments(id int64, asOf time.Time) (LoanPayments, error) {
+	loan, err := service.GetLoan(id)
+	if err != nil {
+		return LoanPayments{}, err
+	}
+	start, err := time.Parse(ReportDateLayout, loan.StartDate)
+	if err != nil {
+		return LoanPayments{}, fmt.Errorf("failed to read start date %q of loan %d", loan.StartDate, loan.Id)
+	}
+	asOf = truncateToDay(asOf)
+	end := asOf.AddDate(0, 0, 1)
+	transactions, err := service.transactionsService.ListTransactions(TransactionFilter{AccountId: &loan.AccountId,
+		From: &start, To: &end})
+	if err != nil {
+		return LoanPayments{}, err
+	}
+	sort.SliceStable(transactions, func(i, j int) bool {
+		return transactions[i].TransactionTime.Before(transactions[j].TransactionTime)
+	})
+
+	today := asOf.Format(ReportDateLayout)
+	schedule := amortize(loan, 0, 0, 0)
+	payments := LoanPayments{LoanId: loan.Id, AsOf: today, Installments: make([]LoanInstallment,
+		len(schedule.Payments))}
+	for i, row := range schedule.Payments {
+		payments.Installments[i] = LoanInstallment{Number: row.Number, DueDate: row.Date, Scheduled: row.Payment}
+		if row.Date <= today {
+			payments.ScheduledToDate += row.Payment
+		}
+	}
+	next := 0
+	for _, transaction := range transactions {
+		if transaction.Amount <= 0 {
+			continue
+		}
+		payments.PaidToDate += transaction.Amount
+		for left := transaction.Amount; left > 0 && next < len(payments.Installments); {
+			installment := &payments.Installments[next]
+			applied := installment.Scheduled - installment.Paid
+			if applied > left {
+				applied = left
+			}
+			installment.Paid += applied
+			left -= applied
+			if installment.Paid == installment.Scheduled {
+				installment.PaidDate = transaction.TransactionTime.UTC().Format(ReportDateLayout)
+				next++
+			}
+		}
+	}
+	for i := range payments.Installments {
+		installment := &payments.Installments[i]
+		switch {
+		case installment.Paid == installment.Scheduled && installment.PaidDate <= installment.DueDate:
+			installment.Status = LoanPaymentPaid
+		case installment.Paid == installment.Scheduled:
+			installment.Status = LoanPaymentPaidLate
+		case installment.Paid > 0:
+			installment.Status = LoanPaymentPartial
+		case installment.DueDate < today:
+			installment.Status = LoanPaymentOverdue
+		default:
+			installment.Status = LoanPaymentUpcoming
+		}
+	}
+	payments.Difference = payments.PaidToDate - payments.ScheduledToDate
+	return payments, nil
+}
+
+func (service *LoansService) validateLoan(request LoanRequest) (LoanRequest, error) {
+	if request.AccountId == nil {
+		return request, fmt.Errorf("%w: account_id is required", ErrInvalidRequest)
+	}
+	if request.Principal == nil || *request.Principal <= 0 {
+		return request, fmt.Errorf("%w: principal is required and has to be positive", ErrInvalidRequest)
+	}
+	if request.AnnualRate == nil || *request.AnnualRate < 0 || *request.AnnualRate >= 1 {
+		return request, fmt.Errorf("%w: annual_rate is required and has to be between 0 and 1, like 0.045 for 4.5%%",
+			ErrInvalidRequest)
+	}
+	if request.TermMonths == nil || *request.TermMonths < 1 || *request.TermMonths > maxLoanTermMonths {
+		return request, fmt.Errorf("%w: term_months is required and has to be between 1 and %d", ErrInvalidRequest,
+			maxLoanTermMonths)
+	}
+	if isBlank(request.StartDate) {
+		return request, fmt.Errorf("%w: start_date is required", ErrInvalidRequest)
+	}
+	start, err := time.Parse(ReportDateLayout, *request.StartDate)
+	if err != nil {
+		return request, fmt.Errorf("%w: start_date %q has to be formatted as 2006-01-02", ErrInvalidRequest,
+			*request.StartDate)
+	}
+	if request.PaymentDay == nil {
+		paymentDay := start.Day()
+		request.PaymentDay = &paymentDay
+	}
+	if *request.PaymentDay < 1 || *request.PaymentDay > 31 {
+		return request, fmt.Errorf("%w: payment_day has to be between 1 and 31", ErrInvalidRequest)
+	}
+	account, err := service.accountsService.GetAccount(*request.AccountId)
+	if err != nil {
+		return request, err
+	}
+	if !IsLoanAccountType(account.AccountType) {
+		return request, fmt.Errorf("%w: account %d of type %s cannot hold a loan", ErrInvalidRequest, account.Id,
+			account.AccountType)
+	}
+	return request, nil
+}
+
+// amortize lists the payments of the loan, adding extraMonthly to every payment and lumpSum to the payment with the
+// lumpSumNumber.
+func amortize(loan Loan, extraMonthly Money, lumpSum Money, lumpSumNumber int) AmortizationSchedule {
+	rate := loan.AnnualRate / 12
+	payment := monthlyPayment(loan.Principal, rate, loan.TermMonths)
+	schedule := AmortizationSchedule{LoanId: loan.Id, MonthlyPayment: payment, Payments: []AmortizationRow{}}
+	start, err := time.Parse(ReportDateLayout, loan.StartDate)
+	if err != nil {
+		return schedule
+	}
+	balance := loan.Principal
+	for number := 1; balance > 0 && number <= loan.TermMonths; number++ {
+		interest := toMoney(float64(balance) * rate)
+		principal := payment - interest
+		prepayment := extraMonthly
+		if number == lumpSumNumber {
+			prepayment += lumpSum
+		}
+		if principal >= balance || number == loan.TermMonths {
+			principal, prepayment = balance, 0
+		} else if principal+prepayment > balance {
+			prepayment = balance - principal
+		}
+		balance -= principal + prepayment
+		row := AmortizationRow{Number: number, Date: loanDueDate(start, loan.PaymentDay, number).
+			Format(ReportDateLayout), Payment: principal + prepayment + interest, Principal: principal + prepayment,
+			Interest: interest, Prepayment: prepayment, Balance: balance}
+		schedule.Payments = append(schedule.Payments, row)
+		schedule.TotalPaid += row.Payment
+		schedule.TotalInterest += interest
+		schedule.PayoffDate = row.Date
+	}
+	return schedule
+}
+
+// monthlyPayment is the payment that pays the principal off in the number of months at the monthly rate, rounded up
+// to the cent so that the last payment is never the largest.
+func monthlyPayment(principal Money, rate float64, months int) Money {
+	if rate == 0 {
+		return Money(math.Ceil(float64(principal) / float64(months)))
+	}
+	return Money(math.Ceil(float64(principal) * rate / (1 - math.Pow(1+rate, -float64(months)))))
+}
+
+// loanDueDate is the day the payment with the number is due, falling on the last day of shorter months.
+func loanDueDate(start time.Time, paymentDay int, number int) time.Time {
+	lastDay := time.Date(start.Year(), start.Month()+time.Month(number)+1, 0, 0, 0, 0, 0, time.UTC)
+	if paymentDay > lastDay.Day() {
+		return lastDay
+	}
+	return time.Date(start.Year(), start.Month()+time.Month(number), paymentDay, 0, 0, 0, 0, time.UTC)
+}
+
+type LoansDataAccessor interface {
+	GetLoan(id int64) (Loan, error)
+	ListLoans() ([]Loan, error)
+	InsertLoan(request LoanRequest) (int64, error)
+	UpdateLoan(id int64, request LoanRequest) error
+	DeleteLoan(id int64) error
+}
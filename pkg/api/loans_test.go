@@ -0,0 +1,226 @@
+package api
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// loansMock holds a car loan of 10000.00 at 6% over a year, taken out at the end of January.
+type loansMock struct {
+	inserted *LoanRequest
+	err      error
+}
+
+func (m *loansMock) GetLoan(id int64) (Loan, error) {
+	if id != 1 {
+		return Loan{}, errors.New("sql: no rows in result set")
+	}
+	return Loan{Id: 1, AccountId: 7, Principal: 1000000, AnnualRate: 0.06, TermMonths: 12, StartDate: "2022-01-31",
+		PaymentDay: 31}, m.err
+}
+
+func (m *loansMock) ListLoans() ([]Loan, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	loan, _ := m.GetLoan(1)
+	return []Loan{loan}, nil
+}
+
+func (m *loansMock) InsertLoan(request LoanRequest) (int64, error) {
+	m.inserted = &request
+	return 2, m.err
+}
+
+func (m *loansMock) UpdateLoan(id int64, request LoanRequest) error {
+	return m.err
+}
+
+func (m *loansMock) DeleteLoan(id int64) error {
+	return m.err
+}
+
+// loanAccountsMock makes account 7 a mortgage.
+type loanAccountsMock struct {
+	happyMock
+}
+
+func (m *loanAccountsMock) GetAccount(id int64) (Account, error) {
+	account, err := m.happyMock.GetAccount(id)
+	if id == 7 {
+		account.Id, account.AccountType = 7, "Mortgage"
+	}
+	return account, err
+}
+
+// loanPaymentsMock pays the first installment on time, the second one late in two parts and part of the third one.
+type loanPaymentsMock struct {
+	happyTransactionsMock
+	filter TransactionFilter
+}
+
+func (m *loanPaymentsMock) ListTransactions(filter TransactionFilter) ([]Transaction, error) {
+	m.filter = filter
+	return []Transaction{
+		{Id: 5, TransactionTime: time.Date(2022, 4, 10, 9, 0, 0, 0, time.UTC), Amount: 1000},
+		{Id: 4, TransactionTime: time.Date(2022, 4, 2, 9, 0, 0, 0, time.UTC), Amount: 36067},
+		{Id: 3, TransactionTime: time.Date(2022, 3, 31, 18, 0, 0, 0, time.UTC), Amount: -5000},
+		{Id: 2, TransactionTime: time.Date(2022, 3, 31, 9, 0, 0, 0, time.UTC), Amount: 50000},
+		{Id: 1, TransactionTime: time.Date(2022, 2, 27, 9, 0, 0, 0, time.UTC), Amount: 86067},
+	}, nil
+}
+
+func TestLoansService_CreateLoan(t *testing.T) {
+
+	accountId, principal, rate, termMonths, startDate := int64(7), Money(30000000), 0.045, 360, "2022-07-15"
+	accountsService := NewAccountsService(&loanAccountsMock{}, nil)
+
+	t.Run("testing happy flow of creating a loan", func(t *testing.T) {
+		dao := loansMock{}
+		id, err := NewLoansService(&dao, accountsService, nil).CreateLoan(LoanRequest{AccountId: &accountId,
+			Principal: &principal, AnnualRate: &rate, TermMonths: &termMonths, StartDate: &startDate})
+		if err != nil || id != 2 || *dao.inserted.PaymentDay != 15 {
+			t.Errorf("unexpected id %d, request %+v or error %v", id, dao.inserted, err)
+		}
+	})
+
+	t.Run("testing validation of loans", func(t *testing.T) {
+		savingsId, zero, negativeRate, badDate, paymentDay := int64(1), 0, -0.01, "15/07/2022", 32
+		noPrincipal := Money(0)
+		for _, request := range []LoanRequest{
+			{Principal: &principal, AnnualRate: &rate, TermMonths: &termMonths, StartDate: &startDate},
+			{AccountId: &accountId, Principal: &noPrincipal, AnnualRate: &rate, TermMonths: &termMonths,
+				StartDate: &startDate},
+			{AccountId: &accountId, Principal: &principal, AnnualRate: &negativeRate, TermMonths: &termMonths,
+				StartDate: &startDate},
+			{AccountId: &accountId, Principal: &principal, AnnualRate: &rate, TermMonths: &zero,
+				StartDate: &startDate},
+			{AccountId: &accountId, Principal: &principal, AnnualRate: &rate, TermMonths: &termMonths},
+			{AccountId: &accountId, Principal: &principal, AnnualRate: &rate, TermMonths: &termMonths,
+				StartDate: &badDate},
+			{AccountId: &accountId, Principal: &principal, AnnualRate: &rate, TermMonths: &termMonths,
+				StartDate: &startDate, PaymentDay: &paymentDay},
+			{AccountId: &savingsId, Principal: &principal, AnnualRate: &rate, TermMonths: &termMonths,
+				StartDate: &startDate},
+		} {
+			_, err := NewLoansService(&loansMock{}, accountsService, nil).CreateLoan(request)
+			if !errors.Is(err, ErrInvalidRequest) {
+				t.Errorf("expected invalid request error for %+v but found %v", request, err)
+			}
+		}
+	})
+}
+
+func TestLoansService_GetAmortizationSchedule(t *testing.T) {
+
+	t.Run("testing happy flow of an amortization schedule", func(t *testing.T) {
+		schedule, err := NewLoansService(&loansMock{}, nil, nil).GetAmortizationSchedule(1)
+		if err != nil {
+			t.Errorf("unexpected error when amortizing the loan: %v", err)
+			return
+		}
+		if schedule.MonthlyPayment != 86067 || schedule.TotalInterest != 32796 || len(schedule.Payments) != 12 ||
+			schedule.PayoffDate != "2023-01-31" || schedule.TotalPaid != 1032796 {
+			t.Errorf("unexpected schedule %+v", schedule)
+		}
+		first := AmortizationRow{Number: 1, Date: "2022-02-28", Payment: 86067, Principal: 81067, Interest: 5000,
+			Balance: 918933}
+		last := AmortizationRow{Number: 12, Date: "2023-01-31", Payment: 86059, Principal: 85631, Interest: 428}
+		if schedule.Payments[0] != first || schedule.Payments[11] != last {
+			t.Errorf("unexpected payments %+v and %+v", schedule.Payments[0], schedule.Payments[11])
+		}
+	})
+
+	t.Run("testing a loan without interest", func(t *testing.T) {
+		schedule := amortize(Loan{Principal: 100000, TermMonths: 3, StartDate: "2022-01-01", PaymentDay: 1}, 0, 0, 0)
+		if schedule.MonthlyPayment != 33334 || schedule.TotalInterest != 0 || schedule.Payments[2].Payment != 33332 {
+			t.Errorf("unexpected schedule %+v", schedule)
+		}
+	})
+
+	t.Run("testing general error flow of amortizing", func(t *testing.T) {
+		_, err := NewLoansService(&loansMock{err: errors.New("db timeout")}, nil, nil).GetAmortizationSchedule(1)
+		expectedErrorMsg := "failed to retrieve loan of id 1 with err:db timeout"
+		if err == nil || err.Error() != expectedErrorMsg {
+			t.Errorf("expected error message %s but found %v", expectedErrorMsg, err)
+		}
+	})
+}
+
+func TestLoansService_SimulatePrepayment(t *testing.T) {
+
+	extraMonthly, lumpSum, lumpSumDate := Money(10000), Money(200000), "2022-04-01"
+
+	t.Run("testing happy flow of prepaying a loan", func(t *testing.T) {
+		result, err := NewLoansService(&loansMock{}, nil, nil).SimulatePrepayment(1, LoanPrepaymentRequest{
+			ExtraMonthly: &extraMonthly, LumpSum: &lumpSum, LumpSumDate: &lumpSumDate})
+		if err != nil {
+			t.Errorf("unexpected error when prepaying the loan: %v", err)
+			return
+		}
+		if result.BaselineInterest != 32796 || result.Interest != 22340 || result.InterestSaved != 10456 ||
+			result.MonthsSaved != 3 || result.PayoffDate != "2022-10-31" {
+			t.Errorf("unexpected result %+v", result)
+		}
+		lumpSumPayment := result.Schedule.Payments[2]
+		if lumpSumPayment.Date != "2022-04-30" || lumpSumPayment.Prepayment != 210000 ||
+			lumpSumPayment.Balance != 525431 {
+			t.Errorf("unexpected payment of the lump sum %+v", lumpSumPayment)
+		}
+	})
+
+	t.Run("testing validation of prepayments", func(t *testing.T) {
+		negative, lateDate := Money(-100), "2023-02-01"
+		for _, request := range []LoanPrepaymentRequest{
+			{ExtraMonthly: &negative},
+			{LumpSum: &lumpSum, LumpSumDate: &lateDate},
+		} {
+			_, err := NewLoansService(&loansMock{}, nil, nil).SimulatePrepayment(1, request)
+			if !errors.Is(err, ErrInvalidRequest) {
+				t.Errorf("expected invalid request error for %+v but found %v", request, err)
+			}
+		}
+	})
+}
+
+func TestLoansService_GetLoanPayments(t *testing.T) {
+
+	t.Run("testing happy flow of tracking payments", func(t *testing.T) {
+		ledger := loanPaymentsMock{}
+		loansService := NewLoansService(&loansMock{}, nil, NewTransactionsService(&ledger, nil, nil))
+		payments, err := loansService.GetLoanPayments(1, time.Date(2022, 5, 10, 20, 0, 0, 0, time.UTC))
+		if err != nil {
+			t.Errorf("unexpected error when tracking payments: %v", err)
+			return
+		}
+		if *ledger.filter.AccountId != 7 || !ledger.filter.To.Equal(time.Date(2022, 5, 11, 0, 0, 0, 0, time.UTC)) {
+			t.Errorf("unexpected filter %+v", ledger.filter)
+		}
+		if payments.ScheduledToDate != 258201 || payments.PaidToDate != 173134 || payments.Difference != -85067 {
+			t.Errorf("unexpected totals %+v", payments)
+		}
+		expected := []LoanInstallment{
+			{Number: 1, DueDate: "2022-02-28", Scheduled: 86067, Paid: 86067, PaidDate: "2022-02-27",
+				Status: LoanPaymentPaid},
+			{Number: 2, DueDate: "2022-03-31", Scheduled: 86067, Paid: 86067, PaidDate: "2022-04-02",
+				Status: LoanPaymentPaidLate},
+			{Number: 3, DueDate: "2022-04-30", Scheduled: 86067, Paid: 1000, Status: LoanPaymentPartial},
+			{Number: 4, DueDate: "2022-05-31", Scheduled: 86067, Status: LoanPaymentUpcoming},
+		}
+		for i, installment := range expected {
+			if payments.Installments[i] != installment {
+				t.Errorf("expected installment %+v but found %+v", installment, payments.Installments[i])
+			}
+		}
+	})
+
+	t.Run("testing overdue installments", func(t *testing.T) {
+		ledger := loanPaymentsMock{}
+		loansService := NewLoansService(&loansMock{}, nil, NewTransactionsService(&ledger, nil, nil))
+		payments, err := loansService.GetLoanPayments(1, time.Date(2022, 6, 1, 0, 0, 0, 0, time.UTC))
+		if err != nil || payments.Installments[3].Status != LoanPaymentOverdue {
+			t.Errorf("unexpected installments %+v or error %v", payments.Installments, err)
+		}
+	})
+}
@@ -0,0 +1,128 @@
+package api
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Money is an exact amount in minor units, the cents of the currency it is held in, so that sums and comparisons do
+// not drift the way floats do. It is written to json as a decimal number like -12.34 and stored as whole cents.
+// Every currency is assumed to have two decimal places, which NormalizeCurrency enforces.
+type Money int64
+
+// maxMoneyDigits bounds the whole digits of a parsed amount so that it fits in minor units without overflowing.
+const maxMoneyDigits = 16
+
+// ParseMoney reads a decimal amount like -1234.5 exactly. Amounts with more than two significant decimal places
+// are refused rather than rounded.
+func ParseMoney(value string) (Money, error) {
+	number := strings.TrimSpace(value)
+	negative := false
+	if strings.HasPrefix(number, "-") || strings.HasPrefix(number, "+") {
+		negative = number[0] == '-'
+		number = number[1:]
+	}
+	whole, fraction, _ := strings.Cut(number, ".")
+	if whole+fraction == "" || !isDigits(whole) || !isDigits(fraction) || len(whole) > maxMoneyDigits {
+		return 0, fmt.Errorf("amount %q is not a number", value)
+	}
+	if len(fraction) > 2 {
+		if strings.TrimRight(fraction[2:], "0") != "" {
+			return 0, fmt.Errorf("amount %q has more than two decimal places", value)
+		}
+		fraction = fraction[:2]
+	}
+	cents, err := strconv.ParseInt(whole+fraction+strings.Repeat("0", 2-len(fraction)), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("amount %q is not a number", value)
+	}
+	if negative {
+		cents = -cents
+	}
+	return Money(cents), nil
+}
+
+func isDigits(value string) bool {
+	return strings.IndexFunc(value, func(r rune) bool {
+		return r < '0' || r > '9'
+	}) < 0
+}
+
+// String formats the amount with two decimal places, like -12.30.
+func (m Money) String() string {
+	sign := ""
+	cents := int64(m)
+	if cents < 0 {
+		sign = "-"
+		cents = -cents
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, cents/100, cents%100)
+}
+
+// Abs is the amount without its sign.
+func (m Money) Abs() Money {
+	if m < 0 {
+		return -m
+	}
+	return m
+}
+
+// Multiply scales the amount by a factor like an exchange rate, rounding half away from zero to whole cents.
+func (m Money) Multiply(factor float64) Money {
+	return Money(math.Round(float64(m) * factor))
+}
+
+func (m Money) MarshalJSON() ([]byte, error) {
+	return []byte(m.String()), nil
+}
+
+// UnmarshalJSON accepts both json numbers and strings holding a decimal amount.
+func (m *Money) UnmarshalJSON(data []byte) error {
+	value := string(data)
+	if value == "null" {
+		return nil
+	}
+	if strings.HasPrefix(value, `"`) {
+		if err := json.Unmarshal(data, &value); err != nil {
+			return err
+		}
+	}
+	parsed, err := ParseMoney(value)
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
+
+// Scan reads whole cents from the database.
+func (m *Money) Scan(src interface{}) error {
+	switch value := src.(type) {
+	case nil:
+		*m = 0
+	case int64:
+		*m = Money(value)
+	case float64:
+		*m = Money(math.Round(value))
+	case []byte:
+		return m.Scan(string(value))
+	case string:
+		cents, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return fmt.Errorf("amount %q is not a number of cents", value)
+		}
+		*m = Money(math.Round(cents))
+	default:
+		return fmt.Errorf("cannot read amount of type %T", src)
+	}
+	return nil
+}
+
+// Value stores whole cents in the database.
+func (m Money) Value() (driver.Value, error) {
+	return int64(m), nil
+}
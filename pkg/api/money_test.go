@@ -0,0 +1,79 @@
+package api
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestParseMoney(t *testing.T) {
+
+	t.Run("testing happy flow of parsing amounts exactly", func(t *testing.T) {
+		for value, expected := range map[string]Money{
+			"0.1":       10,
+			"-1234.5":   -123450,
+			"+12":       1200,
+			" 7.10 ":    710,
+			".99":       99,
+			"-0.07":     -7,
+			"19.990000": 1999,
+		} {
+			amount, err := ParseMoney(value)
+			if err != nil || amount != expected {
+				t.Errorf("expected %q to be %d cents but found %d or error %v", value, expected, amount, err)
+			}
+		}
+	})
+
+	t.Run("testing amounts that are not exact are refused", func(t *testing.T) {
+		for _, value := range []string{"", "-", ".", "1.2.3", "12a", "--5", "1e3", "0.001", "12345678901234567"} {
+			if _, err := ParseMoney(value); err == nil {
+				t.Errorf("expected an error when parsing %q", value)
+			}
+		}
+	})
+}
+
+func TestMoney_JSON(t *testing.T) {
+
+	t.Run("testing amounts are written as decimal numbers", func(t *testing.T) {
+		data, err := json.Marshal(map[string]Money{"amount": -5, "balance": 123450})
+		if err != nil || string(data) != `{"amount":-0.05,"balance":1234.50}` {
+			t.Errorf("unexpected json %s or error %v", data, err)
+		}
+	})
+
+	t.Run("testing amounts are read from numbers and strings", func(t *testing.T) {
+		request := struct {
+			Amount  *Money `json:"amount"`
+			Balance Money  `json:"balance"`
+			Missing *Money `json:"missing"`
+		}{}
+		err := json.Unmarshal([]byte(`{"amount": 0.3, "balance": "-20.10", "missing": null}`), &request)
+		if err != nil || *request.Amount != 30 || request.Balance != -2010 || request.Missing != nil {
+			t.Errorf("unexpected request %+v or error %v", request, err)
+		}
+		if err = json.Unmarshal([]byte(`{"amount": 0.333}`), &request); err == nil {
+			t.Errorf("expected an error when reading fractions of cents")
+		}
+	})
+}
+
+func TestMoney_Scan(t *testing.T) {
+
+	for _, src := range []interface{}{int64(1999), 1999.0, []byte("1999"), "1999"} {
+		var amount Money
+		if err := amount.Scan(src); err != nil || amount != 1999 {
+			t.Errorf("expected %v to be read as 1999 cents but found %d or error %v", src, amount, err)
+		}
+	}
+}
+
+func TestMoney_Multiply(t *testing.T) {
+
+	if amount := Money(1001).Multiply(1.2); amount != 1201 {
+		t.Errorf("expected 10.01 times 1.2 to round to 12.01 but found %s", amount)
+	}
+	if amount := Money(-5).Multiply(0.5); amount != -3 {
+		t.Errorf("expected halves to round away from zero but found %s", amount)
+	}
+}
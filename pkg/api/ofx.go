@@ -0,0 +1,452 @@
+package api
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// OFXAccount is the account information carried by an ofx statement. AccountId is the account number used by the
+// bank and is matched against the external account id of accounts.
+type OFXAccount struct {
+	BankId                 string `json:"bank_id"`
+	AccountId              string `json:"account_id"`
+	AccountType            string `json:"account_type"`
+	Organization           string `json:"organization"`
+	FinancialInstitutionId string `json:"financial_institution_id"`
+}
+
+// OFXStatement is a parsed ofx or qfx statement. Currency is the upper cased CURDEF of the statement, and LedgerBalance
+// is nil when the statement does not report one.
+type OFXStatement struct {
+	Account       OFXAccount
+	Currency      string
+	LedgerBalance *Money
+	Rows          []ImportRow
+}
+
+// OFXPreview shows how a statement would be imported. MatchedAccountId is the account already linked to the account
+// of the statement, and SuggestedOrgId the organization a new account would be created in, when one matches the
+// financial institution of the statement.
+type OFXPreview struct {
+	Account          OFXAccount `json:"account"`
+	MatchedAccountId *int64     `json:"matched_account_id"`
+	SuggestedOrgId   *int64     `json:"suggested_org_id"`
+	ImportPreview
+}
+
+// OFXImportOptions choose the account a statement is imported into. An explicit AccountId is linked to the account of
+// the statement so later statements are matched automatically. Otherwise the linked account is used, and when there
+// is none a new account is created only if CreateAccount is set. New accounts are created in OrgId, or else in the
+// organization named after the financial institution of the statement. Decisions handle the rows that duplicate
+// existing transactions.
+type OFXImportOptions struct {
+	AccountId     *int64
+	CreateAccount bool
+	AccountName   *string
+	OrgId         *int64
+	Decisions     []DuplicateDecision
+}
+
+// ofxAccountTypes maps the ACCTTYPE of bank statements onto account types. Credit card statements have no ACCTTYPE.
+var ofxAccountTypes = map[string]string{
+	"CHECKING":   "checking",
+	"SAVINGS":    "savings",
+	"MONEYMRKT":  "savings",
+	"CREDITLINE": "credit card",
+	"CD":         "investment",
+}
+
+const ofxCreditCardAccountType = "credit card"
+
+// PreviewOFX parses an ofx or qfx statement without importing it, flagging the transactions that duplicate the ones of
+// the matched account.
+func (service *ImportService) PreviewOFX(statement io.Reader) (OFXPreview, error) {
+	parsed, err := ParseOFX(statement)
+	if err != nil {
+		return OFXPreview{}, err
+	}
+	preview := OFXPreview{Account: parsed.Account}
+	account, found, err := service.accountsService.FindAccountByExternalId(parsed.Account.AccountId)
+	if err != nil {
+		return OFXPreview{}, err
+	}
+	if found {
+		preview.MatchedAccountId = &account.Id
+		if err = service.detectDuplicates(account.Id, parsed.Rows); err != nil {
+			return OFXPreview{}, err
+		}
+	} else {
+		orgId, found, err := service.findOrganization(parsed.Account.Organization)
+		if err != nil {
+			return OFXPreview{}, err
+		}
+		if found {
+			preview.SuggestedOrgId = &orgId
+		}
+	}
+	preview.ImportPreview = previewRows(parsed.Rows)
+	return preview, nil
+}
+
+// ImportOFX parses an ofx or qfx statement and imports its transactions, skipping the ones that have been imported
+// before unless decided otherwise. A chosen account is only linked to the statement once its transactions are in.
+// The statement has to be in the currency of the account, which new accounts are created in.
+func (service *ImportService) ImportOFX(statement io.Reader, options OFXImportOptions) (ImportResult, error) {
+	parsed, err := ParseOFX(statement)
+	if err != nil {
+		return ImportResult{}, err
+	}
+	if parsed.Currency != "" {
+		if parsed.Currency, err = NormalizeCurrency(parsed.Currency); err != nil {
+			return ImportResult{}, err
+		}
+	}
+	accountId, err := service.resolveOFXAccount(parsed, options)
+	if err != nil {
+		return ImportResult{}, err
+	}
+	result, err := service.commitRows(accountId, parsed.Rows, options.Decisions)
+	if err != nil {
+		return ImportResult{}, err
+	}
+	if options.AccountId != nil {
+		err = service.accountsService.LinkExternalAccount(accountId, parsed.Account.AccountId)
+		if err != nil {
+			return ImportResult{}, err
+		}
+	}
+	return result, nil
+}
+
+// resolveOFXAccount returns the account the statement is imported into, creating it when requested.
+func (service *ImportService) resolveOFXAccount(statement OFXStatement, options OFXImportOptions) (int64, error) {
+	if options.AccountId != nil {
+		account, err := service.accountsService.GetAccount(*options.AccountId)
+		if err != nil {
+			return -1, err
+		}
+		return *options.AccountId, checkOFXCurrency(statement, *options.AccountId, account)
+	}
+	account, found, err := service.accountsService.FindAccountByExternalId(statement.Account.AccountId)
+	if err != nil {
+		return -1, err
+	}
+	if found {
+		return account.Id, checkOFXCurrency(statement, account.Id, account)
+	}
+	if !options.CreateAccount {
+		return -1, fmt.Errorf("%w: no account is linked to account %s of the statement, either choose an account "+
+			"or create one", ErrInvalidRequest, statement.Account.AccountId)
+	}
+	return service.createOFXAccount(statement, options)
+}
+
+// checkOFXCurrency refuses statements in another currency than the account, as their amounts would not be converted.
+func checkOFXCurrency(statement OFXStatement, accountId int64, account Account) error {
+	if statement.Currency != "" && statement.Currency != accountCurrency(account) {
+		return fmt.Errorf("%w: the statement is in %s but account %d is in %s", ErrInvalidRequest,
+			statement.Currency, accountId, accountCurrency(account))
+	}
+	return nil
+}
+
+func (service *ImportService) createOFXAccount(statement OFXStatement, options OFXImportOptions) (int64, error) {
+	orgId, err := service.resolveOFXOrganization(statement.Account.Organization, options.OrgId)
+	if err != nil {
+		return -1, err
+	}
+	name := statement.Account.Organization + " " + statement.Account.AccountId
+	if !isBlank(options.AccountName) {
+		name = *options.AccountName
+	}
+	accountType := statement.Account.AccountType
+	request := AccountCreationRequest{
+		Name:              &name,
+		AccountType:       &accountType,
+		OrgId:             &orgId,
+		ExternalAccountId: &statement.Account.AccountId,
+	}
+	if statement.Currency != "" {
+		request.Currency = &statement.Currency
+	}
+	// the opening balance is whatever part of the ledger balance the transactions of the statement do not account for
+	if statement.LedgerBalance != nil {
+		openingBalance := *statement.LedgerBalance
+		for _, row := range statement.Rows {
+			openingBalance -= row.Amount
+		}
+		request.OpeningBalance = &openingBalance
+	}
+	id, err := service.accountsService.CreateAccount(request)
+	if err != nil {
+		return -1, fmt.Errorf("failed to create account for statement with err:%v", err)
+	}
+	return id, nil
+}
+
+// resolveOFXOrganization returns orgId when given, or else the organization named after the financial institution,
+// creating it when it does not exist yet.
+func (service *ImportService) resolveOFXOrganization(organization string, orgId *int64) (int64, error) {
+	if orgId != nil {
+		if _, err := service.organizationsService.GetOrganization(*orgId); err != nil {
+			return -1, err
+		}
+		return *orgId, nil
+	}
+	if organization == "" {
+		return -1, fmt.Errorf("%w: the statement does not name its financial institution, an org_id is required",
+			ErrInvalidRequest)
+	}
+	id, found, err := service.findOrganization(organization)
+	if err != nil {
+		return -1, err
+	}
+	if found {
+		return id, nil
+	}
+	return service.organizationsService.CreateOrganization(OrganizationCreationRequest{Name: &organization})
+}
+
+// findOrganization looks up an organization by name, ignoring case.
+func (service *ImportService) findOrganization(name string) (int64, bool, error) {
+	if name == "" {
+		return -1, false, nil
+	}
+	organizations, err := service.organizationsService.ListOrganizations()
+	if err != nil {
+		return -1, false, err
+	}
+	for _, organization := range organizations {
+		if strings.EqualFold(strings.TrimSpace(organization.Name), name) {
+			return organization.Id, true, nil
+		}
+	}
+	return -1, false, nil
+}
+
+// ofxElement is either the opening tag of an aggregate, the closing tag of an aggregate or an element with a value.
+// ofx 1.x is sgml and leaves elements with values unclosed, while ofx 2.x is xml, so both are read the same way by
+// ignoring the closing tags of elements with values.
+type ofxElement struct {
+	name    string
+	value   string
+	closing bool
+	line    int
+}
+
+// ParseOFX reads the transactions and account information of an ofx or qfx statement. Errors in individual
+// transactions are reported on the rows themselves, while a statement that cannot be read fails as a whole.
+func ParseOFX(statement io.Reader) (OFXStatement, error) {
+	content, err := io.ReadAll(statement)
+	if err != nil {
+		return OFXStatement{}, fmt.Errorf("failed to read statement with err:%v", err)
+	}
+	elements, err := tokenizeOFX(string(content))
+	if err != nil {
+		return OFXStatement{}, err
+	}
+
+	parsed := OFXStatement{Rows: []ImportRow{}}
+	var aggregates []string
+	var fields map[string]string
+	line := 0
+	for _, element := range elements {
+		if element.closing {
+			for i := len(aggregates) - 1; i >= 0; i-- {
+				if aggregates[i] != element.name {
+					continue
+				}
+				// closing an aggregate also closes any aggregate left open inside it
+				for _, closed := range aggregates[i:] {
+					if closed == "STMTTRN" && fields != nil {
+						parsed.Rows = append(parsed.Rows, parseOFXTransaction(line, fields))
+						fields = nil
+					}
+				}
+				aggregates = aggregates[:i]
+				break
+			}
+			continue
+		}
+		if element.value == "" {
+			aggregates = append(aggregates, element.name)
+			switch element.name {
+			case "STMTTRN":
+				fields = map[string]string{}
+				line = element.line
+			case "CCSTMTRS":
+				parsed.Account.AccountType = ofxCreditCardAccountType
+			}
+			continue
+		}
+		parent := ""
+		if len(aggregates) > 0 {
+			parent = aggregates[len(aggregates)-1]
+		}
+		switch parent {
+		case "STMTTRN":
+			fields[element.name] = element.value
+		case "BANKACCTFROM", "CCACCTFROM":
+			switch element.name {
+			case "BANKID":
+				parsed.Account.BankId = element.value
+			case "ACCTID":
+				if parsed.Account.AccountId != "" && parsed.Account.AccountId != element.value {
+					return OFXStatement{}, fmt.Errorf("%w: statement covers more than one account",
+						ErrInvalidRequest)
+				}
+				parsed.Account.AccountId = element.value
+			case "ACCTTYPE":
+				accountType, ok := ofxAccountTypes[strings.ToUpper(element.value)]
+				if !ok {
+					accountType = strings.ToLower(element.value)
+				}
+				parsed.Account.AccountType = accountType
+			}
+		case "FI":
+			switch element.name {
+			case "ORG":
+				parsed.Account.Organization = element.value
+			case "FID":
+				parsed.Account.FinancialInstitutionId = element.value
+			}
+		case "STMTRS", "CCSTMTRS":
+			if element.name == "CURDEF" {
+				parsed.Currency = strings.ToUpper(element.value)
+			}
+		case "LEDGERBAL":
+			if element.name == "BALAMT" {
+				if balance, err := parseOFXAmount(element.value); err == nil {
+					parsed.LedgerBalance = &balance
+				}
+			}
+		}
+	}
+	if parsed.Account.AccountId == "" {
+		return OFXStatement{}, fmt.Errorf("%w: statement has no account information", ErrInvalidRequest)
+	}
+	for i := range parsed.Rows {
+		if parsed.Rows[i].Error == "" {
+			parsed.Rows[i].Currency = parsed.Currency
+		}
+	}
+	return parsed, nil
+}
+
+// tokenizeOFX skips the headers of the statement and splits the body into elements.
+func tokenizeOFX(content string) ([]ofxElement, error) {
+	start := strings.Index(strings.ToUpper(content), "<OFX>")
+	if start < 0 {
+		return nil, fmt.Errorf("%w: statement is not an ofx file", ErrInvalidRequest)
+	}
+	line := strings.Count(content[:start], "\n") + 1
+	content = content[start:]
+
+	var elements []ofxElement
+	for {
+		open := strings.IndexByte(content, '<')
+		if open < 0 {
+			break
+		}
+		line += strings.Count(content[:open], "\n")
+		content = content[open:]
+		end := strings.IndexByte(content, '>')
+		if end < 0 {
+			return nil, fmt.Errorf("%w: tag on line %d is not terminated", ErrInvalidRequest, line)
+		}
+		name := strings.ToUpper(strings.TrimSpace(content[1:end]))
+		content = content[end+1:]
+		next := strings.IndexByte(content, '<')
+		if next < 0 {
+			next = len(content)
+		}
+		value := strings.TrimSpace(html.UnescapeString(content[:next]))
+		tagLine := line
+		line += strings.Count(content[:next], "\n")
+		content = content[next:]
+		// processing instructions and comments of xml statements
+		if strings.HasPrefix(name, "?") || strings.HasPrefix(name, "!") {
+			continue
+		}
+		if strings.HasPrefix(name, "/") {
+			elements = append(elements, ofxElement{name: strings.TrimPrefix(name, "/"), closing: true, line: tagLine})
+			continue
+		}
+		elements = append(elements, ofxElement{name: name, value: value, line: tagLine})
+	}
+	return elements, nil
+}
+
+// parseOFXTransaction maps the fields of a STMTTRN aggregate onto a row. NAME is used as the description and falls
+// back to MEMO.
+func parseOFXTransaction(line int, fields map[string]string) ImportRow {
+	row := ImportRow{Line: line, ExternalId: fields["FITID"]}
+	row.Description = fields["NAME"]
+	row.Memo = fields["MEMO"]
+	if row.Description == "" {
+		row.Description, row.Memo = row.Memo, ""
+	}
+	transactionTime, err := parseOFXDate(fields["DTPOSTED"])
+	if err != nil {
+		row.Error = err.Error()
+		return row
+	}
+	row.TransactionTime = &transactionTime
+	amount, err := parseOFXAmount(fields["TRNAMT"])
+	if err != nil {
+		row.Error = err.Error()
+		return row
+	}
+	row.Amount = amount
+	return row
+}
+
+func parseOFXAmount(value string) (Money, error) {
+	if value == "" {
+		return 0, fmt.Errorf("amount is missing")
+	}
+	// some institutions use a comma as the decimal separator
+	if !strings.Contains(value, ".") {
+		value = strings.Replace(value, ",", ".", 1)
+	}
+	return ParseMoney(value)
+}
+
+// parseOFXDate reads dates of the form YYYYMMDD[HHMMSS[.XXX]][[offset:TZ]] where offset is in hours from UTC. Dates
+// without an offset are in UTC.
+func parseOFXDate(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, fmt.Errorf("date is missing")
+	}
+	location := time.UTC
+	date := value
+	if open := strings.IndexByte(value, '['); open >= 0 {
+		date = value[:open]
+		zone := strings.TrimSuffix(value[open+1:], "]")
+		if colon := strings.IndexByte(zone, ':'); colon >= 0 {
+			zone = zone[:colon]
+		}
+		offset, err := strconv.ParseFloat(zone, 64)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("date %q has an invalid time zone", value)
+		}
+		location = time.FixedZone(zone, int(offset*3600))
+	}
+	if dot := strings.IndexByte(date, '.'); dot >= 0 {
+		date = date[:dot]
+	}
+	layouts := map[int]string{8: "20060102", 12: "200601021504", 14: "20060102150405"}
+	layout, ok := layouts[len(date)]
+	if !ok {
+		return time.Time{}, fmt.Errorf("date %q is not an ofx date", value)
+	}
+	parsed, err := time.ParseInLocation(layout, date, location)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("date %q is not an ofx date", value)
+	}
+	return parsed.UTC(), nil
+}
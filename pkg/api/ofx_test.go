@@ -0,0 +1,379 @@
+package api
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+const sgmlStatement = `OFXHEADER:100
+DATA:OFXSGML
+VERSION:102
+ENCODING:USASCII
+
+<OFX>
+<SIGNONMSGSRSV1><SONRS>
+<STATUS><CODE>0<SEVERITY>INFO</STATUS>
+<DTSERVER>20220705120000
+<FI><ORG>Bank &amp; Trust<FID>1001</FI>
+</SONRS></SIGNONMSGSRSV1>
+<BANKMSGSRSV1><STMTTRNRS><STMTRS>
+<CURDEF>USD
+<BANKACCTFROM><BANKID>121000248<ACCTID>000123<ACCTTYPE>CHECKING</BANKACCTFROM>
+<BANKTRANLIST>
+<DTSTART>20220701<DTEND>20220705
+<STMTTRN>
+<TRNTYPE>DEBIT
+<DTPOSTED>20220701120000.000[-5:EST]
+<TRNAMT>-4.50
+<FITID>FIT-1
+<NAME>COFFEE SHOP
+<MEMO>card 1234
+</STMTTRN>
+<STMTTRN>
+<TRNTYPE>CREDIT
+<DTPOSTED>20220702
+<TRNAMT>1000.00
+<FITID>FIT-2
+<MEMO>PAYROLL
+</STMTTRN>
+<STMTTRN>
+<TRNTYPE>DEBIT
+<DTPOSTED>20220703
+<TRNAMT>abc
+<FITID>FIT-3
+<NAME>BROKEN
+</STMTTRN>
+</BANKTRANLIST>
+<LEDGERBAL><BALAMT>2095.50<DTASOF>20220705</LEDGERBAL>
+</STMTRS></STMTTRNRS></BANKMSGSRSV1>
+</OFX>
+`
+
+const xmlStatement = `<?xml version="1.0" encoding="UTF-8"?>
+<?OFX OFXHEADER="200" VERSION="220" SECURITY="NONE"?>
+<OFX>
+  <CREDITCARDMSGSRSV1>
+    <CCSTMTTRNRS>
+      <CCSTMTRS>
+        <CURDEF>EUR</CURDEF>
+        <CCACCTFROM><ACCTID>4111</ACCTID></CCACCTFROM>
+        <BANKTRANLIST>
+          <STMTTRN>
+            <DTPOSTED>20220710</DTPOSTED>
+            <TRNAMT>-12,30</TRNAMT>
+            <FITID>CC-1</FITID>
+            <NAME>BOOKS</NAME>
+          </STMTTRN>
+        </BANKTRANLIST>
+      </CCSTMTRS>
+    </CCSTMTTRNRS>
+  </CREDITCARDMSGSRSV1>
+</OFX>
+`
+
+// ofxAccountsMock links account 3 to the account number 000123 and records created and linked accounts. Account 5 is
+// held in EUR and the others in USD.
+type ofxAccountsMock struct {
+	happyMock
+	inserted *AccountCreationRequest
+	linked   string
+}
+
+func (m *ofxAccountsMock) GetAccount(id int64) (Account, error) {
+	account, err := m.happyMock.GetAccount(id)
+	account.Id = id
+	if id == 5 {
+		account.Currency = "EUR"
+	}
+	return account, err
+}
+
+func (m *ofxAccountsMock) FindAccountByExternalId(externalAccountId string) (Account, bool, error) {
+	if externalAccountId == "000123" {
+		return Account{Id: 3, ExternalAccountId: externalAccountId}, true, nil
+	}
+	return Account{}, false, nil
+}
+
+func (m *ofxAccountsMock) InsertAccount(request AccountCreationRequest) (int64, error) {
+	m.inserted = &request
+	return 7, nil
+}
+
+func (m *ofxAccountsMock) LinkExternalAccount(id int64, externalAccountId string) error {
+	m.linked = externalAccountId
+	return nil
+}
+
+// ofxTransactionsMock has already imported FIT-1 into account 3. Account 5 is held in EUR and the others in USD.
+type ofxTransactionsMock struct {
+	happyTransactionsMock
+	insertedCount int
+}
+
+func (m *ofxTransactionsMock) FindAccountCurrency(accountId int64) (string, bool, error) {
+	if accountId == 5 {
+		return "EUR", true, nil
+	}
+	return m.happyTransactionsMock.FindAccountCurrency(accountId)
+}
+
+func (m *ofxTransactionsMock) FindTransactionByExternalId(accountId int64, externalId string) (int64, bool, error) {
+	return 11, accountId == 3 && externalId == "FIT-1", nil
+}
+
+func (m *ofxTransactionsMock) GetTransaction(id int64) (Transaction, error) {
+	transaction, err := m.happyTransactionsMock.GetTransaction(id)
+	transaction.ExternalId = "FIT-1"
+	return transaction, err
+}
+
+func (m *ofxTransactionsMock) ImportTransactions(batch TransactionImport) ([]int64, error) {
+	m.insertedCount += len(batch.Inserts)
+	return m.happyTransactionsMock.ImportTransactions(batch)
+}
+
+func TestParseOFX(t *testing.T) {
+
+	t.Run("testing happy flow of parsing an sgml statement", func(t *testing.T) {
+		statement, err := ParseOFX(strings.NewReader(sgmlStatement))
+		if err != nil {
+			t.Errorf("unexpected error when parsing statement: %v", err)
+			return
+		}
+		expectedAccount := OFXAccount{BankId: "121000248", AccountId: "000123", AccountType: "checking",
+			Organization: "Bank & Trust", FinancialInstitutionId: "1001"}
+		if statement.Account != expectedAccount {
+			t.Errorf("unexpected account %+v", statement.Account)
+		}
+		if statement.Currency != "USD" || statement.LedgerBalance == nil || *statement.LedgerBalance != 209550 {
+			t.Errorf("unexpected currency %s or ledger balance %v", statement.Currency, statement.LedgerBalance)
+		}
+		if len(statement.Rows) != 3 {
+			t.Errorf("expected 3 rows but found %d", len(statement.Rows))
+			return
+		}
+		first := statement.Rows[0]
+		expectedTime := time.Date(2022, 7, 1, 17, 0, 0, 0, time.UTC)
+		if first.Line != 17 || first.ExternalId != "FIT-1" || first.Amount != -450 ||
+			first.Description != "COFFEE SHOP" || first.Memo != "card 1234" || first.Currency != "USD" ||
+			!first.TransactionTime.Equal(expectedTime) {
+			t.Errorf("unexpected first row %+v", first)
+		}
+		if statement.Rows[1].Description != "PAYROLL" || statement.Rows[1].Memo != "" {
+			t.Errorf("expected the memo to be used as description but found %+v", statement.Rows[1])
+		}
+		if statement.Rows[2].Error != "amount \"abc\" is not a number" {
+			t.Errorf("unexpected error on third row %q", statement.Rows[2].Error)
+		}
+	})
+
+	t.Run("testing happy flow of parsing an xml credit card statement", func(t *testing.T) {
+		statement, err := ParseOFX(strings.NewReader(xmlStatement))
+		if err != nil {
+			t.Errorf("unexpected error when parsing statement: %v", err)
+			return
+		}
+		if statement.Account.AccountId != "4111" || statement.Account.AccountType != "credit card" {
+			t.Errorf("unexpected account %+v", statement.Account)
+		}
+		if len(statement.Rows) != 1 || statement.Rows[0].Amount != -1230 || statement.Rows[0].Currency != "EUR" {
+			t.Errorf("unexpected rows %+v", statement.Rows)
+		}
+	})
+
+	t.Run("testing statements of more than one account are rejected", func(t *testing.T) {
+		statement := strings.Replace(sgmlStatement, "</BANKMSGSRSV1>",
+			"<STMTRS><BANKACCTFROM><ACCTID>999</BANKACCTFROM></STMTRS></BANKMSGSRSV1>", 1)
+		_, err := ParseOFX(strings.NewReader(statement))
+		if !errors.Is(err, ErrInvalidRequest) {
+			t.Errorf("expected invalid request error but found %v", err)
+		}
+	})
+
+	t.Run("testing files that are not ofx are rejected", func(t *testing.T) {
+		_, err := ParseOFX(strings.NewReader("Date,Amount\n07/01/2022,1.00\n"))
+		expectedErrorMsg := "invalid request: statement is not an ofx file"
+		if err == nil || err.Error() != expectedErrorMsg {
+			t.Errorf("expected error message %s but found %v", expectedErrorMsg, err)
+		}
+	})
+}
+
+func TestParseOFXDate(t *testing.T) {
+
+	for value, expected := range map[string]time.Time{
+		"20220701":                   time.Date(2022, 7, 1, 0, 0, 0, 0, time.UTC),
+		"202207011230":               time.Date(2022, 7, 1, 12, 30, 0, 0, time.UTC),
+		"20220701123045.123":         time.Date(2022, 7, 1, 12, 30, 45, 0, time.UTC),
+		"20220701120000[+5.5:IST]":   time.Date(2022, 7, 1, 6, 30, 0, 0, time.UTC),
+		"20220701000000.000[-8:PST]": time.Date(2022, 7, 1, 8, 0, 0, 0, time.UTC),
+	} {
+		parsed, err := parseOFXDate(value)
+		if err != nil || !parsed.Equal(expected) {
+			t.Errorf("expected %s to be parsed as %v but found %v with err %v", value, expected, parsed, err)
+		}
+	}
+	for _, value := range []string{"", "2022-07-01", "20220701[abc]"} {
+		if _, err := parseOFXDate(value); err == nil {
+			t.Errorf("expected %q to be rejected", value)
+		}
+	}
+}
+
+func TestImportService_PreviewOFX(t *testing.T) {
+
+	t.Run("testing happy flow of previewing a statement of a linked account", func(t *testing.T) {
+		importService := NewImportService(&importProfilesMock{},
+			NewTransactionsService(&ofxTransactionsMock{}, nil, nil), NewAccountsService(&ofxAccountsMock{}, nil),
+			NewOrganizationsService(&happyOrganizationsMock{}), nil, nil)
+		preview, err := importService.PreviewOFX(strings.NewReader(sgmlStatement))
+		if err != nil {
+			t.Errorf("unexpected error when previewing statement: %v", err)
+			return
+		}
+		if preview.MatchedAccountId == nil || *preview.MatchedAccountId != 3 {
+			t.Errorf("expected the statement to be matched to account 3 but found %v", preview.MatchedAccountId)
+		}
+		if preview.ValidRows != 2 || preview.InvalidRows != 1 || preview.DuplicateRows != 1 ||
+			!preview.Rows[0].Duplicate {
+			t.Errorf("unexpected preview %+v", preview)
+		}
+	})
+
+	t.Run("testing an existing organization is suggested for an unknown account", func(t *testing.T) {
+		importService := NewImportService(&importProfilesMock{},
+			NewTransactionsService(&ofxTransactionsMock{}, nil, nil), NewAccountsService(&ofxAccountsMock{}, nil),
+			NewOrganizationsService(&happyOrganizationsMock{}), nil, nil)
+		statement := strings.Replace(xmlStatement, "<CCSTMTRS>", "<CCSTMTRS><FI><ORG>BANK</ORG></FI>", 1)
+		preview, err := importService.PreviewOFX(strings.NewReader(statement))
+		if err != nil {
+			t.Errorf("unexpected error when previewing statement: %v", err)
+			return
+		}
+		if preview.MatchedAccountId != nil || preview.SuggestedOrgId == nil || *preview.SuggestedOrgId != 1 {
+			t.Errorf("unexpected matched account %v or suggested organization %v", preview.MatchedAccountId,
+				preview.SuggestedOrgId)
+		}
+	})
+}
+
+func TestImportService_ImportOFX(t *testing.T) {
+
+	t.Run("testing happy flow of importing into the linked account", func(t *testing.T) {
+		transactionsDAO := ofxTransactionsMock{}
+		importService := NewImportService(&importProfilesMock{},
+			NewTransactionsService(&transactionsDAO, nil, nil), NewAccountsService(&ofxAccountsMock{}, nil),
+			NewOrganizationsService(&happyOrganizationsMock{}), nil, nil)
+		statement := strings.Replace(sgmlStatement, "<TRNAMT>abc", "<TRNAMT>-20.00", 1)
+		result, err := importService.ImportOFX(strings.NewReader(statement), OFXImportOptions{})
+		if err != nil {
+			t.Errorf("unexpected error when importing statement: %v", err)
+			return
+		}
+		if result.AccountId != 3 || result.Imported != 2 || result.Skipped != 1 || transactionsDAO.insertedCount != 2 {
+			t.Errorf("unexpected result %+v", result)
+		}
+		inserted := transactionsDAO.inserted
+		if *inserted.ExternalId != "FIT-3" || *inserted.Currency != "USD" || *inserted.AccountId != 3 {
+			t.Errorf("unexpected transaction inserted %+v", inserted)
+		}
+	})
+
+	t.Run("testing a chosen account is linked to the statement", func(t *testing.T) {
+		accountsDAO := ofxAccountsMock{}
+		importService := NewImportService(&importProfilesMock{},
+			NewTransactionsService(&ofxTransactionsMock{}, nil, nil), NewAccountsService(&accountsDAO, nil),
+			NewOrganizationsService(&happyOrganizationsMock{}), nil, nil)
+		accountId := int64(5)
+		result, err := importService.ImportOFX(strings.NewReader(xmlStatement), OFXImportOptions{AccountId: &accountId})
+		if err != nil {
+			t.Errorf("unexpected error when importing statement: %v", err)
+			return
+		}
+		if result.AccountId != 5 || accountsDAO.linked != "4111" {
+			t.Errorf("expected account 5 to be linked to 4111 but found account %d linked to %s", result.AccountId,
+				accountsDAO.linked)
+		}
+	})
+
+	t.Run("testing a chosen account is not linked when the import fails", func(t *testing.T) {
+		accountsDAO := ofxAccountsMock{}
+		importService := NewImportService(&importProfilesMock{},
+			NewTransactionsService(&errorTransactionsMock{}, nil, nil), NewAccountsService(&accountsDAO, nil),
+			NewOrganizationsService(&happyOrganizationsMock{}), nil, nil)
+		accountId := int64(5)
+		_, err := importService.ImportOFX(strings.NewReader(xmlStatement), OFXImportOptions{AccountId: &accountId})
+		if err == nil || accountsDAO.linked != "" {
+			t.Errorf("expected account 5 to stay unlinked but found it linked to %q with error %v", accountsDAO.linked,
+				err)
+		}
+	})
+
+	t.Run("testing an account is created in the organization of the statement", func(t *testing.T) {
+		accountsDAO := ofxAccountsMock{}
+		importService := NewImportService(&importProfilesMock{},
+			NewTransactionsService(&ofxTransactionsMock{}, nil, nil), NewAccountsService(&accountsDAO, nil),
+			NewOrganizationsService(&happyOrganizationsMock{}), nil, nil)
+		statement := strings.Replace(sgmlStatement, "000123", "000456", 1)
+		statement = strings.Replace(statement, "Bank &amp; Trust", "bank", 1)
+		statement = strings.Replace(statement, "<TRNAMT>abc", "<TRNAMT>-20.00", 1)
+		result, err := importService.ImportOFX(strings.NewReader(statement), OFXImportOptions{CreateAccount: true})
+		if err != nil {
+			t.Errorf("unexpected error when importing statement: %v", err)
+			return
+		}
+		created := accountsDAO.inserted
+		if result.AccountId != 7 || result.Imported != 3 || created == nil {
+			t.Errorf("unexpected result %+v", result)
+			return
+		}
+		if *created.OrgId != 1 || *created.AccountType != "checking" || *created.ExternalAccountId != "000456" ||
+			*created.Name != "bank 000456" || *created.OpeningBalance != 112000 || *created.Currency != "USD" {
+			t.Errorf("unexpected account created %+v", created)
+		}
+	})
+
+	t.Run("testing statements in another currency than the account are rejected", func(t *testing.T) {
+		transactionsDAO := ofxTransactionsMock{}
+		importService := NewImportService(&importProfilesMock{},
+			NewTransactionsService(&transactionsDAO, nil, nil), NewAccountsService(&ofxAccountsMock{}, nil),
+			NewOrganizationsService(&happyOrganizationsMock{}), nil, nil)
+		statement := strings.Replace(sgmlStatement, "<CURDEF>USD", "<CURDEF>eur", 1)
+		_, err := importService.ImportOFX(strings.NewReader(statement), OFXImportOptions{})
+		expectedErrorMsg := "invalid request: the statement is in EUR but account 3 is in USD"
+		if err == nil || err.Error() != expectedErrorMsg || transactionsDAO.insertedCount != 0 {
+			t.Errorf("expected error message %s but found %v", expectedErrorMsg, err)
+		}
+		accountId := int64(4)
+		_, err = importService.ImportOFX(strings.NewReader(xmlStatement), OFXImportOptions{AccountId: &accountId})
+		if !errors.Is(err, ErrInvalidRequest) {
+			t.Errorf("expected invalid request error but found %v", err)
+		}
+	})
+
+	t.Run("testing statements of unknown accounts are rejected", func(t *testing.T) {
+		importService := NewImportService(&importProfilesMock{},
+			NewTransactionsService(&ofxTransactionsMock{}, nil, nil), NewAccountsService(&ofxAccountsMock{}, nil),
+			NewOrganizationsService(&happyOrganizationsMock{}), nil, nil)
+		_, err := importService.ImportOFX(strings.NewReader(xmlStatement), OFXImportOptions{})
+		if !errors.Is(err, ErrInvalidRequest) {
+			t.Errorf("expected invalid request error but found %v", err)
+		}
+	})
+
+	t.Run("testing general error flow of importing a statement", func(t *testing.T) {
+		importService := NewImportService(&importProfilesMock{},
+			NewTransactionsService(&errorTransactionsMock{}, nil, nil), NewAccountsService(&ofxAccountsMock{}, nil),
+			NewOrganizationsService(&happyOrganizationsMock{}), nil, nil)
+		orgId := int64(1)
+		_, err := importService.ImportOFX(strings.NewReader(xmlStatement), OFXImportOptions{CreateAccount: true,
+			OrgId: &orgId})
+		expectedErrorMsg := "failed to list transactions with err:db timeout"
+		if err == nil || err.Error() != expectedErrorMsg {
+			t.Errorf("expected error message %s but found %v", expectedErrorMsg, err)
+		}
+	})
+}
@@ -0,0 +1,78 @@
+package api
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Organization represents a financial institution like a bank or an investment firm that holds accounts.
+type Organization struct {
+	Id        int64     `json:"id"`
+	Name      string    `json:"name"`
+	IsDeleted bool      `json:"is_deleted"`
+	CreatedTs time.Time `json:"created_ts"`
+	UpdatedTs time.Time `json:"updated_ts"`
+}
+
+type OrganizationCreationRequest struct {
+	Name *string `json:"name"`
+}
+
+type OrganizationUpdateRequest struct {
+	Name *string `json:"name"`
+}
+
+type OrganizationsService struct {
+	dao OrganizationsDataAccessor
+}
+
+func NewOrganizationsService(dao OrganizationsDataAccessor) *OrganizationsService {
+	return &OrganizationsService{dao}
+}
+
+func (service *OrganizationsService) GetOrganization(id int64) (Organization, error) {
+	organization, err := service.dao.GetOrganization(id)
+	if err != nil {
+		return organization, fmt.Errorf("failed to retrieve organization of id %d with err:%v", id, err)
+	}
+	return organization, nil
+}
+
+func (service *OrganizationsService) ListOrganizations() ([]Organization, error) {
+	organizations, err := service.dao.ListOrganizations()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list organizations with err:%v", err)
+	}
+	return organizations, nil
+}
+
+func (service *OrganizationsService) CreateOrganization(request OrganizationCreationRequest) (int64, error) {
+	if request.Name == nil || strings.TrimSpace(*request.Name) == "" {
+		return -1, fmt.Errorf("%w: name is required", ErrInvalidRequest)
+	}
+	return service.dao.InsertOrganization(request)
+}
+
+func (service *OrganizationsService) DeleteOrganization(id int64) error {
+	err := service.dao.DeleteOrganization(id)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (service *OrganizationsService) UpdateOrganization(id int64, request OrganizationUpdateRequest) error {
+	if request.Name == nil || strings.TrimSpace(*request.Name) == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidRequest)
+	}
+	return service.dao.UpdateOrganization(id, request)
+}
+
+type OrganizationsDataAccessor interface {
+	GetOrganization(id int64) (Organization, error)
+	ListOrganizations() ([]Organization, error)
+	InsertOrganization(request OrganizationCreationRequest) (int64, error)
+	DeleteOrganization(id int64) error
+	UpdateOrganization(id int64, request OrganizationUpdateRequest) error
+}
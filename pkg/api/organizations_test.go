@@ -0,0 +1,155 @@
+package api
+
+import (
+	"errors"
+	"testing"
+)
+
+type happyOrganizationsMock struct {
+}
+
+func (m *happyOrganizationsMock) GetOrganization(id int64) (Organization, error) {
+	return Organization{Id: id, Name: "bank"}, nil
+}
+
+func (m *happyOrganizationsMock) ListOrganizations() ([]Organization, error) {
+	return []Organization{{Id: 1, Name: "bank"}}, nil
+}
+
+func (m *happyOrganizationsMock) InsertOrganization(request OrganizationCreationRequest) (int64, error) {
+	return 1, nil
+}
+
+func (m *happyOrganizationsMock) DeleteOrganization(id int64) error {
+	return nil
+}
+
+func (m *happyOrganizationsMock) UpdateOrganization(id int64, request OrganizationUpdateRequest) error {
+	return nil
+}
+
+type errorOrganizationsMock struct {
+}
+
+func (m *errorOrganizationsMock) GetOrganization(id int64) (Organization, error) {
+	return Organization{}, errors.New("db timeout")
+}
+
+func (m *errorOrganizationsMock) ListOrganizations() ([]Organization, error) {
+	return nil, errors.New("db timeout")
+}
+
+func (m *errorOrganizationsMock) InsertOrganization(request OrganizationCreationRequest) (int64, error) {
+	return -1, errors.New("db timeout")
+}
+
+func (m *errorOrganizationsMock) DeleteOrganization(id int64) error {
+	return errors.New("db timeout")
+}
+
+func (m *errorOrganizationsMock) UpdateOrganization(id int64, request OrganizationUpdateRequest) error {
+	return errors.New("db timeout")
+}
+
+func TestOrganizationsService_GetOrganization(t *testing.T) {
+
+	t.Run("testing happy flow of getting an organization", func(t *testing.T) {
+		organizationsService := NewOrganizationsService(&happyOrganizationsMock{})
+		organization, err := organizationsService.GetOrganization(2)
+		if err != nil {
+			t.Errorf("unexpected error when retrieving organization")
+		}
+		if organization.Id != 2 {
+			t.Errorf("unexpected organization id %d", organization.Id)
+		}
+	})
+
+	t.Run("testing general error flow of getting an organization", func(t *testing.T) {
+		organizationsService := NewOrganizationsService(&errorOrganizationsMock{})
+		_, err := organizationsService.GetOrganization(1)
+		expectedErrorMsg := "failed to retrieve organization of id 1 with err:db timeout"
+		if err.Error() != expectedErrorMsg {
+			t.Errorf("expected error message %s but found %s", expectedErrorMsg, err.Error())
+		}
+	})
+}
+
+func TestOrganizationsService_ListOrganizations(t *testing.T) {
+
+	t.Run("testing happy flow of listing organizations", func(t *testing.T) {
+		organizationsService := NewOrganizationsService(&happyOrganizationsMock{})
+		organizations, err := organizationsService.ListOrganizations()
+		if err != nil {
+			t.Errorf("unexpected error when listing organizations")
+		}
+		if len(organizations) != 1 {
+			t.Errorf("expected 1 organization but found %d", len(organizations))
+		}
+	})
+
+	t.Run("testing general error flow of listing organizations", func(t *testing.T) {
+		organizationsService := NewOrganizationsService(&errorOrganizationsMock{})
+		_, err := organizationsService.ListOrganizations()
+		expectedErrorMsg := "failed to list organizations with err:db timeout"
+		if err.Error() != expectedErrorMsg {
+			t.Errorf("expected error message %s but found %s", expectedErrorMsg, err.Error())
+		}
+	})
+}
+
+func TestOrganizationsService_CreateOrganization(t *testing.T) {
+
+	t.Run("testing happy flow of creating an organization", func(t *testing.T) {
+		organizationsService := NewOrganizationsService(&happyOrganizationsMock{})
+		name := "bank"
+		id, err := organizationsService.CreateOrganization(OrganizationCreationRequest{Name: &name})
+		if err != nil {
+			t.Errorf("unexpected error when creating organization")
+		}
+		if id != 1 {
+			t.Errorf("unexpected organization id %d", id)
+		}
+	})
+
+	t.Run("testing validation of blank names", func(t *testing.T) {
+		organizationsService := NewOrganizationsService(&happyOrganizationsMock{})
+		name := "  "
+		_, err := organizationsService.CreateOrganization(OrganizationCreationRequest{Name: &name})
+		if !errors.Is(err, ErrInvalidRequest) {
+			t.Errorf("expected invalid request error but found %v", err)
+		}
+	})
+}
+
+func TestOrganizationsService_UpdateOrganization(t *testing.T) {
+
+	t.Run("testing validation of missing names", func(t *testing.T) {
+		organizationsService := NewOrganizationsService(&happyOrganizationsMock{})
+		err := organizationsService.UpdateOrganization(1, OrganizationUpdateRequest{})
+		if !errors.Is(err, ErrInvalidRequest) {
+			t.Errorf("expected invalid request error but found %v", err)
+		}
+	})
+
+	t.Run("testing general error flow of updating an organization", func(t *testing.T) {
+		organizationsService := NewOrganizationsService(&errorOrganizationsMock{})
+		name := "bank"
+		err := organizationsService.UpdateOrganization(1, OrganizationUpdateRequest{Name: &name})
+		expectedErrorMsg := "db timeout"
+		if err.Error() != expectedErrorMsg {
+			t.Errorf("expected error message %s but found %s", expectedErrorMsg, err.Error())
+		}
+	})
+}
+
+func TestOrganizationsService_DeleteOrganization(t *testing.T) {
+
+	t.Run("testing general error flow of deleting an organization", func(t *testing.T) {
+		organizationsService := NewOrganizationsService(&errorOrganizationsMock{})
+		err := organizationsService.DeleteOrganization(1)
+		expectedErrorMsg := "db timeout"
+		if err.Error() != expectedErrorMsg {
+			t.Errorf("expected error message %s but found %s", expectedErrorMsg, err.Error())
+		}
+	})
+}
@@ -0,0 +1,188 @@
+package api
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+const PayeeRuleMatchContains = "contains"
+const PayeeRuleMatchPrefix = "prefix"
+const PayeeRuleMatchRegex = "regex"
+
+type Payee struct {
+	Id        int64     `json:"id"`
+	Name      string    `json:"name"`
+	IsDeleted bool      `json:"is_deleted"`
+	CreatedTs time.Time `json:"created_ts"`
+	UpdatedTs time.Time `json:"updated_ts"`
+}
+
+type PayeeCreationRequest struct {
+	Name *string `json:"name"`
+}
+
+type PayeeUpdateRequest struct {
+	Name *string `json:"name"`
+}
+
+// PayeeRule maps transaction descriptions onto a payee. Contains and prefix rules are matched case-insensitively
+// against the normalized description while regex rules are matched against the raw description.
+type PayeeRule struct {
+	Id        int64     `json:"id"`
+	PayeeId   int64     `json:"payee_id"`
+	MatchType string    `json:"match_type"`
+	Pattern   string    `json:"pattern"`
+	Priority  int64     `json:"priority"`
+	CreatedTs time.Time `json:"created_ts"`
+}
+
+type PayeeRuleCreationRequest struct {
+	PayeeId   *int64  `json:"payee_id"`
+	MatchType *string `json:"match_type"`
+	Pattern   *string `json:"pattern"`
+	Priority  *int64  `json:"priority"`
+}
+
+type PayeesService struct {
+	dao PayeesDataAccessor
+}
+
+func NewPayeesService(dao PayeesDataAccessor) *PayeesService {
+	return &PayeesService{dao}
+}
+
+func (service *PayeesService) GetPayee(id int64) (Payee, error) {
+	payee, err := service.dao.GetPayee(id)
+	if err != nil {
+		return payee, fmt.Errorf("failed to retrieve payee of id %d with err:%v", id, err)
+	}
+	return payee, nil
+}
+
+func (service *PayeesService) ListPayees() ([]Payee, error) {
+	payees, err := service.dao.ListPayees()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list payees with err:%v", err)
+	}
+	return payees, nil
+}
+
+func (service *PayeesService) CreatePayee(request PayeeCreationRequest) (int64, error) {
+	if request.Name == nil || strings.TrimSpace(*request.Name) == "" {
+		return -1, fmt.Errorf("%w: name is required", ErrInvalidRequest)
+	}
+	return service.dao.InsertPayee(request)
+}
+
+func (service *PayeesService) DeletePayee(id int64) error {
+	err := service.dao.DeletePayee(id)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (service *PayeesService) UpdatePayee(id int64, request PayeeUpdateRequest) error {
+	if request.Name == nil || strings.TrimSpace(*request.Name) == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidRequest)
+	}
+	return service.dao.UpdatePayee(id, request)
+}
+
+func (service *PayeesService) ListPayeeRules() ([]PayeeRule, error) {
+	rules, err := service.dao.ListPayeeRules()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list payee rules with err:%v", err)
+	}
+	return rules, nil
+}
+
+func (service *PayeesService) CreatePayeeRule(request PayeeRuleCreationRequest) (int64, error) {
+	if request.PayeeId == nil {
+		return -1, fmt.Errorf("%w: payee_id is required", ErrInvalidRequest)
+	}
+	if request.Pattern == nil || *request.Pattern == "" {
+		return -1, fmt.Errorf("%w: pattern is required", ErrInvalidRequest)
+	}
+	matchType := PayeeRuleMatchContains
+	if request.MatchType != nil {
+		matchType = *request.MatchType
+	}
+	switch matchType {
+	case PayeeRuleMatchContains, PayeeRuleMatchPrefix:
+	case PayeeRuleMatchRegex:
+		if _, err := regexp.Compile(*request.Pattern); err != nil {
+			return -1, fmt.Errorf("%w: pattern is not a valid regular expression: %v", ErrInvalidRequest, err)
+		}
+	default:
+		return -1, fmt.Errorf("%w: unknown match type %s", ErrInvalidRequest, matchType)
+	}
+	request.MatchType = &matchType
+	if _, err := service.dao.GetPayee(*request.PayeeId); err != nil {
+		return -1, fmt.Errorf("%w: payee %d does not exist", ErrInvalidRequest, *request.PayeeId)
+	}
+	return service.dao.InsertPayeeRule(request)
+}
+
+func (service *PayeesService) DeletePayeeRule(id int64) error {
+	err := service.dao.DeletePayeeRule(id)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// ResolvePayee returns the payee of the first rule matching the description. Rules are evaluated in descending
+// priority and then in the order they were created. The boolean is false when no rule matches.
+func (service *PayeesService) ResolvePayee(description string) (int64, bool, error) {
+	rules, err := service.dao.ListPayeeRules()
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to load payee rules with err:%v", err)
+	}
+	sort.SliceStable(rules, func(i, j int) bool {
+		if rules[i].Priority != rules[j].Priority {
+			return rules[i].Priority > rules[j].Priority
+		}
+		return rules[i].Id < rules[j].Id
+	})
+	normalized := NormalizeDescription(description)
+	for _, rule := range rules {
+		if ruleMatches(rule, description, normalized) {
+			return rule.PayeeId, true, nil
+		}
+	}
+	return 0, false, nil
+}
+
+func ruleMatches(rule PayeeRule, description string, normalized string) bool {
+	switch rule.MatchType {
+	case PayeeRuleMatchContains:
+		return strings.Contains(normalized, NormalizeDescription(rule.Pattern))
+	case PayeeRuleMatchPrefix:
+		return strings.HasPrefix(normalized, NormalizeDescription(rule.Pattern))
+	case PayeeRuleMatchRegex:
+		expression, err := regexp.Compile(rule.Pattern)
+		return err == nil && expression.MatchString(description)
+	}
+	return false
+}
+
+// NormalizeDescription upper cases a bank description and collapses runs of whitespace so that the same
+// merchant is recognised regardless of how a statement pads it.
+func NormalizeDescription(description string) string {
+	return strings.Join(strings.Fields(strings.ToUpper(description)), " ")
+}
+
+type PayeesDataAccessor interface {
+	GetPayee(id int64) (Payee, error)
+	ListPayees() ([]Payee, error)
+	InsertPayee(request PayeeCreationRequest) (int64, error)
+	DeletePayee(id int64) error
+	UpdatePayee(id int64, request PayeeUpdateRequest) error
+	ListPayeeRules() ([]PayeeRule, error)
+	InsertPayeeRule(request PayeeRuleCreationRequest) (int64, error)
+	DeletePayeeRule(id int64) error
+}
@@ -0,0 +1,161 @@
+package api
+
+import (
+	"errors"
+	"testing"
+)
+
+type payeesMock struct {
+	rules []PayeeRule
+	err   error
+}
+
+func (m *payeesMock) GetPayee(id int64) (Payee, error) {
+	if m.err != nil {
+		return Payee{}, m.err
+	}
+	return Payee{Id: id, Name: "Amazon"}, nil
+}
+
+func (m *payeesMock) ListPayees() ([]Payee, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	return []Payee{{Id: 1, Name: "Amazon"}}, nil
+}
+
+func (m *payeesMock) InsertPayee(request PayeeCreationRequest) (int64, error) {
+	return 1, m.err
+}
+
+func (m *payeesMock) DeletePayee(id int64) error {
+	return m.err
+}
+
+func (m *payeesMock) UpdatePayee(id int64, request PayeeUpdateRequest) error {
+	return m.err
+}
+
+func (m *payeesMock) ListPayeeRules() ([]PayeeRule, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	return m.rules, nil
+}
+
+func (m *payeesMock) InsertPayeeRule(request PayeeRuleCreationRequest) (int64, error) {
+	return 1, m.err
+}
+
+func (m *payeesMock) DeletePayeeRule(id int64) error {
+	return m.err
+}
+
+func TestPayeesService_CreatePayee(t *testing.T) {
+
+	t.Run("testing happy flow of creating a payee", func(t *testing.T) {
+		payeesService := NewPayeesService(&payeesMock{})
+		name := "Amazon"
+		id, err := payeesService.CreatePayee(PayeeCreationRequest{Name: &name})
+		if err != nil || id != 1 {
+			t.Errorf("unexpected result %d, %v when creating payee", id, err)
+		}
+	})
+
+	t.Run("testing validation of missing names", func(t *testing.T) {
+		payeesService := NewPayeesService(&payeesMock{})
+		_, err := payeesService.CreatePayee(PayeeCreationRequest{})
+		if !errors.Is(err, ErrInvalidRequest) {
+			t.Errorf("expected invalid request error but found %v", err)
+		}
+	})
+
+	t.Run("testing general error flow of getting a payee", func(t *testing.T) {
+		payeesService := NewPayeesService(&payeesMock{err: errors.New("db timeout")})
+		_, err := payeesService.GetPayee(1)
+		expectedErrorMsg := "failed to retrieve payee of id 1 with err:db timeout"
+		if err.Error() != expectedErrorMsg {
+			t.Errorf("expected error message %s but found %s", expectedErrorMsg, err.Error())
+		}
+	})
+}
+
+func TestPayeesService_CreatePayeeRule(t *testing.T) {
+
+	payeeId := int64(1)
+
+	t.Run("testing match type defaults to contains", func(t *testing.T) {
+		payeesService := NewPayeesService(&payeesMock{})
+		pattern := "amzn"
+		_, err := payeesService.CreatePayeeRule(PayeeRuleCreationRequest{PayeeId: &payeeId, Pattern: &pattern})
+		if err != nil {
+			t.Errorf("unexpected error when creating payee rule: %v", err)
+		}
+	})
+
+	t.Run("testing validation of invalid regular expressions", func(t *testing.T) {
+		payeesService := NewPayeesService(&payeesMock{})
+		pattern := "AMZN(("
+		matchType := PayeeRuleMatchRegex
+		_, err := payeesService.CreatePayeeRule(PayeeRuleCreationRequest{PayeeId: &payeeId, Pattern: &pattern,
+			MatchType: &matchType})
+		if !errors.Is(err, ErrInvalidRequest) {
+			t.Errorf("expected invalid request error but found %v", err)
+		}
+	})
+
+	t.Run("testing validation of unknown match types", func(t *testing.T) {
+		payeesService := NewPayeesService(&payeesMock{})
+		pattern := "AMZN"
+		matchType := "fuzzy"
+		_, err := payeesService.CreatePayeeRule(PayeeRuleCreationRequest{PayeeId: &payeeId, Pattern: &pattern,
+			MatchType: &matchType})
+		expectedErrorMsg := "invalid request: unknown match type fuzzy"
+		if err == nil || err.Error() != expectedErrorMsg {
+			t.Errorf("expected error message %s but found %v", expectedErrorMsg, err)
+		}
+	})
+}
+
+func TestPayeesService_ResolvePayee(t *testing.T) {
+
+	rules := []PayeeRule{
+		{Id: 1, PayeeId: 10, MatchType: PayeeRuleMatchContains, Pattern: "amzn  mktp"},
+		{Id: 2, PayeeId: 20, MatchType: PayeeRuleMatchPrefix, Pattern: "uber"},
+		{Id: 3, PayeeId: 30, MatchType: PayeeRuleMatchRegex, Pattern: `^UBER\s+\*?EATS`, Priority: 5},
+	}
+	payeesService := NewPayeesService(&payeesMock{rules: rules})
+
+	testCases := []struct {
+		description     string
+		expectedPayeeId int64
+		expectedFound   bool
+	}{
+		{"AMZN MKTP US*2K4", 10, true},
+		{"  amzn   Mktp de*99 ", 10, true},
+		{"UBER *TRIP", 20, true},
+		{"UBER *EATS 1234", 30, true},
+		{"SPOTIFY", 0, false},
+	}
+	for _, testCase := range testCases {
+		t.Run("testing resolution of "+testCase.description, func(t *testing.T) {
+			payeeId, found, err := payeesService.ResolvePayee(testCase.description)
+			if err != nil {
+				t.Errorf("unexpected error when resolving payee: %v", err)
+			}
+			if payeeId != testCase.expectedPayeeId || found != testCase.expectedFound {
+				t.Errorf("expected payee %d (%t) but found %d (%t)", testCase.expectedPayeeId,
+					testCase.expectedFound, payeeId, found)
+			}
+		})
+	}
+
+	t.Run("testing general error flow of resolving a payee", func(t *testing.T) {
+		payeesService := NewPayeesService(&payeesMock{err: errors.New("db timeout")})
+		_, _, err := payeesService.ResolvePayee("AMZN")
+		expectedErrorMsg := "failed to load payee rules with err:db timeout"
+		if err == nil || err.Error() != expectedErrorMsg {
+			t.Errorf("expected error message %s but found %v", expectedErrorMsg, err)
+		}
+	})
+}
@@ -0,0 +1,316 @@
+package api
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+const qifDateLayout = "01/02/2006"
+
+// qifTransactionTypes are the sections of a qif file holding transactions of a cash account. Investment and list
+// sections like !Type:Invst or !Type:Cat are skipped.
+var qifTransactionTypes = map[string]bool{"bank": true, "ccard": true, "cash": true, "oth a": true, "oth l": true}
+
+// qifDateLayouts accepts the month first dates written by desktop finance tools, including the 1/ 2'06 form where
+// an apostrophe separates years after 2000.
+var qifDateLayouts = []string{"1/2/2006", "1/2/06", "2006-01-02", "1-2-2006", "1-2-06"}
+
+// PreviewQIF parses a qif file without importing it. When the account is given, the rows that duplicate its
+// transactions are reported.
+func (service *ImportService) PreviewQIF(accountId *int64, statement io.Reader) (ImportPreview, error) {
+	rows, err := ParseQIF(statement)
+	if err != nil {
+		return ImportPreview{}, err
+	}
+	return service.previewAccountRows(accountId, rows)
+}
+
+// ImportQIF parses a qif file and imports every transaction into the account, handling the rows that duplicate
+// existing transactions as decided. Payees and categories are matched by name and created when they do not exist yet.
+func (service *ImportService) ImportQIF(accountId int64, statement io.Reader, decisions []DuplicateDecision) (
+	ImportResult, error) {
+	rows, err := ParseQIF(statement)
+	if err != nil {
+		return ImportResult{}, err
+	}
+	return service.commitRows(accountId, rows, decisions)
+}
+
+// qifRecord collects the fields of a single transaction up to the ^ that ends it.
+type qifRecord struct {
+	line   int
+	fields map[byte]string
+	splits []qifSplit
+}
+
+type qifSplit struct {
+	category string
+	memo     string
+	amount   string
+}
+
+// ParseQIF reads the transactions of the cash account sections of a qif file. Errors in individual transactions are
+// reported on the rows themselves, while a file that cannot be read or that holds several accounts fails as a whole.
+// Categories in brackets name the other account of a transfer and are not imported as categories.
+func ParseQIF(statement io.Reader) ([]ImportRow, error) {
+	scanner := bufio.NewScanner(statement)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	rows := []ImportRow{}
+	section := ""
+	account, rowsAccount := "", ""
+	var record *qifRecord
+	// finishRecord adds the record being read to the rows
+	finishRecord := func() error {
+		if record != nil && qifTransactionTypes[section] {
+			if len(rows) > 0 && account != rowsAccount {
+				return fmt.Errorf("%w: file covers more than one account", ErrInvalidRequest)
+			}
+			rowsAccount = account
+			rows = append(rows, parseQIFRecord(*record))
+		}
+		record = nil
+		return nil
+	}
+	sawHeader := false
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimRight(scanner.Text(), "\r")
+		if line == 1 {
+			text = strings.TrimPrefix(text, "\ufeff")
+		}
+		if strings.TrimSpace(text) == "" {
+			continue
+		}
+		if strings.HasPrefix(text, "!") {
+			if err := finishRecord(); err != nil {
+				return nil, err
+			}
+			header := strings.ToLower(strings.TrimSpace(text[1:]))
+			switch {
+			case strings.HasPrefix(header, "type:"):
+				section = strings.TrimSpace(strings.TrimPrefix(header, "type:"))
+				sawHeader = true
+			case header == "account":
+				section = "account"
+			}
+			continue
+		}
+		if text == "^" {
+			if err := finishRecord(); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if section == "account" {
+			if text[0] == 'N' {
+				account = strings.TrimSpace(text[1:])
+			}
+			continue
+		}
+		if !qifTransactionTypes[section] {
+			continue
+		}
+		if record == nil {
+			record = &qifRecord{line: line, fields: map[byte]string{}}
+		}
+		code, value := text[0], strings.TrimSpace(text[1:])
+		switch code {
+		case 'S':
+			record.splits = append(record.splits, qifSplit{category: value})
+		case 'E', '$':
+			if len(record.splits) == 0 {
+				record.splits = append(record.splits, qifSplit{})
+			}
+			split := &record.splits[len(record.splits)-1]
+			if code == 'E' {
+				split.memo = value
+			} else {
+				split.amount = value
+			}
+		default:
+			record.fields[code] = value
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read qif file with err:%v", err)
+	}
+	if !sawHeader {
+		return nil, fmt.Errorf("%w: file is not a qif file", ErrInvalidRequest)
+	}
+	// a last transaction that is not terminated by ^ is still imported
+	if err := finishRecord(); err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+// parseQIFRecord maps the fields of a record onto a row. The payee is used as the description.
+func parseQIFRecord(record qifRecord) ImportRow {
+	row := ImportRow{
+		Line:        record.line,
+		Description: record.fields['P'],
+		Payee:       record.fields['P'],
+		Memo:        record.fields['M'],
+		Category:    qifCategory(record.fields['L']),
+	}
+	transactionTime, err := parseQIFDate(record.fields['D'])
+	if err != nil {
+		row.Error = err.Error()
+		return row
+	}
+	row.TransactionTime = &transactionTime
+	amount, ok := record.fields['T']
+	if !ok {
+		amount = record.fields['U']
+	}
+	if row.Amount, err = parseQIFAmount(amount); err != nil {
+		row.Error = err.Error()
+		return row
+	}
+	var total Money
+	for _, split := range record.splits {
+		splitAmount, err := parseQIFAmount(split.amount)
+		if err != nil {
+			row.Error = "split " + err.Error()
+			return row
+		}
+		total += splitAmount
+		row.Splits = append(row.Splits, ImportSplit{Category: qifCategory(split.category), Memo: split.memo,
+			Amount: splitAmount})
+	}
+	if len(row.Splits) > 0 && total != row.Amount {
+		row.Error = fmt.Sprintf("splits add up to %s instead of the amount %s", total, row.Amount)
+	}
+	return row
+}
+
+// qifCategory drops the class that follows a / and the bracketed account name that marks a transfer.
+func qifCategory(value string) string {
+	if slash := strings.IndexByte(value, '/'); slash >= 0 {
+		value = value[:slash]
+	}
+	value = strings.TrimSpace(value)
+	if strings.HasPrefix(value, "[") {
+		return ""
+	}
+	return value
+}
+
+func parseQIFDate(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, fmt.Errorf("date is missing")
+	}
+	normalized := strings.ReplaceAll(strings.ReplaceAll(value, "'", "/"), " ", "")
+	for _, layout := range qifDateLayouts {
+		if parsed, err := time.Parse(layout, normalized); err == nil {
+			return parsed, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("date %q is not a qif date", value)
+}
+
+func parseQIFAmount(value string) (Money, error) {
+	if value == "" {
+		return 0, fmt.Errorf("amount is missing")
+	}
+	return ParseMoney(strings.ReplaceAll(value, ",", ""))
+}
+
+// ExportQIF writes the transactions of the account made in [from, to) as a qif file that desktop finance tools and
+// ImportQIF can read. Payees and categories are written by name, and the other leg of a transfer is written as the
+// bracketed name of its account.
+func (service *ExportService) ExportQIF(accountId int64, from *time.Time, to *time.Time, writer io.Writer) error {
+	account, err := service.accountsService.GetAccount(accountId)
+	if err != nil {
+		return fmt.Errorf("%w: account %d does not exist", ErrInvalidRequest, accountId)
+	}
+	transactions, err := service.transactionsService.ListTransactions(TransactionFilter{AccountId: &accountId,
+		From: from, To: to})
+	if err != nil {
+		return err
+	}
+	payees, err := service.payeesService.ListPayees()
+	if err != nil {
+		return err
+	}
+	payeeNames := make(map[int64]string, len(payees))
+	for _, payee := range payees {
+		payeeNames[payee.Id] = payee.Name
+	}
+	categories, err := service.categoriesService.ListCategories()
+	if err != nil {
+		return err
+	}
+	categoryPaths := CategoryPaths(categories)
+
+	qifType := "Bank"
+	if account.AccountType == ofxCreditCardAccountType {
+		qifType = "CCard"
+	}
+	output := bufio.NewWriter(writer)
+	fmt.Fprintf(output, "!Account\nN%s\nT%s\n^\n!Type:%s\n", qifText(account.Name), qifType, qifType)
+	// transactions are listed latest first
+	for i := len(transactions) - 1; i >= 0; i-- {
+		transaction := transactions[i]
+		fmt.Fprintf(output, "D%s\nT%s\n", transaction.TransactionTime.Format(qifDateLayout),
+			formatQIFAmount(transaction.Amount))
+		payee := transaction.Description
+		if name, ok := payeeNames[transaction.PayeeId]; ok {
+			payee = name
+		}
+		if payee != "" {
+			fmt.Fprintf(output, "P%s\n", qifText(payee))
+		}
+		if transaction.Memo != "" {
+			fmt.Fprintf(output, "M%s\n", qifText(transaction.Memo))
+		}
+		if transaction.TransactionType == TransferTransactionType {
+			otherAccount, err := service.transferAccountName(transaction)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(output, "L[%s]\n", qifText(otherAccount))
+		} else if path, ok := categoryPaths[transaction.CategoryId]; ok {
+			fmt.Fprintf(output, "L%s\n", qifText(path))
+		}
+		for _, split := range transaction.Splits {
+			fmt.Fprintf(output, "S%s\n", qifText(categoryPaths[split.CategoryId]))
+			if split.Memo != "" {
+				fmt.Fprintf(output, "E%s\n", qifText(split.Memo))
+			}
+			fmt.Fprintf(output, "$%s\n", formatQIFAmount(split.Amount))
+		}
+		fmt.Fprintln(output, "^")
+	}
+	if err = output.Flush(); err != nil {
+		return fmt.Errorf("failed to write qif file with err:%v", err)
+	}
+	return nil
+}
+
+// transferAccountName returns the name of the account of the other leg of a transfer.
+func (service *ExportService) transferAccountName(transaction Transaction) (string, error) {
+	linked, err := service.transactionsService.GetTransaction(transaction.LinkedTransactionId)
+	if err != nil {
+		return "", err
+	}
+	account, err := service.accountsService.GetAccount(linked.AccountId)
+	if err != nil {
+		return "", err
+	}
+	return account.Name, nil
+}
+
+// qifText keeps values on a single line since every line of a qif file is a field.
+func qifText(value string) string {
+	return strings.Join(strings.Fields(value), " ")
+}
+
+func formatQIFAmount(amount Money) string {
+	return amount.String()
+}
@@ -0,0 +1,265 @@
+package api
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+const qifStatement = "\ufeff!Account\r\nNEveryday Checking\r\nTBank\r\n^\r\n!Type:Bank\r\n" + `D7/ 1'22
+T-1,250.00
+PLandlord
+MJuly rent
+LHousing:Rent
+^
+D07/03/2022
+T-80.00
+PCostco
+LFood:Groceries/Household
+SFood:Groceries
+EFood
+$-60.00
+SHome
+$-20.00
+^
+D7/4/22
+T-500.00
+PTransfer to savings
+L[Savings]
+^
+D13/45/2022
+T-1.00
+^
+D7/5/2022
+U45.50
+PRefund
+`
+
+// qifPayeesMock knows the payee Amazon and records the payees created by imports.
+type qifPayeesMock struct {
+	payeesMock
+	inserted []string
+}
+
+func (m *qifPayeesMock) InsertPayee(request PayeeCreationRequest) (int64, error) {
+	m.inserted = append(m.inserted, *request.Name)
+	return int64(10 + len(m.inserted)), nil
+}
+
+// qifCategoriesMock records the categories created by imports.
+type qifCategoriesMock struct {
+	categoriesMock
+	inserted []CategoryCreationRequest
+}
+
+func (m *qifCategoriesMock) InsertCategory(request CategoryCreationRequest) (int64, error) {
+	m.inserted = append(m.inserted, request)
+	return int64(20 + len(m.inserted)), nil
+}
+
+// qifTransactionsMock lists a split purchase and a transfer to account 2, latest first.
+type qifTransactionsMock struct {
+	happyTransactionsMock
+	filter TransactionFilter
+}
+
+func (m *qifTransactionsMock) ListTransactions(filter TransactionFilter) ([]Transaction, error) {
+	m.filter = filter
+	return []Transaction{
+		{Id: 2, TransactionTime: time.Date(2022, 7, 4, 0, 0, 0, 0, time.UTC), TransactionType: TransferTransactionType,
+			AccountId: 1, Description: "to savings", Amount: -50000, LinkedTransactionId: 3},
+		{Id: 1, TransactionTime: time.Date(2022, 7, 3, 0, 0, 0, 0, time.UTC), TransactionType: NormalTransactionType,
+			AccountId: 1, Description: "COSTCO WHSE #1", Memo: "weekly\nshopping", Amount: -8000, PayeeId: 1,
+			CategoryId: 2, Splits: []TransactionSplit{{CategoryId: 2, Amount: -6000, Memo: "food"},
+				{CategoryId: 4, Amount: -2000}}},
+	}, nil
+}
+
+func (m *qifTransactionsMock) GetTransaction(id int64) (Transaction, error) {
+	return Transaction{Id: id, AccountId: 2}, nil
+}
+
+// qifAccountsMock names account 2 Savings.
+type qifAccountsMock struct {
+	happyMock
+}
+
+func (m *qifAccountsMock) GetAccount(id int64) (Account, error) {
+	account, err := m.happyMock.GetAccount(id)
+	if id == 2 {
+		account.Name = "Savings"
+	}
+	return account, err
+}
+
+func TestParseQIF(t *testing.T) {
+
+	t.Run("testing happy flow of parsing a qif file", func(t *testing.T) {
+		rows, err := ParseQIF(strings.NewReader(qifStatement))
+		if err != nil {
+			t.Errorf("unexpected error when parsing qif file: %v", err)
+			return
+		}
+		if len(rows) != 5 {
+			t.Errorf("expected 5 rows but found %d", len(rows))
+			return
+		}
+		rent := rows[0]
+		expectedTime := time.Date(2022, 7, 1, 0, 0, 0, 0, time.UTC)
+		if rent.Line != 6 || rent.Amount != -125000 || rent.Payee != "Landlord" || rent.Memo != "July rent" ||
+			rent.Category != "Housing:Rent" || !rent.TransactionTime.Equal(expectedTime) {
+			t.Errorf("unexpected row %+v", rent)
+		}
+		groceries := rows[1]
+		expectedSplits := []ImportSplit{{Category: "Food:Groceries", Memo: "Food", Amount: -6000}, {Category: "Home",
+			Amount: -2000}}
+		if groceries.Category != "Food:Groceries" || len(groceries.Splits) != 2 ||
+			groceries.Splits[0] != expectedSplits[0] || groceries.Splits[1] != expectedSplits[1] {
+			t.Errorf("unexpected category %s or splits %+v", groceries.Category, groceries.Splits)
+		}
+		if rows[2].Category != "" || rows[2].Error != "" {
+			t.Errorf("expected the transfer to be imported without a category but found %+v", rows[2])
+		}
+		if rows[3].Error != "date \"13/45/2022\" is not a qif date" {
+			t.Errorf("unexpected error %s", rows[3].Error)
+		}
+		if rows[4].Amount != 4550 || rows[4].Error != "" {
+			t.Errorf("expected the unterminated last transaction to be read but found %+v", rows[4])
+		}
+	})
+
+	t.Run("testing splits that do not add up are reported", func(t *testing.T) {
+		rows, err := ParseQIF(strings.NewReader(strings.Replace(qifStatement, "$-20.00", "$-25.00", 1)))
+		if err != nil {
+			t.Errorf("unexpected error when parsing qif file: %v", err)
+			return
+		}
+		expectedErrorMsg := "splits add up to -85.00 instead of the amount -80.00"
+		if rows[1].Error != expectedErrorMsg {
+			t.Errorf("expected error message %s but found %s", expectedErrorMsg, rows[1].Error)
+		}
+	})
+
+	t.Run("testing investment sections are skipped", func(t *testing.T) {
+		rows, err := ParseQIF(strings.NewReader("!Type:Invst\nD7/1/22\nNBuy\nT100\n^\n!Type:CCard\nD7/2/22\nT-5\n^\n"))
+		if err != nil || len(rows) != 1 || rows[0].Amount != -500 {
+			t.Errorf("unexpected rows %+v with err %v", rows, err)
+		}
+	})
+
+	t.Run("testing files of several accounts are rejected", func(t *testing.T) {
+		statement := qifStatement + "^\n!Account\nNSavings\n^\n!Type:Bank\nD7/6/22\nT10\n^\n"
+		_, err := ParseQIF(strings.NewReader(statement))
+		if !errors.Is(err, ErrInvalidRequest) {
+			t.Errorf("expected invalid request error but found %v", err)
+		}
+	})
+
+	t.Run("testing files without a type header are rejected", func(t *testing.T) {
+		_, err := ParseQIF(strings.NewReader("Date,Amount\n2022-07-01,10\n"))
+		if !errors.Is(err, ErrInvalidRequest) {
+			t.Errorf("expected invalid request error but found %v", err)
+		}
+	})
+}
+
+func TestImportService_ImportQIF(t *testing.T) {
+
+	t.Run("testing happy flow of importing payees and categories by name", func(t *testing.T) {
+		transactionsDAO := happyTransactionsMock{}
+		payeesDAO := qifPayeesMock{}
+		categoriesDAO := qifCategoriesMock{}
+		importService := NewImportService(&importProfilesMock{},
+			NewTransactionsService(&transactionsDAO, nil, nil), nil, nil, NewPayeesService(&payeesDAO),
+			NewCategoriesService(&categoriesDAO, nil))
+		statement := "!Type:Bank\nD7/1/22\nT-30\nPamazon\nLFood:Restaurants:Brunch\n^\nD7/2/22\nT-30\nPBakery\n" +
+			"SFood:Restaurants:Brunch\n$-10\nSGifts\n$-20\n^\n"
+		result, err := importService.ImportQIF(3, strings.NewReader(statement), nil)
+		if err != nil {
+			t.Errorf("unexpected error when importing qif file: %v", err)
+			return
+		}
+		if result.Imported != 2 || len(payeesDAO.inserted) != 1 || payeesDAO.inserted[0] != "Bakery" {
+			t.Errorf("unexpected result %+v or payees created %v", result, payeesDAO.inserted)
+		}
+		if len(categoriesDAO.inserted) != 2 || *categoriesDAO.inserted[0].Name != "Brunch" ||
+			*categoriesDAO.inserted[0].ParentId != 3 || categoriesDAO.inserted[1].ParentId != nil {
+			t.Errorf("unexpected categories created %+v", categoriesDAO.inserted)
+		}
+		inserted := transactionsDAO.inserted
+		if *inserted.PayeeId != 11 || len(inserted.Splits) != 2 || *inserted.Splits[0].CategoryId != 21 ||
+			*inserted.Splits[1].CategoryId != 22 {
+			t.Errorf("unexpected transaction inserted %+v", inserted)
+		}
+	})
+
+	t.Run("testing validation of empty category names", func(t *testing.T) {
+		importService := NewImportService(&importProfilesMock{},
+			NewTransactionsService(&happyTransactionsMock{}, nil, nil), nil, nil, NewPayeesService(&qifPayeesMock{}),
+			NewCategoriesService(&qifCategoriesMock{}, nil))
+		_, err := importService.ImportQIF(3, strings.NewReader("!Type:Bank\nD7/1/22\nT-30\nLFood::Brunch\n^\n"),
+			nil)
+		if !errors.Is(err, ErrInvalidRequest) {
+			t.Errorf("expected invalid request error but found %v", err)
+		}
+	})
+}
+
+func TestExportService_ExportQIF(t *testing.T) {
+
+	t.Run("testing happy flow of exporting an account", func(t *testing.T) {
+		transactionsDAO := qifTransactionsMock{}
+		exportService := NewExportService(NewTransactionsService(&transactionsDAO, nil, nil),
+			NewAccountsService(&qifAccountsMock{}, nil), NewPayeesService(&payeesMock{}),
+			NewCategoriesService(&categoriesMock{}, nil))
+		from := time.Date(2022, 7, 1, 0, 0, 0, 0, time.UTC)
+		var file bytes.Buffer
+		if err := exportService.ExportQIF(1, &from, nil, &file); err != nil {
+			t.Errorf("unexpected error when exporting account: %v", err)
+			return
+		}
+		expected := "!Account\nNacc1\nTBank\n^\n!Type:Bank\n" +
+			"D07/03/2022\nT-80.00\nPAmazon\nMweekly shopping\nLFood:Groceries\n" +
+			"SFood:Groceries\nEfood\n$-60.00\nSFood:Restaurants:Coffee\n$-20.00\n^\n" +
+			"D07/04/2022\nT-500.00\nPto savings\nL[Savings]\n^\n"
+		if file.String() != expected {
+			t.Errorf("expected qif file\n%s\nbut found\n%s", expected, file.String())
+		}
+		if *transactionsDAO.filter.AccountId != 1 || !transactionsDAO.filter.From.Equal(from) {
+			t.Errorf("unexpected filter %+v", transactionsDAO.filter)
+		}
+	})
+
+	t.Run("testing exported files can be imported again", func(t *testing.T) {
+		exportService := NewExportService(NewTransactionsService(&qifTransactionsMock{}, nil, nil),
+			NewAccountsService(&qifAccountsMock{}, nil), NewPayeesService(&payeesMock{}),
+			NewCategoriesService(&categoriesMock{}, nil))
+		var file bytes.Buffer
+		if err := exportService.ExportQIF(1, nil, nil, &file); err != nil {
+			t.Errorf("unexpected error when exporting account: %v", err)
+			return
+		}
+		rows, err := ParseQIF(&file)
+		if err != nil || len(rows) != 2 {
+			t.Errorf("unexpected rows %+v with err %v", rows, err)
+			return
+		}
+		if rows[0].Payee != "Amazon" || rows[0].Amount != -8000 || len(rows[0].Splits) != 2 ||
+			rows[0].Splits[1].Category != "Food:Restaurants:Coffee" || rows[1].Category != "" {
+			t.Errorf("unexpected rows %+v", rows)
+		}
+	})
+
+	t.Run("testing general error flow of exporting an account", func(t *testing.T) {
+		exportService := NewExportService(NewTransactionsService(&errorTransactionsMock{}, nil, nil),
+			NewAccountsService(&happyMock{}, nil), NewPayeesService(&payeesMock{}),
+			NewCategoriesService(&categoriesMock{}, nil))
+		err := exportService.ExportQIF(1, nil, nil, &bytes.Buffer{})
+		expectedErrorMsg := "failed to list transactions with err:db timeout"
+		if err == nil || err.Error() != expectedErrorMsg {
+			t.Errorf("expected error message %s but found %v", expectedErrorMsg, err)
+		}
+	})
+}
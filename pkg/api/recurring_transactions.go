@@ -0,0 +1,414 @@
+package api
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+const RecurrenceDaily = "daily"
+const RecurrenceWeekly = "weekly"
+const RecurrenceMonthly = "monthly"
+const RecurrenceYearly = "yearly"
+
+// LastWeekOfMonth schedules a monthly recurring transaction on the last given weekday of the month.
+const LastWeekOfMonth = -1
+
+const defaultUpcomingDays = 30
+const maxUpcomingDays = 366
+
+// maxOccurrences bounds the search for the occurrences of a schedule.
+const maxOccurrences = 100000
+
+// maxReportDay is later than any occurrence.
+const maxReportDay = "9999-12-31"
+
+// RecurringTransaction is a template of a transaction that repeats every Interval days, weeks, months or years from
+// StartDate until EndDate, both formatted as 2006-01-02. Monthly schedules fall on the day of the month of StartDate,
+// or with a WeekOfMonth on the nth DayOfWeek of the month, like the second tuesday, where 0 is sunday. Days missing
+// from shorter months fall on their last day. NextDate is the next occurrence that has not been materialized into a
+// transaction yet and is empty once the schedule has ended.
+type RecurringTransaction struct {
+	Id          int64     `json:"id"`
+	Name        string    `json:"name"`
+	AccountId   int64     `json:"account_id"`
+	Description string    `json:"description"`
+	Memo        string    `json:"memo"`
+	Amount      Money     `json:"amount"`
+	Currency    string    `json:"currency"`
+	PayeeId     int64     `json:"payee_id"`
+	CategoryId  int64     `json:"category_id"`
+	Frequency   string    `json:"frequency"`
+	Interval    int       `json:"interval"`
+	WeekOfMonth int       `json:"week_of_month"`
+	DayOfWeek   int       `json:"day_of_week"`
+	StartDate   string    `json:"start_date"`
+	EndDate     string    `json:"end_date"`
+	NextDate    string    `json:"next_date"`
+	IsDeleted   bool      `json:"is_deleted"`
+	CreatedTs   time.Time `json:"created_ts"`
+	UpdatedTs   time.Time `json:"updated_ts"`
+}
+
+// RecurringTransactionRequest is used to both create and update a recurring transaction. Interval defaults to 1 and
+// StartDate to today.
+type RecurringTransactionRequest struct {
+	Name        *string `json:"name"`
+	AccountId   *int64  `json:"account_id"`
+	Description *string `json:"description"`
+	Memo        *string `json:"memo"`
+	Amount      *Money  `json:"amount"`
+	Currency    *string `json:"currency"`
+	PayeeId     *int64  `json:"payee_id"`
+	CategoryId  *int64  `json:"category_id"`
+	Frequency   *string `json:"frequency"`
+	Interval    *int    `json:"interval"`
+	WeekOfMonth *int    `json:"week_of_month"`
+	DayOfWeek   *int    `json:"day_of_week"`
+	StartDate   *string `json:"start_date"`
+	EndDate     *string `json:"end_date"`
+}
+
+// UpcomingBill is an occurrence of a recurring transaction. Overdue occurrences fell before today and have not been
+// materialized yet.
+type UpcomingBill struct {
+	RecurringTransactionId int64  `json:"recurring_transaction_id"`
+	Name                   string `json:"name"`
+	Date                   string `json:"date"`
+	AccountId              int64  `json:"account_id"`
+	Amount                 Money  `json:"amount"`
+	Currency               string `json:"currency"`
+	PayeeId                int64  `json:"payee_id"`
+	CategoryId             int64  `json:"category_id"`
+	Overdue                bool   `json:"overdue"`
+}
+
+type RecurringTransactionsService struct {
+	dao                 RecurringTransactionsDataAccessor
+	transactionsService *TransactionsService
+}
+
+func NewRecurringTransactionsService(dao RecurringTransactionsDataAccessor,
+	transactionsService *TransactionsService) *RecurringTransactionsService {
+	return &RecurringTransactionsService{dao, transactionsService}
+}
+
+func (service *RecurringTransactionsService) GetRecurringTransaction(id int64) (RecurringTransaction, error) {
+	recurring, err := service.dao.GetRecurringTransaction(id)
+	if err != nil {
+		return recurring, fmt.Errorf("failed to retrieve recurring transaction of id %d with err:%v", id, err)
+	}
+	return recurring, nil
+}
+
+func (service *RecurringTransactionsService) ListRecurringTransactions() ([]RecurringTransaction, error) {
+	recurring, err := service.dao.ListRecurringTransactions()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list recurring transactions with err:%v", err)
+	}
+	return recurring, nil
+}
+
+// CreateRecurringTransaction schedules the first occurrence on or after the start date.
+func (service *RecurringTransactionsService) CreateRecurringTransaction(request RecurringTransactionRequest) (int64,
+	error) {
+	request, err := service.validateRecurringTransaction(request)
+	if err != nil {
+		return -1, err
+	}
+	schedule := recurringTransactionFromRequest(request)
+	return service.dao.InsertRecurringTransaction(request, nextOccurrence(schedule, schedule.StartDate))
+}
+
+// UpdateRecurringTransaction reschedules the next occurrence on the new schedule, on or after the occurrence that
+// was due next, so that no occurrence is materialized twice. Schedules that had ended pick up again from today.
+func (service *RecurringTransactionsService) UpdateRecurringTransaction(id int64,
+	request RecurringTransactionRequest) error {
+	request, err := service.validateRecurringTransaction(request)
+	if err != nil {
+		return err
+	}
+	existing, err := service.dao.GetRecurringTransaction(id)
+	if err != nil {
+		return err
+	}
+	from := existing.NextDate
+	if from == "" {
+		from = time.Now().UTC().Format(ReportDateLayout)
+	}
+	schedule := recurringTransactionFromRequest(request)
+	return service.dao.UpdateRecurringTransaction(id, request, nextOccurrence(schedule, from))
+}
+
+func (service *RecurringTransactionsService) DeleteRecurringTransaction(id int64) error {
+	err := service.dao.DeleteRecurringTransaction(id)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// MaterializeTransactions creates the transactions of every occurrence due on or before until and returns how many
+// were created. Each transaction carries an external id naming its occurrence, so an occurrence that is already in
+// the ledger is skipped rather than created twice.
+func (service *RecurringTransactionsService) MaterializeTransactions(until time.Time) (int, error) {
+	recurringTransactions, err := service.ListRecurringTransactions()
+	if err != nil {
+		return 0, err
+	}
+	lastDay := until.UTC().Format(ReportDateLayout)
+	created := 0
+	for _, recurring := range recurringTransactions {
+		for recurring.NextDate != "" && recurring.NextDate <= lastDay {
+			isNew, err := service.materializeOccurrence(recurring)
+			if err != nil {
+				return created, err
+			}
+			if isNew {
+				created++
+			}
+			next := nextOccurrence(recurring, nextDay(recurring.NextDate))
+			if err = service.dao.AdvanceRecurringTransaction(recurring.Id, next); err != nil {
+				return created, fmt.Errorf("failed to advance recurring transaction %d with err:%v", recurring.Id,
+					err)
+			}
+			recurring.NextDate = occurrenceOrEnd(next)
+		}
+	}
+	return created, nil
+}
+
+// materializeOccurrence creates the transaction of the next occurrence. The boolean is false when the ledger has it
+// already.
+func (service *RecurringTransactionsService) materializeOccurrence(recurring RecurringTransaction) (bool, error) {
+	externalId := fmt.Sprintf("recurring-%d-%s", recurring.Id, recurring.NextDate)
+	_, found, err := service.transactionsService.FindTransactionByExternalId(recurring.AccountId, externalId)
+	if err != nil || found {
+		return false, err
+	}
+	transactionTime, err := time.Parse(ReportDateLayout, recurring.NextDate)
+	if err != nil {
+		return false, fmt.Errorf("failed to read next date %q of recurring transaction %d", recurring.NextDate,
+			recurring.Id)
+	}
+	description := recurring.Description
+	if description == "" {
+		description = recurring.Name
+	}
+	request := TransactionCreationRequest{TransactionTime: &transactionTime, AccountId: &recurring.AccountId,
+		Description: &description, Amount: &recurring.Amount, ExternalId: &externalId}
+	if recurring.Memo != "" {
+		request.Memo = &recurring.Memo
+	}
+	if recurring.Currency != "" {
+		request.Currency = &recurring.Currency
+	}
+	if recurring.PayeeId != 0 {
+		request.PayeeId = &recurring.PayeeId
+	}
+	if recurring.CategoryId != 0 {
+		request.CategoryId = &recurring.CategoryId
+	}
+	if _, err = service.transactionsService.CreateTransaction(request); err != nil {
+		return false, fmt.Errorf("failed to materialize recurring transaction %d on %s with err:%v", recurring.Id,
+			recurring.NextDate, err)
+	}
+	return true, nil
+}
+
+// ListUpcomingBills lists the occurrences due from the next occurrence of every recurring transaction until days
+// after today, ordered by date. days defaults to 30.
+func (service *RecurringTransactionsService) ListUpcomingBills(today time.Time, days int) ([]UpcomingBill, error) {
+	if days == 0 {
+		days = defaultUpcomingDays
+	}
+	if days < 0 || days > maxUpcomingDays {
+		return nil, fmt.Errorf("%w: days has to be between 1 and %d", ErrInvalidRequest, maxUpcomingDays)
+	}
+	recurringTransactions, err := service.ListRecurringTransactions()
+	if err != nil {
+		return nil, err
+	}
+	first := today.UTC().Format(ReportDateLayout)
+	last := today.UTC().AddDate(0, 0, days).Format(ReportDateLayout)
+	bills := []UpcomingBill{}
+	for _, recurring := range recurringTransactions {
+		for date := recurring.NextDate; date != "" && date <= last; {
+			bills = append(bills, UpcomingBill{RecurringTransactionId: recurring.Id, Name: recurring.Name,
+				Date: date, AccountId: recurring.AccountId, Amount: recurring.Amount, Currency: recurring.Currency,
+				PayeeId: recurring.PayeeId, CategoryId: recurring.CategoryId, Overdue: date < first})
+			date = occurrenceOrEnd(nextOccurrence(recurring, nextDay(date)))
+		}
+	}
+	sort.SliceStable(bills, func(i, j int) bool {
+		return bills[i].Date < bills[j].Date
+	})
+	return bills, nil
+}
+
+// validateRecurringTransaction checks the currency against the account like new transactions are, so that every
+// occurrence can be materialized.
+func (service *RecurringTransactionsService) validateRecurringTransaction(request RecurringTransactionRequest) (
+	RecurringTransactionRequest, error) {
+	if isBlank(request.Name) {
+		return request, fmt.Errorf("%w: name is required", ErrInvalidRequest)
+	}
+	if request.AccountId == nil {
+		return request, fmt.Errorf("%w: account_id is required", ErrInvalidRequest)
+	}
+	if request.Amount == nil {
+		return request, fmt.Errorf("%w: amount is required", ErrInvalidRequest)
+	}
+	currency, err := service.transactionsService.validateCurrency(*request.AccountId, request.Currency)
+	if err != nil {
+		return request, err
+	}
+	request.Currency = currency
+	if request.Frequency == nil {
+		return request, fmt.Errorf("%w: frequency is required", ErrInvalidRequest)
+	}
+	frequency := strings.ToLower(strings.TrimSpace(*request.Frequency))
+	switch frequency {
+	case RecurrenceDaily, RecurrenceWeekly, RecurrenceMonthly, RecurrenceYearly:
+		request.Frequency = &frequency
+	default:
+		return request, fmt.Errorf("%w: frequency has to be daily, weekly, monthly or yearly instead of %s",
+			ErrInvalidRequest, *request.Frequency)
+	}
+	if request.Interval == nil {
+		interval := 1
+		request.Interval = &interval
+	}
+	if *request.Interval < 1 {
+		return request, fmt.Errorf("%w: interval has to be at least 1", ErrInvalidRequest)
+	}
+	if request.WeekOfMonth != nil || request.DayOfWeek != nil {
+		if frequency != RecurrenceMonthly || request.WeekOfMonth == nil || request.DayOfWeek == nil {
+			return request, fmt.Errorf("%w: week_of_month and day_of_week go together on monthly schedules",
+				ErrInvalidRequest)
+		}
+		if *request.WeekOfMonth != LastWeekOfMonth && (*request.WeekOfMonth < 1 || *request.WeekOfMonth > 4) {
+			return request, fmt.Errorf("%w: week_of_month has to be between 1 and 4, or -1 for the last week",
+				ErrInvalidRequest)
+		}
+		if *request.DayOfWeek < 0 || *request.DayOfWeek > 6 {
+			return request, fmt.Errorf("%w: day_of_week has to be between 0 for sunday and 6 for saturday",
+				ErrInvalidRequest)
+		}
+	}
+	if isBlank(request.StartDate) {
+		startDate := time.Now().UTC().Format(ReportDateLayout)
+		request.StartDate = &startDate
+	} else if _, err := time.Parse(ReportDateLayout, *request.StartDate); err != nil {
+		return request, fmt.Errorf("%w: start_date %q has to be formatted as 2006-01-02", ErrInvalidRequest,
+			*request.StartDate)
+	}
+	if isBlank(request.EndDate) {
+		request.EndDate = nil
+	} else if _, err := time.Parse(ReportDateLayout, *request.EndDate); err != nil {
+		return request, fmt.Errorf("%w: end_date %q has to be formatted as 2006-01-02", ErrInvalidRequest,
+			*request.EndDate)
+	} else if *request.EndDate < *request.StartDate {
+		return request, fmt.Errorf("%w: end_date cannot be before start_date", ErrInvalidRequest)
+	}
+	return request, nil
+}
+
+// recurringTransactionFromRequest reads the schedule of a validated request.
+func recurringTransactionFromRequest(request RecurringTransactionRequest) RecurringTransaction {
+	recurring := RecurringTransaction{Frequency: *request.Frequency, Interval: *request.Interval,
+		StartDate: *request.StartDate}
+	if request.WeekOfMonth != nil {
+		recurring.WeekOfMonth = *request.WeekOfMonth
+		recurring.DayOfWeek = *request.DayOfWeek
+	}
+	if request.EndDate != nil {
+		recurring.EndDate = *request.EndDate
+	}
+	return recurring
+}
+
+// nextOccurrence is the first occurrence of the schedule on or after the day, or nil when the schedule ends before.
+func nextOccurrence(recurring RecurringTransaction, day string) *string {
+	start, err := time.Parse(ReportDateLayout, recurring.StartDate)
+	if err != nil {
+		return nil
+	}
+	for n := 0; n < maxOccurrences; n++ {
+		occurrence := nthOccurrence(recurring, start, n).Format(ReportDateLayout)
+		if recurring.EndDate != "" && occurrence > recurring.EndDate {
+			return nil
+		}
+		if occurrence >= day && occurrence >= recurring.StartDate {
+			return &occurrence
+		}
+	}
+	return nil
+}
+
+// nthOccurrence is the occurrence n periods after the start, counting from 0. Monthly schedules on a weekday can
+// fall before the start in its first month.
+func nthOccurrence(recurring RecurringTransaction, start time.Time, n int) time.Time {
+	periods := n * recurring.Interval
+	switch recurring.Frequency {
+	case RecurrenceWeekly:
+		return start.AddDate(0, 0, 7*periods)
+	case RecurrenceMonthly:
+		if recurring.WeekOfMonth != 0 {
+			return nthWeekdayOfMonth(start.Year(), start.Month()+time.Month(periods), recurring.WeekOfMonth,
+				time.Weekday(recurring.DayOfWeek))
+		}
+		return addMonths(start, periods)
+	case RecurrenceYearly:
+		return addMonths(start, 12*periods)
+	}
+	return start.AddDate(0, 0, periods)
+}
+
+// addMonths moves the day months ahead, falling on the last day of shorter months.
+func addMonths(day time.Time, months int) time.Time {
+	lastDay := time.Date(day.Year(), day.Month()+time.Month(months)+1, 0, 0, 0, 0, 0, time.UTC)
+	if day.Day() > lastDay.Day() {
+		return lastDay
+	}
+	return time.Date(day.Year(), day.Month()+time.Month(months), day.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// nthWeekdayOfMonth is the week-th weekday of the month, or the last one for LastWeekOfMonth. Months beyond December
+// roll over into the following years.
+func nthWeekdayOfMonth(year int, month time.Month, week int, weekday time.Weekday) time.Time {
+	if week == LastWeekOfMonth {
+		lastDay := time.Date(year, month+1, 0, 0, 0, 0, 0, time.UTC)
+		return lastDay.AddDate(0, 0, -((int(lastDay.Weekday()) - int(weekday) + 7) % 7))
+	}
+	firstDay := time.Date(year, month, 1, 0, 0, 0, 0, time.UTC)
+	return firstDay.AddDate(0, 0, (int(weekday)-int(firstDay.Weekday())+7)%7+7*(week-1))
+}
+
+// occurrenceOrEnd is the occurrence, or an empty day once the schedule has ended.
+func occurrenceOrEnd(occurrence *string) string {
+	if occurrence == nil {
+		return ""
+	}
+	return *occurrence
+}
+
+// nextDay is the day after a day formatted as 2006-01-02. Days that cannot be read are followed by the end of time
+// so that nothing loops over them.
+func nextDay(day string) string {
+	parsed, err := time.Parse(ReportDateLayout, day)
+	if err != nil {
+		return maxReportDay
+	}
+	return parsed.AddDate(0, 0, 1).Format(ReportDateLayout)
+}
+
+type RecurringTransactionsDataAccessor interface {
+	GetRecurringTransaction(id int64) (RecurringTransaction, error)
+	ListRecurringTransactions() ([]RecurringTransaction, error)
+	InsertRecurringTransaction(request RecurringTransactionRequest, nextDate *string) (int64, error)
+	UpdateRecurringTransaction(id int64, request RecurringTransactionRequest, nextDate *string) error
+	DeleteRecurringTransaction(id int64) error
+	AdvanceRecurringTransaction(id int64, nextDate *string) error
+}
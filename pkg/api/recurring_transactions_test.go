@@ -0,0 +1,246 @@
+package api
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// recurringTransactionsMock pays rent every month and a weekly cleaner until the middle of July.
+type recurringTransactionsMock struct {
+	nextDate *string
+	advanced []string
+	err      error
+}
+
+func (m *recurringTransactionsMock) GetRecurringTransaction(id int64) (RecurringTransaction, error) {
+	for _, recurring := range m.recurringTransactions() {
+		if recurring.Id == id {
+			return recurring, m.err
+		}
+	}
+	return RecurringTransaction{}, errors.New("sql: no rows in result set")
+}
+
+func (m *recurringTransactionsMock) recurringTransactions() []RecurringTransaction {
+	return []RecurringTransaction{
+		{Id: 1, Name: "Rent", AccountId: 1, Amount: -150000, PayeeId: 3, Frequency: RecurrenceMonthly, Interval: 1,
+			StartDate: "2022-06-01", NextDate: "2022-07-01"},
+		{Id: 2, Name: "Cleaner", AccountId: 1, Description: "CLEANING CO", Amount: -4000,
+			Frequency: RecurrenceWeekly, Interval: 1, StartDate: "2022-07-05", EndDate: "2022-07-12",
+			NextDate: "2022-07-05"},
+		{Id: 3, Name: "Gym", AccountId: 1, Amount: -3000, Frequency: RecurrenceMonthly, Interval: 1,
+			StartDate: "2021-01-01", EndDate: "2021-12-31"},
+	}
+}
+
+func (m *recurringTransactionsMock) ListRecurringTransactions() ([]RecurringTransaction, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	return m.recurringTransactions(), nil
+}
+
+func (m *recurringTransactionsMock) InsertRecurringTransaction(request RecurringTransactionRequest,
+	nextDate *string) (int64, error) {
+	m.nextDate = nextDate
+	return 4, m.err
+}
+
+func (m *recurringTransactionsMock) UpdateRecurringTransaction(id int64, request RecurringTransactionRequest,
+	nextDate *string) error {
+	m.nextDate = nextDate
+	return m.err
+}
+
+func (m *recurringTransactionsMock) DeleteRecurringTransaction(id int64) error {
+	return m.err
+}
+
+func (m *recurringTransactionsMock) AdvanceRecurringTransaction(id int64, nextDate *string) error {
+	m.advanced = append(m.advanced, occurrenceOrEnd(nextDate))
+	return m.err
+}
+
+// recurringLedgerMock has materialized the last visit of the cleaner already.
+type recurringLedgerMock struct {
+	happyTransactionsMock
+	insertedRequests []TransactionCreationRequest
+}
+
+func (m *recurringLedgerMock) FindTransactionByExternalId(accountId int64, externalId string) (int64, bool, error) {
+	return 9, externalId == "recurring-2-2022-07-12", nil
+}
+
+func (m *recurringLedgerMock) InsertTransaction(request TransactionCreationRequest) (int64, error) {
+	m.insertedRequests = append(m.insertedRequests, request)
+	return int64(len(m.insertedRequests)), nil
+}
+
+func TestNextOccurrence(t *testing.T) {
+
+	secondTuesday, lastFriday := 2, LastWeekOfMonth
+	for _, test := range []struct {
+		recurring RecurringTransaction
+		day       string
+		expected  string
+	}{
+		{RecurringTransaction{Frequency: RecurrenceMonthly, Interval: 1, StartDate: "2022-01-31"}, "2022-02-01",
+			"2022-02-28"},
+		{RecurringTransaction{Frequency: RecurrenceMonthly, Interval: 1, StartDate: "2022-01-31"}, "2022-03-01",
+			"2022-03-31"},
+		{RecurringTransaction{Frequency: RecurrenceMonthly, Interval: 3, StartDate: "2022-01-15"}, "2022-01-16",
+			"2022-04-15"},
+		{RecurringTransaction{Frequency: RecurrenceMonthly, Interval: 1, WeekOfMonth: secondTuesday, DayOfWeek: 2,
+			StartDate: "2022-07-20"}, "2022-07-20", "2022-08-09"},
+		{RecurringTransaction{Frequency: RecurrenceMonthly, Interval: 1, WeekOfMonth: lastFriday, DayOfWeek: 5,
+			StartDate: "2022-11-01"}, "2022-12-31", "2023-01-27"},
+		{RecurringTransaction{Frequency: RecurrenceWeekly, Interval: 2, StartDate: "2022-07-01"}, "2022-07-02",
+			"2022-07-15"},
+		{RecurringTransaction{Frequency: RecurrenceYearly, Interval: 1, StartDate: "2024-02-29"}, "2024-03-01",
+			"2025-02-28"},
+		{RecurringTransaction{Frequency: RecurrenceDaily, Interval: 1, StartDate: "2022-07-01",
+			EndDate: "2022-07-03"}, "2022-07-04", ""},
+	} {
+		if next := occurrenceOrEnd(nextOccurrence(test.recurring, test.day)); next != test.expected {
+			t.Errorf("expected %+v to occur next on %q after %s but found %q", test.recurring, test.expected,
+				test.day, next)
+		}
+	}
+}
+
+func TestRecurringTransactionsService_CreateRecurringTransaction(t *testing.T) {
+
+	name, accountId, amount := "Rent", int64(1), Money(-150000)
+	monthly, startDate := RecurrenceMonthly, "2022-07-20"
+
+	t.Run("testing happy flow of creating a recurring transaction on a weekday", func(t *testing.T) {
+		dao := recurringTransactionsMock{}
+		week, tuesday := 2, 2
+		id, err := NewRecurringTransactionsService(&dao, nil).CreateRecurringTransaction(
+			RecurringTransactionRequest{Name: &name, AccountId: &accountId, Amount: &amount, Frequency: &monthly,
+				WeekOfMonth: &week, DayOfWeek: &tuesday, StartDate: &startDate})
+		if err != nil || id != 4 || dao.nextDate == nil || *dao.nextDate != "2022-08-09" {
+			t.Errorf("unexpected id %d, next date %v or error %v", id, dao.nextDate, err)
+		}
+	})
+
+	t.Run("testing validation of recurring transactions", func(t *testing.T) {
+		hourly, weekly, zero, fifth, endDate, badDate := "hourly", RecurrenceWeekly, 0, 5, "2022-07-01", "20/07/2022"
+		for _, request := range []RecurringTransactionRequest{
+			{AccountId: &accountId, Amount: &amount, Frequency: &monthly},
+			{Name: &name, Amount: &amount, Frequency: &monthly},
+			{Name: &name, AccountId: &accountId, Frequency: &monthly},
+			{Name: &name, AccountId: &accountId, Amount: &amount},
+			{Name: &name, AccountId: &accountId, Amount: &amount, Frequency: &hourly},
+			{Name: &name, AccountId: &accountId, Amount: &amount, Frequency: &monthly, Interval: &zero},
+			{Name: &name, AccountId: &accountId, Amount: &amount, Frequency: &weekly, WeekOfMonth: &zero,
+				DayOfWeek: &zero},
+			{Name: &name, AccountId: &accountId, Amount: &amount, Frequency: &monthly, WeekOfMonth: &fifth,
+				DayOfWeek: &zero},
+			{Name: &name, AccountId: &accountId, Amount: &amount, Frequency: &monthly, StartDate: &badDate},
+			{Name: &name, AccountId: &accountId, Amount: &amount, Frequency: &monthly, StartDate: &startDate,
+				EndDate: &endDate},
+		} {
+			_, err := NewRecurringTransactionsService(&recurringTransactionsMock{}, nil).
+				CreateRecurringTransaction(request)
+			if !errors.Is(err, ErrInvalidRequest) {
+				t.Errorf("expected invalid request error for %+v but found %v", request, err)
+			}
+		}
+	})
+
+	t.Run("testing the currency has to be the currency of the account", func(t *testing.T) {
+		euros := "eur"
+		_, err := NewRecurringTransactionsService(&recurringTransactionsMock{},
+			NewTransactionsService(&happyTransactionsMock{}, nil, nil)).CreateRecurringTransaction(
+			RecurringTransactionRequest{Name: &name, AccountId: &accountId, Amount: &amount, Currency: &euros,
+				Frequency: &monthly, StartDate: &startDate})
+		expectedErrorMsg := "invalid request: currency EUR differs from currency USD of account 1"
+		if err == nil || err.Error() != expectedErrorMsg {
+			t.Errorf("expected error message %s but found %v", expectedErrorMsg, err)
+		}
+	})
+}
+
+func TestRecurringTransactionsService_UpdateRecurringTransaction(t *testing.T) {
+
+	name, accountId, amount, weekly := "Cleaner", int64(1), Money(-4500), RecurrenceWeekly
+	startDate := "2022-07-06"
+	dao := recurringTransactionsMock{}
+	err := NewRecurringTransactionsService(&dao, nil).UpdateRecurringTransaction(2, RecurringTransactionRequest{
+		Name: &name, AccountId: &accountId, Amount: &amount, Frequency: &weekly, StartDate: &startDate})
+	if err != nil || dao.nextDate == nil || *dao.nextDate != "2022-07-06" {
+		t.Errorf("expected the schedule to move to the new weekday but found %v or error %v", dao.nextDate, err)
+	}
+}
+
+func TestRecurringTransactionsService_MaterializeTransactions(t *testing.T) {
+
+	until := time.Date(2022, 7, 20, 15, 0, 0, 0, time.UTC)
+
+	t.Run("testing happy flow of materializing due occurrences", func(t *testing.T) {
+		dao := recurringTransactionsMock{}
+		ledger := recurringLedgerMock{}
+		transactionsService := NewTransactionsService(&ledger, nil, nil)
+		created, err := NewRecurringTransactionsService(&dao, transactionsService).MaterializeTransactions(until)
+		if err != nil || created != 2 {
+			t.Errorf("expected the rent and the first visit of the cleaner but found %d or error %v", created, err)
+			return
+		}
+		rent := ledger.insertedRequests[0]
+		if !rent.TransactionTime.Equal(time.Date(2022, 7, 1, 0, 0, 0, 0, time.UTC)) || *rent.Description != "Rent" ||
+			*rent.Amount != -150000 || *rent.PayeeId != 3 || *rent.ExternalId != "recurring-1-2022-07-01" {
+			t.Errorf("unexpected rent %+v", rent)
+		}
+		if *ledger.insertedRequests[1].Description != "CLEANING CO" {
+			t.Errorf("expected the description of the cleaner but found %+v", ledger.insertedRequests[1])
+		}
+		if len(dao.advanced) != 3 || dao.advanced[0] != "2022-08-01" || dao.advanced[1] != "2022-07-12" ||
+			dao.advanced[2] != "" {
+			t.Errorf("unexpected next dates %v", dao.advanced)
+		}
+	})
+
+	t.Run("testing general error flow of materializing", func(t *testing.T) {
+		recurringTransactionsService := NewRecurringTransactionsService(
+			&recurringTransactionsMock{err: errors.New("db timeout")}, nil)
+		_, err := recurringTransactionsService.MaterializeTransactions(until)
+		expectedErrorMsg := "failed to list recurring transactions with err:db timeout"
+		if err == nil || err.Error() != expectedErrorMsg {
+			t.Errorf("expected error message %s but found %v", expectedErrorMsg, err)
+		}
+	})
+}
+
+func TestRecurringTransactionsService_ListUpcomingBills(t *testing.T) {
+
+	today := time.Date(2022, 7, 10, 8, 0, 0, 0, time.UTC)
+
+	t.Run("testing happy flow of listing upcoming bills", func(t *testing.T) {
+		bills, err := NewRecurringTransactionsService(&recurringTransactionsMock{}, nil).ListUpcomingBills(today, 0)
+		if err != nil {
+			t.Errorf("unexpected error when listing upcoming bills: %v", err)
+			return
+		}
+		var dates []string
+		for _, bill := range bills {
+			dates = append(dates, bill.Date)
+		}
+		if len(bills) != 4 || dates[0] != "2022-07-01" || dates[1] != "2022-07-05" || dates[2] != "2022-07-12" ||
+			dates[3] != "2022-08-01" {
+			t.Errorf("unexpected dates %v", dates)
+			return
+		}
+		if !bills[0].Overdue || !bills[1].Overdue || bills[2].Overdue || bills[3].Name != "Rent" {
+			t.Errorf("unexpected bills %+v", bills)
+		}
+	})
+
+	t.Run("testing validation of the number of days", func(t *testing.T) {
+		_, err := NewRecurringTransactionsService(&recurringTransactionsMock{}, nil).ListUpcomingBills(today, 400)
+		if !errors.Is(err, ErrInvalidRequest) {
+			t.Errorf("expected invalid request error but found %v", err)
+		}
+	})
+}
@@ -0,0 +1,449 @@
+package api
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+const ReportGranularityDaily = "daily"
+const ReportGranularityWeekly = "weekly"
+const ReportGranularityMonthly = "monthly"
+const ReportGranularityQuarterly = "quarterly"
+const ReportGranularityYearly = "yearly"
+
+const CashFlowGroupByCategory = "category"
+const CashFlowGroupByPayee = "payee"
+const CashFlowGroupByTag = "tag"
+
+// ReportDateLayout formats the days reports start, end and are bucketed on.
+const ReportDateLayout = "2006-01-02"
+
+// maxReportPoints bounds the number of points of a report so that a long range at a fine granularity stays cheap.
+const maxReportPoints = 1000
+
+// liabilityAccountTypes are the account types whose balances are owed rather than owned, on top of the credit card
+// and loan types. Every other type of account holds assets.
+var liabilityAccountTypes = map[string]bool{
+	"liability":   true,
+	"liabilities": true,
+}
+
+// IsLiabilityAccountType tells whether accounts of the type, like "Credit Cards", "loan" or "mortgage", hold
+// liabilities.
+func IsLiabilityAccountType(accountType string) bool {
+	return IsCreditCardAccountType(accountType) || IsLoanAccountType(accountType) ||
+		liabilityAccountTypes[normalizeAccountType(accountType)]
+}
+
+// AccountDailyTotal is the sum of the transactions of an account on a day formatted as 2006-01-02.
+type AccountDailyTotal struct {
+	AccountId int64
+	Day       string
+	Amount    Money
+}
+
+// NetWorthPoint is the worth at the end of Date. Liabilities are what is owed, so a credit card balance of -120.00
+// adds 120.00 to them, and NetWorth is Assets minus Liabilities.
+type NetWorthPoint struct {
+	Date        string `json:"date"`
+	Assets      Money  `json:"assets"`
+	Liabilities Money  `json:"liabilities"`
+	NetWorth    Money  `json:"net_worth"`
+}
+
+// NetWorthReport has a point at the end of every period of the granularity between From and To, where the last
+// period is cut short at To.
+type NetWorthReport struct {
+	From        string          `json:"from"`
+	To          string          `json:"to"`
+	Granularity string          `json:"granularity"`
+	Currency    string          `json:"currency"`
+	Points      []NetWorthPoint `json:"points"`
+}
+
+// CashFlowTotal is the income and the expenses of a group of transactions in a currency on a day formatted as
+// 2006-01-02. GroupId is the id of the category or payee and Group the name of the payee or tag. Transactions outside
+// of any group have neither.
+type CashFlowTotal struct {
+	GroupId  int64
+	Group    string
+	Currency string
+	Day      string
+	Income   Money
+	Expenses Money
+}
+
+// CashFlowAmounts splits the transactions of a period into Income, the positive amounts, and Expenses, the negative
+// amounts written as a positive total. Net is Income minus Expenses. Period is left out of the totals of whole rows.
+type CashFlowAmounts struct {
+	Period   string `json:"period,omitempty"`
+	Income   Money  `json:"income"`
+	Expenses Money  `json:"expenses"`
+	Net      Money  `json:"net"`
+}
+
+// CashFlowRow is the cash flow of a category, payee or tag in every period of the report. The row of transactions
+// outside of any group has a GroupId of 0 and an empty Group.
+type CashFlowRow struct {
+	GroupId int64             `json:"group_id"`
+	Group   string            `json:"group"`
+	Periods []CashFlowAmounts `json:"periods"`
+	Total   CashFlowAmounts   `json:"total"`
+}
+
+// CashFlowReport is a table with a row per group and a column per period, along with the totals of every period.
+// Periods are labelled like 2022-07, 2022-Q3 or 2022.
+type CashFlowReport struct {
+	From        string            `json:"from"`
+	To          string            `json:"to"`
+	GroupBy     string            `json:"group_by"`
+	Granularity string            `json:"granularity"`
+	Currency    string            `json:"currency"`
+	Periods     []string          `json:"periods"`
+	Rows        []CashFlowRow     `json:"rows"`
+	Totals      []CashFlowAmounts `json:"totals"`
+	Total       CashFlowAmounts   `json:"total"`
+}
+
+type ReportsService struct {
+	dao               ReportsDataAccessor
+	categoriesService *CategoriesService
+	exchangeRates     ExchangeRateSource
+}
+
+// NewReportsService creates the service. categoriesService names the categories of reports. exchangeRates is
+// optional and, when present, is used to report amounts in several currencies in a single one.
+func NewReportsService(dao ReportsDataAccessor, categoriesService *CategoriesService,
+	exchangeRates ExchangeRateSource) *ReportsService {
+	return &ReportsService{dao: dao, categoriesService: categoriesService, exchangeRates: exchangeRates}
+}
+
+// GetNetWorth reports total assets, total liabilities and net worth over the days from and to, both inclusive, at
+// daily, weekly, monthly, quarterly or yearly granularity. Balances are derived from the opening balance and the
+// ledger of every account and converted into currency at the rate of each point. An account that has been deleted
+// still counts towards the points before the day of its deletion when it has transactions, so that deleting an
+// account does not rewrite past net worth. to defaults to today, from to a
+// year before to, granularity to monthly and currency to the currency of the accounts when they share one.
+func (service *ReportsService) GetNetWorth(from *time.Time, to *time.Time, granularity string,
+	currency string) (NetWorthReport, error) {
+	start, end, err := reportRange(from, to)
+	if err != nil {
+		return NetWorthReport{}, err
+	}
+	if granularity == "" {
+		granularity = ReportGranularityMonthly
+	}
+	days, err := reportPoints(start, end, granularity)
+	if err != nil {
+		return NetWorthReport{}, err
+	}
+
+	accounts, err := service.dao.ListReportAccounts(end.AddDate(0, 0, 1))
+	if err != nil {
+		return NetWorthReport{}, fmt.Errorf("failed to list accounts of net worth with err:%v", err)
+	}
+	if currency == "" {
+		currency = sharedCurrency(accounts)
+	}
+	if currency, err = NormalizeCurrency(currency); err != nil {
+		return NetWorthReport{}, err
+	}
+	var converter *CurrencyConverter
+	for _, account := range accounts {
+		if accountCurrency(account) != currency {
+			if converter, err = loadConverter(service.exchangeRates); err != nil {
+				return NetWorthReport{}, err
+			}
+			break
+		}
+	}
+	totals, err := service.dao.SumAmountsByAccountAndDay(end.AddDate(0, 0, 1))
+	if err != nil {
+		return NetWorthReport{}, fmt.Errorf("failed to sum transactions of net worth with err:%v", err)
+	}
+
+	balances := map[int64]Money{}
+	for _, account := range accounts {
+		balances[account.Id] = account.OpeningBalance
+	}
+	report := NetWorthReport{From: start.Format(ReportDateLayout), To: end.Format(ReportDateLayout),
+		Granularity: granularity, Currency: currency, Points: []NetWorthPoint{}}
+	next := 0
+	for _, day := range days {
+		date := day.Format(ReportDateLayout)
+		// totals are ordered by day, so each point only adds the days since the previous point
+		for ; next < len(totals) && totals[next].Day <= date; next++ {
+			if _, ok := balances[totals[next].AccountId]; ok {
+				balances[totals[next].AccountId] += totals[next].Amount
+			}
+		}
+		point := NetWorthPoint{Date: date}
+		for _, account := range accounts {
+			if !isHeldOn(account, date) {
+				continue
+			}
+			balance := balances[account.Id]
+			if converter != nil {
+				if balance, err = converter.Convert(balance, accountCurrency(account), currency, day); err != nil {
+					return NetWorthReport{}, err
+				}
+			}
+			if IsLiabilityAccountType(account.AccountType) {
+				point.Liabilities -= balance
+			} else {
+				point.Assets += balance
+			}
+		}
+		point.NetWorth = point.Assets - point.Liabilities
+		report.Points = append(report.Points, point)
+	}
+	return report, nil
+}
+
+// GetCashFlow reports income and expenses by category, payee or tag over the days from and to, both inclusive, in
+// monthly, quarterly or yearly periods. Transfers between accounts are left out. A transaction with several tags
+// counts towards every one of them, but only once towards the totals. With a currency every transaction is converted
+// into it at the rate of its day, otherwise the amounts are summed as they are. to defaults to today, from to a year
+// before to, groupBy to category and granularity to monthly.
+func (service *ReportsService) GetCashFlow(from *time.Time, to *time.Time, groupBy string, granularity string,
+	currency string) (CashFlowReport, error) {
+	start, end, err := reportRange(from, to)
+	if err != nil {
+		return CashFlowReport{}, err
+	}
+	switch groupBy {
+	case "":
+		groupBy = CashFlowGroupByCategory
+	case CashFlowGroupByCategory, CashFlowGroupByPayee, CashFlowGroupByTag:
+	default:
+		return CashFlowReport{}, fmt.Errorf("%w: cash flow can be grouped by category, payee or tag instead of %s",
+			ErrInvalidRequest, groupBy)
+	}
+	switch granularity {
+	case "":
+		granularity = ReportGranularityMonthly
+	case ReportGranularityMonthly, ReportGranularityQuarterly, ReportGranularityYearly:
+	default:
+		return CashFlowReport{}, fmt.Errorf("%w: granularity of cash flow has to be monthly, quarterly or yearly "+
+			"instead of %s", ErrInvalidRequest, granularity)
+	}
+	days, err := reportPoints(start, end, granularity)
+	if err != nil {
+		return CashFlowReport{}, err
+	}
+	var converter *CurrencyConverter
+	if currency != "" {
+		if currency, err = NormalizeCurrency(currency); err != nil {
+			return CashFlowReport{}, err
+		}
+		if converter, err = loadConverter(service.exchangeRates); err != nil {
+			return CashFlowReport{}, err
+		}
+	}
+	var categoryPaths map[int64]string
+	if groupBy == CashFlowGroupByCategory {
+		categories, err := service.categoriesService.ListCategories()
+		if err != nil {
+			return CashFlowReport{}, err
+		}
+		categoryPaths = CategoryPaths(categories)
+	}
+
+	report := CashFlowReport{From: start.Format(ReportDateLayout), To: end.Format(ReportDateLayout),
+		GroupBy: groupBy, Granularity: granularity, Currency: currency, Periods: []string{}, Rows: []CashFlowRow{},
+		Totals: []CashFlowAmounts{}}
+	columns := map[string]int{}
+	for _, day := range days {
+		period := reportPeriod(day, granularity)
+		columns[period] = len(report.Periods)
+		report.Periods = append(report.Periods, period)
+		report.Totals = append(report.Totals, CashFlowAmounts{Period: period})
+	}
+	// sum reads the totals of the range, converted and placed in the column of their period
+	sum := func(groupBy string, add func(total CashFlowTotal, column int)) error {
+		totals, err := service.dao.SumCashFlow(start, end.AddDate(0, 0, 1), groupBy)
+		if err != nil {
+			return fmt.Errorf("failed to sum cash flow with err:%v", err)
+		}
+		for _, total := range totals {
+			day, err := time.Parse(ReportDateLayout, total.Day)
+			if err != nil {
+				return fmt.Errorf("failed to read day %q of the cash flow", total.Day)
+			}
+			if converter != nil {
+				if total.Income, err = converter.Convert(total.Income, total.Currency, currency, day); err != nil {
+					return err
+				}
+				if total.Expenses, err = converter.Convert(total.Expenses, total.Currency, currency, day); err != nil {
+					return err
+				}
+			}
+			add(total, columns[reportPeriod(day, granularity)])
+		}
+		return nil
+	}
+	addTotals := func(total CashFlowTotal, column int) {
+		addCashFlow(&report.Totals[column], total)
+		addCashFlow(&report.Total, total)
+	}
+	type groupKey struct {
+		id   int64
+		name string
+	}
+	rows := map[groupKey]*CashFlowRow{}
+	err = sum(groupBy, func(total CashFlowTotal, column int) {
+		key := groupKey{total.GroupId, total.Group}
+		row, ok := rows[key]
+		if !ok {
+			row = &CashFlowRow{GroupId: total.GroupId, Group: total.Group, Periods: make([]CashFlowAmounts,
+				len(report.Periods))}
+			if categoryPaths != nil {
+				row.Group = categoryPaths[total.GroupId]
+			}
+			for i, period := range report.Periods {
+				row.Periods[i].Period = period
+			}
+			rows[key] = row
+		}
+		addCashFlow(&row.Periods[column], total)
+		addCashFlow(&row.Total, total)
+		if groupBy != CashFlowGroupByTag {
+			addTotals(total, column)
+		}
+	})
+	if err != nil {
+		return CashFlowReport{}, err
+	}
+	if groupBy == CashFlowGroupByTag {
+		// transactions are repeated for every tag, so the totals are summed over categories instead
+		if err = sum(CashFlowGroupByCategory, addTotals); err != nil {
+			return CashFlowReport{}, err
+		}
+	}
+
+	for _, row := range rows {
+		report.Rows = append(report.Rows, *row)
+	}
+	sort.Slice(report.Rows, func(i, j int) bool {
+		first, second := report.Rows[i], report.Rows[j]
+		if (first.Group == "") != (second.Group == "") {
+			return second.Group == ""
+		}
+		if first.Group != second.Group {
+			return first.Group < second.Group
+		}
+		return first.GroupId < second.GroupId
+	})
+	return report, nil
+}
+
+// addCashFlow adds the income and expenses of a total to amounts.
+func addCashFlow(amounts *CashFlowAmounts, total CashFlowTotal) {
+	amounts.Income += total.Income
+	amounts.Expenses += total.Expenses
+	amounts.Net = amounts.Income - amounts.Expenses
+}
+
+// reportPeriod labels the period of the granularity a day falls in, like 2022-07, 2022-Q3 or 2022.
+func reportPeriod(day time.Time, granularity string) string {
+	switch granularity {
+	case ReportGranularityQuarterly:
+		return fmt.Sprintf("%d-Q%d", day.Year(), (int(day.Month())-1)/3+1)
+	case ReportGranularityYearly:
+		return fmt.Sprintf("%d", day.Year())
+	}
+	return day.Format(BudgetMonthLayout)
+}
+
+// reportRange truncates from and to to days, defaulting to the year up to today.
+func reportRange(from *time.Time, to *time.Time) (time.Time, time.Time, error) {
+	end := truncateToDay(time.Now().UTC())
+	if to != nil {
+		end = truncateToDay(*to)
+	}
+	start := end.AddDate(-1, 0, 0)
+	if from != nil {
+		start = truncateToDay(*from)
+	}
+	if start.After(end) {
+		return start, end, fmt.Errorf("%w: from cannot be after to", ErrInvalidRequest)
+	}
+	return start, end, nil
+}
+
+// reportPoints lists the last day of every period of the granularity between start and end, ending with end itself.
+// Weeks end on Sundays and quarters on the last day of March, June, September and December.
+func reportPoints(start time.Time, end time.Time, granularity string) ([]time.Time, error) {
+	var periodEnd func(day time.Time) time.Time
+	switch granularity {
+	case ReportGranularityDaily:
+		periodEnd = func(day time.Time) time.Time {
+			return day
+		}
+	case ReportGranularityWeekly:
+		periodEnd = func(day time.Time) time.Time {
+			return day.AddDate(0, 0, (7-int(day.Weekday()))%7)
+		}
+	case ReportGranularityMonthly:
+		periodEnd = func(day time.Time) time.Time {
+			return time.Date(day.Year(), day.Month()+1, 0, 0, 0, 0, 0, time.UTC)
+		}
+	case ReportGranularityQuarterly:
+		periodEnd = func(day time.Time) time.Time {
+			return time.Date(day.Year(), (day.Month()-1)/3*3+4, 0, 0, 0, 0, 0, time.UTC)
+		}
+	case ReportGranularityYearly:
+		periodEnd = func(day time.Time) time.Time {
+			return time.Date(day.Year(), time.December, 31, 0, 0, 0, 0, time.UTC)
+		}
+	default:
+		return nil, fmt.Errorf("%w: granularity has to be daily, weekly, monthly, quarterly or yearly instead of %s",
+			ErrInvalidRequest, granularity)
+	}
+	var points []time.Time
+	for day := start; !day.After(end); day = points[len(points)-1].AddDate(0, 0, 1) {
+		if len(points) == maxReportPoints {
+			return nil, fmt.Errorf("%w: reports cannot have more than %d points, pick a shorter range or a coarser "+
+				"granularity", ErrInvalidRequest, maxReportPoints)
+		}
+		point := periodEnd(day)
+		if point.After(end) {
+			point = end
+		}
+		points = append(points, point)
+	}
+	return points, nil
+}
+
+// isHeldOn tells whether the account was held at the end of the day formatted as 2006-01-02. UpdatedTs of a deleted
+// account is the time of its deletion.
+func isHeldOn(account Account, day string) bool {
+	return !account.IsDeleted || day < account.UpdatedTs.UTC().Format(ReportDateLayout)
+}
+
+// sharedCurrency is the currency every account is held in, or DefaultCurrency when they are held in several.
+func sharedCurrency(accounts []Account) string {
+	currency := ""
+	for _, account := range accounts {
+		if currency != "" && accountCurrency(account) != currency {
+			return DefaultCurrency
+		}
+		currency = accountCurrency(account)
+	}
+	if currency == "" {
+		return DefaultCurrency
+	}
+	return currency
+}
+
+func truncateToDay(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+type ReportsDataAccessor interface {
+	ListReportAccounts(to time.Time) ([]Account, error)
+	SumAmountsByAccountAndDay(to time.Time) ([]AccountDailyTotal, error)
+	SumCashFlow(from time.Time, to time.Time, groupBy string) ([]CashFlowTotal, error)
+}
@@ -0,0 +1,291 @@
+package api
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// reportsMock holds a checking account with a salary and a credit card that is paid off in July. Its cash flow is
+// a salary, groceries tagged for a vacation that is shared and coffee with a refund.
+type reportsMock struct {
+	accounts []Account
+	to       time.Time
+	groupBys []string
+	err      error
+}
+
+func (m *reportsMock) ListReportAccounts(to time.Time) ([]Account, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	if m.accounts != nil {
+		return m.accounts, nil
+	}
+	return []Account{
+		{Id: 1, Name: "checking", AccountType: "checking", OpeningBalance: 100000, Currency: "USD"},
+		{Id: 2, Name: "visa", AccountType: "Credit Card", Currency: "USD"},
+	}, nil
+}
+
+func (m *reportsMock) SumAmountsByAccountAndDay(to time.Time) ([]AccountDailyTotal, error) {
+	m.to = to
+	return []AccountDailyTotal{
+		{AccountId: 1, Day: "2022-05-20", Amount: -5000},
+		{AccountId: 2, Day: "2022-06-03", Amount: -12000},
+		{AccountId: 1, Day: "2022-06-30", Amount: 250000},
+		{AccountId: 3, Day: "2022-07-01", Amount: 99900},
+		{AccountId: 2, Day: "2022-07-10", Amount: 12000},
+	}, nil
+}
+
+func (m *reportsMock) SumCashFlow(from time.Time, to time.Time, groupBy string) ([]CashFlowTotal, error) {
+	m.groupBys = append(m.groupBys, groupBy)
+	m.to = to
+	if m.err != nil {
+		return nil, m.err
+	}
+	if groupBy == CashFlowGroupByTag {
+		return []CashFlowTotal{
+			{Group: "vacation", Currency: "USD", Day: "2022-06-20", Expenses: 10000},
+			{Group: "shared", Currency: "USD", Day: "2022-06-20", Expenses: 10000},
+			{Group: "vacation", Currency: "USD", Day: "2022-07-02", Expenses: 5000},
+			{Currency: "USD", Day: "2022-07-01", Income: 250000},
+			{Currency: "USD", Day: "2022-07-05", Income: 500, Expenses: 1250},
+		}, nil
+	}
+	return []CashFlowTotal{
+		{GroupId: 2, Currency: "USD", Day: "2022-06-20", Expenses: 10000},
+		{GroupId: 2, Currency: "USD", Day: "2022-07-02", Expenses: 5000},
+		{Currency: "USD", Day: "2022-07-01", Income: 250000},
+		{GroupId: 4, Currency: "USD", Day: "2022-07-05", Income: 500, Expenses: 1250},
+	}, nil
+}
+
+func TestReportsService_GetNetWorth(t *testing.T) {
+
+	from := time.Date(2022, 6, 15, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2022, 7, 20, 18, 30, 0, 0, time.UTC)
+
+	t.Run("testing happy flow of tracking net worth monthly", func(t *testing.T) {
+		dao := reportsMock{}
+		report, err := NewReportsService(&dao, nil, nil).GetNetWorth(&from, &to, "", "")
+		if err != nil {
+			t.Errorf("unexpected error when tracking net worth: %v", err)
+			return
+		}
+		expected := []NetWorthPoint{
+			{Date: "2022-06-30", Assets: 345000, Liabilities: 12000, NetWorth: 333000},
+			{Date: "2022-07-20", Assets: 345000, Liabilities: 0, NetWorth: 345000},
+		}
+		if len(report.Points) != 2 || report.Points[0] != expected[0] || report.Points[1] != expected[1] {
+			t.Errorf("unexpected points %+v", report.Points)
+		}
+		if report.From != "2022-06-15" || report.To != "2022-07-20" || report.Granularity != ReportGranularityMonthly ||
+			report.Currency != "USD" {
+			t.Errorf("unexpected report %+v", report)
+		}
+		if !dao.to.Equal(time.Date(2022, 7, 21, 0, 0, 0, 0, time.UTC)) {
+			t.Errorf("expected the ledger to be summed up to the end of the last day but found %v", dao.to)
+		}
+	})
+
+	t.Run("testing deleted accounts count towards the points before their deletion", func(t *testing.T) {
+		july14 := time.Date(2022, 7, 14, 0, 0, 0, 0, time.UTC)
+		july15 := time.Date(2022, 7, 15, 0, 0, 0, 0, time.UTC)
+		dao := reportsMock{accounts: []Account{
+			{Id: 1, AccountType: "checking", OpeningBalance: 100000, Currency: "USD"},
+			{Id: 3, AccountType: "savings", Currency: "USD", IsDeleted: true, UpdatedTs: july15.Add(9 * time.Hour)},
+		}}
+		report, err := NewReportsService(&dao, nil, nil).GetNetWorth(&july14, &july15, ReportGranularityDaily, "")
+		if err != nil || len(report.Points) != 2 || report.Points[0].NetWorth != 444900 ||
+			report.Points[1].NetWorth != 345000 {
+			t.Errorf("unexpected report %+v or error %v", report, err)
+		}
+	})
+
+	t.Run("testing balances are converted into one currency", func(t *testing.T) {
+		july := time.Date(2022, 7, 1, 0, 0, 0, 0, time.UTC)
+		dao := reportsMock{accounts: []Account{
+			{Id: 1, AccountType: "checking", OpeningBalance: 100000, Currency: "USD"},
+			{Id: 4, AccountType: "savings", OpeningBalance: 50000, Currency: "EUR"},
+		}}
+		report, err := NewReportsService(&dao, nil, NewExchangeRatesService(&exchangeRatesMock{})).
+			GetNetWorth(&july, &july, ReportGranularityDaily, "")
+		if err != nil || len(report.Points) != 1 || report.Currency != "USD" || report.Points[0].NetWorth != 407500 {
+			t.Errorf("unexpected report %+v or error %v", report, err)
+		}
+		_, err = NewReportsService(&dao, nil, nil).GetNetWorth(&july, &july, ReportGranularityDaily, "")
+		if !errors.Is(err, ErrInvalidRequest) {
+			t.Errorf("expected invalid request error without exchange rates but found %v", err)
+		}
+	})
+
+	t.Run("testing validation of the report", func(t *testing.T) {
+		longAgo := time.Date(2015, 1, 1, 0, 0, 0, 0, time.UTC)
+		for _, test := range []struct {
+			from        time.Time
+			granularity string
+			currency    string
+		}{
+			{from, "hourly", ""},
+			{to.AddDate(0, 0, 1), ReportGranularityDaily, ""},
+			{longAgo, ReportGranularityDaily, ""},
+			{from, ReportGranularityWeekly, "dollars"},
+		} {
+			_, err := NewReportsService(&reportsMock{}, nil, nil).GetNetWorth(&test.from, &to, test.granularity,
+				test.currency)
+			if !errors.Is(err, ErrInvalidRequest) {
+				t.Errorf("expected invalid request error for %+v but found %v", test, err)
+			}
+		}
+	})
+
+	t.Run("testing general error flow of tracking net worth", func(t *testing.T) {
+		reportsService := NewReportsService(&reportsMock{err: errors.New("db timeout")}, nil, nil)
+		_, err := reportsService.GetNetWorth(&from, &to, "", "")
+		expectedErrorMsg := "failed to list accounts of net worth with err:db timeout"
+		if err == nil || err.Error() != expectedErrorMsg {
+			t.Errorf("expected error message %s but found %v", expectedErrorMsg, err)
+		}
+	})
+}
+
+func TestReportPoints(t *testing.T) {
+
+	start := time.Date(2022, 7, 1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2022, 7, 20, 0, 0, 0, 0, time.UTC)
+	points, err := reportPoints(start, end, ReportGranularityWeekly)
+	if err != nil {
+		t.Errorf("unexpected error when listing points: %v", err)
+		return
+	}
+	var days []string
+	for _, point := range points {
+		days = append(days, point.Format(ReportDateLayout))
+	}
+	if len(days) != 4 || days[0] != "2022-07-03" || days[1] != "2022-07-10" || days[3] != "2022-07-20" {
+		t.Errorf("expected weeks to end on sundays and the last one on the end but found %v", days)
+	}
+}
+
+func TestIsLiabilityAccountType(t *testing.T) {
+
+	for accountType, expected := range map[string]bool{
+		"credit card":  true,
+		"Credit-Card":  true,
+		"Credit Cards": true,
+		"loan":         true,
+		"Loans":        true,
+		"mortgage":     true,
+		"mortgages":    true,
+		"Liabilities":  true,
+		"savings":      false,
+		"investment":   false,
+		"checking":     false,
+	} {
+		if IsLiabilityAccountType(accountType) != expected {
+			t.Errorf("expected %s to be a liability: %t", accountType, expected)
+		}
+	}
+}
+
+func TestReportsService_GetCashFlow(t *testing.T) {
+
+	from := time.Date(2022, 6, 15, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2022, 7, 20, 0, 0, 0, 0, time.UTC)
+	categoriesService := NewCategoriesService(&categoriesMock{}, nil)
+
+	t.Run("testing happy flow of a monthly cash flow by category", func(t *testing.T) {
+		dao := reportsMock{}
+		report, err := NewReportsService(&dao, categoriesService, nil).GetCashFlow(&from, &to, "", "", "")
+		if err != nil {
+			t.Errorf("unexpected error when reporting cash flow: %v", err)
+			return
+		}
+		if len(report.Periods) != 2 || report.Periods[0] != "2022-06" || report.Periods[1] != "2022-07" {
+			t.Errorf("unexpected periods %v", report.Periods)
+			return
+		}
+		if len(report.Rows) != 3 || report.Rows[0].Group != "Food:Groceries" ||
+			report.Rows[1].Group != "Food:Restaurants:Coffee" || report.Rows[2].GroupId != 0 {
+			t.Errorf("expected the categories by path and the uncategorized row last but found %+v", report.Rows)
+			return
+		}
+		groceries := report.Rows[0]
+		if groceries.Periods[0] != (CashFlowAmounts{Period: "2022-06", Expenses: 10000, Net: -10000}) ||
+			groceries.Total != (CashFlowAmounts{Expenses: 15000, Net: -15000}) {
+			t.Errorf("unexpected groceries %+v", groceries)
+		}
+		if report.Rows[1].Periods[0] != (CashFlowAmounts{Period: "2022-06"}) {
+			t.Errorf("expected an empty cell when nothing was spent in a period but found %+v", report.Rows[1])
+		}
+		expectedTotal := CashFlowAmounts{Period: "2022-07", Income: 250500, Expenses: 6250, Net: 244250}
+		if report.Totals[1] != expectedTotal || report.Total != (CashFlowAmounts{Income: 250500, Expenses: 16250,
+			Net: 234250}) {
+			t.Errorf("unexpected totals %+v and %+v", report.Totals, report.Total)
+		}
+		if !dao.to.Equal(time.Date(2022, 7, 21, 0, 0, 0, 0, time.UTC)) {
+			t.Errorf("expected the ledger to be summed up to the end of the last day but found %v", dao.to)
+		}
+	})
+
+	t.Run("testing transactions with several tags are counted once in the totals", func(t *testing.T) {
+		dao := reportsMock{}
+		report, err := NewReportsService(&dao, categoriesService, nil).GetCashFlow(&from, &to, CashFlowGroupByTag,
+			ReportGranularityQuarterly, "")
+		if err != nil {
+			t.Errorf("unexpected error when reporting cash flow: %v", err)
+			return
+		}
+		if len(report.Periods) != 2 || report.Periods[0] != "2022-Q2" || report.Periods[1] != "2022-Q3" {
+			t.Errorf("unexpected periods %v", report.Periods)
+		}
+		if len(report.Rows) != 3 || report.Rows[0].Group != "shared" || report.Rows[1].Total.Expenses != 15000 {
+			t.Errorf("unexpected rows %+v", report.Rows)
+		}
+		if report.Total != (CashFlowAmounts{Income: 250500, Expenses: 16250, Net: 234250}) {
+			t.Errorf("unexpected total %+v", report.Total)
+		}
+		if len(dao.groupBys) != 2 || dao.groupBys[1] != CashFlowGroupByCategory {
+			t.Errorf("expected the totals to be summed by category but found %v", dao.groupBys)
+		}
+	})
+
+	t.Run("testing amounts are converted into one currency", func(t *testing.T) {
+		reportsService := NewReportsService(&reportsMock{}, categoriesService,
+			NewExchangeRatesService(&exchangeRatesMock{}))
+		report, err := reportsService.GetCashFlow(&from, &to, "", "", "eur")
+		if err != nil || report.Currency != "EUR" || report.Totals[0].Expenses != 8000 {
+			t.Errorf("unexpected report %+v or error %v", report, err)
+		}
+	})
+
+	t.Run("testing validation of the report", func(t *testing.T) {
+		for _, test := range []struct {
+			groupBy     string
+			granularity string
+			currency    string
+		}{
+			{"account", "", ""},
+			{"", ReportGranularityWeekly, ""},
+			{"", "", "dollars"},
+			{"", "", "EUR"},
+		} {
+			_, err := NewReportsService(&reportsMock{}, categoriesService, nil).GetCashFlow(&from, &to,
+				test.groupBy, test.granularity, test.currency)
+			if !errors.Is(err, ErrInvalidRequest) {
+				t.Errorf("expected invalid request error for %+v but found %v", test, err)
+			}
+		}
+	})
+
+	t.Run("testing general error flow of reporting cash flow", func(t *testing.T) {
+		reportsService := NewReportsService(&reportsMock{err: errors.New("db timeout")}, categoriesService, nil)
+		_, err := reportsService.GetCashFlow(&from, &to, CashFlowGroupByPayee, "", "")
+		expectedErrorMsg := "failed to sum cash flow with err:db timeout"
+		if err == nil || err.Error() != expectedErrorMsg {
+			t.Errorf("expected error message %s but found %v", expectedErrorMsg, err)
+		}
+	})
+}
@@ -0,0 +1,370 @@
+package api
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Rule categorizes the transactions matching all of its conditions. Conditions that are not set match any
+// transaction: DescriptionContains is matched case-insensitively against the normalized description, DescriptionRegex
+// against the raw description, and MinAmount and MaxAmount bound the signed amount inclusively. Every action that is
+// set is applied, SetTags adding to the tags of the transaction.
+type Rule struct {
+	Id                  int64     `json:"id"`
+	Name                string    `json:"name"`
+	Priority            int64     `json:"priority"`
+	DescriptionContains string    `json:"description_contains"`
+	DescriptionRegex    string    `json:"description_regex"`
+	MinAmount           *Money    `json:"min_amount"`
+	MaxAmount           *Money    `json:"max_amount"`
+	AccountId           int64     `json:"account_id"`
+	PayeeId             int64     `json:"payee_id"`
+	SetCategoryId       int64     `json:"set_category_id"`
+	SetPayeeId          int64     `json:"set_payee_id"`
+	SetTags             []string  `json:"set_tags"`
+	SetMemo             string    `json:"set_memo"`
+	IsDeleted           bool      `json:"is_deleted"`
+	CreatedTs           time.Time `json:"created_ts"`
+	UpdatedTs           time.Time `json:"updated_ts"`
+}
+
+// RuleRequest is used to both create and update a rule.
+type RuleRequest struct {
+	Name                *string  `json:"name"`
+	Priority            *int64   `json:"priority"`
+	DescriptionContains *string  `json:"description_contains"`
+	DescriptionRegex    *string  `json:"description_regex"`
+	MinAmount           *Money   `json:"min_amount"`
+	MaxAmount           *Money   `json:"max_amount"`
+	AccountId           *int64   `json:"account_id"`
+	PayeeId             *int64   `json:"payee_id"`
+	SetCategoryId       *int64   `json:"set_category_id"`
+	SetPayeeId          *int64   `json:"set_payee_id"`
+	SetTags             []string `json:"set_tags"`
+	SetMemo             *string  `json:"set_memo"`
+}
+
+// RuleOutcome is what the matching rules set on a transaction. When several rules set the same field the rule that
+// is evaluated first wins, while tags are collected from all of them.
+type RuleOutcome struct {
+	RuleIds    []int64
+	CategoryId *int64
+	PayeeId    *int64
+	Tags       []string
+	Memo       *string
+}
+
+// RuleApplicationRequest re-applies the rules to the transactions made in [From, To), optionally of a single account.
+// Fields that are already set are only replaced with Overwrite, and DryRun reports the changes without saving them.
+type RuleApplicationRequest struct {
+	From      *time.Time `json:"from"`
+	To        *time.Time `json:"to"`
+	AccountId *int64     `json:"account_id"`
+	DryRun    bool       `json:"dry_run"`
+	Overwrite bool       `json:"overwrite"`
+}
+
+// RuleFields are the fields of a transaction that rules change.
+type RuleFields struct {
+	CategoryId int64    `json:"category_id"`
+	PayeeId    int64    `json:"payee_id"`
+	Tags       []string `json:"tags"`
+	Memo       string   `json:"memo"`
+}
+
+// TransactionChange is the diff applying the rules makes to a transaction.
+type TransactionChange struct {
+	TransactionId int64      `json:"transaction_id"`
+	Description   string     `json:"description"`
+	RuleIds       []int64    `json:"rule_ids"`
+	Before        RuleFields `json:"before"`
+	After         RuleFields `json:"after"`
+}
+
+type RuleApplicationResult struct {
+	DryRun  bool                `json:"dry_run"`
+	Matched int                 `json:"matched"`
+	Changed int                 `json:"changed"`
+	Changes []TransactionChange `json:"changes"`
+}
+
+// RulesProvider lists the rules to apply, in the order they are evaluated.
+type RulesProvider interface {
+	ListRules() ([]Rule, error)
+}
+
+type RulesService struct {
+	dao RulesDataAccessor
+}
+
+func NewRulesService(dao RulesDataAccessor) *RulesService {
+	return &RulesService{dao}
+}
+
+func (service *RulesService) GetRule(id int64) (Rule, error) {
+	rule, err := service.dao.GetRule(id)
+	if err != nil {
+		return rule, fmt.Errorf("failed to retrieve rule of id %d with err:%v", id, err)
+	}
+	return rule, nil
+}
+
+// ListRules returns the rules in descending priority and then in the order they were created, which is the order
+// they are evaluated in.
+func (service *RulesService) ListRules() ([]Rule, error) {
+	rules, err := service.dao.ListRules()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list rules with err:%v", err)
+	}
+	sort.SliceStable(rules, func(i, j int) bool {
+		if rules[i].Priority != rules[j].Priority {
+			return rules[i].Priority > rules[j].Priority
+		}
+		return rules[i].Id < rules[j].Id
+	})
+	return rules, nil
+}
+
+func (service *RulesService) CreateRule(request RuleRequest) (int64, error) {
+	request, err := validateRule(request)
+	if err != nil {
+		return -1, err
+	}
+	return service.dao.InsertRule(request)
+}
+
+func (service *RulesService) UpdateRule(id int64, request RuleRequest) error {
+	request, err := validateRule(request)
+	if err != nil {
+		return err
+	}
+	return service.dao.UpdateRule(id, request)
+}
+
+func (service *RulesService) DeleteRule(id int64) error {
+	err := service.dao.DeleteRule(id)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// validateRule requires a condition and an action so that a rule never changes every transaction, and normalizes
+// the tags it adds.
+func validateRule(request RuleRequest) (RuleRequest, error) {
+	if isBlank(request.Name) {
+		return request, fmt.Errorf("%w: name is required", ErrInvalidRequest)
+	}
+	if isBlank(request.DescriptionContains) && isBlank(request.DescriptionRegex) && request.MinAmount == nil &&
+		request.MaxAmount == nil && request.AccountId == nil && request.PayeeId == nil {
+		return request, fmt.Errorf("%w: at least one condition is required", ErrInvalidRequest)
+	}
+	request.SetTags = NormalizeTags(request.SetTags)
+	if request.SetCategoryId == nil && request.SetPayeeId == nil && len(request.SetTags) == 0 &&
+		isBlank(request.SetMemo) {
+		return request, fmt.Errorf("%w: at least one action is required", ErrInvalidRequest)
+	}
+	if !isBlank(request.DescriptionRegex) {
+		if _, err := regexp.Compile(*request.DescriptionRegex); err != nil {
+			return request, fmt.Errorf("%w: description_regex is not a valid regular expression: %v",
+				ErrInvalidRequest, err)
+		}
+	}
+	if request.MinAmount != nil && request.MaxAmount != nil && *request.MinAmount > *request.MaxAmount {
+		return request, fmt.Errorf("%w: min_amount cannot be greater than max_amount", ErrInvalidRequest)
+	}
+	return request, nil
+}
+
+// EvaluateRules applies the rules, in the order given, to a transaction with the account, description, amount and
+// payee.
+func EvaluateRules(rules []Rule, accountId int64, description string, amount Money, payeeId int64) RuleOutcome {
+	outcome := RuleOutcome{}
+	normalized := NormalizeDescription(description)
+	for _, rule := range rules {
+		if !matchesRule(rule, accountId, description, normalized, amount, payeeId) {
+			continue
+		}
+		outcome.RuleIds = append(outcome.RuleIds, rule.Id)
+		if rule.SetCategoryId != 0 && outcome.CategoryId == nil {
+			categoryId := rule.SetCategoryId
+			outcome.CategoryId = &categoryId
+		}
+		if rule.SetPayeeId != 0 && outcome.PayeeId == nil {
+			payeeId := rule.SetPayeeId
+			outcome.PayeeId = &payeeId
+		}
+		if rule.SetMemo != "" && outcome.Memo == nil {
+			memo := rule.SetMemo
+			outcome.Memo = &memo
+		}
+		outcome.Tags = append(outcome.Tags, rule.SetTags...)
+	}
+	outcome.Tags = NormalizeTags(outcome.Tags)
+	return outcome
+}
+
+func matchesRule(rule Rule, accountId int64, description string, normalized string, amount Money,
+	payeeId int64) bool {
+	if rule.AccountId != 0 && rule.AccountId != accountId {
+		return false
+	}
+	if rule.PayeeId != 0 && rule.PayeeId != payeeId {
+		return false
+	}
+	if rule.MinAmount != nil && amount < *rule.MinAmount {
+		return false
+	}
+	if rule.MaxAmount != nil && amount > *rule.MaxAmount {
+		return false
+	}
+	if rule.DescriptionContains != "" &&
+		!strings.Contains(normalized, NormalizeDescription(rule.DescriptionContains)) {
+		return false
+	}
+	if rule.DescriptionRegex != "" {
+		expression, err := regexp.Compile(rule.DescriptionRegex)
+		if err != nil || !expression.MatchString(description) {
+			return false
+		}
+	}
+	return true
+}
+
+// applyRulesToRequest fills in the category, payee and memo of a new transaction from the rules, unless the request
+// sets them itself, and adds the tags of the rules.
+func (service *TransactionsService) applyRulesToRequest(request *TransactionCreationRequest) error {
+	if service.rulesProvider == nil {
+		return nil
+	}
+	rules, err := service.rulesProvider.ListRules()
+	if err != nil {
+		return fmt.Errorf("failed to load rules for new transaction with err:%v", err)
+	}
+	var description string
+	var payeeId int64
+	if request.Description != nil {
+		description = *request.Description
+	}
+	if request.PayeeId != nil {
+		payeeId = *request.PayeeId
+	}
+	outcome := EvaluateRules(rules, *request.AccountId, description, *request.Amount, payeeId)
+	if request.CategoryId == nil {
+		request.CategoryId = outcome.CategoryId
+	}
+	if request.PayeeId == nil {
+		request.PayeeId = outcome.PayeeId
+	}
+	if request.Memo == nil {
+		request.Memo = outcome.Memo
+	}
+	request.Tags = NormalizeTags(append(request.Tags, outcome.Tags...))
+	return nil
+}
+
+// ApplyRules re-applies the rules to existing transactions. Transfers are left alone since rules categorize spending
+// and income.
+func (service *TransactionsService) ApplyRules(request RuleApplicationRequest) (RuleApplicationResult, error) {
+	if service.rulesProvider == nil {
+		return RuleApplicationResult{}, fmt.Errorf("%w: rules are not available", ErrInvalidRequest)
+	}
+	if request.From != nil && request.To != nil && !request.From.Before(*request.To) {
+		return RuleApplicationResult{}, fmt.Errorf("%w: from has to be before to", ErrInvalidRequest)
+	}
+	rules, err := service.rulesProvider.ListRules()
+	if err != nil {
+		return RuleApplicationResult{}, fmt.Errorf("failed to load rules with err:%v", err)
+	}
+	transactions, err := service.ListTransactions(TransactionFilter{AccountId: request.AccountId,
+		From: request.From, To: request.To})
+	if err != nil {
+		return RuleApplicationResult{}, err
+	}
+	result := RuleApplicationResult{DryRun: request.DryRun, Changes: []TransactionChange{}}
+	for _, transaction := range transactions {
+		if transaction.TransactionType == TransferTransactionType {
+			continue
+		}
+		outcome := EvaluateRules(rules, transaction.AccountId, transaction.Description, transaction.Amount,
+			transaction.PayeeId)
+		if len(outcome.RuleIds) == 0 {
+			continue
+		}
+		result.Matched++
+		change, changed := ruleChange(transaction, outcome, request.Overwrite)
+		if !changed {
+			continue
+		}
+		result.Changed++
+		result.Changes = append(result.Changes, change)
+		if request.DryRun {
+			continue
+		}
+		if err = service.updateRuleFields(transaction, change.After); err != nil {
+			return result, fmt.Errorf("failed to apply rules to transaction %d after changing %d transactions "+
+				"with err:%v", transaction.Id, result.Changed-1, err)
+		}
+	}
+	return result, nil
+}
+
+// ruleChange works out the fields of the transaction after applying the outcome. The boolean is false when nothing
+// changes.
+func ruleChange(transaction Transaction, outcome RuleOutcome, overwrite bool) (TransactionChange, bool) {
+	before := RuleFields{CategoryId: transaction.CategoryId, PayeeId: transaction.PayeeId,
+		Tags: NormalizeTags(transaction.Tags), Memo: transaction.Memo}
+	after := before
+	if outcome.CategoryId != nil && (after.CategoryId == 0 || overwrite) {
+		after.CategoryId = *outcome.CategoryId
+	}
+	if outcome.PayeeId != nil && (after.PayeeId == 0 || overwrite) {
+		after.PayeeId = *outcome.PayeeId
+	}
+	if outcome.Memo != nil && (after.Memo == "" || overwrite) {
+		after.Memo = *outcome.Memo
+	}
+	after.Tags = NormalizeTags(append(append([]string{}, before.Tags...), outcome.Tags...))
+	change := TransactionChange{TransactionId: transaction.Id, Description: transaction.Description,
+		RuleIds: outcome.RuleIds, Before: before, After: after}
+	changed := before.CategoryId != after.CategoryId || before.PayeeId != after.PayeeId ||
+		before.Memo != after.Memo || len(before.Tags) != len(after.Tags)
+	return change, changed
+}
+
+func (service *TransactionsService) updateRuleFields(transaction Transaction, fields RuleFields) error {
+	request := TransactionUpdateRequest{
+		TransactionTime: &transaction.TransactionTime,
+		TransactionType: &transaction.TransactionType,
+		AccountId:       &transaction.AccountId,
+		Amount:          &transaction.Amount,
+		Tags:            fields.Tags,
+	}
+	if transaction.Description != "" {
+		request.Description = &transaction.Description
+	}
+	if fields.Memo != "" {
+		request.Memo = &fields.Memo
+	}
+	if transaction.Currency != "" {
+		request.Currency = &transaction.Currency
+	}
+	if fields.PayeeId != 0 {
+		request.PayeeId = &fields.PayeeId
+	}
+	if fields.CategoryId != 0 {
+		request.CategoryId = &fields.CategoryId
+	}
+	return service.dao.UpdateTransaction(transaction.Id, request)
+}
+
+type RulesDataAccessor interface {
+	GetRule(id int64) (Rule, error)
+	ListRules() ([]Rule, error)
+	InsertRule(request RuleRequest) (int64, error)
+	UpdateRule(id int64, request RuleRequest) error
+	DeleteRule(id int64) error
+}
@@ -0,0 +1,253 @@
+package api
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// rulesMock lists a coffee rule, a catch-all for large expenses of account 1 and a rule tagging payee 1, in the
+// order they are stored rather than the order they are evaluated in.
+type rulesMock struct {
+	inserted *RuleRequest
+	err      error
+}
+
+func (m *rulesMock) GetRule(id int64) (Rule, error) {
+	return Rule{Id: id, Name: "coffee"}, m.err
+}
+
+func (m *rulesMock) ListRules() ([]Rule, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	maxAmount := Money(-10000)
+	return []Rule{
+		{Id: 3, Name: "amazon", PayeeId: 1, SetTags: []string{"online"}},
+		{Id: 2, Name: "large expenses", AccountId: 1, MaxAmount: &maxAmount, SetCategoryId: 5, SetMemo: "review",
+			SetTags: []string{"large"}},
+		{Id: 1, Name: "coffee", Priority: 10, DescriptionContains: "starbucks", DescriptionRegex: `#\d+$`,
+			SetCategoryId: 4, SetPayeeId: 2},
+	}, nil
+}
+
+func (m *rulesMock) InsertRule(request RuleRequest) (int64, error) {
+	m.inserted = &request
+	return 1, m.err
+}
+
+func (m *rulesMock) UpdateRule(id int64, request RuleRequest) error {
+	return m.err
+}
+
+func (m *rulesMock) DeleteRule(id int64) error {
+	return m.err
+}
+
+// rulesTransactionsMock lists a coffee that is already categorized, an uncategorized purchase on amazon and a
+// transfer, and records the updates made to them.
+type rulesTransactionsMock struct {
+	happyTransactionsMock
+	updated map[int64]TransactionUpdateRequest
+}
+
+func (m *rulesTransactionsMock) ListTransactions(filter TransactionFilter) ([]Transaction, error) {
+	transactionTime := time.Date(2022, 7, 1, 0, 0, 0, 0, time.UTC)
+	return []Transaction{
+		{Id: 1, TransactionTime: transactionTime, TransactionType: NormalTransactionType, AccountId: 2,
+			Description: "STARBUCKS #889", Amount: -450, CategoryId: 1, Tags: []string{"work"}},
+		{Id: 2, TransactionTime: transactionTime, TransactionType: NormalTransactionType, AccountId: 2,
+			Description: "AMZN MKTP", Amount: -3000, PayeeId: 1},
+		{Id: 3, TransactionTime: transactionTime, TransactionType: TransferTransactionType, AccountId: 2,
+			Description: "STARBUCKS #889", Amount: -450},
+		{Id: 4, TransactionTime: transactionTime, TransactionType: NormalTransactionType, AccountId: 2,
+			Description: "RENT", Amount: -100000},
+	}, nil
+}
+
+func (m *rulesTransactionsMock) UpdateTransaction(id int64, request TransactionUpdateRequest) error {
+	if m.updated == nil {
+		m.updated = map[int64]TransactionUpdateRequest{}
+	}
+	m.updated[id] = request
+	return nil
+}
+
+func TestRulesService_CreateRule(t *testing.T) {
+
+	name := "coffee"
+	contains := "starbucks"
+	categoryId := int64(4)
+
+	t.Run("testing happy flow of creating a rule", func(t *testing.T) {
+		dao := rulesMock{}
+		rulesService := NewRulesService(&dao)
+		id, err := rulesService.CreateRule(RuleRequest{Name: &name, DescriptionContains: &contains,
+			SetTags: []string{" coffee ", "coffee"}})
+		if err != nil || id != 1 {
+			t.Errorf("unexpected id %d or error %v", id, err)
+			return
+		}
+		if len(dao.inserted.SetTags) != 1 || dao.inserted.SetTags[0] != "coffee" {
+			t.Errorf("unexpected tags %v", dao.inserted.SetTags)
+		}
+	})
+
+	t.Run("testing validation of rules", func(t *testing.T) {
+		invalidRegex := "(starbucks"
+		minAmount, maxAmount := Money(-500), Money(-1000)
+		for _, request := range []RuleRequest{
+			{DescriptionContains: &contains, SetCategoryId: &categoryId},
+			{Name: &name, SetCategoryId: &categoryId},
+			{Name: &name, DescriptionContains: &contains, SetTags: []string{" "}},
+			{Name: &name, DescriptionRegex: &invalidRegex, SetCategoryId: &categoryId},
+			{Name: &name, MinAmount: &minAmount, MaxAmount: &maxAmount, SetCategoryId: &categoryId},
+		} {
+			_, err := NewRulesService(&rulesMock{}).CreateRule(request)
+			if !errors.Is(err, ErrInvalidRequest) {
+				t.Errorf("expected invalid request error for %+v but found %v", request, err)
+			}
+		}
+	})
+}
+
+func TestRulesService_ListRules(t *testing.T) {
+
+	t.Run("testing happy flow of listing rules in the order they are evaluated", func(t *testing.T) {
+		rules, err := NewRulesService(&rulesMock{}).ListRules()
+		if err != nil || len(rules) != 3 || rules[0].Id != 1 || rules[1].Id != 2 || rules[2].Id != 3 {
+			t.Errorf("unexpected rules %+v or error %v", rules, err)
+		}
+	})
+
+	t.Run("testing general error flow of listing rules", func(t *testing.T) {
+		_, err := NewRulesService(&rulesMock{err: errors.New("db timeout")}).ListRules()
+		expectedErrorMsg := "failed to list rules with err:db timeout"
+		if err == nil || err.Error() != expectedErrorMsg {
+			t.Errorf("expected error message %s but found %v", expectedErrorMsg, err)
+		}
+	})
+}
+
+func TestEvaluateRules(t *testing.T) {
+
+	rules, _ := NewRulesService(&rulesMock{}).ListRules()
+
+	t.Run("testing the first matching rule wins and tags are collected", func(t *testing.T) {
+		outcome := EvaluateRules(rules, 1, "Starbucks   #889", -15000, 1)
+		if len(outcome.RuleIds) != 3 || *outcome.CategoryId != 4 || *outcome.PayeeId != 2 ||
+			*outcome.Memo != "review" || len(outcome.Tags) != 2 || outcome.Tags[0] != "large" {
+			t.Errorf("unexpected outcome %+v", outcome)
+		}
+	})
+
+	t.Run("testing every condition of a rule has to match", func(t *testing.T) {
+		for _, outcome := range []RuleOutcome{
+			EvaluateRules(rules, 2, "STARBUCKS #889 SEATTLE", -15000, 0),
+			EvaluateRules(rules, 2, "PEETS #12", -450, 0),
+		} {
+			if len(outcome.RuleIds) != 0 || outcome.CategoryId != nil || len(outcome.Tags) != 0 {
+				t.Errorf("expected no rule to match but found %+v", outcome)
+			}
+		}
+		outcome := EvaluateRules(rules, 1, "RENT", -10000, 0)
+		if len(outcome.RuleIds) != 1 || *outcome.CategoryId != 5 {
+			t.Errorf("expected the amount range to be inclusive but found %+v", outcome)
+		}
+	})
+}
+
+func TestTransactionsService_CreateTransaction_rules(t *testing.T) {
+
+	transactionTime := time.Now()
+	accountId := int64(1)
+	description := "STARBUCKS #889"
+	amount := Money(-450)
+
+	t.Run("testing happy flow of categorizing a new transaction", func(t *testing.T) {
+		dao := happyTransactionsMock{}
+		transactionsService := NewTransactionsService(&dao, nil, NewRulesService(&rulesMock{}))
+		_, err := transactionsService.CreateTransaction(TransactionCreationRequest{TransactionTime: &transactionTime,
+			AccountId: &accountId, Description: &description, Amount: &amount, Tags: []string{"work"}})
+		if err != nil {
+			t.Errorf("unexpected error when creating transaction: %v", err)
+			return
+		}
+		if *dao.inserted.CategoryId != 4 || *dao.inserted.PayeeId != 2 || dao.inserted.Memo != nil ||
+			len(dao.inserted.Tags) != 1 {
+			t.Errorf("unexpected transaction inserted %+v", dao.inserted)
+		}
+	})
+
+	t.Run("testing fields of the request are kept", func(t *testing.T) {
+		dao := happyTransactionsMock{}
+		transactionsService := NewTransactionsService(&dao, nil, NewRulesService(&rulesMock{}))
+		categoryId := int64(3)
+		_, err := transactionsService.CreateTransaction(TransactionCreationRequest{TransactionTime: &transactionTime,
+			AccountId: &accountId, Description: &description, Amount: &amount, CategoryId: &categoryId})
+		if err != nil || *dao.inserted.CategoryId != 3 || *dao.inserted.PayeeId != 2 {
+			t.Errorf("unexpected transaction inserted %+v or error %v", dao.inserted, err)
+		}
+	})
+
+	t.Run("testing general error flow of loading rules", func(t *testing.T) {
+		transactionsService := NewTransactionsService(&happyTransactionsMock{}, nil,
+			NewRulesService(&rulesMock{err: errors.New("db timeout")}))
+		_, err := transactionsService.CreateTransaction(TransactionCreationRequest{TransactionTime: &transactionTime,
+			AccountId: &accountId, Description: &description, Amount: &amount})
+		expectedErrorMsg := "failed to load rules for new transaction with err:failed to list rules with err:db timeout"
+		if err == nil || err.Error() != expectedErrorMsg {
+			t.Errorf("expected error message %s but found %v", expectedErrorMsg, err)
+		}
+	})
+}
+
+func TestTransactionsService_ApplyRules(t *testing.T) {
+
+	t.Run("testing happy flow of a dry run", func(t *testing.T) {
+		dao := rulesTransactionsMock{}
+		transactionsService := NewTransactionsService(&dao, nil, NewRulesService(&rulesMock{}))
+		result, err := transactionsService.ApplyRules(RuleApplicationRequest{DryRun: true})
+		if err != nil {
+			t.Errorf("unexpected error when applying rules: %v", err)
+			return
+		}
+		if !result.DryRun || result.Matched != 2 || result.Changed != 2 || len(dao.updated) != 0 {
+			t.Errorf("unexpected result %+v or updates %+v", result, dao.updated)
+			return
+		}
+		coffee := result.Changes[0]
+		if coffee.TransactionId != 1 || coffee.After.CategoryId != 1 || coffee.After.PayeeId != 2 ||
+			len(coffee.After.Tags) != 1 {
+			t.Errorf("expected only the payee of the coffee to be filled in but found %+v", coffee)
+		}
+		amazon := result.Changes[1]
+		if amazon.TransactionId != 2 || len(amazon.Before.Tags) != 0 || amazon.After.Tags[0] != "online" {
+			t.Errorf("unexpected change %+v", amazon)
+		}
+	})
+
+	t.Run("testing happy flow of overwriting transactions", func(t *testing.T) {
+		dao := rulesTransactionsMock{}
+		transactionsService := NewTransactionsService(&dao, nil, NewRulesService(&rulesMock{}))
+		result, err := transactionsService.ApplyRules(RuleApplicationRequest{Overwrite: true})
+		if err != nil || result.Changed != 2 || len(dao.updated) != 2 {
+			t.Errorf("unexpected result %+v or error %v", result, err)
+			return
+		}
+		coffee := dao.updated[1]
+		if *coffee.CategoryId != 4 || *coffee.PayeeId != 2 || *coffee.Description != "STARBUCKS #889" ||
+			*coffee.Amount != -450 || coffee.Tags[0] != "work" || coffee.Memo != nil {
+			t.Errorf("unexpected update %+v", coffee)
+		}
+	})
+
+	t.Run("testing validation of the date range", func(t *testing.T) {
+		from := time.Date(2022, 7, 1, 0, 0, 0, 0, time.UTC)
+		transactionsService := NewTransactionsService(&rulesTransactionsMock{}, nil, NewRulesService(&rulesMock{}))
+		_, err := transactionsService.ApplyRules(RuleApplicationRequest{From: &from, To: &from})
+		if !errors.Is(err, ErrInvalidRequest) {
+			t.Errorf("expected invalid request error but found %v", err)
+		}
+	})
+}
@@ -0,0 +1,302 @@
+package api
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"strings"
+	"time"
+)
+
+const defaultSimulationPaths = 1000
+const maxSimulationPaths = 5000
+const maxSimulationMonths = 480
+const defaultSimulationHistoryMonths = 12
+const maxSimulationHistoryMonths = 120
+
+// simulationPercentiles are the percentiles of net worth reported for every month of a simulation.
+var simulationPercentiles = []float64{5, 25, 50, 75, 95}
+
+// ReturnAssumption is the expected yearly return of the accounts of a sub type, like 0.07 for 7%, and the yearly
+// volatility of that return as a standard deviation.
+type ReturnAssumption struct {
+	AnnualReturn     float64 `json:"annual_return"`
+	AnnualVolatility float64 `json:"annual_volatility"`
+}
+
+// SimulationRequest runs Paths simulations of net worth over HorizonMonths months starting at the balances at the end
+// of AsOf. Every month draws an income and expenses from the distributions of the HistoryMonths full months before
+// the month of AsOf, and grows the balances of accounts whose sub type has a return in Returns. Runs with the same
+// Seed and ledger give the same result.
+type SimulationRequest struct {
+	HorizonMonths *int                        `json:"horizon_months"`
+	Paths         *int                        `json:"paths"`
+	Seed          *int64                      `json:"seed"`
+	HistoryMonths *int                        `json:"history_months"`
+	AsOf          *time.Time                  `json:"as_of"`
+	Currency      *string                     `json:"currency"`
+	Target        *Money                      `json:"target"`
+	Returns       map[string]ReturnAssumption `json:"returns"`
+}
+
+// MonthlyDistribution is the mean and standard deviation of monthly amounts.
+type MonthlyDistribution struct {
+	Mean   Money `json:"mean"`
+	StdDev Money `json:"std_dev"`
+}
+
+// SimulationPoint holds the percentiles of net worth over every path at the end of Month.
+type SimulationPoint struct {
+	Month string `json:"month"`
+	P5    Money  `json:"p5"`
+	P25   Money  `json:"p25"`
+	P50   Money  `json:"p50"`
+	P75   Money  `json:"p75"`
+	P95   Money  `json:"p95"`
+}
+
+// SimulationResult has a point for every month of the horizon. TargetProbability is the share of paths whose net
+// worth reached the target at the end of any month, and is only present along with a target.
+type SimulationResult struct {
+	AsOf              string              `json:"as_of"`
+	Currency          string              `json:"currency"`
+	Seed              int64               `json:"seed"`
+	Paths             int                 `json:"paths"`
+	HorizonMonths     int                 `json:"horizon_months"`
+	StartingNetWorth  Money               `json:"starting_net_worth"`
+	MonthlyIncome     MonthlyDistribution `json:"monthly_income"`
+	MonthlyExpenses   MonthlyDistribution `json:"monthly_expenses"`
+	Points            []SimulationPoint   `json:"points"`
+	Target            *Money              `json:"target,omitempty"`
+	TargetProbability *float64            `json:"target_probability,omitempty"`
+}
+
+// SimulateNetWorth runs a Monte Carlo simulation of net worth. The monthly income and expenses of the simulation
+// leave out transfers and land in the accounts without an expected return, and liabilities do not grow.
+func (service *ReportsService) SimulateNetWorth(request SimulationRequest) (SimulationResult, error) {
+	request, err := validateSimulation(request)
+	if err != nil {
+		return SimulationResult{}, err
+	}
+	asOf := truncateToDay(*request.AsOf)
+	accounts, err := service.dao.ListReportAccounts(asOf.AddDate(0, 0, 1))
+	if err != nil {
+		return SimulationResult{}, fmt.Errorf("failed to list accounts of simulation with err:%v", err)
+	}
+	currency := sharedCurrency(accounts)
+	if request.Currency != nil {
+		currency = *request.Currency
+	}
+	if currency, err = NormalizeCurrency(currency); err != nil {
+		return SimulationResult{}, err
+	}
+	var converter *CurrencyConverter
+	convert := func(amount Money, from string, on time.Time) (Money, error) {
+		if from == currency {
+			return amount, nil
+		}
+		if converter == nil {
+			if converter, err = loadConverter(service.exchangeRates); err != nil {
+				return 0, err
+			}
+		}
+		return converter.Convert(amount, from, currency, on)
+	}
+
+	// the balances at the end of asOf, where accounts without an expected return are pooled into cash
+	dailyTotals, err := service.dao.SumAmountsByAccountAndDay(asOf.AddDate(0, 0, 1))
+	if err != nil {
+		return SimulationResult{}, fmt.Errorf("failed to sum transactions of simulation with err:%v", err)
+	}
+	balances := map[int64]Money{}
+	for _, account := range accounts {
+		balances[account.Id] = account.OpeningBalance
+	}
+	for _, total := range dailyTotals {
+		if _, ok := balances[total.AccountId]; ok {
+			balances[total.AccountId] += total.Amount
+		}
+	}
+	var cash float64
+	invested := map[string]float64{}
+	var startingNetWorth Money
+	for _, account := range accounts {
+		if !isHeldOn(account, asOf.Format(ReportDateLayout)) {
+			continue
+		}
+		balance, err := convert(balances[account.Id], accountCurrency(account), asOf)
+		if err != nil {
+			return SimulationResult{}, err
+		}
+		startingNetWorth += balance
+		subType := normalizeSubType(account.AccountSubType)
+		if _, ok := request.Returns[subType]; ok && !IsLiabilityAccountType(account.AccountType) {
+			invested[subType] += float64(balance)
+		} else {
+			cash += float64(balance)
+		}
+	}
+
+	// the income and expenses of every full month of the history, including months without any
+	historyEnd := time.Date(asOf.Year(), asOf.Month(), 1, 0, 0, 0, 0, time.UTC)
+	historyStart := historyEnd.AddDate(0, -*request.HistoryMonths, 0)
+	cashFlow, err := service.dao.SumCashFlow(historyStart, historyEnd, CashFlowGroupByCategory)
+	if err != nil {
+		return SimulationResult{}, fmt.Errorf("failed to sum cash flow of simulation with err:%v", err)
+	}
+	months := map[string]int{}
+	for i := 0; i < *request.HistoryMonths; i++ {
+		months[historyStart.AddDate(0, i, 0).Format(BudgetMonthLayout)] = i
+	}
+	incomes := make([]float64, *request.HistoryMonths)
+	expenses := make([]float64, *request.HistoryMonths)
+	for _, total := range cashFlow {
+		day, err := time.Parse(ReportDateLayout, total.Day)
+		if err != nil {
+			return SimulationResult{}, fmt.Errorf("failed to read day %q of the cash flow", total.Day)
+		}
+		month, ok := months[day.Format(BudgetMonthLayout)]
+		if !ok {
+			continue
+		}
+		income, err := convert(total.Income, total.Currency, day)
+		if err != nil {
+			return SimulationResult{}, err
+		}
+		expense, err := convert(total.Expenses, total.Currency, day)
+		if err != nil {
+			return SimulationResult{}, err
+		}
+		incomes[month] += float64(income)
+		expenses[month] += float64(expense)
+	}
+	incomeMean, incomeStdDev := meanAndStdDev(incomes)
+	expensesMean, expensesStdDev := meanAndStdDev(expenses)
+
+	subTypes := make([]string, 0, len(invested))
+	for subType := range invested {
+		subTypes = append(subTypes, subType)
+	}
+	// sub types are drawn in a fixed order so that a seed always gives the same paths
+	sort.Strings(subTypes)
+	random := rand.New(rand.NewSource(*request.Seed))
+	horizon, paths := *request.HorizonMonths, *request.Paths
+	netWorths := make([][]float64, horizon)
+	for month := range netWorths {
+		netWorths[month] = make([]float64, paths)
+	}
+	reachedTarget := 0
+	for path := 0; path < paths; path++ {
+		pathCash := cash
+		pathInvested := make([]float64, len(subTypes))
+		for i, subType := range subTypes {
+			pathInvested[i] = invested[subType]
+		}
+		reached := false
+		for month := 0; month < horizon; month++ {
+			pathCash += math.Max(0, incomeMean+incomeStdDev*random.NormFloat64()) -
+				math.Max(0, expensesMean+expensesStdDev*random.NormFloat64())
+			netWorth := pathCash
+			for i, subType := range subTypes {
+				assumption := request.Returns[subType]
+				monthlyReturn := assumption.AnnualReturn/12 + assumption.AnnualVolatility/math.Sqrt(12)*
+					random.NormFloat64()
+				pathInvested[i] *= 1 + math.Max(-1, monthlyReturn)
+				netWorth += pathInvested[i]
+			}
+			netWorths[month][path] = netWorth
+			if request.Target != nil && netWorth >= float64(*request.Target) {
+				reached = true
+			}
+		}
+		if reached {
+			reachedTarget++
+		}
+	}
+
+	result := SimulationResult{AsOf: asOf.Format(ReportDateLayout), Currency: currency, Seed: *request.Seed,
+		Paths: paths, HorizonMonths: horizon, StartingNetWorth: startingNetWorth,
+		MonthlyIncome:   MonthlyDistribution{Mean: toMoney(incomeMean), StdDev: toMoney(incomeStdDev)},
+		MonthlyExpenses: MonthlyDistribution{Mean: toMoney(expensesMean), StdDev: toMoney(expensesStdDev)},
+		Points:          make([]SimulationPoint, horizon), Target: request.Target}
+	for month, values := range netWorths {
+		sort.Float64s(values)
+		percentiles := make([]Money, len(simulationPercentiles))
+		for i, percentile := range simulationPercentiles {
+			percentiles[i] = toMoney(values[int(math.Round(percentile/100*float64(paths-1)))])
+		}
+		result.Points[month] = SimulationPoint{Month: asOf.AddDate(0, month+1, 1-asOf.Day()).
+			Format(BudgetMonthLayout), P5: percentiles[0], P25: percentiles[1], P50: percentiles[2],
+			P75: percentiles[3], P95: percentiles[4]}
+	}
+	if request.Target != nil {
+		probability := float64(reachedTarget) / float64(paths)
+		result.TargetProbability = &probability
+	}
+	return result, nil
+}
+
+// validateSimulation checks the request and fills in its defaults. The seed defaults to the current time and is
+// returned with the result so that the run can be repeated.
+func validateSimulation(request SimulationRequest) (SimulationRequest, error) {
+	if request.HorizonMonths == nil || *request.HorizonMonths < 1 || *request.HorizonMonths > maxSimulationMonths {
+		return request, fmt.Errorf("%w: horizon_months has to be between 1 and %d", ErrInvalidRequest,
+			maxSimulationMonths)
+	}
+	if request.Paths == nil {
+		paths := defaultSimulationPaths
+		request.Paths = &paths
+	}
+	if *request.Paths < 1 || *request.Paths > maxSimulationPaths {
+		return request, fmt.Errorf("%w: paths has to be between 1 and %d", ErrInvalidRequest, maxSimulationPaths)
+	}
+	if request.HistoryMonths == nil {
+		historyMonths := defaultSimulationHistoryMonths
+		request.HistoryMonths = &historyMonths
+	}
+	if *request.HistoryMonths < 1 || *request.HistoryMonths > maxSimulationHistoryMonths {
+		return request, fmt.Errorf("%w: history_months has to be between 1 and %d", ErrInvalidRequest,
+			maxSimulationHistoryMonths)
+	}
+	if request.Seed == nil {
+		seed := time.Now().UnixNano()
+		request.Seed = &seed
+	}
+	if request.AsOf == nil {
+		now := time.Now().UTC()
+		request.AsOf = &now
+	}
+	returns := make(map[string]ReturnAssumption, len(request.Returns))
+	for subType, assumption := range request.Returns {
+		if assumption.AnnualReturn <= -1 || assumption.AnnualVolatility < 0 {
+			return request, fmt.Errorf("%w: the return of %s has to be above -1 and its volatility cannot be "+
+				"negative", ErrInvalidRequest, subType)
+		}
+		returns[normalizeSubType(subType)] = assumption
+	}
+	request.Returns = returns
+	return request, nil
+}
+
+func normalizeSubType(subType string) string {
+	return strings.ToLower(strings.TrimSpace(subType))
+}
+
+func meanAndStdDev(values []float64) (float64, float64) {
+	var sum float64
+	for _, value := range values {
+		sum += value
+	}
+	mean := sum / float64(len(values))
+	var squares float64
+	for _, value := range values {
+		squares += (value - mean) * (value - mean)
+	}
+	return mean, math.Sqrt(squares / float64(len(values)))
+}
+
+// toMoney rounds a simulated amount of cents to whole cents.
+func toMoney(cents float64) Money {
+	return Money(math.Round(cents))
+}
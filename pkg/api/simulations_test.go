@@ -0,0 +1,115 @@
+package api
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestReportsService_SimulateNetWorth(t *testing.T) {
+
+	asOf := time.Date(2022, 8, 10, 0, 0, 0, 0, time.UTC)
+	horizonMonths, historyMonths, paths := 3, 1, 50
+	seed := int64(42)
+
+	t.Run("testing happy flow of a simulation without any uncertainty", func(t *testing.T) {
+		target := Money(800000)
+		result, err := NewReportsService(&reportsMock{}, nil, nil).SimulateNetWorth(SimulationRequest{
+			HorizonMonths: &horizonMonths, HistoryMonths: &historyMonths, Paths: &paths, Seed: &seed, AsOf: &asOf,
+			Target: &target})
+		if err != nil {
+			t.Errorf("unexpected error when simulating net worth: %v", err)
+			return
+		}
+		if result.StartingNetWorth != 345000 || result.Currency != "USD" ||
+			result.MonthlyIncome != (MonthlyDistribution{Mean: 250500}) ||
+			result.MonthlyExpenses != (MonthlyDistribution{Mean: 6250}) {
+			t.Errorf("unexpected inputs of the simulation %+v", result)
+		}
+		expected := []SimulationPoint{
+			{Month: "2022-09", P5: 589250, P25: 589250, P50: 589250, P75: 589250, P95: 589250},
+			{Month: "2022-10", P5: 833500, P25: 833500, P50: 833500, P75: 833500, P95: 833500},
+			{Month: "2022-11", P5: 1077750, P25: 1077750, P50: 1077750, P75: 1077750, P95: 1077750},
+		}
+		if len(result.Points) != 3 || result.Points[0] != expected[0] || result.Points[2] != expected[2] {
+			t.Errorf("unexpected points %+v", result.Points)
+		}
+		if result.TargetProbability == nil || *result.TargetProbability != 1 {
+			t.Errorf("expected the target to be reached on every path but found %v", result.TargetProbability)
+		}
+	})
+
+	t.Run("testing investments grow by their expected return", func(t *testing.T) {
+		dao := reportsMock{accounts: []Account{
+			{Id: 1, AccountType: "checking", OpeningBalance: 100000, Currency: "USD"},
+			{Id: 5, AccountType: "investment", AccountSubType: "Stocks", OpeningBalance: 1000000, Currency: "USD"},
+		}}
+		result, err := NewReportsService(&dao, nil, nil).SimulateNetWorth(SimulationRequest{
+			HorizonMonths: &horizonMonths, HistoryMonths: &historyMonths, Paths: &paths, Seed: &seed, AsOf: &asOf,
+			Returns: map[string]ReturnAssumption{"stocks ": {AnnualReturn: 0.12}}})
+		if err != nil || result.Points[0].P50 != 1599250 {
+			t.Errorf("unexpected points %+v or error %v", result.Points, err)
+		}
+	})
+
+	t.Run("testing simulations are reproducible with a seed", func(t *testing.T) {
+		target := Money(450000)
+		request := SimulationRequest{HorizonMonths: &horizonMonths, Paths: &paths, Seed: &seed, AsOf: &asOf,
+			Target: &target}
+		reportsService := NewReportsService(&reportsMock{}, nil, nil)
+		first, err := reportsService.SimulateNetWorth(request)
+		if err != nil {
+			t.Errorf("unexpected error when simulating net worth: %v", err)
+			return
+		}
+		second, _ := reportsService.SimulateNetWorth(request)
+		otherSeed := int64(7)
+		request.Seed = &otherSeed
+		other, _ := reportsService.SimulateNetWorth(request)
+		for month, point := range first.Points {
+			if point != second.Points[month] {
+				t.Errorf("expected the same points with the same seed but found %+v and %+v", point,
+					second.Points[month])
+			}
+			if point.P5 > point.P25 || point.P25 > point.P50 || point.P50 > point.P75 || point.P75 > point.P95 {
+				t.Errorf("expected ordered percentiles but found %+v", point)
+			}
+		}
+		if first.Points[2] == other.Points[2] {
+			t.Errorf("expected other points with another seed but found %+v", other.Points[2])
+		}
+		if *first.TargetProbability != *second.TargetProbability || *first.TargetProbability <= 0 ||
+			*first.TargetProbability >= 1 {
+			t.Errorf("unexpected target probabilities %f and %f", *first.TargetProbability,
+				*second.TargetProbability)
+		}
+	})
+
+	t.Run("testing validation of the simulation", func(t *testing.T) {
+		zero, tooLong, tooMany := 0, maxSimulationMonths+1, maxSimulationPaths+1
+		currency := "dollars"
+		for _, request := range []SimulationRequest{
+			{},
+			{HorizonMonths: &zero},
+			{HorizonMonths: &tooLong},
+			{HorizonMonths: &horizonMonths, Paths: &tooMany},
+			{HorizonMonths: &horizonMonths, HistoryMonths: &zero},
+			{HorizonMonths: &horizonMonths, Returns: map[string]ReturnAssumption{"stocks": {AnnualReturn: -1}}},
+			{HorizonMonths: &horizonMonths, Currency: &currency},
+		} {
+			_, err := NewReportsService(&reportsMock{}, nil, nil).SimulateNetWorth(request)
+			if !errors.Is(err, ErrInvalidRequest) {
+				t.Errorf("expected invalid request error for %+v but found %v", request, err)
+			}
+		}
+	})
+
+	t.Run("testing general error flow of simulating net worth", func(t *testing.T) {
+		reportsService := NewReportsService(&reportsMock{err: errors.New("db timeout")}, nil, nil)
+		_, err := reportsService.SimulateNetWorth(SimulationRequest{HorizonMonths: &horizonMonths})
+		expectedErrorMsg := "failed to list accounts of simulation with err:db timeout"
+		if err == nil || err.Error() != expectedErrorMsg {
+			t.Errorf("expected error message %s but found %v", expectedErrorMsg, err)
+		}
+	})
+}
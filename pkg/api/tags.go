@@ -0,0 +1,118 @@
+package api
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Tag is a free form label attached to any number of transactions. Tags are identified by their name and do not
+// support soft deletes.
+type Tag struct {
+	Name      string    `json:"name"`
+	CreatedTs time.Time `json:"created_ts"`
+	UpdatedTs time.Time `json:"updated_ts"`
+}
+
+type TagCreationRequest struct {
+	Name *string `json:"name"`
+}
+
+type TagUpdateRequest struct {
+	Name *string `json:"name"`
+}
+
+type TagsService struct {
+	dao TagsDataAccessor
+}
+
+func NewTagsService(dao TagsDataAccessor) *TagsService {
+	return &TagsService{dao}
+}
+
+func (service *TagsService) ListTags() ([]Tag, error) {
+	tags, err := service.dao.ListTags()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tags with err:%v", err)
+	}
+	return tags, nil
+}
+
+func (service *TagsService) CreateTag(request TagCreationRequest) error {
+	name, err := validateTagName(request.Name)
+	if err != nil {
+		return err
+	}
+	return service.dao.InsertTag(name)
+}
+
+// RenameTag renames a tag and every transaction labelled with it.
+func (service *TagsService) RenameTag(name string, request TagUpdateRequest) error {
+	newName, err := validateTagName(request.Name)
+	if err != nil {
+		return err
+	}
+	if newName == name {
+		return nil
+	}
+	return service.dao.RenameTag(name, newName)
+}
+
+// DeleteTag removes the tag from every transaction before deleting it.
+func (service *TagsService) DeleteTag(name string) error {
+	err := service.dao.DeleteTag(name)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// AddTagToTransaction labels a transaction, creating the tag when it does not exist yet.
+func (service *TagsService) AddTagToTransaction(transactionId int64, request TagCreationRequest) error {
+	name, err := validateTagName(request.Name)
+	if err != nil {
+		return err
+	}
+	return service.dao.AddTransactionTag(transactionId, name)
+}
+
+func (service *TagsService) RemoveTagFromTransaction(transactionId int64, name string) error {
+	err := service.dao.RemoveTransactionTag(transactionId, name)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func validateTagName(name *string) (string, error) {
+	if name == nil || strings.TrimSpace(*name) == "" {
+		return "", fmt.Errorf("%w: name is required", ErrInvalidRequest)
+	}
+	return strings.TrimSpace(*name), nil
+}
+
+// NormalizeTags trims tag names, drops blank ones and removes duplicates so that a transaction carries each tag once.
+func NormalizeTags(tags []string) []string {
+	seen := map[string]bool{}
+	normalized := []string{}
+	for _, tag := range tags {
+		tag = strings.TrimSpace(tag)
+		if tag == "" || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		normalized = append(normalized, tag)
+	}
+	sort.Strings(normalized)
+	return normalized
+}
+
+type TagsDataAccessor interface {
+	ListTags() ([]Tag, error)
+	InsertTag(name string) error
+	RenameTag(name string, newName string) error
+	DeleteTag(name string) error
+	AddTransactionTag(transactionId int64, name string) error
+	RemoveTransactionTag(transactionId int64, name string) error
+}
@@ -0,0 +1,105 @@
+package api
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+type tagsMock struct {
+	renamedFrom string
+	renamedTo   string
+	tagged      string
+}
+
+func (m *tagsMock) ListTags() ([]Tag, error) {
+	return []Tag{{Name: "food"}}, nil
+}
+
+func (m *tagsMock) InsertTag(name string) error {
+	return nil
+}
+
+func (m *tagsMock) RenameTag(name string, newName string) error {
+	m.renamedFrom = name
+	m.renamedTo = newName
+	return nil
+}
+
+func (m *tagsMock) DeleteTag(name string) error {
+	return errors.New("db timeout")
+}
+
+func (m *tagsMock) AddTransactionTag(transactionId int64, name string) error {
+	m.tagged = name
+	return nil
+}
+
+func (m *tagsMock) RemoveTransactionTag(transactionId int64, name string) error {
+	return nil
+}
+
+func TestTagsService_RenameTag(t *testing.T) {
+
+	t.Run("testing happy flow of renaming a tag", func(t *testing.T) {
+		dao := tagsMock{}
+		tagsService := NewTagsService(&dao)
+		newName := " groceries "
+		err := tagsService.RenameTag("food", TagUpdateRequest{Name: &newName})
+		if err != nil {
+			t.Errorf("unexpected error when renaming tag: %v", err)
+		}
+		if dao.renamedFrom != "food" || dao.renamedTo != "groceries" {
+			t.Errorf("unexpected rename from %s to %s", dao.renamedFrom, dao.renamedTo)
+		}
+	})
+
+	t.Run("testing renaming a tag to its own name is a no-op", func(t *testing.T) {
+		dao := tagsMock{}
+		tagsService := NewTagsService(&dao)
+		newName := "food"
+		_ = tagsService.RenameTag("food", TagUpdateRequest{Name: &newName})
+		if dao.renamedTo != "" {
+			t.Errorf("expected no rename but tag was renamed to %s", dao.renamedTo)
+		}
+	})
+
+	t.Run("testing validation of blank names", func(t *testing.T) {
+		tagsService := NewTagsService(&tagsMock{})
+		newName := ""
+		err := tagsService.RenameTag("food", TagUpdateRequest{Name: &newName})
+		if !errors.Is(err, ErrInvalidRequest) {
+			t.Errorf("expected invalid request error but found %v", err)
+		}
+	})
+}
+
+func TestTagsService_AddTagToTransaction(t *testing.T) {
+
+	dao := tagsMock{}
+	tagsService := NewTagsService(&dao)
+	name := " weekly"
+	err := tagsService.AddTagToTransaction(1, TagCreationRequest{Name: &name})
+	if err != nil || dao.tagged != "weekly" {
+		t.Errorf("unexpected result %v when tagging transaction with %s", err, dao.tagged)
+	}
+}
+
+func TestTagsService_DeleteTag(t *testing.T) {
+
+	tagsService := NewTagsService(&tagsMock{})
+	err := tagsService.DeleteTag("food")
+	expectedErrorMsg := "db timeout"
+	if err == nil || err.Error() != expectedErrorMsg {
+		t.Errorf("expected error message %s but found %v", expectedErrorMsg, err)
+	}
+}
+
+func TestNormalizeTags(t *testing.T) {
+
+	normalized := NormalizeTags([]string{" weekly", "food", "", "weekly", "  "})
+	expected := []string{"food", "weekly"}
+	if !reflect.DeepEqual(normalized, expected) {
+		t.Errorf("expected tags %v but found %v", expected, normalized)
+	}
+}
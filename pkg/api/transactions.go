@@ -0,0 +1,362 @@
+package api
+
+import (
+	"fmt"
+	"time"
+)
+
+const NormalTransactionType = "normal"
+const TransferTransactionType = "transfer"
+
+type Transaction struct {
+	Id              int64     `json:"id"`
+	TransactionTime time.Time `json:"transaction_time"`
+	TransactionType string    `json:"type"`
+	AccountId       int64     `json:"account_id"`
+	Description     string    `json:"description"`
+	Memo            string    `json:"memo"`
+	Amount          Money     `json:"amount"`
+	// Currency is empty or the currency of the account, which is what every amount of the account is in
+	Currency   string   `json:"currency"`
+	PayeeId    int64    `json:"payee_id"`
+	CategoryId int64    `json:"category_id"`
+	Tags       []string `json:"tags"`
+	// LinkedTransactionId points at the other leg of a transfer
+	LinkedTransactionId int64 `json:"linked_transaction_id"`
+	// ExternalId is the id the bank assigned to an imported transaction, like the FITID of an ofx statement
+	ExternalId string `json:"external_id"`
+	// Splits break the transaction down into several categories and add up to its amount
+	Splits    []TransactionSplit `json:"splits"`
+	IsDeleted bool               `json:"is_deleted"`
+	CreatedTs time.Time          `json:"created_ts"`
+	UpdatedTs time.Time          `json:"updated_ts"`
+}
+
+type TransactionCreationRequest struct {
+	TransactionTime *time.Time                `json:"transaction_time"`
+	TransactionType *string                   `json:"type"`
+	AccountId       *int64                    `json:"account_id"`
+	Description     *string                   `json:"description"`
+	Memo            *string                   `json:"memo"`
+	Amount          *Money                    `json:"amount"`
+	Currency        *string                   `json:"currency"`
+	PayeeId         *int64                    `json:"payee_id"`
+	CategoryId      *int64                    `json:"category_id"`
+	Tags            []string                  `json:"tags"`
+	ExternalId      *string                   `json:"external_id"`
+	Splits          []TransactionSplitRequest `json:"splits"`
+}
+
+// TransactionSplit is the part of the amount of a transaction that belongs to a category. A CategoryId of 0 marks an
+// uncategorized split. Category reports count the splits of a transaction instead of its own category, while its Tags
+// come on top of the tags of the whole transaction.
+type TransactionSplit struct {
+	CategoryId int64    `json:"category_id"`
+	Amount     Money    `json:"amount"`
+	Memo       string   `json:"memo"`
+	Tags       []string `json:"tags"`
+}
+
+type TransactionSplitRequest struct {
+	CategoryId *int64   `json:"category_id"`
+	Amount     *Money   `json:"amount"`
+	Memo       *string  `json:"memo"`
+	Tags       []string `json:"tags"`
+}
+
+// TransactionUpdateRequest overwrites a transaction. Splits replace the splits of the transaction when present, so an
+// empty list removes them, and are left as they are when missing.
+type TransactionUpdateRequest struct {
+	TransactionTime *time.Time                `json:"transaction_time"`
+	TransactionType *string                   `json:"type"`
+	AccountId       *int64                    `json:"account_id"`
+	Description     *string                   `json:"description"`
+	Memo            *string                   `json:"memo"`
+	Amount          *Money                    `json:"amount"`
+	Currency        *string                   `json:"currency"`
+	PayeeId         *int64                    `json:"payee_id"`
+	CategoryId      *int64                    `json:"category_id"`
+	Tags            []string                  `json:"tags"`
+	Splits          []TransactionSplitRequest `json:"splits"`
+}
+
+// TransactionMergeRequest carries the details an imported duplicate adds to an existing transaction. Only the fields
+// the transaction does not have yet are filled in.
+type TransactionMergeRequest struct {
+	ExternalId *string
+	Memo       *string
+	PayeeId    *int64
+	CategoryId *int64
+}
+
+// TransactionImport holds what an import writes to the ledger, which is written in full or not at all. Its inserts
+// have to be prepared by PrepareTransaction.
+type TransactionImport struct {
+	Inserts []TransactionCreationRequest
+	Merges  []TransactionMerge
+}
+
+// TransactionMerge merges an imported duplicate into the transaction it duplicates.
+type TransactionMerge struct {
+	TransactionId int64
+	Request       TransactionMergeRequest
+}
+
+// TransactionFilter narrows down ListTransactions. Nil fields are not applied. From is inclusive and To is exclusive.
+type TransactionFilter struct {
+	AccountId *int64
+	From      *time.Time
+	To        *time.Time
+	Tag       *string
+}
+
+// PayeeResolver maps a raw transaction description onto a canonical payee. The boolean is false when no payee
+// could be found for the description.
+type PayeeResolver interface {
+	ResolvePayee(description string) (int64, bool, error)
+}
+
+type TransactionsService struct {
+	dao           TransactionsDataAccessor
+	payeeResolver PayeeResolver
+	rulesProvider RulesProvider
+}
+
+// NewTransactionsService creates the service. payeeResolver is optional and, when present, is used to fill in
+// the payee of new transactions that only carry a description. rulesProvider is optional as well and, when present,
+// its rules categorize new transactions.
+func NewTransactionsService(dao TransactionsDataAccessor, payeeResolver PayeeResolver,
+	rulesProvider RulesProvider) *TransactionsService {
+	return &TransactionsService{dao: dao, payeeResolver: payeeResolver, rulesProvider: rulesProvider}
+}
+
+func (service *TransactionsService) GetTransaction(id int64) (Transaction, error) {
+	transaction, err := service.dao.GetTransaction(id)
+	if err != nil {
+		return transaction, fmt.Errorf("failed to retrieve transaction of id %d with err:%v", id, err)
+	}
+	return transaction, nil
+}
+
+func (service *TransactionsService) ListTransactions(filter TransactionFilter) ([]Transaction, error) {
+	transactions, err := service.dao.ListTransactions(filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list transactions with err:%v", err)
+	}
+	return transactions, nil
+}
+
+func (service *TransactionsService) CreateTransaction(request TransactionCreationRequest) (int64, error) {
+	request, err := service.PrepareTransaction(request)
+	if err != nil {
+		return -1, err
+	}
+	return service.dao.InsertTransaction(request)
+}
+
+// PrepareTransaction validates a new transaction and fills in its type, its payee and what the rules categorize it as,
+// without writing anything.
+func (service *TransactionsService) PrepareTransaction(request TransactionCreationRequest) (
+	TransactionCreationRequest, error) {
+	transactionType, err := validateTransactionFields(request.TransactionTime, request.TransactionType,
+		request.AccountId, request.Amount)
+	if err != nil {
+		return request, err
+	}
+	if transactionType == TransferTransactionType {
+		return request, fmt.Errorf("%w: transfers have to be created through the transfers api", ErrInvalidRequest)
+	}
+	if err = validateSplits(request.Splits, *request.Amount); err != nil {
+		return request, err
+	}
+	if request.Currency, err = service.validateCurrency(*request.AccountId, request.Currency); err != nil {
+		return request, err
+	}
+	request.TransactionType = &transactionType
+	request.Tags = NormalizeTags(request.Tags)
+	if request.PayeeId == nil && request.Description != nil && service.payeeResolver != nil {
+		payeeId, found, err := service.payeeResolver.ResolvePayee(*request.Description)
+		if err != nil {
+			return request, fmt.Errorf("failed to resolve payee of new transaction with err:%v", err)
+		}
+		if found {
+			request.PayeeId = &payeeId
+		}
+	}
+	if err = service.applyRulesToRequest(&request); err != nil {
+		return request, err
+	}
+	return request, nil
+}
+
+// DeleteTransaction soft deletes a transaction. Deleting either leg of a transfer deletes the whole transfer.
+func (service *TransactionsService) DeleteTransaction(id int64) error {
+	transaction, err := service.dao.GetTransaction(id)
+	if err != nil {
+		return err
+	}
+	if transaction.TransactionType == TransferTransactionType {
+		return service.dao.DeleteTransfer(id)
+	}
+	err = service.dao.DeleteTransaction(id)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// UpdateTransaction overwrites a transaction. Changes to the time or amount of a transfer leg are mirrored onto
+// the other leg, and a transaction cannot be turned into or out of a transfer.
+func (service *TransactionsService) UpdateTransaction(id int64, request TransactionUpdateRequest) error {
+	transactionType, err := validateTransactionFields(request.TransactionTime, request.TransactionType,
+		request.AccountId, request.Amount)
+	if err != nil {
+		return err
+	}
+	existing, err := service.dao.GetTransaction(id)
+	if err != nil {
+		return err
+	}
+	isTransfer := existing.TransactionType == TransferTransactionType
+	if request.TransactionType != nil && isTransfer != (transactionType == TransferTransactionType) {
+		return fmt.Errorf("%w: the type of transaction %d cannot be changed", ErrInvalidRequest, id)
+	}
+	if request.Splits != nil {
+		if isTransfer && len(request.Splits) > 0 {
+			return fmt.Errorf("%w: transfer %d cannot be split", ErrInvalidRequest, id)
+		}
+		if err = validateSplits(request.Splits, *request.Amount); err != nil {
+			return err
+		}
+	} else if len(existing.Splits) > 0 && *request.Amount != existing.Amount {
+		return fmt.Errorf("%w: the amount of transaction %d cannot be changed without splitting it again",
+			ErrInvalidRequest, id)
+	}
+	if request.Currency, err = service.validateCurrency(*request.AccountId, request.Currency); err != nil {
+		return err
+	}
+	request.TransactionType = &existing.TransactionType
+	request.Tags = NormalizeTags(request.Tags)
+	if isTransfer {
+		if *request.AccountId != existing.AccountId {
+			linkedLeg, err := service.dao.GetTransaction(existing.LinkedTransactionId)
+			if err != nil {
+				return err
+			}
+			if err = service.validateTransferCurrencies(*request.AccountId, linkedLeg.AccountId); err != nil {
+				return err
+			}
+		}
+		return service.dao.UpdateTransfer(id, request)
+	}
+	return service.dao.UpdateTransaction(id, request)
+}
+
+// validateCurrency normalizes the currency of a transaction, which has to be the currency of its account as balances
+// and reports take every amount of an account to be in its currency. A blank currency is left out.
+func (service *TransactionsService) validateCurrency(accountId int64, currency *string) (*string, error) {
+	if isBlank(currency) {
+		return nil, nil
+	}
+	normalized, err := NormalizeCurrency(*currency)
+	if err != nil {
+		return nil, err
+	}
+	accountCurrency, err := service.findAccountCurrency(accountId)
+	if err != nil {
+		return nil, err
+	}
+	if normalized != accountCurrency {
+		return nil, fmt.Errorf("%w: currency %s differs from currency %s of account %d", ErrInvalidRequest,
+			normalized, accountCurrency, accountId)
+	}
+	return &normalized, nil
+}
+
+func (service *TransactionsService) findAccountCurrency(accountId int64) (string, error) {
+	currency, found, err := service.dao.FindAccountCurrency(accountId)
+	if err != nil {
+		return "", fmt.Errorf("failed to find currency of account %d with err:%v", accountId, err)
+	}
+	if !found {
+		return "", fmt.Errorf("%w: account %d does not exist", ErrInvalidRequest, accountId)
+	}
+	return currency, nil
+}
+
+// FindTransactionByExternalId looks up a transaction of the account by the id the bank assigned to it. The boolean is
+// false when the account has no such transaction.
+func (service *TransactionsService) FindTransactionByExternalId(accountId int64, externalId string) (int64, bool,
+	error) {
+	id, found, err := service.dao.FindTransactionByExternalId(accountId, externalId)
+	if err != nil {
+		return -1, false, fmt.Errorf("failed to find transaction with external id %s with err:%v", externalId, err)
+	}
+	return id, found, nil
+}
+
+// ImportTransactions writes the inserts and merges of an import within one transaction and returns the ids of the
+// inserted transactions in order.
+func (service *TransactionsService) ImportTransactions(batch TransactionImport) ([]int64, error) {
+	ids, err := service.dao.ImportTransactions(batch)
+	if err != nil {
+		return nil, fmt.Errorf("failed to import transactions with err:%v", err)
+	}
+	return ids, nil
+}
+
+// validateTransactionFields checks the mandatory fields shared by creation and update requests and returns the
+// transaction type to persist, defaulting to a normal transaction.
+func validateTransactionFields(transactionTime *time.Time, transactionType *string, accountId *int64,
+	amount *Money) (string, error) {
+	if transactionTime == nil {
+		return "", fmt.Errorf("%w: transaction_time is required", ErrInvalidRequest)
+	}
+	if accountId == nil {
+		return "", fmt.Errorf("%w: account_id is required", ErrInvalidRequest)
+	}
+	if amount == nil {
+		return "", fmt.Errorf("%w: amount is required", ErrInvalidRequest)
+	}
+	if transactionType == nil {
+		return NormalTransactionType, nil
+	}
+	if *transactionType != NormalTransactionType && *transactionType != TransferTransactionType {
+		return "", fmt.Errorf("%w: unknown transaction type %s", ErrInvalidRequest, *transactionType)
+	}
+	return *transactionType, nil
+}
+
+// validateSplits checks that every split has an amount and that the splits add up to the amount of the transaction.
+// The tags of the splits are normalized in place.
+func validateSplits(splits []TransactionSplitRequest, amount Money) error {
+	if len(splits) == 0 {
+		return nil
+	}
+	var total Money
+	for i, split := range splits {
+		if split.Amount == nil {
+			return fmt.Errorf("%w: amount of split %d is required", ErrInvalidRequest, i+1)
+		}
+		total += *split.Amount
+		splits[i].Tags = NormalizeTags(split.Tags)
+	}
+	if total != amount {
+		return fmt.Errorf("%w: splits add up to %s instead of the amount %s", ErrInvalidRequest, total, amount)
+	}
+	return nil
+}
+
+type TransactionsDataAccessor interface {
+	GetTransaction(id int64) (Transaction, error)
+	ListTransactions(filter TransactionFilter) ([]Transaction, error)
+	InsertTransaction(request TransactionCreationRequest) (int64, error)
+	DeleteTransaction(id int64) error
+	UpdateTransaction(id int64, request TransactionUpdateRequest) error
+	InsertTransfer(request TransferRequest) (int64, int64, error)
+	UpdateTransfer(id int64, request TransactionUpdateRequest) error
+	DeleteTransfer(id int64) error
+	FindTransactionByExternalId(accountId int64, externalId string) (int64, bool, error)
+	FindAccountCurrency(accountId int64) (string, bool, error)
+	ImportTransactions(batch TransactionImport) ([]int64, error)
+}
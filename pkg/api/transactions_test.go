@@ -0,0 +1,383 @@
+package api
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type happyTransactionsMock struct {
+	inserted *TransactionCreationRequest
+}
+
+func (m *happyTransactionsMock) GetTransaction(id int64) (Transaction, error) {
+	return Transaction{
+		Id:              id,
+		TransactionTime: time.Now(),
+		TransactionType: NormalTransactionType,
+		AccountId:       int64(1),
+		Description:     "groceries",
+		Amount:          -2050,
+	}, nil
+}
+
+func (m *happyTransactionsMock) ListTransactions(filter TransactionFilter) ([]Transaction, error) {
+	return []Transaction{{Id: 1}, {Id: 2}}, nil
+}
+
+func (m *happyTransactionsMock) InsertTransaction(request TransactionCreationRequest) (int64, error) {
+	m.inserted = &request
+	return 1, nil
+}
+
+func (m *happyTransactionsMock) DeleteTransaction(id int64) error {
+	return nil
+}
+
+func (m *happyTransactionsMock) UpdateTransaction(id int64, request TransactionUpdateRequest) error {
+	return nil
+}
+
+func (m *happyTransactionsMock) InsertTransfer(request TransferRequest) (int64, int64, error) {
+	return 1, 2, nil
+}
+
+func (m *happyTransactionsMock) UpdateTransfer(id int64, request TransactionUpdateRequest) error {
+	return nil
+}
+
+func (m *happyTransactionsMock) DeleteTransfer(id int64) error {
+	return nil
+}
+
+func (m *happyTransactionsMock) FindTransactionByExternalId(accountId int64, externalId string) (int64, bool, error) {
+	return -1, false, nil
+}
+
+// splitTransactionsMock splits its transactions over two categories.
+type splitTransactionsMock struct {
+	happyTransactionsMock
+	updated *TransactionUpdateRequest
+}
+
+func (m *splitTransactionsMock) UpdateTransaction(id int64, request TransactionUpdateRequest) error {
+	m.updated = &request
+	return nil
+}
+
+func (m *splitTransactionsMock) GetTransaction(id int64) (Transaction, error) {
+	transaction, err := m.happyTransactionsMock.GetTransaction(id)
+	transaction.Splits = []TransactionSplit{{CategoryId: 1, Amount: -1550}, {CategoryId: 2, Amount: -500}}
+	return transaction, err
+}
+
+func (m *happyTransactionsMock) FindAccountCurrency(accountId int64) (string, bool, error) {
+	return "USD", true, nil
+}
+
+func (m *happyTransactionsMock) ImportTransactions(batch TransactionImport) ([]int64, error) {
+	ids := make([]int64, 0, len(batch.Inserts))
+	for _, request := range batch.Inserts {
+		id, _ := m.InsertTransaction(request)
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+type staticPayeeResolver struct {
+	payeeId int64
+}
+
+func (r *staticPayeeResolver) ResolvePayee(description string) (int64, bool, error) {
+	return r.payeeId, true, nil
+}
+
+type errorTransactionsMock struct {
+}
+
+func (m *errorTransactionsMock) GetTransaction(id int64) (Transaction, error) {
+	return Transaction{}, errors.New("db timeout")
+}
+
+func (m *errorTransactionsMock) ListTransactions(filter TransactionFilter) ([]Transaction, error) {
+	return nil, errors.New("db timeout")
+}
+
+func (m *errorTransactionsMock) InsertTransaction(request TransactionCreationRequest) (int64, error) {
+	return -1, errors.New("db timeout")
+}
+
+func (m *errorTransactionsMock) DeleteTransaction(id int64) error {
+	return errors.New("db timeout")
+}
+
+func (m *errorTransactionsMock) UpdateTransaction(id int64, request TransactionUpdateRequest) error {
+	return errors.New("db timeout")
+}
+
+func (m *errorTransactionsMock) InsertTransfer(request TransferRequest) (int64, int64, error) {
+	return -1, -1, errors.New("db timeout")
+}
+
+func (m *errorTransactionsMock) UpdateTransfer(id int64, request TransactionUpdateRequest) error {
+	return errors.New("db timeout")
+}
+
+func (m *errorTransactionsMock) DeleteTransfer(id int64) error {
+	return errors.New("db timeout")
+}
+
+func (m *errorTransactionsMock) FindTransactionByExternalId(accountId int64, externalId string) (int64, bool,
+	error) {
+	return -1, false, errors.New("db timeout")
+}
+
+func (m *errorTransactionsMock) FindAccountCurrency(accountId int64) (string, bool, error) {
+	return "", false, errors.New("db timeout")
+}
+
+func (m *errorTransactionsMock) ImportTransactions(batch TransactionImport) ([]int64, error) {
+	return nil, errors.New("db timeout")
+}
+
+func TestTransactionsService_GetTransaction(t *testing.T) {
+
+	t.Run("testing happy flow of getting a transaction", func(t *testing.T) {
+		transactionsService := NewTransactionsService(&happyTransactionsMock{}, nil, nil)
+		transaction, err := transactionsService.GetTransaction(3)
+		if err != nil {
+			t.Errorf("unexpected error when retrieving transaction")
+		}
+		if transaction.Id != 3 {
+			t.Errorf("unexpected transaction id %d", transaction.Id)
+		}
+	})
+
+	t.Run("testing general error flow of getting a transaction", func(t *testing.T) {
+		transactionsService := NewTransactionsService(&errorTransactionsMock{}, nil, nil)
+		_, err := transactionsService.GetTransaction(1)
+		expectedErrorMsg := "failed to retrieve transaction of id 1 with err:db timeout"
+		if err.Error() != expectedErrorMsg {
+			t.Errorf("expected error message %s but found %s", expectedErrorMsg, err.Error())
+		}
+	})
+}
+
+func TestTransactionsService_ListTransactions(t *testing.T) {
+
+	t.Run("testing happy flow of listing transactions", func(t *testing.T) {
+		transactionsService := NewTransactionsService(&happyTransactionsMock{}, nil, nil)
+		transactions, err := transactionsService.ListTransactions(TransactionFilter{})
+		if err != nil {
+			t.Errorf("unexpected error when listing transactions")
+		}
+		if len(transactions) != 2 {
+			t.Errorf("expected 2 transactions but found %d", len(transactions))
+		}
+	})
+
+	t.Run("testing general error flow of listing transactions", func(t *testing.T) {
+		transactionsService := NewTransactionsService(&errorTransactionsMock{}, nil, nil)
+		_, err := transactionsService.ListTransactions(TransactionFilter{})
+		expectedErrorMsg := "failed to list transactions with err:db timeout"
+		if err.Error() != expectedErrorMsg {
+			t.Errorf("expected error message %s but found %s", expectedErrorMsg, err.Error())
+		}
+	})
+}
+
+func TestTransactionsService_CreateTransaction(t *testing.T) {
+
+	transactionTime := time.Date(2022, 7, 1, 0, 0, 0, 0, time.UTC)
+	accountId := int64(1)
+	amount := Money(-2050)
+
+	t.Run("testing happy flow of creating a transaction", func(t *testing.T) {
+		dao := happyTransactionsMock{}
+		transactionsService := NewTransactionsService(&dao, nil, nil)
+		id, err := transactionsService.CreateTransaction(TransactionCreationRequest{TransactionTime: &transactionTime,
+			AccountId: &accountId, Amount: &amount})
+		if err != nil {
+			t.Errorf("unexpected error when creating transaction: %v", err)
+		}
+		if id != 1 {
+			t.Errorf("unexpected transaction id %d", id)
+		}
+		if *dao.inserted.TransactionType != NormalTransactionType {
+			t.Errorf("expected transaction type to default to %s but found %s", NormalTransactionType,
+				*dao.inserted.TransactionType)
+		}
+	})
+
+	t.Run("testing payee is resolved from the description", func(t *testing.T) {
+		dao := happyTransactionsMock{}
+		transactionsService := NewTransactionsService(&dao, &staticPayeeResolver{payeeId: 9}, nil)
+		description := "AMZN MKTP US*2K4"
+		_, err := transactionsService.CreateTransaction(TransactionCreationRequest{TransactionTime: &transactionTime,
+			AccountId: &accountId, Amount: &amount, Description: &description})
+		if err != nil {
+			t.Errorf("unexpected error when creating transaction: %v", err)
+		}
+		if dao.inserted.PayeeId == nil || *dao.inserted.PayeeId != 9 {
+			t.Errorf("expected payee to be resolved to 9 but found %v", dao.inserted.PayeeId)
+		}
+	})
+
+	t.Run("testing explicit payee is not overridden", func(t *testing.T) {
+		dao := happyTransactionsMock{}
+		transactionsService := NewTransactionsService(&dao, &staticPayeeResolver{payeeId: 9}, nil)
+		description := "AMZN MKTP US*2K4"
+		payeeId := int64(3)
+		_, _ = transactionsService.CreateTransaction(TransactionCreationRequest{TransactionTime: &transactionTime,
+			AccountId: &accountId, Amount: &amount, Description: &description, PayeeId: &payeeId})
+		if *dao.inserted.PayeeId != 3 {
+			t.Errorf("expected payee 3 but found %d", *dao.inserted.PayeeId)
+		}
+	})
+
+	t.Run("testing the currency is normalized and has to be the currency of the account", func(t *testing.T) {
+		dao := happyTransactionsMock{}
+		transactionsService := NewTransactionsService(&dao, nil, nil)
+		dollars := " usd"
+		_, err := transactionsService.CreateTransaction(TransactionCreationRequest{TransactionTime: &transactionTime,
+			AccountId: &accountId, Amount: &amount, Currency: &dollars})
+		if err != nil || *dao.inserted.Currency != "USD" {
+			t.Errorf("unexpected transaction inserted %+v or error %v", dao.inserted, err)
+		}
+		for _, currency := range []string{"EUR", "JPY", "dollars"} {
+			_, err = transactionsService.CreateTransaction(TransactionCreationRequest{
+				TransactionTime: &transactionTime, AccountId: &accountId, Amount: &amount, Currency: &currency})
+			if !errors.Is(err, ErrInvalidRequest) {
+				t.Errorf("expected invalid request error for %s but found %v", currency, err)
+			}
+		}
+	})
+
+	t.Run("testing validation of missing fields when creating a transaction", func(t *testing.T) {
+		transactionsService := NewTransactionsService(&happyTransactionsMock{}, nil, nil)
+		_, err := transactionsService.CreateTransaction(TransactionCreationRequest{TransactionTime: &transactionTime,
+			AccountId: &accountId})
+		if !errors.Is(err, ErrInvalidRequest) {
+			t.Errorf("expected invalid request error but found %v", err)
+		}
+	})
+
+	t.Run("testing validation of unknown transaction types", func(t *testing.T) {
+		transactionsService := NewTransactionsService(&happyTransactionsMock{}, nil, nil)
+		transactionType := "refund"
+		_, err := transactionsService.CreateTransaction(TransactionCreationRequest{TransactionTime: &transactionTime,
+			AccountId: &accountId, Amount: &amount, TransactionType: &transactionType})
+		expectedErrorMsg := "invalid request: unknown transaction type refund"
+		if err == nil || err.Error() != expectedErrorMsg {
+			t.Errorf("expected error message %s but found %v", expectedErrorMsg, err)
+		}
+	})
+
+	t.Run("testing validation of splits that do not add up to the amount", func(t *testing.T) {
+		transactionsService := NewTransactionsService(&happyTransactionsMock{}, nil, nil)
+		first, second := Money(-1050), Money(-500)
+		_, err := transactionsService.CreateTransaction(TransactionCreationRequest{TransactionTime: &transactionTime,
+			AccountId: &accountId, Amount: &amount, Splits: []TransactionSplitRequest{{Amount: &first},
+				{Amount: &second}}})
+		expectedErrorMsg := "invalid request: splits add up to -15.50 instead of the amount -20.50"
+		if err == nil || err.Error() != expectedErrorMsg {
+			t.Errorf("expected error message %s but found %v", expectedErrorMsg, err)
+		}
+	})
+
+	t.Run("testing general error flow of creating a transaction", func(t *testing.T) {
+		transactionsService := NewTransactionsService(&errorTransactionsMock{}, nil, nil)
+		_, err := transactionsService.CreateTransaction(TransactionCreationRequest{TransactionTime: &transactionTime,
+			AccountId: &accountId, Amount: &amount})
+		expectedErrorMsg := "db timeout"
+		if err.Error() != expectedErrorMsg {
+			t.Errorf("expected error message %s but found %s", expectedErrorMsg, err.Error())
+		}
+	})
+}
+
+func TestTransactionsService_UpdateTransaction(t *testing.T) {
+
+	transactionTime := time.Date(2022, 7, 1, 0, 0, 0, 0, time.UTC)
+	accountId := int64(1)
+	amount := Money(-2050)
+
+	t.Run("testing happy flow of updating a transaction", func(t *testing.T) {
+		transactionsService := NewTransactionsService(&happyTransactionsMock{}, nil, nil)
+		err := transactionsService.UpdateTransaction(1, TransactionUpdateRequest{TransactionTime: &transactionTime,
+			AccountId: &accountId, Amount: &amount})
+		if err != nil {
+			t.Errorf("unexpected error when updating transaction: %v", err)
+		}
+	})
+
+	t.Run("testing the amount of a split transaction cannot be changed", func(t *testing.T) {
+		transactionsService := NewTransactionsService(&splitTransactionsMock{}, nil, nil)
+		changedAmount := Money(-2500)
+		err := transactionsService.UpdateTransaction(1, TransactionUpdateRequest{TransactionTime: &transactionTime,
+			AccountId: &accountId, Amount: &changedAmount})
+		if !errors.Is(err, ErrInvalidRequest) {
+			t.Errorf("expected invalid request error but found %v", err)
+		}
+		err = transactionsService.UpdateTransaction(1, TransactionUpdateRequest{TransactionTime: &transactionTime,
+			AccountId: &accountId, Amount: &amount})
+		if err != nil {
+			t.Errorf("unexpected error when updating transaction: %v", err)
+		}
+	})
+
+	t.Run("testing a split transaction is split again with tags", func(t *testing.T) {
+		dao := splitTransactionsMock{}
+		changedAmount, first, second := Money(-2500), Money(-2000), Money(-500)
+		err := NewTransactionsService(&dao, nil, nil).UpdateTransaction(1, TransactionUpdateRequest{
+			TransactionTime: &transactionTime, AccountId: &accountId, Amount: &changedAmount,
+			Splits: []TransactionSplitRequest{{Amount: &first, Tags: []string{" trip", "food", "trip"}},
+				{Amount: &second}}})
+		if err != nil {
+			t.Errorf("unexpected error when updating transaction: %v", err)
+			return
+		}
+		splits := dao.updated.Splits
+		if len(splits) != 2 || !reflect.DeepEqual(splits[0].Tags, []string{"food", "trip"}) ||
+			splits[1].Tags == nil {
+			t.Errorf("unexpected splits %+v", splits)
+		}
+		err = NewTransactionsService(&dao, nil, nil).UpdateTransaction(1, TransactionUpdateRequest{
+			TransactionTime: &transactionTime, AccountId: &accountId, Amount: &amount,
+			Splits: []TransactionSplitRequest{{Amount: &first}, {Amount: &second}}})
+		if !errors.Is(err, ErrInvalidRequest) {
+			t.Errorf("expected invalid request error but found %v", err)
+		}
+	})
+
+	t.Run("testing general error flow of updating a transaction", func(t *testing.T) {
+		transactionsService := NewTransactionsService(&errorTransactionsMock{}, nil, nil)
+		err := transactionsService.UpdateTransaction(1, TransactionUpdateRequest{TransactionTime: &transactionTime,
+			AccountId: &accountId, Amount: &amount})
+		expectedErrorMsg := "db timeout"
+		if err.Error() != expectedErrorMsg {
+			t.Errorf("expected error message %s but found %s", expectedErrorMsg, err.Error())
+		}
+	})
+}
+
+func TestTransactionsService_DeleteTransaction(t *testing.T) {
+
+	t.Run("testing happy flow of deleting a transaction", func(t *testing.T) {
+		transactionsService := NewTransactionsService(&happyTransactionsMock{}, nil, nil)
+		err := transactionsService.DeleteTransaction(1)
+		if err != nil {
+			t.Errorf("unexpected error when deleting transaction")
+		}
+	})
+
+	t.Run("testing general error flow of deleting a transaction", func(t *testing.T) {
+		transactionsService := NewTransactionsService(&errorTransactionsMock{}, nil, nil)
+		err := transactionsService.DeleteTransaction(1)
+		expectedErrorMsg := "db timeout"
+		if err.Error() != expectedErrorMsg {
+			t.Errorf("expected error message %s but found %s", expectedErrorMsg, err.Error())
+		}
+	})
+}
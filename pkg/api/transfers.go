@@ -0,0 +1,91 @@
+package api
+
+import (
+	"fmt"
+	"time"
+)
+
+// TransferRequest moves Amount from one account to another. Amount has to be positive; the debit leg on the source
+// account is recorded as an expense and the credit leg on the destination account as income. Both accounts have to be
+// held in the same currency, as both legs carry the same amount.
+type TransferRequest struct {
+	FromAccountId   *int64     `json:"from_account_id"`
+	ToAccountId     *int64     `json:"to_account_id"`
+	Amount          *Money     `json:"amount"`
+	TransactionTime *time.Time `json:"transaction_time"`
+	Description     *string    `json:"description"`
+	Memo            *string    `json:"memo"`
+	Currency        *string    `json:"currency"`
+}
+
+// Transfer is the pair of linked transactions written for a transfer.
+type Transfer struct {
+	Debit  Transaction `json:"debit"`
+	Credit Transaction `json:"credit"`
+}
+
+// CreateTransfer writes both legs of the transfer and updates the balances of both accounts atomically. The ids of
+// the debit and credit transactions are returned in that order.
+func (service *TransactionsService) CreateTransfer(request TransferRequest) (int64, int64, error) {
+	if request.FromAccountId == nil || request.ToAccountId == nil {
+		return -1, -1, fmt.Errorf("%w: from_account_id and to_account_id are required", ErrInvalidRequest)
+	}
+	if *request.FromAccountId == *request.ToAccountId {
+		return -1, -1, fmt.Errorf("%w: cannot transfer from an account to itself", ErrInvalidRequest)
+	}
+	if request.Amount == nil || *request.Amount <= 0 {
+		return -1, -1, fmt.Errorf("%w: amount has to be positive", ErrInvalidRequest)
+	}
+	if request.TransactionTime == nil {
+		return -1, -1, fmt.Errorf("%w: transaction_time is required", ErrInvalidRequest)
+	}
+	err := service.validateTransferCurrencies(*request.FromAccountId, *request.ToAccountId)
+	if err != nil {
+		return -1, -1, err
+	}
+	if request.Currency, err = service.validateCurrency(*request.FromAccountId, request.Currency); err != nil {
+		return -1, -1, err
+	}
+	debitId, creditId, err := service.dao.InsertTransfer(request)
+	if err != nil {
+		return -1, -1, fmt.Errorf("failed to create transfer with err:%v", err)
+	}
+	return debitId, creditId, nil
+}
+
+// validateTransferCurrencies refuses transfers between accounts in different currencies, which would credit the
+// destination with the amount taken from the source in another unit.
+func (service *TransactionsService) validateTransferCurrencies(fromAccountId int64, toAccountId int64) error {
+	fromCurrency, err := service.findAccountCurrency(fromAccountId)
+	if err != nil {
+		return err
+	}
+	toCurrency, err := service.findAccountCurrency(toAccountId)
+	if err != nil {
+		return err
+	}
+	if fromCurrency != toCurrency {
+		return fmt.Errorf("%w: cannot transfer between account %d in %s and account %d in %s", ErrInvalidRequest,
+			fromAccountId, fromCurrency, toAccountId, toCurrency)
+	}
+	return nil
+}
+
+// GetTransfer returns the transfer that the transaction is a leg of.
+func (service *TransactionsService) GetTransfer(id int64) (Transfer, error) {
+	leg, err := service.GetTransaction(id)
+	if err != nil {
+		return Transfer{}, err
+	}
+	if leg.TransactionType != TransferTransactionType {
+		return Transfer{}, fmt.Errorf("%w: transaction %d is not a transfer", ErrInvalidRequest, id)
+	}
+	linkedLeg, err := service.GetTransaction(leg.LinkedTransactionId)
+	if err != nil {
+		return Transfer{}, err
+	}
+	if leg.Amount < 0 {
+		return Transfer{Debit: leg, Credit: linkedLeg}, nil
+	}
+	return Transfer{Debit: linkedLeg, Credit: leg}, nil
+}
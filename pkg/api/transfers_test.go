@@ -0,0 +1,200 @@
+package api
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// transfersMock stores a single transfer made of transactions 1 (credit) and 2 (debit) and records which dao methods
+// the service called. Account 3 is held in EUR and the others in USD.
+type transfersMock struct {
+	happyTransactionsMock
+	updatedTransfer    bool
+	deletedTransfer    bool
+	deletedTransaction bool
+}
+
+func (m *transfersMock) GetTransaction(id int64) (Transaction, error) {
+	transaction := Transaction{Id: id, TransactionTime: time.Now(), TransactionType: TransferTransactionType}
+	switch id {
+	case 1:
+		transaction.AccountId, transaction.Amount, transaction.LinkedTransactionId = 2, 50, 2
+	case 2:
+		transaction.AccountId, transaction.Amount, transaction.LinkedTransactionId = 1, -50, 1
+	default:
+		transaction.TransactionType = NormalTransactionType
+	}
+	return transaction, nil
+}
+
+func (m *transfersMock) FindAccountCurrency(accountId int64) (string, bool, error) {
+	if accountId == 3 {
+		return "EUR", true, nil
+	}
+	return m.happyTransactionsMock.FindAccountCurrency(accountId)
+}
+
+func (m *transfersMock) UpdateTransfer(id int64, request TransactionUpdateRequest) error {
+	m.updatedTransfer = true
+	return nil
+}
+
+func (m *transfersMock) DeleteTransfer(id int64) error {
+	m.deletedTransfer = true
+	return nil
+}
+
+func (m *transfersMock) DeleteTransaction(id int64) error {
+	m.deletedTransaction = true
+	return nil
+}
+
+func TestTransactionsService_CreateTransfer(t *testing.T) {
+
+	transactionTime := time.Date(2022, 7, 1, 0, 0, 0, 0, time.UTC)
+	fromAccountId := int64(1)
+	toAccountId := int64(2)
+	amount := Money(5000)
+
+	t.Run("testing happy flow of creating a transfer", func(t *testing.T) {
+		transactionsService := NewTransactionsService(&happyTransactionsMock{}, nil, nil)
+		debitId, creditId, err := transactionsService.CreateTransfer(TransferRequest{FromAccountId: &fromAccountId,
+			ToAccountId: &toAccountId, Amount: &amount, TransactionTime: &transactionTime})
+		if err != nil {
+			t.Errorf("unexpected error when creating transfer: %v", err)
+		}
+		if debitId != 1 || creditId != 2 {
+			t.Errorf("unexpected transaction ids %d and %d", debitId, creditId)
+		}
+	})
+
+	t.Run("testing validation of transfers to the same account", func(t *testing.T) {
+		transactionsService := NewTransactionsService(&happyTransactionsMock{}, nil, nil)
+		_, _, err := transactionsService.CreateTransfer(TransferRequest{FromAccountId: &fromAccountId,
+			ToAccountId: &fromAccountId, Amount: &amount, TransactionTime: &transactionTime})
+		expectedErrorMsg := "invalid request: cannot transfer from an account to itself"
+		if err == nil || err.Error() != expectedErrorMsg {
+			t.Errorf("expected error message %s but found %v", expectedErrorMsg, err)
+		}
+	})
+
+	t.Run("testing validation of non positive amounts", func(t *testing.T) {
+		transactionsService := NewTransactionsService(&happyTransactionsMock{}, nil, nil)
+		negativeAmount := Money(-5000)
+		_, _, err := transactionsService.CreateTransfer(TransferRequest{FromAccountId: &fromAccountId,
+			ToAccountId: &toAccountId, Amount: &negativeAmount, TransactionTime: &transactionTime})
+		if !errors.Is(err, ErrInvalidRequest) {
+			t.Errorf("expected invalid request error but found %v", err)
+		}
+	})
+
+	t.Run("testing general error flow of creating a transfer", func(t *testing.T) {
+		transactionsService := NewTransactionsService(&errorTransactionsMock{}, nil, nil)
+		_, _, err := transactionsService.CreateTransfer(TransferRequest{FromAccountId: &fromAccountId,
+			ToAccountId: &toAccountId, Amount: &amount, TransactionTime: &transactionTime})
+		expectedErrorMsg := "failed to find currency of account 1 with err:db timeout"
+		if err == nil || err.Error() != expectedErrorMsg {
+			t.Errorf("expected error message %s but found %v", expectedErrorMsg, err)
+		}
+	})
+
+	t.Run("testing transfers between accounts in different currencies are refused", func(t *testing.T) {
+		transactionsService := NewTransactionsService(&transfersMock{}, nil, nil)
+		euroAccountId := int64(3)
+		_, _, err := transactionsService.CreateTransfer(TransferRequest{FromAccountId: &fromAccountId,
+			ToAccountId: &euroAccountId, Amount: &amount, TransactionTime: &transactionTime})
+		expectedErrorMsg := "invalid request: cannot transfer between account 1 in USD and account 3 in EUR"
+		if err == nil || err.Error() != expectedErrorMsg {
+			t.Errorf("expected error message %s but found %v", expectedErrorMsg, err)
+		}
+	})
+
+	t.Run("testing transfers cannot be created as plain transactions", func(t *testing.T) {
+		transactionsService := NewTransactionsService(&happyTransactionsMock{}, nil, nil)
+		transactionType := TransferTransactionType
+		_, err := transactionsService.CreateTransaction(TransactionCreationRequest{TransactionTime: &transactionTime,
+			AccountId: &fromAccountId, Amount: &amount, TransactionType: &transactionType})
+		if !errors.Is(err, ErrInvalidRequest) {
+			t.Errorf("expected invalid request error but found %v", err)
+		}
+	})
+}
+
+func TestTransactionsService_GetTransfer(t *testing.T) {
+
+	t.Run("testing happy flow of getting a transfer from either leg", func(t *testing.T) {
+		transactionsService := NewTransactionsService(&transfersMock{}, nil, nil)
+		for _, id := range []int64{1, 2} {
+			transfer, err := transactionsService.GetTransfer(id)
+			if err != nil {
+				t.Errorf("unexpected error when retrieving transfer: %v", err)
+				return
+			}
+			if transfer.Debit.Id != 2 || transfer.Credit.Id != 1 {
+				t.Errorf("expected debit 2 and credit 1 but found %d and %d", transfer.Debit.Id, transfer.Credit.Id)
+			}
+		}
+	})
+
+	t.Run("testing retrieval of a transaction that is not a transfer", func(t *testing.T) {
+		transactionsService := NewTransactionsService(&transfersMock{}, nil, nil)
+		_, err := transactionsService.GetTransfer(3)
+		if !errors.Is(err, ErrInvalidRequest) {
+			t.Errorf("expected invalid request error but found %v", err)
+		}
+	})
+}
+
+func TestTransactionsService_TransferLegs(t *testing.T) {
+
+	transactionTime := time.Date(2022, 7, 1, 0, 0, 0, 0, time.UTC)
+	accountId := int64(1)
+	amount := Money(-7500)
+
+	t.Run("testing updates of a transfer leg update the whole transfer", func(t *testing.T) {
+		dao := transfersMock{}
+		transactionsService := NewTransactionsService(&dao, nil, nil)
+		err := transactionsService.UpdateTransaction(2, TransactionUpdateRequest{TransactionTime: &transactionTime,
+			AccountId: &accountId, Amount: &amount})
+		if err != nil {
+			t.Errorf("unexpected error when updating transfer leg: %v", err)
+		}
+		if !dao.updatedTransfer {
+			t.Errorf("expected the transfer to be updated")
+		}
+	})
+
+	t.Run("testing a transfer leg cannot be moved to an account in another currency", func(t *testing.T) {
+		dao := transfersMock{}
+		transactionsService := NewTransactionsService(&dao, nil, nil)
+		euroAccountId := int64(3)
+		err := transactionsService.UpdateTransaction(2, TransactionUpdateRequest{TransactionTime: &transactionTime,
+			AccountId: &euroAccountId, Amount: &amount})
+		expectedErrorMsg := "invalid request: cannot transfer between account 3 in EUR and account 2 in USD"
+		if err == nil || err.Error() != expectedErrorMsg || dao.updatedTransfer {
+			t.Errorf("expected error message %s but found %v", expectedErrorMsg, err)
+		}
+	})
+
+	t.Run("testing a transaction cannot be turned into a transfer", func(t *testing.T) {
+		transactionsService := NewTransactionsService(&transfersMock{}, nil, nil)
+		transactionType := TransferTransactionType
+		err := transactionsService.UpdateTransaction(3, TransactionUpdateRequest{TransactionTime: &transactionTime,
+			AccountId: &accountId, Amount: &amount, TransactionType: &transactionType})
+		if !errors.Is(err, ErrInvalidRequest) {
+			t.Errorf("expected invalid request error but found %v", err)
+		}
+	})
+
+	t.Run("testing deletion of a transfer leg deletes the whole transfer", func(t *testing.T) {
+		dao := transfersMock{}
+		transactionsService := NewTransactionsService(&dao, nil, nil)
+		if err := transactionsService.DeleteTransaction(1); err != nil {
+			t.Errorf("unexpected error when deleting transfer leg: %v", err)
+		}
+		if !dao.deletedTransfer || dao.deletedTransaction {
+			t.Errorf("expected the transfer to be deleted instead of a single transaction")
+		}
+	})
+}
@@ -0,0 +1,190 @@
+package api
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"golang.org/x/crypto/bcrypt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const minPasswordLength = 8
+
+// maxPasswordLength is the number of bytes bcrypt reads from a password, longer passwords would be cut short silently.
+const maxPasswordLength = 72
+
+// User is someone who can sign in to the api. The password hash is never sent back to clients.
+type User struct {
+	Id           int64     `json:"id"`
+	Username     string    `json:"username"`
+	PasswordHash string    `json:"-"`
+	IsDeleted    bool      `json:"is_deleted"`
+	CreatedTs    time.Time `json:"created_ts"`
+	UpdatedTs    time.Time `json:"updated_ts"`
+}
+
+type UserCreationRequest struct {
+	Username *string `json:"username"`
+	Password *string `json:"password"`
+}
+
+type PasswordUpdateRequest struct {
+	Password *string `json:"password"`
+}
+
+type LoginRequest struct {
+	Username *string `json:"username"`
+	Password *string `json:"password"`
+}
+
+// Session is a signed token that authenticates the requests of a user until it expires. The token holds the user id
+// and the expiry signed together with the password hash of the user, so changing the password ends every session.
+type Session struct {
+	Token     string    `json:"token"`
+	UserId    int64     `json:"user_id"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+type UsersService struct {
+	dao        UsersDataAccessor
+	secret     []byte
+	sessionTTL time.Duration
+}
+
+// NewUsersService signs session tokens with the secret, and they stay valid for the ttl after signing in.
+func NewUsersService(dao UsersDataAccessor, secret []byte, sessionTTL time.Duration) *UsersService {
+	return &UsersService{dao, secret, sessionTTL}
+}
+
+func (service *UsersService) ListUsers() ([]User, error) {
+	users, err := service.dao.ListUsers()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list users with err:%v", err)
+	}
+	return users, nil
+}
+
+func (service *UsersService) CreateUser(request UserCreationRequest) (int64, error) {
+	if isBlank(request.Username) {
+		return -1, fmt.Errorf("%w: username is required", ErrInvalidRequest)
+	}
+	username := strings.TrimSpace(*request.Username)
+	passwordHash, err := hashPassword(request.Password)
+	if err != nil {
+		return -1, err
+	}
+	_, found, err := service.dao.FindUserByUsername(username)
+	if err != nil {
+		return -1, fmt.Errorf("failed to find user %s with err:%v", username, err)
+	}
+	if found {
+		return -1, fmt.Errorf("%w: username %s is taken", ErrInvalidRequest, username)
+	}
+	return service.dao.InsertUser(username, passwordHash)
+}
+
+// UpdatePassword ends every session of the user as their tokens were signed with the previous password.
+func (service *UsersService) UpdatePassword(id int64, request PasswordUpdateRequest) error {
+	passwordHash, err := hashPassword(request.Password)
+	if err != nil {
+		return err
+	}
+	return service.dao.UpdateUserPassword(id, passwordHash)
+}
+
+// DeleteUser refuses to delete the last user, as no one could sign in to create another one over the api.
+func (service *UsersService) DeleteUser(id int64) error {
+	users, err := service.ListUsers()
+	if err != nil {
+		return err
+	}
+	if len(users) == 1 && users[0].Id == id {
+		return fmt.Errorf("%w: the last user cannot be deleted", ErrInvalidRequest)
+	}
+	return service.dao.DeleteUser(id)
+}
+
+// Login checks the password of the user and starts a session at now. Unknown usernames and wrong passwords are
+// reported alike.
+func (service *UsersService) Login(request LoginRequest, now time.Time) (Session, error) {
+	if isBlank(request.Username) || request.Password == nil {
+		return Session{}, fmt.Errorf("%w: username and password are required", ErrInvalidRequest)
+	}
+	username := strings.TrimSpace(*request.Username)
+	user, found, err := service.dao.FindUserByUsername(username)
+	if err != nil {
+		return Session{}, fmt.Errorf("failed to find user %s with err:%v", username, err)
+	}
+	if !found || bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(*request.Password)) != nil {
+		return Session{}, fmt.Errorf("%w: invalid username or password", ErrUnauthorized)
+	}
+	expiresAt := now.Add(service.sessionTTL).Truncate(time.Second)
+	payload := fmt.Sprintf("%d.%d", user.Id, expiresAt.Unix())
+	return Session{
+		Token:     payload + "." + service.sign(payload, user.PasswordHash),
+		UserId:    user.Id,
+		ExpiresAt: expiresAt.UTC(),
+	}, nil
+}
+
+// Authenticate returns the user of a session token that is still valid at now.
+func (service *UsersService) Authenticate(token string, now time.Time) (User, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return User{}, fmt.Errorf("%w: invalid session token", ErrUnauthorized)
+	}
+	id, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return User{}, fmt.Errorf("%w: invalid session token", ErrUnauthorized)
+	}
+	expires, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return User{}, fmt.Errorf("%w: invalid session token", ErrUnauthorized)
+	}
+	user, found, err := service.dao.FindUser(id)
+	if err != nil {
+		return User{}, fmt.Errorf("failed to retrieve user of id %d with err:%v", id, err)
+	}
+	signature := service.sign(parts[0]+"."+parts[1], user.PasswordHash)
+	if !found || !hmac.Equal([]byte(parts[2]), []byte(signature)) {
+		return User{}, fmt.Errorf("%w: invalid session token", ErrUnauthorized)
+	}
+	if !now.Before(time.Unix(expires, 0)) {
+		return User{}, fmt.Errorf("%w: session expired", ErrUnauthorized)
+	}
+	return user, nil
+}
+
+// sign returns the url safe HMAC-SHA256 of the payload and the password hash of its user under the secret.
+func (service *UsersService) sign(payload string, passwordHash string) string {
+	mac := hmac.New(sha256.New, service.secret)
+	mac.Write([]byte(payload + "." + passwordHash))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func hashPassword(password *string) (string, error) {
+	if password == nil || len(*password) < minPasswordLength {
+		return "", fmt.Errorf("%w: password must be at least %d characters long", ErrInvalidRequest,
+			minPasswordLength)
+	}
+	if len(*password) > maxPasswordLength {
+		return "", fmt.Errorf("%w: password must be at most %d bytes long", ErrInvalidRequest, maxPasswordLength)
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(*password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", fmt.Errorf("failed to hash password with err:%v", err)
+	}
+	return string(hash), nil
+}
+
+type UsersDataAccessor interface {
+	FindUser(id int64) (User, bool, error)
+	FindUserByUsername(username string) (User, bool, error)
+	ListUsers() ([]User, error)
+	InsertUser(username string, passwordHash string) (int64, error)
+	UpdateUserPassword(id int64, passwordHash string) error
+	DeleteUser(id int64) error
+}
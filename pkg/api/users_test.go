@@ -0,0 +1,211 @@
+package api
+
+import (
+	"errors"
+	"golang.org/x/crypto/bcrypt"
+	"strings"
+	"testing"
+	"time"
+)
+
+// usersMock holds the user alex with the password "correct horse".
+type usersMock struct {
+	passwordHash string
+	inserted     string
+	others       []User
+	deleted      int64
+	err          error
+}
+
+func newUsersMock(t *testing.T) *usersMock {
+	hash, err := bcrypt.GenerateFromPassword([]byte("correct horse"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatalf("failed to hash password: %v", err)
+	}
+	return &usersMock{passwordHash: string(hash)}
+}
+
+func (m *usersMock) FindUser(id int64) (User, bool, error) {
+	if id != 1 {
+		return User{}, false, m.err
+	}
+	return User{Id: 1, Username: "alex", PasswordHash: m.passwordHash}, true, m.err
+}
+
+func (m *usersMock) FindUserByUsername(username string) (User, bool, error) {
+	if username != "alex" {
+		return User{}, false, m.err
+	}
+	return m.FindUser(1)
+}
+
+func (m *usersMock) ListUsers() ([]User, error) {
+	user, _, err := m.FindUser(1)
+	return append([]User{user}, m.others...), err
+}
+
+func (m *usersMock) InsertUser(username string, passwordHash string) (int64, error) {
+	m.inserted = passwordHash
+	return 2, m.err
+}
+
+func (m *usersMock) UpdateUserPassword(id int64, passwordHash string) error {
+	m.passwordHash = passwordHash
+	return m.err
+}
+
+func (m *usersMock) DeleteUser(id int64) error {
+	m.deleted = id
+	return m.err
+}
+
+func TestUsersService_CreateUser(t *testing.T) {
+
+	t.Run("testing happy flow of creating a user", func(t *testing.T) {
+		dao := newUsersMock(t)
+		username, password := " sam ", "long enough"
+		id, err := NewUsersService(dao, []byte("secret"), time.Hour).CreateUser(UserCreationRequest{
+			Username: &username, Password: &password})
+		if err != nil || id != 2 {
+			t.Errorf("unexpected id %d or error %v", id, err)
+			return
+		}
+		if bcrypt.CompareHashAndPassword([]byte(dao.inserted), []byte(password)) != nil {
+			t.Errorf("expected the password to be stored as a bcrypt hash but found %s", dao.inserted)
+		}
+	})
+
+	t.Run("testing validation of users", func(t *testing.T) {
+		blank, taken, newcomer := " ", "alex", "sam"
+		short, long, valid := "short", strings.Repeat("x", 73), "long enough"
+		for _, request := range []UserCreationRequest{
+			{Password: &valid},
+			{Username: &blank, Password: &valid},
+			{Username: &newcomer},
+			{Username: &newcomer, Password: &short},
+			{Username: &newcomer, Password: &long},
+			{Username: &taken, Password: &valid},
+		} {
+			_, err := NewUsersService(newUsersMock(t), []byte("secret"), time.Hour).CreateUser(request)
+			if !errors.Is(err, ErrInvalidRequest) {
+				t.Errorf("expected invalid request error for %+v but found %v", request, err)
+			}
+		}
+	})
+}
+
+func TestUsersService_DeleteUser(t *testing.T) {
+
+	t.Run("testing happy flow of deleting a user", func(t *testing.T) {
+		dao := newUsersMock(t)
+		dao.others = []User{{Id: 2, Username: "sam"}}
+		err := NewUsersService(dao, []byte("secret"), time.Hour).DeleteUser(1)
+		if err != nil || dao.deleted != 1 {
+			t.Errorf("expected user 1 to be deleted but found %d or error %v", dao.deleted, err)
+		}
+	})
+
+	t.Run("testing the last user cannot be deleted", func(t *testing.T) {
+		dao := newUsersMock(t)
+		err := NewUsersService(dao, []byte("secret"), time.Hour).DeleteUser(1)
+		expectedErrorMsg := "invalid request: the last user cannot be deleted"
+		if err == nil || err.Error() != expectedErrorMsg || dao.deleted != 0 {
+			t.Errorf("expected error message %s but found %v", expectedErrorMsg, err)
+		}
+	})
+}
+
+func TestUsersService_Login(t *testing.T) {
+
+	now := time.Date(2022, 7, 1, 12, 0, 0, 0, time.UTC)
+	username := "alex"
+
+	t.Run("testing happy flow of signing in", func(t *testing.T) {
+		service := NewUsersService(newUsersMock(t), []byte("secret"), time.Hour)
+		password := "correct horse"
+		session, err := service.Login(LoginRequest{Username: &username, Password: &password}, now)
+		if err != nil || session.UserId != 1 || !session.ExpiresAt.Equal(now.Add(time.Hour)) ||
+			!strings.HasPrefix(session.Token, "1.") {
+			t.Errorf("unexpected session %+v or error %v", session, err)
+			return
+		}
+		user, err := service.Authenticate(session.Token, now.Add(time.Minute))
+		if err != nil || user.Username != "alex" {
+			t.Errorf("unexpected user %+v or error %v", user, err)
+		}
+	})
+
+	t.Run("testing wrong passwords and unknown users are rejected", func(t *testing.T) {
+		service := NewUsersService(newUsersMock(t), []byte("secret"), time.Hour)
+		stranger, password, wrong := "sam", "correct horse", "wrong horse"
+		for _, request := range []LoginRequest{
+			{Username: &username, Password: &wrong},
+			{Username: &stranger, Password: &password},
+		} {
+			_, err := service.Login(request, now)
+			expectedErrorMsg := "unauthorized: invalid username or password"
+			if !errors.Is(err, ErrUnauthorized) || err.Error() != expectedErrorMsg {
+				t.Errorf("expected error message %s but found %v", expectedErrorMsg, err)
+			}
+		}
+	})
+
+	t.Run("testing general error flow of signing in", func(t *testing.T) {
+		dao := newUsersMock(t)
+		dao.err = errors.New("db timeout")
+		password := "correct horse"
+		_, err := NewUsersService(dao, []byte("secret"), time.Hour).Login(LoginRequest{Username: &username,
+			Password: &password}, now)
+		expectedErrorMsg := "failed to find user alex with err:db timeout"
+		if err == nil || err.Error() != expectedErrorMsg {
+			t.Errorf("expected error message %s but found %v", expectedErrorMsg, err)
+		}
+	})
+}
+
+func TestUsersService_Authenticate(t *testing.T) {
+
+	now := time.Date(2022, 7, 1, 12, 0, 0, 0, time.UTC)
+	username, password := "alex", "correct horse"
+
+	t.Run("testing invalid and expired tokens are rejected", func(t *testing.T) {
+		service := NewUsersService(newUsersMock(t), []byte("secret"), time.Hour)
+		session, err := service.Login(LoginRequest{Username: &username, Password: &password}, now)
+		if err != nil {
+			t.Errorf("unexpected error when signing in: %v", err)
+			return
+		}
+		forged := NewUsersService(newUsersMock(t), []byte("other secret"), time.Hour)
+		forgedSession, _ := forged.Login(LoginRequest{Username: &username, Password: &password}, now)
+		// a blank token, a malformed expiry, another user, a changed signature, another secret and a later expiry
+		for _, token := range []string{"", "1.abc.signature", "2" + session.Token[1:], session.Token + "x",
+			forgedSession.Token, strings.Replace(session.Token, "1656", "1756", 1)} {
+			_, err := service.Authenticate(token, now)
+			expectedErrorMsg := "unauthorized: invalid session token"
+			if !errors.Is(err, ErrUnauthorized) || err.Error() != expectedErrorMsg {
+				t.Errorf("expected error message %s for token %s but found %v", expectedErrorMsg, token, err)
+			}
+		}
+		_, err = service.Authenticate(session.Token, now.Add(time.Hour))
+		if err == nil || err.Error() != "unauthorized: session expired" {
+			t.Errorf("expected the session to expire but found %v", err)
+		}
+	})
+
+	t.Run("testing changing the password ends the sessions of the user", func(t *testing.T) {
+		service := NewUsersService(newUsersMock(t), []byte("secret"), time.Hour)
+		session, err := service.Login(LoginRequest{Username: &username, Password: &password}, now)
+		if err != nil {
+			t.Errorf("unexpected error when signing in: %v", err)
+			return
+		}
+		newPassword := "battery staple"
+		if err = service.UpdatePassword(1, PasswordUpdateRequest{Password: &newPassword}); err != nil {
+			t.Errorf("unexpected error when updating password: %v", err)
+			return
+		}
+		if _, err = service.Authenticate(session.Token, now); !errors.Is(err, ErrUnauthorized) {
+			t.Errorf("expected unauthorized error but found %v", err)
+		}
+	})
+}
@@ -0,0 +1,75 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"go-personal-finance/pkg/api"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// GetAccountBalance derives the balance of an account from the ledger. The optional as_of query parameter is either
+// an RFC 3339 timestamp or a date, in which case the balance at the end of that day is returned. The optional
+// currency query parameter converts the balance into another currency.
+func (s *Server) GetAccountBalance() gin.HandlerFunc {
+	return func(context *gin.Context) {
+
+		id, err := strconv.ParseInt(context.Param("id"), 10, 64)
+		if err != nil {
+			context.JSON(http.StatusBadRequest, gin.H{"status": "failure"})
+			return
+		}
+		var asOf *time.Time
+		if value := context.Query("as_of"); value != "" {
+			if date, err := time.Parse("2006-01-02", value); err == nil {
+				endOfDay := date.AddDate(0, 0, 1)
+				asOf = &endOfDay
+			} else if timestamp, err := time.Parse(time.RFC3339, value); err == nil {
+				asOf = &timestamp
+			} else {
+				respondWithError(context, fmt.Errorf("%w: as_of must be a date (2006-01-02) or an RFC 3339 timestamp",
+					api.ErrInvalidRequest))
+				return
+			}
+		}
+		balance, err := s.accountsService.GetAccountBalance(id, asOf, context.Query("currency"))
+		if errors.Is(err, api.ErrInvalidRequest) {
+			respondWithError(context, err)
+			return
+		}
+		if err != nil {
+			context.JSON(http.StatusNotFound, gin.H{"status": "failure"})
+			return
+		}
+		context.JSON(http.StatusOK, gin.H{
+			"status": "success",
+			"data":   balance,
+		})
+	}
+}
+
+// ReconcileAccounts lists the accounts whose stored balance drifted from the ledger, or every account when all=true.
+func (s *Server) ReconcileAccounts() gin.HandlerFunc {
+	return func(context *gin.Context) {
+
+		includeAll := false
+		if all, ok := context.GetQuery("all"); ok {
+			var err error
+			if includeAll, err = strconv.ParseBool(all); err != nil {
+				respondWithError(context, fmt.Errorf("%w: all must be a boolean", api.ErrInvalidRequest))
+				return
+			}
+		}
+		reconciliations, err := s.accountsService.ReconcileAccounts(includeAll)
+		if err != nil {
+			respondWithError(context, err)
+			return
+		}
+		context.JSON(http.StatusOK, gin.H{
+			"status": "success",
+			"data":   reconciliations,
+		})
+	}
+}
@@ -0,0 +1,121 @@
+package app
+
+import (
+	"github.com/gin-gonic/gin"
+	"go-personal-finance/pkg/api"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+func (s *Server) ListBudgets() gin.HandlerFunc {
+	return func(context *gin.Context) {
+
+		budgets, err := s.budgetsService.ListBudgets()
+		if err != nil {
+			respondWithError(context, err)
+			return
+		}
+		context.JSON(http.StatusOK, gin.H{
+			"status": "success",
+			"data":   budgets,
+		})
+	}
+}
+
+func (s *Server) GetBudget() gin.HandlerFunc {
+	return func(context *gin.Context) {
+
+		id, err := strconv.ParseInt(context.Param("id"), 10, 64)
+		if err != nil {
+			context.JSON(http.StatusBadRequest, gin.H{"status": "failure"})
+			return
+		}
+		budget, err := s.budgetsService.GetBudget(id)
+		if err != nil {
+			context.JSON(http.StatusNotFound, gin.H{"status": "failure"})
+			return
+		}
+		context.JSON(http.StatusOK, gin.H{
+			"status": "success",
+			"data":   budget,
+		})
+	}
+}
+
+func (s *Server) AddBudget() gin.HandlerFunc {
+	return func(context *gin.Context) {
+
+		budgetRequest := api.BudgetRequest{}
+		if err := context.ShouldBindJSON(&budgetRequest); err != nil {
+			context.JSON(http.StatusBadRequest, gin.H{"status": "failure"})
+			return
+		}
+		id, err := s.budgetsService.CreateBudget(budgetRequest)
+		if err != nil {
+			respondWithError(context, err)
+			return
+		}
+		context.JSON(http.StatusOK, gin.H{
+			"status": "success",
+			"data":   gin.H{"id": id},
+		})
+	}
+}
+
+func (s *Server) UpdateBudget() gin.HandlerFunc {
+	return func(context *gin.Context) {
+
+		id, err := strconv.ParseInt(context.Param("id"), 10, 64)
+		if err != nil {
+			context.JSON(http.StatusBadRequest, gin.H{"status": "failure"})
+			return
+		}
+		budgetRequest := api.BudgetRequest{}
+		if err = context.ShouldBindJSON(&budgetRequest); err != nil {
+			context.JSON(http.StatusBadRequest, gin.H{"status": "failure"})
+			return
+		}
+		err = s.budgetsService.UpdateBudget(id, budgetRequest)
+		if err != nil {
+			respondWithError(context, err)
+			return
+		}
+		context.JSON(http.StatusOK, gin.H{"status": "success"})
+	}
+}
+
+func (s *Server) DeleteBudget() gin.HandlerFunc {
+	return func(context *gin.Context) {
+
+		id, err := strconv.ParseInt(context.Param("id"), 10, 64)
+		if err != nil {
+			context.JSON(http.StatusBadRequest, gin.H{"status": "failure"})
+			return
+		}
+		err = s.budgetsService.DeleteBudget(id)
+		if err != nil {
+			respondWithError(context, err)
+			return
+		}
+		context.JSON(http.StatusOK, gin.H{"status": "success"})
+	}
+}
+
+// GetMonthlyBudget returns the progress of the budgets in the month query parameter, formatted like 2022-07, which
+// defaults to the current month. Spending is converted into the currency query parameter when given.
+func (s *Server) GetMonthlyBudget() gin.HandlerFunc {
+	return func(context *gin.Context) {
+
+		month := context.DefaultQuery("month", time.Now().UTC().Format(api.BudgetMonthLayout))
+		report, err := s.budgetsService.GetMonthlyBudget(month, context.Query("currency"))
+		if err != nil {
+			respondWithError(context, err)
+			return
+		}
+		context.JSON(http.StatusOK, gin.H{
+			"status": "success",
+			"data":   report,
+		})
+	}
+}
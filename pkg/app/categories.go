@@ -0,0 +1,171 @@
+package app
+
+import (
+	"github.com/gin-gonic/gin"
+	"go-personal-finance/pkg/api"
+	"net/http"
+	"strconv"
+)
+
+func (s *Server) ListCategories() gin.HandlerFunc {
+	return func(context *gin.Context) {
+
+		categories, err := s.categoriesService.ListCategories()
+		if err != nil {
+			respondWithError(context, err)
+			return
+		}
+		context.JSON(http.StatusOK, gin.H{
+			"status": "success",
+			"data":   categories,
+		})
+	}
+}
+
+func (s *Server) GetCategoryTree() gin.HandlerFunc {
+	return func(context *gin.Context) {
+
+		tree, err := s.categoriesService.GetCategoryTree()
+		if err != nil {
+			respondWithError(context, err)
+			return
+		}
+		context.JSON(http.StatusOK, gin.H{
+			"status": "success",
+			"data":   tree,
+		})
+	}
+}
+
+func (s *Server) GetCategory() gin.HandlerFunc {
+	return func(context *gin.Context) {
+
+		id, err := strconv.ParseInt(context.Param("id"), 10, 64)
+		if err != nil {
+			context.JSON(http.StatusBadRequest, gin.H{"status": "failure"})
+			return
+		}
+		category, err := s.categoriesService.GetCategory(id)
+		if err != nil {
+			context.JSON(http.StatusNotFound, gin.H{"status": "failure"})
+			return
+		}
+		context.JSON(http.StatusOK, gin.H{
+			"status": "success",
+			"data":   category,
+		})
+	}
+}
+
+func (s *Server) AddCategory() gin.HandlerFunc {
+	return func(context *gin.Context) {
+
+		categoryCreationRequest := api.CategoryCreationRequest{}
+		if err := context.ShouldBindJSON(&categoryCreationRequest); err != nil {
+			context.JSON(http.StatusBadRequest, gin.H{"status": "failure"})
+			return
+		}
+		id, err := s.categoriesService.CreateCategory(categoryCreationRequest)
+		if err != nil {
+			respondWithError(context, err)
+			return
+		}
+		context.JSON(http.StatusOK, gin.H{
+			"status": "success",
+			"data":   gin.H{"id": id},
+		})
+	}
+}
+
+func (s *Server) UpdateCategory() gin.HandlerFunc {
+	return func(context *gin.Context) {
+
+		id, err := strconv.ParseInt(context.Param("id"), 10, 64)
+		if err != nil {
+			context.JSON(http.StatusBadRequest, gin.H{"status": "failure"})
+			return
+		}
+		categoryUpdateRequest := api.CategoryUpdateRequest{}
+		if err = context.ShouldBindJSON(&categoryUpdateRequest); err != nil {
+			context.JSON(http.StatusBadRequest, gin.H{"status": "failure"})
+			return
+		}
+		err = s.categoriesService.UpdateCategory(id, categoryUpdateRequest)
+		if err != nil {
+			respondWithError(context, err)
+			return
+		}
+		context.JSON(http.StatusOK, gin.H{"status": "success"})
+	}
+}
+
+func (s *Server) MoveCategory() gin.HandlerFunc {
+	return func(context *gin.Context) {
+
+		id, err := strconv.ParseInt(context.Param("id"), 10, 64)
+		if err != nil {
+			context.JSON(http.StatusBadRequest, gin.H{"status": "failure"})
+			return
+		}
+		categoryMoveRequest := api.CategoryMoveRequest{}
+		if err = context.ShouldBindJSON(&categoryMoveRequest); err != nil {
+			context.JSON(http.StatusBadRequest, gin.H{"status": "failure"})
+			return
+		}
+		err = s.categoriesService.MoveCategory(id, categoryMoveRequest)
+		if err != nil {
+			respondWithError(context, err)
+			return
+		}
+		context.JSON(http.StatusOK, gin.H{"status": "success"})
+	}
+}
+
+func (s *Server) DeleteCategory() gin.HandlerFunc {
+	return func(context *gin.Context) {
+
+		id, err := strconv.ParseInt(context.Param("id"), 10, 64)
+		if err != nil {
+			context.JSON(http.StatusBadRequest, gin.H{"status": "failure"})
+			return
+		}
+		err = s.categoriesService.DeleteCategory(id)
+		if err != nil {
+			respondWithError(context, err)
+			return
+		}
+		context.JSON(http.StatusOK, gin.H{"status": "success"})
+	}
+}
+
+// GetCategoryRollup returns the totals of a category and its descendants, optionally limited by the from and to
+// query parameters and converted into the currency query parameter.
+func (s *Server) GetCategoryRollup() gin.HandlerFunc {
+	return func(context *gin.Context) {
+
+		id, err := strconv.ParseInt(context.Param("id"), 10, 64)
+		if err != nil {
+			context.JSON(http.StatusBadRequest, gin.H{"status": "failure"})
+			return
+		}
+		from, err := parseTimeQuery(context, "from")
+		if err != nil {
+			respondWithError(context, err)
+			return
+		}
+		to, err := parseTimeQuery(context, "to")
+		if err != nil {
+			respondWithError(context, err)
+			return
+		}
+		rollup, err := s.categoriesService.GetCategoryRollup(id, from, to, context.Query("currency"))
+		if err != nil {
+			respondWithError(context, err)
+			return
+		}
+		context.JSON(http.StatusOK, gin.H{
+			"status": "success",
+			"data":   rollup,
+		})
+	}
+}
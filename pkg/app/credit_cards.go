@@ -0,0 +1,139 @@
+package app
+
+import (
+	"github.com/gin-gonic/gin"
+	"go-personal-finance/pkg/api"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+func (s *Server) ListCreditCards() gin.HandlerFunc {
+	return func(context *gin.Context) {
+
+		creditCards, err := s.creditCardsService.ListCreditCards()
+		if err != nil {
+			respondWithError(context, err)
+			return
+		}
+		context.JSON(http.StatusOK, gin.H{
+			"status": "success",
+			"data":   creditCards,
+		})
+	}
+}
+
+func (s *Server) GetCreditCard() gin.HandlerFunc {
+	return func(context *gin.Context) {
+
+		id, err := strconv.ParseInt(context.Param("id"), 10, 64)
+		if err != nil {
+			context.JSON(http.StatusBadRequest, gin.H{"status": "failure"})
+			return
+		}
+		creditCard, err := s.creditCardsService.GetCreditCard(id)
+		if err != nil {
+			context.JSON(http.StatusNotFound, gin.H{"status": "failure"})
+			return
+		}
+		context.JSON(http.StatusOK, gin.H{
+			"status": "success",
+			"data":   creditCard,
+		})
+	}
+}
+
+func (s *Server) AddCreditCard() gin.HandlerFunc {
+	return func(context *gin.Context) {
+
+		creditCardRequest := api.CreditCardRequest{}
+		if err := context.ShouldBindJSON(&creditCardRequest); err != nil {
+			context.JSON(http.StatusBadRequest, gin.H{"status": "failure"})
+			return
+		}
+		id, err := s.creditCardsService.CreateCreditCard(creditCardRequest)
+		if err != nil {
+			respondWithError(context, err)
+			return
+		}
+		context.JSON(http.StatusOK, gin.H{
+			"status": "success",
+			"data":   gin.H{"id": id},
+		})
+	}
+}
+
+func (s *Server) UpdateCreditCard() gin.HandlerFunc {
+	return func(context *gin.Context) {
+
+		id, err := strconv.ParseInt(context.Param("id"), 10, 64)
+		if err != nil {
+			context.JSON(http.StatusBadRequest, gin.H{"status": "failure"})
+			return
+		}
+		creditCardRequest := api.CreditCardRequest{}
+		if err = context.ShouldBindJSON(&creditCardRequest); err != nil {
+			context.JSON(http.StatusBadRequest, gin.H{"status": "failure"})
+			return
+		}
+		err = s.creditCardsService.UpdateCreditCard(id, creditCardRequest)
+		if err != nil {
+			respondWithError(context, err)
+			return
+		}
+		context.JSON(http.StatusOK, gin.H{"status": "success"})
+	}
+}
+
+func (s *Server) DeleteCreditCard() gin.HandlerFunc {
+	return func(context *gin.Context) {
+
+		id, err := strconv.ParseInt(context.Param("id"), 10, 64)
+		if err != nil {
+			context.JSON(http.StatusBadRequest, gin.H{"status": "failure"})
+			return
+		}
+		err = s.creditCardsService.DeleteCreditCard(id)
+		if err != nil {
+			respondWithError(context, err)
+			return
+		}
+		context.JSON(http.StatusOK, gin.H{"status": "success"})
+	}
+}
+
+// GetCreditCardStatements groups the transactions of the credit card account into statement cycles until the as_of
+// query parameter, which defaults to today. The from query parameter picks the first cycle.
+func (s *Server) GetCreditCardStatements() gin.HandlerFunc {
+	return func(context *gin.Context) {
+
+		id, err := strconv.ParseInt(context.Param("id"), 10, 64)
+		if err != nil {
+			context.JSON(http.StatusBadRequest, gin.H{"status": "failure"})
+			return
+		}
+		from, err := parseTimeQuery(context, "from")
+		if err != nil {
+			respondWithError(context, err)
+			return
+		}
+		asOf, err := parseTimeQuery(context, "as_of")
+		if err != nil {
+			respondWithError(context, err)
+			return
+		}
+		if asOf == nil {
+			now := time.Now().UTC()
+			asOf = &now
+		}
+		statements, err := s.creditCardsService.GetStatements(id, from, *asOf)
+		if err != nil {
+			respondWithError(context, err)
+			return
+		}
+		context.JSON(http.StatusOK, gin.H{
+			"status": "success",
+			"data":   statements,
+		})
+	}
+}
@@ -0,0 +1,65 @@
+package app
+
+import (
+	"github.com/gin-gonic/gin"
+	"go-personal-finance/pkg/api"
+	"net/http"
+)
+
+// ListExchangeRates lists the stored exchange rates, optionally limited to the base and quote query parameters.
+func (s *Server) ListExchangeRates() gin.HandlerFunc {
+	return func(context *gin.Context) {
+
+		rates, err := s.exchangeRatesService.ListExchangeRates(api.ExchangeRateFilter{
+			BaseCurrency:  context.Query("base"),
+			QuoteCurrency: context.Query("quote"),
+		})
+		if err != nil {
+			respondWithError(context, err)
+			return
+		}
+		context.JSON(http.StatusOK, gin.H{
+			"status": "success",
+			"data":   rates,
+		})
+	}
+}
+
+// SaveExchangeRate stores a rate, replacing the rate of the pair on the same date.
+func (s *Server) SaveExchangeRate() gin.HandlerFunc {
+	return func(context *gin.Context) {
+
+		rateRequest := api.ExchangeRateRequest{}
+		if err := context.ShouldBindJSON(&rateRequest); err != nil {
+			context.JSON(http.StatusBadRequest, gin.H{"status": "failure"})
+			return
+		}
+		if err := s.exchangeRatesService.SaveExchangeRate(rateRequest); err != nil {
+			respondWithError(context, err)
+			return
+		}
+		context.JSON(http.StatusOK, gin.H{"status": "success"})
+	}
+}
+
+// ImportExchangeRates stores every rate of an uploaded csv file, sent as the multipart file field.
+func (s *Server) ImportExchangeRates() gin.HandlerFunc {
+	return func(context *gin.Context) {
+
+		file, err := openUploadedFile(context)
+		if err != nil {
+			respondWithError(context, err)
+			return
+		}
+		defer file.Close()
+		imported, err := s.exchangeRatesService.ImportExchangeRates(file)
+		if err != nil {
+			respondWithError(context, err)
+			return
+		}
+		context.JSON(http.StatusOK, gin.H{
+			"status": "success",
+			"data":   gin.H{"imported": imported},
+		})
+	}
+}
@@ -0,0 +1,43 @@
+package app
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"go-personal-finance/pkg/api"
+	"net/http"
+)
+
+// ExportQIF downloads the transactions of the account_id query parameter as a qif file, optionally limited to the
+// [from, to) range.
+func (s *Server) ExportQIF() gin.HandlerFunc {
+	return func(context *gin.Context) {
+
+		accountId, err := parseInt64Query(context, "account_id")
+		if err == nil && accountId == nil {
+			err = fmt.Errorf("%w: account_id is required", api.ErrInvalidRequest)
+		}
+		if err != nil {
+			respondWithError(context, err)
+			return
+		}
+		from, err := parseTimeQuery(context, "from")
+		if err != nil {
+			respondWithError(context, err)
+			return
+		}
+		to, err := parseTimeQuery(context, "to")
+		if err != nil {
+			respondWithError(context, err)
+			return
+		}
+		// the file is built before responding so that a failure can still be reported as json
+		var file bytes.Buffer
+		if err = s.exportService.ExportQIF(*accountId, from, to, &file); err != nil {
+			respondWithError(context, err)
+			return
+		}
+		context.Header("Content-Disposition", fmt.Sprintf("attachment; filename=\"account-%d.qif\"", *accountId))
+		context.Data(http.StatusOK, "application/qif", file.Bytes())
+	}
+}
@@ -1,10 +1,14 @@
 package app
 
 import (
+	"errors"
+	"fmt"
 	gin "github.com/gin-gonic/gin"
 	"go-personal-finance/pkg/api"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 )
 
 func (s *Server) ApiStatus() gin.HandlerFunc {
@@ -55,6 +59,61 @@ func (s *Server) GetAccount() gin.HandlerFunc {
 		context.JSON(http.StatusOK, response)
 	}
 }
+
+// ListAccounts supports the type, subtype, org_id and include_deleted filters, sorting through sort
+// (name, balance or created_ts) and order (asc or desc), and offset pagination through limit and offset.
+func (s *Server) ListAccounts() gin.HandlerFunc {
+	return func(context *gin.Context) {
+
+		filter := api.AccountFilter{
+			SortBy:     context.Query("sort"),
+			Descending: strings.EqualFold(context.Query("order"), "desc"),
+		}
+		if accountType, ok := context.GetQuery("type"); ok {
+			filter.AccountType = &accountType
+		}
+		if accountSubType, ok := context.GetQuery("subtype"); ok {
+			filter.AccountSubType = &accountSubType
+		}
+		var err error
+		if filter.OrgId, err = parseInt64Query(context, "org_id"); err != nil {
+			respondWithError(context, err)
+			return
+		}
+		if includeDeleted, ok := context.GetQuery("include_deleted"); ok {
+			if filter.IncludeDeleted, err = strconv.ParseBool(includeDeleted); err != nil {
+				respondWithError(context, fmt.Errorf("%w: include_deleted must be a boolean", api.ErrInvalidRequest))
+				return
+			}
+		}
+		limit, err := parseInt64Query(context, "limit")
+		if err != nil {
+			respondWithError(context, err)
+			return
+		}
+		if limit != nil {
+			filter.Limit = int(*limit)
+		}
+		offset, err := parseInt64Query(context, "offset")
+		if err != nil {
+			respondWithError(context, err)
+			return
+		}
+		if offset != nil {
+			filter.Offset = int(*offset)
+		}
+		page, err := s.accountsService.ListAccounts(filter)
+		if err != nil {
+			respondWithError(context, err)
+			return
+		}
+		context.JSON(http.StatusOK, gin.H{
+			"status": "success",
+			"data":   page,
+		})
+	}
+}
+
 func (s *Server) AddAccount() gin.HandlerFunc {
 	return func(context *gin.Context) {
 
@@ -67,13 +126,14 @@ func (s *Server) AddAccount() gin.HandlerFunc {
 			context.JSON(http.StatusInternalServerError, response)
 			return
 		}
-		err = s.accountsService.CreateAccount(accountCreationRequest)
+		id, err := s.accountsService.CreateAccount(accountCreationRequest)
 		if err != nil {
 			context.JSON(http.StatusInternalServerError, response)
 			return
 		}
 		response = gin.H{
 			"status": "success",
+			"data":   gin.H{"id": id},
 		}
 		context.JSON(http.StatusOK, response)
 	}
@@ -99,3 +159,60 @@ func (s *Server) UpdateAccount() gin.HandlerFunc {
 		context.JSON(http.StatusOK, response)
 	}
 }
+
+// respondWithError maps validation errors to a bad request and hides everything else behind a generic failure.
+func respondWithError(context *gin.Context, err error) {
+	if errors.Is(err, api.ErrInvalidRequest) {
+		context.JSON(http.StatusBadRequest, gin.H{
+			"status": "failure",
+			"error":  err.Error(),
+		})
+		return
+	}
+	if errors.Is(err, api.ErrUnauthorized) {
+		context.JSON(http.StatusUnauthorized, gin.H{
+			"status": "failure",
+			"error":  err.Error(),
+		})
+		return
+	}
+	if errors.Is(err, api.ErrForbidden) {
+		context.JSON(http.StatusForbidden, gin.H{
+			"status": "failure",
+			"error":  err.Error(),
+		})
+		return
+	}
+	context.JSON(http.StatusInternalServerError, gin.H{
+		"status": "failure",
+	})
+}
+
+// parseInt64Query returns nil when the query parameter is absent.
+func parseInt64Query(context *gin.Context, key string) (*int64, error) {
+	value, ok := context.GetQuery(key)
+	if !ok || value == "" {
+		return nil, nil
+	}
+	parsed, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s must be an integer", api.ErrInvalidRequest, key)
+	}
+	return &parsed, nil
+}
+
+// parseTimeQuery accepts either a plain date or an RFC 3339 timestamp and returns nil when the
+// query parameter is absent.
+func parseTimeQuery(context *gin.Context, key string) (*time.Time, error) {
+	value, ok := context.GetQuery(key)
+	if !ok || value == "" {
+		return nil, nil
+	}
+	for _, layout := range []string{"2006-01-02", time.RFC3339} {
+		parsed, err := time.Parse(layout, value)
+		if err == nil {
+			return &parsed, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %s must be a date (2006-01-02) or an RFC 3339 timestamp", api.ErrInvalidRequest, key)
+}
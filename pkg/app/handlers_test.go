@@ -1 +1,199 @@
 package app
+
+import (
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"go-personal-finance/pkg/api"
+	"golang.org/x/crypto/bcrypt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// usersMock holds the user alex with the password "correct horse", and records the user deleted.
+type usersMock struct {
+	passwordHash string
+	deleted      int64
+}
+
+func newUsersMock(t *testing.T) *usersMock {
+	hash, err := bcrypt.GenerateFromPassword([]byte("correct horse"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatalf("failed to hash password: %v", err)
+	}
+	return &usersMock{passwordHash: string(hash)}
+}
+
+func (m *usersMock) FindUser(id int64) (api.User, bool, error) {
+	if id != 1 {
+		return api.User{}, false, nil
+	}
+	return api.User{Id: 1, Username: "alex", PasswordHash: m.passwordHash}, true, nil
+}
+
+func (m *usersMock) FindUserByUsername(username string) (api.User, bool, error) {
+	if username != "alex" {
+		return api.User{}, false, nil
+	}
+	return m.FindUser(1)
+}
+
+func (m *usersMock) ListUsers() ([]api.User, error) {
+	user, _, err := m.FindUser(1)
+	return []api.User{user}, err
+}
+
+func (m *usersMock) InsertUser(username string, passwordHash string) (int64, error) {
+	return 2, nil
+}
+
+func (m *usersMock) UpdateUserPassword(id int64, passwordHash string) error {
+	m.passwordHash = passwordHash
+	return nil
+}
+
+func (m *usersMock) DeleteUser(id int64) error {
+	m.deleted = id
+	return nil
+}
+
+// setupRouter serves the routes of a server that only has users, so any other handler reached would panic.
+func setupRouter(dao *usersMock) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	usersService := api.NewUsersService(dao, []byte("secret"), time.Hour)
+	server := NewServer(gin.New(), nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil,
+		usersService)
+	return server.InitRoutes()
+}
+
+func serve(router *gin.Engine, method string, path string, body string,
+	authorization string) *httptest.ResponseRecorder {
+	request := httptest.NewRequest(method, path, strings.NewReader(body))
+	if authorization != "" {
+		request.Header.Set("Authorization", authorization)
+	}
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+	return recorder
+}
+
+// login returns the authorization header of a new session of alex.
+func login(t *testing.T, router *gin.Engine) string {
+	recorder := serve(router, http.MethodPost, "/v1/api/login", `{"username":"alex","password":"correct horse"}`, "")
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("expected status 200 when signing in but found %d: %s", recorder.Code, recorder.Body)
+	}
+	response := struct {
+		Data api.Session `json:"data"`
+	}{}
+	if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil || response.Data.Token == "" {
+		t.Fatalf("unexpected session %s or error %v", recorder.Body, err)
+	}
+	return "Bearer " + response.Data.Token
+}
+
+func TestServer_RequireSession(t *testing.T) {
+
+	router := setupRouter(newUsersMock(t))
+
+	t.Run("testing the health check is open", func(t *testing.T) {
+		recorder := serve(router, http.MethodGet, "/v1/api/ping", "", "")
+		if recorder.Code != http.StatusOK {
+			t.Errorf("expected status 200 but found %d", recorder.Code)
+		}
+	})
+
+	t.Run("testing requests without a valid session are rejected", func(t *testing.T) {
+		for _, authorization := range []string{"", "Bearer 1.1656680400.forged", "Bearer not a token",
+			"Basic YWxleDpjb3JyZWN0IGhvcnNl"} {
+			for _, path := range []string{"/v1/api/accounts", "/v1/api/users/me"} {
+				recorder := serve(router, http.MethodGet, path, "", authorization)
+				if recorder.Code != http.StatusUnauthorized || recorder.Header().Get("WWW-Authenticate") != "Bearer" {
+					t.Errorf("expected status 401 for %s with %q but found %d", path, authorization, recorder.Code)
+				}
+			}
+		}
+	})
+
+	t.Run("testing requests with a session reach their handler", func(t *testing.T) {
+		recorder := serve(router, http.MethodGet, "/v1/api/users/me", "", login(t, router))
+		if recorder.Code != http.StatusOK || !strings.Contains(recorder.Body.String(), `"username":"alex"`) ||
+			strings.Contains(recorder.Body.String(), "password") {
+			t.Errorf("unexpected response %d: %s", recorder.Code, recorder.Body)
+		}
+	})
+}
+
+func TestServer_Login(t *testing.T) {
+
+	router := setupRouter(newUsersMock(t))
+
+	t.Run("testing wrong credentials are rejected", func(t *testing.T) {
+		for _, body := range []string{`{"username":"alex","password":"wrong horse"}`,
+			`{"username":"sam","password":"correct horse"}`} {
+			recorder := serve(router, http.MethodPost, "/v1/api/login", body, "")
+			if recorder.Code != http.StatusUnauthorized ||
+				!strings.Contains(recorder.Body.String(), "invalid username or password") {
+				t.Errorf("expected status 401 for %s but found %d: %s", body, recorder.Code, recorder.Body)
+			}
+		}
+	})
+
+	t.Run("testing incomplete logins are invalid", func(t *testing.T) {
+		for _, body := range []string{`{"username":"alex"}`, `not json`} {
+			recorder := serve(router, http.MethodPost, "/v1/api/login", body, "")
+			if recorder.Code != http.StatusBadRequest {
+				t.Errorf("expected status 400 for %s but found %d", body, recorder.Code)
+			}
+		}
+	})
+
+	t.Run("testing changing the password ends the session", func(t *testing.T) {
+		authorization := login(t, router)
+		recorder := serve(router, http.MethodPut, "/v1/api/users/1/password", `{"password":"battery staple"}`,
+			authorization)
+		if recorder.Code != http.StatusOK {
+			t.Errorf("expected status 200 but found %d: %s", recorder.Code, recorder.Body)
+			return
+		}
+		recorder = serve(router, http.MethodGet, "/v1/api/users", "", authorization)
+		if recorder.Code != http.StatusUnauthorized {
+			t.Errorf("expected status 401 but found %d", recorder.Code)
+		}
+	})
+}
+
+func TestServer_users(t *testing.T) {
+
+	t.Run("testing a user cannot change the password of another user", func(t *testing.T) {
+		dao := newUsersMock(t)
+		router := setupRouter(dao)
+		passwordHash := dao.passwordHash
+		recorder := serve(router, http.MethodPut, "/v1/api/users/2/password", `{"password":"battery staple"}`,
+			login(t, router))
+		if recorder.Code != http.StatusForbidden || dao.passwordHash != passwordHash {
+			t.Errorf("expected status 403 and the password to stay but found %d: %s", recorder.Code, recorder.Body)
+		}
+	})
+
+	t.Run("testing a user cannot delete another user", func(t *testing.T) {
+		dao := newUsersMock(t)
+		router := setupRouter(dao)
+		recorder := serve(router, http.MethodDelete, "/v1/api/users/2", "", login(t, router))
+		if recorder.Code != http.StatusForbidden || dao.deleted != 0 {
+			t.Errorf("expected status 403 and no deletion but found %d: %s", recorder.Code, recorder.Body)
+		}
+	})
+
+	t.Run("testing the last user cannot be deleted", func(t *testing.T) {
+		dao := newUsersMock(t)
+		router := setupRouter(dao)
+		recorder := serve(router, http.MethodDelete, "/v1/api/users/1", "", login(t, router))
+		if recorder.Code != http.StatusBadRequest || dao.deleted != 0 ||
+			!strings.Contains(recorder.Body.String(), "the last user cannot be deleted") {
+			t.Errorf("expected status 400 and no deletion but found %d: %s", recorder.Code, recorder.Body)
+		}
+	})
+}
@@ -0,0 +1,357 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"go-personal-finance/pkg/api"
+	"mime/multipart"
+	"net/http"
+	"strconv"
+)
+
+func (s *Server) GetImportProfile() gin.HandlerFunc {
+	return func(context *gin.Context) {
+
+		id, err := strconv.ParseInt(context.Param("id"), 10, 64)
+		if err != nil {
+			context.JSON(http.StatusBadRequest, gin.H{"status": "failure"})
+			return
+		}
+		profile, err := s.importService.GetImportProfile(id)
+		if err != nil {
+			context.JSON(http.StatusNotFound, gin.H{"status": "failure"})
+			return
+		}
+		context.JSON(http.StatusOK, gin.H{
+			"status": "success",
+			"data":   profile,
+		})
+	}
+}
+
+func (s *Server) ListImportProfiles() gin.HandlerFunc {
+	return func(context *gin.Context) {
+
+		profiles, err := s.importService.ListImportProfiles()
+		if err != nil {
+			respondWithError(context, err)
+			return
+		}
+		context.JSON(http.StatusOK, gin.H{
+			"status": "success",
+			"data":   profiles,
+		})
+	}
+}
+
+func (s *Server) AddImportProfile() gin.HandlerFunc {
+	return func(context *gin.Context) {
+
+		request := api.ImportProfileRequest{}
+		if err := context.ShouldBindJSON(&request); err != nil {
+			context.JSON(http.StatusBadRequest, gin.H{"status": "failure"})
+			return
+		}
+		id, err := s.importService.CreateImportProfile(request)
+		if err != nil {
+			respondWithError(context, err)
+			return
+		}
+		context.JSON(http.StatusOK, gin.H{
+			"status": "success",
+			"data":   gin.H{"id": id},
+		})
+	}
+}
+
+func (s *Server) UpdateImportProfile() gin.HandlerFunc {
+	return func(context *gin.Context) {
+
+		id, err := strconv.ParseInt(context.Param("id"), 10, 64)
+		if err != nil {
+			context.JSON(http.StatusBadRequest, gin.H{"status": "failure"})
+			return
+		}
+		request := api.ImportProfileRequest{}
+		if err = context.ShouldBindJSON(&request); err != nil {
+			context.JSON(http.StatusBadRequest, gin.H{"status": "failure"})
+			return
+		}
+		err = s.importService.UpdateImportProfile(id, request)
+		if err != nil {
+			respondWithError(context, err)
+			return
+		}
+		context.JSON(http.StatusOK, gin.H{"status": "success"})
+	}
+}
+
+func (s *Server) DeleteImportProfile() gin.HandlerFunc {
+	return func(context *gin.Context) {
+
+		id, err := strconv.ParseInt(context.Param("id"), 10, 64)
+		if err != nil {
+			context.JSON(http.StatusBadRequest, gin.H{"status": "failure"})
+			return
+		}
+		err = s.importService.DeleteImportProfile(id)
+		if err != nil {
+			respondWithError(context, err)
+			return
+		}
+		context.JSON(http.StatusOK, gin.H{"status": "success"})
+	}
+}
+
+// PreviewCSVImport parses an uploaded statement, sent as the multipart file field along with a profile_id form
+// field, and returns the parsed rows without importing them. Rows duplicating transactions of the optional account_id
+// form field are reported.
+func (s *Server) PreviewCSVImport() gin.HandlerFunc {
+	return func(context *gin.Context) {
+
+		profileId, err := parseInt64Form(context, "profile_id")
+		if err != nil {
+			respondWithError(context, err)
+			return
+		}
+		accountId, err := parseOptionalInt64Form(context, "account_id")
+		if err != nil {
+			respondWithError(context, err)
+			return
+		}
+		statement, err := openUploadedFile(context)
+		if err != nil {
+			respondWithError(context, err)
+			return
+		}
+		defer statement.Close()
+		preview, err := s.importService.PreviewCSV(profileId, accountId, statement)
+		if err != nil {
+			respondWithError(context, err)
+			return
+		}
+		context.JSON(http.StatusOK, gin.H{
+			"status": "success",
+			"data":   preview,
+		})
+	}
+}
+
+// ImportCSV imports an uploaded statement into the account given by the account_id form field. The optional decisions
+// form field holds a json list deciding what to do with each duplicate row.
+func (s *Server) ImportCSV() gin.HandlerFunc {
+	return func(context *gin.Context) {
+
+		profileId, err := parseInt64Form(context, "profile_id")
+		if err != nil {
+			respondWithError(context, err)
+			return
+		}
+		accountId, err := parseInt64Form(context, "account_id")
+		if err != nil {
+			respondWithError(context, err)
+			return
+		}
+		decisions, err := parseDecisionsForm(context)
+		if err != nil {
+			respondWithError(context, err)
+			return
+		}
+		statement, err := openUploadedFile(context)
+		if err != nil {
+			respondWithError(context, err)
+			return
+		}
+		defer statement.Close()
+		result, err := s.importService.ImportCSV(profileId, accountId, statement, decisions)
+		if err != nil {
+			respondWithError(context, err)
+			return
+		}
+		context.JSON(http.StatusOK, gin.H{
+			"status": "success",
+			"data":   result,
+		})
+	}
+}
+
+// PreviewOFXImport parses an uploaded ofx or qfx statement, sent as the multipart file field, and returns the parsed
+// transactions along with the account they would be imported into.
+func (s *Server) PreviewOFXImport() gin.HandlerFunc {
+	return func(context *gin.Context) {
+
+		statement, err := openUploadedFile(context)
+		if err != nil {
+			respondWithError(context, err)
+			return
+		}
+		defer statement.Close()
+		preview, err := s.importService.PreviewOFX(statement)
+		if err != nil {
+			respondWithError(context, err)
+			return
+		}
+		context.JSON(http.StatusOK, gin.H{
+			"status": "success",
+			"data":   preview,
+		})
+	}
+}
+
+// ImportOFX imports an uploaded ofx or qfx statement. The optional account_id form field picks the account, otherwise
+// the account linked to the statement is used, or a new one is created in org_id when create_account is true. Duplicate
+// rows are handled by the decisions form field like for csv statements.
+func (s *Server) ImportOFX() gin.HandlerFunc {
+	return func(context *gin.Context) {
+
+		options := api.OFXImportOptions{}
+		var err error
+		if options.AccountId, err = parseOptionalInt64Form(context, "account_id"); err != nil {
+			respondWithError(context, err)
+			return
+		}
+		if options.OrgId, err = parseOptionalInt64Form(context, "org_id"); err != nil {
+			respondWithError(context, err)
+			return
+		}
+		if value := context.PostForm("create_account"); value != "" {
+			if options.CreateAccount, err = strconv.ParseBool(value); err != nil {
+				respondWithError(context, fmt.Errorf("%w: create_account must be a boolean", api.ErrInvalidRequest))
+				return
+			}
+		}
+		if name, ok := context.GetPostForm("account_name"); ok {
+			options.AccountName = &name
+		}
+		if options.Decisions, err = parseDecisionsForm(context); err != nil {
+			respondWithError(context, err)
+			return
+		}
+		statement, err := openUploadedFile(context)
+		if err != nil {
+			respondWithError(context, err)
+			return
+		}
+		defer statement.Close()
+		result, err := s.importService.ImportOFX(statement, options)
+		if err != nil {
+			respondWithError(context, err)
+			return
+		}
+		context.JSON(http.StatusOK, gin.H{
+			"status": "success",
+			"data":   result,
+		})
+	}
+}
+
+// PreviewQIFImport parses an uploaded qif file, sent as the multipart file field, and returns the parsed
+// transactions without importing them. Rows duplicating transactions of the optional account_id form field are
+// reported.
+func (s *Server) PreviewQIFImport() gin.HandlerFunc {
+	return func(context *gin.Context) {
+
+		accountId, err := parseOptionalInt64Form(context, "account_id")
+		if err != nil {
+			respondWithError(context, err)
+			return
+		}
+		statement, err := openUploadedFile(context)
+		if err != nil {
+			respondWithError(context, err)
+			return
+		}
+		defer statement.Close()
+		preview, err := s.importService.PreviewQIF(accountId, statement)
+		if err != nil {
+			respondWithError(context, err)
+			return
+		}
+		context.JSON(http.StatusOK, gin.H{
+			"status": "success",
+			"data":   preview,
+		})
+	}
+}
+
+// ImportQIF imports an uploaded qif file into the account given by the account_id form field. Duplicate rows are
+// handled by the decisions form field like for csv statements.
+func (s *Server) ImportQIF() gin.HandlerFunc {
+	return func(context *gin.Context) {
+
+		accountId, err := parseInt64Form(context, "account_id")
+		if err != nil {
+			respondWithError(context, err)
+			return
+		}
+		decisions, err := parseDecisionsForm(context)
+		if err != nil {
+			respondWithError(context, err)
+			return
+		}
+		statement, err := openUploadedFile(context)
+		if err != nil {
+			respondWithError(context, err)
+			return
+		}
+		defer statement.Close()
+		result, err := s.importService.ImportQIF(accountId, statement, decisions)
+		if err != nil {
+			respondWithError(context, err)
+			return
+		}
+		context.JSON(http.StatusOK, gin.H{
+			"status": "success",
+			"data":   result,
+		})
+	}
+}
+
+// parseInt64Form reads a mandatory integer form field.
+func parseInt64Form(context *gin.Context, key string) (int64, error) {
+	value, err := strconv.ParseInt(context.PostForm(key), 10, 64)
+	if err != nil {
+		return -1, fmt.Errorf("%w: %s must be an integer", api.ErrInvalidRequest, key)
+	}
+	return value, nil
+}
+
+// parseOptionalInt64Form returns nil when the form field is absent.
+func parseOptionalInt64Form(context *gin.Context, key string) (*int64, error) {
+	if context.PostForm(key) == "" {
+		return nil, nil
+	}
+	value, err := parseInt64Form(context, key)
+	if err != nil {
+		return nil, err
+	}
+	return &value, nil
+}
+
+// parseDecisionsForm reads the optional decisions form field, a json list like
+// [{"line": 4, "action": "merge", "transaction_id": 12}].
+func parseDecisionsForm(context *gin.Context) ([]api.DuplicateDecision, error) {
+	value := context.PostForm("decisions")
+	if value == "" {
+		return nil, nil
+	}
+	var decisions []api.DuplicateDecision
+	if err := json.Unmarshal([]byte(value), &decisions); err != nil {
+		return nil, fmt.Errorf("%w: decisions must be a json list of line, action and transaction_id",
+			api.ErrInvalidRequest)
+	}
+	return decisions, nil
+}
+
+func openUploadedFile(context *gin.Context) (multipart.File, error) {
+	header, err := context.FormFile("file")
+	if err != nil {
+		return nil, fmt.Errorf("%w: a statement has to be uploaded as the file field", api.ErrInvalidRequest)
+	}
+	file, err := header.Open()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open uploaded statement with err:%v", err)
+	}
+	return file, nil
+}
@@ -0,0 +1,199 @@
+package app
+
+import (
+	"github.com/gin-gonic/gin"
+	"go-personal-finance/pkg/api"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// ListInvestmentTransactions lists the buys, sells and dividends, optionally limited to the account_id and symbol
+// query parameters.
+func (s *Server) ListInvestmentTransactions() gin.HandlerFunc {
+	return func(context *gin.Context) {
+
+		accountId, err := parseInt64Query(context, "account_id")
+		if err != nil {
+			respondWithError(context, err)
+			return
+		}
+		transactions, err := s.investmentsService.ListInvestmentTransactions(api.InvestmentTransactionFilter{
+			AccountId: accountId,
+			Symbol:    context.Query("symbol"),
+		})
+		if err != nil {
+			respondWithError(context, err)
+			return
+		}
+		context.JSON(http.StatusOK, gin.H{
+			"status": "success",
+			"data":   transactions,
+		})
+	}
+}
+
+func (s *Server) GetInvestmentTransaction() gin.HandlerFunc {
+	return func(context *gin.Context) {
+
+		id, err := strconv.ParseInt(context.Param("id"), 10, 64)
+		if err != nil {
+			context.JSON(http.StatusBadRequest, gin.H{"status": "failure"})
+			return
+		}
+		transaction, err := s.investmentsService.GetInvestmentTransaction(id)
+		if err != nil {
+			context.JSON(http.StatusNotFound, gin.H{"status": "failure"})
+			return
+		}
+		context.JSON(http.StatusOK, gin.H{
+			"status": "success",
+			"data":   transaction,
+		})
+	}
+}
+
+func (s *Server) AddInvestmentTransaction() gin.HandlerFunc {
+	return func(context *gin.Context) {
+
+		transactionRequest := api.InvestmentTransactionRequest{}
+		if err := context.ShouldBindJSON(&transactionRequest); err != nil {
+			context.JSON(http.StatusBadRequest, gin.H{"status": "failure"})
+			return
+		}
+		id, err := s.investmentsService.CreateInvestmentTransaction(transactionRequest)
+		if err != nil {
+			respondWithError(context, err)
+			return
+		}
+		context.JSON(http.StatusOK, gin.H{
+			"status": "success",
+			"data":   gin.H{"id": id},
+		})
+	}
+}
+
+func (s *Server) UpdateInvestmentTransaction() gin.HandlerFunc {
+	return func(context *gin.Context) {
+
+		id, err := strconv.ParseInt(context.Param("id"), 10, 64)
+		if err != nil {
+			context.JSON(http.StatusBadRequest, gin.H{"status": "failure"})
+			return
+		}
+		transactionRequest := api.InvestmentTransactionRequest{}
+		if err = context.ShouldBindJSON(&transactionRequest); err != nil {
+			context.JSON(http.StatusBadRequest, gin.H{"status": "failure"})
+			return
+		}
+		err = s.investmentsService.UpdateInvestmentTransaction(id, transactionRequest)
+		if err != nil {
+			respondWithError(context, err)
+			return
+		}
+		context.JSON(http.StatusOK, gin.H{"status": "success"})
+	}
+}
+
+func (s *Server) DeleteInvestmentTransaction() gin.HandlerFunc {
+	return func(context *gin.Context) {
+
+		id, err := strconv.ParseInt(context.Param("id"), 10, 64)
+		if err != nil {
+			context.JSON(http.StatusBadRequest, gin.H{"status": "failure"})
+			return
+		}
+		err = s.investmentsService.DeleteInvestmentTransaction(id)
+		if err != nil {
+			respondWithError(context, err)
+			return
+		}
+		context.JSON(http.StatusOK, gin.H{"status": "success"})
+	}
+}
+
+// GetHoldings values the holdings with the lots, gains and dividends. The account_id, method (fifo or average) and
+// as_of query parameters are optional; as_of defaults to today.
+func (s *Server) GetHoldings() gin.HandlerFunc {
+	return func(context *gin.Context) {
+
+		accountId, err := parseInt64Query(context, "account_id")
+		if err != nil {
+			respondWithError(context, err)
+			return
+		}
+		asOf, err := parseTimeQuery(context, "as_of")
+		if err != nil {
+			respondWithError(context, err)
+			return
+		}
+		if asOf == nil {
+			now := time.Now().UTC()
+			asOf = &now
+		}
+		portfolio, err := s.investmentsService.GetPortfolio(accountId, context.Query("method"), *asOf)
+		if err != nil {
+			respondWithError(context, err)
+			return
+		}
+		context.JSON(http.StatusOK, gin.H{
+			"status": "success",
+			"data":   portfolio,
+		})
+	}
+}
+
+// ListSecurityPrices lists the stored prices, optionally limited to the symbol query parameter.
+func (s *Server) ListSecurityPrices() gin.HandlerFunc {
+	return func(context *gin.Context) {
+
+		prices, err := s.investmentsService.ListSecurityPrices(context.Query("symbol"))
+		if err != nil {
+			respondWithError(context, err)
+			return
+		}
+		context.JSON(http.StatusOK, gin.H{
+			"status": "success",
+			"data":   prices,
+		})
+	}
+}
+
+// SaveSecurityPrice stores a price, replacing the price of the security on the same date.
+func (s *Server) SaveSecurityPrice() gin.HandlerFunc {
+	return func(context *gin.Context) {
+
+		priceRequest := api.SecurityPriceRequest{}
+		if err := context.ShouldBindJSON(&priceRequest); err != nil {
+			context.JSON(http.StatusBadRequest, gin.H{"status": "failure"})
+			return
+		}
+		if err := s.investmentsService.SaveSecurityPrice(priceRequest); err != nil {
+			respondWithError(context, err)
+			return
+		}
+		context.JSON(http.StatusOK, gin.H{"status": "success"})
+	}
+}
+
+// ImportSecurityPrices stores every price of an uploaded csv file, sent as the multipart file field.
+func (s *Server) ImportSecurityPrices() gin.HandlerFunc {
+	return func(context *gin.Context) {
+
+		file, err := openUploadedFile(context)
+		if err != nil {
+			respondWithError(context, err)
+			return
+		}
+		defer file.Close()
+		imported, err := s.investmentsService.ImportSecurityPrices(file)
+		if err != nil {
+			respondWithError(context, err)
+			return
+		}
+		context.JSON(http.StatusOK, gin.H{
+			"status": "success",
+			"data":   gin.H{"imported": imported},
+		})
+	}
+}
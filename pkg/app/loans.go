@@ -0,0 +1,179 @@
+package app
+
+import (
+	"github.com/gin-gonic/gin"
+	"go-personal-finance/pkg/api"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+func (s *Server) ListLoans() gin.HandlerFunc {
+	return func(context *gin.Context) {
+
+		loans, err := s.loansService.ListLoans()
+		if err != nil {
+			respondWithError(context, err)
+			return
+		}
+		context.JSON(http.StatusOK, gin.H{
+			"status": "success",
+			"data":   loans,
+		})
+	}
+}
+
+func (s *Server) GetLoan() gin.HandlerFunc {
+	return func(context *gin.Context) {
+
+		id, err := strconv.ParseInt(context.Param("id"), 10, 64)
+		if err != nil {
+			context.JSON(http.StatusBadRequest, gin.H{"status": "failure"})
+			return
+		}
+		loan, err := s.loansService.GetLoan(id)
+		if err != nil {
+			context.JSON(http.StatusNotFound, gin.H{"status": "failure"})
+			return
+		}
+		context.JSON(http.StatusOK, gin.H{
+			"status": "success",
+			"data":   loan,
+		})
+	}
+}
+
+func (s *Server) AddLoan() gin.HandlerFunc {
+	return func(context *gin.Context) {
+
+		loanRequest := api.LoanRequest{}
+		if err := context.ShouldBindJSON(&loanRequest); err != nil {
+			context.JSON(http.StatusBadRequest, gin.H{"status": "failure"})
+			return
+		}
+		id, err := s.loansService.CreateLoan(loanRequest)
+		if err != nil {
+			respondWithError(context, err)
+			return
+		}
+		context.JSON(http.StatusOK, gin.H{
+			"status": "success",
+			"data":   gin.H{"id": id},
+		})
+	}
+}
+
+func (s *Server) UpdateLoan() gin.HandlerFunc {
+	return func(context *gin.Context) {
+
+		id, err := strconv.ParseInt(context.Param("id"), 10, 64)
+		if err != nil {
+			context.JSON(http.StatusBadRequest, gin.H{"status": "failure"})
+			return
+		}
+		loanRequest := api.LoanRequest{}
+		if err = context.ShouldBindJSON(&loanRequest); err != nil {
+			context.JSON(http.StatusBadRequest, gin.H{"status": "failure"})
+			return
+		}
+		err = s.loansService.UpdateLoan(id, loanRequest)
+		if err != nil {
+			respondWithError(context, err)
+			return
+		}
+		context.JSON(http.StatusOK, gin.H{"status": "success"})
+	}
+}
+
+func (s *Server) DeleteLoan() gin.HandlerFunc {
+	return func(context *gin.Context) {
+
+		id, err := strconv.ParseInt(context.Param("id"), 10, 64)
+		if err != nil {
+			context.JSON(http.StatusBadRequest, gin.H{"status": "failure"})
+			return
+		}
+		err = s.loansService.DeleteLoan(id)
+		if err != nil {
+			respondWithError(context, err)
+			return
+		}
+		context.JSON(http.StatusOK, gin.H{"status": "success"})
+	}
+}
+
+func (s *Server) GetAmortizationSchedule() gin.HandlerFunc {
+	return func(context *gin.Context) {
+
+		id, err := strconv.ParseInt(context.Param("id"), 10, 64)
+		if err != nil {
+			context.JSON(http.StatusBadRequest, gin.H{"status": "failure"})
+			return
+		}
+		schedule, err := s.loansService.GetAmortizationSchedule(id)
+		if err != nil {
+			context.JSON(http.StatusNotFound, gin.H{"status": "failure"})
+			return
+		}
+		context.JSON(http.StatusOK, gin.H{
+			"status": "success",
+			"data":   schedule,
+		})
+	}
+}
+
+// GetLoanPayments tracks the payments into the loan account against its schedule until the as_of query parameter,
+// which defaults to today.
+func (s *Server) GetLoanPayments() gin.HandlerFunc {
+	return func(context *gin.Context) {
+
+		id, err := strconv.ParseInt(context.Param("id"), 10, 64)
+		if err != nil {
+			context.JSON(http.StatusBadRequest, gin.H{"status": "failure"})
+			return
+		}
+		asOf, err := parseTimeQuery(context, "as_of")
+		if err != nil {
+			respondWithError(context, err)
+			return
+		}
+		if asOf == nil {
+			now := time.Now().UTC()
+			asOf = &now
+		}
+		payments, err := s.loansService.GetLoanPayments(id, *asOf)
+		if err != nil {
+			respondWithError(context, err)
+			return
+		}
+		context.JSON(http.StatusOK, gin.H{
+			"status": "success",
+			"data":   payments,
+		})
+	}
+}
+
+func (s *Server) SimulateLoanPrepayment() gin.HandlerFunc {
+	return func(context *gin.Context) {
+
+		id, err := strconv.ParseInt(context.Param("id"), 10, 64)
+		if err != nil {
+			context.JSON(http.StatusBadRequest, gin.H{"status": "failure"})
+			return
+		}
+		prepaymentRequest := api.LoanPrepaymentRequest{}
+		if err = context.ShouldBindJSON(&prepaymentRequest); err != nil {
+			context.JSON(http.StatusBadRequest, gin.H{"status": "failure"})
+			return
+		}
+		result, err := s.loansService.SimulatePrepayment(id, prepaymentRequest)
+		if err != nil {
+			respondWithError(context, err)
+			return
+		}
+		context.JSON(http.StatusOK, gin.H{
+			"status": "success",
+			"data":   result,
+		})
+	}
+}
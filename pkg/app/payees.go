@@ -0,0 +1,180 @@
+package app
+
+import (
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"go-personal-finance/pkg/api"
+	"net/http"
+	"strconv"
+)
+
+func (s *Server) ListPayees() gin.HandlerFunc {
+	return func(context *gin.Context) {
+
+		payees, err := s.payeesService.ListPayees()
+		if err != nil {
+			respondWithError(context, err)
+			return
+		}
+		context.JSON(http.StatusOK, gin.H{
+			"status": "success",
+			"data":   payees,
+		})
+	}
+}
+
+func (s *Server) GetPayee() gin.HandlerFunc {
+	return func(context *gin.Context) {
+
+		id, err := strconv.ParseInt(context.Param("id"), 10, 64)
+		if err != nil {
+			context.JSON(http.StatusBadRequest, gin.H{"status": "failure"})
+			return
+		}
+		payee, err := s.payeesService.GetPayee(id)
+		if err != nil {
+			context.JSON(http.StatusNotFound, gin.H{"status": "failure"})
+			return
+		}
+		context.JSON(http.StatusOK, gin.H{
+			"status": "success",
+			"data":   payee,
+		})
+	}
+}
+
+func (s *Server) AddPayee() gin.HandlerFunc {
+	return func(context *gin.Context) {
+
+		payeeCreationRequest := api.PayeeCreationRequest{}
+		if err := context.ShouldBindJSON(&payeeCreationRequest); err != nil {
+			context.JSON(http.StatusBadRequest, gin.H{"status": "failure"})
+			return
+		}
+		id, err := s.payeesService.CreatePayee(payeeCreationRequest)
+		if err != nil {
+			respondWithError(context, err)
+			return
+		}
+		context.JSON(http.StatusOK, gin.H{
+			"status": "success",
+			"data":   gin.H{"id": id},
+		})
+	}
+}
+
+func (s *Server) UpdatePayee() gin.HandlerFunc {
+	return func(context *gin.Context) {
+
+		id, err := strconv.ParseInt(context.Param("id"), 10, 64)
+		if err != nil {
+			context.JSON(http.StatusBadRequest, gin.H{"status": "failure"})
+			return
+		}
+		payeeUpdateRequest := api.PayeeUpdateRequest{}
+		if err = context.ShouldBindJSON(&payeeUpdateRequest); err != nil {
+			context.JSON(http.StatusBadRequest, gin.H{"status": "failure"})
+			return
+		}
+		err = s.payeesService.UpdatePayee(id, payeeUpdateRequest)
+		if err != nil {
+			respondWithError(context, err)
+			return
+		}
+		context.JSON(http.StatusOK, gin.H{"status": "success"})
+	}
+}
+
+func (s *Server) DeletePayee() gin.HandlerFunc {
+	return func(context *gin.Context) {
+
+		id, err := strconv.ParseInt(context.Param("id"), 10, 64)
+		if err != nil {
+			context.JSON(http.StatusBadRequest, gin.H{"status": "failure"})
+			return
+		}
+		err = s.payeesService.DeletePayee(id)
+		if err != nil {
+			respondWithError(context, err)
+			return
+		}
+		context.JSON(http.StatusOK, gin.H{"status": "success"})
+	}
+}
+
+func (s *Server) ListPayeeRules() gin.HandlerFunc {
+	return func(context *gin.Context) {
+
+		rules, err := s.payeesService.ListPayeeRules()
+		if err != nil {
+			respondWithError(context, err)
+			return
+		}
+		context.JSON(http.StatusOK, gin.H{
+			"status": "success",
+			"data":   rules,
+		})
+	}
+}
+
+func (s *Server) AddPayeeRule() gin.HandlerFunc {
+	return func(context *gin.Context) {
+
+		payeeRuleCreationRequest := api.PayeeRuleCreationRequest{}
+		if err := context.ShouldBindJSON(&payeeRuleCreationRequest); err != nil {
+			context.JSON(http.StatusBadRequest, gin.H{"status": "failure"})
+			return
+		}
+		id, err := s.payeesService.CreatePayeeRule(payeeRuleCreationRequest)
+		if err != nil {
+			respondWithError(context, err)
+			return
+		}
+		context.JSON(http.StatusOK, gin.H{
+			"status": "success",
+			"data":   gin.H{"id": id},
+		})
+	}
+}
+
+func (s *Server) DeletePayeeRule() gin.HandlerFunc {
+	return func(context *gin.Context) {
+
+		id, err := strconv.ParseInt(context.Param("id"), 10, 64)
+		if err != nil {
+			context.JSON(http.StatusBadRequest, gin.H{"status": "failure"})
+			return
+		}
+		err = s.payeesService.DeletePayeeRule(id)
+		if err != nil {
+			respondWithError(context, err)
+			return
+		}
+		context.JSON(http.StatusOK, gin.H{"status": "success"})
+	}
+}
+
+// ResolvePayee previews which payee the rules assign to the description query parameter.
+func (s *Server) ResolvePayee() gin.HandlerFunc {
+	return func(context *gin.Context) {
+
+		description := context.Query("description")
+		if description == "" {
+			respondWithError(context, fmt.Errorf("%w: description is required", api.ErrInvalidRequest))
+			return
+		}
+		payeeId, found, err := s.payeesService.ResolvePayee(description)
+		if err != nil {
+			respondWithError(context, err)
+			return
+		}
+		if !found {
+			context.JSON(http.StatusNotFound, gin.H{"status": "failure"})
+			return
+		}
+		context.JSON(http.StatusOK, gin.H{
+			"status": "success",
+			"data":   gin.H{"payee_id": payeeId},
+		})
+	}
+}
@@ -0,0 +1,156 @@
+package app
+
+import (
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"go-personal-finance/pkg/api"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+func (s *Server) ListRecurringTransactions() gin.HandlerFunc {
+	return func(context *gin.Context) {
+
+		recurringTransactions, err := s.recurringTransactionsService.ListRecurringTransactions()
+		if err != nil {
+			respondWithError(context, err)
+			return
+		}
+		context.JSON(http.StatusOK, gin.H{
+			"status": "success",
+			"data":   recurringTransactions,
+		})
+	}
+}
+
+func (s *Server) GetRecurringTransaction() gin.HandlerFunc {
+	return func(context *gin.Context) {
+
+		id, err := strconv.ParseInt(context.Param("id"), 10, 64)
+		if err != nil {
+			context.JSON(http.StatusBadRequest, gin.H{"status": "failure"})
+			return
+		}
+		recurring, err := s.recurringTransactionsService.GetRecurringTransaction(id)
+		if err != nil {
+			context.JSON(http.StatusNotFound, gin.H{"status": "failure"})
+			return
+		}
+		context.JSON(http.StatusOK, gin.H{
+			"status": "success",
+			"data":   recurring,
+		})
+	}
+}
+
+func (s *Server) AddRecurringTransaction() gin.HandlerFunc {
+	return func(context *gin.Context) {
+
+		recurringRequest := api.RecurringTransactionRequest{}
+		if err := context.ShouldBindJSON(&recurringRequest); err != nil {
+			context.JSON(http.StatusBadRequest, gin.H{"status": "failure"})
+			return
+		}
+		id, err := s.recurringTransactionsService.CreateRecurringTransaction(recurringRequest)
+		if err != nil {
+			respondWithError(context, err)
+			return
+		}
+		context.JSON(http.StatusOK, gin.H{
+			"status": "success",
+			"data":   gin.H{"id": id},
+		})
+	}
+}
+
+func (s *Server) UpdateRecurringTransaction() gin.HandlerFunc {
+	return func(context *gin.Context) {
+
+		id, err := strconv.ParseInt(context.Param("id"), 10, 64)
+		if err != nil {
+			context.JSON(http.StatusBadRequest, gin.H{"status": "failure"})
+			return
+		}
+		recurringRequest := api.RecurringTransactionRequest{}
+		if err = context.ShouldBindJSON(&recurringRequest); err != nil {
+			context.JSON(http.StatusBadRequest, gin.H{"status": "failure"})
+			return
+		}
+		err = s.recurringTransactionsService.UpdateRecurringTransaction(id, recurringRequest)
+		if err != nil {
+			respondWithError(context, err)
+			return
+		}
+		context.JSON(http.StatusOK, gin.H{"status": "success"})
+	}
+}
+
+func (s *Server) DeleteRecurringTransaction() gin.HandlerFunc {
+	return func(context *gin.Context) {
+
+		id, err := strconv.ParseInt(context.Param("id"), 10, 64)
+		if err != nil {
+			context.JSON(http.StatusBadRequest, gin.H{"status": "failure"})
+			return
+		}
+		err = s.recurringTransactionsService.DeleteRecurringTransaction(id)
+		if err != nil {
+			respondWithError(context, err)
+			return
+		}
+		context.JSON(http.StatusOK, gin.H{"status": "success"})
+	}
+}
+
+// MaterializeRecurringTransactions creates the transactions of every occurrence due on or before the until query
+// parameter, which defaults to now.
+func (s *Server) MaterializeRecurringTransactions() gin.HandlerFunc {
+	return func(context *gin.Context) {
+
+		until, err := parseTimeQuery(context, "until")
+		if err != nil {
+			respondWithError(context, err)
+			return
+		}
+		if until == nil {
+			now := time.Now()
+			until = &now
+		}
+		created, err := s.recurringTransactionsService.MaterializeTransactions(*until)
+		if err != nil {
+			respondWithError(context, err)
+			return
+		}
+		context.JSON(http.StatusOK, gin.H{
+			"status": "success",
+			"data":   gin.H{"created": created},
+		})
+	}
+}
+
+// ListUpcomingBills lists the occurrences due in the number of days of the days query parameter, 30 by default,
+// along with the overdue ones.
+func (s *Server) ListUpcomingBills() gin.HandlerFunc {
+	return func(context *gin.Context) {
+
+		days := 0
+		if value := context.Query("days"); value != "" {
+			parsed, err := strconv.Atoi(value)
+			if err != nil {
+				respondWithError(context, fmt.Errorf("%w: days must be an integer", api.ErrInvalidRequest))
+				return
+			}
+			days = parsed
+		}
+		bills, err := s.recurringTransactionsService.ListUpcomingBills(time.Now(), days)
+		if err != nil {
+			respondWithError(context, err)
+			return
+		}
+		context.JSON(http.StatusOK, gin.H{
+			"status": "success",
+			"data":   bills,
+		})
+	}
+}
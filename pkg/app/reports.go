@@ -0,0 +1,87 @@
+package app
+
+import (
+	"github.com/gin-gonic/gin"
+	"go-personal-finance/pkg/api"
+	"net/http"
+)
+
+// GetNetWorth reports assets, liabilities and net worth between the optional from and to query parameters at the
+// daily, weekly, monthly, quarterly or yearly granularity query parameter, converted into the currency query
+// parameter. Accounts of the credit card, loan, mortgage and liability types, in singular or plural and with spaces,
+// dashes or underscores between words like "Credit Cards", hold liabilities and all other accounts hold assets.
+func (s *Server) GetNetWorth() gin.HandlerFunc {
+	return func(context *gin.Context) {
+
+		from, err := parseTimeQuery(context, "from")
+		if err != nil {
+			respondWithError(context, err)
+			return
+		}
+		to, err := parseTimeQuery(context, "to")
+		if err != nil {
+			respondWithError(context, err)
+			return
+		}
+		report, err := s.reportsService.GetNetWorth(from, to, context.Query("granularity"),
+			context.Query("currency"))
+		if err != nil {
+			respondWithError(context, err)
+			return
+		}
+		context.JSON(http.StatusOK, gin.H{
+			"status": "success",
+			"data":   report,
+		})
+	}
+}
+
+// GetCashFlow reports income and expenses between the optional from and to query parameters, grouped by the
+// category, payee or tag group_by query parameter in monthly, quarterly or yearly periods of the granularity query
+// parameter and converted into the currency query parameter.
+func (s *Server) GetCashFlow() gin.HandlerFunc {
+	return func(context *gin.Context) {
+
+		from, err := parseTimeQuery(context, "from")
+		if err != nil {
+			respondWithError(context, err)
+			return
+		}
+		to, err := parseTimeQuery(context, "to")
+		if err != nil {
+			respondWithError(context, err)
+			return
+		}
+		report, err := s.reportsService.GetCashFlow(from, to, context.Query("group_by"),
+			context.Query("granularity"), context.Query("currency"))
+		if err != nil {
+			respondWithError(context, err)
+			return
+		}
+		context.JSON(http.StatusOK, gin.H{
+			"status": "success",
+			"data":   report,
+		})
+	}
+}
+
+// SimulateNetWorth runs a Monte Carlo simulation of net worth. The seed of the result repeats the simulation.
+func (s *Server) SimulateNetWorth() gin.HandlerFunc {
+	return func(context *gin.Context) {
+
+		simulationRequest := api.SimulationRequest{}
+		if err := context.ShouldBindJSON(&simulationRequest); err != nil {
+			context.JSON(http.StatusBadRequest, gin.H{"status": "failure"})
+			return
+		}
+		result, err := s.reportsService.SimulateNetWorth(simulationRequest)
+		if err != nil {
+			respondWithError(context, err)
+			return
+		}
+		context.JSON(http.StatusOK, gin.H{
+			"status": "success",
+			"data":   result,
+		})
+	}
+}
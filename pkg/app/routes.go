@@ -7,18 +7,154 @@ func (s *Server) InitRoutes() *gin.Engine {
 
 	v1 := router.Group("/v1/api")
 	{
+		//health check and login are open, every route registered after the middleware requires a session
+		v1.GET("/ping", s.ApiStatus())
+		v1.POST("/login", s.Login())
+		v1.Use(s.RequireSession())
+
 		//accounts
 		v1.GET("/accounts/:id", s.GetAccount())
-		v1.GET("/accounts", s.ApiStatus())
+		v1.GET("/accounts", s.ListAccounts())
 		v1.DELETE("/accounts/:id", s.DeleteAccount())
 		v1.POST("/accounts", s.AddAccount())
 		v1.PUT("/accounts/:id", s.UpdateAccount())
+		v1.GET("/accounts/:id/balance", s.GetAccountBalance())
+		v1.GET("/accounts/reconciliation", s.ReconcileAccounts())
+
+		//transactions
+		v1.GET("/transactions", s.ListTransactions())
+		v1.GET("/transactions/:id", s.GetTransaction())
+		v1.POST("/transactions", s.AddTransaction())
+		v1.PUT("/transactions/:id", s.UpdateTransaction())
+		v1.DELETE("/transactions/:id", s.DeleteTransaction())
+		v1.POST("/transactions/:id/tags", s.AddTransactionTag())
+		v1.DELETE("/transactions/:id/tags/:name", s.RemoveTransactionTag())
+
+		//transfers
+		v1.GET("/transfers/:id", s.GetTransfer())
+		v1.POST("/transfers", s.AddTransfer())
 
 		//organizations
-		v1.GET("/organizations", s.ApiStatus())
+		v1.GET("/organizations", s.ListOrganizations())
+		v1.GET("/organizations/:id", s.GetOrganization())
+		v1.POST("/organizations", s.AddOrganization())
+		v1.PUT("/organizations/:id", s.UpdateOrganization())
+		v1.DELETE("/organizations/:id", s.DeleteOrganization())
 
-		//health check
-		v1.GET("/ping", s.ApiStatus())
+		//payees
+		v1.GET("/payees", s.ListPayees())
+		v1.GET("/payees/:id", s.GetPayee())
+		v1.POST("/payees", s.AddPayee())
+		v1.PUT("/payees/:id", s.UpdatePayee())
+		v1.DELETE("/payees/:id", s.DeletePayee())
+		v1.GET("/payees/resolve", s.ResolvePayee())
+		v1.GET("/payees/rules", s.ListPayeeRules())
+		v1.POST("/payees/rules", s.AddPayeeRule())
+		v1.DELETE("/payees/rules/:id", s.DeletePayeeRule())
+
+		//categories
+		v1.GET("/categories", s.ListCategories())
+		v1.GET("/categories/tree", s.GetCategoryTree())
+		v1.GET("/categories/:id", s.GetCategory())
+		v1.GET("/categories/:id/rollup", s.GetCategoryRollup())
+		v1.POST("/categories", s.AddCategory())
+		v1.PUT("/categories/:id", s.UpdateCategory())
+		v1.PUT("/categories/:id/parent", s.MoveCategory())
+		v1.DELETE("/categories/:id", s.DeleteCategory())
+
+		//tags
+		v1.GET("/tags", s.ListTags())
+		v1.POST("/tags", s.AddTag())
+		v1.PUT("/tags/:name", s.RenameTag())
+		v1.DELETE("/tags/:name", s.DeleteTag())
+		v1.GET("/tags/:name/transactions", s.ListTransactionsByTag())
+
+		//imports
+		v1.GET("/imports/profiles", s.ListImportProfiles())
+		v1.GET("/imports/profiles/:id", s.GetImportProfile())
+		v1.POST("/imports/profiles", s.AddImportProfile())
+		v1.PUT("/imports/profiles/:id", s.UpdateImportProfile())
+		v1.DELETE("/imports/profiles/:id", s.DeleteImportProfile())
+		v1.POST("/imports/csv/preview", s.PreviewCSVImport())
+		v1.POST("/imports/csv", s.ImportCSV())
+		v1.POST("/imports/ofx/preview", s.PreviewOFXImport())
+		v1.POST("/imports/ofx", s.ImportOFX())
+		v1.POST("/imports/qif/preview", s.PreviewQIFImport())
+		v1.POST("/imports/qif", s.ImportQIF())
+
+		//rules
+		v1.GET("/rules", s.ListRules())
+		v1.GET("/rules/:id", s.GetRule())
+		v1.POST("/rules", s.AddRule())
+		v1.PUT("/rules/:id", s.UpdateRule())
+		v1.DELETE("/rules/:id", s.DeleteRule())
+		v1.POST("/rules/apply", s.ApplyRules())
+
+		//budgets
+		v1.GET("/budgets", s.ListBudgets())
+		v1.GET("/budgets/progress", s.GetMonthlyBudget())
+		v1.GET("/budgets/:id", s.GetBudget())
+		v1.POST("/budgets", s.AddBudget())
+		v1.PUT("/budgets/:id", s.UpdateBudget())
+		v1.DELETE("/budgets/:id", s.DeleteBudget())
+
+		//exchange rates
+		v1.GET("/exchange-rates", s.ListExchangeRates())
+		v1.POST("/exchange-rates", s.SaveExchangeRate())
+		v1.POST("/exchange-rates/import", s.ImportExchangeRates())
+
+		//reports
+		v1.GET("/reports/net-worth", s.GetNetWorth())
+		v1.GET("/reports/cash-flow", s.GetCashFlow())
+		v1.POST("/reports/simulation", s.SimulateNetWorth())
+
+		//recurring transactions
+		v1.GET("/recurring-transactions", s.ListRecurringTransactions())
+		v1.GET("/recurring-transactions/upcoming", s.ListUpcomingBills())
+		v1.GET("/recurring-transactions/:id", s.GetRecurringTransaction())
+		v1.POST("/recurring-transactions", s.AddRecurringTransaction())
+		v1.PUT("/recurring-transactions/:id", s.UpdateRecurringTransaction())
+		v1.DELETE("/recurring-transactions/:id", s.DeleteRecurringTransaction())
+		v1.POST("/recurring-transactions/materialize", s.MaterializeRecurringTransactions())
+
+		//loans
+		v1.GET("/loans", s.ListLoans())
+		v1.GET("/loans/:id", s.GetLoan())
+		v1.POST("/loans", s.AddLoan())
+		v1.PUT("/loans/:id", s.UpdateLoan())
+		v1.DELETE("/loans/:id", s.DeleteLoan())
+		v1.GET("/loans/:id/schedule", s.GetAmortizationSchedule())
+		v1.GET("/loans/:id/payments", s.GetLoanPayments())
+		v1.POST("/loans/:id/prepayment", s.SimulateLoanPrepayment())
+
+		//credit cards
+		v1.GET("/credit-cards", s.ListCreditCards())
+		v1.GET("/credit-cards/:id", s.GetCreditCard())
+		v1.POST("/credit-cards", s.AddCreditCard())
+		v1.PUT("/credit-cards/:id", s.UpdateCreditCard())
+		v1.DELETE("/credit-cards/:id", s.DeleteCreditCard())
+		v1.GET("/credit-cards/:id/statements", s.GetCreditCardStatements())
+
+		//investments
+		v1.GET("/investments/transactions", s.ListInvestmentTransactions())
+		v1.GET("/investments/transactions/:id", s.GetInvestmentTransaction())
+		v1.POST("/investments/transactions", s.AddInvestmentTransaction())
+		v1.PUT("/investments/transactions/:id", s.UpdateInvestmentTransaction())
+		v1.DELETE("/investments/transactions/:id", s.DeleteInvestmentTransaction())
+		v1.GET("/investments/holdings", s.GetHoldings())
+		v1.GET("/investments/prices", s.ListSecurityPrices())
+		v1.POST("/investments/prices", s.SaveSecurityPrice())
+		v1.POST("/investments/prices/import", s.ImportSecurityPrices())
+
+		//users
+		v1.GET("/users", s.ListUsers())
+		v1.GET("/users/me", s.GetCurrentUser())
+		v1.POST("/users", s.AddUser())
+		v1.PUT("/users/:id/password", s.UpdateUserPassword())
+		v1.DELETE("/users/:id", s.DeleteUser())
+
+		//exports
+		v1.GET("/exports/qif", s.ExportQIF())
 	}
 
 	return router
@@ -0,0 +1,123 @@
+package app
+
+import (
+	"github.com/gin-gonic/gin"
+	"go-personal-finance/pkg/api"
+	"net/http"
+	"strconv"
+)
+
+func (s *Server) ListRules() gin.HandlerFunc {
+	return func(context *gin.Context) {
+
+		rules, err := s.rulesService.ListRules()
+		if err != nil {
+			respondWithError(context, err)
+			return
+		}
+		context.JSON(http.StatusOK, gin.H{
+			"status": "success",
+			"data":   rules,
+		})
+	}
+}
+
+func (s *Server) GetRule() gin.HandlerFunc {
+	return func(context *gin.Context) {
+
+		id, err := strconv.ParseInt(context.Param("id"), 10, 64)
+		if err != nil {
+			context.JSON(http.StatusBadRequest, gin.H{"status": "failure"})
+			return
+		}
+		rule, err := s.rulesService.GetRule(id)
+		if err != nil {
+			context.JSON(http.StatusNotFound, gin.H{"status": "failure"})
+			return
+		}
+		context.JSON(http.StatusOK, gin.H{
+			"status": "success",
+			"data":   rule,
+		})
+	}
+}
+
+func (s *Server) AddRule() gin.HandlerFunc {
+	return func(context *gin.Context) {
+
+		ruleRequest := api.RuleRequest{}
+		if err := context.ShouldBindJSON(&ruleRequest); err != nil {
+			context.JSON(http.StatusBadRequest, gin.H{"status": "failure"})
+			return
+		}
+		id, err := s.rulesService.CreateRule(ruleRequest)
+		if err != nil {
+			respondWithError(context, err)
+			return
+		}
+		context.JSON(http.StatusOK, gin.H{
+			"status": "success",
+			"data":   gin.H{"id": id},
+		})
+	}
+}
+
+func (s *Server) UpdateRule() gin.HandlerFunc {
+	return func(context *gin.Context) {
+
+		id, err := strconv.ParseInt(context.Param("id"), 10, 64)
+		if err != nil {
+			context.JSON(http.StatusBadRequest, gin.H{"status": "failure"})
+			return
+		}
+		ruleRequest := api.RuleRequest{}
+		if err = context.ShouldBindJSON(&ruleRequest); err != nil {
+			context.JSON(http.StatusBadRequest, gin.H{"status": "failure"})
+			return
+		}
+		err = s.rulesService.UpdateRule(id, ruleRequest)
+		if err != nil {
+			respondWithError(context, err)
+			return
+		}
+		context.JSON(http.StatusOK, gin.H{"status": "success"})
+	}
+}
+
+func (s *Server) DeleteRule() gin.HandlerFunc {
+	return func(context *gin.Context) {
+
+		id, err := strconv.ParseInt(context.Param("id"), 10, 64)
+		if err != nil {
+			context.JSON(http.StatusBadRequest, gin.H{"status": "failure"})
+			return
+		}
+		err = s.rulesService.DeleteRule(id)
+		if err != nil {
+			respondWithError(context, err)
+			return
+		}
+		context.JSON(http.StatusOK, gin.H{"status": "success"})
+	}
+}
+
+// ApplyRules re-applies the rules to existing transactions, or only reports what would change on a dry run.
+func (s *Server) ApplyRules() gin.HandlerFunc {
+	return func(context *gin.Context) {
+
+		applicationRequest := api.RuleApplicationRequest{}
+		if err := context.ShouldBindJSON(&applicationRequest); err != nil {
+			context.JSON(http.StatusBadRequest, gin.H{"status": "failure"})
+			return
+		}
+		result, err := s.transactionsService.ApplyRules(applicationRequest)
+		if err != nil {
+			respondWithError(context, err)
+			return
+		}
+		context.JSON(http.StatusOK, gin.H{
+			"status": "success",
+			"data":   result,
+		})
+	}
+}
@@ -7,14 +7,53 @@ import (
 )
 
 type Server struct {
-	router          *gin.Engine
-	accountsService *api.AccountsService
+	router                       *gin.Engine
+	accountsService              *api.AccountsService
+	transactionsService          *api.TransactionsService
+	organizationsService         *api.OrganizationsService
+	payeesService                *api.PayeesService
+	categoriesService            *api.CategoriesService
+	tagsService                  *api.TagsService
+	importService                *api.ImportService
+	exportService                *api.ExportService
+	rulesService                 *api.RulesService
+	budgetsService               *api.BudgetsService
+	exchangeRatesService         *api.ExchangeRatesService
+	reportsService               *api.ReportsService
+	recurringTransactionsService *api.RecurringTransactionsService
+	loansService                 *api.LoansService
+	investmentsService           *api.InvestmentsService
+	creditCardsService           *api.CreditCardsService
+	usersService                 *api.UsersService
 }
 
-func NewServer(router *gin.Engine, accountsService *api.AccountsService) *Server {
+func NewServer(router *gin.Engine, accountsService *api.AccountsService,
+	transactionsService *api.TransactionsService, organizationsService *api.OrganizationsService,
+	payeesService *api.PayeesService, categoriesService *api.CategoriesService, tagsService *api.TagsService,
+	importService *api.ImportService, exportService *api.ExportService, rulesService *api.RulesService,
+	budgetsService *api.BudgetsService, exchangeRatesService *api.ExchangeRatesService,
+	reportsService *api.ReportsService, recurringTransactionsService *api.RecurringTransactionsService,
+	loansService *api.LoansService, investmentsService *api.InvestmentsService,
+	creditCardsService *api.CreditCardsService, usersService *api.UsersService) *Server {
 	return &Server{
-		router:          router,
-		accountsService: accountsService,
+		router:                       router,
+		accountsService:              accountsService,
+		transactionsService:          transactionsService,
+		organizationsService:         organizationsService,
+		payeesService:                payeesService,
+		categoriesService:            categoriesService,
+		tagsService:                  tagsService,
+		importService:                importService,
+		exportService:                exportService,
+		rulesService:                 rulesService,
+		budgetsService:               budgetsService,
+		exchangeRatesService:         exchangeRatesService,
+		reportsService:               reportsService,
+		recurringTransactionsService: recurringTransactionsService,
+		loansService:                 loansService,
+		investmentsService:           investmentsService,
+		creditCardsService:           creditCardsService,
+		usersService:                 usersService,
 	}
 }
 
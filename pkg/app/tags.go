@@ -0,0 +1,119 @@
+package app
+
+import (
+	"github.com/gin-gonic/gin"
+	"go-personal-finance/pkg/api"
+	"net/http"
+	"strconv"
+)
+
+func (s *Server) ListTags() gin.HandlerFunc {
+	return func(context *gin.Context) {
+
+		tags, err := s.tagsService.ListTags()
+		if err != nil {
+			respondWithError(context, err)
+			return
+		}
+		context.JSON(http.StatusOK, gin.H{
+			"status": "success",
+			"data":   tags,
+		})
+	}
+}
+
+func (s *Server) AddTag() gin.HandlerFunc {
+	return func(context *gin.Context) {
+
+		tagCreationRequest := api.TagCreationRequest{}
+		if err := context.ShouldBindJSON(&tagCreationRequest); err != nil {
+			context.JSON(http.StatusBadRequest, gin.H{"status": "failure"})
+			return
+		}
+		if err := s.tagsService.CreateTag(tagCreationRequest); err != nil {
+			respondWithError(context, err)
+			return
+		}
+		context.JSON(http.StatusOK, gin.H{"status": "success"})
+	}
+}
+
+func (s *Server) RenameTag() gin.HandlerFunc {
+	return func(context *gin.Context) {
+
+		tagUpdateRequest := api.TagUpdateRequest{}
+		if err := context.ShouldBindJSON(&tagUpdateRequest); err != nil {
+			context.JSON(http.StatusBadRequest, gin.H{"status": "failure"})
+			return
+		}
+		if err := s.tagsService.RenameTag(context.Param("name"), tagUpdateRequest); err != nil {
+			respondWithError(context, err)
+			return
+		}
+		context.JSON(http.StatusOK, gin.H{"status": "success"})
+	}
+}
+
+func (s *Server) DeleteTag() gin.HandlerFunc {
+	return func(context *gin.Context) {
+
+		if err := s.tagsService.DeleteTag(context.Param("name")); err != nil {
+			respondWithError(context, err)
+			return
+		}
+		context.JSON(http.StatusOK, gin.H{"status": "success"})
+	}
+}
+
+func (s *Server) ListTransactionsByTag() gin.HandlerFunc {
+	return func(context *gin.Context) {
+
+		tag := context.Param("name")
+		transactions, err := s.transactionsService.ListTransactions(api.TransactionFilter{Tag: &tag})
+		if err != nil {
+			respondWithError(context, err)
+			return
+		}
+		context.JSON(http.StatusOK, gin.H{
+			"status": "success",
+			"data":   transactions,
+		})
+	}
+}
+
+func (s *Server) AddTransactionTag() gin.HandlerFunc {
+	return func(context *gin.Context) {
+
+		id, err := strconv.ParseInt(context.Param("id"), 10, 64)
+		if err != nil {
+			context.JSON(http.StatusBadRequest, gin.H{"status": "failure"})
+			return
+		}
+		tagCreationRequest := api.TagCreationRequest{}
+		if err = context.ShouldBindJSON(&tagCreationRequest); err != nil {
+			context.JSON(http.StatusBadRequest, gin.H{"status": "failure"})
+			return
+		}
+		if err = s.tagsService.AddTagToTransaction(id, tagCreationRequest); err != nil {
+			respondWithError(context, err)
+			return
+		}
+		context.JSON(http.StatusOK, gin.H{"status": "success"})
+	}
+}
+
+func (s *Server) RemoveTransactionTag() gin.HandlerFunc {
+	return func(context *gin.Context) {
+
+		id, err := strconv.ParseInt(context.Param("id"), 10, 64)
+		if err != nil {
+			context.JSON(http.StatusBadRequest, gin.H{"status": "failure"})
+			return
+		}
+		if err = s.tagsService.RemoveTagFromTransaction(id, context.Param("name")); err != nil {
+			respondWithError(context, err)
+			return
+		}
+		context.JSON(http.StatusOK, gin.H{"status": "success"})
+	}
+}
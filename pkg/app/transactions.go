@@ -0,0 +1,119 @@
+package app
+
+import (
+	"github.com/gin-gonic/gin"
+	"go-personal-finance/pkg/api"
+	"net/http"
+	"strconv"
+)
+
+func (s *Server) GetTransaction() gin.HandlerFunc {
+	return func(context *gin.Context) {
+
+		id, err := strconv.ParseInt(context.Param("id"), 10, 64)
+		if err != nil {
+			context.JSON(http.StatusBadRequest, gin.H{"status": "failure"})
+			return
+		}
+		transaction, err := s.transactionsService.GetTransaction(id)
+		if err != nil {
+			context.JSON(http.StatusNotFound, gin.H{"status": "failure"})
+			return
+		}
+		context.JSON(http.StatusOK, gin.H{
+			"status": "success",
+			"data":   transaction,
+		})
+	}
+}
+
+func (s *Server) ListTransactions() gin.HandlerFunc {
+	return func(context *gin.Context) {
+
+		filter := api.TransactionFilter{}
+		var err error
+		if filter.AccountId, err = parseInt64Query(context, "account_id"); err != nil {
+			respondWithError(context, err)
+			return
+		}
+		if filter.From, err = parseTimeQuery(context, "from"); err != nil {
+			respondWithError(context, err)
+			return
+		}
+		if filter.To, err = parseTimeQuery(context, "to"); err != nil {
+			respondWithError(context, err)
+			return
+		}
+		if tag, ok := context.GetQuery("tag"); ok {
+			filter.Tag = &tag
+		}
+		transactions, err := s.transactionsService.ListTransactions(filter)
+		if err != nil {
+			respondWithError(context, err)
+			return
+		}
+		context.JSON(http.StatusOK, gin.H{
+			"status": "success",
+			"data":   transactions,
+		})
+	}
+}
+
+func (s *Server) AddTransaction() gin.HandlerFunc {
+	return func(context *gin.Context) {
+
+		transactionCreationRequest := api.TransactionCreationRequest{}
+		if err := context.ShouldBindJSON(&transactionCreationRequest); err != nil {
+			context.JSON(http.StatusBadRequest, gin.H{"status": "failure"})
+			return
+		}
+		id, err := s.transactionsService.CreateTransaction(transactionCreationRequest)
+		if err != nil {
+			respondWithError(context, err)
+			return
+		}
+		context.JSON(http.StatusOK, gin.H{
+			"status": "success",
+			"data":   gin.H{"id": id},
+		})
+	}
+}
+
+func (s *Server) UpdateTransaction() gin.HandlerFunc {
+	return func(context *gin.Context) {
+
+		id, err := strconv.ParseInt(context.Param("id"), 10, 64)
+		if err != nil {
+			context.JSON(http.StatusBadRequest, gin.H{"status": "failure"})
+			return
+		}
+		transactionUpdateRequest := api.TransactionUpdateRequest{}
+		if err = context.ShouldBindJSON(&transactionUpdateRequest); err != nil {
+			context.JSON(http.StatusBadRequest, gin.H{"status": "failure"})
+			return
+		}
+		err = s.transactionsService.UpdateTransaction(id, transactionUpdateRequest)
+		if err != nil {
+			respondWithError(context, err)
+			return
+		}
+		context.JSON(http.StatusOK, gin.H{"status": "success"})
+	}
+}
+
+func (s *Server) DeleteTransaction() gin.HandlerFunc {
+	return func(context *gin.Context) {
+
+		id, err := strconv.ParseInt(context.Param("id"), 10, 64)
+		if err != nil {
+			context.JSON(http.StatusBadRequest, gin.H{"status": "failure"})
+			return
+		}
+		err = s.transactionsService.DeleteTransaction(id)
+		if err != nil {
+			respondWithError(context, err)
+			return
+		}
+		context.JSON(http.StatusOK, gin.H{"status": "success"})
+	}
+}
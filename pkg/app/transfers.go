@@ -0,0 +1,49 @@
+package app
+
+import (
+	"github.com/gin-gonic/gin"
+	"go-personal-finance/pkg/api"
+	"net/http"
+	"strconv"
+)
+
+// GetTransfer returns both legs of the transfer given the id of either of them.
+func (s *Server) GetTransfer() gin.HandlerFunc {
+	return func(context *gin.Context) {
+
+		id, err := strconv.ParseInt(context.Param("id"), 10, 64)
+		if err != nil {
+			context.JSON(http.StatusBadRequest, gin.H{"status": "failure"})
+			return
+		}
+		transfer, err := s.transactionsService.GetTransfer(id)
+		if err != nil {
+			context.JSON(http.StatusNotFound, gin.H{"status": "failure"})
+			return
+		}
+		context.JSON(http.StatusOK, gin.H{
+			"status": "success",
+			"data":   transfer,
+		})
+	}
+}
+
+func (s *Server) AddTransfer() gin.HandlerFunc {
+	return func(context *gin.Context) {
+
+		transferRequest := api.TransferRequest{}
+		if err := context.ShouldBindJSON(&transferRequest); err != nil {
+			context.JSON(http.StatusBadRequest, gin.H{"status": "failure"})
+			return
+		}
+		debitId, creditId, err := s.transactionsService.CreateTransfer(transferRequest)
+		if err != nil {
+			respondWithError(context, err)
+			return
+		}
+		context.JSON(http.StatusOK, gin.H{
+			"status": "success",
+			"data":   gin.H{"debit_transaction_id": debitId, "credit_transaction_id": creditId},
+		})
+	}
+}
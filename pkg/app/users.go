@@ -0,0 +1,159 @@
+package app
+
+import (
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"go-personal-finance/pkg/api"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// userContextKey is where RequireSession stores the signed in user for the handlers after it.
+const userContextKey = "user"
+
+// RequireSession rejects requests without a valid session token in their "Authorization: Bearer <token>" header, and
+// otherwise stores the signed in user in the context.
+func (s *Server) RequireSession() gin.HandlerFunc {
+	return func(context *gin.Context) {
+
+		header := context.GetHeader("Authorization")
+		var user api.User
+		err := fmt.Errorf("%w: a session token is required", api.ErrUnauthorized)
+		if strings.HasPrefix(header, "Bearer ") {
+			user, err = s.usersService.Authenticate(strings.TrimPrefix(header, "Bearer "), time.Now())
+		}
+		if err != nil {
+			context.Header("WWW-Authenticate", "Bearer")
+			respondWithError(context, err)
+			context.Abort()
+			return
+		}
+		context.Set(userContextKey, user)
+		context.Next()
+	}
+}
+
+func (s *Server) Login() gin.HandlerFunc {
+	return func(context *gin.Context) {
+
+		loginRequest := api.LoginRequest{}
+		if err := context.ShouldBindJSON(&loginRequest); err != nil {
+			context.JSON(http.StatusBadRequest, gin.H{"status": "failure"})
+			return
+		}
+		session, err := s.usersService.Login(loginRequest, time.Now())
+		if err != nil {
+			respondWithError(context, err)
+			return
+		}
+		context.JSON(http.StatusOK, gin.H{
+			"status": "success",
+			"data":   session,
+		})
+	}
+}
+
+func (s *Server) GetCurrentUser() gin.HandlerFunc {
+	return func(context *gin.Context) {
+
+		context.JSON(http.StatusOK, gin.H{
+			"status": "success",
+			"data":   context.MustGet(userContextKey),
+		})
+	}
+}
+
+func (s *Server) ListUsers() gin.HandlerFunc {
+	return func(context *gin.Context) {
+
+		users, err := s.usersService.ListUsers()
+		if err != nil {
+			respondWithError(context, err)
+			return
+		}
+		context.JSON(http.StatusOK, gin.H{
+			"status": "success",
+			"data":   users,
+		})
+	}
+}
+
+func (s *Server) AddUser() gin.HandlerFunc {
+	return func(context *gin.Context) {
+
+		userCreationRequest := api.UserCreationRequest{}
+		if err := context.ShouldBindJSON(&userCreationRequest); err != nil {
+			context.JSON(http.StatusBadRequest, gin.H{"status": "failure"})
+			return
+		}
+		id, err := s.usersService.CreateUser(userCreationRequest)
+		if err != nil {
+			respondWithError(context, err)
+			return
+		}
+		context.JSON(http.StatusOK, gin.H{
+			"status": "success",
+			"data":   gin.H{"id": id},
+		})
+	}
+}
+
+// requireSignedInUser rejects requests that act on a user other than the signed in one.
+func requireSignedInUser(context *gin.Context, id int64) bool {
+	if user := context.MustGet(userContextKey).(api.User); user.Id != id {
+		respondWithError(context, fmt.Errorf("%w: user %d can only act on their own account", api.ErrForbidden,
+			user.Id))
+		return false
+	}
+	return true
+}
+
+// UpdateUserPassword only changes the password of the signed in user, and ends every session of the user including
+// the one making the request.
+func (s *Server) UpdateUserPassword() gin.HandlerFunc {
+	return func(context *gin.Context) {
+
+		id, err := strconv.ParseInt(context.Param("id"), 10, 64)
+		if err != nil {
+			context.JSON(http.StatusBadRequest, gin.H{"status": "failure"})
+			return
+		}
+		if !requireSignedInUser(context, id) {
+			return
+		}
+		passwordUpdateRequest := api.PasswordUpdateRequest{}
+		if err = context.ShouldBindJSON(&passwordUpdateRequest); err != nil {
+			context.JSON(http.StatusBadRequest, gin.H{"status": "failure"})
+			return
+		}
+		err = s.usersService.UpdatePassword(id, passwordUpdateRequest)
+		if err != nil {
+			respondWithError(context, err)
+			return
+		}
+		context.JSON(http.StatusOK, gin.H{"status": "success"})
+	}
+}
+
+// DeleteUser only deletes the account of the signed in user.
+func (s *Server) DeleteUser() gin.HandlerFunc {
+	return func(context *gin.Context) {
+
+		id, err := strconv.ParseInt(context.Param("id"), 10, 64)
+		if err != nil {
+			context.JSON(http.StatusBadRequest, gin.H{"status": "failure"})
+			return
+		}
+		if !requireSignedInUser(context, id) {
+			return
+		}
+		err = s.usersService.DeleteUser(id)
+		if err != nil {
+			respondWithError(context, err)
+			return
+		}
+		context.JSON(http.StatusOK, gin.H{"status": "success"})
+	}
+}
@@ -4,10 +4,17 @@ import (
 	"database/sql"
 	"fmt"
 	"go-personal-finance/pkg/api"
+	"strings"
+	"time"
 )
 
+// maxLedgerTime is later than any transaction in the ledger.
+var maxLedgerTime = time.Date(9999, 12, 31, 23, 59, 59, 0, time.UTC)
+
 const accountsTableName = "accounts"
-const organizationsTableName = "organizations"
+
+const accountColumns = "id, name, type, subtype, org_id, opening_balance, current_balance, external_account_id, " +
+	"currency, is_deleted, created_ts, updated_ts"
 
 type accountsDAO struct {
 	db *sql.DB
@@ -17,36 +24,116 @@ func NewAccountsDAO(db *sql.DB) *accountsDAO {
 	return &accountsDAO{db}
 }
 
-func (dao *accountsDAO) GetAccount(id int64) (api.Account, error) {
-	row := dao.db.QueryRow(fmt.Sprintf("SELECT id, name, type, subtype, org_id, current_balance, is_deleted, "+
-		"created_ts, updated_ts FROM %s WHERE id = ? AND is_deleted = 0", accountsTableName), id)
+// accountSortColumns maps the sort keys accepted by the api onto columns so that user input never reaches the query.
+var accountSortColumns = map[string]string{
+	api.AccountSortByName:      "name",
+	api.AccountSortByBalance:   "current_balance",
+	api.AccountSortByCreatedTs: "created_ts",
+}
+
+func scanAccount(row rowScanner) (api.Account, error) {
 	account := api.Account{}
 	//TODO: too much dependence on order of columns. Find a better way.
-	var accountSubType sql.NullString
+	var accountSubType, externalAccountId sql.NullString
 	err := row.Scan(&account.Id, &account.Name, &account.AccountType, &accountSubType, &account.OrgId,
-		&account.CurrentBalance, &account.IsDeleted, &account.CreatedTs, &account.UpdatedTs)
+		&account.OpeningBalance, &account.CurrentBalance, &externalAccountId, &account.Currency, &account.IsDeleted,
+		&account.CreatedTs, &account.UpdatedTs)
 	if err != nil {
-		return account, fmt.Errorf("failed to retrieve account of id %d with err: %v", id, err)
+		return account, err
 	}
 	if accountSubType.Valid {
 		account.AccountSubType = accountSubType.String
 	}
+	account.ExternalAccountId = externalAccountId.String
+	return account, nil
+}
+
+func (dao *accountsDAO) GetAccount(id int64) (api.Account, error) {
+	row := dao.db.QueryRow(fmt.Sprintf("SELECT %s FROM %s WHERE id = ? AND is_deleted = 0", accountColumns,
+		accountsTableName), id)
+	account, err := scanAccount(row)
+	if err != nil {
+		return account, fmt.Errorf("failed to retrieve account of id %d with err: %v", id, err)
+	}
 
 	return account, nil
 }
+
+// ListAccounts returns the requested page of accounts along with the total number of accounts matching the filter.
+func (dao *accountsDAO) ListAccounts(filter api.AccountFilter) ([]api.Account, int64, error) {
+	var conditions []string
+	var args []interface{}
+	if !filter.IncludeDeleted {
+		conditions = append(conditions, "is_deleted = 0")
+	}
+	if filter.AccountType != nil {
+		conditions = append(conditions, "type = ?")
+		args = append(args, *filter.AccountType)
+	}
+	if filter.AccountSubType != nil {
+		conditions = append(conditions, "subtype = ?")
+		args = append(args, *filter.AccountSubType)
+	}
+	if filter.OrgId != nil {
+		conditions = append(conditions, "org_id = ?")
+		args = append(args, *filter.OrgId)
+	}
+	whereClause := ""
+	if len(conditions) > 0 {
+		whereClause = " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	var total int64
+	err := dao.db.QueryRow(fmt.Sprintf("SELECT COUNT(*) FROM %s%s", accountsTableName, whereClause), args...).
+		Scan(&total)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count accounts with err: %v", err)
+	}
+
+	sortColumn, ok := accountSortColumns[filter.SortBy]
+	if !ok {
+		sortColumn = accountSortColumns[api.AccountSortByName]
+	}
+	sortOrder := "ASC"
+	if filter.Descending {
+		sortOrder = "DESC"
+	}
+	rows, err := dao.db.Query(fmt.Sprintf("SELECT %s FROM %s%s ORDER BY %s %s, id %s LIMIT ? OFFSET ?",
+		accountColumns, accountsTableName, whereClause, sortColumn, sortOrder, sortOrder),
+		append(args, filter.Limit, filter.Offset)...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list accounts with err: %v", err)
+	}
+	defer rows.Close()
+
+	accounts := []api.Account{}
+	for rows.Next() {
+		account, err := scanAccount(rows)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to read account with err: %v", err)
+		}
+		accounts = append(accounts, account)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("failed to list accounts with err: %v", err)
+	}
+	return accounts, total, nil
+}
 func (dao *accountsDAO) InsertAccount(request api.AccountCreationRequest) (int64, error) {
 
 	var accountName = NewNullString(request.Name)
 	var accountType = NewNullString(request.AccountType)
 	var accountSubType = NewNullString(request.AccountSubType)
 	var orgId = *request.OrgId
-	var currentBalance float64
-	if request.CurrentBalance != nil {
-		currentBalance = *request.CurrentBalance
+	var openingBalance api.Money
+	if request.OpeningBalance != nil {
+		openingBalance = *request.OpeningBalance
 	}
-	result, err := dao.db.Exec(fmt.Sprintf("INSERT INTO %s (name, type, subtype, org_id, current_balance) "+
-		"VALUES(?,?,?,?,?)",
-		accountsTableName), accountName, accountType, accountSubType, orgId, currentBalance)
+	// a new account has no transactions, so its current balance is the opening balance
+	result, err := dao.db.Exec(fmt.Sprintf("INSERT INTO %s (name, type, subtype, org_id, opening_balance, "+
+		"current_balance, external_account_id, currency) VALUES(?,?,?,?,?,?,?,?)",
+		accountsTableName), accountName, accountType, accountSubType, orgId, openingBalance, openingBalance,
+		NewNullString(request.ExternalAccountId), *request.Currency)
 	if err != nil {
 		return -1, fmt.Errorf("failed to insert new account due to error %v", err)
 	}
@@ -57,8 +144,10 @@ func (dao *accountsDAO) InsertAccount(request api.AccountCreationRequest) (int64
 	return id, err
 }
 
+// DeleteAccount keeps the account and its transactions, and records the time of the deletion in updated_ts.
 func (dao *accountsDAO) DeleteAccount(id int64) error {
-	result, err := dao.db.Exec(fmt.Sprintf("UPDATE %s SET is_deleted=1 WHERE id = ? AND is_deleted = 0", accountsTableName), id)
+	result, err := dao.db.Exec(fmt.Sprintf("UPDATE %s SET is_deleted=1, updated_ts = current_timestamp "+
+		"WHERE id = ? AND is_deleted = 0", accountsTableName), id)
 	if err != nil {
 		return fmt.Errorf("failed to delete account %d due to error %v", id, err)
 	}
@@ -73,18 +162,25 @@ func (dao *accountsDAO) DeleteAccount(id int64) error {
 }
 
 // UpdateAccount assumes all values in request struct are non nil/**
+// The opening balance is only changed when present in the request, and the current balance moves along with it.
+// The currency is only changed when present in the request as well, and only while the account has neither
+// transactions nor a balance, as amounts are not converted and would change their currency.
 func (dao *accountsDAO) UpdateAccount(id int64, request api.AccountUpdateRequest) error {
+	if request.Currency != nil {
+		if err := dao.checkCurrencyChange(id, *request.Currency); err != nil {
+			return err
+		}
+	}
 	var accountName = NewNullString(request.Name)
 	var accountType = NewNullString(request.AccountType)
 	var accountSubType = NewNullString(request.AccountSubType)
 	var orgId = *request.OrgId
-	var currentBalance float64
-	if request.CurrentBalance != nil {
-		currentBalance = *request.CurrentBalance
-	}
+	var openingBalance = NewNullMoney(request.OpeningBalance)
 	result, err := dao.db.Exec(fmt.Sprintf("UPDATE %s SET name = ?, type = ?, subtype = ?, org_id = ?, "+
-		"current_balance = ?  "+
-		"WHERE id = ? AND is_deleted = 0", accountsTableName), accountName, accountType, accountSubType, orgId, currentBalance, id)
+		"current_balance = current_balance + COALESCE(?, opening_balance) - opening_balance, "+
+		"opening_balance = COALESCE(?, opening_balance), currency = COALESCE(?, currency), "+
+		"updated_ts = current_timestamp WHERE id = ? AND is_deleted = 0", accountsTableName), accountName,
+		accountType, accountSubType, orgId, openingBalance, openingBalance, NewNullString(request.Currency), id)
 	if err != nil {
 		return fmt.Errorf("failed to update account %d due to error %v", id, err)
 	}
@@ -97,3 +193,132 @@ func (dao *accountsDAO) UpdateAccount(id int64, request api.AccountUpdateRequest
 	}
 	return nil
 }
+
+// checkCurrencyChange refuses to move an account with transactions or a balance to another currency. Accounts that do
+// not exist are left for the update to report.
+func (dao *accountsDAO) checkCurrencyChange(id int64, currency string) error {
+	var current string
+	var openingBalance, currentBalance api.Money
+	var hasTransactions bool
+	err := dao.db.QueryRow(fmt.Sprintf("SELECT currency, opening_balance, current_balance, EXISTS(SELECT 1 FROM %s "+
+		"WHERE account_id = %s.id AND is_deleted = 0) FROM %s WHERE id = ? AND is_deleted = 0", transactionsTableName,
+		accountsTableName, accountsTableName), id).Scan(&current, &openingBalance, &currentBalance, &hasTransactions)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to retrieve account of id %d with err: %v", id, err)
+	}
+	if current != currency && (hasTransactions || openingBalance != 0 || currentBalance != 0) {
+		return fmt.Errorf("%w: the currency of account %d cannot be changed from %s once it has transactions or "+
+			"a balance", api.ErrInvalidRequest, id, current)
+	}
+	return nil
+}
+
+// FindAccountByExternalId returns the account linked to the account number used by the bank. The boolean is false
+// when no account is linked to it.
+func (dao *accountsDAO) FindAccountByExternalId(externalAccountId string) (api.Account, bool, error) {
+	row := dao.db.QueryRow(fmt.Sprintf("SELECT %s FROM %s WHERE external_account_id = ? AND is_deleted = 0",
+		accountColumns, accountsTableName), externalAccountId)
+	account, err := scanAccount(row)
+	if err == sql.ErrNoRows {
+		return account, false, nil
+	}
+	if err != nil {
+		return account, false, fmt.Errorf("failed to retrieve account of external id %s with err: %v",
+			externalAccountId, err)
+	}
+	return account, true, nil
+}
+
+// LinkExternalAccount moves the external account id onto the account, since only one account can be linked to it.
+func (dao *accountsDAO) LinkExternalAccount(id int64, externalAccountId string) error {
+	tx, err := dao.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin linking of account %d due to error %v", id, err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(fmt.Sprintf("UPDATE %s SET external_account_id = NULL, updated_ts = current_timestamp "+
+		"WHERE external_account_id = ? AND id != ?", accountsTableName), externalAccountId, id)
+	if err != nil {
+		return fmt.Errorf("failed to unlink external account %s due to error %v", externalAccountId, err)
+	}
+	result, err := tx.Exec(fmt.Sprintf("UPDATE %s SET external_account_id = ?, updated_ts = current_timestamp "+
+		"WHERE id = ? AND is_deleted = 0", accountsTableName), externalAccountId, id)
+	if err != nil {
+		return fmt.Errorf("failed to link account %d due to error %v", id, err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to verify if account %d has been linked due to error %v", id, err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("WARN: detected request to link non-existent account with id %d \n", id)
+	}
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit link of account %d due to error %v", id, err)
+	}
+	return nil
+}
+
+// adjustAccountBalance adds delta to the current balance of the account as part of tx.
+func adjustAccountBalance(tx *sql.Tx, accountId int64, delta api.Money) error {
+	result, err := tx.Exec(fmt.Sprintf("UPDATE %s SET current_balance = current_balance + ?, "+
+		"updated_ts = current_timestamp WHERE id = ? AND is_deleted = 0", accountsTableName), delta, accountId)
+	if err != nil {
+		return fmt.Errorf("failed to adjust balance of account %d due to error %v", accountId, err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to verify if balance of account %d has been adjusted due to error %v", accountId, err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("WARN: detected request to adjust balance of non-existent account with id %d \n", accountId)
+	}
+	return nil
+}
+
+// derivedBalanceColumn sums the ledger of the account on top of its opening balance. The only argument is the time
+// before which transactions are included.
+var derivedBalanceColumn = fmt.Sprintf("opening_balance + COALESCE((SELECT SUM(amount) FROM %s "+
+	"WHERE account_id = %s.id AND is_deleted = 0 AND transaction_time < ?), 0)", transactionsTableName,
+	accountsTableName)
+
+// GetDerivedBalance computes the balance of the account from its opening balance and the transactions made before
+// asOf.
+func (dao *accountsDAO) GetDerivedBalance(id int64, asOf time.Time) (api.Money, error) {
+	var balance api.Money
+	err := dao.db.QueryRow(fmt.Sprintf("SELECT %s FROM %s WHERE id = ? AND is_deleted = 0", derivedBalanceColumn,
+		accountsTableName), NewDBTime(asOf), id).Scan(&balance)
+	if err != nil {
+		return 0, fmt.Errorf("failed to derive balance of account %d with err: %v", id, err)
+	}
+	return balance, nil
+}
+
+// ListAccountBalances returns the stored and derived balance of every account. Future dated transactions are
+// included since they are part of the stored balance as well.
+func (dao *accountsDAO) ListAccountBalances() ([]api.AccountReconciliation, error) {
+	rows, err := dao.db.Query(fmt.Sprintf("SELECT id, name, current_balance, %s FROM %s WHERE is_deleted = 0 "+
+		"ORDER BY id", derivedBalanceColumn, accountsTableName), NewDBTime(maxLedgerTime))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list account balances with err: %v", err)
+	}
+	defer rows.Close()
+
+	balances := []api.AccountReconciliation{}
+	for rows.Next() {
+		balance := api.AccountReconciliation{}
+		err = rows.Scan(&balance.AccountId, &balance.Name, &balance.StoredBalance, &balance.DerivedBalance)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read account balance with err: %v", err)
+		}
+		balances = append(balances, balance)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list account balances with err: %v", err)
+	}
+	return balances, nil
+}
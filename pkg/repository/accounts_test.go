@@ -2,6 +2,7 @@ package repository
 
 import (
 	"database/sql"
+	"database/sql/driver"
 	"errors"
 	"github.com/DATA-DOG/go-sqlmock"
 	"go-personal-finance/pkg/api"
@@ -15,13 +16,16 @@ func TestAccountsDAO_GetAccount(t *testing.T) {
 	defer db.Close()
 	dao := accountsDAO{db: db}
 
-	expectedSelectQuery := "SELECT id, name, type, subtype, org_id, current_balance, is_deleted, created_ts, " +
-		"updated_ts FROM accounts WHERE id = ? AND is_deleted = 0"
+	expectedSelectQuery := "SELECT id, name, type, subtype, org_id, opening_balance, current_balance, " +
+		"external_account_id, currency, is_deleted, created_ts, updated_ts FROM accounts " +
+		"WHERE id = ? AND is_deleted = 0"
 
 	t.Run("testing happy flow", func(t *testing.T) {
 
-		rows := sqlmock.NewRows([]string{"id", "name", "type", "subtype", "org_id", "current_balance", "is_deleted",
-			"created_ts", "updated_ts"}).AddRow(1, "acc1", "investment", "life insurance", int64(1), 30.00, false, time.Now(), time.Now())
+		rows := sqlmock.NewRows([]string{"id", "name", "type", "subtype", "org_id", "opening_balance",
+			"current_balance", "external_account_id", "currency", "is_deleted", "created_ts", "updated_ts"}).
+			AddRow(1, "acc1", "investment", "life insurance", int64(1), 1000, 3000, "000123", "USD", false,
+				time.Now(), time.Now())
 		mock.ExpectQuery(expectedSelectQuery).WillReturnRows(rows)
 
 		account, err := dao.GetAccount(1)
@@ -61,15 +65,17 @@ func TestAccountsDAO_InsertAccount(t *testing.T) {
 	accountName := "acc1"
 	accountType := "savings"
 	orgId := int64(1)
-	currentBalance := 24.00
-	createQuery := "INSERT INTO accounts (name, type, subtype, org_id, current_balance) VALUES(?,?,?,?,?)"
+	openingBalance := api.Money(2400)
+	currency := api.DefaultCurrency
+	createQuery := "INSERT INTO accounts (name, type, subtype, org_id, opening_balance, current_balance, " +
+		"external_account_id, currency) VALUES(?,?,?,?,?,?,?,?)"
 	t.Run("testing happy flow", func(t *testing.T) {
 		mock.ExpectExec(createQuery).
-			WithArgs(accountName, accountType, nil, orgId, currentBalance).
+			WithArgs(accountName, accountType, nil, orgId, openingBalance, openingBalance, nil, "USD").
 			WillReturnResult(sqlmock.NewResult(int64(1), 1))
 
 		actualId, err := dao.InsertAccount(api.AccountCreationRequest{Name: &accountName, AccountSubType: nil,
-			AccountType: &accountType, OrgId: &orgId, CurrentBalance: &currentBalance})
+			AccountType: &accountType, OrgId: &orgId, OpeningBalance: &openingBalance, Currency: &currency})
 		if err != nil {
 			t.Errorf("Unexpected error when trying to insert account: %v", err)
 			return
@@ -85,11 +91,11 @@ func TestAccountsDAO_InsertAccount(t *testing.T) {
 
 	t.Run("testing general error flow", func(t *testing.T) {
 		mock.ExpectExec(createQuery).
-			WithArgs(accountName, accountType, nil, orgId, currentBalance).
+			WithArgs(accountName, accountType, nil, orgId, openingBalance, openingBalance, nil, "USD").
 			WillReturnError(errors.New("db timeout"))
 
 		_, err := dao.InsertAccount(api.AccountCreationRequest{Name: &accountName, AccountSubType: nil,
-			AccountType: &accountType, OrgId: &orgId, CurrentBalance: &currentBalance})
+			AccountType: &accountType, OrgId: &orgId, OpeningBalance: &openingBalance, Currency: &currency})
 		expectedErrorMsg := "failed to insert new account due to error db timeout"
 
 		if expectedErrorMsg != err.Error() {
@@ -99,6 +105,73 @@ func TestAccountsDAO_InsertAccount(t *testing.T) {
 	})
 }
 
+func TestAccountsDAO_ListAccounts(t *testing.T) {
+
+	db, mock := setupMockDB(t)
+	defer db.Close()
+	dao := accountsDAO{db: db}
+
+	accountColumns := []string{"id", "name", "type", "subtype", "org_id", "opening_balance", "current_balance",
+		"external_account_id", "currency", "is_deleted", "created_ts", "updated_ts"}
+
+	t.Run("testing filters, sorting and pagination", func(t *testing.T) {
+		accountType := "investment"
+		orgId := int64(2)
+		mock.ExpectQuery("SELECT COUNT(*) FROM accounts WHERE is_deleted = 0 AND type = ? AND org_id = ?").
+			WithArgs(accountType, orgId).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+		mock.ExpectQuery("SELECT id, name, type, subtype, org_id, opening_balance, current_balance, external_account_id, "+
+			"currency, is_deleted, created_ts, updated_ts FROM accounts WHERE is_deleted = 0 AND type = ? "+
+			"AND org_id = ? ORDER BY current_balance DESC, id DESC LIMIT ? OFFSET ?").
+			WithArgs(accountType, orgId, 2, 0).
+			WillReturnRows(sqlmock.NewRows(accountColumns).
+				AddRow(1, "acc1", accountType, "stocks", orgId, 0, 9000, nil, "EUR", false, time.Now(),
+					time.Now()).
+				AddRow(2, "acc2", accountType, nil, orgId, 0, 3000, nil, "USD", false, time.Now(),
+					time.Now()))
+
+		accounts, total, err := dao.ListAccounts(api.AccountFilter{AccountType: &accountType, OrgId: &orgId,
+			SortBy: api.AccountSortByBalance, Descending: true, Limit: 2})
+		if err != nil {
+			t.Errorf("Unexpected error when listing accounts: %v", err)
+			return
+		}
+		if total != 3 || len(accounts) != 2 {
+			t.Errorf("unexpected total %d or page size %d", total, len(accounts))
+		}
+		checkingMockExpectations(t, mock)
+	})
+
+	t.Run("testing deleted accounts can be included", func(t *testing.T) {
+		mock.ExpectQuery("SELECT COUNT(*) FROM accounts").
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+		mock.ExpectQuery("SELECT id, name, type, subtype, org_id, opening_balance, current_balance, external_account_id, "+
+			"currency, is_deleted, created_ts, updated_ts FROM accounts ORDER BY name ASC, id ASC "+
+			"LIMIT ? OFFSET ?").
+			WithArgs(10, 20).
+			WillReturnRows(sqlmock.NewRows(accountColumns))
+
+		_, _, err := dao.ListAccounts(api.AccountFilter{IncludeDeleted: true, SortBy: api.AccountSortByName,
+			Limit: 10, Offset: 20})
+		if err != nil {
+			t.Errorf("Unexpected error when listing accounts: %v", err)
+		}
+		checkingMockExpectations(t, mock)
+	})
+
+	t.Run("testing situation where we fail to count accounts", func(t *testing.T) {
+		mock.ExpectQuery("SELECT COUNT(*) FROM accounts WHERE is_deleted = 0").
+			WillReturnError(errors.New("db timeout"))
+
+		_, _, err := dao.ListAccounts(api.AccountFilter{Limit: 10})
+		expectedErrorMsg := "failed to count accounts with err: db timeout"
+		if err == nil || expectedErrorMsg != err.Error() {
+			t.Errorf("expected error message %s but found %v", expectedErrorMsg, err)
+		}
+		checkingMockExpectations(t, mock)
+	})
+}
+
 func setupMockDB(t *testing.T) (*sql.DB, sqlmock.Sqlmock) {
 	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
 	if err != nil {
@@ -115,7 +188,8 @@ func TestAccountsDAO_DeleteAccount(t *testing.T) {
 
 	t.Run("testing happy flow", func(t *testing.T) {
 		accountId := int64(1)
-		mock.ExpectExec("UPDATE accounts SET is_deleted=1 WHERE id = ? AND is_deleted = 0").
+		mock.ExpectExec("UPDATE accounts SET is_deleted=1, updated_ts = current_timestamp WHERE id = ? " +
+			"AND is_deleted = 0").
 			WithArgs(accountId).
 			WillReturnResult(sqlmock.NewResult(0, 1))
 
@@ -128,7 +202,8 @@ func TestAccountsDAO_DeleteAccount(t *testing.T) {
 
 	t.Run("testing general error flow", func(t *testing.T) {
 		accountId := int64(1)
-		mock.ExpectExec("UPDATE accounts SET is_deleted=1 WHERE id = ? AND is_deleted = 0").
+		mock.ExpectExec("UPDATE accounts SET is_deleted=1, updated_ts = current_timestamp WHERE id = ? " +
+			"AND is_deleted = 0").
 			WithArgs(accountId).
 			WillReturnError(errors.New("db timeout"))
 
@@ -141,7 +216,8 @@ func TestAccountsDAO_DeleteAccount(t *testing.T) {
 
 	t.Run("testing deletion of non existent account", func(t *testing.T) {
 		accountId := int64(1)
-		mock.ExpectExec("UPDATE accounts SET is_deleted=1 WHERE id = ? AND is_deleted = 0").
+		mock.ExpectExec("UPDATE accounts SET is_deleted=1, updated_ts = current_timestamp WHERE id = ? " +
+			"AND is_deleted = 0").
 			WithArgs(accountId).
 			WillReturnResult(sqlmock.NewResult(0, 0))
 
@@ -164,19 +240,22 @@ func TestAccountsDAO_UpdateAccount(t *testing.T) {
 	accountName := "acc1"
 	accountType := "savings"
 	orgId := int64(1)
-	currentBalance := 24.00
+	openingBalance := api.Money(2400)
 
-	expectedUpdateQuery := "UPDATE accounts SET name = ?, type = ?, subtype = ?, org_id = ?, current_balance = ? " +
+	expectedUpdateQuery := "UPDATE accounts SET name = ?, type = ?, subtype = ?, org_id = ?, " +
+		"current_balance = current_balance + COALESCE(?, opening_balance) - opening_balance, " +
+		"opening_balance = COALESCE(?, opening_balance), currency = COALESCE(?, currency), " +
+		"updated_ts = current_timestamp " +
 		"WHERE id = ? AND is_deleted = 0"
 	dao := accountsDAO{db: db}
 
 	t.Run("testing happy flow of updating account", func(t *testing.T) {
 		mock.ExpectExec(expectedUpdateQuery).
-			WithArgs(accountName, accountType, nil, orgId, currentBalance, accountId).
+			WithArgs(accountName, accountType, nil, orgId, openingBalance, openingBalance, nil, accountId).
 			WillReturnResult(sqlmock.NewResult(0, 1))
 
 		err := dao.UpdateAccount(accountId, api.AccountUpdateRequest{AccountType: &accountType, Name: &accountName,
-			AccountSubType: nil, OrgId: &orgId, CurrentBalance: &currentBalance})
+			AccountSubType: nil, OrgId: &orgId, OpeningBalance: &openingBalance})
 		if err != nil {
 			t.Errorf("Unexpected error when trying to update account: %v", err)
 			return
@@ -187,11 +266,11 @@ func TestAccountsDAO_UpdateAccount(t *testing.T) {
 
 	t.Run("testing generic error handling flows of updating an account", func(t *testing.T) {
 		mock.ExpectExec(expectedUpdateQuery).
-			WithArgs(accountName, accountType, nil, orgId, currentBalance, accountId).
+			WithArgs(accountName, accountType, nil, orgId, openingBalance, openingBalance, nil, accountId).
 			WillReturnError(errors.New("db timeout"))
 
 		err := dao.UpdateAccount(accountId, api.AccountUpdateRequest{AccountType: &accountType, Name: &accountName,
-			AccountSubType: nil, OrgId: &orgId, CurrentBalance: &currentBalance})
+			AccountSubType: nil, OrgId: &orgId, OpeningBalance: &openingBalance})
 		expectedErrorMsg := "failed to update account 1 due to error db timeout"
 
 		if expectedErrorMsg != err.Error() {
@@ -203,11 +282,11 @@ func TestAccountsDAO_UpdateAccount(t *testing.T) {
 
 	t.Run("testing situation when account doesn't exist with that id", func(t *testing.T) {
 		mock.ExpectExec(expectedUpdateQuery).
-			WithArgs(accountName, accountType, nil, orgId, currentBalance, accountId).
+			WithArgs(accountName, accountType, nil, orgId, openingBalance, openingBalance, nil, accountId).
 			WillReturnResult(sqlmock.NewResult(0, 0))
 
 		err := dao.UpdateAccount(accountId, api.AccountUpdateRequest{AccountType: &accountType, Name: &accountName,
-			AccountSubType: nil, OrgId: &orgId, CurrentBalance: &currentBalance})
+			AccountSubType: nil, OrgId: &orgId, OpeningBalance: &openingBalance})
 		expectedErrorMsg := "WARN: detected request to update non-existent account with id 1 \n"
 
 		if expectedErrorMsg != err.Error() {
@@ -219,11 +298,11 @@ func TestAccountsDAO_UpdateAccount(t *testing.T) {
 
 	t.Run("testing situation when account hasn't changed due to some anomaly", func(t *testing.T) {
 		mock.ExpectExec(expectedUpdateQuery).
-			WithArgs(accountName, accountType, nil, orgId, currentBalance, accountId).
+			WithArgs(accountName, accountType, nil, orgId, openingBalance, openingBalance, nil, accountId).
 			WillReturnResult(sqlmock.NewResult(0, 0))
 
 		err := dao.UpdateAccount(accountId, api.AccountUpdateRequest{AccountType: &accountType, Name: &accountName,
-			AccountSubType: nil, OrgId: &orgId, CurrentBalance: &currentBalance})
+			AccountSubType: nil, OrgId: &orgId, OpeningBalance: &openingBalance})
 		expectedErrorMsg := "WARN: detected request to update non-existent account with id 1 \n"
 
 		if expectedErrorMsg != err.Error() {
@@ -232,6 +311,44 @@ func TestAccountsDAO_UpdateAccount(t *testing.T) {
 
 		checkingMockExpectations(t, mock)
 	})
+
+	currencyQuery := "SELECT currency, opening_balance, current_balance, EXISTS(SELECT 1 FROM transactions " +
+		"WHERE account_id = accounts.id AND is_deleted = 0) FROM accounts WHERE id = ? AND is_deleted = 0"
+	currencyColumns := []string{"currency", "opening_balance", "current_balance", "has_transactions"}
+	euros := "EUR"
+
+	t.Run("testing the currency of an empty account can be changed", func(t *testing.T) {
+		mock.ExpectQuery(currencyQuery).
+			WithArgs(accountId).
+			WillReturnRows(sqlmock.NewRows(currencyColumns).AddRow("USD", 0, 0, false))
+		mock.ExpectExec(expectedUpdateQuery).
+			WithArgs(accountName, accountType, nil, orgId, nil, nil, euros, accountId).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		err := dao.UpdateAccount(accountId, api.AccountUpdateRequest{AccountType: &accountType, Name: &accountName,
+			OrgId: &orgId, Currency: &euros})
+		if err != nil {
+			t.Errorf("Unexpected error when trying to update account: %v", err)
+		}
+		checkingMockExpectations(t, mock)
+	})
+
+	t.Run("testing the currency of an account with transactions or a balance cannot be changed", func(t *testing.T) {
+		for _, row := range [][]driver.Value{{"USD", 0, 0, true}, {"USD", 2400, 2400, false}, {"USD", 0, -500, false}} {
+			mock.ExpectQuery(currencyQuery).
+				WithArgs(accountId).
+				WillReturnRows(sqlmock.NewRows(currencyColumns).AddRow(row...))
+
+			err := dao.UpdateAccount(accountId, api.AccountUpdateRequest{AccountType: &accountType,
+				Name: &accountName, OrgId: &orgId, Currency: &euros})
+			expectedErrorMsg := "invalid request: the currency of account 1 cannot be changed from USD once it has " +
+				"transactions or a balance"
+			if err == nil || expectedErrorMsg != err.Error() {
+				t.Errorf("expected error message %s but found %v", expectedErrorMsg, err)
+			}
+		}
+		checkingMockExpectations(t, mock)
+	})
 }
 
 func checkingMockExpectations(t *testing.T, mock sqlmock.Sqlmock) {
@@ -240,3 +357,153 @@ func checkingMockExpectations(t *testing.T, mock sqlmock.Sqlmock) {
 		t.Errorf("expected query not found with err: %v", err)
 	}
 }
+
+const expectedAdjustBalanceQuery = "UPDATE accounts SET current_balance = current_balance + ?, " +
+	"updated_ts = current_timestamp WHERE id = ? AND is_deleted = 0"
+
+const expectedDerivedBalanceColumn = "opening_balance + COALESCE((SELECT SUM(amount) FROM transactions " +
+	"WHERE account_id = accounts.id AND is_deleted = 0 AND transaction_time < ?), 0)"
+
+func TestAccountsDAO_GetDerivedBalance(t *testing.T) {
+
+	db, mock := setupMockDB(t)
+	defer db.Close()
+	dao := accountsDAO{db: db}
+
+	asOf := time.Date(2022, 7, 1, 0, 0, 0, 0, time.UTC)
+	expectedSelectQuery := "SELECT " + expectedDerivedBalanceColumn + " FROM accounts WHERE id = ? AND is_deleted = 0"
+
+	t.Run("testing happy flow", func(t *testing.T) {
+		mock.ExpectQuery(expectedSelectQuery).
+			WithArgs(asOf, int64(1)).
+			WillReturnRows(sqlmock.NewRows([]string{"balance"}).AddRow(4250))
+
+		balance, err := dao.GetDerivedBalance(1, asOf)
+		if err != nil {
+			t.Errorf("Unexpected error when deriving balance: %v", err)
+			return
+		}
+		if balance != 4250 {
+			t.Errorf("unexpected balance %s", balance)
+		}
+		checkingMockExpectations(t, mock)
+	})
+
+	t.Run("testing situation where account doesn't exist", func(t *testing.T) {
+		mock.ExpectQuery(expectedSelectQuery).WillReturnError(sql.ErrNoRows)
+
+		_, err := dao.GetDerivedBalance(1, asOf)
+		expectedErrorMsg := "failed to derive balance of account 1 with err: sql: no rows in result set"
+		if err == nil || expectedErrorMsg != err.Error() {
+			t.Errorf("expected error message %s but found %v", expectedErrorMsg, err)
+		}
+		checkingMockExpectations(t, mock)
+	})
+}
+
+func TestAccountsDAO_ListAccountBalances(t *testing.T) {
+
+	db, mock := setupMockDB(t)
+	defer db.Close()
+	dao := accountsDAO{db: db}
+
+	t.Run("testing happy flow", func(t *testing.T) {
+		mock.ExpectQuery("SELECT id, name, current_balance, " + expectedDerivedBalanceColumn + " FROM accounts " +
+			"WHERE is_deleted = 0 ORDER BY id").
+			WithArgs(maxLedgerTime).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name", "current_balance", "derived_balance"}).
+				AddRow(1, "acc1", 5000, 5000).
+				AddRow(2, "acc2", 7500, 7000))
+
+		balances, err := dao.ListAccountBalances()
+		if err != nil {
+			t.Errorf("Unexpected error when listing balances: %v", err)
+			return
+		}
+		if len(balances) != 2 || balances[1].StoredBalance != 7500 || balances[1].DerivedBalance != 7000 {
+			t.Errorf("unexpected balances %+v", balances)
+		}
+		checkingMockExpectations(t, mock)
+	})
+}
+
+func TestAccountsDAO_FindAccountByExternalId(t *testing.T) {
+
+	db, mock := setupMockDB(t)
+	defer db.Close()
+	dao := accountsDAO{db: db}
+
+	expectedSelectQuery := "SELECT id, name, type, subtype, org_id, opening_balance, current_balance, " +
+		"external_account_id, currency, is_deleted, created_ts, updated_ts FROM accounts " +
+		"WHERE external_account_id = ? AND is_deleted = 0"
+	accountColumns := []string{"id", "name", "type", "subtype", "org_id", "opening_balance", "current_balance",
+		"external_account_id", "currency", "is_deleted", "created_ts", "updated_ts"}
+
+	t.Run("testing happy flow", func(t *testing.T) {
+		mock.ExpectQuery(expectedSelectQuery).
+			WithArgs("000123").
+			WillReturnRows(sqlmock.NewRows(accountColumns).AddRow(3, "checking", "checking", nil, int64(1), 0,
+				1000, "000123", "USD", false, time.Now(), time.Now()))
+
+		account, found, err := dao.FindAccountByExternalId("000123")
+		if err != nil {
+			t.Errorf("Unexpected error when finding account: %v", err)
+			return
+		}
+		if !found || account.Id != 3 || account.ExternalAccountId != "000123" {
+			t.Errorf("unexpected account %+v found: %t", account, found)
+		}
+		checkingMockExpectations(t, mock)
+	})
+
+	t.Run("testing situation where no account is linked", func(t *testing.T) {
+		mock.ExpectQuery(expectedSelectQuery).
+			WithArgs("000123").
+			WillReturnRows(sqlmock.NewRows(accountColumns))
+
+		_, found, err := dao.FindAccountByExternalId("000123")
+		if err != nil || found {
+			t.Errorf("expected no account but found: %t with err %v", found, err)
+		}
+		checkingMockExpectations(t, mock)
+	})
+}
+
+func TestAccountsDAO_LinkExternalAccount(t *testing.T) {
+
+	db, mock := setupMockDB(t)
+	defer db.Close()
+	dao := accountsDAO{db: db}
+
+	unlinkQuery := "UPDATE accounts SET external_account_id = NULL, updated_ts = current_timestamp " +
+		"WHERE external_account_id = ? AND id != ?"
+	linkQuery := "UPDATE accounts SET external_account_id = ?, updated_ts = current_timestamp " +
+		"WHERE id = ? AND is_deleted = 0"
+
+	t.Run("testing happy flow", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec(unlinkQuery).WithArgs("000123", int64(3)).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(linkQuery).WithArgs("000123", int64(3)).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		err := dao.LinkExternalAccount(3, "000123")
+		if err != nil {
+			t.Errorf("Unexpected error when linking account: %v", err)
+		}
+		checkingMockExpectations(t, mock)
+	})
+
+	t.Run("testing link of non existent account", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec(unlinkQuery).WithArgs("000123", int64(3)).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(linkQuery).WithArgs("000123", int64(3)).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectRollback()
+
+		err := dao.LinkExternalAccount(3, "000123")
+		expectedErrorMsg := "WARN: detected request to link non-existent account with id 3 \n"
+		if err == nil || expectedErrorMsg != err.Error() {
+			t.Errorf("expected error message %s but found %v", expectedErrorMsg, err)
+		}
+		checkingMockExpectations(t, mock)
+	})
+}
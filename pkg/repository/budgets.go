@@ -0,0 +1,107 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"go-personal-finance/pkg/api"
+)
+
+const budgetsTableName = "budgets"
+
+const budgetColumns = "id, category_id, amount, rollover, start_month, is_deleted, created_ts, updated_ts"
+
+type budgetsDAO struct {
+	db *sql.DB
+}
+
+func NewBudgetsDAO(db *sql.DB) *budgetsDAO {
+	return &budgetsDAO{db}
+}
+
+func scanBudget(row rowScanner) (api.Budget, error) {
+	budget := api.Budget{}
+	err := row.Scan(&budget.Id, &budget.CategoryId, &budget.Amount, &budget.Rollover, &budget.StartMonth,
+		&budget.IsDeleted, &budget.CreatedTs, &budget.UpdatedTs)
+	return budget, err
+}
+
+func (dao *budgetsDAO) GetBudget(id int64) (api.Budget, error) {
+	row := dao.db.QueryRow(fmt.Sprintf("SELECT %s FROM %s WHERE id = ? AND is_deleted = 0", budgetColumns,
+		budgetsTableName), id)
+	budget, err := scanBudget(row)
+	if err != nil {
+		return budget, fmt.Errorf("failed to retrieve budget of id %d with err: %v", id, err)
+	}
+	return budget, nil
+}
+
+func (dao *budgetsDAO) ListBudgets() ([]api.Budget, error) {
+	rows, err := dao.db.Query(fmt.Sprintf("SELECT %s FROM %s WHERE is_deleted = 0 ORDER BY id", budgetColumns,
+		budgetsTableName))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list budgets with err: %v", err)
+	}
+	defer rows.Close()
+
+	budgets := []api.Budget{}
+	for rows.Next() {
+		budget, err := scanBudget(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read budget with err: %v", err)
+		}
+		budgets = append(budgets, budget)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list budgets with err: %v", err)
+	}
+	return budgets, nil
+}
+
+// InsertBudget assumes the defaults of the request have been filled in by the service.
+func (dao *budgetsDAO) InsertBudget(request api.BudgetRequest) (int64, error) {
+	result, err := dao.db.Exec(fmt.Sprintf("INSERT INTO %s (category_id, amount, rollover, start_month) "+
+		"VALUES(?,?,?,?)", budgetsTableName), *request.CategoryId, *request.Amount, *request.Rollover,
+		*request.StartMonth)
+	if err != nil {
+		return -1, fmt.Errorf("failed to insert new budget due to error %v", err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return -1, fmt.Errorf("failed to retrieve last inserted id %v", err)
+	}
+	return id, nil
+}
+
+// UpdateBudget assumes the defaults of the request have been filled in by the service.
+func (dao *budgetsDAO) UpdateBudget(id int64, request api.BudgetRequest) error {
+	result, err := dao.db.Exec(fmt.Sprintf("UPDATE %s SET category_id = ?, amount = ?, rollover = ?, "+
+		"start_month = ?, updated_ts = current_timestamp WHERE id = ? AND is_deleted = 0", budgetsTableName),
+		*request.CategoryId, *request.Amount, *request.Rollover, *request.StartMonth, id)
+	if err != nil {
+		return fmt.Errorf("failed to update budget %d due to error %v", id, err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to verify if budget %d has been updated due to error %v", id, err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("WARN: detected request to update non-existent budget with id %d \n", id)
+	}
+	return nil
+}
+
+func (dao *budgetsDAO) DeleteBudget(id int64) error {
+	result, err := dao.db.Exec(fmt.Sprintf("UPDATE %s SET is_deleted = 1, updated_ts = current_timestamp "+
+		"WHERE id = ? AND is_deleted = 0", budgetsTableName), id)
+	if err != nil {
+		return fmt.Errorf("failed to delete budget %d due to error %v", id, err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to verify if budget %d has been deleted due to error %v", id, err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("WARN: detected request to delete non-existent budget with id %d \n", id)
+	}
+	return nil
+}
@@ -0,0 +1,182 @@
+package repository
+
+import (
+	"errors"
+	"github.com/DATA-DOG/go-sqlmock"
+	"go-personal-finance/pkg/api"
+	"testing"
+	"time"
+)
+
+const expectedBudgetColumns = "id, category_id, amount, rollover, start_month, is_deleted, created_ts, updated_ts"
+
+var budgetRowColumns = []string{"id", "category_id", "amount", "rollover", "start_month", "is_deleted",
+	"created_ts", "updated_ts"}
+
+func TestBudgetsDAO_GetBudget(t *testing.T) {
+
+	db, mock := setupMockDB(t)
+	defer db.Close()
+	dao := budgetsDAO{db: db}
+
+	expectedSelectQuery := "SELECT " + expectedBudgetColumns + " FROM budgets WHERE id = ? AND is_deleted = 0"
+
+	t.Run("testing happy flow", func(t *testing.T) {
+		rows := sqlmock.NewRows(budgetRowColumns).AddRow(1, 2, 40000, true, "2022-07", false, time.Now(), time.Now())
+		mock.ExpectQuery(expectedSelectQuery).WithArgs(int64(1)).WillReturnRows(rows)
+
+		budget, err := dao.GetBudget(1)
+		if err != nil {
+			t.Errorf("Unexpected error when retrieving budget: %v", err)
+			return
+		}
+		if budget.CategoryId != 2 || budget.Amount != 40000 || !budget.Rollover || budget.StartMonth != "2022-07" {
+			t.Errorf("unexpected budget %+v", budget)
+		}
+		checkingMockExpectations(t, mock)
+	})
+
+	t.Run("testing situation where we fail to retrieve budget", func(t *testing.T) {
+		mock.ExpectQuery(expectedSelectQuery).WillReturnError(errors.New("db timeout"))
+
+		_, err := dao.GetBudget(1)
+		expectedErrorMsg := "failed to retrieve budget of id 1 with err: db timeout"
+		if err == nil || expectedErrorMsg != err.Error() {
+			t.Errorf("expected error message %s but found %v", expectedErrorMsg, err)
+		}
+		checkingMockExpectations(t, mock)
+	})
+}
+
+func TestBudgetsDAO_ListBudgets(t *testing.T) {
+
+	db, mock := setupMockDB(t)
+	defer db.Close()
+	dao := budgetsDAO{db: db}
+
+	t.Run("testing happy flow", func(t *testing.T) {
+		rows := sqlmock.NewRows(budgetRowColumns).
+			AddRow(1, 2, 40000, true, "2022-07", false, time.Now(), time.Now()).
+			AddRow(2, 4, 2000, false, "2022-06", false, time.Now(), time.Now())
+		mock.ExpectQuery("SELECT " + expectedBudgetColumns + " FROM budgets WHERE is_deleted = 0 ORDER BY id").
+			WillReturnRows(rows)
+
+		budgets, err := dao.ListBudgets()
+		if err != nil {
+			t.Errorf("Unexpected error when listing budgets: %v", err)
+			return
+		}
+		if len(budgets) != 2 || budgets[1].Rollover {
+			t.Errorf("unexpected budgets %+v", budgets)
+		}
+		checkingMockExpectations(t, mock)
+	})
+}
+
+func TestBudgetsDAO_InsertBudget(t *testing.T) {
+
+	db, mock := setupMockDB(t)
+	defer db.Close()
+	dao := budgetsDAO{db: db}
+
+	categoryId := int64(2)
+	amount := api.Money(40000)
+	rollover := true
+	startMonth := "2022-07"
+	insertQuery := "INSERT INTO budgets (category_id, amount, rollover, start_month) VALUES(?,?,?,?)"
+	request := api.BudgetRequest{CategoryId: &categoryId, Amount: &amount, Rollover: &rollover,
+		StartMonth: &startMonth}
+
+	t.Run("testing happy flow", func(t *testing.T) {
+		mock.ExpectExec(insertQuery).WithArgs(categoryId, amount, rollover, startMonth).
+			WillReturnResult(sqlmock.NewResult(int64(3), 1))
+
+		id, err := dao.InsertBudget(request)
+		if err != nil {
+			t.Errorf("Unexpected error when trying to insert budget: %v", err)
+			return
+		}
+		if id != 3 {
+			t.Errorf("unexpected id returned")
+		}
+		checkingMockExpectations(t, mock)
+	})
+
+	t.Run("testing general error flow", func(t *testing.T) {
+		mock.ExpectExec(insertQuery).WillReturnError(errors.New("db timeout"))
+
+		_, err := dao.InsertBudget(request)
+		expectedErrorMsg := "failed to insert new budget due to error db timeout"
+		if err == nil || expectedErrorMsg != err.Error() {
+			t.Errorf("expected error message %s but found %v", expectedErrorMsg, err)
+		}
+		checkingMockExpectations(t, mock)
+	})
+}
+
+func TestBudgetsDAO_UpdateBudget(t *testing.T) {
+
+	db, mock := setupMockDB(t)
+	defer db.Close()
+	dao := budgetsDAO{db: db}
+
+	categoryId := int64(2)
+	amount := api.Money(45000)
+	rollover := false
+	startMonth := "2022-08"
+	updateQuery := "UPDATE budgets SET category_id = ?, amount = ?, rollover = ?, start_month = ?, " +
+		"updated_ts = current_timestamp WHERE id = ? AND is_deleted = 0"
+	request := api.BudgetRequest{CategoryId: &categoryId, Amount: &amount, Rollover: &rollover,
+		StartMonth: &startMonth}
+
+	t.Run("testing happy flow", func(t *testing.T) {
+		mock.ExpectExec(updateQuery).WithArgs(categoryId, amount, rollover, startMonth, int64(1)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		err := dao.UpdateBudget(1, request)
+		if err != nil {
+			t.Errorf("Unexpected error when trying to update budget: %v", err)
+		}
+		checkingMockExpectations(t, mock)
+	})
+
+	t.Run("testing situation when budget doesn't exist with that id", func(t *testing.T) {
+		mock.ExpectExec(updateQuery).WillReturnResult(sqlmock.NewResult(0, 0))
+
+		err := dao.UpdateBudget(1, request)
+		expectedErrorMsg := "WARN: detected request to update non-existent budget with id 1 \n"
+		if err == nil || expectedErrorMsg != err.Error() {
+			t.Errorf("expected error message %s but found %v", expectedErrorMsg, err)
+		}
+		checkingMockExpectations(t, mock)
+	})
+}
+
+func TestBudgetsDAO_DeleteBudget(t *testing.T) {
+
+	db, mock := setupMockDB(t)
+	defer db.Close()
+	dao := budgetsDAO{db: db}
+	deleteQuery := "UPDATE budgets SET is_deleted = 1, updated_ts = current_timestamp WHERE id = ? AND is_deleted = 0"
+
+	t.Run("testing happy flow", func(t *testing.T) {
+		mock.ExpectExec(deleteQuery).WithArgs(int64(1)).WillReturnResult(sqlmock.NewResult(0, 1))
+
+		err := dao.DeleteBudget(1)
+		if err != nil {
+			t.Errorf("Unexpected error when trying to delete budget: %v", err)
+		}
+	})
+
+	t.Run("testing deletion of non existent budget", func(t *testing.T) {
+		mock.ExpectExec(deleteQuery).WithArgs(int64(1)).WillReturnResult(sqlmock.NewResult(0, 0))
+
+		err := dao.DeleteBudget(1)
+		expectedErrorMsg := "WARN: detected request to delete non-existent budget with id 1 \n"
+		if err == nil || expectedErrorMsg != err.Error() {
+			t.Errorf("expected error message %s but found %v", expectedErrorMsg, err)
+		}
+	})
+
+	checkingMockExpectations(t, mock)
+}
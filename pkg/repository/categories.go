@@ -0,0 +1,209 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"go-personal-finance/pkg/api"
+	"strings"
+	"time"
+)
+
+const categoriesTableName = "categories"
+
+type categoriesDAO struct {
+	db *sql.DB
+}
+
+func NewCategoriesDAO(db *sql.DB) *categoriesDAO {
+	return &categoriesDAO{db}
+}
+
+func scanCategory(row rowScanner) (api.Category, error) {
+	category := api.Category{}
+	var parentId sql.NullInt64
+	err := row.Scan(&category.Id, &category.Name, &parentId, &category.IsDeleted, &category.CreatedTs,
+		&category.UpdatedTs)
+	category.ParentId = parentId.Int64
+	return category, err
+}
+
+func (dao *categoriesDAO) GetCategory(id int64) (api.Category, error) {
+	row := dao.db.QueryRow(fmt.Sprintf("SELECT id, name, parent, is_deleted, created_ts, updated_ts FROM %s "+
+		"WHERE id = ? AND is_deleted = 0", categoriesTableName), id)
+	category, err := scanCategory(row)
+	if err != nil {
+		return category, fmt.Errorf("failed to retrieve category of id %d with err: %v", id, err)
+	}
+	return category, nil
+}
+
+func (dao *categoriesDAO) ListCategories() ([]api.Category, error) {
+	rows, err := dao.db.Query(fmt.Sprintf("SELECT id, name, parent, is_deleted, created_ts, updated_ts FROM %s "+
+		"WHERE is_deleted = 0 ORDER BY name", categoriesTableName))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list categories with err: %v", err)
+	}
+	defer rows.Close()
+
+	categories := []api.Category{}
+	for rows.Next() {
+		category, err := scanCategory(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read category with err: %v", err)
+		}
+		categories = append(categories, category)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list categories with err: %v", err)
+	}
+	return categories, nil
+}
+
+func (dao *categoriesDAO) InsertCategory(request api.CategoryCreationRequest) (int64, error) {
+	result, err := dao.db.Exec(fmt.Sprintf("INSERT INTO %s (name, parent) VALUES(?,?)", categoriesTableName),
+		*request.Name, NewNullInt64(request.ParentId))
+	if err != nil {
+		return -1, fmt.Errorf("failed to insert new category due to error %v", err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return -1, fmt.Errorf("failed to retrieve last inserted id %v", err)
+	}
+	return id, nil
+}
+
+func (dao *categoriesDAO) UpdateCategory(id int64, request api.CategoryUpdateRequest) error {
+	result, err := dao.db.Exec(fmt.Sprintf("UPDATE %s SET name = ?, updated_ts = current_timestamp "+
+		"WHERE id = ? AND is_deleted = 0", categoriesTableName), *request.Name, id)
+	if err != nil {
+		return fmt.Errorf("failed to update category %d due to error %v", id, err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to verify if category %d has been updated due to error %v", id, err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("WARN: detected request to update non-existent category with id %d \n", id)
+	}
+	return nil
+}
+
+func (dao *categoriesDAO) MoveCategory(id int64, parentId *int64) error {
+	result, err := dao.db.Exec(fmt.Sprintf("UPDATE %s SET parent = ?, updated_ts = current_timestamp "+
+		"WHERE id = ? AND is_deleted = 0", categoriesTableName), NewNullInt64(parentId), id)
+	if err != nil {
+		return fmt.Errorf("failed to move category %d due to error %v", id, err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to verify if category %d has been moved due to error %v", id, err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("WARN: detected request to move non-existent category with id %d \n", id)
+	}
+	return nil
+}
+
+// DeleteCategory soft deletes the category and hands its children over to its parent within one transaction.
+func (dao *categoriesDAO) DeleteCategory(id int64) error {
+	tx, err := dao.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin deletion of category %d due to error %v", id, err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(fmt.Sprintf("UPDATE %s SET is_deleted=1 WHERE id = ? AND is_deleted = 0",
+		categoriesTableName), id)
+	if err != nil {
+		return fmt.Errorf("failed to delete category %d due to error %v", id, err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to verify if category %d has been deleted due to error %v", id, err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("WARN: detected request to delete non-existent category with id %d \n", id)
+	}
+	_, err = tx.Exec(fmt.Sprintf("UPDATE %s SET parent = (SELECT parent FROM %s WHERE id = ?), "+
+		"updated_ts = current_timestamp WHERE parent = ? AND is_deleted = 0", categoriesTableName,
+		categoriesTableName), id, id)
+	if err != nil {
+		return fmt.Errorf("failed to re-parent children of category %d due to error %v", id, err)
+	}
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit deletion of category %d due to error %v", id, err)
+	}
+	return nil
+}
+
+// SumAmountsByCategory totals the amounts of non deleted transactions per category over [from, to), counting split
+// transactions by their splits.
+func (dao *categoriesDAO) SumAmountsByCategory(from *time.Time, to *time.Time) (map[int64]api.Money, error) {
+	conditions := []string{"is_deleted = 0", "category_id IS NOT NULL"}
+	var args []interface{}
+	if from != nil {
+		conditions = append(conditions, "transaction_time >= ?")
+		args = append(args, NewDBTime(*from))
+	}
+	if to != nil {
+		conditions = append(conditions, "transaction_time < ?")
+		args = append(args, NewDBTime(*to))
+	}
+	rows, err := dao.db.Query(fmt.Sprintf("SELECT category_id, SUM(amount) FROM %s WHERE %s GROUP BY category_id",
+		splitTransactions, strings.Join(conditions, " AND ")), args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sum transactions by category with err: %v", err)
+	}
+	defer rows.Close()
+
+	totals := map[int64]api.Money{}
+	for rows.Next() {
+		var categoryId int64
+		var total api.Money
+		if err = rows.Scan(&categoryId, &total); err != nil {
+			return nil, fmt.Errorf("failed to read category total with err: %v", err)
+		}
+		totals[categoryId] = total
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to sum transactions by category with err: %v", err)
+	}
+	return totals, nil
+}
+
+// SumAmountsByCategoryAndCurrency totals the amounts of non deleted transactions per category, currency and day over
+// [from, to), counting split transactions by their splits. Amounts are in the currency of their account, like the
+// balances are.
+func (dao *categoriesDAO) SumAmountsByCategoryAndCurrency(from *time.Time,
+	to *time.Time) ([]api.CategoryCurrencyTotal, error) {
+	conditions := []string{"t.is_deleted = 0", "t.category_id IS NOT NULL"}
+	var args []interface{}
+	if from != nil {
+		conditions = append(conditions, "t.transaction_time >= ?")
+		args = append(args, NewDBTime(*from))
+	}
+	if to != nil {
+		conditions = append(conditions, "t.transaction_time < ?")
+		args = append(args, NewDBTime(*to))
+	}
+	rows, err := dao.db.Query(fmt.Sprintf("SELECT t.category_id, a.currency, "+
+		"substr(t.transaction_time, 1, 10), SUM(t.amount) FROM %s t JOIN %s a ON a.id = t.account_id WHERE %s "+
+		"GROUP BY 1, 2, 3", splitTransactions, accountsTableName, strings.Join(conditions, " AND ")), args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sum transactions by category with err: %v", err)
+	}
+	defer rows.Close()
+
+	var totals []api.CategoryCurrencyTotal
+	for rows.Next() {
+		var total api.CategoryCurrencyTotal
+		if err = rows.Scan(&total.CategoryId, &total.Currency, &total.Day, &total.Amount); err != nil {
+			return nil, fmt.Errorf("failed to read category total with err: %v", err)
+		}
+		totals = append(totals, total)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to sum transactions by category with err: %v", err)
+	}
+	return totals, nil
+}
@@ -0,0 +1,157 @@
+package repository
+
+import (
+	"errors"
+	"github.com/DATA-DOG/go-sqlmock"
+	"go-personal-finance/pkg/api"
+	"testing"
+	"time"
+)
+
+func TestCategoriesDAO_ListCategories(t *testing.T) {
+
+	db, mock := setupMockDB(t)
+	defer db.Close()
+	dao := categoriesDAO{db: db}
+
+	rows := sqlmock.NewRows([]string{"id", "name", "parent", "is_deleted", "created_ts", "updated_ts"}).
+		AddRow(1, "Food", nil, false, time.Now(), time.Now()).
+		AddRow(2, "Groceries", 1, false, time.Now(), time.Now())
+	mock.ExpectQuery("SELECT id, name, parent, is_deleted, created_ts, updated_ts FROM categories " +
+		"WHERE is_deleted = 0 ORDER BY name").WillReturnRows(rows)
+
+	categories, err := dao.ListCategories()
+	if err != nil {
+		t.Errorf("Unexpected error when listing categories: %v", err)
+		return
+	}
+	if len(categories) != 2 || categories[0].ParentId != 0 || categories[1].ParentId != 1 {
+		t.Errorf("unexpected categories returned %v", categories)
+	}
+	checkingMockExpectations(t, mock)
+}
+
+func TestCategoriesDAO_InsertCategory(t *testing.T) {
+
+	db, mock := setupMockDB(t)
+	defer db.Close()
+	dao := categoriesDAO{db: db}
+	name := "Food"
+
+	mock.ExpectExec("INSERT INTO categories (name, parent) VALUES(?,?)").
+		WithArgs(name, nil).
+		WillReturnResult(sqlmock.NewResult(int64(1), 1))
+
+	id, err := dao.InsertCategory(api.CategoryCreationRequest{Name: &name})
+	if err != nil || id != 1 {
+		t.Errorf("Unexpected result %d, %v when trying to insert category", id, err)
+	}
+	checkingMockExpectations(t, mock)
+}
+
+func TestCategoriesDAO_MoveCategory(t *testing.T) {
+
+	db, mock := setupMockDB(t)
+	defer db.Close()
+	dao := categoriesDAO{db: db}
+	parentId := int64(5)
+
+	mock.ExpectExec("UPDATE categories SET parent = ?, updated_ts = current_timestamp WHERE id = ? AND is_deleted = 0").
+		WithArgs(parentId, int64(3)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	err := dao.MoveCategory(3, &parentId)
+	if err != nil {
+		t.Errorf("Unexpected error when trying to move category: %v", err)
+	}
+	checkingMockExpectations(t, mock)
+}
+
+func TestCategoriesDAO_DeleteCategory(t *testing.T) {
+
+	db, mock := setupMockDB(t)
+	defer db.Close()
+	dao := categoriesDAO{db: db}
+	deleteQuery := "UPDATE categories SET is_deleted=1 WHERE id = ? AND is_deleted = 0"
+	reparentQuery := "UPDATE categories SET parent = (SELECT parent FROM categories WHERE id = ?), " +
+		"updated_ts = current_timestamp WHERE parent = ? AND is_deleted = 0"
+
+	t.Run("testing children are re-parented in the same transaction", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec(deleteQuery).WithArgs(int64(3)).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(reparentQuery).WithArgs(int64(3), int64(3)).WillReturnResult(sqlmock.NewResult(0, 2))
+		mock.ExpectCommit()
+
+		err := dao.DeleteCategory(3)
+		if err != nil {
+			t.Errorf("Unexpected error when trying to delete category: %v", err)
+		}
+		checkingMockExpectations(t, mock)
+	})
+
+	t.Run("testing failures roll back the deletion", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec(deleteQuery).WithArgs(int64(3)).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(reparentQuery).WillReturnError(errors.New("db timeout"))
+		mock.ExpectRollback()
+
+		err := dao.DeleteCategory(3)
+		expectedErrorMsg := "failed to re-parent children of category 3 due to error db timeout"
+		if err == nil || err.Error() != expectedErrorMsg {
+			t.Errorf("expected error message %s but found %v", expectedErrorMsg, err)
+		}
+		checkingMockExpectations(t, mock)
+	})
+}
+
+func TestCategoriesDAO_SumAmountsByCategory(t *testing.T) {
+
+	db, mock := setupMockDB(t)
+	defer db.Close()
+	dao := categoriesDAO{db: db}
+	from := time.Date(2022, 7, 1, 0, 0, 0, 0, time.UTC)
+
+	mock.ExpectQuery("SELECT category_id, SUM(amount) FROM " + expectedSplitTransactions +
+		" WHERE is_deleted = 0 AND " +
+		"category_id IS NOT NULL AND transaction_time >= ? GROUP BY category_id").
+		WithArgs(from).
+		WillReturnRows(sqlmock.NewRows([]string{"category_id", "total"}).AddRow(1, -2050).AddRow(2, 10000))
+
+	totals, err := dao.SumAmountsByCategory(&from, nil)
+	if err != nil {
+		t.Errorf("Unexpected error when summing by category: %v", err)
+		return
+	}
+	if totals[1] != -2050 || totals[2] != 10000 {
+		t.Errorf("unexpected totals %v", totals)
+	}
+	checkingMockExpectations(t, mock)
+}
+
+func TestCategoriesDAO_SumAmountsByCategoryAndCurrency(t *testing.T) {
+
+	db, mock := setupMockDB(t)
+	defer db.Close()
+	dao := categoriesDAO{db: db}
+	to := time.Date(2022, 8, 1, 0, 0, 0, 0, time.UTC)
+
+	mock.ExpectQuery("SELECT t.category_id, a.currency, " +
+		"substr(t.transaction_time, 1, 10), SUM(t.amount) FROM " + expectedSplitTransactions +
+		" t JOIN accounts a ON a.id = t.account_id " +
+		"WHERE t.is_deleted = 0 AND t.category_id IS NOT NULL AND t.transaction_time < ? GROUP BY 1, 2, 3").
+		WithArgs(to).
+		WillReturnRows(sqlmock.NewRows([]string{"category_id", "currency", "day", "total"}).
+			AddRow(1, "USD", "2022-07-01", -2050).
+			AddRow(1, "EUR", "2022-07-01", -1000))
+
+	totals, err := dao.SumAmountsByCategoryAndCurrency(nil, &to)
+	if err != nil {
+		t.Errorf("Unexpected error when summing by category and currency: %v", err)
+		return
+	}
+	expected := api.CategoryCurrencyTotal{CategoryId: 1, Currency: "EUR", Day: "2022-07-01", Amount: -1000}
+	if len(totals) != 2 || totals[1] != expected {
+		t.Errorf("unexpected totals %+v", totals)
+	}
+	checkingMockExpectations(t, mock)
+}
@@ -0,0 +1,110 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"go-personal-finance/pkg/api"
+)
+
+const creditCardsTableName = "credit_cards"
+
+const creditCardColumns = "id, account_id, closing_day, due_day, minimum_payment_rate, minimum_payment, is_deleted, " +
+	"created_ts, updated_ts"
+
+type creditCardsDAO struct {
+	db *sql.DB
+}
+
+func NewCreditCardsDAO(db *sql.DB) *creditCardsDAO {
+	return &creditCardsDAO{db}
+}
+
+func scanCreditCard(row rowScanner) (api.CreditCard, error) {
+	creditCard := api.CreditCard{}
+	err := row.Scan(&creditCard.Id, &creditCard.AccountId, &creditCard.ClosingDay, &creditCard.DueDay,
+		&creditCard.MinimumPaymentRate, &creditCard.MinimumPayment, &creditCard.IsDeleted, &creditCard.CreatedTs,
+		&creditCard.UpdatedTs)
+	return creditCard, err
+}
+
+func (dao *creditCardsDAO) GetCreditCard(id int64) (api.CreditCard, error) {
+	row := dao.db.QueryRow(fmt.Sprintf("SELECT %s FROM %s WHERE id = ? AND is_deleted = 0", creditCardColumns,
+		creditCardsTableName), id)
+	creditCard, err := scanCreditCard(row)
+	if err != nil {
+		return creditCard, fmt.Errorf("failed to retrieve credit card of id %d with err: %v", id, err)
+	}
+	return creditCard, nil
+}
+
+func (dao *creditCardsDAO) ListCreditCards() ([]api.CreditCard, error) {
+	rows, err := dao.db.Query(fmt.Sprintf("SELECT %s FROM %s WHERE is_deleted = 0 ORDER BY id", creditCardColumns,
+		creditCardsTableName))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list credit cards with err: %v", err)
+	}
+	defer rows.Close()
+
+	creditCards := []api.CreditCard{}
+	for rows.Next() {
+		creditCard, err := scanCreditCard(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read credit card with err: %v", err)
+		}
+		creditCards = append(creditCards, creditCard)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list credit cards with err: %v", err)
+	}
+	return creditCards, nil
+}
+
+// InsertCreditCard assumes the defaults of the request have been filled in by the service.
+func (dao *creditCardsDAO) InsertCreditCard(request api.CreditCardRequest) (int64, error) {
+	result, err := dao.db.Exec(fmt.Sprintf("INSERT INTO %s (account_id, closing_day, due_day, minimum_payment_rate, "+
+		"minimum_payment) VALUES(?,?,?,?,?)", creditCardsTableName), *request.AccountId, *request.ClosingDay,
+		*request.DueDay, *request.MinimumPaymentRate, *request.MinimumPayment)
+	if err != nil {
+		return -1, fmt.Errorf("failed to insert new credit card due to error %v", err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return -1, fmt.Errorf("failed to retrieve last inserted id %v", err)
+	}
+	return id, nil
+}
+
+// UpdateCreditCard assumes the defaults of the request have been filled in by the service.
+func (dao *creditCardsDAO) UpdateCreditCard(id int64, request api.CreditCardRequest) error {
+	result, err := dao.db.Exec(fmt.Sprintf("UPDATE %s SET account_id = ?, closing_day = ?, due_day = ?, "+
+		"minimum_payment_rate = ?, minimum_payment = ?, updated_ts = current_timestamp WHERE id = ? "+
+		"AND is_deleted = 0", creditCardsTableName), *request.AccountId, *request.ClosingDay, *request.DueDay,
+		*request.MinimumPaymentRate, *request.MinimumPayment, id)
+	if err != nil {
+		return fmt.Errorf("failed to update credit card %d due to error %v", id, err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to verify if credit card %d has been updated due to error %v", id, err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("WARN: detected request to update non-existent credit card with id %d \n", id)
+	}
+	return nil
+}
+
+func (dao *creditCardsDAO) DeleteCreditCard(id int64) error {
+	result, err := dao.db.Exec(fmt.Sprintf("UPDATE %s SET is_deleted = 1, updated_ts = current_timestamp "+
+		"WHERE id = ? AND is_deleted = 0", creditCardsTableName), id)
+	if err != nil {
+		return fmt.Errorf("failed to delete credit card %d due to error %v", id, err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to verify if credit card %d has been deleted due to error %v", id, err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("WARN: detected request to delete non-existent credit card with id %d \n", id)
+	}
+	return nil
+}
@@ -0,0 +1,180 @@
+package repository
+
+import (
+	"errors"
+	"github.com/DATA-DOG/go-sqlmock"
+	"go-personal-finance/pkg/api"
+	"testing"
+	"time"
+)
+
+const expectedCreditCardColumns = "id, account_id, closing_day, due_day, minimum_payment_rate, minimum_payment, " +
+	"is_deleted, created_ts, updated_ts"
+
+var creditCardRowColumns = []string{"id", "account_id", "closing_day", "due_day", "minimum_payment_rate",
+	"minimum_payment", "is_deleted", "created_ts", "updated_ts"}
+
+func TestCreditCardsDAO_GetCreditCard(t *testing.T) {
+
+	db, mock := setupMockDB(t)
+	defer db.Close()
+	dao := creditCardsDAO{db: db}
+
+	expectedSelectQuery := "SELECT " + expectedCreditCardColumns + " FROM credit_cards WHERE id = ? AND is_deleted = 0"
+
+	t.Run("testing happy flow", func(t *testing.T) {
+		rows := sqlmock.NewRows(creditCardRowColumns).AddRow(1, 5, 15, 10, 0.02, 2500, false, time.Now(), time.Now())
+		mock.ExpectQuery(expectedSelectQuery).WithArgs(int64(1)).WillReturnRows(rows)
+
+		creditCard, err := dao.GetCreditCard(1)
+		if err != nil {
+			t.Errorf("Unexpected error when retrieving credit card: %v", err)
+			return
+		}
+		if creditCard.AccountId != 5 || creditCard.ClosingDay != 15 || creditCard.DueDay != 10 ||
+			creditCard.MinimumPaymentRate != 0.02 || creditCard.MinimumPayment != 2500 {
+			t.Errorf("unexpected credit card %+v", creditCard)
+		}
+		checkingMockExpectations(t, mock)
+	})
+
+	t.Run("testing situation where we fail to retrieve credit card", func(t *testing.T) {
+		mock.ExpectQuery(expectedSelectQuery).WillReturnError(errors.New("db timeout"))
+
+		_, err := dao.GetCreditCard(1)
+		expectedErrorMsg := "failed to retrieve credit card of id 1 with err: db timeout"
+		if err == nil || expectedErrorMsg != err.Error() {
+			t.Errorf("expected error message %s but found %v", expectedErrorMsg, err)
+		}
+		checkingMockExpectations(t, mock)
+	})
+}
+
+func TestCreditCardsDAO_ListCreditCards(t *testing.T) {
+
+	db, mock := setupMockDB(t)
+	defer db.Close()
+	dao := creditCardsDAO{db: db}
+
+	t.Run("testing happy flow", func(t *testing.T) {
+		rows := sqlmock.NewRows(creditCardRowColumns).
+			AddRow(1, 5, 15, 10, 0.02, 2500, false, time.Now(), time.Now()).
+			AddRow(2, 6, 31, 25, 0.01, 1000, false, time.Now(), time.Now())
+		mock.ExpectQuery("SELECT " + expectedCreditCardColumns + " FROM credit_cards WHERE is_deleted = 0 ORDER BY id").
+			WillReturnRows(rows)
+
+		creditCards, err := dao.ListCreditCards()
+		if err != nil {
+			t.Errorf("Unexpected error when listing credit cards: %v", err)
+			return
+		}
+		if len(creditCards) != 2 || creditCards[1].ClosingDay != 31 {
+			t.Errorf("unexpected credit cards %+v", creditCards)
+		}
+		checkingMockExpectations(t, mock)
+	})
+}
+
+func TestCreditCardsDAO_InsertCreditCard(t *testing.T) {
+
+	db, mock := setupMockDB(t)
+	defer db.Close()
+	dao := creditCardsDAO{db: db}
+
+	accountId, closingDay, dueDay, rate, minimumPayment := int64(5), 15, 10, 0.02, api.Money(2500)
+	insertQuery := "INSERT INTO credit_cards (account_id, closing_day, due_day, minimum_payment_rate, " +
+		"minimum_payment) VALUES(?,?,?,?,?)"
+	request := api.CreditCardRequest{AccountId: &accountId, ClosingDay: &closingDay, DueDay: &dueDay,
+		MinimumPaymentRate: &rate, MinimumPayment: &minimumPayment}
+
+	t.Run("testing happy flow", func(t *testing.T) {
+		mock.ExpectExec(insertQuery).WithArgs(accountId, closingDay, dueDay, rate, minimumPayment).
+			WillReturnResult(sqlmock.NewResult(int64(3), 1))
+
+		id, err := dao.InsertCreditCard(request)
+		if err != nil {
+			t.Errorf("Unexpected error when trying to insert credit card: %v", err)
+			return
+		}
+		if id != 3 {
+			t.Errorf("unexpected id returned")
+		}
+		checkingMockExpectations(t, mock)
+	})
+
+	t.Run("testing general error flow", func(t *testing.T) {
+		mock.ExpectExec(insertQuery).WillReturnError(errors.New("db timeout"))
+
+		_, err := dao.InsertCreditCard(request)
+		expectedErrorMsg := "failed to insert new credit card due to error db timeout"
+		if err == nil || expectedErrorMsg != err.Error() {
+			t.Errorf("expected error message %s but found %v", expectedErrorMsg, err)
+		}
+		checkingMockExpectations(t, mock)
+	})
+}
+
+func TestCreditCardsDAO_UpdateCreditCard(t *testing.T) {
+
+	db, mock := setupMockDB(t)
+	defer db.Close()
+	dao := creditCardsDAO{db: db}
+
+	accountId, closingDay, dueDay, rate, minimumPayment := int64(5), 20, 15, 0.03, api.Money(3500)
+	updateQuery := "UPDATE credit_cards SET account_id = ?, closing_day = ?, due_day = ?, minimum_payment_rate = ?, " +
+		"minimum_payment = ?, updated_ts = current_timestamp WHERE id = ? AND is_deleted = 0"
+	request := api.CreditCardRequest{AccountId: &accountId, ClosingDay: &closingDay, DueDay: &dueDay,
+		MinimumPaymentRate: &rate, MinimumPayment: &minimumPayment}
+
+	t.Run("testing happy flow", func(t *testing.T) {
+		mock.ExpectExec(updateQuery).WithArgs(accountId, closingDay, dueDay, rate, minimumPayment, int64(1)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		err := dao.UpdateCreditCard(1, request)
+		if err != nil {
+			t.Errorf("Unexpected error when trying to update credit card: %v", err)
+		}
+		checkingMockExpectations(t, mock)
+	})
+
+	t.Run("testing situation when credit card doesn't exist with that id", func(t *testing.T) {
+		mock.ExpectExec(updateQuery).WillReturnResult(sqlmock.NewResult(0, 0))
+
+		err := dao.UpdateCreditCard(1, request)
+		expectedErrorMsg := "WARN: detected request to update non-existent credit card with id 1 \n"
+		if err == nil || expectedErrorMsg != err.Error() {
+			t.Errorf("expected error message %s but found %v", expectedErrorMsg, err)
+		}
+		checkingMockExpectations(t, mock)
+	})
+}
+
+func TestCreditCardsDAO_DeleteCreditCard(t *testing.T) {
+
+	db, mock := setupMockDB(t)
+	defer db.Close()
+	dao := creditCardsDAO{db: db}
+	deleteQuery := "UPDATE credit_cards SET is_deleted = 1, updated_ts = current_timestamp WHERE id = ? " +
+		"AND is_deleted = 0"
+
+	t.Run("testing happy flow", func(t *testing.T) {
+		mock.ExpectExec(deleteQuery).WithArgs(int64(1)).WillReturnResult(sqlmock.NewResult(0, 1))
+
+		err := dao.DeleteCreditCard(1)
+		if err != nil {
+			t.Errorf("Unexpected error when trying to delete credit card: %v", err)
+		}
+	})
+
+	t.Run("testing deletion of non existent credit card", func(t *testing.T) {
+		mock.ExpectExec(deleteQuery).WithArgs(int64(1)).WillReturnResult(sqlmock.NewResult(0, 0))
+
+		err := dao.DeleteCreditCard(1)
+		expectedErrorMsg := "WARN: detected request to delete non-existent credit card with id 1 \n"
+		if err == nil || expectedErrorMsg != err.Error() {
+			t.Errorf("expected error message %s but found %v", expectedErrorMsg, err)
+		}
+	})
+
+	checkingMockExpectations(t, mock)
+}
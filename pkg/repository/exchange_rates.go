@@ -0,0 +1,78 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"go-personal-finance/pkg/api"
+	"strings"
+)
+
+const exchangeRatesTableName = "exchange_rates"
+
+const exchangeRateColumns = "id, rate_date, base_currency, quote_currency, rate, created_ts, updated_ts"
+
+type exchangeRatesDAO struct {
+	db *sql.DB
+}
+
+func NewExchangeRatesDAO(db *sql.DB) *exchangeRatesDAO {
+	return &exchangeRatesDAO{db}
+}
+
+func (dao *exchangeRatesDAO) ListExchangeRates(filter api.ExchangeRateFilter) ([]api.ExchangeRate, error) {
+	conditions := []string{"1 = 1"}
+	var args []interface{}
+	if filter.BaseCurrency != "" {
+		conditions = append(conditions, "base_currency = ?")
+		args = append(args, filter.BaseCurrency)
+	}
+	if filter.QuoteCurrency != "" {
+		conditions = append(conditions, "quote_currency = ?")
+		args = append(args, filter.QuoteCurrency)
+	}
+	rows, err := dao.db.Query(fmt.Sprintf("SELECT %s FROM %s WHERE %s ORDER BY base_currency, quote_currency, "+
+		"rate_date", exchangeRateColumns, exchangeRatesTableName, strings.Join(conditions, " AND ")), args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list exchange rates with err: %v", err)
+	}
+	defer rows.Close()
+
+	rates := []api.ExchangeRate{}
+	for rows.Next() {
+		rate := api.ExchangeRate{}
+		err = rows.Scan(&rate.Id, &rate.Date, &rate.BaseCurrency, &rate.QuoteCurrency, &rate.Rate, &rate.CreatedTs,
+			&rate.UpdatedTs)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read exchange rate with err: %v", err)
+		}
+		rates = append(rates, rate)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list exchange rates with err: %v", err)
+	}
+	return rates, nil
+}
+
+// UpsertExchangeRates saves all the rates or none of them. A rate replaces the one of the same pair and date.
+func (dao *exchangeRatesDAO) UpsertExchangeRates(requests []api.ExchangeRateRequest) error {
+	tx, err := dao.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin saving exchange rates due to error %v", err)
+	}
+	defer tx.Rollback()
+
+	for _, request := range requests {
+		_, err = tx.Exec(fmt.Sprintf("INSERT INTO %s (rate_date, base_currency, quote_currency, rate) "+
+			"VALUES(?,?,?,?) ON CONFLICT (base_currency, quote_currency, rate_date) DO UPDATE "+
+			"SET rate = excluded.rate, updated_ts = current_timestamp", exchangeRatesTableName), *request.Date,
+			*request.BaseCurrency, *request.QuoteCurrency, *request.Rate)
+		if err != nil {
+			return fmt.Errorf("failed to save exchange rate of %s/%s on %s due to error %v", *request.BaseCurrency,
+				*request.QuoteCurrency, *request.Date, err)
+		}
+	}
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit exchange rates due to error %v", err)
+	}
+	return nil
+}
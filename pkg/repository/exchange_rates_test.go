@@ -0,0 +1,95 @@
+package repository
+
+import (
+	"errors"
+	"github.com/DATA-DOG/go-sqlmock"
+	"go-personal-finance/pkg/api"
+	"testing"
+	"time"
+)
+
+const expectedExchangeRateColumns = "id, rate_date, base_currency, quote_currency, rate, created_ts, updated_ts"
+
+func TestExchangeRatesDAO_ListExchangeRates(t *testing.T) {
+
+	db, mock := setupMockDB(t)
+	defer db.Close()
+	dao := exchangeRatesDAO{db: db}
+
+	t.Run("testing happy flow", func(t *testing.T) {
+		rows := sqlmock.NewRows([]string{"id", "rate_date", "base_currency", "quote_currency", "rate", "created_ts",
+			"updated_ts"}).
+			AddRow(1, "2022-07-01", "EUR", "USD", 1.02, time.Now(), time.Now()).
+			AddRow(2, "2022-07-02", "EUR", "USD", 1.03, time.Now(), time.Now())
+		mock.ExpectQuery("SELECT " + expectedExchangeRateColumns + " FROM exchange_rates WHERE 1 = 1 AND " +
+			"base_currency = ? ORDER BY base_currency, quote_currency, rate_date").
+			WithArgs("EUR").
+			WillReturnRows(rows)
+
+		rates, err := dao.ListExchangeRates(api.ExchangeRateFilter{BaseCurrency: "EUR"})
+		if err != nil {
+			t.Errorf("Unexpected error when listing exchange rates: %v", err)
+			return
+		}
+		if len(rates) != 2 || rates[1].Date != "2022-07-02" || rates[1].Rate != 1.03 {
+			t.Errorf("unexpected rates %+v", rates)
+		}
+		checkingMockExpectations(t, mock)
+	})
+
+	t.Run("testing general error flow", func(t *testing.T) {
+		mock.ExpectQuery("SELECT " + expectedExchangeRateColumns + " FROM exchange_rates WHERE 1 = 1 " +
+			"ORDER BY base_currency, quote_currency, rate_date").
+			WillReturnError(errors.New("db timeout"))
+
+		_, err := dao.ListExchangeRates(api.ExchangeRateFilter{})
+		expectedErrorMsg := "failed to list exchange rates with err: db timeout"
+		if err == nil || expectedErrorMsg != err.Error() {
+			t.Errorf("expected error message %s but found %v", expectedErrorMsg, err)
+		}
+		checkingMockExpectations(t, mock)
+	})
+}
+
+func TestExchangeRatesDAO_UpsertExchangeRates(t *testing.T) {
+
+	db, mock := setupMockDB(t)
+	defer db.Close()
+	dao := exchangeRatesDAO{db: db}
+
+	date, base, quote := "2022-07-01", "EUR", "USD"
+	first, second := 1.02, 0.98
+	requests := []api.ExchangeRateRequest{
+		{Date: &date, BaseCurrency: &base, QuoteCurrency: &quote, Rate: &first},
+		{Date: &date, BaseCurrency: &quote, QuoteCurrency: &base, Rate: &second},
+	}
+	upsertQuery := "INSERT INTO exchange_rates (rate_date, base_currency, quote_currency, rate) VALUES(?,?,?,?) " +
+		"ON CONFLICT (base_currency, quote_currency, rate_date) DO UPDATE SET rate = excluded.rate, " +
+		"updated_ts = current_timestamp"
+
+	t.Run("testing happy flow", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec(upsertQuery).WithArgs(date, base, quote, first).WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec(upsertQuery).WithArgs(date, quote, base, second).WillReturnResult(sqlmock.NewResult(2, 1))
+		mock.ExpectCommit()
+
+		if err := dao.UpsertExchangeRates(requests); err != nil {
+			t.Errorf("Unexpected error when saving exchange rates: %v", err)
+		}
+		checkingMockExpectations(t, mock)
+	})
+
+	t.Run("testing no rate is saved when one fails", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec(upsertQuery).WithArgs(date, base, quote, first).WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec(upsertQuery).WithArgs(date, quote, base, second).WillReturnError(errors.New("db timeout"))
+		mock.ExpectRollback()
+
+		err := dao.UpsertExchangeRates(requests)
+		expectedErrorMsg := "failed to save exchange rate of USD/EUR on 2022-07-01 due to error db timeout"
+		if err == nil || expectedErrorMsg != err.Error() {
+			t.Errorf("expected error message %s but found %v", expectedErrorMsg, err)
+		}
+		checkingMockExpectations(t, mock)
+	})
+}
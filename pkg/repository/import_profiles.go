@@ -0,0 +1,133 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"go-personal-finance/pkg/api"
+)
+
+const importProfilesTableName = "import_profiles"
+
+const importProfileColumns = "id, name, delimiter, skip_rows, date_column, date_format, description_column, " +
+	"memo_column, amount_column, debit_column, credit_column, amount_sign, is_deleted, created_ts, updated_ts"
+
+type importProfilesDAO struct {
+	db *sql.DB
+}
+
+func NewImportProfilesDAO(db *sql.DB) *importProfilesDAO {
+	return &importProfilesDAO{db}
+}
+
+func scanImportProfile(row rowScanner) (api.ImportProfile, error) {
+	profile := api.ImportProfile{}
+	var descriptionColumn, memoColumn, amountColumn, debitColumn, creditColumn sql.NullString
+	err := row.Scan(&profile.Id, &profile.Name, &profile.Delimiter, &profile.SkipRows, &profile.DateColumn,
+		&profile.DateFormat, &descriptionColumn, &memoColumn, &amountColumn, &debitColumn, &creditColumn,
+		&profile.AmountSign, &profile.IsDeleted, &profile.CreatedTs, &profile.UpdatedTs)
+	if err != nil {
+		return profile, err
+	}
+	profile.DescriptionColumn = descriptionColumn.String
+	profile.MemoColumn = memoColumn.String
+	profile.AmountColumn = amountColumn.String
+	profile.DebitColumn = debitColumn.String
+	profile.CreditColumn = creditColumn.String
+	return profile, nil
+}
+
+func (dao *importProfilesDAO) GetImportProfile(id int64) (api.ImportProfile, error) {
+	row := dao.db.QueryRow(fmt.Sprintf("SELECT %s FROM %s WHERE id = ? AND is_deleted = 0", importProfileColumns,
+		importProfilesTableName), id)
+	profile, err := scanImportProfile(row)
+	if err != nil {
+		return profile, fmt.Errorf("failed to retrieve import profile of id %d with err: %v", id, err)
+	}
+	return profile, nil
+}
+
+func (dao *importProfilesDAO) ListImportProfiles() ([]api.ImportProfile, error) {
+	rows, err := dao.db.Query(fmt.Sprintf("SELECT %s FROM %s WHERE is_deleted = 0 ORDER BY name",
+		importProfileColumns, importProfilesTableName))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list import profiles with err: %v", err)
+	}
+	defer rows.Close()
+
+	profiles := []api.ImportProfile{}
+	for rows.Next() {
+		profile, err := scanImportProfile(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read import profile with err: %v", err)
+		}
+		profiles = append(profiles, profile)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list import profiles with err: %v", err)
+	}
+	return profiles, nil
+}
+
+// InsertImportProfile assumes the defaults of the request have been filled in by the service.
+func (dao *importProfilesDAO) InsertImportProfile(request api.ImportProfileRequest) (int64, error) {
+	var skipRows int64
+	if request.SkipRows != nil {
+		skipRows = *request.SkipRows
+	}
+	result, err := dao.db.Exec(fmt.Sprintf("INSERT INTO %s (name, delimiter, skip_rows, date_column, date_format, "+
+		"description_column, memo_column, amount_column, debit_column, credit_column, amount_sign) "+
+		"VALUES(?,?,?,?,?,?,?,?,?,?,?)", importProfilesTableName), *request.Name, *request.Delimiter, skipRows,
+		*request.DateColumn, *request.DateFormat, NewNullString(request.DescriptionColumn),
+		NewNullString(request.MemoColumn), NewNullString(request.AmountColumn), NewNullString(request.DebitColumn),
+		NewNullString(request.CreditColumn), *request.AmountSign)
+	if err != nil {
+		return -1, fmt.Errorf("failed to insert new import profile due to error %v", err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return -1, fmt.Errorf("failed to retrieve last inserted id %v", err)
+	}
+	return id, nil
+}
+
+// UpdateImportProfile assumes the defaults of the request have been filled in by the service.
+func (dao *importProfilesDAO) UpdateImportProfile(id int64, request api.ImportProfileRequest) error {
+	var skipRows int64
+	if request.SkipRows != nil {
+		skipRows = *request.SkipRows
+	}
+	result, err := dao.db.Exec(fmt.Sprintf("UPDATE %s SET name = ?, delimiter = ?, skip_rows = ?, date_column = ?, "+
+		"date_format = ?, description_column = ?, memo_column = ?, amount_column = ?, debit_column = ?, "+
+		"credit_column = ?, amount_sign = ?, updated_ts = current_timestamp WHERE id = ? AND is_deleted = 0",
+		importProfilesTableName), *request.Name, *request.Delimiter, skipRows, *request.DateColumn,
+		*request.DateFormat, NewNullString(request.DescriptionColumn), NewNullString(request.MemoColumn),
+		NewNullString(request.AmountColumn), NewNullString(request.DebitColumn), NewNullString(request.CreditColumn),
+		*request.AmountSign, id)
+	if err != nil {
+		return fmt.Errorf("failed to update import profile %d due to error %v", id, err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to verify if import profile %d has been updated due to error %v", id, err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("WARN: detected request to update non-existent import profile with id %d \n", id)
+	}
+	return nil
+}
+
+func (dao *importProfilesDAO) DeleteImportProfile(id int64) error {
+	result, err := dao.db.Exec(fmt.Sprintf("UPDATE %s SET is_deleted = 1, updated_ts = current_timestamp "+
+		"WHERE id = ? AND is_deleted = 0", importProfilesTableName), id)
+	if err != nil {
+		return fmt.Errorf("failed to delete import profile %d due to error %v", id, err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to verify if import profile %d has been deleted due to error %v", id, err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("WARN: detected request to delete non-existent import profile with id %d \n", id)
+	}
+	return nil
+}
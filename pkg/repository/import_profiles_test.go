@@ -0,0 +1,203 @@
+package repository
+
+import (
+	"errors"
+	"github.com/DATA-DOG/go-sqlmock"
+	"go-personal-finance/pkg/api"
+	"testing"
+	"time"
+)
+
+const expectedImportProfileColumns = "id, name, delimiter, skip_rows, date_column, date_format, " +
+	"description_column, memo_column, amount_column, debit_column, credit_column, amount_sign, is_deleted, " +
+	"created_ts, updated_ts"
+
+var importProfileRowColumns = []string{"id", "name", "delimiter", "skip_rows", "date_column", "date_format",
+	"description_column", "memo_column", "amount_column", "debit_column", "credit_column", "amount_sign",
+	"is_deleted", "created_ts", "updated_ts"}
+
+func TestImportProfilesDAO_GetImportProfile(t *testing.T) {
+
+	db, mock := setupMockDB(t)
+	defer db.Close()
+	dao := importProfilesDAO{db: db}
+
+	expectedSelectQuery := "SELECT " + expectedImportProfileColumns + " FROM import_profiles " +
+		"WHERE id = ? AND is_deleted = 0"
+
+	t.Run("testing happy flow", func(t *testing.T) {
+		rows := sqlmock.NewRows(importProfileRowColumns).AddRow(1, "chase", ",", 0, "Posting Date", "01/02/2006",
+			"Description", nil, "Amount", nil, nil, "normal", false, time.Now(), time.Now())
+		mock.ExpectQuery(expectedSelectQuery).WithArgs(int64(1)).WillReturnRows(rows)
+
+		profile, err := dao.GetImportProfile(1)
+		if err != nil {
+			t.Errorf("Unexpected error when retrieving import profile: %v", err)
+			return
+		}
+		if profile.AmountColumn != "Amount" || profile.DebitColumn != "" {
+			t.Errorf("unexpected amount columns %s and %s", profile.AmountColumn, profile.DebitColumn)
+		}
+		checkingMockExpectations(t, mock)
+	})
+
+	t.Run("testing situation where we fail to retrieve import profile", func(t *testing.T) {
+		mock.ExpectQuery(expectedSelectQuery).WillReturnError(errors.New("db timeout"))
+
+		_, err := dao.GetImportProfile(1)
+		expectedErrorMsg := "failed to retrieve import profile of id 1 with err: db timeout"
+		if err == nil || expectedErrorMsg != err.Error() {
+			t.Errorf("expected error message %s but found %v", expectedErrorMsg, err)
+		}
+		checkingMockExpectations(t, mock)
+	})
+}
+
+func TestImportProfilesDAO_ListImportProfiles(t *testing.T) {
+
+	db, mock := setupMockDB(t)
+	defer db.Close()
+	dao := importProfilesDAO{db: db}
+
+	t.Run("testing happy flow", func(t *testing.T) {
+		rows := sqlmock.NewRows(importProfileRowColumns).
+			AddRow(2, "bank of america", ",", 6, "Date", "01/02/2006", "Description", nil, "Amount", nil, nil,
+				"normal", false, time.Now(), time.Now()).
+			AddRow(1, "chase", ",", 0, "Posting Date", "01/02/2006", "Description", nil, nil, "Debit", "Credit",
+				"normal", false, time.Now(), time.Now())
+		mock.ExpectQuery("SELECT " + expectedImportProfileColumns + " FROM import_profiles WHERE is_deleted = 0 " +
+			"ORDER BY name").WillReturnRows(rows)
+
+		profiles, err := dao.ListImportProfiles()
+		if err != nil {
+			t.Errorf("Unexpected error when listing import profiles: %v", err)
+			return
+		}
+		if len(profiles) != 2 || profiles[0].SkipRows != 6 {
+			t.Errorf("unexpected profiles %+v", profiles)
+		}
+		checkingMockExpectations(t, mock)
+	})
+}
+
+func TestImportProfilesDAO_InsertImportProfile(t *testing.T) {
+
+	db, mock := setupMockDB(t)
+	defer db.Close()
+	dao := importProfilesDAO{db: db}
+
+	name := "chase"
+	delimiter := ","
+	dateColumn := "Posting Date"
+	dateFormat := "01/02/2006"
+	amountColumn := "Amount"
+	amountSign := api.AmountSignNormal
+	insertQuery := "INSERT INTO import_profiles (name, delimiter, skip_rows, date_column, date_format, " +
+		"description_column, memo_column, amount_column, debit_column, credit_column, amount_sign) " +
+		"VALUES(?,?,?,?,?,?,?,?,?,?,?)"
+	request := api.ImportProfileRequest{Name: &name, Delimiter: &delimiter, DateColumn: &dateColumn,
+		DateFormat: &dateFormat, AmountColumn: &amountColumn, AmountSign: &amountSign}
+
+	t.Run("testing happy flow", func(t *testing.T) {
+		mock.ExpectExec(insertQuery).
+			WithArgs(name, delimiter, int64(0), dateColumn, dateFormat, nil, nil, amountColumn, nil, nil, amountSign).
+			WillReturnResult(sqlmock.NewResult(int64(4), 1))
+
+		id, err := dao.InsertImportProfile(request)
+		if err != nil {
+			t.Errorf("Unexpected error when trying to insert import profile: %v", err)
+			return
+		}
+		if id != 4 {
+			t.Errorf("unexpected id returned")
+		}
+		checkingMockExpectations(t, mock)
+	})
+
+	t.Run("testing general error flow", func(t *testing.T) {
+		mock.ExpectExec(insertQuery).WillReturnError(errors.New("db timeout"))
+
+		_, err := dao.InsertImportProfile(request)
+		expectedErrorMsg := "failed to insert new import profile due to error db timeout"
+		if err == nil || expectedErrorMsg != err.Error() {
+			t.Errorf("expected error message %s but found %v", expectedErrorMsg, err)
+		}
+		checkingMockExpectations(t, mock)
+	})
+}
+
+func TestImportProfilesDAO_UpdateImportProfile(t *testing.T) {
+
+	db, mock := setupMockDB(t)
+	defer db.Close()
+	dao := importProfilesDAO{db: db}
+
+	name := "chase"
+	delimiter := ";"
+	skipRows := int64(2)
+	dateColumn := "Posting Date"
+	dateFormat := "01/02/2006"
+	debitColumn := "Debit"
+	creditColumn := "Credit"
+	amountSign := api.AmountSignNormal
+	updateQuery := "UPDATE import_profiles SET name = ?, delimiter = ?, skip_rows = ?, date_column = ?, " +
+		"date_format = ?, description_column = ?, memo_column = ?, amount_column = ?, debit_column = ?, " +
+		"credit_column = ?, amount_sign = ?, updated_ts = current_timestamp WHERE id = ? AND is_deleted = 0"
+	request := api.ImportProfileRequest{Name: &name, Delimiter: &delimiter, SkipRows: &skipRows,
+		DateColumn: &dateColumn, DateFormat: &dateFormat, DebitColumn: &debitColumn, CreditColumn: &creditColumn,
+		AmountSign: &amountSign}
+
+	t.Run("testing happy flow", func(t *testing.T) {
+		mock.ExpectExec(updateQuery).
+			WithArgs(name, delimiter, skipRows, dateColumn, dateFormat, nil, nil, nil, debitColumn, creditColumn,
+				amountSign, int64(1)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		err := dao.UpdateImportProfile(1, request)
+		if err != nil {
+			t.Errorf("Unexpected error when trying to update import profile: %v", err)
+		}
+		checkingMockExpectations(t, mock)
+	})
+
+	t.Run("testing situation when import profile doesn't exist with that id", func(t *testing.T) {
+		mock.ExpectExec(updateQuery).WillReturnResult(sqlmock.NewResult(0, 0))
+
+		err := dao.UpdateImportProfile(1, request)
+		expectedErrorMsg := "WARN: detected request to update non-existent import profile with id 1 \n"
+		if err == nil || expectedErrorMsg != err.Error() {
+			t.Errorf("expected error message %s but found %v", expectedErrorMsg, err)
+		}
+		checkingMockExpectations(t, mock)
+	})
+}
+
+func TestImportProfilesDAO_DeleteImportProfile(t *testing.T) {
+
+	db, mock := setupMockDB(t)
+	defer db.Close()
+	dao := importProfilesDAO{db: db}
+	deleteQuery := "UPDATE import_profiles SET is_deleted = 1, updated_ts = current_timestamp " +
+		"WHERE id = ? AND is_deleted = 0"
+
+	t.Run("testing happy flow", func(t *testing.T) {
+		mock.ExpectExec(deleteQuery).WithArgs(int64(1)).WillReturnResult(sqlmock.NewResult(0, 1))
+
+		err := dao.DeleteImportProfile(1)
+		if err != nil {
+			t.Errorf("Unexpected error when trying to delete import profile: %v", err)
+		}
+	})
+
+	t.Run("testing deletion of non existent import profile", func(t *testing.T) {
+		mock.ExpectExec(deleteQuery).WithArgs(int64(1)).WillReturnResult(sqlmock.NewResult(0, 0))
+
+		err := dao.DeleteImportProfile(1)
+		expectedErrorMsg := "WARN: detected request to delete non-existent import profile with id 1 \n"
+		if err == nil || expectedErrorMsg != err.Error() {
+			t.Errorf("expected error message %s but found %v", expectedErrorMsg, err)
+		}
+	})
+
+	checkingMockExpectations(t, mock)
+}
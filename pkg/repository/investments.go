@@ -0,0 +1,178 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"go-personal-finance/pkg/api"
+	"strings"
+)
+
+const investmentTransactionsTableName = "investment_transactions"
+const securityPricesTableName = "security_prices"
+
+const investmentTransactionColumns = "id, account_id, symbol, type, trade_date, quantity, amount, fee, is_deleted, " +
+	"created_ts, updated_ts"
+
+const securityPriceColumns = "id, symbol, price_date, price, created_ts, updated_ts"
+
+type investmentsDAO struct {
+	db *sql.DB
+}
+
+func NewInvestmentsDAO(db *sql.DB) *investmentsDAO {
+	return &investmentsDAO{db}
+}
+
+func scanInvestmentTransaction(row rowScanner) (api.InvestmentTransaction, error) {
+	transaction := api.InvestmentTransaction{}
+	err := row.Scan(&transaction.Id, &transaction.AccountId, &transaction.Symbol, &transaction.Type,
+		&transaction.TradeDate, &transaction.Quantity, &transaction.Amount, &transaction.Fee, &transaction.IsDeleted,
+		&transaction.CreatedTs, &transaction.UpdatedTs)
+	return transaction, err
+}
+
+func (dao *investmentsDAO) GetInvestmentTransaction(id int64) (api.InvestmentTransaction, error) {
+	row := dao.db.QueryRow(fmt.Sprintf("SELECT %s FROM %s WHERE id = ? AND is_deleted = 0",
+		investmentTransactionColumns, investmentTransactionsTableName), id)
+	transaction, err := scanInvestmentTransaction(row)
+	if err != nil {
+		return transaction, fmt.Errorf("failed to retrieve investment transaction of id %d with err: %v", id, err)
+	}
+	return transaction, nil
+}
+
+func (dao *investmentsDAO) ListInvestmentTransactions(filter api.InvestmentTransactionFilter) (
+	[]api.InvestmentTransaction, error) {
+	conditions := []string{"is_deleted = 0"}
+	var args []interface{}
+	if filter.AccountId != nil {
+		conditions = append(conditions, "account_id = ?")
+		args = append(args, *filter.AccountId)
+	}
+	if filter.Symbol != "" {
+		conditions = append(conditions, "symbol = ?")
+		args = append(args, filter.Symbol)
+	}
+	rows, err := dao.db.Query(fmt.Sprintf("SELECT %s FROM %s WHERE %s ORDER BY trade_date, id",
+		investmentTransactionColumns, investmentTransactionsTableName, strings.Join(conditions, " AND ")), args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list investment transactions with err: %v", err)
+	}
+	defer rows.Close()
+
+	transactions := []api.InvestmentTransaction{}
+	for rows.Next() {
+		transaction, err := scanInvestmentTransaction(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read investment transaction with err: %v", err)
+		}
+		transactions = append(transactions, transaction)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list investment transactions with err: %v", err)
+	}
+	return transactions, nil
+}
+
+// InsertInvestmentTransaction assumes the defaults of the request have been filled in by the service.
+func (dao *investmentsDAO) InsertInvestmentTransaction(request api.InvestmentTransactionRequest) (int64, error) {
+	result, err := dao.db.Exec(fmt.Sprintf("INSERT INTO %s (account_id, symbol, type, trade_date, quantity, amount, "+
+		"fee) VALUES(?,?,?,?,?,?,?)", investmentTransactionsTableName), *request.AccountId, *request.Symbol,
+		*request.Type, *request.TradeDate, *request.Quantity, *request.Amount, *request.Fee)
+	if err != nil {
+		return -1, fmt.Errorf("failed to insert new investment transaction due to error %v", err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return -1, fmt.Errorf("failed to retrieve last inserted id %v", err)
+	}
+	return id, nil
+}
+
+// UpdateInvestmentTransaction assumes the defaults of the request have been filled in by the service.
+func (dao *investmentsDAO) UpdateInvestmentTransaction(id int64, request api.InvestmentTransactionRequest) error {
+	result, err := dao.db.Exec(fmt.Sprintf("UPDATE %s SET account_id = ?, symbol = ?, type = ?, trade_date = ?, "+
+		"quantity = ?, amount = ?, fee = ?, updated_ts = current_timestamp WHERE id = ? AND is_deleted = 0",
+		investmentTransactionsTableName), *request.AccountId, *request.Symbol, *request.Type, *request.TradeDate,
+		*request.Quantity, *request.Amount, *request.Fee, id)
+	if err != nil {
+		return fmt.Errorf("failed to update investment transaction %d due to error %v", id, err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to verify if investment transaction %d has been updated due to error %v", id, err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("WARN: detected request to update non-existent investment transaction with id %d \n", id)
+	}
+	return nil
+}
+
+func (dao *investmentsDAO) DeleteInvestmentTransaction(id int64) error {
+	result, err := dao.db.Exec(fmt.Sprintf("UPDATE %s SET is_deleted = 1, updated_ts = current_timestamp "+
+		"WHERE id = ? AND is_deleted = 0", investmentTransactionsTableName), id)
+	if err != nil {
+		return fmt.Errorf("failed to delete investment transaction %d due to error %v", id, err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to verify if investment transaction %d has been deleted due to error %v", id, err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("WARN: detected request to delete non-existent investment transaction with id %d \n", id)
+	}
+	return nil
+}
+
+// ListSecurityPrices lists the prices of every security when the symbol is empty.
+func (dao *investmentsDAO) ListSecurityPrices(symbol string) ([]api.SecurityPrice, error) {
+	conditions := []string{"1 = 1"}
+	var args []interface{}
+	if symbol != "" {
+		conditions = append(conditions, "symbol = ?")
+		args = append(args, symbol)
+	}
+	rows, err := dao.db.Query(fmt.Sprintf("SELECT %s FROM %s WHERE %s ORDER BY symbol, price_date",
+		securityPriceColumns, securityPricesTableName, strings.Join(conditions, " AND ")), args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list security prices with err: %v", err)
+	}
+	defer rows.Close()
+
+	prices := []api.SecurityPrice{}
+	for rows.Next() {
+		price := api.SecurityPrice{}
+		err = rows.Scan(&price.Id, &price.Symbol, &price.Date, &price.Price, &price.CreatedTs, &price.UpdatedTs)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read security price with err: %v", err)
+		}
+		prices = append(prices, price)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list security prices with err: %v", err)
+	}
+	return prices, nil
+}
+
+// UpsertSecurityPrices saves all the prices or none of them. A price replaces the one of the same security and date.
+func (dao *investmentsDAO) UpsertSecurityPrices(requests []api.SecurityPriceRequest) error {
+	tx, err := dao.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin saving security prices due to error %v", err)
+	}
+	defer tx.Rollback()
+
+	for _, request := range requests {
+		_, err = tx.Exec(fmt.Sprintf("INSERT INTO %s (symbol, price_date, price) VALUES(?,?,?) "+
+			"ON CONFLICT (symbol, price_date) DO UPDATE SET price = excluded.price, updated_ts = current_timestamp",
+			securityPricesTableName), *request.Symbol, *request.Date, *request.Price)
+		if err != nil {
+			return fmt.Errorf("failed to save price of %s on %s due to error %v", *request.Symbol, *request.Date,
+				err)
+		}
+	}
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit security prices due to error %v", err)
+	}
+	return nil
+}
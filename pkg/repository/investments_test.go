@@ -0,0 +1,286 @@
+package repository
+
+import (
+	"errors"
+	"github.com/DATA-DOG/go-sqlmock"
+	"go-personal-finance/pkg/api"
+	"testing"
+	"time"
+)
+
+const expectedInvestmentTransactionColumns = "id, account_id, symbol, type, trade_date, quantity, amount, fee, " +
+	"is_deleted, created_ts, updated_ts"
+
+const expectedSecurityPriceColumns = "id, symbol, price_date, price, created_ts, updated_ts"
+
+var investmentTransactionRowColumns = []string{"id", "account_id", "symbol", "type", "trade_date", "quantity",
+	"amount", "fee", "is_deleted", "created_ts", "updated_ts"}
+
+func TestInvestmentsDAO_GetInvestmentTransaction(t *testing.T) {
+
+	db, mock := setupMockDB(t)
+	defer db.Close()
+	dao := investmentsDAO{db: db}
+
+	expectedSelectQuery := "SELECT " + expectedInvestmentTransactionColumns + " FROM investment_transactions " +
+		"WHERE id = ? AND is_deleted = 0"
+
+	t.Run("testing happy flow", func(t *testing.T) {
+		rows := sqlmock.NewRows(investmentTransactionRowColumns).AddRow(1, 9, "ACME", "buy", "2022-01-10", 10.5,
+			105000, 500, false, time.Now(), time.Now())
+		mock.ExpectQuery(expectedSelectQuery).WithArgs(int64(1)).WillReturnRows(rows)
+
+		transaction, err := dao.GetInvestmentTransaction(1)
+		if err != nil {
+			t.Errorf("Unexpected error when retrieving investment transaction: %v", err)
+			return
+		}
+		if transaction.AccountId != 9 || transaction.Symbol != "ACME" || transaction.Quantity != 10.5 ||
+			transaction.Amount != 105000 || transaction.Fee != 500 {
+			t.Errorf("unexpected investment transaction %+v", transaction)
+		}
+		checkingMockExpectations(t, mock)
+	})
+
+	t.Run("testing situation where we fail to retrieve investment transaction", func(t *testing.T) {
+		mock.ExpectQuery(expectedSelectQuery).WillReturnError(errors.New("db timeout"))
+
+		_, err := dao.GetInvestmentTransaction(1)
+		expectedErrorMsg := "failed to retrieve investment transaction of id 1 with err: db timeout"
+		if err == nil || expectedErrorMsg != err.Error() {
+			t.Errorf("expected error message %s but found %v", expectedErrorMsg, err)
+		}
+		checkingMockExpectations(t, mock)
+	})
+}
+
+func TestInvestmentsDAO_ListInvestmentTransactions(t *testing.T) {
+
+	db, mock := setupMockDB(t)
+	defer db.Close()
+	dao := investmentsDAO{db: db}
+
+	t.Run("testing happy flow", func(t *testing.T) {
+		accountId := int64(9)
+		rows := sqlmock.NewRows(investmentTransactionRowColumns).
+			AddRow(1, 9, "ACME", "buy", "2022-01-10", 10, 100000, 500, false, time.Now(), time.Now()).
+			AddRow(2, 9, "ACME", "sell", "2022-03-10", 5, 60000, 500, false, time.Now(), time.Now())
+		mock.ExpectQuery("SELECT "+expectedInvestmentTransactionColumns+" FROM investment_transactions "+
+			"WHERE is_deleted = 0 AND account_id = ? AND symbol = ? ORDER BY trade_date, id").
+			WithArgs(accountId, "ACME").
+			WillReturnRows(rows)
+
+		transactions, err := dao.ListInvestmentTransactions(api.InvestmentTransactionFilter{AccountId: &accountId,
+			Symbol: "ACME"})
+		if err != nil {
+			t.Errorf("Unexpected error when listing investment transactions: %v", err)
+			return
+		}
+		if len(transactions) != 2 || transactions[1].Type != "sell" || transactions[1].Amount != 60000 {
+			t.Errorf("unexpected investment transactions %+v", transactions)
+		}
+		checkingMockExpectations(t, mock)
+	})
+
+	t.Run("testing general error flow", func(t *testing.T) {
+		mock.ExpectQuery("SELECT " + expectedInvestmentTransactionColumns + " FROM investment_transactions " +
+			"WHERE is_deleted = 0 ORDER BY trade_date, id").
+			WillReturnError(errors.New("db timeout"))
+
+		_, err := dao.ListInvestmentTransactions(api.InvestmentTransactionFilter{})
+		expectedErrorMsg := "failed to list investment transactions with err: db timeout"
+		if err == nil || expectedErrorMsg != err.Error() {
+			t.Errorf("expected error message %s but found %v", expectedErrorMsg, err)
+		}
+		checkingMockExpectations(t, mock)
+	})
+}
+
+func TestInvestmentsDAO_InsertInvestmentTransaction(t *testing.T) {
+
+	db, mock := setupMockDB(t)
+	defer db.Close()
+	dao := investmentsDAO{db: db}
+
+	accountId, symbol, transactionType, tradeDate := int64(9), "ACME", "buy", "2022-01-10"
+	quantity, amount, fee := 10.0, api.Money(100000), api.Money(500)
+	insertQuery := "INSERT INTO investment_transactions (account_id, symbol, type, trade_date, quantity, amount, " +
+		"fee) VALUES(?,?,?,?,?,?,?)"
+	request := api.InvestmentTransactionRequest{AccountId: &accountId, Symbol: &symbol, Type: &transactionType,
+		TradeDate: &tradeDate, Quantity: &quantity, Amount: &amount, Fee: &fee}
+
+	t.Run("testing happy flow", func(t *testing.T) {
+		mock.ExpectExec(insertQuery).WithArgs(accountId, symbol, transactionType, tradeDate, quantity, amount, fee).
+			WillReturnResult(sqlmock.NewResult(int64(3), 1))
+
+		id, err := dao.InsertInvestmentTransaction(request)
+		if err != nil {
+			t.Errorf("Unexpected error when trying to insert investment transaction: %v", err)
+			return
+		}
+		if id != 3 {
+			t.Errorf("unexpected id returned")
+		}
+		checkingMockExpectations(t, mock)
+	})
+
+	t.Run("testing general error flow", func(t *testing.T) {
+		mock.ExpectExec(insertQuery).WillReturnError(errors.New("db timeout"))
+
+		_, err := dao.InsertInvestmentTransaction(request)
+		expectedErrorMsg := "failed to insert new investment transaction due to error db timeout"
+		if err == nil || expectedErrorMsg != err.Error() {
+			t.Errorf("expected error message %s but found %v", expectedErrorMsg, err)
+		}
+		checkingMockExpectations(t, mock)
+	})
+}
+
+func TestInvestmentsDAO_UpdateInvestmentTransaction(t *testing.T) {
+
+	db, mock := setupMockDB(t)
+	defer db.Close()
+	dao := investmentsDAO{db: db}
+
+	accountId, symbol, transactionType, tradeDate := int64(9), "ACME", "sell", "2022-03-10"
+	quantity, amount, fee := 5.0, api.Money(60000), api.Money(0)
+	updateQuery := "UPDATE investment_transactions SET account_id = ?, symbol = ?, type = ?, trade_date = ?, " +
+		"quantity = ?, amount = ?, fee = ?, updated_ts = current_timestamp WHERE id = ? AND is_deleted = 0"
+	request := api.InvestmentTransactionRequest{AccountId: &accountId, Symbol: &symbol, Type: &transactionType,
+		TradeDate: &tradeDate, Quantity: &quantity, Amount: &amount, Fee: &fee}
+
+	t.Run("testing happy flow", func(t *testing.T) {
+		mock.ExpectExec(updateQuery).
+			WithArgs(accountId, symbol, transactionType, tradeDate, quantity, amount, fee, int64(2)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		err := dao.UpdateInvestmentTransaction(2, request)
+		if err != nil {
+			t.Errorf("Unexpected error when trying to update investment transaction: %v", err)
+		}
+		checkingMockExpectations(t, mock)
+	})
+
+	t.Run("testing situation when investment transaction doesn't exist with that id", func(t *testing.T) {
+		mock.ExpectExec(updateQuery).WillReturnResult(sqlmock.NewResult(0, 0))
+
+		err := dao.UpdateInvestmentTransaction(2, request)
+		expectedErrorMsg := "WARN: detected request to update non-existent investment transaction with id 2 \n"
+		if err == nil || expectedErrorMsg != err.Error() {
+			t.Errorf("expected error message %s but found %v", expectedErrorMsg, err)
+		}
+		checkingMockExpectations(t, mock)
+	})
+}
+
+func TestInvestmentsDAO_DeleteInvestmentTransaction(t *testing.T) {
+
+	db, mock := setupMockDB(t)
+	defer db.Close()
+	dao := investmentsDAO{db: db}
+	deleteQuery := "UPDATE investment_transactions SET is_deleted = 1, updated_ts = current_timestamp " +
+		"WHERE id = ? AND is_deleted = 0"
+
+	t.Run("testing happy flow", func(t *testing.T) {
+		mock.ExpectExec(deleteQuery).WithArgs(int64(1)).WillReturnResult(sqlmock.NewResult(0, 1))
+
+		err := dao.DeleteInvestmentTransaction(1)
+		if err != nil {
+			t.Errorf("Unexpected error when trying to delete investment transaction: %v", err)
+		}
+	})
+
+	t.Run("testing deletion of non existent investment transaction", func(t *testing.T) {
+		mock.ExpectExec(deleteQuery).WithArgs(int64(1)).WillReturnResult(sqlmock.NewResult(0, 0))
+
+		err := dao.DeleteInvestmentTransaction(1)
+		expectedErrorMsg := "WARN: detected request to delete non-existent investment transaction with id 1 \n"
+		if err == nil || expectedErrorMsg != err.Error() {
+			t.Errorf("expected error message %s but found %v", expectedErrorMsg, err)
+		}
+	})
+
+	checkingMockExpectations(t, mock)
+}
+
+func TestInvestmentsDAO_ListSecurityPrices(t *testing.T) {
+
+	db, mock := setupMockDB(t)
+	defer db.Close()
+	dao := investmentsDAO{db: db}
+
+	t.Run("testing happy flow", func(t *testing.T) {
+		rows := sqlmock.NewRows([]string{"id", "symbol", "price_date", "price", "created_ts", "updated_ts"}).
+			AddRow(1, "ACME", "2022-06-30", 150.25, time.Now(), time.Now()).
+			AddRow(2, "ACME", "2022-07-01", 151.5, time.Now(), time.Now())
+		mock.ExpectQuery("SELECT " + expectedSecurityPriceColumns + " FROM security_prices WHERE 1 = 1 AND " +
+			"symbol = ? ORDER BY symbol, price_date").
+			WithArgs("ACME").
+			WillReturnRows(rows)
+
+		prices, err := dao.ListSecurityPrices("ACME")
+		if err != nil {
+			t.Errorf("Unexpected error when listing security prices: %v", err)
+			return
+		}
+		if len(prices) != 2 || prices[1].Date != "2022-07-01" || prices[1].Price != 151.5 {
+			t.Errorf("unexpected prices %+v", prices)
+		}
+		checkingMockExpectations(t, mock)
+	})
+
+	t.Run("testing general error flow", func(t *testing.T) {
+		mock.ExpectQuery("SELECT " + expectedSecurityPriceColumns + " FROM security_prices WHERE 1 = 1 " +
+			"ORDER BY symbol, price_date").
+			WillReturnError(errors.New("db timeout"))
+
+		_, err := dao.ListSecurityPrices("")
+		expectedErrorMsg := "failed to list security prices with err: db timeout"
+		if err == nil || expectedErrorMsg != err.Error() {
+			t.Errorf("expected error message %s but found %v", expectedErrorMsg, err)
+		}
+		checkingMockExpectations(t, mock)
+	})
+}
+
+func TestInvestmentsDAO_UpsertSecurityPrices(t *testing.T) {
+
+	db, mock := setupMockDB(t)
+	defer db.Close()
+	dao := investmentsDAO{db: db}
+
+	date, acme, fund := "2022-07-01", "ACME", "FUND"
+	first, second := 151.5, 20.125
+	requests := []api.SecurityPriceRequest{
+		{Symbol: &acme, Date: &date, Price: &first},
+		{Symbol: &fund, Date: &date, Price: &second},
+	}
+	upsertQuery := "INSERT INTO security_prices (symbol, price_date, price) VALUES(?,?,?) " +
+		"ON CONFLICT (symbol, price_date) DO UPDATE SET price = excluded.price, updated_ts = current_timestamp"
+
+	t.Run("testing happy flow", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec(upsertQuery).WithArgs(acme, date, first).WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec(upsertQuery).WithArgs(fund, date, second).WillReturnResult(sqlmock.NewResult(2, 1))
+		mock.ExpectCommit()
+
+		if err := dao.UpsertSecurityPrices(requests); err != nil {
+			t.Errorf("Unexpected error when saving security prices: %v", err)
+		}
+		checkingMockExpectations(t, mock)
+	})
+
+	t.Run("testing no price is saved when one fails", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec(upsertQuery).WithArgs(acme, date, first).WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec(upsertQuery).WithArgs(fund, date, second).WillReturnError(errors.New("db timeout"))
+		mock.ExpectRollback()
+
+		err := dao.UpsertSecurityPrices(requests)
+		expectedErrorMsg := "failed to save price of FUND on 2022-07-01 due to error db timeout"
+		if err == nil || expectedErrorMsg != err.Error() {
+			t.Errorf("expected error message %s but found %v", expectedErrorMsg, err)
+		}
+		checkingMockExpectations(t, mock)
+	})
+}
@@ -0,0 +1,109 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"go-personal-finance/pkg/api"
+)
+
+const loansTableName = "loans"
+
+const loanColumns = "id, account_id, principal, annual_rate, term_months, start_date, payment_day, is_deleted, " +
+	"created_ts, updated_ts"
+
+type loansDAO struct {
+	db *sql.DB
+}
+
+func NewLoansDAO(db *sql.DB) *loansDAO {
+	return &loansDAO{db}
+}
+
+func scanLoan(row rowScanner) (api.Loan, error) {
+	loan := api.Loan{}
+	err := row.Scan(&loan.Id, &loan.AccountId, &loan.Principal, &loan.AnnualRate, &loan.TermMonths, &loan.StartDate,
+		&loan.PaymentDay, &loan.IsDeleted, &loan.CreatedTs, &loan.UpdatedTs)
+	return loan, err
+}
+
+func (dao *loansDAO) GetLoan(id int64) (api.Loan, error) {
+	row := dao.db.QueryRow(fmt.Sprintf("SELECT %s FROM %s WHERE id = ? AND is_deleted = 0", loanColumns,
+		loansTableName), id)
+	loan, err := scanLoan(row)
+	if err != nil {
+		return loan, fmt.Errorf("failed to retrieve loan of id %d with err: %v", id, err)
+	}
+	return loan, nil
+}
+
+func (dao *loansDAO) ListLoans() ([]api.Loan, error) {
+	rows, err := dao.db.Query(fmt.Sprintf("SELECT %s FROM %s WHERE is_deleted = 0 ORDER BY id", loanColumns,
+		loansTableName))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list loans with err: %v", err)
+	}
+	defer rows.Close()
+
+	loans := []api.Loan{}
+	for rows.Next() {
+		loan, err := scanLoan(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read loan with err: %v", err)
+		}
+		loans = append(loans, loan)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list loans with err: %v", err)
+	}
+	return loans, nil
+}
+
+// InsertLoan assumes the defaults of the request have been filled in by the service.
+func (dao *loansDAO) InsertLoan(request api.LoanRequest) (int64, error) {
+	result, err := dao.db.Exec(fmt.Sprintf("INSERT INTO %s (account_id, principal, annual_rate, term_months, "+
+		"start_date, payment_day) VALUES(?,?,?,?,?,?)", loansTableName), *request.AccountId, *request.Principal,
+		*request.AnnualRate, *request.TermMonths, *request.StartDate, *request.PaymentDay)
+	if err != nil {
+		return -1, fmt.Errorf("failed to insert new loan due to error %v", err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return -1, fmt.Errorf("failed to retrieve last inserted id %v", err)
+	}
+	return id, nil
+}
+
+// UpdateLoan assumes the defaults of the request have been filled in by the service.
+func (dao *loansDAO) UpdateLoan(id int64, request api.LoanRequest) error {
+	result, err := dao.db.Exec(fmt.Sprintf("UPDATE %s SET account_id = ?, principal = ?, annual_rate = ?, "+
+		"term_months = ?, start_date = ?, payment_day = ?, updated_ts = current_timestamp WHERE id = ? "+
+		"AND is_deleted = 0", loansTableName), *request.AccountId, *request.Principal, *request.AnnualRate,
+		*request.TermMonths, *request.StartDate, *request.PaymentDay, id)
+	if err != nil {
+		return fmt.Errorf("failed to update loan %d due to error %v", id, err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to verify if loan %d has been updated due to error %v", id, err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("WARN: detected request to update non-existent loan with id %d \n", id)
+	}
+	return nil
+}
+
+func (dao *loansDAO) DeleteLoan(id int64) error {
+	result, err := dao.db.Exec(fmt.Sprintf("UPDATE %s SET is_deleted = 1, updated_ts = current_timestamp "+
+		"WHERE id = ? AND is_deleted = 0", loansTableName), id)
+	if err != nil {
+		return fmt.Errorf("failed to delete loan %d due to error %v", id, err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to verify if loan %d has been deleted due to error %v", id, err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("WARN: detected request to delete non-existent loan with id %d \n", id)
+	}
+	return nil
+}
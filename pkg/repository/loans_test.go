@@ -0,0 +1,182 @@
+package repository
+
+import (
+	"errors"
+	"github.com/DATA-DOG/go-sqlmock"
+	"go-personal-finance/pkg/api"
+	"testing"
+	"time"
+)
+
+const expectedLoanColumns = "id, account_id, principal, annual_rate, term_months, start_date, payment_day, " +
+	"is_deleted, created_ts, updated_ts"
+
+var loanRowColumns = []string{"id", "account_id", "principal", "annual_rate", "term_months", "start_date",
+	"payment_day", "is_deleted", "created_ts", "updated_ts"}
+
+func TestLoansDAO_GetLoan(t *testing.T) {
+
+	db, mock := setupMockDB(t)
+	defer db.Close()
+	dao := loansDAO{db: db}
+
+	expectedSelectQuery := "SELECT " + expectedLoanColumns + " FROM loans WHERE id = ? AND is_deleted = 0"
+
+	t.Run("testing happy flow", func(t *testing.T) {
+		rows := sqlmock.NewRows(loanRowColumns).AddRow(1, 7, 30000000, 0.045, 360, "2022-07-15", 15, false, time.Now(),
+			time.Now())
+		mock.ExpectQuery(expectedSelectQuery).WithArgs(int64(1)).WillReturnRows(rows)
+
+		loan, err := dao.GetLoan(1)
+		if err != nil {
+			t.Errorf("Unexpected error when retrieving loan: %v", err)
+			return
+		}
+		if loan.AccountId != 7 || loan.Principal != 30000000 || loan.AnnualRate != 0.045 || loan.TermMonths != 360 ||
+			loan.PaymentDay != 15 {
+			t.Errorf("unexpected loan %+v", loan)
+		}
+		checkingMockExpectations(t, mock)
+	})
+
+	t.Run("testing situation where we fail to retrieve loan", func(t *testing.T) {
+		mock.ExpectQuery(expectedSelectQuery).WillReturnError(errors.New("db timeout"))
+
+		_, err := dao.GetLoan(1)
+		expectedErrorMsg := "failed to retrieve loan of id 1 with err: db timeout"
+		if err == nil || expectedErrorMsg != err.Error() {
+			t.Errorf("expected error message %s but found %v", expectedErrorMsg, err)
+		}
+		checkingMockExpectations(t, mock)
+	})
+}
+
+func TestLoansDAO_ListLoans(t *testing.T) {
+
+	db, mock := setupMockDB(t)
+	defer db.Close()
+	dao := loansDAO{db: db}
+
+	t.Run("testing happy flow", func(t *testing.T) {
+		rows := sqlmock.NewRows(loanRowColumns).
+			AddRow(1, 7, 30000000, 0.045, 360, "2022-07-15", 15, false, time.Now(), time.Now()).
+			AddRow(2, 8, 1000000, 0.06, 12, "2022-01-31", 31, false, time.Now(), time.Now())
+		mock.ExpectQuery("SELECT " + expectedLoanColumns + " FROM loans WHERE is_deleted = 0 ORDER BY id").
+			WillReturnRows(rows)
+
+		loans, err := dao.ListLoans()
+		if err != nil {
+			t.Errorf("Unexpected error when listing loans: %v", err)
+			return
+		}
+		if len(loans) != 2 || loans[1].StartDate != "2022-01-31" {
+			t.Errorf("unexpected loans %+v", loans)
+		}
+		checkingMockExpectations(t, mock)
+	})
+}
+
+func TestLoansDAO_InsertLoan(t *testing.T) {
+
+	db, mock := setupMockDB(t)
+	defer db.Close()
+	dao := loansDAO{db: db}
+
+	accountId, principal, rate, termMonths := int64(7), api.Money(30000000), 0.045, 360
+	startDate, paymentDay := "2022-07-15", 15
+	insertQuery := "INSERT INTO loans (account_id, principal, annual_rate, term_months, start_date, payment_day) " +
+		"VALUES(?,?,?,?,?,?)"
+	request := api.LoanRequest{AccountId: &accountId, Principal: &principal, AnnualRate: &rate,
+		TermMonths: &termMonths, StartDate: &startDate, PaymentDay: &paymentDay}
+
+	t.Run("testing happy flow", func(t *testing.T) {
+		mock.ExpectExec(insertQuery).WithArgs(accountId, principal, rate, termMonths, startDate, paymentDay).
+			WillReturnResult(sqlmock.NewResult(int64(3), 1))
+
+		id, err := dao.InsertLoan(request)
+		if err != nil {
+			t.Errorf("Unexpected error when trying to insert loan: %v", err)
+			return
+		}
+		if id != 3 {
+			t.Errorf("unexpected id returned")
+		}
+		checkingMockExpectations(t, mock)
+	})
+
+	t.Run("testing general error flow", func(t *testing.T) {
+		mock.ExpectExec(insertQuery).WillReturnError(errors.New("db timeout"))
+
+		_, err := dao.InsertLoan(request)
+		expectedErrorMsg := "failed to insert new loan due to error db timeout"
+		if err == nil || expectedErrorMsg != err.Error() {
+			t.Errorf("expected error message %s but found %v", expectedErrorMsg, err)
+		}
+		checkingMockExpectations(t, mock)
+	})
+}
+
+func TestLoansDAO_UpdateLoan(t *testing.T) {
+
+	db, mock := setupMockDB(t)
+	defer db.Close()
+	dao := loansDAO{db: db}
+
+	accountId, principal, rate, termMonths := int64(7), api.Money(30000000), 0.0399, 300
+	startDate, paymentDay := "2022-07-15", 1
+	updateQuery := "UPDATE loans SET account_id = ?, principal = ?, annual_rate = ?, term_months = ?, " +
+		"start_date = ?, payment_day = ?, updated_ts = current_timestamp WHERE id = ? AND is_deleted = 0"
+	request := api.LoanRequest{AccountId: &accountId, Principal: &principal, AnnualRate: &rate,
+		TermMonths: &termMonths, StartDate: &startDate, PaymentDay: &paymentDay}
+
+	t.Run("testing happy flow", func(t *testing.T) {
+		mock.ExpectExec(updateQuery).WithArgs(accountId, principal, rate, termMonths, startDate, paymentDay, int64(1)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		err := dao.UpdateLoan(1, request)
+		if err != nil {
+			t.Errorf("Unexpected error when trying to update loan: %v", err)
+		}
+		checkingMockExpectations(t, mock)
+	})
+
+	t.Run("testing situation when loan doesn't exist with that id", func(t *testing.T) {
+		mock.ExpectExec(updateQuery).WillReturnResult(sqlmock.NewResult(0, 0))
+
+		err := dao.UpdateLoan(1, request)
+		expectedErrorMsg := "WARN: detected request to update non-existent loan with id 1 \n"
+		if err == nil || expectedErrorMsg != err.Error() {
+			t.Errorf("expected error message %s but found %v", expectedErrorMsg, err)
+		}
+		checkingMockExpectations(t, mock)
+	})
+}
+
+func TestLoansDAO_DeleteLoan(t *testing.T) {
+
+	db, mock := setupMockDB(t)
+	defer db.Close()
+	dao := loansDAO{db: db}
+	deleteQuery := "UPDATE loans SET is_deleted = 1, updated_ts = current_timestamp WHERE id = ? AND is_deleted = 0"
+
+	t.Run("testing happy flow", func(t *testing.T) {
+		mock.ExpectExec(deleteQuery).WithArgs(int64(1)).WillReturnResult(sqlmock.NewResult(0, 1))
+
+		err := dao.DeleteLoan(1)
+		if err != nil {
+			t.Errorf("Unexpected error when trying to delete loan: %v", err)
+		}
+	})
+
+	t.Run("testing deletion of non existent loan", func(t *testing.T) {
+		mock.ExpectExec(deleteQuery).WithArgs(int64(1)).WillReturnResult(sqlmock.NewResult(0, 0))
+
+		err := dao.DeleteLoan(1)
+		expectedErrorMsg := "WARN: detected request to delete non-existent loan with id 1 \n"
+		if err == nil || expectedErrorMsg != err.Error() {
+			t.Errorf("expected error message %s but found %v", expectedErrorMsg, err)
+		}
+	})
+
+	checkingMockExpectations(t, mock)
+}
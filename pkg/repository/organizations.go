@@ -0,0 +1,97 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"go-personal-finance/pkg/api"
+)
+
+const organizationsTableName = "organizations"
+
+type organizationsDAO struct {
+	db *sql.DB
+}
+
+func NewOrganizationsDAO(db *sql.DB) *organizationsDAO {
+	return &organizationsDAO{db}
+}
+
+func (dao *organizationsDAO) GetOrganization(id int64) (api.Organization, error) {
+	row := dao.db.QueryRow(fmt.Sprintf("SELECT id, name, is_deleted, created_ts, updated_ts FROM %s "+
+		"WHERE id = ? AND is_deleted = 0", organizationsTableName), id)
+	organization := api.Organization{}
+	err := row.Scan(&organization.Id, &organization.Name, &organization.IsDeleted, &organization.CreatedTs,
+		&organization.UpdatedTs)
+	if err != nil {
+		return organization, fmt.Errorf("failed to retrieve organization of id %d with err: %v", id, err)
+	}
+	return organization, nil
+}
+
+func (dao *organizationsDAO) ListOrganizations() ([]api.Organization, error) {
+	rows, err := dao.db.Query(fmt.Sprintf("SELECT id, name, is_deleted, created_ts, updated_ts FROM %s "+
+		"WHERE is_deleted = 0 ORDER BY name", organizationsTableName))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list organizations with err: %v", err)
+	}
+	defer rows.Close()
+
+	organizations := []api.Organization{}
+	for rows.Next() {
+		organization := api.Organization{}
+		err = rows.Scan(&organization.Id, &organization.Name, &organization.IsDeleted, &organization.CreatedTs,
+			&organization.UpdatedTs)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read organization with err: %v", err)
+		}
+		organizations = append(organizations, organization)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list organizations with err: %v", err)
+	}
+	return organizations, nil
+}
+
+func (dao *organizationsDAO) InsertOrganization(request api.OrganizationCreationRequest) (int64, error) {
+	result, err := dao.db.Exec(fmt.Sprintf("INSERT INTO %s (name) VALUES(?)", organizationsTableName), *request.Name)
+	if err != nil {
+		return -1, fmt.Errorf("failed to insert new organization due to error %v", err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return -1, fmt.Errorf("failed to retrieve last inserted id %v", err)
+	}
+	return id, nil
+}
+
+func (dao *organizationsDAO) DeleteOrganization(id int64) error {
+	result, err := dao.db.Exec(fmt.Sprintf("UPDATE %s SET is_deleted=1 WHERE id = ? AND is_deleted = 0",
+		organizationsTableName), id)
+	if err != nil {
+		return fmt.Errorf("failed to delete organization %d due to error %v", id, err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to verify if organization %d has been deleted due to error %v", id, err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("WARN: detected request to delete non-existent organization with id %d \n", id)
+	}
+	return nil
+}
+
+func (dao *organizationsDAO) UpdateOrganization(id int64, request api.OrganizationUpdateRequest) error {
+	result, err := dao.db.Exec(fmt.Sprintf("UPDATE %s SET name = ?, updated_ts = current_timestamp "+
+		"WHERE id = ? AND is_deleted = 0", organizationsTableName), *request.Name, id)
+	if err != nil {
+		return fmt.Errorf("failed to update organization %d due to error %v", id, err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to verify if organization %d has been updated due to error %v", id, err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("WARN: detected request to update non-existent organization with id %d \n", id)
+	}
+	return nil
+}
@@ -0,0 +1,165 @@
+package repository
+
+import (
+	"errors"
+	"github.com/DATA-DOG/go-sqlmock"
+	"go-personal-finance/pkg/api"
+	"testing"
+	"time"
+)
+
+func TestOrganizationsDAO_GetOrganization(t *testing.T) {
+
+	db, mock := setupMockDB(t)
+	defer db.Close()
+	dao := organizationsDAO{db: db}
+
+	expectedSelectQuery := "SELECT id, name, is_deleted, created_ts, updated_ts FROM organizations " +
+		"WHERE id = ? AND is_deleted = 0"
+
+	t.Run("testing happy flow", func(t *testing.T) {
+		rows := sqlmock.NewRows([]string{"id", "name", "is_deleted", "created_ts", "updated_ts"}).
+			AddRow(1, "bank", false, time.Now(), time.Now())
+		mock.ExpectQuery(expectedSelectQuery).WithArgs(int64(1)).WillReturnRows(rows)
+
+		organization, err := dao.GetOrganization(1)
+		if err != nil {
+			t.Errorf("Unexpected error when retrieving organization: %v", err)
+			return
+		}
+		if organization.Name != "bank" {
+			t.Errorf("unexpected name returned")
+		}
+		checkingMockExpectations(t, mock)
+	})
+
+	t.Run("testing situation where we fail to retrieve organization", func(t *testing.T) {
+		mock.ExpectQuery(expectedSelectQuery).WillReturnError(errors.New("db timeout"))
+
+		_, err := dao.GetOrganization(1)
+		expectedErrorMsg := "failed to retrieve organization of id 1 with err: db timeout"
+		if expectedErrorMsg != err.Error() {
+			t.Errorf("expected error message %s but found %s", expectedErrorMsg, err.Error())
+		}
+		checkingMockExpectations(t, mock)
+	})
+}
+
+func TestOrganizationsDAO_ListOrganizations(t *testing.T) {
+
+	db, mock := setupMockDB(t)
+	defer db.Close()
+	dao := organizationsDAO{db: db}
+
+	rows := sqlmock.NewRows([]string{"id", "name", "is_deleted", "created_ts", "updated_ts"}).
+		AddRow(1, "bank", false, time.Now(), time.Now()).
+		AddRow(2, "broker", false, time.Now(), time.Now())
+	mock.ExpectQuery("SELECT id, name, is_deleted, created_ts, updated_ts FROM organizations " +
+		"WHERE is_deleted = 0 ORDER BY name").WillReturnRows(rows)
+
+	organizations, err := dao.ListOrganizations()
+	if err != nil {
+		t.Errorf("Unexpected error when listing organizations: %v", err)
+		return
+	}
+	if len(organizations) != 2 {
+		t.Errorf("expected 2 organizations but found %d", len(organizations))
+	}
+	checkingMockExpectations(t, mock)
+}
+
+func TestOrganizationsDAO_InsertOrganization(t *testing.T) {
+
+	db, mock := setupMockDB(t)
+	defer db.Close()
+	dao := organizationsDAO{db: db}
+	name := "bank"
+
+	t.Run("testing happy flow", func(t *testing.T) {
+		mock.ExpectExec("INSERT INTO organizations (name) VALUES(?)").
+			WithArgs(name).
+			WillReturnResult(sqlmock.NewResult(int64(3), 1))
+
+		id, err := dao.InsertOrganization(api.OrganizationCreationRequest{Name: &name})
+		if err != nil {
+			t.Errorf("Unexpected error when trying to insert organization: %v", err)
+			return
+		}
+		if id != 3 {
+			t.Errorf("unexpected id returned")
+		}
+		checkingMockExpectations(t, mock)
+	})
+
+	t.Run("testing general error flow", func(t *testing.T) {
+		mock.ExpectExec("INSERT INTO organizations (name) VALUES(?)").
+			WithArgs(name).
+			WillReturnError(errors.New("db timeout"))
+
+		_, err := dao.InsertOrganization(api.OrganizationCreationRequest{Name: &name})
+		expectedErrorMsg := "failed to insert new organization due to error db timeout"
+		if expectedErrorMsg != err.Error() {
+			t.Errorf("expected error message %s but found %s", expectedErrorMsg, err.Error())
+		}
+		checkingMockExpectations(t, mock)
+	})
+}
+
+func TestOrganizationsDAO_DeleteOrganization(t *testing.T) {
+
+	db, mock := setupMockDB(t)
+	defer db.Close()
+	dao := organizationsDAO{db: db}
+	deleteQuery := "UPDATE organizations SET is_deleted=1 WHERE id = ? AND is_deleted = 0"
+
+	t.Run("testing happy flow", func(t *testing.T) {
+		mock.ExpectExec(deleteQuery).WithArgs(int64(1)).WillReturnResult(sqlmock.NewResult(0, 1))
+
+		err := dao.DeleteOrganization(1)
+		if err != nil {
+			t.Errorf("Unexpected error when trying to delete organization: %v", err)
+		}
+	})
+
+	t.Run("testing deletion of non existent organization", func(t *testing.T) {
+		mock.ExpectExec(deleteQuery).WithArgs(int64(1)).WillReturnResult(sqlmock.NewResult(0, 0))
+
+		err := dao.DeleteOrganization(1)
+		expectedErrorMsg := "WARN: detected request to delete non-existent organization with id 1 \n"
+		if expectedErrorMsg != err.Error() {
+			t.Errorf("expected error message %s but found %s", expectedErrorMsg, err.Error())
+		}
+	})
+
+	checkingMockExpectations(t, mock)
+}
+
+func TestOrganizationsDAO_UpdateOrganization(t *testing.T) {
+
+	db, mock := setupMockDB(t)
+	defer db.Close()
+	dao := organizationsDAO{db: db}
+	name := "bank"
+	updateQuery := "UPDATE organizations SET name = ?, updated_ts = current_timestamp WHERE id = ? AND is_deleted = 0"
+
+	t.Run("testing happy flow", func(t *testing.T) {
+		mock.ExpectExec(updateQuery).WithArgs(name, int64(1)).WillReturnResult(sqlmock.NewResult(0, 1))
+
+		err := dao.UpdateOrganization(1, api.OrganizationUpdateRequest{Name: &name})
+		if err != nil {
+			t.Errorf("Unexpected error when trying to update organization: %v", err)
+		}
+	})
+
+	t.Run("testing situation when organization doesn't exist with that id", func(t *testing.T) {
+		mock.ExpectExec(updateQuery).WithArgs(name, int64(1)).WillReturnResult(sqlmock.NewResult(0, 0))
+
+		err := dao.UpdateOrganization(1, api.OrganizationUpdateRequest{Name: &name})
+		expectedErrorMsg := "WARN: detected request to update non-existent organization with id 1 \n"
+		if expectedErrorMsg != err.Error() {
+			t.Errorf("expected error message %s but found %s", expectedErrorMsg, err.Error())
+		}
+	})
+
+	checkingMockExpectations(t, mock)
+}
@@ -0,0 +1,154 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"go-personal-finance/pkg/api"
+)
+
+const payeesTableName = "payees"
+const payeeRulesTableName = "payee_rules"
+
+type payeesDAO struct {
+	db *sql.DB
+}
+
+func NewPayeesDAO(db *sql.DB) *payeesDAO {
+	return &payeesDAO{db}
+}
+
+func (dao *payeesDAO) GetPayee(id int64) (api.Payee, error) {
+	row := dao.db.QueryRow(fmt.Sprintf("SELECT id, name, is_deleted, created_ts, updated_ts FROM %s "+
+		"WHERE id = ? AND is_deleted = 0", payeesTableName), id)
+	payee := api.Payee{}
+	err := row.Scan(&payee.Id, &payee.Name, &payee.IsDeleted, &payee.CreatedTs, &payee.UpdatedTs)
+	if err != nil {
+		return payee, fmt.Errorf("failed to retrieve payee of id %d with err: %v", id, err)
+	}
+	return payee, nil
+}
+
+func (dao *payeesDAO) ListPayees() ([]api.Payee, error) {
+	rows, err := dao.db.Query(fmt.Sprintf("SELECT id, name, is_deleted, created_ts, updated_ts FROM %s "+
+		"WHERE is_deleted = 0 ORDER BY name", payeesTableName))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list payees with err: %v", err)
+	}
+	defer rows.Close()
+
+	payees := []api.Payee{}
+	for rows.Next() {
+		payee := api.Payee{}
+		err = rows.Scan(&payee.Id, &payee.Name, &payee.IsDeleted, &payee.CreatedTs, &payee.UpdatedTs)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read payee with err: %v", err)
+		}
+		payees = append(payees, payee)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list payees with err: %v", err)
+	}
+	return payees, nil
+}
+
+func (dao *payeesDAO) InsertPayee(request api.PayeeCreationRequest) (int64, error) {
+	result, err := dao.db.Exec(fmt.Sprintf("INSERT INTO %s (name) VALUES(?)", payeesTableName), *request.Name)
+	if err != nil {
+		return -1, fmt.Errorf("failed to insert new payee due to error %v", err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return -1, fmt.Errorf("failed to retrieve last inserted id %v", err)
+	}
+	return id, nil
+}
+
+func (dao *payeesDAO) DeletePayee(id int64) error {
+	result, err := dao.db.Exec(fmt.Sprintf("UPDATE %s SET is_deleted=1 WHERE id = ? AND is_deleted = 0",
+		payeesTableName), id)
+	if err != nil {
+		return fmt.Errorf("failed to delete payee %d due to error %v", id, err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to verify if payee %d has been deleted due to error %v", id, err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("WARN: detected request to delete non-existent payee with id %d \n", id)
+	}
+	return nil
+}
+
+func (dao *payeesDAO) UpdatePayee(id int64, request api.PayeeUpdateRequest) error {
+	result, err := dao.db.Exec(fmt.Sprintf("UPDATE %s SET name = ?, updated_ts = current_timestamp "+
+		"WHERE id = ? AND is_deleted = 0", payeesTableName), *request.Name, id)
+	if err != nil {
+		return fmt.Errorf("failed to update payee %d due to error %v", id, err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to verify if payee %d has been updated due to error %v", id, err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("WARN: detected request to update non-existent payee with id %d \n", id)
+	}
+	return nil
+}
+
+// ListPayeeRules skips rules whose payee has been deleted so that they no longer match.
+func (dao *payeesDAO) ListPayeeRules() ([]api.PayeeRule, error) {
+	rows, err := dao.db.Query(fmt.Sprintf("SELECT r.id, r.payee_id, r.match_type, r.pattern, r.priority, "+
+		"r.created_ts FROM %s r JOIN %s p ON p.id = r.payee_id WHERE r.is_deleted = 0 AND p.is_deleted = 0 "+
+		"ORDER BY r.priority DESC, r.id", payeeRulesTableName, payeesTableName))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list payee rules with err: %v", err)
+	}
+	defer rows.Close()
+
+	rules := []api.PayeeRule{}
+	for rows.Next() {
+		rule := api.PayeeRule{}
+		err = rows.Scan(&rule.Id, &rule.PayeeId, &rule.MatchType, &rule.Pattern, &rule.Priority, &rule.CreatedTs)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read payee rule with err: %v", err)
+		}
+		rules = append(rules, rule)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list payee rules with err: %v", err)
+	}
+	return rules, nil
+}
+
+func (dao *payeesDAO) InsertPayeeRule(request api.PayeeRuleCreationRequest) (int64, error) {
+	var priority int64
+	if request.Priority != nil {
+		priority = *request.Priority
+	}
+	result, err := dao.db.Exec(fmt.Sprintf("INSERT INTO %s (payee_id, match_type, pattern, priority) "+
+		"VALUES(?,?,?,?)", payeeRulesTableName), *request.PayeeId, *request.MatchType, *request.Pattern, priority)
+	if err != nil {
+		return -1, fmt.Errorf("failed to insert new payee rule due to error %v", err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return -1, fmt.Errorf("failed to retrieve last inserted id %v", err)
+	}
+	return id, nil
+}
+
+func (dao *payeesDAO) DeletePayeeRule(id int64) error {
+	result, err := dao.db.Exec(fmt.Sprintf("UPDATE %s SET is_deleted=1 WHERE id = ? AND is_deleted = 0",
+		payeeRulesTableName), id)
+	if err != nil {
+		return fmt.Errorf("failed to delete payee rule %d due to error %v", id, err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to verify if payee rule %d has been deleted due to error %v", id, err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("WARN: detected request to delete non-existent payee rule with id %d \n", id)
+	}
+	return nil
+}
@@ -0,0 +1,146 @@
+package repository
+
+import (
+	"errors"
+	"github.com/DATA-DOG/go-sqlmock"
+	"go-personal-finance/pkg/api"
+	"testing"
+	"time"
+)
+
+func TestPayeesDAO_GetPayee(t *testing.T) {
+
+	db, mock := setupMockDB(t)
+	defer db.Close()
+	dao := payeesDAO{db: db}
+
+	expectedSelectQuery := "SELECT id, name, is_deleted, created_ts, updated_ts FROM payees " +
+		"WHERE id = ? AND is_deleted = 0"
+
+	t.Run("testing happy flow", func(t *testing.T) {
+		rows := sqlmock.NewRows([]string{"id", "name", "is_deleted", "created_ts", "updated_ts"}).
+			AddRow(1, "Amazon", false, time.Now(), time.Now())
+		mock.ExpectQuery(expectedSelectQuery).WithArgs(int64(1)).WillReturnRows(rows)
+
+		payee, err := dao.GetPayee(1)
+		if err != nil {
+			t.Errorf("Unexpected error when retrieving payee: %v", err)
+			return
+		}
+		if payee.Name != "Amazon" {
+			t.Errorf("unexpected name returned")
+		}
+		checkingMockExpectations(t, mock)
+	})
+
+	t.Run("testing situation where we fail to retrieve payee", func(t *testing.T) {
+		mock.ExpectQuery(expectedSelectQuery).WillReturnError(errors.New("db timeout"))
+
+		_, err := dao.GetPayee(1)
+		expectedErrorMsg := "failed to retrieve payee of id 1 with err: db timeout"
+		if expectedErrorMsg != err.Error() {
+			t.Errorf("expected error message %s but found %s", expectedErrorMsg, err.Error())
+		}
+		checkingMockExpectations(t, mock)
+	})
+}
+
+func TestPayeesDAO_InsertPayee(t *testing.T) {
+
+	db, mock := setupMockDB(t)
+	defer db.Close()
+	dao := payeesDAO{db: db}
+	name := "Amazon"
+
+	mock.ExpectExec("INSERT INTO payees (name) VALUES(?)").
+		WithArgs(name).
+		WillReturnResult(sqlmock.NewResult(int64(4), 1))
+
+	id, err := dao.InsertPayee(api.PayeeCreationRequest{Name: &name})
+	if err != nil {
+		t.Errorf("Unexpected error when trying to insert payee: %v", err)
+		return
+	}
+	if id != 4 {
+		t.Errorf("unexpected id returned")
+	}
+	checkingMockExpectations(t, mock)
+}
+
+func TestPayeesDAO_DeletePayee(t *testing.T) {
+
+	db, mock := setupMockDB(t)
+	defer db.Close()
+	dao := payeesDAO{db: db}
+
+	mock.ExpectExec("UPDATE payees SET is_deleted=1 WHERE id = ? AND is_deleted = 0").
+		WithArgs(int64(1)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	err := dao.DeletePayee(1)
+	expectedErrorMsg := "WARN: detected request to delete non-existent payee with id 1 \n"
+	if err == nil || expectedErrorMsg != err.Error() {
+		t.Errorf("expected error message %s but found %v", expectedErrorMsg, err)
+	}
+	checkingMockExpectations(t, mock)
+}
+
+func TestPayeesDAO_ListPayeeRules(t *testing.T) {
+
+	db, mock := setupMockDB(t)
+	defer db.Close()
+	dao := payeesDAO{db: db}
+
+	rows := sqlmock.NewRows([]string{"id", "payee_id", "match_type", "pattern", "priority", "created_ts"}).
+		AddRow(2, 1, "regex", "^AMZN", 5, time.Now()).
+		AddRow(1, 1, "contains", "AMAZON", 0, time.Now())
+	mock.ExpectQuery("SELECT r.id, r.payee_id, r.match_type, r.pattern, r.priority, r.created_ts FROM payee_rules r " +
+		"JOIN payees p ON p.id = r.payee_id WHERE r.is_deleted = 0 AND p.is_deleted = 0 " +
+		"ORDER BY r.priority DESC, r.id").WillReturnRows(rows)
+
+	rules, err := dao.ListPayeeRules()
+	if err != nil {
+		t.Errorf("Unexpected error when listing payee rules: %v", err)
+		return
+	}
+	if len(rules) != 2 || rules[0].MatchType != api.PayeeRuleMatchRegex {
+		t.Errorf("unexpected rules returned %v", rules)
+	}
+	checkingMockExpectations(t, mock)
+}
+
+func TestPayeesDAO_InsertPayeeRule(t *testing.T) {
+
+	db, mock := setupMockDB(t)
+	defer db.Close()
+	dao := payeesDAO{db: db}
+	payeeId := int64(1)
+	matchType := api.PayeeRuleMatchPrefix
+	pattern := "AMZN"
+
+	t.Run("testing priority defaults to zero", func(t *testing.T) {
+		mock.ExpectExec("INSERT INTO payee_rules (payee_id, match_type, pattern, priority) VALUES(?,?,?,?)").
+			WithArgs(payeeId, matchType, pattern, int64(0)).
+			WillReturnResult(sqlmock.NewResult(int64(1), 1))
+
+		_, err := dao.InsertPayeeRule(api.PayeeRuleCreationRequest{PayeeId: &payeeId, MatchType: &matchType,
+			Pattern: &pattern})
+		if err != nil {
+			t.Errorf("Unexpected error when trying to insert payee rule: %v", err)
+		}
+		checkingMockExpectations(t, mock)
+	})
+
+	t.Run("testing general error flow", func(t *testing.T) {
+		mock.ExpectExec("INSERT INTO payee_rules (payee_id, match_type, pattern, priority) VALUES(?,?,?,?)").
+			WillReturnError(errors.New("db timeout"))
+
+		_, err := dao.InsertPayeeRule(api.PayeeRuleCreationRequest{PayeeId: &payeeId, MatchType: &matchType,
+			Pattern: &pattern})
+		expectedErrorMsg := "failed to insert new payee rule due to error db timeout"
+		if err == nil || expectedErrorMsg != err.Error() {
+			t.Errorf("expected error message %s but found %v", expectedErrorMsg, err)
+		}
+		checkingMockExpectations(t, mock)
+	})
+}
@@ -0,0 +1,154 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"go-personal-finance/pkg/api"
+)
+
+const recurringTransactionsTableName = "recurring_transactions"
+
+const recurringTransactionColumns = "id, name, account_id, description, memo, amount, currency, payee_id, " +
+	"category_id, frequency, interval_count, week_of_month, day_of_week, start_date, end_date, next_date, " +
+	"is_deleted, created_ts, updated_ts"
+
+type recurringTransactionsDAO struct {
+	db *sql.DB
+}
+
+func NewRecurringTransactionsDAO(db *sql.DB) *recurringTransactionsDAO {
+	return &recurringTransactionsDAO{db}
+}
+
+func scanRecurringTransaction(row rowScanner) (api.RecurringTransaction, error) {
+	recurring := api.RecurringTransaction{}
+	var description, memo, currency, endDate, nextDate sql.NullString
+	var payeeId, categoryId, weekOfMonth, dayOfWeek sql.NullInt64
+	err := row.Scan(&recurring.Id, &recurring.Name, &recurring.AccountId, &description, &memo, &recurring.Amount,
+		&currency, &payeeId, &categoryId, &recurring.Frequency, &recurring.Interval, &weekOfMonth, &dayOfWeek,
+		&recurring.StartDate, &endDate, &nextDate, &recurring.IsDeleted, &recurring.CreatedTs, &recurring.UpdatedTs)
+	if err != nil {
+		return recurring, err
+	}
+	recurring.Description = description.String
+	recurring.Memo = memo.String
+	recurring.Currency = currency.String
+	recurring.PayeeId = payeeId.Int64
+	recurring.CategoryId = categoryId.Int64
+	recurring.WeekOfMonth = int(weekOfMonth.Int64)
+	recurring.DayOfWeek = int(dayOfWeek.Int64)
+	recurring.EndDate = endDate.String
+	recurring.NextDate = nextDate.String
+	return recurring, nil
+}
+
+func (dao *recurringTransactionsDAO) GetRecurringTransaction(id int64) (api.RecurringTransaction, error) {
+	row := dao.db.QueryRow(fmt.Sprintf("SELECT %s FROM %s WHERE id = ? AND is_deleted = 0",
+		recurringTransactionColumns, recurringTransactionsTableName), id)
+	recurring, err := scanRecurringTransaction(row)
+	if err != nil {
+		return recurring, fmt.Errorf("failed to retrieve recurring transaction of id %d with err: %v", id, err)
+	}
+	return recurring, nil
+}
+
+func (dao *recurringTransactionsDAO) ListRecurringTransactions() ([]api.RecurringTransaction, error) {
+	rows, err := dao.db.Query(fmt.Sprintf("SELECT %s FROM %s WHERE is_deleted = 0 ORDER BY id",
+		recurringTransactionColumns, recurringTransactionsTableName))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list recurring transactions with err: %v", err)
+	}
+	defer rows.Close()
+
+	recurringTransactions := []api.RecurringTransaction{}
+	for rows.Next() {
+		recurring, err := scanRecurringTransaction(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read recurring transaction with err: %v", err)
+		}
+		recurringTransactions = append(recurringTransactions, recurring)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list recurring transactions with err: %v", err)
+	}
+	return recurringTransactions, nil
+}
+
+// InsertRecurringTransaction assumes the defaults of the request have been filled in by the service.
+func (dao *recurringTransactionsDAO) InsertRecurringTransaction(request api.RecurringTransactionRequest,
+	nextDate *string) (int64, error) {
+	result, err := dao.db.Exec(fmt.Sprintf("INSERT INTO %s (name, account_id, description, memo, amount, currency, "+
+		"payee_id, category_id, frequency, interval_count, week_of_month, day_of_week, start_date, end_date, "+
+		"next_date) VALUES(?,?,?,?,?,?,?,?,?,?,?,?,?,?,?)", recurringTransactionsTableName), *request.Name,
+		*request.AccountId, NewNullString(request.Description), NewNullString(request.Memo), *request.Amount,
+		NewNullString(request.Currency), NewNullInt64(request.PayeeId), NewNullInt64(request.CategoryId),
+		*request.Frequency, *request.Interval, NewNullInt(request.WeekOfMonth), NewNullInt(request.DayOfWeek),
+		*request.StartDate, NewNullString(request.EndDate), NewNullString(nextDate))
+	if err != nil {
+		return -1, fmt.Errorf("failed to insert new recurring transaction due to error %v", err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return -1, fmt.Errorf("failed to retrieve last inserted id %v", err)
+	}
+	return id, nil
+}
+
+// UpdateRecurringTransaction assumes the defaults of the request have been filled in by the service.
+func (dao *recurringTransactionsDAO) UpdateRecurringTransaction(id int64, request api.RecurringTransactionRequest,
+	nextDate *string) error {
+	result, err := dao.db.Exec(fmt.Sprintf("UPDATE %s SET name = ?, account_id = ?, description = ?, memo = ?, "+
+		"amount = ?, currency = ?, payee_id = ?, category_id = ?, frequency = ?, interval_count = ?, "+
+		"week_of_month = ?, day_of_week = ?, start_date = ?, end_date = ?, next_date = ?, "+
+		"updated_ts = current_timestamp WHERE id = ? AND is_deleted = 0", recurringTransactionsTableName),
+		*request.Name, *request.AccountId, NewNullString(request.Description), NewNullString(request.Memo),
+		*request.Amount, NewNullString(request.Currency), NewNullInt64(request.PayeeId),
+		NewNullInt64(request.CategoryId), *request.Frequency, *request.Interval, NewNullInt(request.WeekOfMonth),
+		NewNullInt(request.DayOfWeek), *request.StartDate, NewNullString(request.EndDate), NewNullString(nextDate),
+		id)
+	if err != nil {
+		return fmt.Errorf("failed to update recurring transaction %d due to error %v", id, err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to verify if recurring transaction %d has been updated due to error %v", id, err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("WARN: detected request to update non-existent recurring transaction with id %d \n", id)
+	}
+	return nil
+}
+
+func (dao *recurringTransactionsDAO) DeleteRecurringTransaction(id int64) error {
+	result, err := dao.db.Exec(fmt.Sprintf("UPDATE %s SET is_deleted = 1, updated_ts = current_timestamp "+
+		"WHERE id = ? AND is_deleted = 0", recurringTransactionsTableName), id)
+	if err != nil {
+		return fmt.Errorf("failed to delete recurring transaction %d due to error %v", id, err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to verify if recurring transaction %d has been deleted due to error %v", id, err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("WARN: detected request to delete non-existent recurring transaction with id %d \n", id)
+	}
+	return nil
+}
+
+// AdvanceRecurringTransaction moves the next occurrence on once the one before has been materialized. A nil next
+// date ends the schedule.
+func (dao *recurringTransactionsDAO) AdvanceRecurringTransaction(id int64, nextDate *string) error {
+	result, err := dao.db.Exec(fmt.Sprintf("UPDATE %s SET next_date = ?, updated_ts = current_timestamp "+
+		"WHERE id = ? AND is_deleted = 0", recurringTransactionsTableName), NewNullString(nextDate), id)
+	if err != nil {
+		return fmt.Errorf("failed to advance recurring transaction %d due to error %v", id, err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to verify if recurring transaction %d has been advanced due to error %v", id, err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("WARN: detected request to advance non-existent recurring transaction with id %d \n", id)
+	}
+	return nil
+}
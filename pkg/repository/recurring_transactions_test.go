@@ -0,0 +1,228 @@
+package repository
+
+import (
+	"errors"
+	"github.com/DATA-DOG/go-sqlmock"
+	"go-personal-finance/pkg/api"
+	"testing"
+	"time"
+)
+
+const expectedRecurringTransactionColumns = "id, name, account_id, description, memo, amount, currency, payee_id, " +
+	"category_id, frequency, interval_count, week_of_month, day_of_week, start_date, end_date, next_date, " +
+	"is_deleted, created_ts, updated_ts"
+
+var recurringTransactionRowColumns = []string{"id", "name", "account_id", "description", "memo", "amount",
+	"currency", "payee_id", "category_id", "frequency", "interval_count", "week_of_month", "day_of_week",
+	"start_date", "end_date", "next_date", "is_deleted", "created_ts", "updated_ts"}
+
+func TestRecurringTransactionsDAO_GetRecurringTransaction(t *testing.T) {
+
+	db, mock := setupMockDB(t)
+	defer db.Close()
+	dao := recurringTransactionsDAO{db: db}
+
+	expectedSelectQuery := "SELECT " + expectedRecurringTransactionColumns +
+		" FROM recurring_transactions WHERE id = ? AND is_deleted = 0"
+
+	t.Run("testing happy flow", func(t *testing.T) {
+		rows := sqlmock.NewRows(recurringTransactionRowColumns).AddRow(1, "Rent", 1, nil, nil, -150000, nil, 3, nil,
+			"monthly", 1, 2, 2, "2022-07-01", nil, "2022-07-12", false, time.Now(), time.Now())
+		mock.ExpectQuery(expectedSelectQuery).WithArgs(int64(1)).WillReturnRows(rows)
+
+		recurring, err := dao.GetRecurringTransaction(1)
+		if err != nil {
+			t.Errorf("Unexpected error when retrieving recurring transaction: %v", err)
+			return
+		}
+		if recurring.Amount != -150000 || recurring.PayeeId != 3 || recurring.CategoryId != 0 ||
+			recurring.WeekOfMonth != 2 || recurring.DayOfWeek != 2 || recurring.EndDate != "" ||
+			recurring.NextDate != "2022-07-12" {
+			t.Errorf("unexpected recurring transaction %+v", recurring)
+		}
+		checkingMockExpectations(t, mock)
+	})
+
+	t.Run("testing situation where we fail to retrieve recurring transaction", func(t *testing.T) {
+		mock.ExpectQuery(expectedSelectQuery).WillReturnError(errors.New("db timeout"))
+
+		_, err := dao.GetRecurringTransaction(1)
+		expectedErrorMsg := "failed to retrieve recurring transaction of id 1 with err: db timeout"
+		if err == nil || expectedErrorMsg != err.Error() {
+			t.Errorf("expected error message %s but found %v", expectedErrorMsg, err)
+		}
+		checkingMockExpectations(t, mock)
+	})
+}
+
+func TestRecurringTransactionsDAO_ListRecurringTransactions(t *testing.T) {
+
+	db, mock := setupMockDB(t)
+	defer db.Close()
+	dao := recurringTransactionsDAO{db: db}
+
+	t.Run("testing happy flow", func(t *testing.T) {
+		rows := sqlmock.NewRows(recurringTransactionRowColumns).
+			AddRow(1, "Rent", 1, nil, nil, -150000, nil, 3, nil, "monthly", 1, nil, nil, "2022-07-01", nil,
+				"2022-08-01", false, time.Now(), time.Now()).
+			AddRow(2, "Gym", 1, "GYM", "membership", -3000, "EUR", nil, 4, "monthly", 1, nil, nil, "2021-01-01",
+				"2021-12-31", nil, false, time.Now(), time.Now())
+		mock.ExpectQuery("SELECT " + expectedRecurringTransactionColumns +
+			" FROM recurring_transactions WHERE is_deleted = 0 ORDER BY id").
+			WillReturnRows(rows)
+
+		recurringTransactions, err := dao.ListRecurringTransactions()
+		if err != nil {
+			t.Errorf("Unexpected error when listing recurring transactions: %v", err)
+			return
+		}
+		if len(recurringTransactions) != 2 || recurringTransactions[1].Currency != "EUR" ||
+			recurringTransactions[1].EndDate != "2021-12-31" || recurringTransactions[1].NextDate != "" {
+			t.Errorf("unexpected recurring transactions %+v", recurringTransactions)
+		}
+		checkingMockExpectations(t, mock)
+	})
+}
+
+func TestRecurringTransactionsDAO_InsertRecurringTransaction(t *testing.T) {
+
+	db, mock := setupMockDB(t)
+	defer db.Close()
+	dao := recurringTransactionsDAO{db: db}
+
+	name, accountId, amount, frequency := "Rent", int64(1), api.Money(-150000), "monthly"
+	interval, weekOfMonth, dayOfWeek, startDate, nextDate := 1, 2, 2, "2022-07-20", "2022-08-09"
+	insertQuery := "INSERT INTO recurring_transactions (name, account_id, description, memo, amount, currency, " +
+		"payee_id, category_id, frequency, interval_count, week_of_month, day_of_week, start_date, end_date, " +
+		"next_date) VALUES(?,?,?,?,?,?,?,?,?,?,?,?,?,?,?)"
+	request := api.RecurringTransactionRequest{Name: &name, AccountId: &accountId, Amount: &amount,
+		Frequency: &frequency, Interval: &interval, WeekOfMonth: &weekOfMonth, DayOfWeek: &dayOfWeek,
+		StartDate: &startDate}
+
+	t.Run("testing happy flow", func(t *testing.T) {
+		mock.ExpectExec(insertQuery).WithArgs(name, accountId, nil, nil, amount, nil, nil, nil, frequency, interval,
+			int64(weekOfMonth), int64(dayOfWeek), startDate, nil, nextDate).
+			WillReturnResult(sqlmock.NewResult(int64(3), 1))
+
+		id, err := dao.InsertRecurringTransaction(request, &nextDate)
+		if err != nil {
+			t.Errorf("Unexpected error when trying to insert recurring transaction: %v", err)
+			return
+		}
+		if id != 3 {
+			t.Errorf("unexpected id returned")
+		}
+		checkingMockExpectations(t, mock)
+	})
+
+	t.Run("testing general error flow", func(t *testing.T) {
+		mock.ExpectExec(insertQuery).WillReturnError(errors.New("db timeout"))
+
+		_, err := dao.InsertRecurringTransaction(request, &nextDate)
+		expectedErrorMsg := "failed to insert new recurring transaction due to error db timeout"
+		if err == nil || expectedErrorMsg != err.Error() {
+			t.Errorf("expected error message %s but found %v", expectedErrorMsg, err)
+		}
+		checkingMockExpectations(t, mock)
+	})
+}
+
+func TestRecurringTransactionsDAO_UpdateRecurringTransaction(t *testing.T) {
+
+	db, mock := setupMockDB(t)
+	defer db.Close()
+	dao := recurringTransactionsDAO{db: db}
+
+	name, accountId, amount, frequency := "Cleaner", int64(1), api.Money(-4500), "weekly"
+	interval, startDate, endDate := 2, "2022-07-06", "2022-12-31"
+	updateQuery := "UPDATE recurring_transactions SET name = ?, account_id = ?, description = ?, memo = ?, " +
+		"amount = ?, currency = ?, payee_id = ?, category_id = ?, frequency = ?, interval_count = ?, " +
+		"week_of_month = ?, day_of_week = ?, start_date = ?, end_date = ?, next_date = ?, " +
+		"updated_ts = current_timestamp WHERE id = ? AND is_deleted = 0"
+	request := api.RecurringTransactionRequest{Name: &name, AccountId: &accountId, Amount: &amount,
+		Frequency: &frequency, Interval: &interval, StartDate: &startDate, EndDate: &endDate}
+
+	t.Run("testing happy flow of a schedule that has ended", func(t *testing.T) {
+		mock.ExpectExec(updateQuery).WithArgs(name, accountId, nil, nil, amount, nil, nil, nil, frequency, interval,
+			nil, nil, startDate, endDate, nil, int64(2)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		err := dao.UpdateRecurringTransaction(2, request, nil)
+		if err != nil {
+			t.Errorf("Unexpected error when trying to update recurring transaction: %v", err)
+		}
+		checkingMockExpectations(t, mock)
+	})
+
+	t.Run("testing situation when recurring transaction doesn't exist with that id", func(t *testing.T) {
+		mock.ExpectExec(updateQuery).WillReturnResult(sqlmock.NewResult(0, 0))
+
+		err := dao.UpdateRecurringTransaction(2, request, nil)
+		expectedErrorMsg := "WARN: detected request to update non-existent recurring transaction with id 2 \n"
+		if err == nil || expectedErrorMsg != err.Error() {
+			t.Errorf("expected error message %s but found %v", expectedErrorMsg, err)
+		}
+		checkingMockExpectations(t, mock)
+	})
+}
+
+func TestRecurringTransactionsDAO_DeleteRecurringTransaction(t *testing.T) {
+
+	db, mock := setupMockDB(t)
+	defer db.Close()
+	dao := recurringTransactionsDAO{db: db}
+	deleteQuery := "UPDATE recurring_transactions SET is_deleted = 1, updated_ts = current_timestamp " +
+		"WHERE id = ? AND is_deleted = 0"
+
+	t.Run("testing happy flow", func(t *testing.T) {
+		mock.ExpectExec(deleteQuery).WithArgs(int64(1)).WillReturnResult(sqlmock.NewResult(0, 1))
+
+		err := dao.DeleteRecurringTransaction(1)
+		if err != nil {
+			t.Errorf("Unexpected error when trying to delete recurring transaction: %v", err)
+		}
+	})
+
+	t.Run("testing deletion of non existent recurring transaction", func(t *testing.T) {
+		mock.ExpectExec(deleteQuery).WithArgs(int64(1)).WillReturnResult(sqlmock.NewResult(0, 0))
+
+		err := dao.DeleteRecurringTransaction(1)
+		expectedErrorMsg := "WARN: detected request to delete non-existent recurring transaction with id 1 \n"
+		if err == nil || expectedErrorMsg != err.Error() {
+			t.Errorf("expected error message %s but found %v", expectedErrorMsg, err)
+		}
+	})
+
+	checkingMockExpectations(t, mock)
+}
+
+func TestRecurringTransactionsDAO_AdvanceRecurringTransaction(t *testing.T) {
+
+	db, mock := setupMockDB(t)
+	defer db.Close()
+	dao := recurringTransactionsDAO{db: db}
+	advanceQuery := "UPDATE recurring_transactions SET next_date = ?, updated_ts = current_timestamp " +
+		"WHERE id = ? AND is_deleted = 0"
+
+	t.Run("testing happy flow", func(t *testing.T) {
+		nextDate := "2022-08-01"
+		mock.ExpectExec(advanceQuery).WithArgs(nextDate, int64(1)).WillReturnResult(sqlmock.NewResult(0, 1))
+
+		err := dao.AdvanceRecurringTransaction(1, &nextDate)
+		if err != nil {
+			t.Errorf("Unexpected error when trying to advance recurring transaction: %v", err)
+		}
+	})
+
+	t.Run("testing general error flow", func(t *testing.T) {
+		mock.ExpectExec(advanceQuery).WithArgs(nil, int64(1)).WillReturnError(errors.New("db timeout"))
+
+		err := dao.AdvanceRecurringTransaction(1, nil)
+		expectedErrorMsg := "failed to advance recurring transaction 1 due to error db timeout"
+		if err == nil || expectedErrorMsg != err.Error() {
+			t.Errorf("expected error message %s but found %v", expectedErrorMsg, err)
+		}
+	})
+
+	checkingMockExpectations(t, mock)
+}
@@ -0,0 +1,123 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"go-personal-finance/pkg/api"
+	"time"
+)
+
+type reportsDAO struct {
+	db *sql.DB
+}
+
+func NewReportsDAO(db *sql.DB) *reportsDAO {
+	return &reportsDAO{db}
+}
+
+// ListReportAccounts returns every account that is not deleted, without paging, along with the deleted accounts that
+// have non deleted transactions made before to.
+func (dao *reportsDAO) ListReportAccounts(to time.Time) ([]api.Account, error) {
+	rows, err := dao.db.Query(fmt.Sprintf("SELECT %s FROM %s WHERE is_deleted = 0 OR EXISTS(SELECT 1 FROM %s "+
+		"WHERE account_id = %s.id AND is_deleted = 0 AND transaction_time < ?) ORDER BY id", accountColumns,
+		accountsTableName, transactionsTableName, accountsTableName), NewDBTime(to))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list accounts with err: %v", err)
+	}
+	defer rows.Close()
+
+	accounts := []api.Account{}
+	for rows.Next() {
+		account, err := scanAccount(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read account with err: %v", err)
+		}
+		accounts = append(accounts, account)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list accounts with err: %v", err)
+	}
+	return accounts, nil
+}
+
+// SumAmountsByAccountAndDay totals the amounts of non deleted transactions made before to per account and day,
+// ordered by day.
+func (dao *reportsDAO) SumAmountsByAccountAndDay(to time.Time) ([]api.AccountDailyTotal, error) {
+	rows, err := dao.db.Query(fmt.Sprintf("SELECT account_id, substr(transaction_time, 1, 10), SUM(amount) FROM %s "+
+		"WHERE is_deleted = 0 AND transaction_time < ? GROUP BY 1, 2 ORDER BY 2, 1", transactionsTableName),
+		NewDBTime(to))
+	if err != nil {
+		return nil, fmt.Errorf("failed to sum transactions by account and day with err: %v", err)
+	}
+	defer rows.Close()
+
+	var totals []api.AccountDailyTotal
+	for rows.Next() {
+		var total api.AccountDailyTotal
+		if err = rows.Scan(&total.AccountId, &total.Day, &total.Amount); err != nil {
+			return nil, fmt.Errorf("failed to read daily total with err: %v", err)
+		}
+		totals = append(totals, total)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to sum transactions by account and day with err: %v", err)
+	}
+	return totals, nil
+}
+
+// cashFlowGroup selects the id and name of the group of a transaction, along with the join that brings in the name.
+type cashFlowGroup struct {
+	idColumn   string
+	nameColumn string
+	join       string
+}
+
+// cashFlowGroups maps the groupings accepted by the api onto columns so that user input never reaches the query.
+var cashFlowGroups = map[string]cashFlowGroup{
+	api.CashFlowGroupByCategory: {"COALESCE(t.category_id, 0)", "''", ""},
+	api.CashFlowGroupByPayee: {"COALESCE(t.payee, 0)", "COALESCE(p.name, '')",
+		fmt.Sprintf(" LEFT JOIN %s p ON p.id = t.payee", payeesTableName)},
+	api.CashFlowGroupByTag: {"0", "COALESCE(tt.tag_name, '')", splitTagsJoin},
+}
+
+// splitTagsJoin brings in the tags of a transaction along with the tags of each of its splits that the transaction
+// does not have itself, so that a split counts once towards every tag of the transaction and of its own.
+var splitTagsJoin = fmt.Sprintf(" LEFT JOIN (SELECT transaction_id, 0 AS split_id, tag_name FROM %s UNION ALL "+
+	"SELECT s.transaction_id, s.id, st.tag_name FROM %s st JOIN %s s ON s.id = st.split_id WHERE st.tag_name NOT IN "+
+	"(SELECT tag_name FROM %s WHERE transaction_id = s.transaction_id)) tt ON tt.transaction_id = t.id "+
+	"AND tt.split_id IN (0, t.split_id)", transactionTagsTableName, transactionSplitTagsTableName,
+	transactionSplitsTableName, transactionTagsTableName)
+
+// SumCashFlow totals the income and expenses of non deleted transactions over [from, to) per group, currency and day.
+// Split transactions count by their splits, so each split is income or an expense of its own category. Transfers are
+// left out, and amounts are in the currency of their account, like the balances are.
+func (dao *reportsDAO) SumCashFlow(from time.Time, to time.Time, groupBy string) ([]api.CashFlowTotal, error) {
+	group, ok := cashFlowGroups[groupBy]
+	if !ok {
+		return nil, fmt.Errorf("failed to sum cash flow of unknown grouping %s", groupBy)
+	}
+	rows, err := dao.db.Query(fmt.Sprintf("SELECT %s, %s, a.currency, "+
+		"substr(t.transaction_time, 1, 10), SUM(CASE WHEN t.amount > 0 THEN t.amount ELSE 0 END), "+
+		"SUM(CASE WHEN t.amount < 0 THEN -t.amount ELSE 0 END) FROM %s t JOIN %s a ON a.id = t.account_id%s "+
+		"WHERE t.is_deleted = 0 AND t.type != ? AND t.transaction_time >= ? AND t.transaction_time < ? "+
+		"GROUP BY 1, 2, 3, 4", group.idColumn, group.nameColumn, splitTransactions, accountsTableName,
+		group.join), api.TransferTransactionType, NewDBTime(from), NewDBTime(to))
+	if err != nil {
+		return nil, fmt.Errorf("failed to sum cash flow with err: %v", err)
+	}
+	defer rows.Close()
+
+	var totals []api.CashFlowTotal
+	for rows.Next() {
+		var total api.CashFlowTotal
+		err = rows.Scan(&total.GroupId, &total.Group, &total.Currency, &total.Day, &total.Income, &total.Expenses)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read cash flow total with err: %v", err)
+		}
+		totals = append(totals, total)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to sum cash flow with err: %v", err)
+	}
+	return totals, nil
+}
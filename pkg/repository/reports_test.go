@@ -0,0 +1,176 @@
+package repository
+
+import (
+	"errors"
+	"github.com/DATA-DOG/go-sqlmock"
+	"go-personal-finance/pkg/api"
+	"testing"
+	"time"
+)
+
+func TestReportsDAO_ListReportAccounts(t *testing.T) {
+
+	db, mock := setupMockDB(t)
+	defer db.Close()
+	dao := reportsDAO{db: db}
+
+	accountColumns := []string{"id", "name", "type", "subtype", "org_id", "opening_balance", "current_balance",
+		"external_account_id", "currency", "is_deleted", "created_ts", "updated_ts"}
+	expectedQuery := "SELECT id, name, type, subtype, org_id, opening_balance, current_balance, " +
+		"external_account_id, currency, is_deleted, created_ts, updated_ts FROM accounts WHERE is_deleted = 0 " +
+		"OR EXISTS(SELECT 1 FROM transactions WHERE account_id = accounts.id AND is_deleted = 0 " +
+		"AND transaction_time < ?) ORDER BY id"
+	to := time.Date(2022, 7, 21, 0, 0, 0, 0, time.UTC)
+
+	t.Run("testing happy flow", func(t *testing.T) {
+		mock.ExpectQuery(expectedQuery).
+			WithArgs(NewDBTime(to)).
+			WillReturnRows(sqlmock.NewRows(accountColumns).
+				AddRow(1, "checking", "checking", nil, int64(1), 10000, 12000, nil, "USD", false, time.Now(),
+					time.Now()).
+				AddRow(2, "visa", "credit card", nil, int64(1), 0, -3000, nil, "USD", true, time.Now(),
+					time.Now()))
+
+		accounts, err := dao.ListReportAccounts(to)
+		if err != nil {
+			t.Errorf("Unexpected error when listing accounts: %v", err)
+			return
+		}
+		if len(accounts) != 2 || accounts[0].OpeningBalance != 10000 || accounts[1].AccountType != "credit card" ||
+			!accounts[1].IsDeleted {
+			t.Errorf("unexpected accounts %+v", accounts)
+		}
+		checkingMockExpectations(t, mock)
+	})
+
+	t.Run("testing general error flow", func(t *testing.T) {
+		mock.ExpectQuery(expectedQuery).
+			WithArgs(NewDBTime(to)).
+			WillReturnError(errors.New("db timeout"))
+
+		_, err := dao.ListReportAccounts(to)
+		expectedErrorMsg := "failed to list accounts with err: db timeout"
+		if err == nil || expectedErrorMsg != err.Error() {
+			t.Errorf("expected error message %s but found %v", expectedErrorMsg, err)
+		}
+		checkingMockExpectations(t, mock)
+	})
+}
+
+func TestReportsDAO_SumAmountsByAccountAndDay(t *testing.T) {
+
+	db, mock := setupMockDB(t)
+	defer db.Close()
+	dao := reportsDAO{db: db}
+
+	to := time.Date(2022, 8, 1, 0, 0, 0, 0, time.UTC)
+	expectedQuery := "SELECT account_id, substr(transaction_time, 1, 10), SUM(amount) FROM transactions " +
+		"WHERE is_deleted = 0 AND transaction_time < ? GROUP BY 1, 2 ORDER BY 2, 1"
+
+	t.Run("testing happy flow", func(t *testing.T) {
+		mock.ExpectQuery(expectedQuery).
+			WithArgs(to).
+			WillReturnRows(sqlmock.NewRows([]string{"account_id", "day", "amount"}).
+				AddRow(1, "2022-07-01", -2500).
+				AddRow(2, "2022-07-01", 1000).
+				AddRow(1, "2022-07-15", 300000))
+
+		totals, err := dao.SumAmountsByAccountAndDay(to)
+		if err != nil {
+			t.Errorf("Unexpected error when summing transactions: %v", err)
+			return
+		}
+		if len(totals) != 3 || totals[0].Amount != -2500 || totals[2].Day != "2022-07-15" {
+			t.Errorf("unexpected totals %+v", totals)
+		}
+		checkingMockExpectations(t, mock)
+	})
+
+	t.Run("testing general error flow", func(t *testing.T) {
+		mock.ExpectQuery(expectedQuery).
+			WithArgs(to).
+			WillReturnError(errors.New("db timeout"))
+
+		_, err := dao.SumAmountsByAccountAndDay(to)
+		expectedErrorMsg := "failed to sum transactions by account and day with err: db timeout"
+		if err == nil || expectedErrorMsg != err.Error() {
+			t.Errorf("expected error message %s but found %v", expectedErrorMsg, err)
+		}
+		checkingMockExpectations(t, mock)
+	})
+}
+
+func TestReportsDAO_SumCashFlow(t *testing.T) {
+
+	db, mock := setupMockDB(t)
+	defer db.Close()
+	dao := reportsDAO{db: db}
+
+	from := time.Date(2022, 7, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2022, 8, 1, 0, 0, 0, 0, time.UTC)
+	columns := []string{"group_id", "group_name", "currency", "day", "income", "expenses"}
+	expectedQuery := func(idColumn string, nameColumn string, join string) string {
+		return "SELECT " + idColumn + ", " + nameColumn + ", a.currency, " +
+			"substr(t.transaction_time, 1, 10), SUM(CASE WHEN t.amount > 0 THEN t.amount ELSE 0 END), " +
+			"SUM(CASE WHEN t.amount < 0 THEN -t.amount ELSE 0 END) FROM " + expectedSplitTransactions +
+			" t JOIN accounts a " +
+			"ON a.id = t.account_id" + join + " WHERE t.is_deleted = 0 AND t.type != ? " +
+			"AND t.transaction_time >= ? AND t.transaction_time < ? GROUP BY 1, 2, 3, 4"
+	}
+
+	t.Run("testing happy flow of grouping by payee", func(t *testing.T) {
+		mock.ExpectQuery(expectedQuery("COALESCE(t.payee, 0)", "COALESCE(p.name, '')",
+			" LEFT JOIN payees p ON p.id = t.payee")).
+			WithArgs("transfer", from, to).
+			WillReturnRows(sqlmock.NewRows(columns).
+				AddRow(3, "Grocer", "USD", "2022-07-02", 0, 4500).
+				AddRow(0, "", "EUR", "2022-07-05", 250000, 0))
+
+		totals, err := dao.SumCashFlow(from, to, api.CashFlowGroupByPayee)
+		if err != nil {
+			t.Errorf("Unexpected error when summing cash flow: %v", err)
+			return
+		}
+		if len(totals) != 2 || totals[0].Group != "Grocer" || totals[0].Expenses != 4500 ||
+			totals[1].Income != 250000 {
+			t.Errorf("unexpected totals %+v", totals)
+		}
+		checkingMockExpectations(t, mock)
+	})
+
+	t.Run("testing grouping by tag counts the tags of splits", func(t *testing.T) {
+		mock.ExpectQuery(expectedQuery("0", "COALESCE(tt.tag_name, '')",
+			" LEFT JOIN (SELECT transaction_id, 0 AS split_id, tag_name FROM transaction_tags UNION ALL "+
+				"SELECT s.transaction_id, s.id, st.tag_name FROM transaction_split_tags st JOIN transaction_splits s "+
+				"ON s.id = st.split_id WHERE st.tag_name NOT IN (SELECT tag_name FROM transaction_tags "+
+				"WHERE transaction_id = s.transaction_id)) tt ON tt.transaction_id = t.id "+
+				"AND tt.split_id IN (0, t.split_id)")).
+			WithArgs("transfer", from, to).
+			WillReturnRows(sqlmock.NewRows(columns).
+				AddRow(0, "vacation", "USD", "2022-07-02", 0, 4500).
+				AddRow(0, "gifts", "USD", "2022-07-02", 0, 1500))
+
+		totals, err := dao.SumCashFlow(from, to, api.CashFlowGroupByTag)
+		if err != nil || len(totals) != 2 || totals[0].Group != "vacation" || totals[1].Group != "gifts" ||
+			totals[1].Expenses != 1500 {
+			t.Errorf("unexpected totals %+v or error %v", totals, err)
+		}
+		checkingMockExpectations(t, mock)
+	})
+
+	t.Run("testing general error flow", func(t *testing.T) {
+		mock.ExpectQuery(expectedQuery("COALESCE(t.category_id, 0)", "''", "")).
+			WithArgs("transfer", from, to).
+			WillReturnError(errors.New("db timeout"))
+
+		_, err := dao.SumCashFlow(from, to, api.CashFlowGroupByCategory)
+		expectedErrorMsg := "failed to sum cash flow with err: db timeout"
+		if err == nil || expectedErrorMsg != err.Error() {
+			t.Errorf("expected error message %s but found %v", expectedErrorMsg, err)
+		}
+		if _, err = dao.SumCashFlow(from, to, "account"); err == nil {
+			t.Errorf("expected an error when grouping by an unknown column")
+		}
+		checkingMockExpectations(t, mock)
+	})
+}
@@ -0,0 +1,150 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"go-personal-finance/pkg/api"
+)
+
+const rulesTableName = "transaction_rules"
+
+const ruleColumns = "id, name, priority, description_contains, description_regex, min_amount, max_amount, " +
+	"account_id, payee_id, set_category_id, set_payee_id, set_tags, set_memo, is_deleted, created_ts, updated_ts"
+
+type rulesDAO struct {
+	db *sql.DB
+}
+
+func NewRulesDAO(db *sql.DB) *rulesDAO {
+	return &rulesDAO{db}
+}
+
+func scanRule(row rowScanner) (api.Rule, error) {
+	rule := api.Rule{}
+	var descriptionContains, descriptionRegex, setTags, setMemo sql.NullString
+	var minAmount, maxAmount, accountId, payeeId, setCategoryId, setPayeeId sql.NullInt64
+	err := row.Scan(&rule.Id, &rule.Name, &rule.Priority, &descriptionContains, &descriptionRegex, &minAmount,
+		&maxAmount, &accountId, &payeeId, &setCategoryId, &setPayeeId, &setTags, &setMemo, &rule.IsDeleted,
+		&rule.CreatedTs, &rule.UpdatedTs)
+	if err != nil {
+		return rule, err
+	}
+	rule.DescriptionContains = descriptionContains.String
+	rule.DescriptionRegex = descriptionRegex.String
+	if minAmount.Valid {
+		amount := api.Money(minAmount.Int64)
+		rule.MinAmount = &amount
+	}
+	if maxAmount.Valid {
+		amount := api.Money(maxAmount.Int64)
+		rule.MaxAmount = &amount
+	}
+	rule.AccountId = accountId.Int64
+	rule.PayeeId = payeeId.Int64
+	rule.SetCategoryId = setCategoryId.Int64
+	rule.SetPayeeId = setPayeeId.Int64
+	rule.SetMemo = setMemo.String
+	rule.SetTags, err = parseJSONList(setTags)
+	if err != nil {
+		return rule, fmt.Errorf("failed to parse tags of rule %d: %v", rule.Id, err)
+	}
+	return rule, nil
+}
+
+func (dao *rulesDAO) GetRule(id int64) (api.Rule, error) {
+	row := dao.db.QueryRow(fmt.Sprintf("SELECT %s FROM %s WHERE id = ? AND is_deleted = 0", ruleColumns,
+		rulesTableName), id)
+	rule, err := scanRule(row)
+	if err != nil {
+		return rule, fmt.Errorf("failed to retrieve rule of id %d with err: %v", id, err)
+	}
+	return rule, nil
+}
+
+func (dao *rulesDAO) ListRules() ([]api.Rule, error) {
+	rows, err := dao.db.Query(fmt.Sprintf("SELECT %s FROM %s WHERE is_deleted = 0 ORDER BY priority DESC, id",
+		ruleColumns, rulesTableName))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list rules with err: %v", err)
+	}
+	defer rows.Close()
+
+	rules := []api.Rule{}
+	for rows.Next() {
+		rule, err := scanRule(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read rule with err: %v", err)
+		}
+		rules = append(rules, rule)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list rules with err: %v", err)
+	}
+	return rules, nil
+}
+
+// InsertRule assumes the request has been validated by the service.
+func (dao *rulesDAO) InsertRule(request api.RuleRequest) (int64, error) {
+	var priority int64
+	if request.Priority != nil {
+		priority = *request.Priority
+	}
+	result, err := dao.db.Exec(fmt.Sprintf("INSERT INTO %s (name, priority, description_contains, "+
+		"description_regex, min_amount, max_amount, account_id, payee_id, set_category_id, set_payee_id, set_tags, "+
+		"set_memo) VALUES(?,?,?,?,?,?,?,?,?,?,?,?)", rulesTableName), *request.Name, priority,
+		NewNullString(request.DescriptionContains), NewNullString(request.DescriptionRegex),
+		NewNullMoney(request.MinAmount), NewNullMoney(request.MaxAmount), NewNullInt64(request.AccountId),
+		NewNullInt64(request.PayeeId), NewNullInt64(request.SetCategoryId), NewNullInt64(request.SetPayeeId),
+		newJSONList(request.SetTags), NewNullString(request.SetMemo))
+	if err != nil {
+		return -1, fmt.Errorf("failed to insert new rule due to error %v", err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return -1, fmt.Errorf("failed to retrieve last inserted id %v", err)
+	}
+	return id, nil
+}
+
+// UpdateRule assumes the request has been validated by the service. Conditions and actions missing from the request
+// are cleared.
+func (dao *rulesDAO) UpdateRule(id int64, request api.RuleRequest) error {
+	var priority int64
+	if request.Priority != nil {
+		priority = *request.Priority
+	}
+	result, err := dao.db.Exec(fmt.Sprintf("UPDATE %s SET name = ?, priority = ?, description_contains = ?, "+
+		"description_regex = ?, min_amount = ?, max_amount = ?, account_id = ?, payee_id = ?, set_category_id = ?, "+
+		"set_payee_id = ?, set_tags = ?, set_memo = ?, updated_ts = current_timestamp WHERE id = ? AND is_deleted = 0",
+		rulesTableName), *request.Name, priority, NewNullString(request.DescriptionContains),
+		NewNullString(request.DescriptionRegex), NewNullMoney(request.MinAmount), NewNullMoney(request.MaxAmount),
+		NewNullInt64(request.AccountId), NewNullInt64(request.PayeeId), NewNullInt64(request.SetCategoryId),
+		NewNullInt64(request.SetPayeeId), newJSONList(request.SetTags), NewNullString(request.SetMemo), id)
+	if err != nil {
+		return fmt.Errorf("failed to update rule %d due to error %v", id, err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to verify if rule %d has been updated due to error %v", id, err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("WARN: detected request to update non-existent rule with id %d \n", id)
+	}
+	return nil
+}
+
+func (dao *rulesDAO) DeleteRule(id int64) error {
+	result, err := dao.db.Exec(fmt.Sprintf("UPDATE %s SET is_deleted = 1, updated_ts = current_timestamp "+
+		"WHERE id = ? AND is_deleted = 0", rulesTableName), id)
+	if err != nil {
+		return fmt.Errorf("failed to delete rule %d due to error %v", id, err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to verify if rule %d has been deleted due to error %v", id, err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("WARN: detected request to delete non-existent rule with id %d \n", id)
+	}
+	return nil
+}
@@ -0,0 +1,206 @@
+package repository
+
+import (
+	"errors"
+	"github.com/DATA-DOG/go-sqlmock"
+	"go-personal-finance/pkg/api"
+	"testing"
+	"time"
+)
+
+const expectedRuleColumns = "id, name, priority, description_contains, description_regex, min_amount, max_amount, " +
+	"account_id, payee_id, set_category_id, set_payee_id, set_tags, set_memo, is_deleted, created_ts, updated_ts"
+
+var ruleRowColumns = []string{"id", "name", "priority", "description_contains", "description_regex", "min_amount",
+	"max_amount", "account_id", "payee_id", "set_category_id", "set_payee_id", "set_tags", "set_memo", "is_deleted",
+	"created_ts", "updated_ts"}
+
+func TestRulesDAO_GetRule(t *testing.T) {
+
+	db, mock := setupMockDB(t)
+	defer db.Close()
+	dao := rulesDAO{db: db}
+
+	expectedSelectQuery := "SELECT " + expectedRuleColumns + " FROM transaction_rules WHERE id = ? AND is_deleted = 0"
+
+	t.Run("testing happy flow", func(t *testing.T) {
+		rows := sqlmock.NewRows(ruleRowColumns).AddRow(1, "coffee", 10, "starbucks", nil, -2000, nil, nil, nil, 4,
+			nil, `["coffee","food"]`, nil, false, time.Now(), time.Now())
+		mock.ExpectQuery(expectedSelectQuery).WithArgs(int64(1)).WillReturnRows(rows)
+
+		rule, err := dao.GetRule(1)
+		if err != nil {
+			t.Errorf("Unexpected error when retrieving rule: %v", err)
+			return
+		}
+		if rule.Priority != 10 || *rule.MinAmount != -2000 || rule.MaxAmount != nil || rule.SetCategoryId != 4 ||
+			len(rule.SetTags) != 2 || rule.SetTags[1] != "food" {
+			t.Errorf("unexpected rule %+v", rule)
+		}
+		checkingMockExpectations(t, mock)
+	})
+
+	t.Run("testing situation where we fail to retrieve rule", func(t *testing.T) {
+		mock.ExpectQuery(expectedSelectQuery).WillReturnError(errors.New("db timeout"))
+
+		_, err := dao.GetRule(1)
+		expectedErrorMsg := "failed to retrieve rule of id 1 with err: db timeout"
+		if err == nil || expectedErrorMsg != err.Error() {
+			t.Errorf("expected error message %s but found %v", expectedErrorMsg, err)
+		}
+		checkingMockExpectations(t, mock)
+	})
+}
+
+func TestRulesDAO_ListRules(t *testing.T) {
+
+	db, mock := setupMockDB(t)
+	defer db.Close()
+	dao := rulesDAO{db: db}
+
+	t.Run("testing happy flow", func(t *testing.T) {
+		rows := sqlmock.NewRows(ruleRowColumns).
+			AddRow(1, "coffee", 10, "starbucks", nil, nil, nil, nil, nil, 4, nil, nil, nil, false, time.Now(),
+				time.Now()).
+			AddRow(2, "rent", 0, nil, "^RENT", nil, nil, 1, nil, nil, nil, nil, "monthly", false, time.Now(),
+				time.Now())
+		mock.ExpectQuery("SELECT " + expectedRuleColumns + " FROM transaction_rules WHERE is_deleted = 0 " +
+			"ORDER BY priority DESC, id").WillReturnRows(rows)
+
+		rules, err := dao.ListRules()
+		if err != nil {
+			t.Errorf("Unexpected error when listing rules: %v", err)
+			return
+		}
+		if len(rules) != 2 || rules[0].SetTags != nil || rules[1].DescriptionRegex != "^RENT" ||
+			rules[1].SetMemo != "monthly" {
+			t.Errorf("unexpected rules %+v", rules)
+		}
+		checkingMockExpectations(t, mock)
+	})
+
+	t.Run("testing situation where the tags of a rule are corrupt", func(t *testing.T) {
+		rows := sqlmock.NewRows(ruleRowColumns).AddRow(1, "coffee", 10, "starbucks", nil, nil, nil, nil, nil, nil,
+			nil, "coffee", nil, false, time.Now(), time.Now())
+		mock.ExpectQuery("SELECT " + expectedRuleColumns + " FROM transaction_rules WHERE is_deleted = 0 " +
+			"ORDER BY priority DESC, id").WillReturnRows(rows)
+
+		_, err := dao.ListRules()
+		if err == nil {
+			t.Errorf("expected an error when reading corrupt tags")
+		}
+		checkingMockExpectations(t, mock)
+	})
+}
+
+func TestRulesDAO_InsertRule(t *testing.T) {
+
+	db, mock := setupMockDB(t)
+	defer db.Close()
+	dao := rulesDAO{db: db}
+
+	name := "coffee"
+	contains := "starbucks"
+	categoryId := int64(4)
+	insertQuery := "INSERT INTO transaction_rules (name, priority, description_contains, description_regex, " +
+		"min_amount, max_amount, account_id, payee_id, set_category_id, set_payee_id, set_tags, set_memo) " +
+		"VALUES(?,?,?,?,?,?,?,?,?,?,?,?)"
+	request := api.RuleRequest{Name: &name, DescriptionContains: &contains, SetCategoryId: &categoryId,
+		SetTags: []string{"coffee"}}
+
+	t.Run("testing happy flow", func(t *testing.T) {
+		mock.ExpectExec(insertQuery).
+			WithArgs(name, int64(0), contains, nil, nil, nil, nil, nil, categoryId, nil, `["coffee"]`, nil).
+			WillReturnResult(sqlmock.NewResult(int64(3), 1))
+
+		id, err := dao.InsertRule(request)
+		if err != nil {
+			t.Errorf("Unexpected error when trying to insert rule: %v", err)
+			return
+		}
+		if id != 3 {
+			t.Errorf("unexpected id returned")
+		}
+		checkingMockExpectations(t, mock)
+	})
+
+	t.Run("testing general error flow", func(t *testing.T) {
+		mock.ExpectExec(insertQuery).WillReturnError(errors.New("db timeout"))
+
+		_, err := dao.InsertRule(request)
+		expectedErrorMsg := "failed to insert new rule due to error db timeout"
+		if err == nil || expectedErrorMsg != err.Error() {
+			t.Errorf("expected error message %s but found %v", expectedErrorMsg, err)
+		}
+		checkingMockExpectations(t, mock)
+	})
+}
+
+func TestRulesDAO_UpdateRule(t *testing.T) {
+
+	db, mock := setupMockDB(t)
+	defer db.Close()
+	dao := rulesDAO{db: db}
+
+	name := "large expenses"
+	priority := int64(5)
+	maxAmount := api.Money(-10000)
+	memo := "review"
+	updateQuery := "UPDATE transaction_rules SET name = ?, priority = ?, description_contains = ?, " +
+		"description_regex = ?, min_amount = ?, max_amount = ?, account_id = ?, payee_id = ?, set_category_id = ?, " +
+		"set_payee_id = ?, set_tags = ?, set_memo = ?, updated_ts = current_timestamp WHERE id = ? AND is_deleted = 0"
+	request := api.RuleRequest{Name: &name, Priority: &priority, MaxAmount: &maxAmount, SetMemo: &memo}
+
+	t.Run("testing happy flow", func(t *testing.T) {
+		mock.ExpectExec(updateQuery).
+			WithArgs(name, priority, nil, nil, nil, maxAmount, nil, nil, nil, nil, nil, memo, int64(1)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		err := dao.UpdateRule(1, request)
+		if err != nil {
+			t.Errorf("Unexpected error when trying to update rule: %v", err)
+		}
+		checkingMockExpectations(t, mock)
+	})
+
+	t.Run("testing situation when rule doesn't exist with that id", func(t *testing.T) {
+		mock.ExpectExec(updateQuery).WillReturnResult(sqlmock.NewResult(0, 0))
+
+		err := dao.UpdateRule(1, request)
+		expectedErrorMsg := "WARN: detected request to update non-existent rule with id 1 \n"
+		if err == nil || expectedErrorMsg != err.Error() {
+			t.Errorf("expected error message %s but found %v", expectedErrorMsg, err)
+		}
+		checkingMockExpectations(t, mock)
+	})
+}
+
+func TestRulesDAO_DeleteRule(t *testing.T) {
+
+	db, mock := setupMockDB(t)
+	defer db.Close()
+	dao := rulesDAO{db: db}
+	deleteQuery := "UPDATE transaction_rules SET is_deleted = 1, updated_ts = current_timestamp " +
+		"WHERE id = ? AND is_deleted = 0"
+
+	t.Run("testing happy flow", func(t *testing.T) {
+		mock.ExpectExec(deleteQuery).WithArgs(int64(1)).WillReturnResult(sqlmock.NewResult(0, 1))
+
+		err := dao.DeleteRule(1)
+		if err != nil {
+			t.Errorf("Unexpected error when trying to delete rule: %v", err)
+		}
+	})
+
+	t.Run("testing deletion of non existent rule", func(t *testing.T) {
+		mock.ExpectExec(deleteQuery).WithArgs(int64(1)).WillReturnResult(sqlmock.NewResult(0, 0))
+
+		err := dao.DeleteRule(1)
+		expectedErrorMsg := "WARN: detected request to delete non-existent rule with id 1 \n"
+		if err == nil || expectedErrorMsg != err.Error() {
+			t.Errorf("expected error message %s but found %v", expectedErrorMsg, err)
+		}
+	})
+
+	checkingMockExpectations(t, mock)
+}
@@ -0,0 +1,169 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"go-personal-finance/pkg/api"
+)
+
+const tagsTableName = "tags"
+
+type tagsDAO struct {
+	db *sql.DB
+}
+
+func NewTagsDAO(db *sql.DB) *tagsDAO {
+	return &tagsDAO{db}
+}
+
+func (dao *tagsDAO) ListTags() ([]api.Tag, error) {
+	rows, err := dao.db.Query(fmt.Sprintf("SELECT name, created_ts, updated_ts FROM %s ORDER BY name", tagsTableName))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tags with err: %v", err)
+	}
+	defer rows.Close()
+
+	tags := []api.Tag{}
+	for rows.Next() {
+		tag := api.Tag{}
+		if err = rows.Scan(&tag.Name, &tag.CreatedTs, &tag.UpdatedTs); err != nil {
+			return nil, fmt.Errorf("failed to read tag with err: %v", err)
+		}
+		tags = append(tags, tag)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list tags with err: %v", err)
+	}
+	return tags, nil
+}
+
+func (dao *tagsDAO) InsertTag(name string) error {
+	_, err := dao.db.Exec(fmt.Sprintf("INSERT INTO %s (name) VALUES(?)", tagsTableName), name)
+	if err != nil {
+		return fmt.Errorf("failed to insert new tag %s due to error %v", name, err)
+	}
+	return nil
+}
+
+// RenameTag moves every transaction and split over to the new name before dropping the old tag. Renaming onto an
+// existing tag merges the two.
+func (dao *tagsDAO) RenameTag(name string, newName string) error {
+	tx, err := dao.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin rename of tag %s due to error %v", name, err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(fmt.Sprintf("DELETE FROM %s WHERE name = ?", tagsTableName), name)
+	if err != nil {
+		return fmt.Errorf("failed to rename tag %s due to error %v", name, err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to verify if tag %s has been renamed due to error %v", name, err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("WARN: detected request to rename non-existent tag %s \n", name)
+	}
+	_, err = tx.Exec(fmt.Sprintf("INSERT OR IGNORE INTO %s (name) VALUES(?)", tagsTableName), newName)
+	if err != nil {
+		return fmt.Errorf("failed to create tag %s due to error %v", newName, err)
+	}
+	_, err = tx.Exec(fmt.Sprintf("INSERT OR IGNORE INTO %s (transaction_id, tag_name) SELECT transaction_id, ? "+
+		"FROM %s WHERE tag_name = ?", transactionTagsTableName, transactionTagsTableName), newName, name)
+	if err != nil {
+		return fmt.Errorf("failed to move transactions from tag %s to %s due to error %v", name, newName, err)
+	}
+	_, err = tx.Exec(fmt.Sprintf("DELETE FROM %s WHERE tag_name = ?", transactionTagsTableName), name)
+	if err != nil {
+		return fmt.Errorf("failed to untag transactions from %s due to error %v", name, err)
+	}
+	_, err = tx.Exec(fmt.Sprintf("INSERT OR IGNORE INTO %s (split_id, tag_name) SELECT split_id, ? FROM %s "+
+		"WHERE tag_name = ?", transactionSplitTagsTableName, transactionSplitTagsTableName), newName, name)
+	if err != nil {
+		return fmt.Errorf("failed to move splits from tag %s to %s due to error %v", name, newName, err)
+	}
+	_, err = tx.Exec(fmt.Sprintf("DELETE FROM %s WHERE tag_name = ?", transactionSplitTagsTableName), name)
+	if err != nil {
+		return fmt.Errorf("failed to untag splits from %s due to error %v", name, err)
+	}
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit rename of tag %s due to error %v", name, err)
+	}
+	return nil
+}
+
+func (dao *tagsDAO) DeleteTag(name string) error {
+	tx, err := dao.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin deletion of tag %s due to error %v", name, err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(fmt.Sprintf("DELETE FROM %s WHERE tag_name = ?", transactionTagsTableName), name)
+	if err != nil {
+		return fmt.Errorf("failed to untag transactions from %s due to error %v", name, err)
+	}
+	_, err = tx.Exec(fmt.Sprintf("DELETE FROM %s WHERE tag_name = ?", transactionSplitTagsTableName), name)
+	if err != nil {
+		return fmt.Errorf("failed to untag splits from %s due to error %v", name, err)
+	}
+	result, err := tx.Exec(fmt.Sprintf("DELETE FROM %s WHERE name = ?", tagsTableName), name)
+	if err != nil {
+		return fmt.Errorf("failed to delete tag %s due to error %v", name, err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to verify if tag %s has been deleted due to error %v", name, err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("WARN: detected request to delete non-existent tag %s \n", name)
+	}
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit deletion of tag %s due to error %v", name, err)
+	}
+	return nil
+}
+
+func (dao *tagsDAO) AddTransactionTag(transactionId int64, name string) error {
+	tx, err := dao.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin tagging of transaction %d due to error %v", transactionId, err)
+	}
+	defer tx.Rollback()
+
+	var count int64
+	err = tx.QueryRow(fmt.Sprintf("SELECT COUNT(*) FROM %s WHERE id = ? AND is_deleted = 0", transactionsTableName),
+		transactionId).Scan(&count)
+	if err != nil {
+		return fmt.Errorf("failed to retrieve transaction %d due to error %v", transactionId, err)
+	}
+	if count == 0 {
+		return fmt.Errorf("WARN: detected request to tag non-existent transaction with id %d \n", transactionId)
+	}
+	if err = insertTransactionTags(tx, transactionId, []string{name}); err != nil {
+		return err
+	}
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit tagging of transaction %d due to error %v", transactionId, err)
+	}
+	return nil
+}
+
+func (dao *tagsDAO) RemoveTransactionTag(transactionId int64, name string) error {
+	result, err := dao.db.Exec(fmt.Sprintf("DELETE FROM %s WHERE transaction_id = ? AND tag_name = ?",
+		transactionTagsTableName), transactionId, name)
+	if err != nil {
+		return fmt.Errorf("failed to remove tag %s from transaction %d due to error %v", name, transactionId, err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to verify if tag %s has been removed from transaction %d due to error %v", name,
+			transactionId, err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("WARN: detected request to remove tag %s which transaction %d does not carry \n", name,
+			transactionId)
+	}
+	return nil
+}
@@ -0,0 +1,129 @@
+package repository
+
+import (
+	"errors"
+	"github.com/DATA-DOG/go-sqlmock"
+	"testing"
+	"time"
+)
+
+func TestTagsDAO_ListTags(t *testing.T) {
+
+	db, mock := setupMockDB(t)
+	defer db.Close()
+	dao := tagsDAO{db: db}
+
+	mock.ExpectQuery("SELECT name, created_ts, updated_ts FROM tags ORDER BY name").
+		WillReturnRows(sqlmock.NewRows([]string{"name", "created_ts", "updated_ts"}).
+			AddRow("food", time.Now(), time.Now()))
+
+	tags, err := dao.ListTags()
+	if err != nil || len(tags) != 1 || tags[0].Name != "food" {
+		t.Errorf("Unexpected result %v, %v when listing tags", tags, err)
+	}
+	checkingMockExpectations(t, mock)
+}
+
+func TestTagsDAO_RenameTag(t *testing.T) {
+
+	db, mock := setupMockDB(t)
+	defer db.Close()
+	dao := tagsDAO{db: db}
+
+	t.Run("testing happy flow", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec("DELETE FROM tags WHERE name = ?").WithArgs("food").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec("INSERT OR IGNORE INTO tags (name) VALUES(?)").WithArgs("groceries").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec("INSERT OR IGNORE INTO transaction_tags (transaction_id, tag_name) SELECT transaction_id, ? "+
+			"FROM transaction_tags WHERE tag_name = ?").WithArgs("groceries", "food").
+			WillReturnResult(sqlmock.NewResult(0, 3))
+		mock.ExpectExec("DELETE FROM transaction_tags WHERE tag_name = ?").WithArgs("food").
+			WillReturnResult(sqlmock.NewResult(0, 3))
+		mock.ExpectExec("INSERT OR IGNORE INTO transaction_split_tags (split_id, tag_name) SELECT split_id, ? "+
+			"FROM transaction_split_tags WHERE tag_name = ?").WithArgs("groceries", "food").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec("DELETE FROM transaction_split_tags WHERE tag_name = ?").WithArgs("food").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		err := dao.RenameTag("food", "groceries")
+		if err != nil {
+			t.Errorf("Unexpected error when renaming tag: %v", err)
+		}
+		checkingMockExpectations(t, mock)
+	})
+
+	t.Run("testing renaming a non existent tag", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec("DELETE FROM tags WHERE name = ?").WithArgs("food").
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectRollback()
+
+		err := dao.RenameTag("food", "groceries")
+		expectedErrorMsg := "WARN: detected request to rename non-existent tag food \n"
+		if err == nil || err.Error() != expectedErrorMsg {
+			t.Errorf("expected error message %s but found %v", expectedErrorMsg, err)
+		}
+		checkingMockExpectations(t, mock)
+	})
+}
+
+func TestTagsDAO_AddTransactionTag(t *testing.T) {
+
+	db, mock := setupMockDB(t)
+	defer db.Close()
+	dao := tagsDAO{db: db}
+	countQuery := "SELECT COUNT(*) FROM transactions WHERE id = ? AND is_deleted = 0"
+
+	t.Run("testing happy flow", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(countQuery).WithArgs(int64(1)).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+		mock.ExpectExec("INSERT OR IGNORE INTO tags (name) VALUES(?)").WithArgs("food").
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec("INSERT OR IGNORE INTO transaction_tags (transaction_id, tag_name) VALUES(?,?)").
+			WithArgs(int64(1), "food").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		err := dao.AddTransactionTag(1, "food")
+		if err != nil {
+			t.Errorf("Unexpected error when tagging transaction: %v", err)
+		}
+		checkingMockExpectations(t, mock)
+	})
+
+	t.Run("testing tagging a non existent transaction", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(countQuery).WithArgs(int64(1)).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+		mock.ExpectRollback()
+
+		err := dao.AddTransactionTag(1, "food")
+		expectedErrorMsg := "WARN: detected request to tag non-existent transaction with id 1 \n"
+		if err == nil || err.Error() != expectedErrorMsg {
+			t.Errorf("expected error message %s but found %v", expectedErrorMsg, err)
+		}
+		checkingMockExpectations(t, mock)
+	})
+}
+
+func TestTagsDAO_RemoveTransactionTag(t *testing.T) {
+
+	db, mock := setupMockDB(t)
+	defer db.Close()
+	dao := tagsDAO{db: db}
+
+	mock.ExpectExec("DELETE FROM transaction_tags WHERE transaction_id = ? AND tag_name = ?").
+		WithArgs(int64(1), "food").
+		WillReturnError(errors.New("db timeout"))
+
+	err := dao.RemoveTransactionTag(1, "food")
+	expectedErrorMsg := "failed to remove tag food from transaction 1 due to error db timeout"
+	if err == nil || err.Error() != expectedErrorMsg {
+		t.Errorf("expected error message %s but found %v", expectedErrorMsg, err)
+	}
+	checkingMockExpectations(t, mock)
+}
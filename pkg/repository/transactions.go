@@ -0,0 +1,442 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"go-personal-finance/pkg/api"
+	"strings"
+)
+
+const transactionsTableName = "transactions"
+
+const transactionTagsTableName = "transaction_tags"
+
+const transactionSplitsTableName = "transaction_splits"
+
+const transactionSplitTagsTableName = "transaction_split_tags"
+
+// transactionColumns selects the tags and splits of each transaction as json arrays aggregated from their tables.
+var transactionColumns = fmt.Sprintf("id, transaction_time, type, account_id, description, memo, amount, currency, "+
+	"payee, category_id, (SELECT json_group_array(tag_name) FROM (SELECT tag_name FROM %s "+
+	"WHERE transaction_id = %s.id ORDER BY tag_name)) AS tags, (SELECT json_group_array(json_object("+
+	"'category_id', category_id, 'amount', amount, 'memo', memo, 'tags', json((SELECT json_group_array(tag_name) "+
+	"FROM (SELECT tag_name FROM %s WHERE split_id = s.id ORDER BY tag_name))))) FROM (SELECT id, category_id, "+
+	"amount, memo FROM %s WHERE transaction_id = %s.id ORDER BY id) s) AS splits, linked_transaction_id, "+
+	"external_id, is_deleted, created_ts, updated_ts", transactionTagsTableName, transactionsTableName,
+	transactionSplitTagsTableName, transactionSplitsTableName, transactionsTableName)
+
+// splitTransactions lists every transaction once, or once per split when it is split, with the category and amount of
+// the transaction or of the split. It has the columns of transactions that reports group by, so that category totals
+// count the splits of a transaction instead of its own category. Splits without a category stay uncategorized.
+// split_id is null for transactions that are not split.
+var splitTransactions = fmt.Sprintf("(SELECT t.id, s.id AS split_id, t.transaction_time, t.type, t.account_id, "+
+	"t.currency, t.payee, CASE WHEN s.id IS NULL THEN t.category_id ELSE s.category_id END AS category_id, "+
+	"COALESCE(s.amount, t.amount) AS amount, t.is_deleted FROM %s t LEFT JOIN %s s ON s.transaction_id = t.id)",
+	transactionsTableName, transactionSplitsTableName)
+
+// splitRow is a split as aggregated by transactionColumns. Its amount is in whole cents as stored, unlike the decimal
+// amounts api.Money reads from json.
+type splitRow struct {
+	CategoryId int64    `json:"category_id"`
+	Amount     int64    `json:"amount"`
+	Memo       string   `json:"memo"`
+	Tags       []string `json:"tags"`
+}
+
+type transactionsDAO struct {
+	db *sql.DB
+}
+
+func NewTransactionsDAO(db *sql.DB) *transactionsDAO {
+	return &transactionsDAO{db}
+}
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanTransaction(row rowScanner) (api.Transaction, error) {
+	transaction := api.Transaction{}
+	var description, memo, currency, tags, splits, externalId sql.NullString
+	var payeeId, categoryId, linkedTransactionId sql.NullInt64
+	err := row.Scan(&transaction.Id, &transaction.TransactionTime, &transaction.TransactionType, &transaction.AccountId,
+		&description, &memo, &transaction.Amount, &currency, &payeeId, &categoryId, &tags, &splits,
+		&linkedTransactionId, &externalId, &transaction.IsDeleted, &transaction.CreatedTs, &transaction.UpdatedTs)
+	if err != nil {
+		return transaction, err
+	}
+	transaction.Description = description.String
+	transaction.Memo = memo.String
+	transaction.Currency = currency.String
+	transaction.PayeeId = payeeId.Int64
+	transaction.CategoryId = categoryId.Int64
+	transaction.LinkedTransactionId = linkedTransactionId.Int64
+	transaction.ExternalId = externalId.String
+	transaction.Tags, err = parseJSONList(tags)
+	if err != nil {
+		return transaction, fmt.Errorf("failed to parse tags of transaction %d with err: %v", transaction.Id, err)
+	}
+	transaction.Splits = []api.TransactionSplit{}
+	if splits.Valid && splits.String != "" {
+		var splitRows []splitRow
+		if err = json.Unmarshal([]byte(splits.String), &splitRows); err != nil {
+			return transaction, fmt.Errorf("failed to parse splits of transaction %d with err: %v", transaction.Id,
+				err)
+		}
+		for _, split := range splitRows {
+			if split.Tags == nil {
+				split.Tags = []string{}
+			}
+			transaction.Splits = append(transaction.Splits, api.TransactionSplit{CategoryId: split.CategoryId,
+				Amount: api.Money(split.Amount), Memo: split.Memo, Tags: split.Tags})
+		}
+	}
+	return transaction, nil
+}
+
+// ledgerEntry is the part of a transaction needed to revert its effect on the balance of its account.
+type ledgerEntry struct {
+	id        int64
+	accountId int64
+	amount    api.Money
+}
+
+// getLedgerEntry returns false when there is no transaction with the id.
+func getLedgerEntry(tx *sql.Tx, id int64) (ledgerEntry, bool, error) {
+	entry := ledgerEntry{id: id}
+	err := tx.QueryRow(fmt.Sprintf("SELECT account_id, amount FROM %s WHERE id = ? AND is_deleted = 0",
+		transactionsTableName), id).Scan(&entry.accountId, &entry.amount)
+	if err == sql.ErrNoRows {
+		return entry, false, nil
+	}
+	if err != nil {
+		return entry, false, fmt.Errorf("failed to retrieve transaction of id %d with err: %v", id, err)
+	}
+	return entry, true, nil
+}
+
+func (dao *transactionsDAO) GetTransaction(id int64) (api.Transaction, error) {
+	row := dao.db.QueryRow(fmt.Sprintf("SELECT %s FROM %s WHERE id = ? AND is_deleted = 0", transactionColumns,
+		transactionsTableName), id)
+	transaction, err := scanTransaction(row)
+	if err != nil {
+		return transaction, fmt.Errorf("failed to retrieve transaction of id %d with err: %v", id, err)
+	}
+	return transaction, nil
+}
+
+func (dao *transactionsDAO) ListTransactions(filter api.TransactionFilter) ([]api.Transaction, error) {
+	conditions := []string{"is_deleted = 0"}
+	var args []interface{}
+	if filter.AccountId != nil {
+		conditions = append(conditions, "account_id = ?")
+		args = append(args, *filter.AccountId)
+	}
+	if filter.From != nil {
+		conditions = append(conditions, "transaction_time >= ?")
+		args = append(args, NewDBTime(*filter.From))
+	}
+	if filter.To != nil {
+		conditions = append(conditions, "transaction_time < ?")
+		args = append(args, NewDBTime(*filter.To))
+	}
+	if filter.Tag != nil {
+		conditions = append(conditions, fmt.Sprintf("id IN (SELECT transaction_id FROM %s WHERE tag_name = ? "+
+			"UNION SELECT s.transaction_id FROM %s s JOIN %s st ON st.split_id = s.id WHERE st.tag_name = ?)",
+			transactionTagsTableName, transactionSplitsTableName, transactionSplitTagsTableName))
+		args = append(args, *filter.Tag, *filter.Tag)
+	}
+	rows, err := dao.db.Query(fmt.Sprintf("SELECT %s FROM %s WHERE %s ORDER BY transaction_time DESC, id DESC",
+		transactionColumns, transactionsTableName, strings.Join(conditions, " AND ")), args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list transactions with err: %v", err)
+	}
+	defer rows.Close()
+
+	transactions := []api.Transaction{}
+	for rows.Next() {
+		transaction, err := scanTransaction(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read transaction with err: %v", err)
+		}
+		transactions = append(transactions, transaction)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list transactions with err: %v", err)
+	}
+	return transactions, nil
+}
+
+// FindTransactionByExternalId returns the id of the transaction of the account with the id assigned by the bank. The
+// boolean is false when there is no such transaction.
+func (dao *transactionsDAO) FindTransactionByExternalId(accountId int64, externalId string) (int64, bool, error) {
+	var id int64
+	err := dao.db.QueryRow(fmt.Sprintf("SELECT id FROM %s WHERE account_id = ? AND external_id = ? AND is_deleted = 0",
+		transactionsTableName), accountId, externalId).Scan(&id)
+	if err == sql.ErrNoRows {
+		return -1, false, nil
+	}
+	if err != nil {
+		return -1, false, fmt.Errorf("failed to retrieve transaction of external id %s with err: %v", externalId, err)
+	}
+	return id, true, nil
+}
+
+// FindAccountCurrency returns false when there is no account with the id.
+func (dao *transactionsDAO) FindAccountCurrency(accountId int64) (string, bool, error) {
+	var currency string
+	err := dao.db.QueryRow(fmt.Sprintf("SELECT currency FROM %s WHERE id = ? AND is_deleted = 0", accountsTableName),
+		accountId).Scan(&currency)
+	if err == sql.ErrNoRows {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, fmt.Errorf("failed to retrieve currency of account %d with err: %v", accountId, err)
+	}
+	return currency, true, nil
+}
+
+// ImportTransactions writes the inserts and merges of an import within one transaction, so that a failure leaves
+// the ledger as it was. The ids of the inserted transactions are returned in order.
+func (dao *transactionsDAO) ImportTransactions(batch api.TransactionImport) ([]int64, error) {
+	tx, err := dao.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin import of transactions due to error %v", err)
+	}
+	defer tx.Rollback()
+
+	for _, merge := range batch.Merges {
+		if err = mergeTransaction(tx, merge.TransactionId, merge.Request); err != nil {
+			return nil, err
+		}
+	}
+	ids := []int64{}
+	for _, request := range batch.Inserts {
+		id, err := insertTransaction(tx, request)
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit import of transactions due to error %v", err)
+	}
+	return ids, nil
+}
+
+// mergeTransaction only sets the columns that are still empty, so nothing entered by hand is overwritten.
+func mergeTransaction(tx *sql.Tx, id int64, request api.TransactionMergeRequest) error {
+	result, err := tx.Exec(fmt.Sprintf("UPDATE %s SET external_id = COALESCE(external_id, ?), "+
+		"memo = COALESCE(NULLIF(memo, ''), ?), payee = COALESCE(payee, ?), category_id = COALESCE(category_id, ?), "+
+		"updated_ts = current_timestamp WHERE id = ? AND is_deleted = 0", transactionsTableName),
+		NewNullString(request.ExternalId), NewNullString(request.Memo), NewNullInt64(request.PayeeId),
+		NewNullInt64(request.CategoryId), id)
+	if err != nil {
+		return fmt.Errorf("failed to merge into transaction %d due to error %v", id, err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to verify if transaction %d has been merged into due to error %v", id, err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("WARN: detected request to merge into non-existent transaction with id %d \n", id)
+	}
+	return nil
+}
+
+// InsertTransaction writes the transaction and its tags and adds the amount to the balance of the account within one
+// transaction. Unknown tags are created.
+func (dao *transactionsDAO) InsertTransaction(request api.TransactionCreationRequest) (int64, error) {
+	tx, err := dao.db.Begin()
+	if err != nil {
+		return -1, fmt.Errorf("failed to begin insertion of new transaction due to error %v", err)
+	}
+	defer tx.Rollback()
+
+	id, err := insertTransaction(tx, request)
+	if err != nil {
+		return -1, err
+	}
+	if err = tx.Commit(); err != nil {
+		return -1, fmt.Errorf("failed to commit new transaction due to error %v", err)
+	}
+	return id, nil
+}
+
+// insertTransaction writes the transaction with its tags and splits and adds the amount to the balance of the account.
+func insertTransaction(tx *sql.Tx, request api.TransactionCreationRequest) (int64, error) {
+	result, err := tx.Exec(fmt.Sprintf("INSERT INTO %s (transaction_time, type, account_id, description, memo, "+
+		"amount, currency, payee, category_id, external_id) VALUES(?,?,?,?,?,?,?,?,?,?)", transactionsTableName),
+		NewDBTime(*request.TransactionTime), *request.TransactionType, *request.AccountId,
+		NewNullString(request.Description), NewNullString(request.Memo), *request.Amount,
+		NewNullString(request.Currency), NewNullInt64(request.PayeeId), NewNullInt64(request.CategoryId),
+		NewNullString(request.ExternalId))
+	if err != nil {
+		return -1, fmt.Errorf("failed to insert new transaction due to error %v", err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return -1, fmt.Errorf("failed to retrieve last inserted id %v", err)
+	}
+	if err = insertTransactionTags(tx, id, request.Tags); err != nil {
+		return -1, err
+	}
+	if err = insertTransactionSplits(tx, id, request.Splits); err != nil {
+		return -1, err
+	}
+	if err = adjustAccountBalance(tx, *request.AccountId, *request.Amount); err != nil {
+		return -1, err
+	}
+	return id, nil
+}
+
+// insertTransactionTags links the tags to the transaction, creating any tag that does not exist yet.
+func insertTransactionTags(tx *sql.Tx, transactionId int64, tags []string) error {
+	for _, tag := range tags {
+		_, err := tx.Exec(fmt.Sprintf("INSERT OR IGNORE INTO %s (name) VALUES(?)", tagsTableName), tag)
+		if err != nil {
+			return fmt.Errorf("failed to create tag %s due to error %v", tag, err)
+		}
+		_, err = tx.Exec(fmt.Sprintf("INSERT OR IGNORE INTO %s (transaction_id, tag_name) VALUES(?,?)",
+			transactionTagsTableName), transactionId, tag)
+		if err != nil {
+			return fmt.Errorf("failed to tag transaction %d with %s due to error %v", transactionId, tag, err)
+		}
+	}
+	return nil
+}
+
+// insertTransactionSplits assumes the splits have been validated by the service. The tags of the splits are linked
+// like the tags of transactions.
+func insertTransactionSplits(tx *sql.Tx, transactionId int64, splits []api.TransactionSplitRequest) error {
+	for _, split := range splits {
+		result, err := tx.Exec(fmt.Sprintf("INSERT INTO %s (transaction_id, category_id, amount, memo) "+
+			"VALUES(?,?,?,?)", transactionSplitsTableName), transactionId, NewNullInt64(split.CategoryId),
+			*split.Amount, NewNullString(split.Memo))
+		if err != nil {
+			return fmt.Errorf("failed to split transaction %d due to error %v", transactionId, err)
+		}
+		if len(split.Tags) == 0 {
+			continue
+		}
+		splitId, err := result.LastInsertId()
+		if err != nil {
+			return fmt.Errorf("failed to retrieve last inserted split id %v", err)
+		}
+		for _, tag := range split.Tags {
+			_, err = tx.Exec(fmt.Sprintf("INSERT OR IGNORE INTO %s (name) VALUES(?)", tagsTableName), tag)
+			if err != nil {
+				return fmt.Errorf("failed to create tag %s due to error %v", tag, err)
+			}
+			_, err = tx.Exec(fmt.Sprintf("INSERT OR IGNORE INTO %s (split_id, tag_name) VALUES(?,?)",
+				transactionSplitTagsTableName), splitId, tag)
+			if err != nil {
+				return fmt.Errorf("failed to tag split of transaction %d with %s due to error %v", transactionId, tag,
+					err)
+			}
+		}
+	}
+	return nil
+}
+
+// replaceTransactionSplits removes the splits of the transaction along with their tags before inserting the new ones.
+func replaceTransactionSplits(tx *sql.Tx, transactionId int64, splits []api.TransactionSplitRequest) error {
+	_, err := tx.Exec(fmt.Sprintf("DELETE FROM %s WHERE split_id IN (SELECT id FROM %s WHERE transaction_id = ?)",
+		transactionSplitTagsTableName, transactionSplitsTableName), transactionId)
+	if err != nil {
+		return fmt.Errorf("failed to clear tags of the splits of transaction %d due to error %v", transactionId, err)
+	}
+	_, err = tx.Exec(fmt.Sprintf("DELETE FROM %s WHERE transaction_id = ?", transactionSplitsTableName),
+		transactionId)
+	if err != nil {
+		return fmt.Errorf("failed to clear splits of transaction %d due to error %v", transactionId, err)
+	}
+	return insertTransactionSplits(tx, transactionId, splits)
+}
+
+// DeleteTransaction soft deletes the transaction and takes its amount out of the balance of the account.
+func (dao *transactionsDAO) DeleteTransaction(id int64) error {
+	tx, err := dao.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin deletion of transaction %d due to error %v", id, err)
+	}
+	defer tx.Rollback()
+
+	entry, found, err := getLedgerEntry(tx, id)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return fmt.Errorf("WARN: detected request to delete non-existent transaction with id %d \n", id)
+	}
+	_, err = tx.Exec(fmt.Sprintf("UPDATE %s SET is_deleted = 1, updated_ts = current_timestamp "+
+		"WHERE id = ? AND is_deleted = 0", transactionsTableName), id)
+	if err != nil {
+		return fmt.Errorf("failed to delete transaction %d due to error %v", id, err)
+	}
+	if err = adjustAccountBalance(tx, entry.accountId, -entry.amount); err != nil {
+		return err
+	}
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit deletion of transaction %d due to error %v", id, err)
+	}
+	return nil
+}
+
+// UpdateTransaction assumes the mandatory fields of the request have been validated by the service. The tags of
+// the transaction are replaced by the ones in the request, and so are its splits when the request carries any, and
+// the old amount is moved out of the balance of the old account into the balance of the new one.
+func (dao *transactionsDAO) UpdateTransaction(id int64, request api.TransactionUpdateRequest) error {
+	tx, err := dao.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin update of transaction %d due to error %v", id, err)
+	}
+	defer tx.Rollback()
+
+	entry, found, err := getLedgerEntry(tx, id)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return fmt.Errorf("WARN: detected request to update non-existent transaction with id %d \n", id)
+	}
+	result, err := tx.Exec(fmt.Sprintf("UPDATE %s SET transaction_time = ?, type = ?, account_id = ?, "+
+		"description = ?, memo = ?, amount = ?, currency = ?, payee = ?, category_id = ?, "+
+		"updated_ts = current_timestamp WHERE id = ? AND is_deleted = 0", transactionsTableName),
+		NewDBTime(*request.TransactionTime), *request.TransactionType, *request.AccountId,
+		NewNullString(request.Description), NewNullString(request.Memo), *request.Amount,
+		NewNullString(request.Currency), NewNullInt64(request.PayeeId), NewNullInt64(request.CategoryId), id)
+	if err != nil {
+		return fmt.Errorf("failed to update transaction %d due to error %v", id, err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to verify if transaction %d has been updated due to error %v", id, err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("WARN: detected request to update non-existent transaction with id %d \n", id)
+	}
+	_, err = tx.Exec(fmt.Sprintf("DELETE FROM %s WHERE transaction_id = ?", transactionTagsTableName), id)
+	if err != nil {
+		return fmt.Errorf("failed to clear tags of transaction %d due to error %v", id, err)
+	}
+	if err = insertTransactionTags(tx, id, request.Tags); err != nil {
+		return err
+	}
+	if request.Splits != nil {
+		if err = replaceTransactionSplits(tx, id, request.Splits); err != nil {
+			return err
+		}
+	}
+	if err = adjustAccountBalance(tx, entry.accountId, -entry.amount); err != nil {
+		return err
+	}
+	if err = adjustAccountBalance(tx, *request.AccountId, *request.Amount); err != nil {
+		return err
+	}
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit update of transaction %d due to error %v", id, err)
+	}
+	return nil
+}
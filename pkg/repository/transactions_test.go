@@ -0,0 +1,484 @@
+package repository
+
+import (
+	"errors"
+	"github.com/DATA-DOG/go-sqlmock"
+	"go-personal-finance/pkg/api"
+	"reflect"
+	"testing"
+	"time"
+)
+
+const expectedTransactionColumns = "id, transaction_time, type, account_id, description, memo, amount, currency, " +
+	"payee, category_id, (SELECT json_group_array(tag_name) FROM (SELECT tag_name FROM transaction_tags " +
+	"WHERE transaction_id = transactions.id ORDER BY tag_name)) AS tags, (SELECT json_group_array(json_object(" +
+	"'category_id', category_id, 'amount', amount, 'memo', memo, 'tags', json((SELECT json_group_array(tag_name) " +
+	"FROM (SELECT tag_name FROM transaction_split_tags WHERE split_id = s.id ORDER BY tag_name))))) " +
+	"FROM (SELECT id, category_id, amount, memo FROM transaction_splits WHERE transaction_id = transactions.id " +
+	"ORDER BY id) s) AS splits, linked_transaction_id, external_id, is_deleted, created_ts, updated_ts"
+
+const expectedSplitTransactions = "(SELECT t.id, s.id AS split_id, t.transaction_time, t.type, t.account_id, " +
+	"t.currency, t.payee, CASE WHEN s.id IS NULL THEN t.category_id ELSE s.category_id END AS category_id, " +
+	"COALESCE(s.amount, t.amount) AS amount, t.is_deleted FROM transactions t LEFT JOIN transaction_splits s " +
+	"ON s.transaction_id = t.id)"
+
+const expectedSelectLedgerEntryQuery = "SELECT account_id, amount FROM transactions WHERE id = ? AND is_deleted = 0"
+
+var transactionRowColumns = []string{"id", "transaction_time", "type", "account_id", "description", "memo", "amount",
+	"currency", "payee", "category_id", "tags", "splits", "linked_transaction_id", "external_id", "is_deleted",
+	"created_ts", "updated_ts"}
+
+func TestTransactionsDAO_GetTransaction(t *testing.T) {
+
+	db, mock := setupMockDB(t)
+	defer db.Close()
+	dao := transactionsDAO{db: db}
+
+	expectedSelectQuery := "SELECT " + expectedTransactionColumns + " FROM transactions WHERE id = ? AND is_deleted = 0"
+
+	t.Run("testing happy flow", func(t *testing.T) {
+		rows := sqlmock.NewRows(transactionRowColumns).AddRow(1, time.Now(), "normal", int64(1), "groceries", nil,
+			-2050, "USD", nil, int64(4), `["food","weekly"]`,
+			`[{"category_id":4,"amount":-1550,"memo":null,"tags":["groceries"]},`+
+				`{"category_id":null,"amount":-500,"memo":"bags","tags":[]}]`, nil,
+			"FIT-1", false, time.Now(), time.Now())
+		mock.ExpectQuery(expectedSelectQuery).WithArgs(int64(1)).WillReturnRows(rows)
+
+		transaction, err := dao.GetTransaction(1)
+		if err != nil {
+			t.Errorf("Unexpected error when retrieving transaction: %v", err)
+			return
+		}
+		if transaction.CategoryId != 4 || transaction.PayeeId != 0 {
+			t.Errorf("unexpected category %d or payee %d", transaction.CategoryId, transaction.PayeeId)
+		}
+		if len(transaction.Tags) != 2 || transaction.Tags[1] != "weekly" {
+			t.Errorf("unexpected tags %v", transaction.Tags)
+		}
+		expectedSplits := []api.TransactionSplit{{CategoryId: 4, Amount: -1550, Tags: []string{"groceries"}},
+			{Amount: -500, Memo: "bags", Tags: []string{}}}
+		if !reflect.DeepEqual(transaction.Splits, expectedSplits) {
+			t.Errorf("unexpected splits %+v", transaction.Splits)
+		}
+		checkingMockExpectations(t, mock)
+	})
+
+	t.Run("testing situation where we fail to retrieve transaction", func(t *testing.T) {
+		mock.ExpectQuery(expectedSelectQuery).WillReturnError(errors.New("db timeout"))
+
+		_, err := dao.GetTransaction(1)
+		expectedErrorMsg := "failed to retrieve transaction of id 1 with err: db timeout"
+		if expectedErrorMsg != err.Error() {
+			t.Errorf("expected error message %s but found %s", expectedErrorMsg, err.Error())
+		}
+		checkingMockExpectations(t, mock)
+	})
+}
+
+func TestTransactionsDAO_ListTransactions(t *testing.T) {
+
+	db, mock := setupMockDB(t)
+	defer db.Close()
+	dao := transactionsDAO{db: db}
+
+	t.Run("testing filters are applied", func(t *testing.T) {
+		accountId := int64(2)
+		from := time.Date(2022, 7, 1, 0, 0, 0, 0, time.UTC)
+		rows := sqlmock.NewRows(transactionRowColumns).
+			AddRow(2, time.Now(), "normal", accountId, nil, nil, 1000, nil, nil, nil, nil, "[]", nil, nil,
+				false, time.Now(), time.Now()).
+			AddRow(1, time.Now(), "normal", accountId, nil, nil, 500, nil, nil, nil, nil, "[]", nil, nil,
+				false, time.Now(), time.Now())
+		tag := "food"
+		mock.ExpectQuery("SELECT "+expectedTransactionColumns+" FROM transactions WHERE is_deleted = 0 AND "+
+			"account_id = ? AND transaction_time >= ? AND id IN (SELECT transaction_id FROM transaction_tags "+
+			"WHERE tag_name = ? UNION SELECT s.transaction_id FROM transaction_splits s JOIN transaction_split_tags "+
+			"st ON st.split_id = s.id WHERE st.tag_name = ?) ORDER BY transaction_time DESC, id DESC").
+			WithArgs(accountId, from, tag, tag).
+			WillReturnRows(rows)
+
+		transactions, err := dao.ListTransactions(api.TransactionFilter{AccountId: &accountId, From: &from, Tag: &tag})
+		if err != nil {
+			t.Errorf("Unexpected error when listing transactions: %v", err)
+			return
+		}
+		if len(transactions) != 2 {
+			t.Errorf("expected 2 transactions but found %d", len(transactions))
+		}
+		checkingMockExpectations(t, mock)
+	})
+}
+
+func TestTransactionsDAO_InsertTransaction(t *testing.T) {
+
+	db, mock := setupMockDB(t)
+	defer db.Close()
+	dao := transactionsDAO{db: db}
+
+	transactionTime := time.Date(2022, 7, 1, 10, 0, 0, 0, time.UTC)
+	transactionType := api.NormalTransactionType
+	accountId := int64(1)
+	amount := api.Money(-2050)
+	description := "groceries"
+	insertQuery := "INSERT INTO transactions (transaction_time, type, account_id, description, memo, amount, " +
+		"currency, payee, category_id, external_id) VALUES(?,?,?,?,?,?,?,?,?,?)"
+	categoryId := int64(4)
+	splitAmount := api.Money(-2050)
+	request := api.TransactionCreationRequest{TransactionTime: &transactionTime, TransactionType: &transactionType,
+		AccountId: &accountId, Description: &description, Amount: &amount, Tags: []string{"food"},
+		Splits: []api.TransactionSplitRequest{{CategoryId: &categoryId, Amount: &splitAmount,
+			Tags: []string{"weekly"}}}}
+
+	t.Run("testing happy flow", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec(insertQuery).
+			WithArgs(transactionTime, transactionType, accountId, description, nil, amount, nil, nil, nil, nil).
+			WillReturnResult(sqlmock.NewResult(int64(7), 1))
+		mock.ExpectExec("INSERT OR IGNORE INTO tags (name) VALUES(?)").
+			WithArgs("food").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec("INSERT OR IGNORE INTO transaction_tags (transaction_id, tag_name) VALUES(?,?)").
+			WithArgs(int64(7), "food").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec("INSERT INTO transaction_splits (transaction_id, category_id, amount, memo) VALUES(?,?,?,?)").
+			WithArgs(int64(7), categoryId, splitAmount, nil).
+			WillReturnResult(sqlmock.NewResult(3, 1))
+		mock.ExpectExec("INSERT OR IGNORE INTO tags (name) VALUES(?)").
+			WithArgs("weekly").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec("INSERT OR IGNORE INTO transaction_split_tags (split_id, tag_name) VALUES(?,?)").
+			WithArgs(int64(3), "weekly").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(expectedAdjustBalanceQuery).
+			WithArgs(amount, accountId).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		id, err := dao.InsertTransaction(request)
+		if err != nil {
+			t.Errorf("Unexpected error when trying to insert transaction: %v", err)
+			return
+		}
+		if id != 7 {
+			t.Errorf("unexpected id returned")
+		}
+		checkingMockExpectations(t, mock)
+	})
+
+	t.Run("testing general error flow", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec(insertQuery).WillReturnError(errors.New("db timeout"))
+		mock.ExpectRollback()
+
+		_, err := dao.InsertTransaction(request)
+		expectedErrorMsg := "failed to insert new transaction due to error db timeout"
+		if expectedErrorMsg != err.Error() {
+			t.Errorf("expected error message %s but found %s", expectedErrorMsg, err.Error())
+		}
+		checkingMockExpectations(t, mock)
+	})
+}
+
+func TestTransactionsDAO_UpdateTransaction(t *testing.T) {
+
+	db, mock := setupMockDB(t)
+	defer db.Close()
+	dao := transactionsDAO{db: db}
+
+	transactionTime := time.Date(2022, 7, 1, 10, 0, 0, 0, time.UTC)
+	transactionType := api.NormalTransactionType
+	accountId := int64(1)
+	amount := api.Money(-2050)
+	updateQuery := "UPDATE transactions SET transaction_time = ?, type = ?, account_id = ?, description = ?, " +
+		"memo = ?, amount = ?, currency = ?, payee = ?, category_id = ?, updated_ts = current_timestamp " +
+		"WHERE id = ? AND is_deleted = 0"
+	request := api.TransactionUpdateRequest{TransactionTime: &transactionTime, TransactionType: &transactionType,
+		AccountId: &accountId, Amount: &amount}
+
+	t.Run("testing happy flow", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(expectedSelectLedgerEntryQuery).
+			WithArgs(int64(1)).
+			WillReturnRows(sqlmock.NewRows([]string{"account_id", "amount"}).AddRow(int64(2), -1000))
+		mock.ExpectExec(updateQuery).
+			WithArgs(transactionTime, transactionType, accountId, nil, nil, amount, nil, nil, nil, int64(1)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec("DELETE FROM transaction_tags WHERE transaction_id = ?").
+			WithArgs(int64(1)).
+			WillReturnResult(sqlmock.NewResult(0, 2))
+		mock.ExpectExec(expectedAdjustBalanceQuery).
+			WithArgs(1000, int64(2)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(expectedAdjustBalanceQuery).
+			WithArgs(amount, accountId).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		err := dao.UpdateTransaction(1, request)
+		if err != nil {
+			t.Errorf("Unexpected error when trying to update transaction: %v", err)
+		}
+		checkingMockExpectations(t, mock)
+	})
+
+	t.Run("testing splits are replaced", func(t *testing.T) {
+		first, second := api.Money(-1550), api.Money(-500)
+		splitRequest := request
+		splitRequest.Splits = []api.TransactionSplitRequest{{Amount: &first}, {Amount: &second, Tags: []string{"home"}}}
+		mock.ExpectBegin()
+		mock.ExpectQuery(expectedSelectLedgerEntryQuery).
+			WithArgs(int64(1)).
+			WillReturnRows(sqlmock.NewRows([]string{"account_id", "amount"}).AddRow(int64(1), -2050))
+		mock.ExpectExec(updateQuery).
+			WithArgs(transactionTime, transactionType, accountId, nil, nil, amount, nil, nil, nil, int64(1)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec("DELETE FROM transaction_tags WHERE transaction_id = ?").
+			WithArgs(int64(1)).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec("DELETE FROM transaction_split_tags WHERE split_id IN (SELECT id FROM transaction_splits " +
+			"WHERE transaction_id = ?)").
+			WithArgs(int64(1)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec("DELETE FROM transaction_splits WHERE transaction_id = ?").
+			WithArgs(int64(1)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec("INSERT INTO transaction_splits (transaction_id, category_id, amount, memo) VALUES(?,?,?,?)").
+			WithArgs(int64(1), nil, first, nil).
+			WillReturnResult(sqlmock.NewResult(4, 1))
+		mock.ExpectExec("INSERT INTO transaction_splits (transaction_id, category_id, amount, memo) VALUES(?,?,?,?)").
+			WithArgs(int64(1), nil, second, nil).
+			WillReturnResult(sqlmock.NewResult(5, 1))
+		mock.ExpectExec("INSERT OR IGNORE INTO tags (name) VALUES(?)").
+			WithArgs("home").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec("INSERT OR IGNORE INTO transaction_split_tags (split_id, tag_name) VALUES(?,?)").
+			WithArgs(int64(5), "home").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(expectedAdjustBalanceQuery).
+			WithArgs(2050, int64(1)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(expectedAdjustBalanceQuery).
+			WithArgs(amount, accountId).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		err := dao.UpdateTransaction(1, splitRequest)
+		if err != nil {
+			t.Errorf("Unexpected error when trying to split transaction: %v", err)
+		}
+		checkingMockExpectations(t, mock)
+	})
+
+	t.Run("testing situation when transaction doesn't exist with that id", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(expectedSelectLedgerEntryQuery).
+			WithArgs(int64(1)).
+			WillReturnRows(sqlmock.NewRows([]string{"account_id", "amount"}))
+		mock.ExpectRollback()
+
+		err := dao.UpdateTransaction(1, request)
+		expectedErrorMsg := "WARN: detected request to update non-existent transaction with id 1 \n"
+		if expectedErrorMsg != err.Error() {
+			t.Errorf("expected error message %s but found %s", expectedErrorMsg, err.Error())
+		}
+		checkingMockExpectations(t, mock)
+	})
+}
+
+func TestTransactionsDAO_DeleteTransaction(t *testing.T) {
+
+	db, mock := setupMockDB(t)
+	defer db.Close()
+	dao := transactionsDAO{db: db}
+	deleteQuery := "UPDATE transactions SET is_deleted = 1, updated_ts = current_timestamp " +
+		"WHERE id = ? AND is_deleted = 0"
+
+	t.Run("testing happy flow", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(expectedSelectLedgerEntryQuery).
+			WithArgs(int64(1)).
+			WillReturnRows(sqlmock.NewRows([]string{"account_id", "amount"}).AddRow(int64(2), -1000))
+		mock.ExpectExec(deleteQuery).WithArgs(int64(1)).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(expectedAdjustBalanceQuery).
+			WithArgs(1000, int64(2)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		err := dao.DeleteTransaction(1)
+		if err != nil {
+			t.Errorf("Unexpected error when trying to delete transaction: %v", err)
+		}
+	})
+
+	t.Run("testing deletion of non existent transaction", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(expectedSelectLedgerEntryQuery).
+			WithArgs(int64(1)).
+			WillReturnRows(sqlmock.NewRows([]string{"account_id", "amount"}))
+		mock.ExpectRollback()
+
+		err := dao.DeleteTransaction(1)
+		expectedErrorMsg := "WARN: detected request to delete non-existent transaction with id 1 \n"
+		if expectedErrorMsg != err.Error() {
+			t.Errorf("expected error message %s but found %s", expectedErrorMsg, err.Error())
+		}
+	})
+
+	checkingMockExpectations(t, mock)
+}
+
+func TestTransactionsDAO_FindTransactionByExternalId(t *testing.T) {
+
+	db, mock := setupMockDB(t)
+	defer db.Close()
+	dao := transactionsDAO{db: db}
+	selectQuery := "SELECT id FROM transactions WHERE account_id = ? AND external_id = ? AND is_deleted = 0"
+
+	t.Run("testing happy flow", func(t *testing.T) {
+		mock.ExpectQuery(selectQuery).
+			WithArgs(int64(2), "FIT-1").
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(int64(9)))
+
+		id, found, err := dao.FindTransactionByExternalId(2, "FIT-1")
+		if err != nil {
+			t.Errorf("Unexpected error when finding transaction: %v", err)
+			return
+		}
+		if !found || id != 9 {
+			t.Errorf("unexpected transaction %d found: %t", id, found)
+		}
+		checkingMockExpectations(t, mock)
+	})
+
+	t.Run("testing situation where the transaction is not imported yet", func(t *testing.T) {
+		mock.ExpectQuery(selectQuery).
+			WithArgs(int64(2), "FIT-2").
+			WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+		_, found, err := dao.FindTransactionByExternalId(2, "FIT-2")
+		if err != nil || found {
+			t.Errorf("expected no transaction but found: %t with err %v", found, err)
+		}
+		checkingMockExpectations(t, mock)
+	})
+
+	t.Run("testing general error flow", func(t *testing.T) {
+		mock.ExpectQuery(selectQuery).
+			WithArgs(int64(2), "FIT-1").
+			WillReturnError(errors.New("db timeout"))
+
+		_, _, err := dao.FindTransactionByExternalId(2, "FIT-1")
+		expectedErrorMsg := "failed to retrieve transaction of external id FIT-1 with err: db timeout"
+		if err == nil || expectedErrorMsg != err.Error() {
+			t.Errorf("expected error message %s but found %v", expectedErrorMsg, err)
+		}
+		checkingMockExpectations(t, mock)
+	})
+}
+
+func TestTransactionsDAO_FindAccountCurrency(t *testing.T) {
+
+	db, mock := setupMockDB(t)
+	defer db.Close()
+	dao := transactionsDAO{db: db}
+	selectQuery := "SELECT currency FROM accounts WHERE id = ? AND is_deleted = 0"
+
+	t.Run("testing happy flow", func(t *testing.T) {
+		mock.ExpectQuery(selectQuery).
+			WithArgs(int64(2)).
+			WillReturnRows(sqlmock.NewRows([]string{"currency"}).AddRow("EUR"))
+
+		currency, found, err := dao.FindAccountCurrency(2)
+		if err != nil || !found || currency != "EUR" {
+			t.Errorf("unexpected currency %s found: %t with err %v", currency, found, err)
+		}
+		checkingMockExpectations(t, mock)
+	})
+
+	t.Run("testing situation where the account doesn't exist", func(t *testing.T) {
+		mock.ExpectQuery(selectQuery).
+			WithArgs(int64(2)).
+			WillReturnRows(sqlmock.NewRows([]string{"currency"}))
+
+		_, found, err := dao.FindAccountCurrency(2)
+		if err != nil || found {
+			t.Errorf("expected no account but found: %t with err %v", found, err)
+		}
+		checkingMockExpectations(t, mock)
+	})
+}
+
+func TestTransactionsDAO_ImportTransactions(t *testing.T) {
+
+	db, mock := setupMockDB(t)
+	defer db.Close()
+	dao := transactionsDAO{db: db}
+	mergeQuery := "UPDATE transactions SET external_id = COALESCE(external_id, ?), " +
+		"memo = COALESCE(NULLIF(memo, ''), ?), payee = COALESCE(payee, ?), category_id = COALESCE(category_id, ?), " +
+		"updated_ts = current_timestamp WHERE id = ? AND is_deleted = 0"
+	insertQuery := "INSERT INTO transactions (transaction_time, type, account_id, description, memo, amount, " +
+		"currency, payee, category_id, external_id) VALUES(?,?,?,?,?,?,?,?,?,?)"
+	externalId := "FIT-1"
+	categoryId := int64(4)
+	transactionTime := time.Date(2022, 7, 1, 10, 0, 0, 0, time.UTC)
+	transactionType := api.NormalTransactionType
+	accountId := int64(1)
+	amount := api.Money(-2050)
+	batch := api.TransactionImport{
+		Inserts: []api.TransactionCreationRequest{{TransactionTime: &transactionTime,
+			TransactionType: &transactionType, AccountId: &accountId, Amount: &amount}},
+		Merges: []api.TransactionMerge{{TransactionId: 9,
+			Request: api.TransactionMergeRequest{ExternalId: &externalId, CategoryId: &categoryId}}},
+	}
+
+	t.Run("testing happy flow", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec(mergeQuery).
+			WithArgs("FIT-1", nil, nil, int64(4), int64(9)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(insertQuery).
+			WithArgs(transactionTime, transactionType, accountId, nil, nil, amount, nil, nil, nil, nil).
+			WillReturnResult(sqlmock.NewResult(int64(7), 1))
+		mock.ExpectExec(expectedAdjustBalanceQuery).
+			WithArgs(amount, accountId).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		ids, err := dao.ImportTransactions(batch)
+		if err != nil || len(ids) != 1 || ids[0] != 7 {
+			t.Errorf("unexpected ids %v or error %v", ids, err)
+		}
+		checkingMockExpectations(t, mock)
+	})
+
+	t.Run("testing nothing is written when a merge fails", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec(mergeQuery).
+			WithArgs("FIT-1", nil, nil, int64(4), int64(9)).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectRollback()
+
+		_, err := dao.ImportTransactions(batch)
+		expectedErrorMsg := "WARN: detected request to merge into non-existent transaction with id 9 \n"
+		if err == nil || expectedErrorMsg != err.Error() {
+			t.Errorf("expected error message %s but found %v", expectedErrorMsg, err)
+		}
+		checkingMockExpectations(t, mock)
+	})
+
+	t.Run("testing nothing is written when an insert fails", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec(mergeQuery).
+			WithArgs("FIT-1", nil, nil, int64(4), int64(9)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(insertQuery).WillReturnError(errors.New("db timeout"))
+		mock.ExpectRollback()
+
+		_, err := dao.ImportTransactions(batch)
+		expectedErrorMsg := "failed to insert new transaction due to error db timeout"
+		if err == nil || expectedErrorMsg != err.Error() {
+			t.Errorf("expected error message %s but found %v", expectedErrorMsg, err)
+		}
+		checkingMockExpectations(t, mock)
+	})
+}
@@ -0,0 +1,166 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"go-personal-finance/pkg/api"
+)
+
+// InsertTransfer writes the debit and credit legs of the transfer, links them to each other and moves the amount
+// between the balances of both accounts within one transaction.
+func (dao *transactionsDAO) InsertTransfer(request api.TransferRequest) (int64, int64, error) {
+	tx, err := dao.db.Begin()
+	if err != nil {
+		return -1, -1, fmt.Errorf("failed to begin insertion of new transfer due to error %v", err)
+	}
+	defer tx.Rollback()
+
+	debitId, err := insertTransferLeg(tx, request, *request.FromAccountId, -*request.Amount, nil)
+	if err != nil {
+		return -1, -1, err
+	}
+	creditId, err := insertTransferLeg(tx, request, *request.ToAccountId, *request.Amount, &debitId)
+	if err != nil {
+		return -1, -1, err
+	}
+	_, err = tx.Exec(fmt.Sprintf("UPDATE %s SET linked_transaction_id = ? WHERE id = ?", transactionsTableName),
+		creditId, debitId)
+	if err != nil {
+		return -1, -1, fmt.Errorf("failed to link transaction %d to %d due to error %v", debitId, creditId, err)
+	}
+	if err = adjustAccountBalance(tx, *request.FromAccountId, -*request.Amount); err != nil {
+		return -1, -1, err
+	}
+	if err = adjustAccountBalance(tx, *request.ToAccountId, *request.Amount); err != nil {
+		return -1, -1, err
+	}
+	if err = tx.Commit(); err != nil {
+		return -1, -1, fmt.Errorf("failed to commit new transfer due to error %v", err)
+	}
+	return debitId, creditId, nil
+}
+
+func insertTransferLeg(tx *sql.Tx, request api.TransferRequest, accountId int64, amount api.Money,
+	linkedTransactionId *int64) (int64, error) {
+	result, err := tx.Exec(fmt.Sprintf("INSERT INTO %s (transaction_time, type, account_id, description, memo, "+
+		"amount, currency, linked_transaction_id) VALUES(?,?,?,?,?,?,?,?)", transactionsTableName),
+		NewDBTime(*request.TransactionTime), api.TransferTransactionType, accountId,
+		NewNullString(request.Description), NewNullString(request.Memo), amount, NewNullString(request.Currency),
+		NewNullInt64(linkedTransactionId))
+	if err != nil {
+		return -1, fmt.Errorf("failed to insert transfer leg of account %d due to error %v", accountId, err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return -1, fmt.Errorf("failed to retrieve last inserted id %v", err)
+	}
+	return id, nil
+}
+
+func getTransferLeg(tx *sql.Tx, id int64) (ledgerEntry, int64, error) {
+	leg := ledgerEntry{id: id}
+	var linkedTransactionId sql.NullInt64
+	err := tx.QueryRow(fmt.Sprintf("SELECT account_id, amount, linked_transaction_id FROM %s "+
+		"WHERE id = ? AND type = ? AND is_deleted = 0", transactionsTableName), id, api.TransferTransactionType).
+		Scan(&leg.accountId, &leg.amount, &linkedTransactionId)
+	if err != nil {
+		return leg, -1, fmt.Errorf("failed to retrieve transfer leg of id %d with err: %v", id, err)
+	}
+	return leg, linkedTransactionId.Int64, nil
+}
+
+// getTransferLegs returns the leg with the given id followed by the leg linked to it.
+func getTransferLegs(tx *sql.Tx, id int64) ([]ledgerEntry, error) {
+	leg, linkedTransactionId, err := getTransferLeg(tx, id)
+	if err != nil {
+		return nil, err
+	}
+	linkedLeg, _, err := getTransferLeg(tx, linkedTransactionId)
+	if err != nil {
+		return nil, err
+	}
+	return []ledgerEntry{leg, linkedLeg}, nil
+}
+
+// DeleteTransfer soft deletes both legs of the transfer and reverts their effect on the account balances.
+func (dao *transactionsDAO) DeleteTransfer(id int64) error {
+	tx, err := dao.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin deletion of transfer %d due to error %v", id, err)
+	}
+	defer tx.Rollback()
+
+	legs, err := getTransferLegs(tx, id)
+	if err != nil {
+		return err
+	}
+	for _, leg := range legs {
+		_, err = tx.Exec(fmt.Sprintf("UPDATE %s SET is_deleted = 1, updated_ts = current_timestamp "+
+			"WHERE id = ? AND is_deleted = 0", transactionsTableName), leg.id)
+		if err != nil {
+			return fmt.Errorf("failed to delete transaction %d due to error %v", leg.id, err)
+		}
+		if err = adjustAccountBalance(tx, leg.accountId, -leg.amount); err != nil {
+			return err
+		}
+	}
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit deletion of transfer %d due to error %v", id, err)
+	}
+	return nil
+}
+
+// UpdateTransfer updates the leg with the given id and mirrors its amount and time onto the linked leg so that the
+// transfer stays balanced. The account balances are adjusted by the difference.
+func (dao *transactionsDAO) UpdateTransfer(id int64, request api.TransactionUpdateRequest) error {
+	tx, err := dao.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin update of transfer %d due to error %v", id, err)
+	}
+	defer tx.Rollback()
+
+	legs, err := getTransferLegs(tx, id)
+	if err != nil {
+		return err
+	}
+	if *request.AccountId == legs[1].accountId {
+		return fmt.Errorf("%w: transfer %d cannot be moved to account %d of its other leg", api.ErrInvalidRequest, id,
+			legs[1].accountId)
+	}
+	for _, leg := range legs {
+		if err = adjustAccountBalance(tx, leg.accountId, -leg.amount); err != nil {
+			return err
+		}
+	}
+	_, err = tx.Exec(fmt.Sprintf("UPDATE %s SET transaction_time = ?, account_id = ?, description = ?, memo = ?, "+
+		"amount = ?, currency = ?, payee = ?, category_id = ?, updated_ts = current_timestamp WHERE id = ?",
+		transactionsTableName), NewDBTime(*request.TransactionTime), *request.AccountId,
+		NewNullString(request.Description), NewNullString(request.Memo), *request.Amount,
+		NewNullString(request.Currency), NewNullInt64(request.PayeeId), NewNullInt64(request.CategoryId), id)
+	if err != nil {
+		return fmt.Errorf("failed to update transaction %d due to error %v", id, err)
+	}
+	linkedLeg := legs[1]
+	_, err = tx.Exec(fmt.Sprintf("UPDATE %s SET transaction_time = ?, amount = ?, updated_ts = current_timestamp "+
+		"WHERE id = ?", transactionsTableName), NewDBTime(*request.TransactionTime), -*request.Amount, linkedLeg.id)
+	if err != nil {
+		return fmt.Errorf("failed to update transaction %d due to error %v", linkedLeg.id, err)
+	}
+	_, err = tx.Exec(fmt.Sprintf("DELETE FROM %s WHERE transaction_id = ?", transactionTagsTableName), id)
+	if err != nil {
+		return fmt.Errorf("failed to clear tags of transaction %d due to error %v", id, err)
+	}
+	if err = insertTransactionTags(tx, id, request.Tags); err != nil {
+		return err
+	}
+	if err = adjustAccountBalance(tx, *request.AccountId, *request.Amount); err != nil {
+		return err
+	}
+	if err = adjustAccountBalance(tx, linkedLeg.accountId, -*request.Amount); err != nil {
+		return err
+	}
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit update of transfer %d due to error %v", id, err)
+	}
+	return nil
+}
@@ -0,0 +1,185 @@
+package repository
+
+import (
+	"errors"
+	"github.com/DATA-DOG/go-sqlmock"
+	"go-personal-finance/pkg/api"
+	"testing"
+	"time"
+)
+
+const expectedSelectTransferLegQuery = "SELECT account_id, amount, linked_transaction_id FROM transactions " +
+	"WHERE id = ? AND type = ? AND is_deleted = 0"
+
+func TestTransactionsDAO_InsertTransfer(t *testing.T) {
+
+	db, mock := setupMockDB(t)
+	defer db.Close()
+	dao := transactionsDAO{db: db}
+
+	transactionTime := time.Date(2022, 7, 1, 10, 0, 0, 0, time.UTC)
+	fromAccountId := int64(1)
+	toAccountId := int64(2)
+	amount := api.Money(5000)
+	insertQuery := "INSERT INTO transactions (transaction_time, type, account_id, description, memo, amount, " +
+		"currency, linked_transaction_id) VALUES(?,?,?,?,?,?,?,?)"
+	request := api.TransferRequest{FromAccountId: &fromAccountId, ToAccountId: &toAccountId, Amount: &amount,
+		TransactionTime: &transactionTime}
+
+	t.Run("testing happy flow", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec(insertQuery).
+			WithArgs(transactionTime, api.TransferTransactionType, fromAccountId, nil, nil, -amount, nil, nil).
+			WillReturnResult(sqlmock.NewResult(int64(7), 1))
+		mock.ExpectExec(insertQuery).
+			WithArgs(transactionTime, api.TransferTransactionType, toAccountId, nil, nil, amount, nil, int64(7)).
+			WillReturnResult(sqlmock.NewResult(int64(8), 1))
+		mock.ExpectExec("UPDATE transactions SET linked_transaction_id = ? WHERE id = ?").
+			WithArgs(int64(8), int64(7)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(expectedAdjustBalanceQuery).WithArgs(-amount, fromAccountId).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(expectedAdjustBalanceQuery).WithArgs(amount, toAccountId).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		debitId, creditId, err := dao.InsertTransfer(request)
+		if err != nil {
+			t.Errorf("Unexpected error when trying to insert transfer: %v", err)
+			return
+		}
+		if debitId != 7 || creditId != 8 {
+			t.Errorf("unexpected ids %d and %d returned", debitId, creditId)
+		}
+		checkingMockExpectations(t, mock)
+	})
+
+	t.Run("testing transfer is rolled back when an account doesn't exist", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec(insertQuery).WillReturnResult(sqlmock.NewResult(int64(7), 1))
+		mock.ExpectExec(insertQuery).WillReturnResult(sqlmock.NewResult(int64(8), 1))
+		mock.ExpectExec("UPDATE transactions SET linked_transaction_id = ? WHERE id = ?").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(expectedAdjustBalanceQuery).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectRollback()
+
+		_, _, err := dao.InsertTransfer(request)
+		expectedErrorMsg := "WARN: detected request to adjust balance of non-existent account with id 1 \n"
+		if err == nil || expectedErrorMsg != err.Error() {
+			t.Errorf("expected error message %s but found %v", expectedErrorMsg, err)
+		}
+		checkingMockExpectations(t, mock)
+	})
+}
+
+func TestTransactionsDAO_DeleteTransfer(t *testing.T) {
+
+	db, mock := setupMockDB(t)
+	defer db.Close()
+	dao := transactionsDAO{db: db}
+	deleteQuery := "UPDATE transactions SET is_deleted = 1, updated_ts = current_timestamp " +
+		"WHERE id = ? AND is_deleted = 0"
+
+	t.Run("testing happy flow", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(expectedSelectTransferLegQuery).WithArgs(int64(7), api.TransferTransactionType).
+			WillReturnRows(sqlmock.NewRows([]string{"account_id", "amount", "linked_transaction_id"}).
+				AddRow(int64(1), -5000, int64(8)))
+		mock.ExpectQuery(expectedSelectTransferLegQuery).WithArgs(int64(8), api.TransferTransactionType).
+			WillReturnRows(sqlmock.NewRows([]string{"account_id", "amount", "linked_transaction_id"}).
+				AddRow(int64(2), 5000, int64(7)))
+		mock.ExpectExec(deleteQuery).WithArgs(int64(7)).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(expectedAdjustBalanceQuery).WithArgs(5000, int64(1)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(deleteQuery).WithArgs(int64(8)).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(expectedAdjustBalanceQuery).WithArgs(-5000, int64(2)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		err := dao.DeleteTransfer(7)
+		if err != nil {
+			t.Errorf("Unexpected error when trying to delete transfer: %v", err)
+		}
+		checkingMockExpectations(t, mock)
+	})
+
+	t.Run("testing deletion of non existent transfer", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(expectedSelectTransferLegQuery).WillReturnError(errors.New("sql: no rows in result set"))
+		mock.ExpectRollback()
+
+		err := dao.DeleteTransfer(7)
+		expectedErrorMsg := "failed to retrieve transfer leg of id 7 with err: sql: no rows in result set"
+		if err == nil || expectedErrorMsg != err.Error() {
+			t.Errorf("expected error message %s but found %v", expectedErrorMsg, err)
+		}
+		checkingMockExpectations(t, mock)
+	})
+}
+
+func TestTransactionsDAO_UpdateTransfer(t *testing.T) {
+
+	db, mock := setupMockDB(t)
+	defer db.Close()
+	dao := transactionsDAO{db: db}
+
+	transactionTime := time.Date(2022, 7, 2, 10, 0, 0, 0, time.UTC)
+	accountId := int64(1)
+	amount := api.Money(-8000)
+	request := api.TransactionUpdateRequest{TransactionTime: &transactionTime, AccountId: &accountId, Amount: &amount}
+
+	t.Run("testing happy flow", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(expectedSelectTransferLegQuery).WithArgs(int64(7), api.TransferTransactionType).
+			WillReturnRows(sqlmock.NewRows([]string{"account_id", "amount", "linked_transaction_id"}).
+				AddRow(int64(1), -5000, int64(8)))
+		mock.ExpectQuery(expectedSelectTransferLegQuery).WithArgs(int64(8), api.TransferTransactionType).
+			WillReturnRows(sqlmock.NewRows([]string{"account_id", "amount", "linked_transaction_id"}).
+				AddRow(int64(2), 5000, int64(7)))
+		mock.ExpectExec(expectedAdjustBalanceQuery).WithArgs(5000, int64(1)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(expectedAdjustBalanceQuery).WithArgs(-5000, int64(2)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec("UPDATE transactions SET transaction_time = ?, account_id = ?, description = ?, memo = ?, "+
+			"amount = ?, currency = ?, payee = ?, category_id = ?, updated_ts = current_timestamp WHERE id = ?").
+			WithArgs(transactionTime, accountId, nil, nil, amount, nil, nil, nil, int64(7)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec("UPDATE transactions SET transaction_time = ?, amount = ?, updated_ts = current_timestamp "+
+			"WHERE id = ?").
+			WithArgs(transactionTime, 8000, int64(8)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec("DELETE FROM transaction_tags WHERE transaction_id = ?").
+			WithArgs(int64(7)).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(expectedAdjustBalanceQuery).WithArgs(amount, accountId).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(expectedAdjustBalanceQuery).WithArgs(8000, int64(2)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		err := dao.UpdateTransfer(7, request)
+		if err != nil {
+			t.Errorf("Unexpected error when trying to update transfer: %v", err)
+		}
+		checkingMockExpectations(t, mock)
+	})
+	t.Run("testing a leg cannot be moved to the account of the other leg", func(t *testing.T) {
+		otherAccountId := int64(2)
+		mock.ExpectBegin()
+		mock.ExpectQuery(expectedSelectTransferLegQuery).WithArgs(int64(7), api.TransferTransactionType).
+			WillReturnRows(sqlmock.NewRows([]string{"account_id", "amount", "linked_transaction_id"}).
+				AddRow(int64(1), -5000, int64(8)))
+		mock.ExpectQuery(expectedSelectTransferLegQuery).WithArgs(int64(8), api.TransferTransactionType).
+			WillReturnRows(sqlmock.NewRows([]string{"account_id", "amount", "linked_transaction_id"}).
+				AddRow(int64(2), 5000, int64(7)))
+		mock.ExpectRollback()
+
+		err := dao.UpdateTransfer(7, api.TransactionUpdateRequest{TransactionTime: &transactionTime,
+			AccountId: &otherAccountId, Amount: &amount})
+		expectedErrorMsg := "invalid request: transfer 7 cannot be moved to account 2 of its other leg"
+		if !errors.Is(err, api.ErrInvalidRequest) || err.Error() != expectedErrorMsg {
+			t.Errorf("expected error message %s but found %v", expectedErrorMsg, err)
+		}
+		checkingMockExpectations(t, mock)
+	})
+}
@@ -0,0 +1,120 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"go-personal-finance/pkg/api"
+)
+
+const usersTableName = "users"
+
+const userColumns = "id, username, password_hash, is_deleted, created_ts, updated_ts"
+
+type usersDAO struct {
+	db *sql.DB
+}
+
+func NewUsersDAO(db *sql.DB) *usersDAO {
+	return &usersDAO{db}
+}
+
+func scanUser(row rowScanner) (api.User, error) {
+	user := api.User{}
+	err := row.Scan(&user.Id, &user.Username, &user.PasswordHash, &user.IsDeleted, &user.CreatedTs, &user.UpdatedTs)
+	return user, err
+}
+
+// FindUser returns false when there is no active user with the id.
+func (dao *usersDAO) FindUser(id int64) (api.User, bool, error) {
+	row := dao.db.QueryRow(fmt.Sprintf("SELECT %s FROM %s WHERE id = ? AND is_deleted = 0", userColumns,
+		usersTableName), id)
+	user, err := scanUser(row)
+	if err == sql.ErrNoRows {
+		return user, false, nil
+	}
+	if err != nil {
+		return user, false, fmt.Errorf("failed to retrieve user of id %d with err: %v", id, err)
+	}
+	return user, true, nil
+}
+
+// FindUserByUsername returns false when there is no active user with the username.
+func (dao *usersDAO) FindUserByUsername(username string) (api.User, bool, error) {
+	row := dao.db.QueryRow(fmt.Sprintf("SELECT %s FROM %s WHERE username = ? AND is_deleted = 0", userColumns,
+		usersTableName), username)
+	user, err := scanUser(row)
+	if err == sql.ErrNoRows {
+		return user, false, nil
+	}
+	if err != nil {
+		return user, false, fmt.Errorf("failed to retrieve user %s with err: %v", username, err)
+	}
+	return user, true, nil
+}
+
+func (dao *usersDAO) ListUsers() ([]api.User, error) {
+	rows, err := dao.db.Query(fmt.Sprintf("SELECT %s FROM %s WHERE is_deleted = 0 ORDER BY username", userColumns,
+		usersTableName))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list users with err: %v", err)
+	}
+	defer rows.Close()
+
+	users := []api.User{}
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read user with err: %v", err)
+		}
+		users = append(users, user)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list users with err: %v", err)
+	}
+	return users, nil
+}
+
+func (dao *usersDAO) InsertUser(username string, passwordHash string) (int64, error) {
+	result, err := dao.db.Exec(fmt.Sprintf("INSERT INTO %s (username, password_hash) VALUES(?,?)", usersTableName),
+		username, passwordHash)
+	if err != nil {
+		return -1, fmt.Errorf("failed to insert new user due to error %v", err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return -1, fmt.Errorf("failed to retrieve last inserted id %v", err)
+	}
+	return id, nil
+}
+
+func (dao *usersDAO) UpdateUserPassword(id int64, passwordHash string) error {
+	result, err := dao.db.Exec(fmt.Sprintf("UPDATE %s SET password_hash = ?, updated_ts = current_timestamp "+
+		"WHERE id = ? AND is_deleted = 0", usersTableName), passwordHash, id)
+	if err != nil {
+		return fmt.Errorf("failed to update password of user %d due to error %v", id, err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to verify if password of user %d has been updated due to error %v", id, err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("WARN: detected request to update non-existent user with id %d \n", id)
+	}
+	return nil
+}
+
+func (dao *usersDAO) DeleteUser(id int64) error {
+	result, err := dao.db.Exec(fmt.Sprintf("UPDATE %s SET is_deleted = 1, updated_ts = current_timestamp "+
+		"WHERE id = ? AND is_deleted = 0", usersTableName), id)
+	if err != nil {
+		return fmt.Errorf("failed to delete user %d due to error %v", id, err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to verify if user %d has been deleted due to error %v", id, err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("WARN: detected request to delete non-existent user with id %d \n", id)
+	}
+	return nil
+}
@@ -0,0 +1,175 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"github.com/DATA-DOG/go-sqlmock"
+	"testing"
+	"time"
+)
+
+const expectedUserColumns = "id, username, password_hash, is_deleted, created_ts, updated_ts"
+
+var userRowColumns = []string{"id", "username", "password_hash", "is_deleted", "created_ts", "updated_ts"}
+
+func TestUsersDAO_FindUser(t *testing.T) {
+
+	db, mock := setupMockDB(t)
+	defer db.Close()
+	dao := usersDAO{db: db}
+
+	expectedSelectQuery := "SELECT " + expectedUserColumns + " FROM users WHERE id = ? AND is_deleted = 0"
+
+	t.Run("testing happy flow", func(t *testing.T) {
+		rows := sqlmock.NewRows(userRowColumns).AddRow(1, "alex", "$2a$10$hash", false, time.Now(), time.Now())
+		mock.ExpectQuery(expectedSelectQuery).WithArgs(int64(1)).WillReturnRows(rows)
+
+		user, found, err := dao.FindUser(1)
+		if err != nil || !found || user.Username != "alex" || user.PasswordHash != "$2a$10$hash" {
+			t.Errorf("unexpected user %+v, found %t or error %v", user, found, err)
+		}
+		checkingMockExpectations(t, mock)
+	})
+
+	t.Run("testing situation where the user doesn't exist", func(t *testing.T) {
+		mock.ExpectQuery(expectedSelectQuery).WithArgs(int64(1)).WillReturnError(sql.ErrNoRows)
+
+		_, found, err := dao.FindUser(1)
+		if err != nil || found {
+			t.Errorf("expected no user but found %t or error %v", found, err)
+		}
+		checkingMockExpectations(t, mock)
+	})
+
+	t.Run("testing situation where we fail to retrieve user", func(t *testing.T) {
+		mock.ExpectQuery(expectedSelectQuery).WillReturnError(errors.New("db timeout"))
+
+		_, _, err := dao.FindUser(1)
+		expectedErrorMsg := "failed to retrieve user of id 1 with err: db timeout"
+		if err == nil || expectedErrorMsg != err.Error() {
+			t.Errorf("expected error message %s but found %v", expectedErrorMsg, err)
+		}
+		checkingMockExpectations(t, mock)
+	})
+}
+
+func TestUsersDAO_FindUserByUsername(t *testing.T) {
+
+	db, mock := setupMockDB(t)
+	defer db.Close()
+	dao := usersDAO{db: db}
+
+	rows := sqlmock.NewRows(userRowColumns).AddRow(2, "sam", "$2a$10$hash", false, time.Now(), time.Now())
+	mock.ExpectQuery("SELECT " + expectedUserColumns + " FROM users WHERE username = ? AND is_deleted = 0").
+		WithArgs("sam").WillReturnRows(rows)
+
+	user, found, err := dao.FindUserByUsername("sam")
+	if err != nil || !found || user.Id != 2 {
+		t.Errorf("unexpected user %+v, found %t or error %v", user, found, err)
+	}
+	checkingMockExpectations(t, mock)
+}
+
+func TestUsersDAO_ListUsers(t *testing.T) {
+
+	db, mock := setupMockDB(t)
+	defer db.Close()
+	dao := usersDAO{db: db}
+
+	rows := sqlmock.NewRows(userRowColumns).
+		AddRow(1, "alex", "$2a$10$hash", false, time.Now(), time.Now()).
+		AddRow(2, "sam", "$2a$10$hash", false, time.Now(), time.Now())
+	mock.ExpectQuery("SELECT " + expectedUserColumns + " FROM users WHERE is_deleted = 0 ORDER BY username").
+		WillReturnRows(rows)
+
+	users, err := dao.ListUsers()
+	if err != nil || len(users) != 2 || users[1].Username != "sam" {
+		t.Errorf("unexpected users %+v or error %v", users, err)
+	}
+	checkingMockExpectations(t, mock)
+}
+
+func TestUsersDAO_InsertUser(t *testing.T) {
+
+	db, mock := setupMockDB(t)
+	defer db.Close()
+	dao := usersDAO{db: db}
+	insertQuery := "INSERT INTO users (username, password_hash) VALUES(?,?)"
+
+	t.Run("testing happy flow", func(t *testing.T) {
+		mock.ExpectExec(insertQuery).WithArgs("alex", "$2a$10$hash").WillReturnResult(sqlmock.NewResult(int64(3), 1))
+
+		id, err := dao.InsertUser("alex", "$2a$10$hash")
+		if err != nil || id != 3 {
+			t.Errorf("unexpected id %d or error %v", id, err)
+		}
+		checkingMockExpectations(t, mock)
+	})
+
+	t.Run("testing general error flow", func(t *testing.T) {
+		mock.ExpectExec(insertQuery).WillReturnError(errors.New("db timeout"))
+
+		_, err := dao.InsertUser("alex", "$2a$10$hash")
+		expectedErrorMsg := "failed to insert new user due to error db timeout"
+		if err == nil || expectedErrorMsg != err.Error() {
+			t.Errorf("expected error message %s but found %v", expectedErrorMsg, err)
+		}
+		checkingMockExpectations(t, mock)
+	})
+}
+
+func TestUsersDAO_UpdateUserPassword(t *testing.T) {
+
+	db, mock := setupMockDB(t)
+	defer db.Close()
+	dao := usersDAO{db: db}
+	updateQuery := "UPDATE users SET password_hash = ?, updated_ts = current_timestamp WHERE id = ? AND is_deleted = 0"
+
+	t.Run("testing happy flow", func(t *testing.T) {
+		mock.ExpectExec(updateQuery).WithArgs("$2a$10$new", int64(1)).WillReturnResult(sqlmock.NewResult(0, 1))
+
+		if err := dao.UpdateUserPassword(1, "$2a$10$new"); err != nil {
+			t.Errorf("Unexpected error when trying to update password: %v", err)
+		}
+		checkingMockExpectations(t, mock)
+	})
+
+	t.Run("testing situation when user doesn't exist with that id", func(t *testing.T) {
+		mock.ExpectExec(updateQuery).WillReturnResult(sqlmock.NewResult(0, 0))
+
+		err := dao.UpdateUserPassword(1, "$2a$10$new")
+		expectedErrorMsg := "WARN: detected request to update non-existent user with id 1 \n"
+		if err == nil || expectedErrorMsg != err.Error() {
+			t.Errorf("expected error message %s but found %v", expectedErrorMsg, err)
+		}
+		checkingMockExpectations(t, mock)
+	})
+}
+
+func TestUsersDAO_DeleteUser(t *testing.T) {
+
+	db, mock := setupMockDB(t)
+	defer db.Close()
+	dao := usersDAO{db: db}
+	deleteQuery := "UPDATE users SET is_deleted = 1, updated_ts = current_timestamp WHERE id = ? AND is_deleted = 0"
+
+	t.Run("testing happy flow", func(t *testing.T) {
+		mock.ExpectExec(deleteQuery).WithArgs(int64(1)).WillReturnResult(sqlmock.NewResult(0, 1))
+
+		if err := dao.DeleteUser(1); err != nil {
+			t.Errorf("Unexpected error when trying to delete user: %v", err)
+		}
+	})
+
+	t.Run("testing deletion of non existent user", func(t *testing.T) {
+		mock.ExpectExec(deleteQuery).WithArgs(int64(1)).WillReturnResult(sqlmock.NewResult(0, 0))
+
+		err := dao.DeleteUser(1)
+		expectedErrorMsg := "WARN: detected request to delete non-existent user with id 1 \n"
+		if err == nil || expectedErrorMsg != err.Error() {
+			t.Errorf("expected error message %s but found %v", expectedErrorMsg, err)
+		}
+	})
+
+	checkingMockExpectations(t, mock)
+}
@@ -1,6 +1,11 @@
 package repository
 
-import "database/sql"
+import (
+	"database/sql"
+	"encoding/json"
+	"go-personal-finance/pkg/api"
+	"time"
+)
 
 func NewNullString(strPtr *string) sql.NullString {
 	if strPtr != nil {
@@ -8,3 +13,50 @@ func NewNullString(strPtr *string) sql.NullString {
 	}
 	return sql.NullString{}
 }
+
+func NewNullInt64(intPtr *int64) sql.NullInt64 {
+	if intPtr != nil {
+		return sql.NullInt64{Int64: *intPtr, Valid: true}
+	}
+	return sql.NullInt64{}
+}
+
+// NewNullInt stores an optional small number like a day of the week.
+func NewNullInt(intPtr *int) sql.NullInt64 {
+	if intPtr != nil {
+		return sql.NullInt64{Int64: int64(*intPtr), Valid: true}
+	}
+	return sql.NullInt64{}
+}
+
+// NewNullMoney stores an optional amount as whole cents.
+func NewNullMoney(moneyPtr *api.Money) sql.NullInt64 {
+	if moneyPtr != nil {
+		return sql.NullInt64{Int64: int64(*moneyPtr), Valid: true}
+	}
+	return sql.NullInt64{}
+}
+
+// NewDBTime normalizes timestamps to UTC before they are written so that the text representation
+// stored by sqlite compares correctly in range queries.
+func NewDBTime(t time.Time) time.Time {
+	return t.UTC()
+}
+
+func parseJSONList(value sql.NullString) ([]string, error) {
+	if !value.Valid || value.String == "" {
+		return nil, nil
+	}
+	var values []string
+	err := json.Unmarshal([]byte(value.String), &values)
+	return values, err
+}
+
+// newJSONList stores a list as a json array, leaving empty lists null.
+func newJSONList(values []string) sql.NullString {
+	if len(values) == 0 {
+		return sql.NullString{}
+	}
+	encoded, _ := json.Marshal(values)
+	return sql.NullString{String: string(encoded), Valid: true}
+}